@@ -11,6 +11,7 @@ import (
 	"github.com/weaveworks/common/server"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/responsecompare"
 	"github.com/cortexproject/cortex/tools/querytee"
 )
 
@@ -67,7 +68,7 @@ func cortexReadRoutes(cfg Config) []querytee.Route {
 		prefix = prefix[:len(prefix)-1]
 	}
 
-	samplesComparator := querytee.NewSamplesComparator(cfg.ProxyConfig.ValueComparisonTolerance)
+	samplesComparator := responsecompare.NewSamplesComparator(cfg.ProxyConfig.ValueComparisonTolerance)
 	return []querytee.Route{
 		{Path: prefix + "/api/v1/query", RouteName: "api_v1_query", Methods: []string{"GET"}, ResponseComparator: samplesComparator},
 		{Path: prefix + "/api/v1/query_range", RouteName: "api_v1_query_range", Methods: []string{"GET"}, ResponseComparator: samplesComparator},
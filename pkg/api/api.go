@@ -299,10 +299,14 @@ func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distrib
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/ring", "Distributor Ring Status")
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/all_user_stats", "Usage Statistics")
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/ha_tracker", "HA Tracking Status")
+	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/tenant_migrations", "Tenant Migrations Status")
+	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/cardinality_violations", "Label Cardinality Violations")
 
 	a.RegisterRoute("/distributor/ring", d, false, "GET", "POST")
 	a.RegisterRoute("/distributor/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, "GET")
 	a.RegisterRoute("/distributor/ha_tracker", d.HATracker, false, "GET")
+	a.RegisterRoute("/distributor/tenant_migrations", http.HandlerFunc(d.TenantMigrationHandler), false, "GET", "POST", "DELETE")
+	a.RegisterRoute("/distributor/cardinality_violations", http.HandlerFunc(d.LabelCardinalityViolationsHandler), false, "GET")
 
 	// Legacy Routes
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/push"), push.Handler(pushConfig.RemoteWriteV2Enabled, pushConfig.AcceptUnknownRemoteWriteContentType, pushConfig.MaxRecvMsgSize, overrides, a.sourceIPs, a.cfg.wrapDistributorPush(d), requestTotal), true, "POST")
@@ -319,6 +323,8 @@ type Ingester interface {
 	RenewTokenHandler(http.ResponseWriter, *http.Request)
 	AllUserStatsHandler(http.ResponseWriter, *http.Request)
 	ModeHandler(http.ResponseWriter, *http.Request)
+	PrepareShutdownHandler(http.ResponseWriter, *http.Request)
+	TokenRebalanceHandler(http.ResponseWriter, *http.Request)
 	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
 }
 
@@ -333,11 +339,14 @@ func (a *API) RegisterIngester(i Ingester, pushConfig distributor.Config, overri
 	a.indexPage.AddLink(SectionDangerous, "/ingester/renewTokens", "Renew Ingester Tokens (10%)")
 	a.indexPage.AddLink(SectionDangerous, "/ingester/mode?mode=READONLY", "Set Ingester to READONLY mode")
 	a.indexPage.AddLink(SectionDangerous, "/ingester/mode?mode=ACTIVE", "Set Ingester to ACTIVE mode")
+	a.indexPage.AddLink(SectionDangerous, "/ingester/prepare_shutdown", "Ingester prepare shutdown status")
 	a.RegisterRoute("/ingester/flush", http.HandlerFunc(i.FlushHandler), false, "GET", "POST")
 	a.RegisterRoute("/ingester/shutdown", http.HandlerFunc(i.ShutdownHandler), false, "GET", "POST")
 	a.RegisterRoute("/ingester/renewTokens", http.HandlerFunc(i.RenewTokenHandler), false, "GET", "POST")
 	a.RegisterRoute("/ingester/all_user_stats", http.HandlerFunc(i.AllUserStatsHandler), false, "GET")
 	a.RegisterRoute("/ingester/mode", http.HandlerFunc(i.ModeHandler), false, "GET", "POST")
+	a.RegisterRoute("/ingester/prepare_shutdown", http.HandlerFunc(i.PrepareShutdownHandler), false, "GET", "POST", "DELETE")
+	a.RegisterRoute("/ingester/token_rebalance", http.HandlerFunc(i.TokenRebalanceHandler), false, "GET", "POST", "DELETE")
 	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.RemoteWriteV2Enabled, pushConfig.AcceptUnknownRemoteWriteContentType, pushConfig.MaxRecvMsgSize, overrides, a.sourceIPs, i.Push, nil), true, "POST") // For testing and debugging.
 
 	// Legacy Routes
@@ -407,6 +416,10 @@ func (a *API) RegisterOverrides(o *overrides.API) {
 func (a *API) RegisterRing(r *ring.Ring) {
 	a.indexPage.AddLink(SectionAdminEndpoints, "/ingester/ring", "Ingester Ring Status")
 	a.RegisterRoute("/ingester/ring", r, false, "GET", "POST")
+	a.indexPage.AddLink(SectionAdminEndpoints, "/ingester/zone_rollout", "Ingester Zone Rollout Status")
+	a.RegisterRoute("/ingester/zone_rollout", http.HandlerFunc(r.ZoneRolloutHandler), false, "GET")
+	a.indexPage.AddLink(SectionAdminEndpoints, "/ingester/ring_ownership", "Ingester Ring Ownership")
+	a.RegisterRoute("/ingester/ring_ownership", http.HandlerFunc(r.OwnershipHandler), false, "GET")
 
 	// Legacy Route
 	a.RegisterRoute("/ring", r, false, "GET", "POST")
@@ -420,10 +433,13 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 	a.RegisterRoute("/store-gateway/ring", http.HandlerFunc(s.RingHandler), false, "GET", "POST")
 }
 
-// RegisterCompactor registers the ring UI page associated with the compactor.
+// RegisterCompactor registers the ring, block scrubber and compaction plan UI pages associated with the compactor.
 func (a *API) RegisterCompactor(c *compactor.Compactor) {
 	a.indexPage.AddLink(SectionAdminEndpoints, "/compactor/ring", "Compactor Ring Status")
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, "GET", "POST")
+	a.indexPage.AddLink(SectionAdminEndpoints, "/compactor/scrub_status", "Compactor Block Scrubber Status")
+	a.RegisterRoute("/compactor/scrub_status", http.HandlerFunc(c.ScrubStatusHandler), false, "GET")
+	a.RegisterRoute("/compactor/tenants/{tenant}/plan", http.HandlerFunc(c.PlanHandler), false, "GET")
 }
 
 // RegisterParquetConverter registers the ring UI page associated with the parquet-converter.
@@ -499,6 +515,10 @@ func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
 
 func (a *API) RegisterQueryFrontend2(f *frontendv2.Frontend) {
 	frontendv2pb.RegisterFrontendForQuerierServer(a.server.GRPC, f)
+
+	a.indexPage.AddLink(SectionAdminEndpoints, "/api/v1/status/running_queries", "Running Queries")
+	a.RegisterRoute("/api/v1/status/running_queries", http.HandlerFunc(f.RunningQueriesHandler), false, "GET")
+	a.RegisterRoute("/api/v1/status/running_queries/{id}", http.HandlerFunc(f.CancelRunningQueryHandler), false, "DELETE")
 }
 
 func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
@@ -506,6 +526,12 @@ func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
 	schedulerpb.RegisterSchedulerForQuerierServer(a.server.GRPC, f)
 }
 
+// RegisterQuerierWorker registers the gRPC service used by query-schedulers to collect
+// statistics of queries running on this querier.
+func (a *API) RegisterQuerierWorker(w schedulerpb.QuerierForSchedulerServer) {
+	schedulerpb.RegisterQuerierForSchedulerServer(a.server.GRPC, w)
+}
+
 // RegisterServiceMapHandler registers the Cortex structs service handler
 // TODO: Refactor this code to be accomplished using the services.ServiceManager
 // or a future module manager #2291
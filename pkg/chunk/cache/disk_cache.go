@@ -0,0 +1,746 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+)
+
+// The disk cache stores each entry in its own file, named after the SHA-256 of the key, and keeps
+// an in-memory LRU list of the entries to enforce the max size. Entry files are written to a
+// temporary file first and then renamed, and each file embeds the key and a checksum of its content,
+// so that an entry file partially written before a crash is detected and discarded when read.
+//
+// The LRU list is periodically persisted to an index file, which is loaded on startup to warm
+// start the cache: the index is reconciled with the entry files found on disk, so that entries
+// written after the last index sync are not lost and entries whose file is gone are dropped.
+
+const (
+	diskCacheDataDir   = "data"
+	diskCacheIndexFile = "index"
+	diskCacheTmpSuffix = ".tmp"
+
+	diskCacheEntryMagic      = uint32(0xCDC0E17E)
+	diskCacheIndexMagic      = uint32(0xCDC01DE8)
+	diskCacheFormatVersion   = byte(1)
+	diskCacheEntryHeaderSize = 4 + 1 + 8 + 4 + 4 + 4 // Magic, version, expiry, key length, value length and checksum.
+
+	defaultDiskCacheMaxAsyncConcurrency = 4
+	defaultDiskCacheMaxAsyncBufferSize  = 10000
+)
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errDiskCacheMissingPath         = errors.New("the disk cache path is required")
+	errDiskCacheInvalidMaxSizeBytes = errors.New("the disk cache max size bytes must be greater than 0")
+	errDiskCacheCorruptedEntry      = errors.New("corrupted disk cache entry")
+	errDiskCacheCorruptedIndex      = errors.New("corrupted disk cache index")
+)
+
+// DiskCacheConfig holds config for the DiskCache.
+type DiskCacheConfig struct {
+	Path            string        `yaml:"path"`
+	MaxSizeBytes    uint64        `yaml:"max_size_bytes"`
+	Validity        time.Duration `yaml:"validity"`
+	IndexSyncPeriod time.Duration `yaml:"index_sync_period"`
+
+	// Used by SetAsync. Defaults are used if not set.
+	MaxAsyncConcurrency int `yaml:"-"`
+	MaxAsyncBufferSize  int `yaml:"-"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet
+func (cfg *DiskCacheConfig) RegisterFlagsWithPrefix(prefix, description string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Path, prefix+"diskcache.path", "", description+"Directory where the disk cache stores its entries. The disk cache is enabled when the path is set. Each cache must use a different directory.")
+	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"diskcache.max-size-bytes", 10*1024*1024*1024, description+"Maximum size in bytes of the entries stored in the disk cache. The least recently used entries are evicted once the size is exceeded.")
+	f.DurationVar(&cfg.Validity, prefix+"diskcache.duration", 0, description+"The expiry duration for the disk cache.")
+	f.DurationVar(&cfg.IndexSyncPeriod, prefix+"diskcache.index-sync-period", time.Minute, description+"How frequently the disk cache index is persisted, to warm start the cache after a restart. The index is also persisted on shutdown. 0 to persist the index only on shutdown.")
+}
+
+func (cfg *DiskCacheConfig) Validate() error {
+	if cfg.Path == "" {
+		return nil
+	}
+	if cfg.MaxSizeBytes == 0 {
+		return errDiskCacheInvalidMaxSizeBytes
+	}
+	return nil
+}
+
+// DiskCache is a persistent cache storing entries on the local disk, bounded in size with
+// a LRU eviction policy. It implements both Cache and cacheutil.RemoteCacheClient, so that
+// it can be used for the chunks and results caches as well as for the blocks storage caches.
+type DiskCache struct {
+	name     string
+	cfg      DiskCacheConfig
+	logger   log.Logger
+	dataDir  string
+	validity time.Duration
+
+	lock       sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List
+	sizeBytes  uint64
+	indexDirty bool
+
+	async    *cacheutil.AsyncOperationProcessor
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+
+	// Used in tests.
+	now func() time.Time
+
+	requests      prometheus.Counter
+	hits          prometheus.Counter
+	entriesAdded  prometheus.Counter
+	evicted       prometheus.Counter
+	corrupted     prometheus.Counter
+	failures      *prometheus.CounterVec
+	itemsCurrent  prometheus.Gauge
+	bytesCurrent  prometheus.Gauge
+	loadedEntries prometheus.Gauge
+}
+
+type diskCacheEntry struct {
+	key string
+	// Size of the entry file.
+	size uint64
+	// Expiration time in milliseconds since epoch, 0 if the entry never expires.
+	expiry int64
+}
+
+// NewDiskCache makes a new DiskCache, loading the entries stored in the configured path by a previous instance.
+func NewDiskCache(name string, cfg DiskCacheConfig, reg prometheus.Registerer, logger log.Logger) (*DiskCache, error) {
+	if cfg.Path == "" {
+		return nil, errDiskCacheMissingPath
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.MaxAsyncConcurrency <= 0 {
+		cfg.MaxAsyncConcurrency = defaultDiskCacheMaxAsyncConcurrency
+	}
+	if cfg.MaxAsyncBufferSize <= 0 {
+		cfg.MaxAsyncBufferSize = defaultDiskCacheMaxAsyncBufferSize
+	}
+
+	c := &DiskCache{
+		name:     name,
+		cfg:      cfg,
+		logger:   log.With(logger, "cache", name),
+		dataDir:  filepath.Join(cfg.Path, diskCacheDataDir),
+		validity: cfg.Validity,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		now:      time.Now,
+
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_requests_total",
+			Help:        "Total number of keys requested to the disk cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_hits_total",
+			Help:        "Total number of keys requested to the disk cache that were a hit.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		entriesAdded: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_added_total",
+			Help:        "Total number of entries written to the disk cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		evicted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_evicted_total",
+			Help:        "Total number of entries evicted from the disk cache because the max size was exceeded.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		corrupted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_corrupted_entries_total",
+			Help:        "Total number of corrupted entries found and removed from the disk cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_operation_failures_total",
+			Help:        "Total number of disk cache operations which failed.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}, []string{"operation"}),
+		itemsCurrent: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_items",
+			Help:        "The number of entries currently in the disk cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		bytesCurrent: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_size_bytes",
+			Help:        "The size in bytes of the entries currently in the disk cache.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+		loadedEntries: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   "cortex",
+			Name:        "disk_cache_loaded_items",
+			Help:        "The number of entries loaded from disk when the disk cache started.",
+			ConstLabels: prometheus.Labels{"name": name},
+		}),
+	}
+
+	if err := os.MkdirAll(c.dataDir, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "create disk cache directory %s", c.dataDir)
+	}
+	if err := c.load(); err != nil {
+		return nil, errors.Wrapf(err, "load disk cache from %s", cfg.Path)
+	}
+
+	c.async = cacheutil.NewAsyncOperationProcessor(cfg.MaxAsyncBufferSize, cfg.MaxAsyncConcurrency)
+	go c.syncIndexLoop()
+
+	return c, nil
+}
+
+// Store implements Cache.
+func (c *DiskCache) Store(_ context.Context, keys []string, bufs [][]byte, ttl time.Duration) {
+	for i := range keys {
+		c.store(keys[i], bufs[i], ttl)
+	}
+}
+
+// Fetch implements Cache.
+func (c *DiskCache) Fetch(_ context.Context, keys []string, _ time.Duration) (found []string, bufs [][]byte, missing []string) {
+	for _, key := range keys {
+		if value, ok := c.get(key); ok {
+			found = append(found, key)
+			bufs = append(bufs, value)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return
+}
+
+// GetMulti implements cacheutil.RemoteCacheClient.
+func (c *DiskCache) GetMulti(_ context.Context, keys []string) map[string][]byte {
+	hits := map[string][]byte{}
+	for _, key := range keys {
+		if value, ok := c.get(key); ok {
+			hits[key] = value
+		}
+	}
+	return hits
+}
+
+// SetAsync implements cacheutil.RemoteCacheClient.
+func (c *DiskCache) SetAsync(key string, value []byte, ttl time.Duration) error {
+	err := c.async.EnqueueAsync(func() {
+		c.store(key, value, ttl)
+	})
+	if err != nil {
+		c.failures.WithLabelValues("enqueue").Inc()
+	}
+	return err
+}
+
+// Stop implements Cache and cacheutil.RemoteCacheClient. The entries are kept on disk
+// and the index is persisted, so that the next DiskCache using the same path warm starts.
+func (c *DiskCache) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+
+		// Wait until all enqueued writes are done before persisting the index.
+		c.async.Stop()
+		c.syncIndex()
+	})
+}
+
+func (c *DiskCache) store(key string, value []byte, ttl time.Duration) {
+	size := uint64(diskCacheEntryHeaderSize + len(key) + len(value))
+	if size > c.cfg.MaxSizeBytes {
+		return
+	}
+
+	if ttl <= 0 {
+		ttl = c.validity
+	}
+	var expiry int64
+	if ttl > 0 {
+		expiry = c.now().Add(ttl).UnixMilli()
+	}
+
+	if err := c.writeEntryFile(key, value, expiry); err != nil {
+		c.failures.WithLabelValues("write").Inc()
+		level.Warn(c.logger).Log("msg", "failed to write disk cache entry", "err", err)
+		return
+	}
+	c.entriesAdded.Inc()
+
+	c.lock.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*diskCacheEntry)
+		c.sizeBytes -= entry.size
+		entry.size, entry.expiry = size, expiry
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.lru.PushFront(&diskCacheEntry{key: key, size: size, expiry: expiry})
+	}
+	c.sizeBytes += size
+	c.indexDirty = true
+	evicted := c.evictLocked()
+	c.updateGaugesLocked()
+	c.lock.Unlock()
+
+	c.evicted.Add(float64(len(evicted)))
+	c.removeEntryFiles(evicted)
+}
+
+func (c *DiskCache) get(key string) ([]byte, bool) {
+	c.requests.Inc()
+
+	c.lock.Lock()
+	elem, ok := c.entries[key]
+	if ok && c.isExpired(elem.Value.(*diskCacheEntry)) {
+		c.removeLocked(elem)
+		c.updateGaugesLocked()
+		c.lock.Unlock()
+
+		c.removeEntryFiles([]string{key})
+		return nil, false
+	}
+	c.lock.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	value, err := c.readEntryFile(key)
+	if err != nil {
+		if errors.Is(err, errDiskCacheCorruptedEntry) {
+			c.corrupted.Inc()
+			level.Warn(c.logger).Log("msg", "removing corrupted disk cache entry", "err", err)
+			c.removeEntryFiles([]string{key})
+		} else if !os.IsNotExist(err) {
+			c.failures.WithLabelValues("read").Inc()
+			level.Warn(c.logger).Log("msg", "failed to read disk cache entry", "err", err)
+		}
+
+		c.lock.Lock()
+		if current, ok := c.entries[key]; ok && current == elem {
+			c.removeLocked(elem)
+			c.updateGaugesLocked()
+		}
+		c.lock.Unlock()
+		return nil, false
+	}
+
+	c.lock.Lock()
+	if current, ok := c.entries[key]; ok && current == elem {
+		c.lru.MoveToFront(elem)
+		c.indexDirty = true
+	}
+	c.lock.Unlock()
+
+	c.hits.Inc()
+	return value, true
+}
+
+// evictLocked removes the least recently used entries until the max size is honored,
+// and returns the keys of the entries removed. Must be called with the lock held.
+func (c *DiskCache) evictLocked() []string {
+	var evicted []string
+	for c.sizeBytes > c.cfg.MaxSizeBytes {
+		elem := c.lru.Back()
+		if elem == nil {
+			break
+		}
+		evicted = append(evicted, elem.Value.(*diskCacheEntry).key)
+		c.removeLocked(elem)
+	}
+	return evicted
+}
+
+func (c *DiskCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*diskCacheEntry)
+	delete(c.entries, entry.key)
+	c.sizeBytes -= entry.size
+	c.indexDirty = true
+}
+
+func (c *DiskCache) updateGaugesLocked() {
+	c.itemsCurrent.Set(float64(len(c.entries)))
+	c.bytesCurrent.Set(float64(c.sizeBytes))
+}
+
+func (c *DiskCache) isExpired(entry *diskCacheEntry) bool {
+	return entry.expiry > 0 && c.now().UnixMilli() >= entry.expiry
+}
+
+func (c *DiskCache) removeEntryFiles(keys []string) {
+	for _, key := range keys {
+		if err := os.Remove(c.entryPath(key)); err != nil && !os.IsNotExist(err) {
+			c.failures.WithLabelValues("remove").Inc()
+			level.Warn(c.logger).Log("msg", "failed to remove disk cache entry", "err", err)
+		}
+	}
+}
+
+// entryPath returns the path of the file storing the entry for the key. Entries are spread
+// across 256 sub-directories, to keep the number of files per directory reasonable.
+func (c *DiskCache) entryPath(key string) string {
+	name := entryFileName(key)
+	return filepath.Join(c.dataDir, name[:2], name)
+}
+
+func entryFileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *DiskCache) writeEntryFile(key string, value []byte, expiry int64) error {
+	path := c.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	buf := make([]byte, diskCacheEntryHeaderSize, diskCacheEntryHeaderSize+len(key)+len(value))
+	binary.BigEndian.PutUint32(buf[0:], diskCacheEntryMagic)
+	buf[4] = diskCacheFormatVersion
+	binary.BigEndian.PutUint64(buf[5:], uint64(expiry))
+	binary.BigEndian.PutUint32(buf[13:], uint32(len(key)))
+	binary.BigEndian.PutUint32(buf[17:], uint32(len(value)))
+	buf = append(buf, key...)
+	buf = append(buf, value...)
+	binary.BigEndian.PutUint32(buf[21:], crc32.Checksum(buf[diskCacheEntryHeaderSize:], castagnoliTable))
+
+	// Write to a temporary file and rename it, so that readers never see a partially written entry.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*"+diskCacheTmpSuffix)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+func (c *DiskCache) readEntryFile(key string) ([]byte, error) {
+	buf, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey, _, valueLen, err := decodeEntryHeader(buf, uint64(len(buf)))
+	if err != nil {
+		return nil, err
+	}
+	if fileKey != key {
+		return nil, errors.Wrapf(errDiskCacheCorruptedEntry, "key mismatch")
+	}
+	content := buf[diskCacheEntryHeaderSize:]
+	if crc32.Checksum(content, castagnoliTable) != binary.BigEndian.Uint32(buf[21:]) {
+		return nil, errors.Wrapf(errDiskCacheCorruptedEntry, "checksum mismatch")
+	}
+	return content[len(content)-valueLen:], nil
+}
+
+// decodeEntryHeader decodes the header of an entry file, which must be followed by at least the key.
+func decodeEntryHeader(buf []byte, fileSize uint64) (key string, expiry int64, valueLen int, _ error) {
+	if len(buf) < diskCacheEntryHeaderSize {
+		return "", 0, 0, errors.Wrapf(errDiskCacheCorruptedEntry, "entry too short")
+	}
+	if binary.BigEndian.Uint32(buf[0:]) != diskCacheEntryMagic || buf[4] != diskCacheFormatVersion {
+		return "", 0, 0, errors.Wrapf(errDiskCacheCorruptedEntry, "invalid header")
+	}
+	expiry = int64(binary.BigEndian.Uint64(buf[5:]))
+	keyLen := int(binary.BigEndian.Uint32(buf[13:]))
+	valueLen = int(binary.BigEndian.Uint32(buf[17:]))
+	if uint64(diskCacheEntryHeaderSize+keyLen+valueLen) != fileSize || len(buf) < diskCacheEntryHeaderSize+keyLen {
+		return "", 0, 0, errors.Wrapf(errDiskCacheCorruptedEntry, "size mismatch")
+	}
+	return string(buf[diskCacheEntryHeaderSize : diskCacheEntryHeaderSize+keyLen]), expiry, valueLen, nil
+}
+
+// readEntryFileHeader reads the key and expiry of an entry file, without reading and verifying its value.
+func readEntryFileHeader(path string, fileSize uint64) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	header := make([]byte, diskCacheEntryHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return "", 0, errors.Wrapf(errDiskCacheCorruptedEntry, "read header: %v", err)
+	}
+	keyLen := int(binary.BigEndian.Uint32(header[13:]))
+	if uint64(diskCacheEntryHeaderSize+keyLen) > fileSize {
+		return "", 0, errors.Wrapf(errDiskCacheCorruptedEntry, "size mismatch")
+	}
+	buf := make([]byte, diskCacheEntryHeaderSize+keyLen)
+	copy(buf, header)
+	if _, err := io.ReadFull(f, buf[diskCacheEntryHeaderSize:]); err != nil {
+		return "", 0, errors.Wrapf(errDiskCacheCorruptedEntry, "read key: %v", err)
+	}
+
+	key, expiry, _, err := decodeEntryHeader(buf, fileSize)
+	return key, expiry, err
+}
+
+// load warm starts the cache from the index and the entry files found on disk.
+func (c *DiskCache) load() error {
+	// Find the entry files, removing the temporary files left by writes interrupted by a crash.
+	files := map[string]uint64{}
+	err := filepath.WalkDir(c.dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, diskCacheTmpSuffix) {
+			return os.Remove(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = uint64(info.Size())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The index keeps the LRU order of the entries as of the last sync.
+	indexed, err := readDiskCacheIndex(filepath.Join(c.cfg.Path, diskCacheIndexFile))
+	if err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "failed to read disk cache index, the entries will be loaded from the entry files", "err", err)
+	}
+	for _, entry := range indexed {
+		path := c.entryPath(entry.key)
+		if size, ok := files[path]; !ok || size != entry.size || c.isExpired(&entry) {
+			continue
+		}
+		delete(files, path)
+		c.entries[entry.key] = c.lru.PushBack(&diskCacheEntry{key: entry.key, size: entry.size, expiry: entry.expiry})
+		c.sizeBytes += entry.size
+	}
+
+	// The entries written after the last index sync are considered the least recently used.
+	var invalid []string
+	for path, size := range files {
+		key, expiry, err := readEntryFileHeader(path, size)
+		if err != nil || c.entryPath(key) != path {
+			c.corrupted.Inc()
+			invalid = append(invalid, path)
+			continue
+		}
+		entry := &diskCacheEntry{key: key, size: size, expiry: expiry}
+		if c.isExpired(entry) {
+			invalid = append(invalid, path)
+			continue
+		}
+		c.entries[key] = c.lru.PushBack(entry)
+		c.sizeBytes += size
+	}
+	for _, path := range invalid {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	evicted := c.evictLocked()
+	c.evicted.Add(float64(len(evicted)))
+	c.removeEntryFiles(evicted)
+
+	c.indexDirty = true
+	c.updateGaugesLocked()
+	c.loadedEntries.Set(float64(len(c.entries)))
+	level.Info(c.logger).Log("msg", "loaded disk cache", "entries", len(c.entries), "bytes", c.sizeBytes, "indexed", len(indexed))
+	return nil
+}
+
+func (c *DiskCache) syncIndexLoop() {
+	defer close(c.done)
+
+	if c.cfg.IndexSyncPeriod <= 0 {
+		<-c.stop
+		return
+	}
+
+	ticker := time.NewTicker(c.cfg.IndexSyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.syncIndex()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// syncIndex persists the index, if it changed since the last sync.
+func (c *DiskCache) syncIndex() {
+	c.lock.Lock()
+	if !c.indexDirty {
+		c.lock.Unlock()
+		return
+	}
+	entries := make([]diskCacheEntry, 0, c.lru.Len())
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entries = append(entries, *elem.Value.(*diskCacheEntry))
+	}
+	c.indexDirty = false
+	c.lock.Unlock()
+
+	if err := writeDiskCacheIndex(filepath.Join(c.cfg.Path, diskCacheIndexFile), entries); err != nil {
+		c.failures.WithLabelValues("sync_index").Inc()
+		level.Warn(c.logger).Log("msg", "failed to persist disk cache index", "err", err)
+
+		c.lock.Lock()
+		c.indexDirty = true
+		c.lock.Unlock()
+	}
+}
+
+// writeDiskCacheIndex atomically writes the index, with the entries ordered from the most
+// to the least recently used, followed by a checksum of the whole index.
+func writeDiskCacheIndex(path string, entries []diskCacheEntry) error {
+	tmp := path + diskCacheTmpSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	crc := crc32.New(castagnoliTable)
+	w := bufio.NewWriter(io.MultiWriter(f, crc))
+	scratch := make([]byte, 8)
+
+	writeUint32 := func(v uint32) {
+		binary.BigEndian.PutUint32(scratch, v)
+		_, _ = w.Write(scratch[:4])
+	}
+	writeUint64 := func(v uint64) {
+		binary.BigEndian.PutUint64(scratch, v)
+		_, _ = w.Write(scratch)
+	}
+
+	writeUint32(diskCacheIndexMagic)
+	_ = w.WriteByte(diskCacheFormatVersion)
+	writeUint32(uint32(len(entries)))
+	for _, entry := range entries {
+		writeUint32(uint32(len(entry.key)))
+		_, _ = w.WriteString(entry.key)
+		writeUint64(entry.size)
+		writeUint64(uint64(entry.expiry))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint32(scratch, crc.Sum32())
+	if _, err := f.Write(scratch[:4]); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	err = f.Close()
+	f = nil
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readDiskCacheIndex(path string) ([]diskCacheEntry, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < 4+1+4+4 {
+		return nil, errDiskCacheCorruptedIndex
+	}
+	content, sum := buf[:len(buf)-4], binary.BigEndian.Uint32(buf[len(buf)-4:])
+	if crc32.Checksum(content, castagnoliTable) != sum {
+		return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "checksum mismatch")
+	}
+	if binary.BigEndian.Uint32(content) != diskCacheIndexMagic || content[4] != diskCacheFormatVersion {
+		return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "invalid header")
+	}
+
+	r := bytes.NewReader(content[5:])
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "read entries count: %v", err)
+	}
+
+	entries := make([]diskCacheEntry, 0, min(int(count), len(content)/(4+8+8)))
+	for range count {
+		var keyLen uint32
+		if err := binary.Read(r, binary.BigEndian, &keyLen); err != nil {
+			return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "read entry: %v", err)
+		}
+		if int(keyLen) > r.Len() {
+			return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "invalid key length")
+		}
+		key := make([]byte, keyLen)
+		_, _ = io.ReadFull(r, key)
+
+		entry := diskCacheEntry{key: string(key)}
+		var expiry uint64
+		if err := binary.Read(r, binary.BigEndian, &entry.size); err != nil {
+			return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "read entry: %v", err)
+		}
+		if err := binary.Read(r, binary.BigEndian, &expiry); err != nil {
+			return nil, errors.Wrapf(errDiskCacheCorruptedIndex, "read entry: %v", err)
+		}
+		entry.expiry = int64(expiry)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+)
+
+// Interface check.
+var _ cacheutil.RemoteCacheClient = &DiskCache{}
+
+const testDiskCacheValueSize = 100
+
+// testDiskCacheEntrySize is the size of the entries stored by the tests, whose keys are 5 bytes long.
+const testDiskCacheEntrySize = diskCacheEntryHeaderSize + 5 + testDiskCacheValueSize
+
+func newTestDiskCache(t *testing.T, cfg DiskCacheConfig) (*DiskCache, *prometheus.Registry) {
+	reg := prometheus.NewPedanticRegistry()
+	c, err := NewDiskCache("test", cfg, reg, log.NewNopLogger())
+	require.NoError(t, err)
+	return c, reg
+}
+
+func testDiskCacheValue(i int) []byte {
+	return []byte(strings.Repeat(fmt.Sprintf("%d", i%10), testDiskCacheValueSize))
+}
+
+func storeTestDiskCacheEntries(c *DiskCache, keys ...string) {
+	for i, key := range keys {
+		c.Store(context.Background(), []string{key}, [][]byte{testDiskCacheValue(i)}, 0)
+	}
+}
+
+func TestDiskCache_StoreAndFetch(t *testing.T) {
+	c, reg := newTestDiskCache(t, DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024})
+	defer c.Stop()
+
+	storeTestDiskCacheEntries(c, "key-0", "key-1")
+
+	found, bufs, missing := c.Fetch(context.Background(), []string{"key-0", "key-1", "key-2"}, 0)
+	assert.Equal(t, []string{"key-0", "key-1"}, found)
+	assert.Equal(t, [][]byte{testDiskCacheValue(0), testDiskCacheValue(1)}, bufs)
+	assert.Equal(t, []string{"key-2"}, missing)
+
+	// Overwriting an entry replaces its value.
+	c.Store(context.Background(), []string{"key-0"}, [][]byte{[]byte("updated")}, 0)
+	assert.Equal(t, map[string][]byte{"key-0": []byte("updated")}, c.GetMulti(context.Background(), []string{"key-0"}))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP cortex_disk_cache_items The number of entries currently in the disk cache.
+		# TYPE cortex_disk_cache_items gauge
+		cortex_disk_cache_items{name="test"} 2
+		# HELP cortex_disk_cache_size_bytes The size in bytes of the entries currently in the disk cache.
+		# TYPE cortex_disk_cache_size_bytes gauge
+		cortex_disk_cache_size_bytes{name="test"} %d
+		# HELP cortex_disk_cache_requests_total Total number of keys requested to the disk cache.
+		# TYPE cortex_disk_cache_requests_total counter
+		cortex_disk_cache_requests_total{name="test"} 4
+		# HELP cortex_disk_cache_hits_total Total number of keys requested to the disk cache that were a hit.
+		# TYPE cortex_disk_cache_hits_total counter
+		cortex_disk_cache_hits_total{name="test"} 3
+	`, testDiskCacheEntrySize+diskCacheEntryHeaderSize+5+len("updated"))),
+		"cortex_disk_cache_items", "cortex_disk_cache_size_bytes", "cortex_disk_cache_requests_total", "cortex_disk_cache_hits_total"))
+}
+
+func TestDiskCache_SetAsync(t *testing.T) {
+	c, _ := newTestDiskCache(t, DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024})
+
+	require.NoError(t, c.SetAsync("key-0", testDiskCacheValue(0), time.Hour))
+
+	// Stopping the cache waits until the enqueued writes are done.
+	c.Stop()
+	assert.FileExists(t, c.entryPath("key-0"))
+}
+
+func TestDiskCache_Eviction(t *testing.T) {
+	c, reg := newTestDiskCache(t, DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 3 * testDiskCacheEntrySize})
+	defer c.Stop()
+
+	storeTestDiskCacheEntries(c, "key-0", "key-1", "key-2")
+
+	// Fetching key-0 makes key-1 the least recently used entry.
+	_, _, missing := c.Fetch(context.Background(), []string{"key-0"}, 0)
+	require.Empty(t, missing)
+
+	c.Store(context.Background(), []string{"key-3"}, [][]byte{testDiskCacheValue(3)}, 0)
+
+	found, _, missing := c.Fetch(context.Background(), []string{"key-0", "key-1", "key-2", "key-3"}, 0)
+	assert.Equal(t, []string{"key-0", "key-2", "key-3"}, found)
+	assert.Equal(t, []string{"key-1"}, missing)
+	assert.NoFileExists(t, c.entryPath("key-1"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.evicted))
+
+	// Entries bigger than the cache are not stored.
+	c.Store(context.Background(), []string{"key-4"}, [][]byte{make([]byte, 3*testDiskCacheEntrySize)}, 0)
+	assert.NoFileExists(t, c.entryPath("key-4"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP cortex_disk_cache_items The number of entries currently in the disk cache.
+		# TYPE cortex_disk_cache_items gauge
+		cortex_disk_cache_items{name="test"} 3
+		# HELP cortex_disk_cache_size_bytes The size in bytes of the entries currently in the disk cache.
+		# TYPE cortex_disk_cache_size_bytes gauge
+		cortex_disk_cache_size_bytes{name="test"} %d
+	`, 3*testDiskCacheEntrySize)), "cortex_disk_cache_items", "cortex_disk_cache_size_bytes"))
+}
+
+func TestDiskCache_Expiry(t *testing.T) {
+	c, _ := newTestDiskCache(t, DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024, Validity: time.Hour})
+	defer c.Stop()
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Store(context.Background(), []string{"key-0"}, [][]byte{testDiskCacheValue(0)}, time.Minute)
+	c.Store(context.Background(), []string{"key-1"}, [][]byte{testDiskCacheValue(1)}, 0)
+
+	// The entries stored without a TTL expire after the configured validity.
+	now = now.Add(2 * time.Minute)
+	found, _, _ := c.Fetch(context.Background(), []string{"key-0", "key-1"}, 0)
+	assert.Equal(t, []string{"key-1"}, found)
+	assert.NoFileExists(t, c.entryPath("key-0"))
+
+	now = now.Add(time.Hour)
+	found, _, _ = c.Fetch(context.Background(), []string{"key-0", "key-1"}, 0)
+	assert.Empty(t, found)
+}
+
+func TestDiskCache_CorruptedEntry(t *testing.T) {
+	c, _ := newTestDiskCache(t, DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024})
+	defer c.Stop()
+
+	storeTestDiskCacheEntries(c, "key-0", "key-1")
+
+	// Flip the last byte of the key-0 value.
+	path := c.entryPath("key-0")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content[len(content)-1]++
+	require.NoError(t, os.WriteFile(path, content, 0o666))
+
+	found, _, missing := c.Fetch(context.Background(), []string{"key-0", "key-1"}, 0)
+	assert.Equal(t, []string{"key-1"}, found)
+	assert.Equal(t, []string{"key-0"}, missing)
+	assert.NoFileExists(t, path)
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.corrupted))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.itemsCurrent))
+}
+
+func TestDiskCache_WarmStart(t *testing.T) {
+	t.Run("should restore the entries and their order after a clean shutdown", func(t *testing.T) {
+		cfg := DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 3 * testDiskCacheEntrySize}
+
+		c, _ := newTestDiskCache(t, cfg)
+		storeTestDiskCacheEntries(c, "key-0", "key-1", "key-2")
+		_, _, missing := c.Fetch(context.Background(), []string{"key-0"}, 0)
+		require.Empty(t, missing)
+		c.Stop()
+		require.FileExists(t, filepath.Join(cfg.Path, diskCacheIndexFile))
+
+		c, reg := newTestDiskCache(t, cfg)
+		defer c.Stop()
+		assert.Equal(t, float64(3), testutil.ToFloat64(c.loadedEntries))
+
+		// key-1 is still the least recently used entry.
+		c.Store(context.Background(), []string{"key-3"}, [][]byte{testDiskCacheValue(3)}, 0)
+		found, bufs, _ := c.Fetch(context.Background(), []string{"key-0", "key-1", "key-2", "key-3"}, 0)
+		assert.Equal(t, []string{"key-0", "key-2", "key-3"}, found)
+		assert.Equal(t, [][]byte{testDiskCacheValue(0), testDiskCacheValue(2), testDiskCacheValue(3)}, bufs)
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_disk_cache_loaded_items The number of entries loaded from disk when the disk cache started.
+			# TYPE cortex_disk_cache_loaded_items gauge
+			cortex_disk_cache_loaded_items{name="test"} 3
+		`), "cortex_disk_cache_loaded_items"))
+	})
+
+	t.Run("should reconcile the index with the entry files after a crash", func(t *testing.T) {
+		cfg := DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024}
+
+		c, _ := newTestDiskCache(t, cfg)
+		storeTestDiskCacheEntries(c, "key-0", "key-1")
+		c.syncIndex()
+
+		// Entries written or removed after the last index sync.
+		storeTestDiskCacheEntries(c, "key-2")
+		require.NoError(t, os.Remove(c.entryPath("key-1")))
+
+		// A partially written entry and a corrupted entry.
+		for _, key := range []string{"key-3", "key-4"} {
+			require.NoError(t, os.MkdirAll(filepath.Dir(c.entryPath(key)), os.ModePerm))
+		}
+		require.NoError(t, os.WriteFile(c.entryPath("key-3")+"-123"+diskCacheTmpSuffix, []byte("partial"), 0o666))
+		require.NoError(t, os.WriteFile(c.entryPath("key-4"), []byte("corrupted"), 0o666))
+
+		// The cache isn't stopped, to simulate a crash.
+		c, _ = newTestDiskCache(t, cfg)
+		defer c.Stop()
+
+		found, _, missing := c.Fetch(context.Background(), []string{"key-0", "key-1", "key-2", "key-3", "key-4"}, 0)
+		assert.Equal(t, []string{"key-0", "key-2"}, found)
+		assert.Equal(t, []string{"key-1", "key-3", "key-4"}, missing)
+		assert.Equal(t, float64(1), testutil.ToFloat64(c.corrupted))
+		assert.NoFileExists(t, c.entryPath("key-4"))
+		assert.NoFileExists(t, c.entryPath("key-3")+"-123"+diskCacheTmpSuffix)
+	})
+
+	t.Run("should load the entry files if the index is corrupted", func(t *testing.T) {
+		cfg := DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024}
+
+		c, _ := newTestDiskCache(t, cfg)
+		storeTestDiskCacheEntries(c, "key-0", "key-1")
+		c.Stop()
+
+		indexPath := filepath.Join(cfg.Path, diskCacheIndexFile)
+		content, err := os.ReadFile(indexPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(indexPath, content[:len(content)-1], 0o666))
+
+		c, _ = newTestDiskCache(t, cfg)
+		defer c.Stop()
+
+		found, _, _ := c.Fetch(context.Background(), []string{"key-0", "key-1"}, 0)
+		assert.Equal(t, []string{"key-0", "key-1"}, found)
+	})
+
+	t.Run("should evict the least recently used entries if the max size has been reduced", func(t *testing.T) {
+		cfg := DiskCacheConfig{Path: t.TempDir(), MaxSizeBytes: 1024 * 1024}
+
+		c, _ := newTestDiskCache(t, cfg)
+		storeTestDiskCacheEntries(c, "key-0", "key-1", "key-2")
+		c.Stop()
+
+		cfg.MaxSizeBytes = 2 * testDiskCacheEntrySize
+		c, _ = newTestDiskCache(t, cfg)
+		defer c.Stop()
+
+		found, _, _ := c.Fetch(context.Background(), []string{"key-0", "key-1", "key-2"}, 0)
+		assert.Equal(t, []string{"key-1", "key-2"}, found)
+		assert.NoFileExists(t, c.entryPath("key-0"))
+	})
+}
+
+func TestDiskCacheConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&DiskCacheConfig{}).Validate())
+	assert.NoError(t, (&DiskCacheConfig{Path: "/data", MaxSizeBytes: 1}).Validate())
+	assert.Equal(t, errDiskCacheInvalidMaxSizeBytes, (&DiskCacheConfig{Path: "/data"}).Validate())
+}
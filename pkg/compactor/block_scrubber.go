@@ -0,0 +1,691 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/logutil"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/runutil"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+const (
+	reasonValueScrubberRepair = "scrubber-repair"
+
+	// quarantinedNoCompactReason is the reason of the no-compact mark of the quarantined blocks.
+	quarantinedNoCompactReason metadata.NoCompactReason = "block-quarantined"
+
+	scrubActionQuarantined  = "quarantined"
+	scrubActionRepaired     = "repaired"
+	scrubActionRepairFailed = "repair-failed"
+	scrubActionNone         = "none"
+
+	// blockScrubberStatusFile is the name of the file, in the tenant's bucket location, recording
+	// the blocks already verified by the block scrubber.
+	blockScrubberStatusFile = "block-scrubber-status.json"
+	// blockScrubberStatusVersion1 is the current version of the block scrubber status file.
+	blockScrubberStatusVersion1 = 1
+)
+
+var (
+	errInvalidBlockScrubberInterval    = errors.New("block scrubber interval must be greater than 0")
+	errInvalidBlockScrubberConcurrency = errors.New("block scrubber concurrency must be greater than 0")
+	errInvalidBlockScrubberReverify    = errors.New("block scrubber reverify ratio must be between 0 and 1, and reverify max blocks must be greater than or equal to 0")
+)
+
+// BlockScrubberConfig configures the background verification of the blocks integrity.
+type BlockScrubberConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	Interval          time.Duration `yaml:"interval"`
+	Concurrency       int           `yaml:"concurrency"`
+	RepairEnabled     bool          `yaml:"repair_enabled"`
+	ReverifyRatio     float64       `yaml:"reverify_ratio"`
+	ReverifyMaxBlocks int           `yaml:"reverify_max_blocks"`
+}
+
+func (cfg *BlockScrubberConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "[Experimental] If true, the compactor periodically verifies the index checksums, chunk CRCs and meta consistency of the blocks of the tenants it owns. Corrupted blocks are quarantined: they are marked for no compaction and the queriers skip them. The report of the affected time ranges is exposed via the /compactor/scrub_status endpoint.")
+	f.DurationVar(&cfg.Interval, prefix+"interval", 24*time.Hour, "[Experimental] How frequently the new blocks of each tenant are verified. The blocks already verified are recorded in the block-scrubber-status.json file of the tenant, and are not verified again unless sampled for re-verification.")
+	f.IntVar(&cfg.Concurrency, prefix+"concurrency", 1, "[Experimental] Max number of blocks verified concurrently. Each block is downloaded to the compactor data directory to be verified.")
+	f.BoolVar(&cfg.RepairEnabled, prefix+"repair-enabled", false, "[Experimental] If true, the blocks whose index contains out-of-order, duplicated or outside chunks are repaired: a fixed copy of the block is uploaded and the original block is marked for deletion.")
+	f.Float64Var(&cfg.ReverifyRatio, prefix+"reverify-ratio", 0, "[Experimental] Fraction of the blocks already verified which are verified again in each run, starting from the least recently verified ones. 0 to verify each block only once.")
+	f.IntVar(&cfg.ReverifyMaxBlocks, prefix+"reverify-max-blocks", 10, "[Experimental] Max number of blocks already verified which are verified again in each run, for each tenant. 0 for no limit.")
+}
+
+func (cfg *BlockScrubberConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Interval <= 0 {
+		return errInvalidBlockScrubberInterval
+	}
+	if cfg.Concurrency <= 0 {
+		return errInvalidBlockScrubberConcurrency
+	}
+	if cfg.ReverifyRatio < 0 || cfg.ReverifyRatio > 1 || cfg.ReverifyMaxBlocks < 0 {
+		return errInvalidBlockScrubberReverify
+	}
+	return nil
+}
+
+// ScrubbedBlock is a block found with integrity issues by the block scrubber.
+type ScrubbedBlock struct {
+	BlockID ulid.ULID `json:"block_id"`
+	// MinTime and MaxTime are the boundaries of the time range affected by the issues, in milliseconds.
+	MinTime int64  `json:"min_time"`
+	MaxTime int64  `json:"max_time"`
+	Details string `json:"details"`
+	// Action is what the scrubber did about the block.
+	Action string `json:"action"`
+	// RepairedBlockID is the ID of the fixed copy of the block, if it has been repaired.
+	RepairedBlockID string `json:"repaired_block_id,omitempty"`
+}
+
+// TenantScrubReport is the report of the last verification of a tenant's blocks.
+type TenantScrubReport struct {
+	Tenant           string    `json:"tenant"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	VerifiedBlocks   int       `json:"verified_blocks"`
+	UnverifiedBlocks int       `json:"unverified_blocks"`
+	// PreviouslyVerifiedBlocks is the number of blocks not verified because they have
+	// already been verified in a previous run.
+	PreviouslyVerifiedBlocks int             `json:"previously_verified_blocks"`
+	AffectedBlocks           []ScrubbedBlock `json:"affected_blocks"`
+}
+
+// blockScrubberStatus records the blocks of a tenant already verified by the block scrubber. It's
+// stored in the bucket, so that the blocks are not verified again after a restart or a resharding.
+type blockScrubberStatus struct {
+	Version int `json:"version"`
+	// VerifiedBlocks maps the IDs of the blocks found healthy to the unix timestamp of their last verification.
+	VerifiedBlocks map[ulid.ULID]int64 `json:"verified_blocks"`
+}
+
+// blockIssue is an integrity issue of a block.
+type blockIssue struct {
+	details string
+	// repairable is true if the issue can be fixed by rewriting the block.
+	repairable bool
+	minTime    int64
+	maxTime    int64
+}
+
+// BlockScrubber periodically verifies the integrity of the blocks of the tenants owned
+// by the compactor, and repairs or quarantines the blocks found corrupted.
+type BlockScrubber struct {
+	services.Service
+
+	cfg          BlockScrubberConfig
+	cfgProvider  ConfigProvider
+	bucketClient objstore.InstrumentedBucket
+	usersScanner users.Scanner
+	scrubDir     string
+	logger       log.Logger
+
+	reportsMtx sync.RWMutex
+	reports    map[string]*TenantScrubReport
+
+	runsStarted                 prometheus.Counter
+	runsCompleted               prometheus.Counter
+	runsFailed                  prometheus.Counter
+	runsLastSuccess             prometheus.Gauge
+	blocksVerified              prometheus.Counter
+	blocksVerifyFailed          prometheus.Counter
+	blocksRepaired              prometheus.Counter
+	blocksQuarantined           prometheus.Counter
+	blocksMarkedForDeletion     *prometheus.CounterVec
+	blocksMarkedForNoCompaction prometheus.Counter
+}
+
+func NewBlockScrubber(cfg BlockScrubberConfig, bucketClient objstore.InstrumentedBucket, usersScanner users.Scanner, cfgProvider ConfigProvider, scrubDir string, logger log.Logger, reg prometheus.Registerer, blocksMarkedForDeletion *prometheus.CounterVec, blocksMarkedForNoCompaction prometheus.Counter) *BlockScrubber {
+	s := &BlockScrubber{
+		cfg:          cfg,
+		cfgProvider:  cfgProvider,
+		bucketClient: bucketClient,
+		usersScanner: usersScanner,
+		scrubDir:     scrubDir,
+		logger:       log.With(logger, "component", "block-scrubber"),
+		reports:      map[string]*TenantScrubReport{},
+		runsStarted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_runs_started_total",
+			Help: "Total number of block scrubber runs started.",
+		}),
+		runsCompleted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_runs_completed_total",
+			Help: "Total number of block scrubber runs successfully completed.",
+		}),
+		runsFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_runs_failed_total",
+			Help: "Total number of block scrubber runs failed.",
+		}),
+		runsLastSuccess: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_compactor_block_scrubber_last_successful_run_timestamp_seconds",
+			Help: "Unix timestamp of the last successful block scrubber run.",
+		}),
+		blocksVerified: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_blocks_verified_total",
+			Help: "Total number of blocks verified by the block scrubber.",
+		}),
+		blocksVerifyFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_block_verifications_failed_total",
+			Help: "Total number of blocks the block scrubber failed to verify, for example because they couldn't be downloaded.",
+		}),
+		blocksRepaired: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_blocks_repaired_total",
+			Help: "Total number of blocks repaired by the block scrubber.",
+		}),
+		blocksQuarantined: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_scrubber_blocks_quarantined_total",
+			Help: "Total number of blocks found corrupted and quarantined by the block scrubber.",
+		}),
+		blocksMarkedForDeletion:     blocksMarkedForDeletion,
+		blocksMarkedForNoCompaction: blocksMarkedForNoCompaction,
+	}
+
+	s.Service = services.NewBasicService(nil, s.running, nil)
+
+	return s
+}
+
+func (s *BlockScrubber) running(ctx context.Context) error {
+	// Run a first scrub right away, otherwise a compactor restarted more often than the
+	// scrub interval would never verify the blocks.
+	s.scrubUsers(ctx)
+
+	t := time.NewTicker(s.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			s.scrubUsers(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Report returns the reports of the last verification of the tenants owned by the compactor,
+// sorted by tenant.
+func (s *BlockScrubber) Report() []TenantScrubReport {
+	s.reportsMtx.RLock()
+	defer s.reportsMtx.RUnlock()
+
+	out := make([]TenantScrubReport, 0, len(s.reports))
+	for _, r := range s.reports {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Tenant < out[j].Tenant
+	})
+	return out
+}
+
+func (s *BlockScrubber) scrubUsers(ctx context.Context) {
+	s.runsStarted.Inc()
+
+	active, _, _, err := s.usersScanner.ScanUsers(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("msg", "failed to discover users from bucket", "err", err)
+		s.runsFailed.Inc()
+		return
+	}
+
+	// Drop the reports of the tenants not belonging anymore to this shard.
+	owned := make(map[string]struct{}, len(active))
+	for _, userID := range active {
+		owned[userID] = struct{}{}
+	}
+	s.reportsMtx.Lock()
+	for userID := range s.reports {
+		if _, ok := owned[userID]; !ok {
+			delete(s.reports, userID)
+		}
+	}
+	s.reportsMtx.Unlock()
+
+	failed := false
+	for _, userID := range active {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := s.scrubUser(ctx, userID); err != nil {
+			level.Error(s.logger).Log("msg", "failed to scrub blocks", "user", userID, "err", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		s.runsFailed.Inc()
+	} else {
+		s.runsCompleted.Inc()
+		s.runsLastSuccess.SetToCurrentTime()
+	}
+}
+
+func (s *BlockScrubber) scrubUser(ctx context.Context, userID string) error {
+	userLogger := util_log.WithUserID(userID, s.logger)
+	userBucket := bucket.NewUserBucketClient(userID, s.bucketClient, s.cfgProvider)
+
+	// The blocks to verify are read from the bucket index, which only contains complete blocks.
+	idx, err := bucketindex.ReadIndex(ctx, s.bucketClient, userID, s.cfgProvider, userLogger)
+	if errors.Is(err, bucketindex.ErrIndexNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "read bucket index")
+	}
+
+	status, err := readBlockScrubberStatus(ctx, userBucket, userLogger)
+	if err != nil {
+		return errors.Wrap(err, "read block scrubber status")
+	}
+
+	report := &TenantScrubReport{Tenant: userID, StartedAt: time.Now()}
+
+	markedForDeletion := make(map[ulid.ULID]struct{}, len(idx.BlockDeletionMarks))
+	for _, d := range idx.BlockDeletionMarks {
+		markedForDeletion[d.ID] = struct{}{}
+	}
+
+	var (
+		mtx   sync.Mutex
+		queue = make([]any, 0, len(idx.Blocks))
+
+		// The blocks not in the bucket index anymore are dropped from the status.
+		verified   = make(map[ulid.ULID]int64, len(status.VerifiedBlocks))
+		candidates []*bucketindex.Block
+	)
+
+	for _, b := range idx.Blocks {
+		// Parquet-only blocks have no TSDB index and chunks to verify.
+		if _, ok := markedForDeletion[b.ID]; ok || b.ParquetOnly {
+			continue
+		}
+
+		// The blocks quarantined in a previous run are reported without being verified again.
+		if b.Quarantined {
+			affected := ScrubbedBlock{BlockID: b.ID, MinTime: b.MinTime, MaxTime: b.MaxTime, Action: scrubActionQuarantined}
+			if mark, err := bucketindex.ReadQuarantineMark(ctx, userBucket, b.ID); err == nil {
+				affected.MinTime, affected.MaxTime, affected.Details = mark.MinTime, mark.MaxTime, mark.Details
+			}
+			report.AffectedBlocks = append(report.AffectedBlocks, affected)
+			continue
+		}
+
+		if verifiedAt, ok := status.VerifiedBlocks[b.ID]; ok {
+			verified[b.ID] = verifiedAt
+			candidates = append(candidates, b)
+			continue
+		}
+
+		queue = append(queue, b)
+	}
+
+	reverify := s.sampleReverification(candidates, verified)
+	for _, b := range reverify {
+		queue = append(queue, b)
+	}
+	report.PreviouslyVerifiedBlocks = len(candidates) - len(reverify)
+
+	err = concurrency.ForEach(ctx, queue, s.cfg.Concurrency, func(ctx context.Context, job any) error {
+		b := job.(*bucketindex.Block)
+
+		affected, err := s.scrubBlock(ctx, userLogger, userID, userBucket, b)
+
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if err != nil {
+			s.blocksVerifyFailed.Inc()
+			report.UnverifiedBlocks++
+			level.Warn(userLogger).Log("msg", "failed to verify block", "block", b.ID, "err", err)
+			return nil
+		}
+
+		s.blocksVerified.Inc()
+		report.VerifiedBlocks++
+
+		// The blocks with issues are not recorded as verified, so that they keep being
+		// reported until they are repaired, quarantined or deleted.
+		if affected != nil {
+			report.AffectedBlocks = append(report.AffectedBlocks, *affected)
+			delete(verified, b.ID)
+		} else {
+			verified[b.ID] = time.Now().Unix()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	status = &blockScrubberStatus{Version: blockScrubberStatusVersion1, VerifiedBlocks: verified}
+	if err := writeBlockScrubberStatus(ctx, userBucket, status); err != nil {
+		return errors.Wrap(err, "write block scrubber status")
+	}
+
+	sort.Slice(report.AffectedBlocks, func(i, j int) bool {
+		return report.AffectedBlocks[i].MinTime < report.AffectedBlocks[j].MinTime
+	})
+	report.FinishedAt = time.Now()
+
+	s.reportsMtx.Lock()
+	s.reports[userID] = report
+	s.reportsMtx.Unlock()
+
+	return nil
+}
+
+// sampleReverification returns the blocks already verified which have to be verified again,
+// starting from the least recently verified ones.
+func (s *BlockScrubber) sampleReverification(candidates []*bucketindex.Block, verified map[ulid.ULID]int64) []*bucketindex.Block {
+	n := int(math.Ceil(s.cfg.ReverifyRatio * float64(len(candidates))))
+	if s.cfg.ReverifyMaxBlocks > 0 {
+		n = min(n, s.cfg.ReverifyMaxBlocks)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return verified[candidates[i].ID] < verified[candidates[j].ID]
+	})
+	return candidates[:n]
+}
+
+// readBlockScrubberStatus reads the block scrubber status of the tenant. An empty status is
+// returned if the status doesn't exist or can't be decoded, so that all blocks are verified.
+func readBlockScrubberStatus(ctx context.Context, userBucket objstore.InstrumentedBucket, logger log.Logger) (*blockScrubberStatus, error) {
+	empty := &blockScrubberStatus{Version: blockScrubberStatusVersion1}
+
+	reader, err := userBucket.WithExpectedErrs(userBucket.IsObjNotFoundErr).Get(ctx, blockScrubberStatusFile)
+	if err != nil {
+		if userBucket.IsObjNotFoundErr(err) {
+			return empty, nil
+		}
+		return nil, err
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close block scrubber status reader")
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &blockScrubberStatus{}
+	if err := json.Unmarshal(content, status); err != nil || status.Version != blockScrubberStatusVersion1 {
+		level.Warn(logger).Log("msg", "ignoring invalid block scrubber status, all blocks will be verified", "err", err, "version", status.Version)
+		return empty, nil
+	}
+	return status, nil
+}
+
+func writeBlockScrubberStatus(ctx context.Context, userBucket objstore.Bucket, status *blockScrubberStatus) error {
+	content, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return userBucket.Upload(ctx, blockScrubberStatusFile, bytes.NewReader(content))
+}
+
+// scrubBlock downloads and verifies a block. If the block has integrity issues, it is repaired or
+// quarantined, and the returned ScrubbedBlock describes what has been done. An error is returned
+// only if the block couldn't be verified.
+func (s *BlockScrubber) scrubBlock(ctx context.Context, logger log.Logger, userID string, userBucket objstore.InstrumentedBucket, b *bucketindex.Block) (*ScrubbedBlock, error) {
+	logger = log.With(logger, "block", b.ID)
+
+	// Each block is downloaded in its own directory, where the repaired block is written too.
+	workDir := filepath.Join(s.scrubDir, userID, b.ID.String())
+	if err := os.RemoveAll(workDir); err != nil {
+		return nil, errors.Wrap(err, "clean up scrub directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove scrub directory", "dir", workDir, "err", err)
+		}
+	}()
+
+	blockDir := filepath.Join(workDir, b.ID.String())
+	if err := block.Download(ctx, logger, userBucket, b.ID, blockDir); err != nil {
+		return nil, errors.Wrap(err, "download block")
+	}
+
+	meta, err := metadata.ReadFromDir(blockDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read block meta")
+	}
+
+	issue, err := verifyBlock(ctx, logger, blockDir, b.ID, meta)
+	if err != nil {
+		return nil, err
+	}
+	if issue == nil {
+		return nil, nil
+	}
+
+	affected := &ScrubbedBlock{BlockID: b.ID, MinTime: issue.minTime, MaxTime: issue.maxTime, Details: issue.details, Action: scrubActionNone}
+
+	if !issue.repairable {
+		if err := s.quarantineBlock(ctx, logger, userBucket, b.ID, issue); err != nil {
+			return nil, errors.Wrap(err, "quarantine block")
+		}
+		affected.Action = scrubActionQuarantined
+		return affected, nil
+	}
+
+	// The repairable issues don't prevent the block from being queried, so the block is left
+	// as is if the repair is disabled or fails.
+	if !s.cfg.RepairEnabled {
+		level.Warn(logger).Log("msg", "found block with repairable issues", "issue", issue.details)
+		return affected, nil
+	}
+
+	repairedID, err := s.repairBlock(ctx, logger, userID, userBucket, workDir, b.ID, meta)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to repair block", "issue", issue.details, "err", err)
+		affected.Action = scrubActionRepairFailed
+		return affected, nil
+	}
+
+	affected.Action = scrubActionRepaired
+	affected.RepairedBlockID = repairedID.String()
+	return affected, nil
+}
+
+// verifyBlock verifies the meta consistency, the index checksums and health, and the chunk CRCs
+// of the block in the input directory. It returns the first issue found, if any.
+func verifyBlock(ctx context.Context, logger log.Logger, blockDir string, id ulid.ULID, meta *metadata.Meta) (*blockIssue, error) {
+	if issue := verifyBlockMeta(blockDir, id, meta); issue != nil {
+		return issue, nil
+	}
+
+	// Reading the index verifies the checksums of its table of contents and series.
+	stats, err := block.GatherIndexHealthStats(ctx, logger, filepath.Join(blockDir, block.IndexFilename), meta.MinTime, meta.MaxTime)
+	if err != nil {
+		return &blockIssue{details: fmt.Sprintf("corrupted index: %s", err), minTime: meta.MinTime, maxTime: meta.MaxTime}, nil
+	}
+	if err := stats.AnyErr(); err != nil {
+		return &blockIssue{details: fmt.Sprintf("index issues: %s", err), repairable: true, minTime: meta.MinTime, maxTime: meta.MaxTime}, nil
+	}
+
+	return verifyBlockChunks(ctx, logger, blockDir)
+}
+
+// verifyBlockMeta verifies the block meta is consistent with the block and its files.
+func verifyBlockMeta(blockDir string, id ulid.ULID, meta *metadata.Meta) *blockIssue {
+	newIssue := func(format string, args ...any) *blockIssue {
+		return &blockIssue{details: fmt.Sprintf(format, args...), minTime: meta.MinTime, maxTime: meta.MaxTime}
+	}
+
+	if meta.ULID != id {
+		return newIssue("meta ID %s doesn't match the block ID", meta.ULID)
+	}
+	if meta.MinTime >= meta.MaxTime {
+		return newIssue("meta has an invalid time range [%d, %d)", meta.MinTime, meta.MaxTime)
+	}
+
+	for _, f := range meta.Thanos.Files {
+		if f.RelPath == "" || f.RelPath == block.MetaFilename {
+			continue
+		}
+
+		filePath := filepath.Join(blockDir, f.RelPath)
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return newIssue("file %s listed in meta is missing", f.RelPath)
+		}
+		if f.SizeBytes > 0 && info.Size() != f.SizeBytes {
+			return newIssue("file %s has size %d while %d is expected", f.RelPath, info.Size(), f.SizeBytes)
+		}
+		if f.Hash != nil && f.Hash.Func != metadata.NoneFunc {
+			actual, err := metadata.CalculateHash(filePath, f.Hash.Func, log.NewNopLogger())
+			if err != nil || !f.Hash.Equal(&actual) {
+				return newIssue("file %s doesn't match its hash", f.RelPath)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyBlockChunks reads all the chunks of the block, verifying their CRCs. The affected time
+// range of the returned issue is the one of the corrupted chunks.
+func verifyBlockChunks(ctx context.Context, logger log.Logger, blockDir string) (_ *blockIssue, err error) {
+	b, err := tsdb.OpenBlock(logutil.GoKitLogToSlog(logger), blockDir, nil, tsdb.DefaultPostingsDecoderFactory)
+	if err != nil {
+		return nil, errors.Wrap(err, "open block")
+	}
+	defer runutil.CloseWithErrCapture(&err, b, "close block")
+
+	indexr, err := b.Index()
+	if err != nil {
+		return nil, errors.Wrap(err, "open index")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "close index reader")
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return nil, errors.Wrap(err, "open chunks")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkr, "close chunks reader")
+
+	k, v := index.AllPostingsKey()
+	p, err := indexr.Postings(ctx, k, v)
+	if err != nil {
+		return nil, errors.Wrap(err, "read postings")
+	}
+
+	var (
+		builder   labels.ScratchBuilder
+		chks      []chunks.Meta
+		corrupted int
+		issue     *blockIssue
+	)
+
+	for p.Next() {
+		if err := indexr.Series(p.At(), &builder, &chks); err != nil {
+			meta := b.Meta()
+			return &blockIssue{details: fmt.Sprintf("corrupted index series: %s", err), minTime: meta.MinTime, maxTime: meta.MaxTime}, nil
+		}
+
+		for _, c := range chks {
+			if _, _, err := chunkr.ChunkOrIterable(c); err != nil {
+				corrupted++
+				if issue == nil {
+					issue = &blockIssue{details: err.Error(), minTime: c.MinTime, maxTime: c.MaxTime}
+				}
+				issue.minTime = min(issue.minTime, c.MinTime)
+				issue.maxTime = max(issue.maxTime, c.MaxTime)
+			}
+		}
+	}
+	if err := p.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate postings")
+	}
+
+	if issue != nil {
+		issue.details = fmt.Sprintf("%d corrupted chunks, first error: %s", corrupted, issue.details)
+	}
+	return issue, nil
+}
+
+// quarantineBlock marks the block for no compaction and uploads its quarantine mark, so that
+// it's neither compacted nor queried anymore.
+func (s *BlockScrubber) quarantineBlock(ctx context.Context, logger log.Logger, userBucket objstore.InstrumentedBucket, id ulid.ULID, issue *blockIssue) error {
+	if err := block.MarkForNoCompact(ctx, logger, userBucket, id, quarantinedNoCompactReason, issue.details, s.blocksMarkedForNoCompaction); err != nil {
+		return errors.Wrap(err, "mark block for no compaction")
+	}
+
+	if err := bucketindex.WriteQuarantineMark(ctx, userBucket, &bucketindex.QuarantineMark{
+		ID:             id,
+		Version:        bucketindex.QuarantineMarkVersion1,
+		Details:        issue.details,
+		QuarantineTime: time.Now().Unix(),
+		MinTime:        issue.minTime,
+		MaxTime:        issue.maxTime,
+	}); err != nil {
+		return errors.Wrap(err, "upload quarantine mark")
+	}
+
+	s.blocksQuarantined.Inc()
+	level.Warn(logger).Log("msg", "quarantined corrupted block", "issue", issue.details, "minTime", issue.minTime, "maxTime", issue.maxTime)
+	return nil
+}
+
+// repairBlock rewrites the block, dropping its out-of-order, duplicated and outside chunks. The
+// repaired block is uploaded once verified, and the original block is marked for deletion.
+func (s *BlockScrubber) repairBlock(ctx context.Context, logger log.Logger, userID string, userBucket objstore.InstrumentedBucket, workDir string, id ulid.ULID, meta *metadata.Meta) (ulid.ULID, error) {
+	repairedID, err := block.Repair(ctx, logger, workDir, id, metadata.BucketRepairSource, block.IgnoreCompleteOutsideChunk, block.IgnoreIssue347OutsideChunk, block.IgnoreDuplicateOutsideChunk)
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "rewrite block")
+	}
+
+	repairedDir := filepath.Join(workDir, repairedID.String())
+	if err := block.VerifyIndex(ctx, logger, filepath.Join(repairedDir, block.IndexFilename), meta.MinTime, meta.MaxTime); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "verify repaired block")
+	}
+
+	if err := block.UploadPromBlock(ctx, logger, userBucket, repairedDir, metadata.NoneFunc); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "upload repaired block")
+	}
+
+	if err := block.MarkForDeletion(ctx, logger, userBucket, id, fmt.Sprintf("repaired by the block scrubber into block %s", repairedID), s.blocksMarkedForDeletion.WithLabelValues(userID, reasonValueScrubberRepair)); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "mark repaired block for deletion")
+	}
+
+	s.blocksRepaired.Inc()
+	level.Info(logger).Log("msg", "repaired block", "repaired_block", repairedID)
+	return repairedID, nil
+}
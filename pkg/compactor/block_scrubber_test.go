@@ -0,0 +1,283 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+func TestBlockScrubberConfig_Validate(t *testing.T) {
+	cfg := BlockScrubberConfig{Interval: 0, Concurrency: 0}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Enabled = true
+	assert.Equal(t, errInvalidBlockScrubberInterval, cfg.Validate())
+
+	cfg.Interval = time.Hour
+	assert.Equal(t, errInvalidBlockScrubberConcurrency, cfg.Validate())
+
+	cfg.Concurrency = 1
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ReverifyRatio = 1.5
+	assert.Equal(t, errInvalidBlockScrubberReverify, cfg.Validate())
+
+	cfg.ReverifyRatio = 0.5
+	cfg.ReverifyMaxBlocks = -1
+	assert.Equal(t, errInvalidBlockScrubberReverify, cfg.Validate())
+}
+
+func updateBlockMeta(t *testing.T, bkt objstore.Bucket, userID string, id ulid.ULID, update func(m *metadata.Meta)) {
+	ctx := context.Background()
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	m, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, id)
+	require.NoError(t, err)
+	update(&m)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, m.Write(&buf))
+	require.NoError(t, userBkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), &buf))
+}
+
+func writeBucketIndex(t *testing.T, bkt objstore.Bucket, userID string) *bucketindex.Index {
+	ctx := context.Background()
+
+	idx, _, _, err := bucketindex.NewUpdater(bkt, userID, nil, log.NewNopLogger()).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, idx))
+	return idx
+}
+
+func TestBlockScrubber(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, storageDir := cortex_testutil.PrepareFilesystemBucket(t)
+	bkt = bucketindex.BucketWithGlobalMarkers(bkt)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// A healthy block.
+	healthyBlock := createTSDBBlock(t, bkt, userID, 10, 100, nil)
+
+	// A block whose last chunk is corrupted.
+	corruptedChunkBlock := createTSDBBlock(t, bkt, userID, 100, 200, nil)
+	chunksFile := filepath.Join(storageDir, userID, corruptedChunkBlock.String(), block.ChunksDirname, "000001")
+	content, err := os.ReadFile(chunksFile)
+	require.NoError(t, err)
+	content[len(content)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(chunksFile, content, 0o644))
+
+	// A block with a chunk completely outside its time range, which can be repaired.
+	outsideChunkBlock := createTSDBBlock(t, bkt, userID, 200, 300, nil)
+	updateBlockMeta(t, bkt, userID, outsideChunkBlock, func(m *metadata.Meta) {
+		m.MinTime = 250
+	})
+
+	// A block whose meta lists a missing file.
+	missingFileBlock := createTSDBBlock(t, bkt, userID, 300, 400, nil)
+	updateBlockMeta(t, bkt, userID, missingFileBlock, func(m *metadata.Meta) {
+		m.Thanos.Files = []metadata.File{{RelPath: "chunks/000002", SizeBytes: 10}}
+	})
+
+	// A block marked for deletion, which is not verified.
+	deletedBlock := createTSDBBlock(t, bkt, userID, 400, 500, nil)
+	corruptedChunksFile := filepath.Join(storageDir, userID, deletedBlock.String(), block.ChunksDirname, "000001")
+	require.NoError(t, os.WriteFile(corruptedChunksFile, []byte("corrupted"), 0o644))
+	createDeletionMark(t, bkt, userID, deletedBlock, time.Now())
+
+	writeBucketIndex(t, bkt, userID)
+
+	logger := log.NewNopLogger()
+	reg := prometheus.NewPedanticRegistry()
+	scanner, err := users.NewScanner(users.UsersScannerConfig{Strategy: users.UserScanStrategyList}, bkt, logger, nil)
+	require.NoError(t, err)
+	blocksMarkedForDeletion := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: blocksMarkedForDeletionName,
+		Help: blocksMarkedForDeletionHelp,
+	}, append(commonLabels, reasonLabelName))
+	blocksMarkedForNoCompaction := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_compactor_blocks_marked_for_no_compaction_total",
+		Help: "Total number of blocks marked for no compact during a compaction run.",
+	})
+
+	cfg := BlockScrubberConfig{Enabled: true, Interval: time.Hour, Concurrency: 2, RepairEnabled: true}
+	scrubber := NewBlockScrubber(cfg, bkt, scanner, newMockConfigProvider(), t.TempDir(), logger, reg, blocksMarkedForDeletion, blocksMarkedForNoCompaction)
+	scrubber.scrubUsers(ctx)
+
+	reports := scrubber.Report()
+	require.Len(t, reports, 1)
+	assert.Equal(t, userID, reports[0].Tenant)
+	assert.Equal(t, 4, reports[0].VerifiedBlocks)
+	assert.Equal(t, 0, reports[0].UnverifiedBlocks)
+
+	affected := reports[0].AffectedBlocks
+	require.Len(t, affected, 3)
+
+	// The corrupted chunk is the one of the last sample of the block.
+	assert.Equal(t, corruptedChunkBlock, affected[0].BlockID)
+	assert.Equal(t, scrubActionQuarantined, affected[0].Action)
+	assert.Equal(t, int64(199), affected[0].MinTime)
+	assert.Equal(t, int64(199), affected[0].MaxTime)
+	assert.Contains(t, affected[0].Details, "1 corrupted chunks")
+
+	assert.Equal(t, outsideChunkBlock, affected[1].BlockID)
+	assert.Equal(t, scrubActionRepaired, affected[1].Action)
+	assert.Equal(t, int64(250), affected[1].MinTime)
+	assert.Equal(t, int64(300), affected[1].MaxTime)
+	repairedBlock, err := ulid.Parse(affected[1].RepairedBlockID)
+	require.NoError(t, err)
+
+	assert.Equal(t, missingFileBlock, affected[2].BlockID)
+	assert.Equal(t, scrubActionQuarantined, affected[2].Action)
+	assert.Contains(t, affected[2].Details, "chunks/000002")
+
+	// The corrupted blocks have been quarantined.
+	for _, id := range []ulid.ULID{corruptedChunkBlock, missingFileBlock} {
+		mark, err := bucketindex.ReadQuarantineMark(ctx, userBkt, id)
+		require.NoError(t, err)
+		assert.Equal(t, id, mark.ID)
+
+		ok, err := userBkt.Exists(ctx, path.Join(id.String(), metadata.NoCompactMarkFilename))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	// The repaired block has been uploaded, and the original one marked for deletion.
+	repairedMeta, err := block.DownloadMeta(ctx, logger, userBkt, repairedBlock)
+	require.NoError(t, err)
+	assert.Equal(t, metadata.BucketRepairSource, repairedMeta.Thanos.Source)
+	ok, err := userBkt.Exists(ctx, path.Join(outsideChunkBlock.String(), metadata.DeletionMarkFilename))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Only the healthy block has been recorded as verified.
+	status, err := readBlockScrubberStatus(ctx, userBkt, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []ulid.ULID{healthyBlock}, slices.Collect(maps.Keys(status.VerifiedBlocks)))
+
+	// Once the bucket index has been updated, the quarantined blocks are flagged in it, and they
+	// are reported without being verified again. Only the repaired block is new, and the healthy
+	// block has already been verified.
+	idx := writeBucketIndex(t, bkt, userID)
+	for _, b := range idx.Blocks {
+		assert.Equal(t, b.ID == corruptedChunkBlock || b.ID == missingFileBlock, b.Quarantined, b.ID.String())
+	}
+
+	scrubber.scrubUsers(ctx)
+
+	reports = scrubber.Report()
+	require.Len(t, reports, 1)
+	assert.Equal(t, 1, reports[0].VerifiedBlocks)
+	assert.Equal(t, 1, reports[0].PreviouslyVerifiedBlocks)
+	require.Len(t, reports[0].AffectedBlocks, 2)
+	assert.Equal(t, ScrubbedBlock{BlockID: corruptedChunkBlock, MinTime: 199, MaxTime: 199, Details: affected[0].Details, Action: scrubActionQuarantined}, reports[0].AffectedBlocks[0])
+	assert.Equal(t, missingFileBlock, reports[0].AffectedBlocks[1].BlockID)
+
+	assert.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_block_scrubber_blocks_verified_total Total number of blocks verified by the block scrubber.
+		# TYPE cortex_compactor_block_scrubber_blocks_verified_total counter
+		cortex_compactor_block_scrubber_blocks_verified_total 5
+
+		# HELP cortex_compactor_block_scrubber_block_verifications_failed_total Total number of blocks the block scrubber failed to verify, for example because they couldn't be downloaded.
+		# TYPE cortex_compactor_block_scrubber_block_verifications_failed_total counter
+		cortex_compactor_block_scrubber_block_verifications_failed_total 0
+
+		# HELP cortex_compactor_block_scrubber_blocks_quarantined_total Total number of blocks found corrupted and quarantined by the block scrubber.
+		# TYPE cortex_compactor_block_scrubber_blocks_quarantined_total counter
+		cortex_compactor_block_scrubber_blocks_quarantined_total 2
+
+		# HELP cortex_compactor_block_scrubber_blocks_repaired_total Total number of blocks repaired by the block scrubber.
+		# TYPE cortex_compactor_block_scrubber_blocks_repaired_total counter
+		cortex_compactor_block_scrubber_blocks_repaired_total 1
+
+		# HELP cortex_compactor_block_scrubber_runs_completed_total Total number of block scrubber runs successfully completed.
+		# TYPE cortex_compactor_block_scrubber_runs_completed_total counter
+		cortex_compactor_block_scrubber_runs_completed_total 2
+
+		# HELP cortex_compactor_blocks_marked_for_deletion_total Total number of blocks marked for deletion in compactor.
+		# TYPE cortex_compactor_blocks_marked_for_deletion_total counter
+		cortex_compactor_blocks_marked_for_deletion_total{reason="scrubber-repair",user="user-1"} 1
+
+		# HELP cortex_compactor_blocks_marked_for_no_compaction_total Total number of blocks marked for no compact during a compaction run.
+		# TYPE cortex_compactor_blocks_marked_for_no_compaction_total counter
+		cortex_compactor_blocks_marked_for_no_compaction_total 2
+	`),
+		"cortex_compactor_block_scrubber_blocks_verified_total",
+		"cortex_compactor_block_scrubber_block_verifications_failed_total",
+		"cortex_compactor_block_scrubber_blocks_quarantined_total",
+		"cortex_compactor_block_scrubber_blocks_repaired_total",
+		"cortex_compactor_block_scrubber_runs_completed_total",
+		"cortex_compactor_blocks_marked_for_deletion_total",
+		"cortex_compactor_blocks_marked_for_no_compaction_total",
+	))
+
+	// The report is served by the compactor.
+	c := &Compactor{blockScrubber: scrubber}
+	req := httptest.NewRequest(http.MethodGet, "/compactor/scrub_status", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	c.ScrubStatusHandler(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Tenants []TenantScrubReport `json:"tenants"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Tenants, 1)
+	assert.Len(t, resp.Tenants[0].AffectedBlocks, 2)
+
+	rec = httptest.NewRecorder()
+	c.ScrubStatusHandler(rec, httptest.NewRequest(http.MethodGet, "/compactor/scrub_status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), corruptedChunkBlock.String())
+
+	// A new scrubber, like after a restart, doesn't verify the blocks already verified again,
+	// unless they are sampled for re-verification.
+	scrubber = NewBlockScrubber(cfg, bkt, scanner, newMockConfigProvider(), t.TempDir(), logger, prometheus.NewPedanticRegistry(), blocksMarkedForDeletion, blocksMarkedForNoCompaction)
+	scrubber.scrubUsers(ctx)
+	reports = scrubber.Report()
+	require.Len(t, reports, 1)
+	assert.Equal(t, 0, reports[0].VerifiedBlocks)
+	assert.Equal(t, 2, reports[0].PreviouslyVerifiedBlocks)
+
+	cfg.ReverifyRatio = 1
+	cfg.ReverifyMaxBlocks = 1
+	scrubber = NewBlockScrubber(cfg, bkt, scanner, newMockConfigProvider(), t.TempDir(), logger, prometheus.NewPedanticRegistry(), blocksMarkedForDeletion, blocksMarkedForNoCompaction)
+	scrubber.scrubUsers(ctx)
+	reports = scrubber.Report()
+	require.Len(t, reports, 1)
+	assert.Equal(t, 1, reports[0].VerifiedBlocks)
+	assert.Equal(t, 1, reports[0].PreviouslyVerifiedBlocks)
+
+	// The healthy block hasn't been touched.
+	ok, err = userBkt.Exists(ctx, path.Join(healthyBlock.String(), metadata.NoCompactMarkFilename))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
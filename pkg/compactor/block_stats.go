@@ -0,0 +1,71 @@
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util/runutil"
+)
+
+// blockStatsCompactionLifecycleCallback wraps a compaction lifecycle callback, so that the label
+// statistics of the blocks resulting from the compaction are computed and uploaded next to them.
+type blockStatsCompactionLifecycleCallback struct {
+	compact.CompactionLifecycleCallback
+
+	bkt        objstore.Bucket
+	compactDir string
+	labelNames []string
+	failures   prometheus.Counter
+}
+
+func newBlockStatsCompactionLifecycleCallback(callback compact.CompactionLifecycleCallback, cfg Config, bkt objstore.Bucket, compactDir string, failures prometheus.Counter) compact.CompactionLifecycleCallback {
+	if !cfg.BlockStatsEnabled {
+		return callback
+	}
+	return &blockStatsCompactionLifecycleCallback{
+		CompactionLifecycleCallback: callback,
+		bkt:                         bkt,
+		compactDir:                  compactDir,
+		labelNames:                  cfg.BlockStatsLabels,
+		failures:                    failures,
+	}
+}
+
+func (c *blockStatsCompactionLifecycleCallback) PostCompactionCallback(ctx context.Context, logger log.Logger, cg *compact.Group, blockID ulid.ULID) error {
+	if err := c.CompactionLifecycleCallback.PostCompactionCallback(ctx, logger, cg, blockID); err != nil {
+		return err
+	}
+
+	// The block has already been uploaded, so failing here would only cause the compaction to be retried.
+	// Blocks without stats are always queried.
+	if err := c.uploadBlockStats(ctx, filepath.Join(c.compactDir, cg.Key(), blockID.String()), blockID); err != nil {
+		c.failures.Inc()
+		level.Warn(logger).Log("msg", "failed to upload block stats", "block", blockID.String(), "err", err)
+	}
+	return nil
+}
+
+func (c *blockStatsCompactionLifecycleCallback) uploadBlockStats(ctx context.Context, blockDir string, blockID ulid.ULID) (err error) {
+	indexr, err := index.NewFileReader(filepath.Join(blockDir, block.IndexFilename), index.DecodePostingsRaw)
+	if err != nil {
+		return errors.Wrap(err, "open block index")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "close block index")
+
+	stats, err := bucketindex.ComputeBlockStats(ctx, indexr, c.labelNames)
+	if err != nil {
+		return errors.Wrap(err, "compute block stats")
+	}
+	return bucketindex.WriteBlockStats(ctx, c.bkt, blockID, stats)
+}
@@ -0,0 +1,63 @@
+package compactor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+)
+
+func TestBlockStatsCompactionLifecycleCallback(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	const groupKey = "test_group_key"
+	group, err := compact.NewGroup(logger, nil, groupKey, labels.EmptyLabels(), 0, true, true, nil, nil, nil, nil, nil, nil, nil, nil, metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+
+	series := []labels.Labels{
+		labels.FromStrings(MetricLabelName, "up", "cluster", "us-1"),
+		labels.FromStrings(MetricLabelName, "up", "cluster", "eu-1"),
+		labels.FromStrings(MetricLabelName, "http_requests_total", "cluster", "us-2"),
+	}
+	compactDir := t.TempDir()
+	blockID, err := e2eutil.CreateBlock(ctx, filepath.Join(compactDir, groupKey), series, 10, 0, 100, labels.EmptyLabels(), 0, metadata.NoneFunc, nil)
+	require.NoError(t, err)
+
+	cfg := Config{BlockStatsEnabled: true, BlockStatsLabels: []string{"cluster"}}
+	bkt := objstore.WithNoopInstr(objstore.NewInMemBucket())
+	failures := prometheus.NewCounter(prometheus.CounterOpts{})
+
+	callback := newBlockStatsCompactionLifecycleCallback(compact.DefaultCompactionLifecycleCallback{}, cfg, bkt, compactDir, failures)
+	require.NoError(t, callback.PostCompactionCallback(ctx, logger, group, blockID))
+
+	stats, err := bucketindex.ReadBlockStats(ctx, bkt, blockID, logger)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bucketindex.LabelValuesRange{"cluster": {Min: "eu-1", Max: "us-2"}}, stats.Labels)
+	assert.True(t, stats.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, MetricLabelName, "up")}))
+	assert.True(t, stats.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, MetricLabelName, "http_requests_total")}))
+	assert.False(t, stats.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "cluster", "ap-1")}))
+	assert.Equal(t, float64(0), prom_testutil.ToFloat64(failures))
+
+	// Failing to compute the stats doesn't fail the compaction.
+	require.NoError(t, callback.PostCompactionCallback(ctx, logger, group, ulid.MustNew(1, nil)))
+	assert.Equal(t, float64(1), prom_testutil.ToFloat64(failures))
+}
+
+func TestBlockStatsCompactionLifecycleCallback_ShouldNotWrapWhenDisabled(t *testing.T) {
+	callback := compact.DefaultCompactionLifecycleCallback{}
+	assert.Equal(t, callback, newBlockStatsCompactionLifecycleCallback(callback, Config{}, nil, "", nil))
+}
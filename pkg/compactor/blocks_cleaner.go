@@ -3,6 +3,7 @@ package compactor
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
 	"sync"
 	"time"
@@ -30,12 +31,19 @@ import (
 
 const (
 	defaultDeleteBlocksConcurrency               = 16
+	defaultMoveBlocksToColdStorageConcurrency    = 4
 	defaultDeletePartitionedGroupInfoConcurrency = 5
 	reasonValueRetention                         = "retention"
+	reasonValueRetentionRules                    = "retention-rules"
 	activeStatus                                 = "active"
 	deletedStatus                                = "deleted"
 )
 
+// coldStorageHotCopiesDeletionDelay is how long the bucket index recording a block as cold must have
+// been written before deleting the block from the hot storage. The components reading the blocks
+// reload the bucket index, when missing an object, at most every bucket.ColdBlocksRefreshInterval.
+const coldStorageHotCopiesDeletionDelay = 2 * bucket.ColdBlocksRefreshInterval
+
 type BlocksCleanerConfig struct {
 	DeletionDelay                      time.Duration
 	CleanupInterval                    time.Duration
@@ -45,6 +53,10 @@ type BlocksCleanerConfig struct {
 	ShardingStrategy                   string
 	CompactionStrategy                 string
 	BlockRanges                        []int64
+	BlockStatsEnabled                  bool
+	ColdStorage                        *bucket.TieredBucketClient // Nil if the cold storage is disabled.
+	ColdStorageMinBlockAge             time.Duration
+	RetentionRulesDir                  string // Directory used to rewrite the blocks containing series expired by the retention rules.
 }
 
 type BlocksCleaner struct {
@@ -86,6 +98,8 @@ type BlocksCleaner struct {
 	inProgressCompactions             *prometheus.GaugeVec
 	oldestPartitionGroupOffset        *prometheus.GaugeVec
 	enqueueJobFailed                  *prometheus.CounterVec
+	blocksMovedToColdStorage          prometheus.Counter
+	blocksMoveToColdStorageFailed     prometheus.Counter
 }
 
 func NewBlocksCleaner(
@@ -116,6 +130,19 @@ func NewBlocksCleaner(
 		}, commonLabels)
 	}
 
+	var blocksMovedToColdStorage prometheus.Counter
+	var blocksMoveToColdStorageFailed prometheus.Counter
+	if cfg.ColdStorage != nil {
+		blocksMovedToColdStorage = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_moved_to_cold_storage_total",
+			Help: "Total number of blocks moved to the cold storage. Only available if the cold storage is enabled.",
+		})
+		blocksMoveToColdStorageFailed = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_move_to_cold_storage_failures_total",
+			Help: "Total number of blocks failed to be moved to the cold storage. Only available if the cold storage is enabled.",
+		})
+	}
+
 	c := &BlocksCleaner{
 		cfg:                                  cfg,
 		bucketClient:                         bucketClient,
@@ -191,9 +218,11 @@ func NewBlocksCleaner(
 			Name: "cortex_bucket_clean_duration_seconds",
 			Help: "Duration of cleaner runtime for a tenant in seconds",
 		}, commonLabels),
-		remainingPlannedCompactions: remainingPlannedCompactions,
-		inProgressCompactions:       inProgressCompactions,
-		oldestPartitionGroupOffset:  oldestPartitionGroupOffset,
+		remainingPlannedCompactions:   remainingPlannedCompactions,
+		inProgressCompactions:         inProgressCompactions,
+		oldestPartitionGroupOffset:    oldestPartitionGroupOffset,
+		blocksMovedToColdStorage:      blocksMovedToColdStorage,
+		blocksMoveToColdStorageFailed: blocksMoveToColdStorageFailed,
 		enqueueJobFailed: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_compactor_enqueue_cleaner_job_failed_total",
 			Help: "Total number of cleaner jobs failed to be enqueued.",
@@ -604,6 +633,10 @@ func (c *BlocksCleaner) deleteNonDataFiles(ctx context.Context, userLogger log.L
 		level.Info(userLogger).Log("msg", "deleted files under "+block.DebugMetas+" for tenant marked for deletion", "count", deleted, "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
 	}
 
+	if err := userBucket.Delete(ctx, blockScrubberStatusFile); err != nil && !userBucket.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "failed to delete "+blockScrubberStatusFile)
+	}
+
 	if c.cfg.CompactionStrategy == util.CompactionStrategyPartitioning {
 		begin = time.Now()
 		// Clean up partitioned group info files
@@ -705,6 +738,10 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 		// error occurs here. Errors are logged in the function.
 		retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
 		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger, userID)
+
+		begin := time.Now()
+		c.applyUserRetentionRules(ctx, idx, userBucket, userLogger, userID)
+		level.Info(userLogger).Log("msg", "finish applying retention rules", "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
 	}
 
 	// Generate an updated in-memory version of the bucket index.
@@ -715,6 +752,25 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 	if parquetEnabled {
 		w.EnableParquet()
 	}
+	if c.cfg.BlockStatsEnabled {
+		w.EnableBlockStats()
+	}
+	if c.cfg.ColdStorage != nil {
+		w.EnableColdStorage(c.cfg.ColdStorage)
+	}
+
+	// The blocks recorded as cold in the bucket index written by a previous cleanup can be deleted
+	// from the hot storage, once that bucket index has been propagated.
+	var coldBlocksPublishedAt time.Time
+	coldBlocksPublished := map[ulid.ULID]struct{}{}
+	if idx != nil && c.cfg.ColdStorage != nil {
+		coldBlocksPublishedAt = idx.GetUpdatedAt()
+		for _, b := range idx.Blocks {
+			if b.StorageTier == bucketindex.StorageTierCold {
+				coldBlocksPublished[b.ID] = struct{}{}
+			}
+		}
+	}
 
 	idx, partials, totalBlocksBlocksMarkedForNoCompaction, err := w.UpdateIndex(ctx, idx)
 	if err != nil {
@@ -759,6 +815,13 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userLogger log.Logger, us
 	})
 	level.Info(userLogger).Log("msg", "finish deleting blocks", "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
 
+	// Move the old blocks to the cold storage. Their location is recorded in the bucket index.
+	if c.cfg.ColdStorage != nil {
+		begin = time.Now()
+		c.moveBlocksToColdStorage(ctx, idx, coldBlocksPublished, coldBlocksPublishedAt, userLogger, userID)
+		level.Info(userLogger).Log("msg", "finish moving blocks to cold storage", "duration", time.Since(begin), "duration_ms", time.Since(begin).Milliseconds())
+	}
+
 	// Partial blocks with a deletion mark can be cleaned up. This is a best effort, so we don't return
 	// error if the cleanup of partial blocks fail.
 	if len(partials) > 0 {
@@ -1021,6 +1084,68 @@ func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucke
 	}
 }
 
+// moveBlocksToColdStorage moves the blocks older than the cold storage min block age to the
+// cold storage in two steps. The blocks are first copied to the cold storage, and their storage
+// tier is recorded in the bucket index. Their copies in the hot storage are deleted in a later
+// cleanup, once the bucket index recording them as cold has been written for long enough to be
+// known by all the components reading them. It is not critical if a step fails, as the cleaner
+// retries it in its next cycle.
+func (c *BlocksCleaner) moveBlocksToColdStorage(ctx context.Context, idx *bucketindex.Index, coldBlocksPublished map[ulid.ULID]struct{}, coldBlocksPublishedAt time.Time, userLogger log.Logger, userID string) {
+	threshold := time.Now().Add(-c.cfg.ColdStorageMinBlockAge).UnixMilli()
+	hotCopiesDeletable := !coldBlocksPublishedAt.IsZero() && time.Since(coldBlocksPublishedAt) > coldStorageHotCopiesDeletionDelay
+
+	marked := make(map[ulid.ULID]struct{}, len(idx.BlockDeletionMarks))
+	for _, d := range idx.BlockDeletionMarks {
+		marked[d.ID] = struct{}{}
+	}
+
+	// There is no need to move the blocks which are going to be deleted.
+	jobs := make([]any, 0)
+	for _, b := range idx.Blocks {
+		if _, isMarked := marked[b.ID]; isMarked {
+			continue
+		}
+
+		if b.StorageTier == bucketindex.StorageTierCold {
+			if _, published := coldBlocksPublished[b.ID]; !published || !hotCopiesDeletable || b.HotCopiesDeleted {
+				continue
+			}
+		} else if b.MaxTime >= threshold {
+			continue
+		}
+		jobs = append(jobs, b)
+	}
+
+	_ = concurrency.ForEach(ctx, jobs, defaultMoveBlocksToColdStorageConcurrency, func(ctx context.Context, job any) error {
+		b := job.(*bucketindex.Block)
+		dir := path.Join(userID, b.ID.String())
+
+		// Each job updates a different block of the index.
+		if b.StorageTier != bucketindex.StorageTierCold {
+			if err := c.cfg.ColdStorage.CopyToCold(ctx, dir); err != nil {
+				c.blocksMoveToColdStorageFailed.Inc()
+				level.Warn(userLogger).Log("msg", "failed to copy block to cold storage", "block", b.ID, "err", err)
+				return nil
+			}
+
+			b.StorageTier = bucketindex.StorageTierCold
+			level.Info(userLogger).Log("msg", "copied block to cold storage", "block", b.ID, "maxTime", b.MaxTime)
+			return nil
+		}
+
+		if err := c.cfg.ColdStorage.DeleteHotCopies(ctx, dir); err != nil {
+			c.blocksMoveToColdStorageFailed.Inc()
+			level.Warn(userLogger).Log("msg", "failed to delete block copied to cold storage from hot storage", "block", b.ID, "err", err)
+			return nil
+		}
+
+		b.HotCopiesDeleted = true
+		c.blocksMovedToColdStorage.Inc()
+		level.Info(userLogger).Log("msg", "moved block to cold storage", "block", b.ID, "maxTime", b.MaxTime)
+		return nil
+	})
+}
+
 // listBlocksOutsideRetentionPeriod determines the blocks which have aged past
 // the specified retention period, and are not already marked for deletion.
 func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Time) (result bucketindex.Blocks) {
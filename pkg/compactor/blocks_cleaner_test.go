@@ -29,6 +29,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/services"
 	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
 	"github.com/cortexproject/cortex/pkg/util/users"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 type testBlocksCleanerOptions struct {
@@ -600,6 +601,113 @@ func TestBlocksCleaner_ShouldRemoveMetricsForTenantsNotBelongingAnymoreToTheShar
 	))
 }
 
+func TestBlocksCleaner_ShouldMoveOldBlocksToColdStorage(t *testing.T) {
+	const userID = "user-1"
+
+	hotClient, hotDir := cortex_testutil.PrepareFilesystemBucket(t)
+	coldClient, coldDir := cortex_testutil.PrepareFilesystemBucket(t)
+	tieredClient := bucket.NewTieredBucketClient(hotClient, coldClient)
+	bucketClient := bucketindex.BucketWithGlobalMarkers(tieredClient)
+
+	// Create an old block and a recent one.
+	now := time.Now()
+	oldBlock := createTSDBBlock(t, bucketClient, userID, 10, 20, nil)
+	newBlock := createTSDBBlock(t, bucketClient, userID, now.Add(-time.Hour).UnixMilli(), now.UnixMilli(), nil)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:          time.Hour,
+		CleanupInterval:        time.Minute,
+		CleanupConcurrency:     1,
+		BlockRanges:            (&tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}).ToMilliseconds(),
+		ColdStorage:            tieredClient,
+		ColdStorageMinBlockAge: 24 * time.Hour,
+	}
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	reg := prometheus.NewPedanticRegistry()
+	scanner, err := users.NewScanner(users.UsersScannerConfig{
+		Strategy: users.UserScanStrategyList,
+	}, bucketClient, logger, reg)
+	require.NoError(t, err)
+	cfgProvider := newMockConfigProvider()
+	blocksMarkedForDeletion := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: blocksMarkedForDeletionName,
+		Help: blocksMarkedForDeletionHelp,
+	}, append(commonLabels, reasonLabelName))
+	dummyGaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"test"})
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, scanner, 60*time.Second, cfgProvider, logger, "test-cleaner", reg, time.Minute, 30*time.Second, blocksMarkedForDeletion, dummyGaugeVec)
+
+	// The old block is first copied to the cold storage, and recorded as cold in the bucket index,
+	// while the hot copies are kept until the bucket index has been propagated.
+	for range 2 {
+		require.NoError(t, cleaner.cleanUpActiveUsers(ctx, []string{userID}, true))
+	}
+
+	assert.FileExists(t, path.Join(hotDir, userID, oldBlock.String(), block.MetaFilename))
+	assert.FileExists(t, path.Join(coldDir, userID, oldBlock.String(), block.MetaFilename))
+
+	idx, err := bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		if b.ID == oldBlock {
+			assert.Equal(t, bucketindex.StorageTierCold, b.StorageTier)
+			assert.False(t, b.HotCopiesDeleted)
+		} else {
+			assert.Empty(t, b.StorageTier)
+		}
+	}
+
+	// The hot copies are deleted once the bucket index recording the block as cold is old enough.
+	idx.UpdatedAt = time.Now().Add(-2 * coldStorageHotCopiesDeletionDelay).Unix()
+	require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, userID, nil, idx))
+	require.NoError(t, cleaner.cleanUpActiveUsers(ctx, []string{userID}, true))
+
+	// The old block has been moved to the cold storage, while the recent one is still in the hot storage.
+	assert.NoFileExists(t, path.Join(hotDir, userID, oldBlock.String(), block.MetaFilename))
+	assert.FileExists(t, path.Join(coldDir, userID, oldBlock.String(), block.MetaFilename))
+	assert.FileExists(t, path.Join(hotDir, userID, newBlock.String(), block.MetaFilename))
+	assert.NoFileExists(t, path.Join(coldDir, userID, newBlock.String(), block.MetaFilename))
+
+	// The storage tier of the blocks is recorded in the bucket index, and is recovered if the
+	// bucket index is generated from scratch.
+	for _, deleteIndex := range []bool{false, true} {
+		if deleteIndex {
+			require.NoError(t, bucketindex.DeleteIndex(ctx, bucketClient, userID, nil))
+			require.NoError(t, cleaner.cleanUpActiveUsers(ctx, []string{userID}, true))
+		}
+
+		idx, err = bucketindex.ReadIndex(ctx, bucketClient, userID, nil, logger)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []ulid.ULID{oldBlock, newBlock}, idx.Blocks.GetULIDs())
+		for _, b := range idx.Blocks {
+			if b.ID == oldBlock {
+				assert.Equal(t, bucketindex.StorageTierCold, b.StorageTier)
+				assert.True(t, b.HotCopiesDeleted)
+			} else {
+				assert.Empty(t, b.StorageTier)
+			}
+		}
+	}
+
+	// The moved block is still readable through the bucket client.
+	_, err = block.DownloadMeta(ctx, logger, bucket.NewUserBucketClient(userID, bucketClient, nil), oldBlock)
+	require.NoError(t, err)
+
+	assert.NoError(t, prom_testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_blocks_moved_to_cold_storage_total Total number of blocks moved to the cold storage. Only available if the cold storage is enabled.
+		# TYPE cortex_compactor_blocks_moved_to_cold_storage_total counter
+		cortex_compactor_blocks_moved_to_cold_storage_total 1
+		# HELP cortex_compactor_blocks_move_to_cold_storage_failures_total Total number of blocks failed to be moved to the cold storage. Only available if the cold storage is enabled.
+		# TYPE cortex_compactor_blocks_move_to_cold_storage_failures_total counter
+		cortex_compactor_blocks_move_to_cold_storage_failures_total 0
+	`),
+		"cortex_compactor_blocks_moved_to_cold_storage_total",
+		"cortex_compactor_blocks_move_to_cold_storage_failures_total",
+	))
+}
+
 func TestBlocksCleaner_ShouldCleanupBucketIndexMetricOnOwnershipChange(t *testing.T) {
 	bucketClient, _ := cortex_testutil.PrepareFilesystemBucket(t)
 	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
@@ -1355,6 +1463,7 @@ func TestBlocksCleaner_EmitUserMetrics(t *testing.T) {
 
 type mockConfigProvider struct {
 	userRetentionPeriods    map[string]time.Duration
+	userRetentionRules      map[string]validation.RetentionRulesConfig
 	parquetConverterEnabled map[string]bool
 }
 
@@ -1368,6 +1477,7 @@ func (m *mockConfigProvider) ParquetConverterEnabled(userID string) bool {
 func newMockConfigProvider() *mockConfigProvider {
 	return &mockConfigProvider{
 		userRetentionPeriods:    make(map[string]time.Duration),
+		userRetentionRules:      make(map[string]validation.RetentionRulesConfig),
 		parquetConverterEnabled: make(map[string]bool),
 	}
 }
@@ -1379,6 +1489,10 @@ func (m *mockConfigProvider) CompactorBlocksRetentionPeriod(user string) time.Du
 	return 0
 }
 
+func (m *mockConfigProvider) RetentionRules(userID string) validation.RetentionRulesConfig {
+	return m.userRetentionRules[userID]
+}
+
 func (m *mockConfigProvider) S3SSEType(user string) string {
 	return ""
 }
@@ -250,6 +250,7 @@ type Limits interface {
 	CompactorTenantShardSize(userID string) float64
 	CompactorPartitionIndexSizeBytes(userID string) int64
 	CompactorPartitionSeriesCount(userID string) int64
+	RetentionRules(userID string) validation.RetentionRulesConfig
 }
 
 // Config holds the Compactor config.
@@ -306,6 +307,13 @@ type Config struct {
 	AcceptMalformedIndex        bool `yaml:"accept_malformed_index"`
 	CachingBucketEnabled        bool `yaml:"caching_bucket_enabled"`
 	CleanerCachingBucketEnabled bool `yaml:"cleaner_caching_bucket_enabled"`
+
+	// Label statistics of the compacted blocks.
+	BlockStatsEnabled bool                   `yaml:"block_stats_enabled"`
+	BlockStatsLabels  flagext.StringSliceCSV `yaml:"block_stats_labels"`
+
+	// Background verification of the blocks integrity.
+	BlockScrubber BlockScrubberConfig `yaml:"block_scrubber"`
 }
 
 // RegisterFlags registers the Compactor flags.
@@ -351,6 +359,11 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.CachingBucketEnabled, "compactor.caching-bucket-enabled", false, "When enabled, caching bucket will be used for compactor, except cleaner service, which serves as the source of truth for block status")
 	f.BoolVar(&cfg.CleanerCachingBucketEnabled, "compactor.cleaner-caching-bucket-enabled", false, "When enabled, caching bucket will be used for cleaner")
 
+	f.BoolVar(&cfg.BlockStatsEnabled, "compactor.block-stats-enabled", false, "EXPERIMENTAL: When enabled, the compactor computes label statistics of the compacted blocks, a bloom filter of the metric names and the range of values of the labels configured via -compactor.block-stats-labels, and records them in the bucket index. Queriers using the bucket index skip the blocks which can't match the equality matchers of a query. The statistics add up to about 2.7KB per block to the bucket index.")
+	f.Var(&cfg.BlockStatsLabels, "compactor.block-stats-labels", "EXPERIMENTAL: Comma separated list of label names whose range of values is recorded in the block stats. Labels the series are sorted by or which are often used in equality matchers, like the cluster or namespace, are good candidates.")
+
+	cfg.BlockScrubber.RegisterFlagsWithPrefix("compactor.block-scrubber.", f)
+
 	f.DurationVar(&cfg.ShardingPlannerDelay, "compactor.sharding-planner-delay", 10*time.Second, "How long shuffle sharding planner would wait before running planning code. This delay would prevent double compaction when two compactors claimed same partition in grouper at same time.")
 }
 
@@ -385,6 +398,10 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errInvalidCompactionStrategyPartitioning
 	}
 
+	if err := cfg.BlockScrubber.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -393,6 +410,7 @@ type ConfigProvider interface {
 	bucket.TenantConfigProvider
 	ParquetConverterEnabled(userID string) bool
 	CompactorBlocksRetentionPeriod(user string) time.Duration
+	RetentionRules(userID string) validation.RetentionRulesConfig
 }
 
 // Compactor is a multi-tenant TSDB blocks compactor based on Thanos.
@@ -421,6 +439,9 @@ type Compactor struct {
 	// Blocks cleaner is responsible to hard delete blocks marked for deletion.
 	blocksCleaner *BlocksCleaner
 
+	// Nil if the block scrubber is disabled.
+	blockScrubber *BlockScrubber
+
 	// Underlying compactor used to compact TSDB blocks.
 	blocksCompactor compact.Compactor
 
@@ -454,6 +475,7 @@ type Compactor struct {
 	BlocksMarkedForNoCompaction    prometheus.Counter
 	blockVisitMarkerReadFailed     prometheus.Counter
 	blockVisitMarkerWriteFailed    prometheus.Counter
+	blockStatsFailures             prometheus.Counter
 
 	// Thanos compactor metrics per user
 	compactorMetrics *compactorMetrics
@@ -465,7 +487,7 @@ type Compactor struct {
 // NewCompactor makes a new Compactor.
 func NewCompactor(compactorCfg Config, storageCfg cortex_tsdb.BlocksStorageConfig, logger log.Logger, registerer prometheus.Registerer, limits *validation.Overrides, ingestionReplicationFactor int) (*Compactor, error) {
 	bucketClientFactory := func(ctx context.Context) (objstore.InstrumentedBucket, error) {
-		return bucket.NewClient(ctx, storageCfg.Bucket, nil, "compactor", logger, registerer)
+		return cortex_tsdb.NewBucketClient(ctx, storageCfg, nil, "compactor", logger, registerer)
 	}
 
 	blocksGrouperFactory := compactorCfg.BlocksGrouperFactory
@@ -600,6 +622,10 @@ func newCompactor(
 			Name: "cortex_compactor_block_visit_marker_write_failed",
 			Help: "Number of block visit marker file failed to be written.",
 		}),
+		blockStatsFailures: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_block_stats_failures_total",
+			Help: "Total number of compacted blocks whose stats failed to be computed or uploaded.",
+		}),
 		limits:                     limits,
 		compactorMetrics:           compactorMetrics,
 		ingestionReplicationFactor: ingestionReplicationFactor,
@@ -652,6 +678,9 @@ func (c *Compactor) starting(ctx context.Context) error {
 		return errors.Wrap(err, "failed to initialize compactor dependencies")
 	}
 
+	// Keep the tiered bucket client, if the cold storage is enabled, to move the old blocks to it.
+	coldStorage, _ := c.bucketClient.(*bucket.TieredBucketClient)
+
 	// Wrap the bucket client to write block deletion marks in the global location too.
 	c.bucketClient = bucketindex.BucketWithGlobalMarkers(c.bucketClient)
 
@@ -753,9 +782,19 @@ func (c *Compactor) starting(ctx context.Context) error {
 		ShardingStrategy:                   c.compactorCfg.ShardingStrategy,
 		CompactionStrategy:                 c.compactorCfg.CompactionStrategy,
 		BlockRanges:                        c.compactorCfg.BlockRanges.ToMilliseconds(),
+		BlockStatsEnabled:                  c.compactorCfg.BlockStatsEnabled,
+		ColdStorage:                        coldStorage,
+		ColdStorageMinBlockAge:             c.storageCfg.ColdStorage.MinBlockAge,
+		RetentionRulesDir:                  filepath.Join(c.compactorCfg.DataDir, "retention-rules"),
 	}, cleanerBucketClient, cleanerUsersScanner, c.compactorCfg.CompactionVisitMarkerTimeout, c.limits, c.parentLogger, cleanerRingLifecyclerID, c.registerer, c.compactorCfg.CleanerVisitMarkerTimeout, c.compactorCfg.CleanerVisitMarkerFileUpdateInterval,
 		c.compactorMetrics.syncerBlocksMarkedForDeletion, c.compactorMetrics.remainingPlannedCompactions)
 
+	// The block scrubber verifies each tenant's blocks from a single compactor, like the cleaner.
+	if c.compactorCfg.BlockScrubber.Enabled {
+		c.blockScrubber = NewBlockScrubber(c.compactorCfg.BlockScrubber, c.bucketClient, cleanerUsersScanner, c.limits, filepath.Join(c.compactorCfg.DataDir, "scrub"), c.logger, c.registerer,
+			c.compactorMetrics.syncerBlocksMarkedForDeletion, c.BlocksMarkedForNoCompaction)
+	}
+
 	// If sharding is disabled, there is no need to have every compactor to run the user index updater
 	// as it will be the same to fallback to list strategy.
 	if c.compactorCfg.ShardingEnabled && c.storageCfg.UsersScanner.Strategy == users.UserScanStrategyUserIndex {
@@ -778,6 +817,9 @@ func (c *Compactor) stopping(_ error) error {
 	ctx := context.Background()
 
 	services.StopAndAwaitTerminated(ctx, c.blocksCleaner) //nolint:errcheck
+	if c.blockScrubber != nil {
+		services.StopAndAwaitTerminated(ctx, c.blockScrubber) //nolint:errcheck
+	}
 	if c.ringSubservices != nil {
 		return services.StopManagerAndAwaitStopped(ctx, c.ringSubservices)
 	}
@@ -791,6 +833,13 @@ func (c *Compactor) running(ctx context.Context) error {
 		return errors.Wrap(err, "failed to start the blocks cleaner")
 	}
 
+	if c.blockScrubber != nil {
+		if err := services.StartAndAwaitRunning(ctx, c.blockScrubber); err != nil {
+			c.ringSubservices.StopAsync()
+			return errors.Wrap(err, "failed to start the block scrubber")
+		}
+	}
+
 	if c.userIndexUpdater != nil {
 		go c.userIndexUpdateLoop(ctx)
 	}
@@ -1005,12 +1054,7 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 
 	// Filters out duplicate blocks that can be formed from two or more overlapping
 	// blocks that fully submatches the source blocks of the older blocks.
-	var deduplicateBlocksFilter CortexMetadataFilter
-	if c.compactorCfg.ShardingStrategy == util.ShardingStrategyShuffle && c.compactorCfg.CompactionStrategy == util.CompactionStrategyPartitioning {
-		deduplicateBlocksFilter = &disabledDeduplicateFilter{}
-	} else {
-		deduplicateBlocksFilter = block.NewDeduplicateFilter(c.compactorCfg.BlockSyncConcurrency)
-	}
+	deduplicateBlocksFilter := c.newDeduplicateBlocksFilter()
 
 	// While fetching blocks, we filter out blocks that were marked for deletion by using IgnoreDeletionMarkFilter.
 	// No delay is used -- all blocks with deletion marker are ignored, and not considered for compaction.
@@ -1024,20 +1068,10 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 	// out of order chunks or index file too big.
 	noCompactMarkerFilter := compact.NewGatherNoCompactionMarkFilter(ulogger, bucket, c.compactorCfg.MetaSyncConcurrency)
 
-	var blockLister block.Lister
 	blockDiscoveryStrategy := cortex_tsdb.BlockDiscoveryStrategy(c.storageCfg.BucketStore.BlockDiscoveryStrategy)
-	switch blockDiscoveryStrategy {
-	case cortex_tsdb.ConcurrentDiscovery:
-		blockLister = block.NewConcurrentLister(ulogger, bucket)
-	case cortex_tsdb.RecursiveDiscovery:
-		blockLister = block.NewRecursiveLister(ulogger, bucket)
-	case cortex_tsdb.BucketIndexDiscovery:
-		if !c.storageCfg.BucketStore.BucketIndex.Enabled {
-			return cortex_tsdb.ErrInvalidBucketIndexBlockDiscoveryStrategy
-		}
-		blockLister = bucketindex.NewBlockLister(ulogger, c.bucketClient, userID, c.limits)
-	default:
-		return cortex_tsdb.ErrBlockDiscoveryStrategy
+	blockLister, err := c.newBlockListerForUser(ulogger, bucket, userID)
+	if err != nil {
+		return err
 	}
 
 	// List of filters to apply (order matters).
@@ -1097,7 +1131,10 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 		c.blocksPlannerFactory(currentCtx, bucket, ulogger, c.compactorCfg, noCompactMarkerFilter, c.ringLifecycler, userID, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.compactorMetrics, ignoreDeletionMarkFilter),
 		c.blocksCompactor,
 		c.blockDeletableCheckerFactory(currentCtx, bucket, ulogger),
-		c.compactionLifecycleCallbackFactory(currentCtx, bucket, ulogger, c.compactorCfg.MetaSyncConcurrency, c.compactDirForUser(userID), userID, c.compactorMetrics),
+		newBlockStatsCompactionLifecycleCallback(
+			newRetentionRulesCompactionLifecycleCallback(c.compactionLifecycleCallbackFactory(currentCtx, bucket, ulogger, c.compactorCfg.MetaSyncConcurrency, c.compactDirForUser(userID), userID, c.compactorMetrics), c.limits.RetentionRules(userID)),
+			c.compactorCfg, bucket, c.compactDirForUser(userID), c.blockStatsFailures,
+		),
 		c.compactDirForUser(userID),
 		bucket,
 		c.compactorCfg.CompactionConcurrency,
@@ -1123,6 +1160,32 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 	return nil
 }
 
+// newDeduplicateBlocksFilter returns the filter removing the blocks fully covered by the sources of other blocks.
+func (c *Compactor) newDeduplicateBlocksFilter() CortexMetadataFilter {
+	if c.compactorCfg.ShardingStrategy == util.ShardingStrategyShuffle && c.compactorCfg.CompactionStrategy == util.CompactionStrategyPartitioning {
+		return &disabledDeduplicateFilter{}
+	}
+	return block.NewDeduplicateFilter(c.compactorCfg.BlockSyncConcurrency)
+}
+
+// newBlockListerForUser returns the lister used to discover the blocks of a user, based on the configured
+// block discovery strategy.
+func (c *Compactor) newBlockListerForUser(logger log.Logger, bucket objstore.InstrumentedBucket, userID string) (block.Lister, error) {
+	switch cortex_tsdb.BlockDiscoveryStrategy(c.storageCfg.BucketStore.BlockDiscoveryStrategy) {
+	case cortex_tsdb.ConcurrentDiscovery:
+		return block.NewConcurrentLister(logger, bucket), nil
+	case cortex_tsdb.RecursiveDiscovery:
+		return block.NewRecursiveLister(logger, bucket), nil
+	case cortex_tsdb.BucketIndexDiscovery:
+		if !c.storageCfg.BucketStore.BucketIndex.Enabled {
+			return nil, cortex_tsdb.ErrInvalidBucketIndexBlockDiscoveryStrategy
+		}
+		return bucketindex.NewBlockLister(logger, c.bucketClient, userID, c.limits), nil
+	default:
+		return nil, cortex_tsdb.ErrBlockDiscoveryStrategy
+	}
+}
+
 func (c *Compactor) discoverUsersWithRetries(ctx context.Context) ([]string, error) {
 	var lastErr error
 
@@ -3,11 +3,16 @@ package compactor
 import (
 	"html/template"
 	"net/http"
+	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 
+	"github.com/cortexproject/cortex/pkg/util"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/users"
 )
 
 var (
@@ -51,3 +56,192 @@ func (c *Compactor) RingHandler(w http.ResponseWriter, req *http.Request) {
 
 	c.ring.ServeHTTP(w, req)
 }
+
+const scrubStatusTpl = `
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Cortex Compactor Block Scrubber</title>
+	</head>
+	<body>
+		<h1>Cortex Compactor Block Scrubber</h1>
+		<p>Current time: {{ .Now }}</p>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>Tenant</th>
+					<th>Last Scrub</th>
+					<th>Verified Blocks</th>
+					<th>Unverified Blocks</th>
+					<th>Previously Verified Blocks</th>
+					<th>Block</th>
+					<th>Affected Time Range</th>
+					<th>Action</th>
+					<th>Details</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .Tenants }}
+				{{ $tenant := . }}
+				{{ range .AffectedBlocks }}
+				<tr>
+					<td>{{ $tenant.Tenant }}</td>
+					<td>{{ $tenant.FinishedAt }}</td>
+					<td>{{ $tenant.VerifiedBlocks }}</td>
+					<td>{{ $tenant.UnverifiedBlocks }}</td>
+					<td>{{ $tenant.PreviouslyVerifiedBlocks }}</td>
+					<td>{{ .BlockID }}</td>
+					<td>{{ .MinTime }} - {{ .MaxTime }}</td>
+					<td>{{ .Action }}{{ if .RepairedBlockID }} ({{ .RepairedBlockID }}){{ end }}</td>
+					<td>{{ .Details }}</td>
+				</tr>
+				{{ else }}
+				<tr>
+					<td>{{ $tenant.Tenant }}</td>
+					<td>{{ $tenant.FinishedAt }}</td>
+					<td>{{ $tenant.VerifiedBlocks }}</td>
+					<td>{{ $tenant.UnverifiedBlocks }}</td>
+					<td>{{ $tenant.PreviouslyVerifiedBlocks }}</td>
+					<td colspan="4">No affected blocks</td>
+				</tr>
+				{{ end }}
+				{{ end }}
+			</tbody>
+		</table>
+	</body>
+</html>`
+
+var scrubStatusTmpl = template.Must(template.New("scrub-status").Parse(scrubStatusTpl))
+
+// ScrubStatusHandler serves the report of the last block scrubber run for each tenant owned
+// by the compactor, as an HTML page or as JSON if requested via the Accept header.
+func (c *Compactor) ScrubStatusHandler(w http.ResponseWriter, req *http.Request) {
+	if c.blockScrubber == nil {
+		writeMessage(w, "Compactor block scrubber is disabled.")
+		return
+	}
+
+	util.RenderHTTPResponse(w, struct {
+		Tenants []TenantScrubReport `json:"tenants"`
+		Now     time.Time           `json:"now"`
+	}{
+		Tenants: c.blockScrubber.Report(),
+		Now:     time.Now(),
+	}, scrubStatusTmpl, req)
+}
+
+const compactionPlanTpl = `
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Cortex Compactor Compaction Plan</title>
+	</head>
+	<body>
+		<h1>Cortex Compactor Compaction Plan</h1>
+		<p>Tenant: {{ .Tenant }}</p>
+		<p>Generated at: {{ .GeneratedAt }}</p>
+		<p>Grouper: {{ .Grouper }}</p>
+		<p>Tenant shard: {{ range .TenantShard }}{{ . }} {{ else }}no healthy compactor{{ end }}</p>
+		<p>Blocks: {{ .Blocks }}</p>
+		<h2>Planned Groups</h2>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>Group</th>
+					<th>Time Range</th>
+					<th>Partition</th>
+					<th>Blocks</th>
+					<th>Status</th>
+					<th>Rejected Blocks</th>
+					<th>Owner</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .Groups }}
+				{{ $group := . }}
+				{{ range .Partitions }}
+				<tr>
+					<td>{{ $group.PartitionedGroupID }}</td>
+					<td>{{ $group.RangeStart }} - {{ $group.RangeEnd }}</td>
+					<td>{{ .PartitionID }}</td>
+					<td>{{ range .Blocks }}{{ . }}<br>{{ end }}</td>
+					<td>{{ .Status }}{{ with .PlannerError }}: {{ . }}{{ end }}</td>
+					<td>{{ range .RejectedBlocks }}{{ .BlockID }}: {{ .Reason }}<br>{{ end }}</td>
+					<td>{{ with .VisitMarker }}{{ .CompactorID }} ({{ .Status }}, {{ .VisitTime }}{{ if .Expired }}, expired{{ end }}){{ end }}</td>
+				</tr>
+				{{ else }}
+				<tr>
+					<td>{{ .Key }}</td>
+					<td>{{ .RangeStart }} - {{ .RangeEnd }}</td>
+					<td></td>
+					<td>{{ range .Blocks }}{{ . }}<br>{{ end }}</td>
+					<td>{{ .Status }}{{ with .PlannerError }}: {{ . }}{{ end }}</td>
+					<td>{{ range .RejectedBlocks }}{{ .BlockID }}: {{ .Reason }}<br>{{ end }}</td>
+					<td>{{ range .VisitMarkers }}{{ .BlockID }}: {{ .CompactorID }} ({{ .VisitTime }}{{ if .Expired }}, expired{{ end }})<br>{{ end }}</td>
+				</tr>
+				{{ end }}
+				{{ end }}
+			</tbody>
+		</table>
+		<h2>Excluded Blocks</h2>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>Block</th>
+					<th>Time Range</th>
+					<th>Reason</th>
+					<th>Details</th>
+					<th>Marked At</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .ExcludedBlocks }}
+				<tr>
+					<td>{{ .BlockID }}</td>
+					<td>{{ .MinTime }} - {{ .MaxTime }}</td>
+					<td>{{ .Reason }}</td>
+					<td>{{ .Details }}</td>
+					<td>{{ with .NoCompactTime }}{{ . }}{{ else }}not marked{{ end }}</td>
+				</tr>
+				{{ end }}
+			</tbody>
+		</table>
+	</body>
+</html>`
+
+var compactionPlanTmpl = template.Must(template.New("compaction-plan").Parse(compactionPlanTpl))
+
+// PlanHandler runs the compaction planning of a tenant in dry-run mode, and serves the planned groups
+// and the blocks excluded from compaction as an HTML page or as JSON if requested via the Accept header.
+func (c *Compactor) PlanHandler(w http.ResponseWriter, req *http.Request) {
+	if !c.compactorCfg.ShardingEnabled {
+		writeMessage(w, "Compactor planning dry-run requires sharding to be enabled.")
+		return
+	}
+
+	if c.State() != services.Running {
+		writeMessage(w, "Compactor is not running yet.")
+		return
+	}
+
+	userID := mux.Vars(req)["tenant"]
+	if err := users.ValidTenantID(userID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plan, err := c.planUser(req.Context(), userID)
+	if errors.Is(err, errCompactionPlanUnsupported) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to plan compaction", "user", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.RenderHTTPResponse(w, plan, compactionPlanTmpl, req)
+}
@@ -0,0 +1,580 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	// PlannedGroupReady means the group (or partition) will be picked up by the next compaction
+	// run of a compactor owning the tenant.
+	PlannedGroupReady = "ready"
+	// PlannedGroupInProgress means the group (or partition) is currently owned by a compactor,
+	// according to its visit markers.
+	PlannedGroupInProgress = "in-progress"
+	// PlannedGroupCompleted means all the partitions of a partitioned group have been compacted.
+	PlannedGroupCompleted = "completed"
+	// PlannedGroupRejected means the planner would not compact the group (or partition), e.g. because
+	// some of its blocks are missing or outside of its time range.
+	PlannedGroupRejected = "rejected"
+)
+
+var errCompactionPlanUnsupported = errors.New("compaction planning dry-run is only supported by the shuffle_sharding_grouper and partition_compaction_grouper")
+
+// TenantCompactionPlan is the outcome of a dry-run of the compaction planning for a tenant.
+type TenantCompactionPlan struct {
+	Tenant      string    `json:"tenant"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Grouper is the name of the grouper which planned the compaction.
+	Grouper string `json:"grouper"`
+	// TenantShard contains the addresses of the healthy compactors owning the tenant.
+	TenantShard []string `json:"tenant_shard"`
+	// Blocks is the number of blocks of the tenant considered for compaction.
+	Blocks         int             `json:"blocks"`
+	Groups         []PlannedGroup  `json:"groups"`
+	ExcludedBlocks []ExcludedBlock `json:"excluded_blocks"`
+}
+
+// PlannedGroup is a group of blocks the grouper would compact together.
+type PlannedGroup struct {
+	// Key is the key of the compaction group. Only set by the shuffle_sharding_grouper.
+	Key string `json:"key,omitempty"`
+	// PartitionedGroupID and PartitionedGroupCreationTime are only set by the partition_compaction_grouper.
+	// The creation time is zero if the partitioned group doesn't exist yet in the bucket.
+	PartitionedGroupID           uint32    `json:"partitioned_group_id,omitempty"`
+	PartitionedGroupCreationTime int64     `json:"partitioned_group_creation_time,omitempty"`
+	RangeStart                   time.Time `json:"range_start"`
+	RangeEnd                     time.Time `json:"range_end"`
+	Blocks                       []string  `json:"blocks"`
+	Status                       string    `json:"status"`
+	// VisitMarkers contains the block visit markers of the group. Only set by the shuffle_sharding_grouper.
+	VisitMarkers []PlannedVisitMarker `json:"visit_markers,omitempty"`
+	// RejectedBlocks and PlannerError are only set by the shuffle_sharding_planner.
+	RejectedBlocks []RejectedBlock    `json:"rejected_blocks,omitempty"`
+	PlannerError   string             `json:"planner_error,omitempty"`
+	Partitions     []PlannedPartition `json:"partitions,omitempty"`
+}
+
+// PlannedPartition is a partition of a group planned by the partition_compaction_grouper.
+type PlannedPartition struct {
+	PartitionID int                 `json:"partition_id"`
+	Blocks      []string            `json:"blocks"`
+	Status      string              `json:"status"`
+	VisitMarker *PlannedVisitMarker `json:"visit_marker,omitempty"`
+	// RejectedBlocks and PlannerError are set by the partition_compaction_planner.
+	RejectedBlocks []RejectedBlock `json:"rejected_blocks,omitempty"`
+	PlannerError   string          `json:"planner_error,omitempty"`
+}
+
+// RejectedBlock is a block of a planned group (or partition) the planner would not compact.
+type RejectedBlock struct {
+	BlockID string `json:"block_id"`
+	Reason  string `json:"reason"`
+}
+
+// PlannedVisitMarker describes which compactor owns a block or a partition, according to its visit marker.
+type PlannedVisitMarker struct {
+	BlockID     string      `json:"block_id,omitempty"`
+	CompactorID string      `json:"compactor_id"`
+	Status      VisitStatus `json:"status,omitempty"`
+	VisitTime   time.Time   `json:"visit_time"`
+	Expired     bool        `json:"expired"`
+}
+
+// ExcludedBlock is a block excluded from compaction because it's marked for no compaction, or because
+// its index contains out of order chunks. Blocks with out of order chunks are listed with the
+// block-index-out-of-order-chunk reason even if they're not marked for no compaction yet, which is the
+// case when -compactor.skip-blocks-with-out-of-order-chunks-enabled is false and their compaction fails.
+type ExcludedBlock struct {
+	BlockID string `json:"block_id"`
+	// MinTime and MaxTime are the block time range in milliseconds.
+	MinTime int64  `json:"min_time"`
+	MaxTime int64  `json:"max_time"`
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+	// NoCompactTime is nil if the block is not marked for no compaction.
+	NoCompactTime *time.Time `json:"no_compact_time,omitempty"`
+}
+
+// planUser runs the configured grouper for the given user in dry-run mode, without uploading
+// visit markers nor partitioned groups to the bucket.
+func (c *Compactor) planUser(ctx context.Context, userID string) (*TenantCompactionPlan, error) {
+	bucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.limits)
+	ulogger := util_log.WithUserID(userID, c.logger)
+
+	blockLister, err := c.newBlockListerForUser(ulogger, bucket, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	noCompactMarkerFilter := compact.NewGatherNoCompactionMarkFilter(ulogger, bucket, c.compactorCfg.MetaSyncConcurrency)
+
+	// Use the same filters as the compaction, except that blocks marked for deletion are always
+	// filtered out since they won't be compacted anyway.
+	ignoreDeletionMarkFilter := block.NewIgnoreDeletionMarkFilter(ulogger, bucket, 0, c.compactorCfg.MetaSyncConcurrency)
+	fetcher, err := block.NewMetaFetcher(
+		ulogger,
+		c.compactorCfg.MetaSyncConcurrency,
+		bucket,
+		blockLister,
+		"", // No local cache, to not interfere with the compaction.
+		prometheus.NewRegistry(),
+		[]block.MetadataFilter{
+			NewLabelRemoverFilter([]string{cortex_tsdb.IngesterIDExternalLabel}),
+			block.NewConsistencyDelayMetaFilter(ulogger, c.compactorCfg.ConsistencyDelay, prometheus.NewRegistry()),
+			ignoreDeletionMarkFilter,
+			c.newDeduplicateBlocksFilter(),
+			noCompactMarkerFilter,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	metas, _, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch blocks metadata")
+	}
+
+	rs, err := c.ring.ShuffleShard(userID, c.getShardSizeForUser(userID)).GetAllHealthy(RingOp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get tenant shard")
+	}
+	tenantShard := rs.GetAddresses()
+	sort.Strings(tenantShard)
+
+	plan := &TenantCompactionPlan{
+		Tenant:         userID,
+		GeneratedAt:    time.Now(),
+		TenantShard:    tenantShard,
+		Blocks:         len(metas),
+		ExcludedBlocks: excludedBlocks(metas, noCompactMarkerFilter.NoCompactMarkedBlocks()),
+	}
+
+	// The grouper and the planner log each group they generate or check, which is not wanted for a dry-run.
+	// The planner is the one matching the grouper, and is only used to run its checks on the planned groups.
+	grouper := c.blocksGrouperFactory(ctx, c.compactorCfg, bucket, log.NewNopLogger(), c.BlocksMarkedForNoCompaction, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.compactorMetrics.getSyncerMetrics(userID), c.compactorMetrics, c.ring, c.ringLifecycler, c.limits, userID, noCompactMarkerFilter, c.ingestionReplicationFactor)
+
+	// The blocks which would be compacted next are checked for out of order chunks.
+	var toCheck []ulid.ULID
+	switch g := grouper.(type) {
+	case *ShuffleShardingGrouper:
+		p := NewShuffleShardingPlanner(ctx, bucket, log.NewNopLogger(), c.compactorCfg.BlockRanges.ToMilliseconds(), noCompactMarkerFilter.NoCompactMarkedBlocks, c.ringLifecycler.ID, c.compactorCfg.CompactionVisitMarkerTimeout, c.compactorCfg.CompactionVisitMarkerFileUpdateInterval, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed)
+		plan.Grouper = "shuffle_sharding_grouper"
+		if plan.Groups, err = g.planDryRun(metas, p); err != nil {
+			return nil, err
+		}
+		for _, group := range plan.Groups {
+			if group.Status == PlannedGroupReady {
+				toCheck = appendBlockIDs(toCheck, group.Blocks)
+			}
+		}
+	case *PartitionCompactionGrouper:
+		p := NewPartitionCompactionPlanner(ctx, bucket, log.NewNopLogger(), c.compactorCfg.BlockRanges.ToMilliseconds(), noCompactMarkerFilter.NoCompactMarkedBlocks, c.ringLifecycler.ID, userID, c.compactorCfg.ShardingPlannerDelay, c.compactorCfg.CompactionVisitMarkerTimeout, c.compactorCfg.CompactionVisitMarkerFileUpdateInterval, c.compactorMetrics, ignoreDeletionMarkFilter)
+		plan.Grouper = "partition_compaction_grouper"
+		if plan.Groups, err = g.planDryRun(metas, p); err != nil {
+			return nil, err
+		}
+		for _, group := range plan.Groups {
+			for _, partition := range group.Partitions {
+				if partition.Status == PlannedGroupReady {
+					toCheck = appendBlockIDs(toCheck, partition.Blocks)
+				}
+			}
+		}
+	default:
+		return nil, errCompactionPlanUnsupported
+	}
+
+	outOfOrder, err := c.outOfOrderChunksBlocks(ctx, bucket, ulogger, metas, toCheck, noCompactMarkerFilter.NoCompactMarkedBlocks())
+	if err != nil {
+		return nil, err
+	}
+	plan.ExcludedBlocks = append(plan.ExcludedBlocks, outOfOrder...)
+	sortExcludedBlocks(plan.ExcludedBlocks)
+
+	return plan, nil
+}
+
+func appendBlockIDs(ids []ulid.ULID, blocks []string) []ulid.ULID {
+	for _, b := range blocks {
+		if id, err := ulid.Parse(b); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// outOfOrderChunksBlocks downloads the index of the given blocks, and returns the ones whose index contains out of
+// order chunks, like the compaction does before compacting them. The blocks marked for no compaction are skipped.
+func (c *Compactor) outOfOrderChunksBlocks(ctx context.Context, bkt objstore.Bucket, logger log.Logger, metas map[ulid.ULID]*metadata.Meta, ids []ulid.ULID, noCompactMarked map[ulid.ULID]*metadata.NoCompactMark) ([]ExcludedBlock, error) {
+	jobs := make([]any, 0, len(ids))
+	checked := map[ulid.ULID]struct{}{}
+	for _, id := range ids {
+		if _, ok := checked[id]; ok {
+			continue
+		}
+		checked[id] = struct{}{}
+		if _, ok := noCompactMarked[id]; ok {
+			continue
+		}
+		if m, ok := metas[id]; ok {
+			jobs = append(jobs, m)
+		}
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(c.compactorCfg.DataDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(c.compactorCfg.DataDir, "plan-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	var (
+		mtx      sync.Mutex
+		excluded []ExcludedBlock
+	)
+	err = concurrency.ForEach(ctx, jobs, c.compactorCfg.MetaSyncConcurrency, func(ctx context.Context, job any) error {
+		m := job.(*metadata.Meta)
+		indexFile := filepath.Join(dir, m.ULID.String()+"-"+block.IndexFilename)
+		if err := objstore.DownloadFile(ctx, logger, bkt, path.Join(m.ULID.String(), block.IndexFilename), indexFile); err != nil {
+			return errors.Wrapf(err, "download index of block %s", m.ULID)
+		}
+		defer os.Remove(indexFile) //nolint:errcheck
+
+		stats, err := block.GatherIndexHealthStats(ctx, logger, indexFile, m.MinTime, m.MaxTime)
+		if err != nil {
+			return errors.Wrapf(err, "gather index issues of block %s", m.ULID)
+		}
+		if err := stats.OutOfOrderChunksErr(); err != nil {
+			mtx.Lock()
+			excluded = append(excluded, ExcludedBlock{
+				BlockID: m.ULID.String(),
+				MinTime: m.MinTime,
+				MaxTime: m.MaxTime,
+				Reason:  string(metadata.OutOfOrderChunksNoCompactReason),
+				Details: err.Error(),
+			})
+			mtx.Unlock()
+		}
+		return nil
+	})
+	return excluded, err
+}
+
+func excludedBlocks(metas map[ulid.ULID]*metadata.Meta, noCompactMarked map[ulid.ULID]*metadata.NoCompactMark) []ExcludedBlock {
+	excluded := make([]ExcludedBlock, 0, len(noCompactMarked))
+	for id, mark := range noCompactMarked {
+		noCompactTime := time.Unix(mark.NoCompactTime, 0)
+		b := ExcludedBlock{
+			BlockID:       id.String(),
+			Reason:        string(mark.Reason),
+			Details:       mark.Details,
+			NoCompactTime: &noCompactTime,
+		}
+		if m, ok := metas[id]; ok {
+			b.MinTime, b.MaxTime = m.MinTime, m.MaxTime
+		}
+		excluded = append(excluded, b)
+	}
+	return excluded
+}
+
+func sortExcludedBlocks(excluded []ExcludedBlock) {
+	sort.Slice(excluded, func(i, j int) bool {
+		if excluded[i].MinTime != excluded[j].MinTime {
+			return excluded[i].MinTime < excluded[j].MinTime
+		}
+		return excluded[i].BlockID < excluded[j].BlockID
+	})
+}
+
+// planDryRun returns all the groups the grouper would compact, regardless of the compactor
+// owning the tenant and of the compaction concurrency, along with the current owner of their blocks
+// and the blocks the planner would reject.
+func (g *ShuffleShardingGrouper) planDryRun(blocks map[ulid.ULID]*metadata.Meta, planner *ShuffleShardingPlanner) ([]PlannedGroup, error) {
+	var planned []PlannedGroup
+	for _, group := range g.groupBlocks(blocks) {
+		// Nothing to do if we don't have at least 2 blocks.
+		if len(group.blocks) < 2 {
+			continue
+		}
+
+		p := PlannedGroup{
+			Key:        createGroupKey(hashGroup(g.userID, group.rangeStart, group.rangeEnd), group),
+			RangeStart: group.rangeStartTime(),
+			RangeEnd:   group.rangeEndTime(),
+			Status:     PlannedGroupReady,
+		}
+		for _, m := range group.blocks {
+			blockID := m.ULID.String()
+			p.Blocks = append(p.Blocks, blockID)
+
+			visitMarker, err := ReadBlockVisitMarker(g.ctx, g.bkt, g.logger, blockID, g.blockVisitMarkerReadFailed)
+			if errors.Is(err, ErrorBlockVisitMarkerNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			visited := visitMarker.isVisited(g.blockVisitMarkerTimeout)
+			if visited {
+				p.Status = PlannedGroupInProgress
+			}
+			p.VisitMarkers = append(p.VisitMarkers, PlannedVisitMarker{
+				BlockID:     blockID,
+				CompactorID: visitMarker.CompactorID,
+				VisitTime:   time.Unix(visitMarker.VisitTime, 0),
+				Expired:     !visited,
+			})
+		}
+		planner.planDryRun(&p, group.blocks)
+		planned = append(planned, p)
+	}
+	return planned, nil
+}
+
+// planDryRun runs the checks of the planner on the blocks of the group, without claiming them. The blocks
+// visited by a compactor are rejected, while the ones without visit marker, or with an expired one, would
+// be claimed by the grouper. The visit markers are the ones already read by the grouper.
+func (p *ShuffleShardingPlanner) planDryRun(planned *PlannedGroup, blocks []*metadata.Meta) {
+	metasByMinTime := sortedByMinTime(blocks)
+	resultMetas, err := p.filterBlocks(metasByMinTime)
+	if err != nil {
+		planned.Status = PlannedGroupRejected
+		planned.PlannerError = err.Error()
+		return
+	}
+
+	for _, m := range planned.VisitMarkers {
+		if !m.Expired {
+			planned.RejectedBlocks = append(planned.RejectedBlocks, RejectedBlock{BlockID: m.BlockID, Reason: fmt.Sprintf("visited by compactor %s", m.CompactorID)})
+		}
+	}
+
+	if len(resultMetas) < 2 {
+		planned.Status = PlannedGroupRejected
+		planned.PlannerError = "less than 2 blocks to compact"
+	}
+}
+
+func sortedByMinTime(blocks []*metadata.Meta) []*metadata.Meta {
+	sorted := make([]*metadata.Meta, len(blocks))
+	copy(sorted, blocks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].MinTime < sorted[j].MinTime
+	})
+	return sorted
+}
+
+// planDryRun returns the partitioned groups the grouper would compact, including the ones which
+// don't exist yet and the completed ones, along with the status and current owner of their partitions
+// and the blocks the planner would reject.
+func (g *PartitionCompactionGrouper) planDryRun(allBlocks map[ulid.ULID]*metadata.Meta, planner *PartitionCompactionPlanner) ([]PlannedGroup, error) {
+	g.dryRun = true
+
+	// Filter out no compact blocks, without modifying the input.
+	blocks := maps.Clone(allBlocks)
+	for id := range g.noCompBlocksFunc() {
+		delete(blocks, id)
+	}
+
+	timeRanges := g.compactorCfg.BlockRanges.ToMilliseconds()
+	existingPartitionedGroups, err := g.loadExistingPartitionedGroups()
+	if err != nil {
+		return nil, err
+	}
+	partitionedGroups, err := g.generatePartitionedGroups(blocks, g.groupBlocks(blocks, timeRanges), existingPartitionedGroups, timeRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	// The existing partitioned groups left out are the completed ones.
+	completedGroups := maps.Clone(existingPartitionedGroups)
+	for _, partitionedGroup := range partitionedGroups {
+		delete(completedGroups, partitionedGroup.PartitionedGroupID)
+	}
+	for _, partitionedGroup := range completedGroups {
+		partitionedGroups = append(partitionedGroups, partitionedGroup)
+	}
+	g.sortPartitionedGroups(partitionedGroups)
+
+	planned := make([]PlannedGroup, 0, len(partitionedGroups))
+	for _, partitionedGroup := range partitionedGroups {
+		p := PlannedGroup{
+			PartitionedGroupID:           partitionedGroup.PartitionedGroupID,
+			PartitionedGroupCreationTime: partitionedGroup.CreationTime,
+			RangeStart:                   partitionedGroup.rangeStartTime(),
+			RangeEnd:                     partitionedGroup.rangeEndTime(),
+			Blocks:                       partitionedGroup.getAllBlockIDs(),
+		}
+
+		completed, rejected := 0, 0
+		_, isCompleted := completedGroups[partitionedGroup.PartitionedGroupID]
+		for _, partition := range partitionedGroup.Partitions {
+			pp, err := g.planPartitionDryRun(partitionedGroup, partition)
+			if err != nil {
+				return nil, err
+			}
+			if pp.Status == PlannedGroupReady && !isCompleted {
+				planner.planDryRun(&pp, partition, allBlocks)
+			}
+			switch pp.Status {
+			case PlannedGroupInProgress:
+				p.Status = PlannedGroupInProgress
+			case PlannedGroupCompleted:
+				completed++
+			case PlannedGroupRejected:
+				rejected++
+			}
+			p.Partitions = append(p.Partitions, pp)
+		}
+		if isCompleted {
+			p.Status = PlannedGroupCompleted
+		} else if p.Status == "" {
+			switch {
+			case completed == len(p.Partitions):
+				p.Status = PlannedGroupCompleted
+			case completed+rejected == len(p.Partitions):
+				p.Status = PlannedGroupRejected
+			default:
+				p.Status = PlannedGroupReady
+			}
+		}
+		planned = append(planned, p)
+	}
+	return planned, nil
+}
+
+func (g *PartitionCompactionGrouper) planPartitionDryRun(partitionedGroup *PartitionedGroupInfo, partition Partition) (PlannedPartition, error) {
+	p := PlannedPartition{
+		PartitionID: partition.PartitionID,
+		Blocks:      make([]string, 0, len(partition.Blocks)),
+		Status:      PlannedGroupReady,
+	}
+	for _, id := range partition.Blocks {
+		p.Blocks = append(p.Blocks, id.String())
+	}
+
+	// Empty partitions are marked as completed as soon as they are picked up.
+	if len(partition.Blocks) == 0 {
+		p.Status = PlannedGroupCompleted
+	}
+
+	// Visit markers can't exist for partitioned groups which haven't been created yet.
+	if partitionedGroup.CreationTime <= 0 {
+		return p, nil
+	}
+
+	visitMarker := &partitionVisitMarker{
+		PartitionedGroupID: partitionedGroup.PartitionedGroupID,
+		PartitionID:        partition.PartitionID,
+	}
+	visitMarkerManager := NewVisitMarkerManager(g.bkt, g.logger, g.ringLifecyclerID, visitMarker)
+	if err := visitMarkerManager.ReadVisitMarker(g.ctx, visitMarker); err != nil {
+		if errors.Is(err, errorVisitMarkerNotFound) {
+			return p, nil
+		}
+		return p, errors.Wrapf(err, "failed to read visit marker of partition %d of partitioned group %d", partition.PartitionID, partitionedGroup.PartitionedGroupID)
+	}
+
+	// Visit markers left over by a previous version of the partitioned group are ignored.
+	if visitMarker.VisitTime < partitionedGroup.CreationTime ||
+		(visitMarker.PartitionedGroupCreationTime > 0 && visitMarker.PartitionedGroupCreationTime < partitionedGroup.CreationTime) {
+		return p, nil
+	}
+
+	expired := visitMarker.IsExpired(g.partitionVisitMarkerTimeout)
+	p.VisitMarker = &PlannedVisitMarker{
+		CompactorID: visitMarker.CompactorID,
+		Status:      visitMarker.GetStatus(),
+		VisitTime:   time.Unix(visitMarker.VisitTime, 0),
+		Expired:     expired,
+	}
+
+	switch {
+	case visitMarker.GetStatus() == Completed:
+		p.Status = PlannedGroupCompleted
+	case (visitMarker.GetStatus() == Pending || visitMarker.GetStatus() == InProgress) && !expired:
+		p.Status = PlannedGroupInProgress
+	}
+	return p, nil
+}
+
+// planDryRun runs the checks of the planner on the blocks of the partition, without claiming it nor
+// waiting for the planner delay. The blocks of the partition are looked up among the given blocks,
+// like the grouper does, and partitions with missing blocks are never compacted.
+func (p *PartitionCompactionPlanner) planDryRun(planned *PlannedPartition, partition Partition, blocks map[ulid.ULID]*metadata.Meta) {
+	var deletionMarked map[ulid.ULID]*metadata.DeletionMark
+	if p.ignoreDeletionMarkFilter != nil {
+		deletionMarked = p.ignoreDeletionMarkFilter.DeletionMarkBlocks()
+	}
+
+	metasByMinTime := make([]*metadata.Meta, 0, len(partition.Blocks))
+	for _, id := range partition.Blocks {
+		if m, ok := blocks[id]; ok {
+			metasByMinTime = append(metasByMinTime, m)
+			continue
+		}
+		reason := "not found"
+		if _, ok := deletionMarked[id]; ok {
+			reason = "marked for deletion"
+		}
+		planned.RejectedBlocks = append(planned.RejectedBlocks, RejectedBlock{BlockID: id.String(), Reason: reason})
+	}
+	if len(planned.RejectedBlocks) > 0 {
+		planned.Status = PlannedGroupRejected
+		planned.PlannerError = fmt.Sprintf("partition contains %d missing blocks", len(planned.RejectedBlocks))
+		return
+	}
+	if len(metasByMinTime) == 0 {
+		planned.Status = PlannedGroupRejected
+		planned.PlannerError = "no blocks to compact"
+		return
+	}
+
+	noCompactMarked := p.noCompBlocksFunc()
+	for _, m := range metasByMinTime {
+		if mark, ok := noCompactMarked[m.ULID]; ok {
+			planned.RejectedBlocks = append(planned.RejectedBlocks, RejectedBlock{BlockID: m.ULID.String(), Reason: fmt.Sprintf("marked for no compaction: %s", mark.Reason)})
+		}
+	}
+
+	resultMetas, err := p.filterBlocks(sortedByMinTime(metasByMinTime))
+	if err != nil {
+		planned.Status = PlannedGroupRejected
+		planned.PlannerError = err.Error()
+		return
+	}
+	if len(resultMetas) < 1 {
+		planned.Status = PlannedGroupRejected
+		planned.PlannerError = "no blocks to compact"
+	}
+}
@@ -0,0 +1,298 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
+)
+
+func TestCompactor_PlanHandler(t *testing.T) {
+	const userID = "user-1"
+
+	tests := map[string]struct {
+		compactionStrategy string
+		expectedGrouper    string
+	}{
+		"shuffle_sharding_grouper": {
+			compactionStrategy: util.CompactionStrategyDefault,
+			expectedGrouper:    "shuffle_sharding_grouper",
+		},
+		"partition_compaction_grouper": {
+			compactionStrategy: util.CompactionStrategyPartitioning,
+			expectedGrouper:    "partition_compaction_grouper",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			bkt, bktDir := cortex_testutil.PrepareFilesystemBucket(t)
+			bkt = objstore.WithNoopInstr(bkt)
+
+			// Two groups of blocks, plus a block marked for no compaction.
+			block1 := createTSDBBlock(t, bkt, userID, 0, 2*time.Hour.Milliseconds(), nil)
+			block2 := createTSDBBlock(t, bkt, userID, 0, 2*time.Hour.Milliseconds(), nil)
+			block3 := createTSDBBlock(t, bkt, userID, 2*time.Hour.Milliseconds(), 4*time.Hour.Milliseconds(), nil)
+			block4 := createTSDBBlock(t, bkt, userID, 2*time.Hour.Milliseconds(), 4*time.Hour.Milliseconds(), nil)
+			block5 := createTSDBBlock(t, bkt, userID, 4*time.Hour.Milliseconds(), 6*time.Hour.Milliseconds(), nil)
+			createNoCompactionMark(t, bkt, userID, block5)
+
+			// The index of the first block contains out of order chunks, but the block is not marked for no compaction.
+			indexFile, err := os.ReadFile("testdata/out_of_order_chunks/index")
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(filepath.Join(bktDir, userID, block1.String(), block.IndexFilename), indexFile, 0o644))
+
+			// The second group is owned by another compactor.
+			partitionedGroupID := hashGroup(userID, 2*time.Hour.Milliseconds(), 4*time.Hour.Milliseconds())
+			if testData.compactionStrategy == util.CompactionStrategyPartitioning {
+				userBkt := objstore.WithNoopInstr(objstore.NewPrefixedBucket(bkt, userID))
+				partitionedGroup, err := UpdatePartitionedGroupInfo(ctx, userBkt, log.NewNopLogger(), PartitionedGroupInfo{
+					PartitionedGroupID: partitionedGroupID,
+					PartitionCount:     1,
+					Partitions:         []Partition{{PartitionID: 0, Blocks: []ulid.ULID{block3, block4}}},
+					RangeStart:         2 * time.Hour.Milliseconds(),
+					RangeEnd:           4 * time.Hour.Milliseconds(),
+					Version:            PartitionedGroupInfoVersion1,
+				})
+				require.NoError(t, err)
+
+				visitMarker := newPartitionVisitMarker("dummy", partitionedGroupID, partitionedGroup.CreationTime, 0)
+				visitMarker.Status = Pending
+				visitMarker.VisitTime = time.Now().Unix()
+				visitMarker.Version = PartitionVisitMarkerVersion1
+				content, err := json.Marshal(visitMarker)
+				require.NoError(t, err)
+				require.NoError(t, userBkt.Upload(ctx, visitMarker.GetVisitMarkerFilePath(), bytes.NewReader(content)))
+
+				// A partitioned group already compacted, whose source blocks have been deleted.
+				completedGroupID := hashGroup(userID, 6*time.Hour.Milliseconds(), 8*time.Hour.Milliseconds())
+				completedGroup, err := UpdatePartitionedGroupInfo(ctx, userBkt, log.NewNopLogger(), PartitionedGroupInfo{
+					PartitionedGroupID: completedGroupID,
+					PartitionCount:     1,
+					Partitions:         []Partition{{PartitionID: 0, Blocks: []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(2, nil)}}},
+					RangeStart:         6 * time.Hour.Milliseconds(),
+					RangeEnd:           8 * time.Hour.Milliseconds(),
+					Version:            PartitionedGroupInfoVersion1,
+				})
+				require.NoError(t, err)
+
+				visitMarker = newPartitionVisitMarker("dummy", completedGroupID, completedGroup.CreationTime, 0)
+				visitMarker.Status = Completed
+				visitMarker.VisitTime = time.Now().Unix()
+				visitMarker.Version = PartitionVisitMarkerVersion1
+				content, err = json.Marshal(visitMarker)
+				require.NoError(t, err)
+				require.NoError(t, userBkt.Upload(ctx, visitMarker.GetVisitMarkerFilePath(), bytes.NewReader(content)))
+			} else {
+				createBlockVisitMarker(t, bkt, userID, block3)
+			}
+
+			ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			cfg := prepareConfig()
+			cfg.ShardingEnabled = true
+			cfg.ShardingStrategy = util.ShardingStrategyShuffle
+			cfg.CompactionStrategy = testData.compactionStrategy
+			cfg.ShardingRing.InstanceID = "compactor-1"
+			cfg.ShardingRing.InstanceAddr = "1.2.3.4"
+			cfg.ShardingRing.KVStore.Mock = ringStore
+			// Do not compact the tenant, so that the bucket is only modified by the test.
+			cfg.DisabledTenants = []string{userID}
+
+			c, _, _, _, _ := prepare(t, cfg, bkt, nil)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+			defer services.StopAndAwaitTerminated(ctx, c) //nolint:errcheck
+
+			// An invalid tenant ID is rejected.
+			resp := requestCompactionPlan(c, "..", true)
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+			// The plan is rendered as HTML by default.
+			resp = requestCompactionPlan(c, userID, false)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Contains(t, resp.Body.String(), "Cortex Compactor Compaction Plan")
+
+			resp = requestCompactionPlan(c, userID, true)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			plan := TenantCompactionPlan{}
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &plan))
+
+			assert.Equal(t, userID, plan.Tenant)
+			assert.Equal(t, testData.expectedGrouper, plan.Grouper)
+			assert.Equal(t, []string{"1.2.3.4:0"}, plan.TenantShard)
+			assert.Equal(t, 5, plan.Blocks)
+
+			require.Len(t, plan.ExcludedBlocks, 2)
+			assert.Equal(t, block1.String(), plan.ExcludedBlocks[0].BlockID)
+			assert.Equal(t, string(metadata.OutOfOrderChunksNoCompactReason), plan.ExcludedBlocks[0].Reason)
+			assert.Contains(t, plan.ExcludedBlocks[0].Details, "out-of-order")
+			assert.Nil(t, plan.ExcludedBlocks[0].NoCompactTime)
+			assert.Equal(t, block5.String(), plan.ExcludedBlocks[1].BlockID)
+			assert.Equal(t, 4*time.Hour.Milliseconds(), plan.ExcludedBlocks[1].MinTime)
+			assert.Equal(t, "testing", plan.ExcludedBlocks[1].Reason)
+			assert.Equal(t, "yolo", plan.ExcludedBlocks[1].Details)
+			assert.NotNil(t, plan.ExcludedBlocks[1].NoCompactTime)
+
+			if testData.compactionStrategy == util.CompactionStrategyPartitioning {
+				// The completed partitioned group is listed too.
+				require.Len(t, plan.Groups, 3)
+				completed := plan.Groups[2]
+				assert.Equal(t, hashGroup(userID, 6*time.Hour.Milliseconds(), 8*time.Hour.Milliseconds()), completed.PartitionedGroupID)
+				assert.Equal(t, PlannedGroupCompleted, completed.Status)
+				require.Len(t, completed.Partitions, 1)
+				assert.Equal(t, PlannedGroupCompleted, completed.Partitions[0].Status)
+			} else {
+				require.Len(t, plan.Groups, 2)
+			}
+			first, second := plan.Groups[0], plan.Groups[1]
+			assert.ElementsMatch(t, []string{block1.String(), block2.String()}, first.Blocks)
+			assert.Equal(t, int64(0), first.RangeStart.UnixMilli())
+			assert.Equal(t, 2*time.Hour.Milliseconds(), first.RangeEnd.UnixMilli())
+			assert.Equal(t, PlannedGroupReady, first.Status)
+			assert.ElementsMatch(t, []string{block3.String(), block4.String()}, second.Blocks)
+			assert.Equal(t, PlannedGroupInProgress, second.Status)
+
+			if testData.compactionStrategy == util.CompactionStrategyPartitioning {
+				// The first partitioned group doesn't exist yet, and is not uploaded by the dry-run.
+				assert.Zero(t, first.PartitionedGroupCreationTime)
+				require.Len(t, first.Partitions, 1)
+				assert.Equal(t, PlannedGroupReady, first.Partitions[0].Status)
+				assert.Nil(t, first.Partitions[0].VisitMarker)
+
+				exists, err := bkt.Exists(ctx, path.Join(userID, GetPartitionedGroupFile(first.PartitionedGroupID)))
+				require.NoError(t, err)
+				assert.False(t, exists)
+
+				assert.Equal(t, partitionedGroupID, second.PartitionedGroupID)
+				assert.NotZero(t, second.PartitionedGroupCreationTime)
+				require.Len(t, second.Partitions, 1)
+				assert.Equal(t, PlannedGroupInProgress, second.Partitions[0].Status)
+				require.NotNil(t, second.Partitions[0].VisitMarker)
+				assert.Equal(t, "dummy", second.Partitions[0].VisitMarker.CompactorID)
+				assert.Equal(t, Pending, second.Partitions[0].VisitMarker.Status)
+				assert.False(t, second.Partitions[0].VisitMarker.Expired)
+			} else {
+				assert.NotEmpty(t, first.Key)
+				assert.Empty(t, first.VisitMarkers)
+
+				// The dry-run doesn't upload any visit marker.
+				exists, err := bkt.Exists(ctx, path.Join(userID, block1.String(), BlockVisitMarkerFile))
+				require.NoError(t, err)
+				assert.False(t, exists)
+
+				require.Len(t, second.VisitMarkers, 1)
+				assert.Equal(t, block3.String(), second.VisitMarkers[0].BlockID)
+				assert.Equal(t, "dummy", second.VisitMarkers[0].CompactorID)
+				assert.False(t, second.VisitMarkers[0].Expired)
+
+				// The planner rejects the blocks visited by another compactor.
+				assert.Empty(t, first.RejectedBlocks)
+				assert.Equal(t, []RejectedBlock{{BlockID: block3.String(), Reason: "visited by compactor dummy"}}, second.RejectedBlocks)
+			}
+		})
+	}
+}
+
+func requestCompactionPlan(c *Compactor, userID string, asJSON bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/compactor/tenants/"+userID+"/plan", nil)
+	if asJSON {
+		req.Header.Set("Accept", "application/json")
+	}
+	req = mux.SetURLVars(req, map[string]string{"tenant": userID})
+
+	resp := httptest.NewRecorder()
+	c.PlanHandler(resp, req)
+	return resp
+}
+
+func TestPartitionCompactionPlanner_PlanDryRun(t *testing.T) {
+	ranges := []int64{2 * time.Hour.Milliseconds(), 12 * time.Hour.Milliseconds()}
+	meta := func(id ulid.ULID, minT, maxT int64) *metadata.Meta {
+		return &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: minT, MaxTime: maxT}}
+	}
+
+	block1, block2, block3, block4 := ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil), ulid.MustNew(4, nil)
+	blocks := map[ulid.ULID]*metadata.Meta{
+		block1: meta(block1, 0, 2*time.Hour.Milliseconds()),
+		block2: meta(block2, 2*time.Hour.Milliseconds(), 4*time.Hour.Milliseconds()),
+		block3: meta(block3, 10*time.Hour.Milliseconds(), 14*time.Hour.Milliseconds()),
+	}
+	noCompactMarked := map[ulid.ULID]*metadata.NoCompactMark{
+		block2: {ID: block2, Reason: metadata.ManualNoCompactReason},
+	}
+
+	tests := map[string]struct {
+		partition        Partition
+		expectedStatus   string
+		expectedError    string
+		expectedRejected []RejectedBlock
+	}{
+		"should not reject a partition whose blocks can be compacted": {
+			partition:      Partition{Blocks: []ulid.ULID{block1}},
+			expectedStatus: PlannedGroupReady,
+		},
+		"should reject the blocks marked for no compaction": {
+			partition:        Partition{Blocks: []ulid.ULID{block1, block2}},
+			expectedStatus:   PlannedGroupReady,
+			expectedRejected: []RejectedBlock{{BlockID: block2.String(), Reason: "marked for no compaction: manual"}},
+		},
+		"should reject a partition with only blocks marked for no compaction": {
+			partition:        Partition{Blocks: []ulid.ULID{block2}},
+			expectedStatus:   PlannedGroupRejected,
+			expectedError:    "no blocks to compact",
+			expectedRejected: []RejectedBlock{{BlockID: block2.String(), Reason: "marked for no compaction: manual"}},
+		},
+		"should reject a partition with missing blocks": {
+			partition:        Partition{Blocks: []ulid.ULID{block1, block4}},
+			expectedStatus:   PlannedGroupRejected,
+			expectedError:    "partition contains 1 missing blocks",
+			expectedRejected: []RejectedBlock{{BlockID: block4.String(), Reason: "not found"}},
+		},
+		"should reject a partition with blocks outside of the largest range": {
+			partition:      Partition{Blocks: []ulid.ULID{block1, block3}},
+			expectedStatus: PlannedGroupRejected,
+			expectedError:  "is outside the largest expected range",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			planner := NewPartitionCompactionPlanner(context.Background(), nil, log.NewNopLogger(), ranges, func() map[ulid.ULID]*metadata.NoCompactMark { return noCompactMarked }, "compactor-1", "user-1", 0, time.Minute, time.Minute, nil, nil)
+
+			planned := PlannedPartition{Status: PlannedGroupReady}
+			planner.planDryRun(&planned, testData.partition, blocks)
+			assert.Equal(t, testData.expectedStatus, planned.Status)
+			if testData.expectedError == "" {
+				assert.Empty(t, planned.PlannerError)
+			} else {
+				assert.Contains(t, planned.PlannerError, testData.expectedError)
+			}
+			assert.Equal(t, testData.expectedRejected, planned.RejectedBlocks)
+		})
+	}
+}
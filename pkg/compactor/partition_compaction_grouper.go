@@ -56,6 +56,9 @@ type PartitionCompactionGrouper struct {
 	partitionVisitMarkerTimeout time.Duration
 
 	ingestionReplicationFactor int
+
+	// dryRun prevents the grouper from uploading the partitioned groups it generates.
+	dryRun bool
 }
 
 func NewPartitionCompactionGrouper(
@@ -395,6 +398,9 @@ func (g *PartitionCompactionGrouper) generatePartitionBlockGroup(group blocksGro
 	if err != nil {
 		return nil, err
 	}
+	if g.dryRun {
+		return partitionedGroupInfo, nil
+	}
 	updatedPartitionedGroupInfo, err := UpdatePartitionedGroupInfo(g.ctx, g.bkt, g.logger, *partitionedGroupInfo)
 	if err != nil {
 		return nil, err
@@ -141,32 +141,11 @@ func (p *PartitionCompactionPlanner) PlanWithPartition(_ context.Context, metasB
 		return nil, plannerCompletedPartitionError
 	}
 
-	// Ensure all blocks fits within the largest range. This is a double check
-	// to ensure there's no bug in the previous blocks grouping, given this Plan()
-	// is just a pass-through.
-	// Modified from https://github.com/cortexproject/cortex/pull/2616/files#diff-e3051fc530c48bb276ba958dd8fadc684e546bd7964e6bc75cef9a86ef8df344R28-R63
-	largestRange := p.ranges[len(p.ranges)-1]
-	rangeStart := getRangeStart(metasByMinTime[0], largestRange)
-	rangeEnd := rangeStart + largestRange
-	noCompactMarked := p.noCompBlocksFunc()
-	resultMetas := make([]*metadata.Meta, 0, len(metasByMinTime))
-
-	for _, b := range metasByMinTime {
-		if b.ULID == DUMMY_BLOCK_ID {
-			continue
-		}
-		blockID := b.ULID.String()
-		if _, excluded := noCompactMarked[b.ULID]; excluded {
-			continue
-		}
-
-		if b.MinTime < rangeStart || b.MaxTime > rangeEnd {
-			p.compactorMetrics.compactionsNotPlanned.WithLabelValues(p.userID, cortexMetaExtensions.TimeRangeStr()).Inc()
-			level.Warn(p.logger).Log("msg", "block is outside the largest expected range", "partitioned_group_id", partitionedGroupID, "partition_id", partitionID, "block_id", blockID, "block_min_time", b.MinTime, "block_max_time", b.MaxTime, "range_start", rangeStart, "range_end", rangeEnd)
-			return nil, fmt.Errorf("block %s with time range %d:%d is outside the largest expected range %d:%d", blockID, b.MinTime, b.MaxTime, rangeStart, rangeEnd)
-		}
-
-		resultMetas = append(resultMetas, b)
+	resultMetas, err := p.filterBlocks(metasByMinTime)
+	if err != nil {
+		p.compactorMetrics.compactionsNotPlanned.WithLabelValues(p.userID, cortexMetaExtensions.TimeRangeStr()).Inc()
+		level.Warn(p.logger).Log("msg", "block is outside the largest expected range", "partitioned_group_id", partitionedGroupID, "partition_id", partitionID, "err", err)
+		return nil, err
 	}
 
 	if len(resultMetas) < 1 {
@@ -201,3 +180,31 @@ func (p *PartitionCompactionPlanner) PlanWithPartition(_ context.Context, metasB
 
 	return resultMetas, nil
 }
+
+// filterBlocks returns the blocks which are neither dummy nor marked for no compaction, and ensures they all fit
+// within the largest range. This is a double check to ensure there's no bug in the previous blocks grouping, given
+// Plan() is just a pass-through.
+// Modified from https://github.com/cortexproject/cortex/pull/2616/files#diff-e3051fc530c48bb276ba958dd8fadc684e546bd7964e6bc75cef9a86ef8df344R28-R63
+func (p *PartitionCompactionPlanner) filterBlocks(metasByMinTime []*metadata.Meta) ([]*metadata.Meta, error) {
+	largestRange := p.ranges[len(p.ranges)-1]
+	rangeStart := getRangeStart(metasByMinTime[0], largestRange)
+	rangeEnd := rangeStart + largestRange
+	noCompactMarked := p.noCompBlocksFunc()
+	resultMetas := make([]*metadata.Meta, 0, len(metasByMinTime))
+
+	for _, b := range metasByMinTime {
+		if b.ULID == DUMMY_BLOCK_ID {
+			continue
+		}
+		if _, excluded := noCompactMarked[b.ULID]; excluded {
+			continue
+		}
+
+		if b.MinTime < rangeStart || b.MaxTime > rangeEnd {
+			return nil, fmt.Errorf("block %s with time range %d:%d is outside the largest expected range %d:%d", b.ULID.String(), b.MinTime, b.MaxTime, rangeStart, rangeEnd)
+		}
+
+		resultMetas = append(resultMetas, b)
+	}
+	return resultMetas, nil
+}
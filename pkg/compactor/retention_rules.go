@@ -0,0 +1,297 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// retentionRulesCompactionLifecycleCallback wraps a compaction lifecycle callback, so that the blocks
+// populated by the compaction don't include the samples expired according to the retention rules.
+type retentionRulesCompactionLifecycleCallback struct {
+	compact.CompactionLifecycleCallback
+
+	rules validation.RetentionRulesConfig
+}
+
+func newRetentionRulesCompactionLifecycleCallback(callback compact.CompactionLifecycleCallback, rules validation.RetentionRulesConfig) compact.CompactionLifecycleCallback {
+	if len(rules) == 0 {
+		return callback
+	}
+	return &retentionRulesCompactionLifecycleCallback{CompactionLifecycleCallback: callback, rules: rules}
+}
+
+func (c *retentionRulesCompactionLifecycleCallback) GetBlockPopulator(ctx context.Context, logger log.Logger, cg *compact.Group) (tsdb.BlockPopulator, error) {
+	populator, err := c.CompactionLifecycleCallback.GetBlockPopulator(ctx, logger, cg)
+	if err != nil {
+		return nil, err
+	}
+	return &retentionRulesBlockPopulator{populator: populator, rules: c.rules}, nil
+}
+
+// retentionRulesBlockPopulator populates the block with the wrapped populator, after having
+// added the samples expired according to the retention rules to the tombstones of the source blocks.
+type retentionRulesBlockPopulator struct {
+	populator tsdb.BlockPopulator
+	rules     validation.RetentionRulesConfig
+	now       time.Time // Defaults to the time the block is populated at if zero.
+}
+
+func (p *retentionRulesBlockPopulator) PopulateBlock(ctx context.Context, metrics *tsdb.CompactorMetrics, logger *slog.Logger, chunkPool chunkenc.Pool, mergeFunc storage.VerticalChunkSeriesMergeFunc, blocks []tsdb.BlockReader, meta *tsdb.BlockMeta, indexw tsdb.IndexWriter, chunkw tsdb.ChunkWriter, postingsFunc tsdb.IndexReaderPostingsFunc) error {
+	now := p.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	readers := make([]tsdb.BlockReader, 0, len(blocks))
+	for _, b := range blocks {
+		readers = append(readers, &retentionRulesBlockReader{BlockReader: b, ctx: ctx, rules: p.rules, now: now})
+	}
+	return p.populator.PopulateBlock(ctx, metrics, logger, chunkPool, mergeFunc, readers, meta, indexw, chunkw, postingsFunc)
+}
+
+// retentionRulesBlockReader is a block reader whose tombstones include the samples expired according to the retention rules.
+type retentionRulesBlockReader struct {
+	tsdb.BlockReader
+
+	ctx   context.Context
+	rules validation.RetentionRulesConfig
+	now   time.Time
+}
+
+func (r *retentionRulesBlockReader) Tombstones() (tombstones.Reader, error) {
+	tombsr, err := r.BlockReader.Tombstones()
+	if err != nil {
+		return nil, err
+	}
+	defer tombsr.Close()
+
+	stones := tombstones.NewMemTombstones()
+	if err := tombsr.Iter(func(ref storage.SeriesRef, ivs tombstones.Intervals) error {
+		stones.AddInterval(ref, ivs...)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "read tombstones")
+	}
+
+	indexr, err := r.Index()
+	if err != nil {
+		return nil, err
+	}
+	defer indexr.Close()
+
+	expired, err := retentionRulesExpiredSeries(r.ctx, indexr, r.rules, r.now, r.Meta().MinTime)
+	if err != nil {
+		return nil, err
+	}
+	for ref, maxt := range expired {
+		stones.AddInterval(ref, tombstones.Interval{Mint: math.MinInt64, Maxt: maxt})
+	}
+	return stones, nil
+}
+
+// retentionRulesExpiredSeries returns, for each series of the block with samples expired according to the
+// retention rules, the max timestamp of its expired samples. The first rule matching a series applies.
+func retentionRulesExpiredSeries(ctx context.Context, indexr tsdb.IndexReader, rules validation.RetentionRulesConfig, now time.Time, blockMinTime int64) (map[storage.SeriesRef]int64, error) {
+	expired := map[storage.SeriesRef]int64{}
+
+	// Series matched by previous rules are skipped.
+	matched := map[storage.SeriesRef]struct{}{}
+	for _, rule := range rules {
+		maxt := now.Add(-time.Duration(rule.Period)).UnixMilli()
+
+		postings, err := tsdb.PostingsForMatchers(ctx, indexr, rule.ParsedMatchers()...)
+		if err != nil {
+			return nil, errors.Wrap(err, "expand postings for retention rule")
+		}
+		for postings.Next() {
+			ref := postings.At()
+			if _, ok := matched[ref]; ok {
+				continue
+			}
+			matched[ref] = struct{}{}
+
+			if maxt >= blockMinTime {
+				expired[ref] = maxt
+			}
+		}
+		if err := postings.Err(); err != nil {
+			return nil, errors.Wrap(err, "iterate postings for retention rule")
+		}
+	}
+	return expired, nil
+}
+
+// applyUserRetentionRules rewrites the blocks containing series whose retention period has elapsed for the whole
+// block time range, and marks the original blocks for deletion. Only the blocks of the largest block range are
+// rewritten, as they are not compacted anymore, while the expired samples of smaller blocks are dropped when
+// they are compacted.
+func (c *BlocksCleaner) applyUserRetentionRules(ctx context.Context, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger, userID string) {
+	rules := c.cfgProvider.RetentionRules(userID)
+	if len(rules) == 0 || len(c.cfg.BlockRanges) == 0 {
+		return
+	}
+
+	marked := make(map[ulid.ULID]struct{}, len(idx.BlockDeletionMarks))
+	for _, d := range idx.BlockDeletionMarks {
+		marked[d.ID] = struct{}{}
+	}
+
+	now := time.Now()
+	largestRange := c.cfg.BlockRanges[len(c.cfg.BlockRanges)-1]
+	for _, b := range idx.Blocks {
+		if _, isMarked := marked[b.ID]; isMarked || b.MaxTime-b.MinTime < largestRange {
+			continue
+		}
+
+		// Blocks are only checked again if more rules have expired for them since the last check,
+		// which is recorded in the bucket index so that it survives restarts.
+		hash := retentionRulesCheckHash(b, rules, now)
+		if hash == "" || hash == b.RetentionRulesChecked {
+			continue
+		}
+
+		if err := c.rewriteBlockWithRetentionRules(ctx, userBucket, userLogger, userID, b.ID, rules, now); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to apply retention rules to block", "block", b.ID, "err", err)
+			continue
+		}
+		b.RetentionRulesChecked = hash
+	}
+}
+
+// retentionRulesCheckHash returns the hash of the rules whose retention period has elapsed for the whole block
+// time range. It's empty if there are no such rules.
+func retentionRulesCheckHash(b *bucketindex.Block, rules validation.RetentionRulesConfig, now time.Time) string {
+	h := fnv.New64a()
+	expired := false
+	for i, rule := range rules {
+		if b.MaxTime <= now.Add(-time.Duration(rule.Period)).UnixMilli() {
+			_, _ = fmt.Fprintf(h, "/%d:%s:%s", i, rule.Matchers, rule.Period)
+			expired = true
+		}
+	}
+	if !expired {
+		return ""
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// rewriteBlockWithRetentionRules downloads the block, and rewrites it without the samples expired according to
+// the retention rules if it contains series expired for the whole block time range. The original block is marked
+// for deletion once the rewritten block has been uploaded.
+func (c *BlocksCleaner) rewriteBlockWithRetentionRules(ctx context.Context, userBucket objstore.InstrumentedBucket, userLogger log.Logger, userID string, id ulid.ULID, rules validation.RetentionRulesConfig, now time.Time) error {
+	workDir := filepath.Join(c.cfg.RetentionRulesDir, userID, id.String())
+	if err := os.RemoveAll(workDir); err != nil {
+		return errors.Wrap(err, "clean work directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to remove retention rules work directory", "dir", workDir, "err", err)
+		}
+	}()
+
+	blockDir := filepath.Join(workDir, id.String())
+	if err := block.Download(ctx, userLogger, userBucket, id, blockDir); err != nil {
+		return errors.Wrap(err, "download block")
+	}
+	meta, err := metadata.ReadFromDir(blockDir)
+	if err != nil {
+		return errors.Wrap(err, "read block meta")
+	}
+
+	slogger := util_log.GoKitLogToSlog(userLogger)
+	b, err := tsdb.OpenBlock(slogger, blockDir, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "open block")
+	}
+	defer b.Close()
+
+	expired, err := c.hasFullyExpiredSeries(ctx, b, rules, now)
+	if err != nil {
+		return err
+	}
+	if !expired {
+		level.Debug(userLogger).Log("msg", "no series expired by the retention rules in block", "block", id)
+		return nil
+	}
+
+	compactor, err := tsdb.NewLeveledCompactor(ctx, nil, slogger, []int64{meta.MaxTime - meta.MinTime}, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "create compactor")
+	}
+	populator := &retentionRulesBlockPopulator{populator: tsdb.DefaultBlockPopulator{}, rules: rules, now: now}
+	rewrittenIDs, err := compactor.CompactWithBlockPopulator(workDir, []string{blockDir}, []*tsdb.Block{b}, populator)
+	if err != nil {
+		return errors.Wrap(err, "rewrite block")
+	}
+
+	// The rewritten block is empty if all its samples have expired.
+	details := "all series expired by the retention rules"
+	if len(rewrittenIDs) > 0 {
+		rewrittenDir := filepath.Join(workDir, rewrittenIDs[0].String())
+		thanosMeta := meta.Thanos
+		thanosMeta.Source = metadata.BucketRewriteSource
+		thanosMeta.SegmentFiles = block.GetSegmentFiles(rewrittenDir)
+		thanosMeta.Files = nil
+		thanosMeta.Rewrites = append(slices.Clone(meta.Thanos.Rewrites), metadata.Rewrite{Sources: meta.Compaction.Sources})
+		if _, err := metadata.InjectThanos(userLogger, rewrittenDir, thanosMeta, nil); err != nil {
+			return errors.Wrap(err, "write rewritten block meta")
+		}
+
+		if err := block.UploadPromBlock(ctx, userLogger, userBucket, rewrittenDir, metadata.NoneFunc); err != nil {
+			return errors.Wrap(err, "upload rewritten block")
+		}
+		details = fmt.Sprintf("rewritten without the series expired by the retention rules into block %s", rewrittenIDs[0])
+	}
+
+	if err := block.MarkForDeletion(ctx, userLogger, userBucket, id, details, c.blocksMarkedForDeletion.WithLabelValues(userID, reasonValueRetentionRules)); err != nil {
+		return errors.Wrap(err, "mark rewritten block for deletion")
+	}
+	level.Info(userLogger).Log("msg", "applied retention rules: rewrote block", "block", id, "rewritten_blocks", fmt.Sprintf("%v", rewrittenIDs))
+	return nil
+}
+
+// hasFullyExpiredSeries returns whether the block contains series whose retention period has elapsed for the whole
+// block time range. Series expired only partially are not enough to rewrite the block, as they would be rewritten
+// again at each cleanup until they are fully expired.
+func (c *BlocksCleaner) hasFullyExpiredSeries(ctx context.Context, b *tsdb.Block, rules validation.RetentionRulesConfig, now time.Time) (bool, error) {
+	indexr, err := b.Index()
+	if err != nil {
+		return false, errors.Wrap(err, "open block index")
+	}
+	defer indexr.Close()
+
+	expired, err := retentionRulesExpiredSeries(ctx, indexr, rules, now, b.Meta().MinTime)
+	if err != nil {
+		return false, err
+	}
+	for _, maxt := range expired {
+		// The block max time is exclusive.
+		if maxt >= b.Meta().MaxTime-1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,229 @@
+package compactor
+
+import (
+	"context"
+	"math"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
+	"github.com/cortexproject/cortex/pkg/util/users"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestRetentionRulesBlockPopulator(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	mint := now.Add(-10 * 24 * time.Hour).UnixMilli()
+	maxt := now.UnixMilli()
+
+	series := []labels.Labels{
+		labels.FromStrings(MetricLabelName, "debug_metric", "job", "a"),
+		labels.FromStrings(MetricLabelName, "debug_metric", "job", "b"),
+		labels.FromStrings(MetricLabelName, "slo_metric", "job", "a"),
+	}
+
+	sourceDir := t.TempDir()
+	blockID, err := e2eutil.CreateBlock(ctx, sourceDir, series, 100, mint, maxt, labels.EmptyLabels(), 0, metadata.NoneFunc, nil)
+	require.NoError(t, err)
+
+	rules := validation.RetentionRulesConfig{
+		// The first matching rule applies, so debug_metric{job="b"} is retained 20 days.
+		{Matchers: `{__name__="debug_metric", job="b"}`, Period: model.Duration(20 * 24 * time.Hour)},
+		{Matchers: `{__name__="debug_metric"}`, Period: model.Duration(5 * 24 * time.Hour)},
+	}
+	require.NoError(t, rules.Validate())
+
+	compactor, err := tsdb.NewLeveledCompactor(ctx, nil, util_log.GoKitLogToSlog(log.NewNopLogger()), []int64{maxt - mint}, nil, nil)
+	require.NoError(t, err)
+
+	callback := newRetentionRulesCompactionLifecycleCallback(DefaultCompactionLifecycleCallbackFactory(ctx, nil, log.NewNopLogger(), 1, "", "", nil), rules)
+	populator, err := callback.GetBlockPopulator(ctx, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	destDir := t.TempDir()
+	compactedIDs, err := compactor.CompactWithBlockPopulator(destDir, []string{filepath.Join(sourceDir, blockID.String())}, nil, populator)
+	require.NoError(t, err)
+	require.Len(t, compactedIDs, 1)
+
+	block, err := tsdb.OpenBlock(nil, filepath.Join(destDir, compactedIDs[0].String()), nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, block.Close()) })
+
+	q, err := tsdb.NewBlockQuerier(block, math.MinInt64, math.MaxInt64)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, q.Close()) })
+
+	// Returns the min timestamp and the number of samples of each series.
+	got := map[string][2]int64{}
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchRegexp, MetricLabelName, ".+"))
+	for set.Next() {
+		it := set.At().Iterator(nil)
+		var minTs, count int64 = math.MaxInt64, 0
+		for it.Next() == chunkenc.ValFloat {
+			ts, _ := it.At()
+			minTs = min(minTs, ts)
+			count++
+		}
+		require.NoError(t, it.Err())
+		got[set.At().Labels().String()] = [2]int64{minTs, count}
+	}
+	require.NoError(t, set.Err())
+	require.Len(t, got, 3)
+
+	retentionCutoff := now.Add(-5 * 24 * time.Hour).UnixMilli()
+	require.Greater(t, got[series[0].String()][0], retentionCutoff)
+	require.Less(t, got[series[0].String()][1], int64(100))
+	require.Equal(t, int64(100), got[series[1].String()][1])
+	require.Equal(t, int64(100), got[series[2].String()][1])
+}
+
+func TestBlocksCleaner_ShouldRewriteTopLevelBlocksWithExpiredSeries(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	bkt = bucketindex.BucketWithGlobalMarkers(bkt)
+
+	// A block of the largest block range, which is not going to be compacted anymore.
+	now := time.Now()
+	maxt := now.Add(-10 * 24 * time.Hour).UnixMilli()
+	mint := maxt - 24*time.Hour.Milliseconds()
+	series := []labels.Labels{
+		labels.FromStrings(MetricLabelName, "debug_metric", "job", "a"),
+		labels.FromStrings(MetricLabelName, "slo_metric", "job", "a"),
+	}
+	blockDir := t.TempDir()
+	blockID, err := e2eutil.CreateBlock(ctx, blockDir, series, 100, mint, maxt, labels.EmptyLabels(), 0, metadata.NoneFunc, nil)
+	require.NoError(t, err)
+	userBucket := objstore.WithNoopInstr(objstore.NewPrefixedBucket(bkt, userID))
+	require.NoError(t, block.UploadPromBlock(ctx, logger, userBucket, filepath.Join(blockDir, blockID.String()), metadata.NoneFunc))
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.userRetentionRules[userID] = validation.RetentionRulesConfig{
+		{Matchers: `{__name__="debug_metric"}`, Period: model.Duration(5 * 24 * time.Hour)},
+	}
+	require.NoError(t, cfgProvider.userRetentionRules[userID].Validate())
+
+	reg := prometheus.NewPedanticRegistry()
+	blocksMarkedForDeletion := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: blocksMarkedForDeletionName,
+		Help: blocksMarkedForDeletionHelp,
+	}, append(commonLabels, reasonLabelName))
+	scanner, err := users.NewScanner(users.UsersScannerConfig{Strategy: users.UserScanStrategyList}, bkt, logger, reg)
+	require.NoError(t, err)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:      time.Hour,
+		CleanupInterval:    time.Minute,
+		CleanupConcurrency: 1,
+		BlockRanges:        (&cortex_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}).ToMilliseconds(),
+		RetentionRulesDir:  t.TempDir(),
+	}
+	cleaner := NewBlocksCleaner(cfg, bkt, scanner, time.Minute, cfgProvider, logger, "test-cleaner", reg, time.Minute, 30*time.Second, blocksMarkedForDeletion, prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"test"}))
+
+	cleanUser := func() *bucketindex.Index {
+		userLogger := util_log.WithUserID(userID, cleaner.logger)
+		require.NoError(t, cleaner.cleanUser(ctx, userLogger, bucket.NewUserBucketClient(userID, bkt, cfgProvider), userID, false))
+		idx, err := bucketindex.ReadIndex(ctx, bkt, userID, cfgProvider, logger)
+		require.NoError(t, err)
+		return idx
+	}
+
+	// The retention rules are applied once the bucket index exists.
+	idx := cleanUser()
+	require.Len(t, idx.Blocks, 1)
+	require.Empty(t, idx.BlockDeletionMarks)
+
+	// The block is rewritten without the expired series, and marked for deletion.
+	idx = cleanUser()
+	require.Len(t, idx.Blocks, 2)
+	require.Len(t, idx.BlockDeletionMarks, 1)
+	require.Equal(t, blockID, idx.BlockDeletionMarks[0].ID)
+	require.Equal(t, float64(1), prom_testutil.ToFloat64(blocksMarkedForDeletion.WithLabelValues(userID, reasonValueRetentionRules)))
+
+	var rewritten *bucketindex.Block
+	for _, b := range idx.Blocks {
+		if b.ID != blockID {
+			rewritten = b
+		}
+	}
+	require.NotNil(t, rewritten)
+	require.Equal(t, mint, rewritten.MinTime)
+	require.Equal(t, maxt, rewritten.MaxTime)
+
+	rewrittenDir := filepath.Join(t.TempDir(), rewritten.ID.String())
+	require.NoError(t, block.Download(ctx, logger, userBucket, rewritten.ID, rewrittenDir))
+	rewrittenMeta, err := metadata.ReadFromDir(rewrittenDir)
+	require.NoError(t, err)
+	require.Equal(t, metadata.BucketRewriteSource, rewrittenMeta.Thanos.Source)
+	require.Equal(t, uint64(1), rewrittenMeta.Stats.NumSeries)
+
+	b, err := tsdb.OpenBlock(nil, rewrittenDir, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, b.Close()) })
+	q, err := tsdb.NewBlockQuerier(b, math.MinInt64, math.MaxInt64)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, q.Close()) })
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchRegexp, MetricLabelName, ".+"))
+	require.True(t, set.Next())
+	require.Equal(t, series[1], set.At().Labels())
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+
+	// The rewritten block doesn't contain expired series anymore, so it's not rewritten again.
+	idx = cleanUser()
+	require.Len(t, idx.Blocks, 2)
+	require.Len(t, idx.BlockDeletionMarks, 1)
+	require.Equal(t, float64(1), prom_testutil.ToFloat64(blocksMarkedForDeletion.WithLabelValues(userID, reasonValueRetentionRules)))
+
+	// The checks are recorded in the bucket index, so that the blocks are not checked again after a restart.
+	for _, b := range idx.Blocks {
+		require.NotEmpty(t, b.RetentionRulesChecked, "block %s", b.ID)
+	}
+	require.Equal(t, idx.Blocks[0].RetentionRulesChecked, idx.Blocks[1].RetentionRulesChecked)
+
+	// The checks survive a restart: a new cleaner doesn't download the rewritten block again,
+	// which would fail without its index.
+	require.NoError(t, userBucket.Delete(ctx, path.Join(rewritten.ID.String(), block.IndexFilename)))
+	logs := &concurrency.SyncBuffer{}
+	cleaner = NewBlocksCleaner(cfg, bkt, scanner, time.Minute, cfgProvider, log.NewLogfmtLogger(logs), "test-cleaner", prometheus.NewPedanticRegistry(), time.Minute, 30*time.Second, blocksMarkedForDeletion, prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"test"}))
+	idx = cleanUser()
+	require.Len(t, idx.Blocks, 2)
+	require.NotContains(t, logs.String(), "failed to apply retention rules to block")
+
+	// The blocks are checked again once more rules have expired for them.
+	require.NoError(t, objstore.UploadFile(ctx, logger, userBucket, filepath.Join(rewrittenDir, block.IndexFilename), path.Join(rewritten.ID.String(), block.IndexFilename)))
+	cfgProvider.userRetentionRules[userID] = append(cfgProvider.userRetentionRules[userID], validation.RetentionRuleConfig{Matchers: `{__name__="slo_metric"}`, Period: model.Duration(6 * 24 * time.Hour)})
+	require.NoError(t, cfgProvider.userRetentionRules[userID].Validate())
+	idx = cleanUser()
+	require.Len(t, idx.BlockDeletionMarks, 2)
+	require.Equal(t, float64(2), prom_testutil.ToFloat64(blocksMarkedForDeletion.WithLabelValues(userID, reasonValueRetentionRules)))
+}
+
+func TestRetentionRulesCompactionLifecycleCallback_ShouldNotWrapWithoutRules(t *testing.T) {
+	callback := DefaultCompactionLifecycleCallbackFactory(context.Background(), nil, log.NewNopLogger(), 1, "", "", nil)
+	require.Equal(t, callback, newRetentionRulesCompactionLifecycleCallback(callback, nil))
+}
@@ -106,19 +106,6 @@ func NewShuffleShardingGrouper(
 
 // Groups function modified from https://github.com/cortexproject/cortex/pull/2616
 func (g *ShuffleShardingGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) (res []*compact.Group, err error) {
-	noCompactMarked := g.noCompBlocksFunc()
-	// First of all we have to group blocks using the Thanos default
-	// grouping (based on downsample resolution + external labels).
-	mainGroups := map[string][]*metadata.Meta{}
-	for _, b := range blocks {
-		if _, excluded := noCompactMarked[b.ULID]; !excluded {
-			key := b.Thanos.GroupKey()
-			mainGroups[key] = append(mainGroups[key], b)
-		}
-	}
-
-	// For each group, we have to further split it into set of blocks
-	// which we can parallelly compact.
 	var outGroups []*compact.Group
 
 	// Check if this compactor is on the subring.
@@ -139,42 +126,8 @@ func (g *ShuffleShardingGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) (re
 		g.compactorMetrics.remainingPlannedCompactions.WithLabelValues(g.userID).Set(remainingCompactions)
 	}()
 
-	var groups []blocksGroup
-	for _, mainBlocks := range mainGroups {
-		groups = append(groups, groupBlocksByCompactableRanges(mainBlocks, g.compactorCfg.BlockRanges.ToMilliseconds())...)
-	}
-
-	// Ensure groups are sorted by smallest range, oldest min time first. The rationale
-	// is that we want to favor smaller ranges first (ie. to deduplicate samples sooner
-	// than later) and older ones are more likely to be "complete" (no missing block still
-	// to be uploaded).
-	sort.SliceStable(groups, func(i, j int) bool {
-		iGroup := groups[i]
-		jGroup := groups[j]
-		iMinTime := iGroup.minTime()
-		iMaxTime := iGroup.maxTime()
-		jMinTime := jGroup.minTime()
-		jMaxTime := jGroup.maxTime()
-		iLength := iMaxTime - iMinTime
-		jLength := jMaxTime - jMinTime
-
-		if iLength != jLength {
-			return iLength < jLength
-		}
-		if iMinTime != jMinTime {
-			return iMinTime < jMinTime
-		}
-
-		iGroupHash := hashGroup(g.userID, iGroup.rangeStart, iGroup.rangeEnd)
-		iGroupKey := createGroupKey(iGroupHash, iGroup)
-		jGroupHash := hashGroup(g.userID, jGroup.rangeStart, jGroup.rangeEnd)
-		jGroupKey := createGroupKey(jGroupHash, jGroup)
-		// Guarantee stable sort for tests.
-		return iGroupKey < jGroupKey
-	})
-
 mainLoop:
-	for _, group := range groups {
+	for _, group := range g.groupBlocks(blocks) {
 		var blockIds []string
 		for _, block := range group.blocks {
 			blockIds = append(blockIds, block.ULID.String())
@@ -258,6 +211,59 @@ mainLoop:
 	return outGroups, nil
 }
 
+// groupBlocks splits the blocks which are not marked for no compaction into the groups of blocks
+// which can be compacted together, sorted in the order they should be compacted.
+func (g *ShuffleShardingGrouper) groupBlocks(blocks map[ulid.ULID]*metadata.Meta) []blocksGroup {
+	noCompactMarked := g.noCompBlocksFunc()
+	// First of all we have to group blocks using the Thanos default
+	// grouping (based on downsample resolution + external labels).
+	mainGroups := map[string][]*metadata.Meta{}
+	for _, b := range blocks {
+		if _, excluded := noCompactMarked[b.ULID]; !excluded {
+			key := b.Thanos.GroupKey()
+			mainGroups[key] = append(mainGroups[key], b)
+		}
+	}
+
+	// For each group, we have to further split it into set of blocks
+	// which we can parallelly compact.
+	var groups []blocksGroup
+	for _, mainBlocks := range mainGroups {
+		groups = append(groups, groupBlocksByCompactableRanges(mainBlocks, g.compactorCfg.BlockRanges.ToMilliseconds())...)
+	}
+
+	// Ensure groups are sorted by smallest range, oldest min time first. The rationale
+	// is that we want to favor smaller ranges first (ie. to deduplicate samples sooner
+	// than later) and older ones are more likely to be "complete" (no missing block still
+	// to be uploaded).
+	sort.SliceStable(groups, func(i, j int) bool {
+		iGroup := groups[i]
+		jGroup := groups[j]
+		iMinTime := iGroup.minTime()
+		iMaxTime := iGroup.maxTime()
+		jMinTime := jGroup.minTime()
+		jMaxTime := jGroup.maxTime()
+		iLength := iMaxTime - iMinTime
+		jLength := jMaxTime - jMinTime
+
+		if iLength != jLength {
+			return iLength < jLength
+		}
+		if iMinTime != jMinTime {
+			return iMinTime < jMinTime
+		}
+
+		iGroupHash := hashGroup(g.userID, iGroup.rangeStart, iGroup.rangeEnd)
+		iGroupKey := createGroupKey(iGroupHash, iGroup)
+		jGroupHash := hashGroup(g.userID, jGroup.rangeStart, jGroup.rangeEnd)
+		jGroupKey := createGroupKey(jGroupHash, jGroup)
+		// Guarantee stable sort for tests.
+		return iGroupKey < jGroupKey
+	})
+
+	return groups
+}
+
 func (g *ShuffleShardingGrouper) isGroupVisited(blocks []*metadata.Meta, compactorID string) (bool, error) {
 	for _, block := range blocks {
 		blockID := block.ULID.String()
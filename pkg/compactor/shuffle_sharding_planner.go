@@ -53,26 +53,13 @@ func NewShuffleShardingPlanner(
 }
 
 func (p *ShuffleShardingPlanner) Plan(_ context.Context, metasByMinTime []*metadata.Meta, _ chan error, _ any) ([]*metadata.Meta, error) {
-	// Ensure all blocks fits within the largest range. This is a double check
-	// to ensure there's no bug in the previous blocks grouping, given this Plan()
-	// is just a pass-through.
-	// Modified from https://github.com/cortexproject/cortex/pull/2616/files#diff-e3051fc530c48bb276ba958dd8fadc684e546bd7964e6bc75cef9a86ef8df344R28-R63
-	largestRange := p.ranges[len(p.ranges)-1]
-	rangeStart := getRangeStart(metasByMinTime[0], largestRange)
-	rangeEnd := rangeStart + largestRange
-	noCompactMarked := p.noCompBlocksFunc()
-	resultMetas := make([]*metadata.Meta, 0, len(metasByMinTime))
+	resultMetas, err := p.filterBlocks(metasByMinTime)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, b := range metasByMinTime {
+	for _, b := range resultMetas {
 		blockID := b.ULID.String()
-		if _, excluded := noCompactMarked[b.ULID]; excluded {
-			continue
-		}
-
-		if b.MinTime < rangeStart || b.MaxTime > rangeEnd {
-			return nil, fmt.Errorf("block %s with time range %d:%d is outside the largest expected range %d:%d", blockID, b.MinTime, b.MaxTime, rangeStart, rangeEnd)
-		}
-
 		blockVisitMarker, err := ReadBlockVisitMarker(p.ctx, p.bkt, p.logger, blockID, p.blockVisitMarkerReadFailed)
 		if err != nil {
 			// shuffle_sharding_grouper should put visit marker file for blocks ready for
@@ -83,8 +70,6 @@ func (p *ShuffleShardingPlanner) Plan(_ context.Context, metasByMinTime []*metad
 			level.Warn(p.logger).Log("msg", "block is not visited by current compactor", "block_id", blockID, "compactor_id", p.ringLifecyclerID)
 			return nil, nil
 		}
-
-		resultMetas = append(resultMetas, b)
 	}
 
 	if len(resultMetas) < 2 {
@@ -95,3 +80,28 @@ func (p *ShuffleShardingPlanner) Plan(_ context.Context, metasByMinTime []*metad
 
 	return resultMetas, nil
 }
+
+// filterBlocks returns the blocks which are not marked for no compaction, and ensures they all fit within the
+// largest range. This is a double check to ensure there's no bug in the previous blocks grouping, given Plan()
+// is just a pass-through.
+// Modified from https://github.com/cortexproject/cortex/pull/2616/files#diff-e3051fc530c48bb276ba958dd8fadc684e546bd7964e6bc75cef9a86ef8df344R28-R63
+func (p *ShuffleShardingPlanner) filterBlocks(metasByMinTime []*metadata.Meta) ([]*metadata.Meta, error) {
+	largestRange := p.ranges[len(p.ranges)-1]
+	rangeStart := getRangeStart(metasByMinTime[0], largestRange)
+	rangeEnd := rangeStart + largestRange
+	noCompactMarked := p.noCompBlocksFunc()
+	resultMetas := make([]*metadata.Meta, 0, len(metasByMinTime))
+
+	for _, b := range metasByMinTime {
+		if _, excluded := noCompactMarked[b.ULID]; excluded {
+			continue
+		}
+
+		if b.MinTime < rangeStart || b.MaxTime > rangeEnd {
+			return nil, fmt.Errorf("block %s with time range %d:%d is outside the largest expected range %d:%d", b.ULID.String(), b.MinTime, b.MaxTime, rangeStart, rangeEnd)
+		}
+
+		resultMetas = append(resultMetas, b)
+	}
+	return resultMetas, nil
+}
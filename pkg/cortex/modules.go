@@ -57,6 +57,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
 	"github.com/cortexproject/cortex/pkg/ruler"
 	"github.com/cortexproject/cortex/pkg/scheduler"
+	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
 	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	"github.com/cortexproject/cortex/pkg/storegateway"
 	"github.com/cortexproject/cortex/pkg/util/grpcclient"
@@ -156,6 +157,7 @@ func (t *Cortex) initServer() (services.Service, error) {
 
 func (t *Cortex) initRing() (serv services.Service, err error) {
 	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.RuntimeConfig)
+	t.Cfg.Ingester.LifecyclerConfig.RingConfig.TokensGeneratorStrategy = t.Cfg.Ingester.LifecyclerConfig.TokensGeneratorStrategy
 	t.Ring, err = ring.New(t.Cfg.Ingester.LifecyclerConfig.RingConfig, "ingester", ingester.RingKey, util_log.Logger, prometheus.WrapRegistererWithPrefix("cortex_", prometheus.DefaultRegisterer))
 	if err != nil {
 		return nil, err
@@ -450,7 +452,17 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 
 	t.Cfg.Worker.ListenPort = t.Cfg.Server.GRPCListenPort
 
-	return querier_worker.NewQuerierWorker(t.Cfg.Worker, httpgrpc_server.NewServer(internalQuerierRouter), util_log.Logger, prometheus.DefaultRegisterer)
+	worker, err := querier_worker.NewQuerierWorker(t.Cfg.Worker, httpgrpc_server.NewServer(internalQuerierRouter), util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Allow query-schedulers to collect statistics of the queries running on this querier.
+	if s, ok := worker.(schedulerpb.QuerierForSchedulerServer); ok && t.Cfg.Worker.SchedulerAddress != "" {
+		t.API.RegisterQuerierWorker(s)
+	}
+
+	return worker, nil
 }
 
 func (t *Cortex) initStoreQueryables() (services.Service, error) {
@@ -584,6 +596,7 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		t.Cfg.Querier.LookbackDelta,
 		t.Cfg.Querier.DefaultEvaluationInterval,
 		t.Cfg.Querier.DistributedExecEnabled,
+		t.Cfg.Querier.DistributedExecAggregationPartials,
 		t.Cfg.Querier.ThanosEngine.LogicalOptimizers,
 		tenantResolverFn,
 	)
@@ -599,6 +612,7 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		t.Cfg.Querier.LookbackDelta,
 		t.Cfg.Querier.DefaultEvaluationInterval,
 		t.Cfg.Querier.DistributedExecEnabled,
+		t.Cfg.Querier.DistributedExecAggregationPartials,
 		t.Cfg.Querier.ThanosEngine.LogicalOptimizers)
 	if err != nil {
 		return nil, err
@@ -636,6 +650,7 @@ func (t *Cortex) initQueryFrontend() (serv services.Service, err error) {
 
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
+	roundTripper = transport.NewShadowRoundTripper(t.Cfg.Frontend.Handler.QueryShadow, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
 
 	handler := transport.NewHandler(t.Cfg.Frontend.Handler, t.Cfg.TenantFederation, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
 	t.API.RegisterQueryFrontendHandler(handler)
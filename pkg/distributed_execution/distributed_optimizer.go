@@ -1,18 +1,59 @@
 package distributed_execution
 
 import (
+	"slices"
+	"strconv"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/util/annotations"
 	"github.com/thanos-io/promql-engine/logicalplan"
 	"github.com/thanos-io/promql-engine/query"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/querysharding"
+)
+
+const (
+	// PartialLabel is added to the partial aggregates of a distributed aggregation, so that the
+	// partials computed by different fragments can be unioned before being merged.
+	PartialLabel = "__cortex_partial__"
+)
+
+var (
+	// mergeAggregationOps maps the distributable aggregations to the aggregation merging their partials.
+	mergeAggregationOps = map[parser.ItemType]parser.ItemType{
+		parser.SUM:     parser.SUM,
+		parser.COUNT:   parser.SUM,
+		parser.MIN:     parser.MIN,
+		parser.MAX:     parser.MAX,
+		parser.TOPK:    parser.TOPK,
+		parser.BOTTOMK: parser.BOTTOMK,
+	}
+
+	// nonShardableFunctions look at several series at once, so they cannot be computed on a subset of the series.
+	nonShardableFunctions = map[string]struct{}{
+		"absent":             {},
+		"absent_over_time":   {},
+		"histogram_fraction": {},
+		"histogram_quantile": {},
+		"info":               {},
+		"scalar":             {},
+		"vector":             {},
+	}
 )
 
-// This is a simplified implementation that only handles binary aggregation cases
+// This is a simplified implementation that handles binary and aggregation cases
 // Future versions of the distributed optimizer are expected to:
 // - Support more complex query patterns
 // - Incorporate diverse optimization strategies
-// - Extend support to node types beyond binary operations
+// - Extend support to node types beyond binary operations and aggregations
 
-type DistributedOptimizer struct{}
+type DistributedOptimizer struct {
+	// AggregationPartials is the number of partial aggregates computed in parallel for each
+	// distributable aggregation. Aggregations are not distributed when lower than 2.
+	AggregationPartials int
+}
 
 func (d *DistributedOptimizer) Optimize(root logicalplan.Node, opts *query.Options) (logicalplan.Node, annotations.Annotations) {
 	warns := annotations.New()
@@ -20,6 +61,13 @@ func (d *DistributedOptimizer) Optimize(root logicalplan.Node, opts *query.Optio
 	// insert remote nodes
 	logicalplan.TraverseBottomUp(nil, &root, func(parent, current *logicalplan.Node) bool {
 
+		if d.AggregationPartials > 1 && d.isDistributableAggregation(current) {
+			if distributed, err := d.distributeAggregation((*current).(*logicalplan.Aggregation)); err == nil {
+				*current = distributed
+			}
+			return false
+		}
+
 		if (*current).Type() == logicalplan.BinaryNode && d.hasAggregation(current) {
 			ch := (*current).Children()
 
@@ -47,3 +95,154 @@ func (d *DistributedOptimizer) hasAggregation(root *logicalplan.Node) bool {
 	})
 	return isAggr
 }
+
+// isDistributableAggregation returns whether the aggregation can be computed as the merge of partial
+// aggregates, each computed on a shard of the series of its only selector.
+func (d *DistributedOptimizer) isDistributableAggregation(node *logicalplan.Node) bool {
+	aggr, ok := (*node).(*logicalplan.Aggregation)
+	if !ok {
+		return false
+	}
+	if _, ok := mergeAggregationOps[aggr.Op]; !ok {
+		return false
+	}
+	if aggr.Param != nil && countSelectors(&aggr.Param) != 0 {
+		return false
+	}
+
+	shardable := true
+	logicalplan.TraverseBottomUp(nil, &aggr.Expr, func(parent, current *logicalplan.Node) bool {
+		switch n := (*current).(type) {
+		case *logicalplan.Aggregation, *Remote:
+			shardable = false
+		case *logicalplan.FunctionCall:
+			if _, ok := nonShardableFunctions[n.Func.Name]; ok {
+				shardable = false
+			}
+		}
+		return !shardable
+	})
+
+	// Series of different selectors may be joined together, which is only
+	// correct if they are all sharded the same way.
+	return shardable && countSelectors(&aggr.Expr) == 1
+}
+
+// distributeAggregation rewrites the aggregation as the merge of partial aggregates executed remotely:
+//
+//	sum by (job) (x) => sum by (job) (label_replace(remote(sum by (job) (x{shard 0})), ...) or label_replace(remote(sum by (job) (x{shard 1})), ...))
+func (d *DistributedOptimizer) distributeAggregation(aggr *logicalplan.Aggregation) (logicalplan.Node, error) {
+	var partials logicalplan.Node
+	for i := 0; i < d.AggregationPartials; i++ {
+		partial := aggr.Clone().(*logicalplan.Aggregation)
+		if err := injectShard(&partial.Expr, int64(i), int64(d.AggregationPartials)); err != nil {
+			return nil, err
+		}
+
+		labelled := labelReplace(NewRemoteNode(partial), PartialLabel, strconv.Itoa(i))
+		if partials == nil {
+			partials = labelled
+			continue
+		}
+		partials = &logicalplan.Binary{
+			Op:             parser.LOR,
+			LHS:            partials,
+			RHS:            labelled,
+			VectorMatching: &parser.VectorMatching{Card: parser.CardManyToMany},
+			ValueType:      parser.ValueTypeVector,
+		}
+	}
+
+	merge := &logicalplan.Aggregation{
+		Op:       mergeAggregationOps[aggr.Op],
+		Expr:     partials,
+		Grouping: aggr.Grouping,
+		Without:  aggr.Without,
+	}
+	if aggr.Param != nil {
+		merge.Param = aggr.Param.Clone()
+	}
+
+	switch {
+	case merge.Op == parser.TOPK || merge.Op == parser.BOTTOMK:
+		// topk and bottomk return the input series, which still carry the partial label.
+		return labelReplace(merge, PartialLabel, ""), nil
+	case merge.Without:
+		merge.Grouping = append(slices.Clone(aggr.Grouping), PartialLabel)
+	}
+	return merge, nil
+}
+
+// injectShard restricts all the selectors of the expression to the given shard, so that the storage only
+// returns the series belonging to it. Selectors already vertically sharded by the query-frontend are split
+// further: shard i of n, hashed by the same labels, is made of the shards i+n*j of n*totalShards.
+func injectShard(expr *logicalplan.Node, shardIndex, totalShards int64) error {
+	var err error
+	logicalplan.TraverseBottomUp(nil, expr, func(parent, current *logicalplan.Node) bool {
+		switch n := (*current).(type) {
+		case *logicalplan.VectorSelector:
+			var matchers []*labels.Matcher
+			matchers, err = shardMatchers(n.LabelMatchers, shardIndex, totalShards)
+			if err != nil {
+				return true
+			}
+			n.LabelMatchers = matchers
+		case *logicalplan.MatrixSelector:
+			// Matrix selectors are rendered from their original string, which has to include the sharding info too.
+			n.OriginalString = (&parser.MatrixSelector{VectorSelector: n.VectorSelector.VectorSelector, Range: n.Range}).String()
+		}
+		return false
+	})
+	return err
+}
+
+// shardMatchers returns the matchers restricted to the given shard, composed with the shard of the
+// matchers if they are already sharded.
+func shardMatchers(matchers []*labels.Matcher, shardIndex, totalShards int64) ([]*labels.Matcher, error) {
+	others, existing, err := querysharding.ExtractShardingInfo(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	shardInfo := &storepb.ShardInfo{ShardIndex: shardIndex, TotalShards: totalShards}
+	if existing.TotalShards > 0 {
+		shardInfo = &storepb.ShardInfo{
+			ShardIndex:  existing.ShardIndex + existing.TotalShards*shardIndex,
+			TotalShards: existing.TotalShards * totalShards,
+			By:          existing.By,
+			Labels:      existing.Labels,
+		}
+	}
+
+	matcher, err := querysharding.ShardingInfoMatcher(shardInfo)
+	if err != nil {
+		return nil, err
+	}
+	return append(others, matcher), nil
+}
+
+func countSelectors(root *logicalplan.Node) int {
+	count := 0
+	logicalplan.TraverseBottomUp(nil, root, func(parent, current *logicalplan.Node) bool {
+		if (*current).Type() == logicalplan.VectorSelectorNode {
+			count++
+		}
+		return false
+	})
+	return count
+}
+
+// labelReplace sets the label to the given value on all the series returned by the expression,
+// removing the label when the value is empty.
+func labelReplace(expr logicalplan.Node, name, value string) logicalplan.Node {
+	return &logicalplan.FunctionCall{
+		Func: *parser.Functions["label_replace"],
+		Args: []logicalplan.Node{
+			expr,
+			&logicalplan.StringLiteral{Val: name},
+			&logicalplan.StringLiteral{Val: value},
+			&logicalplan.StringLiteral{Val: ""},
+			&logicalplan.StringLiteral{Val: ""},
+		},
+	}
+}
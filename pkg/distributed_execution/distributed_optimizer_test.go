@@ -1,11 +1,16 @@
 package distributed_execution
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/promql-engine/logicalplan"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/querysharding"
 )
 
 func TestDistributedOptimizer(t *testing.T) {
@@ -73,3 +78,141 @@ func TestDistributedOptimizer(t *testing.T) {
 		})
 	}
 }
+
+func TestDistributedOptimizer_Aggregations(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name            string
+		query           string
+		remoteExecCount int
+		expectedResult  string
+	}{
+		{
+			name:            "sum by",
+			query:           "sum by (job) (rate(http_requests_total[5m]))",
+			remoteExecCount: 2,
+			expectedResult:  `sum by (job) (label_replace(remote(sum by (job) (rate(http_requests_total{__CORTEX_SHARD_BY__="EAI="}[5m]))), "__cortex_partial__", "0", "", "") or label_replace(remote(sum by (job) (rate(http_requests_total{__CORTEX_SHARD_BY__="CAEQAg=="}[5m]))), "__cortex_partial__", "1", "", ""))`,
+		},
+		{
+			name:            "count is merged by sum",
+			query:           "count(up)",
+			remoteExecCount: 2,
+			expectedResult:  `sum(label_replace(remote(count(up{__CORTEX_SHARD_BY__="EAI="})), "__cortex_partial__", "0", "", "") or label_replace(remote(count(up{__CORTEX_SHARD_BY__="CAEQAg=="})), "__cortex_partial__", "1", "", ""))`,
+		},
+		{
+			name:            "max without",
+			query:           "max without (instance) (up)",
+			remoteExecCount: 2,
+			expectedResult:  `max without (instance, __cortex_partial__) (label_replace(remote(max without (instance) (up{__CORTEX_SHARD_BY__="EAI="})), "__cortex_partial__", "0", "", "") or label_replace(remote(max without (instance) (up{__CORTEX_SHARD_BY__="CAEQAg=="})), "__cortex_partial__", "1", "", ""))`,
+		},
+		{
+			name:            "topk removes the partial label",
+			query:           "topk(5, up)",
+			remoteExecCount: 2,
+			expectedResult:  `label_replace(topk(5, label_replace(remote(topk(5, up{__CORTEX_SHARD_BY__="EAI="})), "__cortex_partial__", "0", "", "") or label_replace(remote(topk(5, up{__CORTEX_SHARD_BY__="CAEQAg=="})), "__cortex_partial__", "1", "", "")), "__cortex_partial__", "", "", "")`,
+		},
+		{
+			name:            "only the inner aggregation is distributed",
+			query:           "max(sum by (job) (up))",
+			remoteExecCount: 2,
+			expectedResult:  `max(sum by (job) (label_replace(remote(sum by (job) (up{__CORTEX_SHARD_BY__="EAI="})), "__cortex_partial__", "0", "", "") or label_replace(remote(sum by (job) (up{__CORTEX_SHARD_BY__="CAEQAg=="})), "__cortex_partial__", "1", "", "")))`,
+		},
+		{
+			name:            "binary operation with distributed aggregations",
+			query:           "sum(up) + sum(down)",
+			remoteExecCount: 6,
+			expectedResult:  `remote(sum(label_replace(remote(sum(up{__CORTEX_SHARD_BY__="EAI="})), "__cortex_partial__", "0", "", "") or label_replace(remote(sum(up{__CORTEX_SHARD_BY__="CAEQAg=="})), "__cortex_partial__", "1", "", ""))) + remote(sum(label_replace(remote(sum(down{__CORTEX_SHARD_BY__="EAI="})), "__cortex_partial__", "0", "", "") or label_replace(remote(sum(down{__CORTEX_SHARD_BY__="CAEQAg=="})), "__cortex_partial__", "1", "", "")))`,
+		},
+		{
+			name:            "aggregation over multiple selectors",
+			query:           "sum(up / down)",
+			remoteExecCount: 0,
+			expectedResult:  "sum(up / down)",
+		},
+		{
+			name:            "aggregation not supporting partials",
+			query:           "avg(up)",
+			remoteExecCount: 0,
+			expectedResult:  "avg(up)",
+		},
+		{
+			name:            "aggregation over function looking at several series",
+			query:           "sum(histogram_quantile(0.9, up))",
+			remoteExecCount: 0,
+			expectedResult:  "sum(histogram_quantile(0.9, up))",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lp, err := CreateTestLogicalPlanWithOptimizer(tc.query, now, now, time.Minute, &DistributedOptimizer{AggregationPartials: 2})
+			require.NoError(t, err)
+
+			node := (*lp).Root()
+
+			remoteNodeCount := 0
+			logicalplan.TraverseBottomUp(nil, &node, func(parent, current *logicalplan.Node) bool {
+				if RemoteNode == (*current).Type() {
+					remoteNodeCount++
+				}
+				return false
+			})
+			require.Equal(t, tc.remoteExecCount, remoteNodeCount)
+			require.Equal(t, tc.expectedResult, (*lp).Root().String())
+		})
+	}
+}
+
+func TestDistributedOptimizer_VerticallyShardedAggregation(t *testing.T) {
+	now := time.Now()
+	verticalShard := &storepb.ShardInfo{ShardIndex: 1, TotalShards: 3, By: true, Labels: []string{"job"}}
+
+	// The query-frontend already restricted the selector to a vertical shard.
+	qs, err := querysharding.InjectShardingInfo("sum by (job) (rate(http_requests_total[5m]))", verticalShard)
+	require.NoError(t, err)
+
+	lp, err := CreateTestLogicalPlanWithOptimizer(qs, now, now, time.Minute, &DistributedOptimizer{AggregationPartials: 2})
+	require.NoError(t, err)
+
+	var partialShards []*storepb.ShardInfo
+	node := (*lp).Root()
+	logicalplan.TraverseBottomUp(nil, &node, func(parent, current *logicalplan.Node) bool {
+		if selector, ok := (*current).(*logicalplan.VectorSelector); ok {
+			shardMatchers := 0
+			for _, m := range selector.LabelMatchers {
+				if m.Name == querysharding.CortexShardByLabel {
+					shardMatchers++
+				}
+			}
+			require.Equal(t, 1, shardMatchers)
+
+			_, shardInfo, err := querysharding.ExtractShardingInfo(selector.LabelMatchers)
+			require.NoError(t, err)
+			partialShards = append(partialShards, shardInfo)
+		}
+		return false
+	})
+	require.Len(t, partialShards, 2)
+
+	// Each series of the vertical shard belongs to exactly one partial, and other series to none.
+	verticalMatcher := verticalShard.Matcher(&querysharding.Buffers)
+	defer verticalMatcher.Close()
+	for i := 0; i < 100; i++ {
+		lset := labels.FromStrings(labels.MetricName, "http_requests_total", "job", fmt.Sprintf("job-%d", i), "instance", fmt.Sprintf("instance-%d", i%7))
+
+		matches := 0
+		for _, shardInfo := range partialShards {
+			m := shardInfo.Matcher(&querysharding.Buffers)
+			if m.MatchesLabels(lset) {
+				matches++
+			}
+			m.Close()
+		}
+
+		if verticalMatcher.MatchesLabels(lset) {
+			require.Equal(t, 1, matches, lset.String())
+		} else {
+			require.Equal(t, 0, matches, lset.String())
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package plan_fragments
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/promql/promqltest"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/promql-engine/api"
+	"github.com/thanos-io/promql-engine/logicalplan"
+
+	"github.com/cortexproject/cortex/pkg/distributed_execution"
+	"github.com/cortexproject/cortex/pkg/engine"
+	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/querysharding"
+	utillog "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const distributedExecutionTestData = `
+load 1m
+	http_requests_total{job="api", instance="0", pod="api-0"} 1+10x60
+	http_requests_total{job="api", instance="1", pod="api-1"} 2+21x60
+	http_requests_total{job="api", instance="2", pod="api-2"} 3+32x60
+	http_requests_total{job="db", instance="0", pod="db-0"} 4+43x60
+	http_requests_total{job="db", instance="1", pod="db-1"} 5+54x60
+	http_requests_total{job="web", instance="0", pod="web-0"} 6+65x60
+	http_requests_total{job="web", instance="1", pod="web-1"} 7+76x60
+	http_requests_total{job="web", instance="2", pod="web-2"} 8+87x60
+	process_resident_memory_bytes{job="api", instance="0"} 100+1x60
+	process_resident_memory_bytes{job="api", instance="1"} 200+2x60
+	process_resident_memory_bytes{job="db", instance="0"} 300+3x60
+	process_resident_memory_bytes{job="web", instance="0"} 400+4x60
+`
+
+// Tests the execution of distributed queries end-to-end: the logical plan is optimized and fragmented
+// as done by the query-frontend and query-scheduler, the fragments are executed by in-process queriers,
+// and the result is compared to the one of the same query executed by a single querier.
+func TestDistributedExecution(t *testing.T) {
+	db := promqltest.LoadedStorage(t, distributedExecutionTestData)
+	t.Cleanup(func() { _ = db.Close() })
+
+	start := time.Unix(0, 0)
+	end := start.Add(time.Hour)
+
+	for _, tc := range []struct {
+		name              string
+		query             string
+		expectedFragments int
+	}{
+		{
+			name:              "sum",
+			query:             `sum by (job) (rate(http_requests_total[5m]))`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "sum without",
+			query:             `sum without (instance, pod) (http_requests_total)`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "count",
+			query:             `count by (instance) (http_requests_total)`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "min",
+			query:             `min by (job) (http_requests_total)`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "max",
+			query:             `max(rate(http_requests_total[5m]))`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "topk",
+			query:             `topk(3, http_requests_total)`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "topk by",
+			query:             `topk by (job) (1, http_requests_total)`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "bottomk",
+			query:             `bottomk(2, rate(http_requests_total[5m]))`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "nested aggregation",
+			query:             `max(sum by (job) (http_requests_total))`,
+			expectedFragments: 4,
+		},
+		{
+			name:              "binary expression with aggregations",
+			query:             `sum by (job) (rate(http_requests_total[5m])) / sum by (job) (process_resident_memory_bytes)`,
+			expectedFragments: 9,
+		},
+		{
+			name:              "binary expression between selectors is not distributed",
+			query:             `sum(http_requests_total / on (job, instance) group_left process_resident_memory_bytes)`,
+			expectedFragments: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := newTestCluster(db, 3, 3)
+
+			for _, step := range []time.Duration{time.Minute, 0} {
+				t.Run(fmt.Sprintf("step=%s", step), func(t *testing.T) {
+					expected := cluster.queriers[0].singleQuery(t, tc.query, start, end, step)
+
+					actual, fragments := cluster.distributedQuery(t, tc.query, start, end, step)
+					require.Len(t, fragments, tc.expectedFragments)
+					requireEqualResults(t, expected, actual)
+
+					// Fragments must be executed from their plan, rather than falling back to the query string.
+					for _, q := range cluster.queriers {
+						require.NoError(t, testutil.GatherAndCompare(q.reg, strings.NewReader(`
+							# HELP cortex_thanos_engine_fallback_queries_total Total number of fallback queries due to not implementation in thanos engine
+							# TYPE cortex_thanos_engine_fallback_queries_total counter
+							cortex_thanos_engine_fallback_queries_total 0
+						`), "cortex_thanos_engine_fallback_queries_total"))
+					}
+				})
+			}
+		})
+	}
+}
+
+// testQuerier is an in-process querier, reading the series from the storage as ingesters
+// and store-gateways do: only the series of the shard requested by the query are returned.
+type testQuerier struct {
+	engine    *engine.Engine
+	queryable storage.Queryable
+	reg       *prometheus.Registry
+}
+
+func newTestQuerier(db storage.Queryable) *testQuerier {
+	opts := promql.EngineOpts{
+		Logger:     utillog.GoKitLogToSlog(log.NewNopLogger()),
+		MaxSamples: 1e6,
+		Timeout:    time.Minute,
+	}
+	reg := prometheus.NewPedanticRegistry()
+	return &testQuerier{
+		engine:    engine.New(opts, engine.ThanosEngineConfig{Enabled: true}, reg),
+		queryable: shardingQueryable{Queryable: db},
+		reg:       reg,
+	}
+}
+
+func (q *testQuerier) singleQuery(t *testing.T, qs string, start, end time.Time, step time.Duration) parser.Value {
+	var (
+		qry promql.Query
+		err error
+	)
+	if step == 0 {
+		qry, err = q.engine.NewInstantQuery(context.Background(), q.queryable, nil, qs, start)
+	} else {
+		qry, err = q.engine.NewRangeQuery(context.Background(), q.queryable, nil, qs, start, end, step)
+	}
+	require.NoError(t, err)
+	t.Cleanup(qry.Close)
+
+	res := qry.Exec(context.Background())
+	require.NoError(t, res.Err)
+	return res.Value
+}
+
+// testCluster executes the fragments of a distributed query across in-process queriers.
+type testCluster struct {
+	queriers   []*testQuerier
+	optimizer  *distributed_execution.DistributedOptimizer
+	fragmenter Fragmenter
+}
+
+func newTestCluster(db storage.Queryable, queriers, aggregationPartials int) *testCluster {
+	c := &testCluster{
+		optimizer:  &distributed_execution.DistributedOptimizer{AggregationPartials: aggregationPartials},
+		fragmenter: NewPlanFragmenter(),
+	}
+	for i := 0; i < queriers; i++ {
+		c.queriers = append(c.queriers, newTestQuerier(db))
+	}
+	return c
+}
+
+// distributedQuery executes the query as the queriers would do when receiving its fragments from the query-scheduler.
+// Fragments are assigned to queriers in round-robin, and each remote node is resolved to the querier executing
+// the fragment it refers to.
+func (c *testCluster) distributedQuery(t *testing.T, qs string, start, end time.Time, step time.Duration) (parser.Value, []Fragment) {
+	lp, err := distributed_execution.CreateTestLogicalPlanWithOptimizer(qs, start, end, step, c.optimizer)
+	require.NoError(t, err)
+
+	// The plan goes through the query-scheduler serialized.
+	const queryID = uint64(1)
+	fragments, err := c.fragmenter.Fragment(queryID, roundTrip(t, (*lp).Root()))
+	require.NoError(t, err)
+
+	// Each fragment is sent to its querier serialized too.
+	assigned := map[uint64]*testFragment{}
+	for i, fragment := range fragments {
+		assigned[fragment.FragmentID] = &testFragment{
+			querier: c.queriers[i%len(c.queriers)],
+			node:    roundTrip(t, fragment.Node),
+		}
+	}
+
+	endTime := end
+	if step == 0 {
+		endTime = start
+	}
+	for _, fragment := range assigned {
+		fragment.node = resolveRemoteNodes(fragment.node, assigned, start, endTime)
+	}
+
+	root := assigned[fragments[len(fragments)-1].FragmentID]
+	require.True(t, fragments[len(fragments)-1].IsRoot)
+
+	var qry promql.Query
+	if step == 0 {
+		qry, err = root.querier.engine.MakeInstantQueryFromPlan(context.Background(), root.querier.queryable, nil, root.node, start, qs)
+	} else {
+		qry, err = root.querier.engine.MakeRangeQueryFromPlan(context.Background(), root.querier.queryable, nil, root.node, start, end, step, qs)
+	}
+	require.NoError(t, err)
+	t.Cleanup(qry.Close)
+
+	res := qry.Exec(context.Background())
+	require.NoError(t, res.Err)
+	return res.Value, fragments
+}
+
+type testFragment struct {
+	querier *testQuerier
+	node    logicalplan.Node
+}
+
+// NewRangeQuery implements api.RemoteEngine, executing the fragment on its querier.
+func (f *testFragment) NewRangeQuery(ctx context.Context, opts promql.QueryOpts, _ api.RemoteQuery, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	return f.querier.engine.MakeRangeQueryFromPlan(ctx, f.querier.queryable, opts, f.node, start, end, interval, f.node.String())
+}
+
+func (f *testFragment) MaxT() int64                         { return math.MaxInt64 }
+func (f *testFragment) MinT() int64                         { return math.MinInt64 }
+func (f *testFragment) LabelSets() []labels.Labels          { return nil }
+func (f *testFragment) PartitionLabelSets() []labels.Labels { return nil }
+
+// resolveRemoteNodes replaces the remote nodes with the execution of the fragments they refer to.
+func resolveRemoteNodes(node logicalplan.Node, fragments map[uint64]*testFragment, start, end time.Time) logicalplan.Node {
+	if remote, ok := node.(*distributed_execution.Remote); ok {
+		return logicalplan.RemoteExecution{
+			Engine:          fragments[remote.FragmentKey.GetFragmentID()],
+			Query:           remote.Expr,
+			QueryRangeStart: start,
+			QueryRangeEnd:   end,
+		}
+	}
+	for _, child := range node.Children() {
+		*child = resolveRemoteNodes(*child, fragments, start, end)
+	}
+	return node
+}
+
+func roundTrip(t *testing.T, node logicalplan.Node) logicalplan.Node {
+	data, err := logicalplan.Marshal(node)
+	require.NoError(t, err)
+	node, err = distributed_execution.Unmarshal(data)
+	require.NoError(t, err)
+	return node
+}
+
+func requireEqualResults(t *testing.T, expected, actual parser.Value) {
+	switch e := expected.(type) {
+	case promql.Matrix:
+		a, ok := actual.(promql.Matrix)
+		require.True(t, ok)
+		sort.Sort(e)
+		sort.Sort(a)
+		require.Len(t, a, len(e))
+		for i := range e {
+			require.Equal(t, e[i].Metric, a[i].Metric)
+			require.Len(t, a[i].Floats, len(e[i].Floats))
+			for j := range e[i].Floats {
+				require.Equal(t, e[i].Floats[j].T, a[i].Floats[j].T)
+				requireFloatEqual(t, e[i].Floats[j].F, a[i].Floats[j].F)
+			}
+		}
+	case promql.Vector:
+		a, ok := actual.(promql.Vector)
+		require.True(t, ok)
+		sort.Slice(e, func(i, j int) bool { return labels.Compare(e[i].Metric, e[j].Metric) < 0 })
+		sort.Slice(a, func(i, j int) bool { return labels.Compare(a[i].Metric, a[j].Metric) < 0 })
+		require.Len(t, a, len(e))
+		for i := range e {
+			require.Equal(t, e[i].Metric, a[i].Metric)
+			require.Equal(t, e[i].T, a[i].T)
+			requireFloatEqual(t, e[i].F, a[i].F)
+		}
+	default:
+		require.Equal(t, expected, actual)
+	}
+}
+
+// requireFloatEqual allows partial aggregates to be merged in a different order than the original aggregation.
+func requireFloatEqual(t *testing.T, expected, actual float64) {
+	require.InDelta(t, expected, actual, 1e-9*math.Max(1, math.Abs(expected)))
+}
+
+type shardingQueryable struct {
+	storage.Queryable
+}
+
+func (q shardingQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	querier, err := q.Queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return shardingQuerier{Querier: querier}, nil
+}
+
+type shardingQuerier struct {
+	storage.Querier
+}
+
+func (q shardingQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	matchers, shardMatcher, err := querysharding.ExtractShardingMatchers(matchers)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	defer shardMatcher.Close()
+
+	set := q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	var result []storage.Series
+	for set.Next() {
+		if shardMatcher.MatchesLabels(set.At().Labels()) {
+			result = append(result, set.At())
+		}
+	}
+	if err := set.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	return series.NewSeriesSetWithWarnings(series.NewConcreteSeriesSet(sortSeries, result), set.Warnings())
+}
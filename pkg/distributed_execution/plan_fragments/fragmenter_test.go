@@ -125,3 +125,25 @@ func TestFragmenter(t *testing.T) {
 		})
 	}
 }
+
+func TestFragmenter_AggregationPartials(t *testing.T) {
+	now := time.Now()
+	optimizer := &distributed_execution.DistributedOptimizer{AggregationPartials: 3}
+	lp, err := distributed_execution.CreateTestLogicalPlanWithOptimizer("sum by (job) (rate(http_requests_total[5m]))", now, now, 0, optimizer)
+	require.NoError(t, err)
+
+	res, err := NewPlanFragmenter().Fragment(uint64(1), (*lp).Root())
+	require.NoError(t, err)
+
+	// current aggregation split:
+	//        3
+	//     /  |  \
+	//    0   1   2
+	require.Len(t, res, 4)
+	for _, partial := range res[:3] {
+		require.Empty(t, partial.ChildIDs)
+		require.False(t, partial.IsRoot)
+	}
+	require.True(t, res[3].IsRoot)
+	require.ElementsMatch(t, []uint64{res[0].FragmentID, res[1].FragmentID, res[2].FragmentID}, res[3].ChildIDs)
+}
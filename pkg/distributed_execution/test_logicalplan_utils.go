@@ -16,6 +16,10 @@ func getStartAndEnd(start time.Time, end time.Time, step time.Duration) (time.Ti
 }
 
 func CreateTestLogicalPlan(qs string, start time.Time, end time.Time, step time.Duration) (*logicalplan.Plan, error) {
+	return CreateTestLogicalPlanWithOptimizer(qs, start, end, step, &DistributedOptimizer{})
+}
+
+func CreateTestLogicalPlanWithOptimizer(qs string, start time.Time, end time.Time, step time.Duration, optimizer *DistributedOptimizer) (*logicalplan.Plan, error) {
 
 	start, end = getStartAndEnd(start, end, step)
 
@@ -44,7 +48,7 @@ func CreateTestLogicalPlan(qs string, start time.Time, end time.Time, step time.
 	if err != nil {
 		return nil, err
 	}
-	optimizedPlan, _ := logicalPlan.Optimize(append(logicalplan.DefaultOptimizers, &DistributedOptimizer{}))
+	optimizedPlan, _ := logicalPlan.Optimize(append(logicalplan.DefaultOptimizers, optimizer))
 
 	return &optimizedPlan, nil
 }
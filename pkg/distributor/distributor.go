@@ -54,8 +54,9 @@ var (
 	supportedShardingStrategies = []string{util.ShardingStrategyDefault, util.ShardingStrategyShuffle}
 
 	// Validation errors.
-	errInvalidShardingStrategy = errors.New("invalid sharding strategy")
-	errInvalidTenantShardSize  = errors.New("invalid tenant shard size. The value must be greater than or equal to 0")
+	errInvalidShardingStrategy       = errors.New("invalid sharding strategy")
+	errInvalidTenantShardSize        = errors.New("invalid tenant shard size. The value must be greater than or equal to 0")
+	errInvalidLabelCardinalityWindow = errors.New("invalid label cardinality window. The value must be greater than 0")
 )
 
 const (
@@ -99,6 +100,11 @@ type Distributor struct {
 	// For handling HA replicas.
 	HATracker *ha.HATracker
 
+	tenantMigrations *tenantMigrations
+
+	// Tracks the labels cardinality of the tenants with a max label cardinality.
+	labelCardinality *labelCardinalityTracker
+
 	// Per-user rate limiter.
 	ingestionRateLimiter                *limiter.RateLimiter
 	nativeHistogramIngestionRateLimiter *limiter.RateLimiter
@@ -151,6 +157,8 @@ type Config struct {
 
 	HATrackerConfig ha.HATrackerConfig `yaml:"ha_tracker"`
 
+	TenantMigration TenantMigrationConfig `yaml:"tenant_migration"`
+
 	MaxRecvMsgSize     int           `yaml:"max_recv_msg_size"`
 	OTLPMaxRecvMsgSize int           `yaml:"otlp_max_recv_msg_size"`
 	RemoteTimeout      time.Duration `yaml:"remote_timeout"`
@@ -164,6 +172,7 @@ type Config struct {
 	UseStreamPush                       bool                         `yaml:"use_stream_push"`
 	RemoteWriteV2Enabled                bool                         `yaml:"remote_writev2_enabled"`
 	AcceptUnknownRemoteWriteContentType bool                         `yaml:"accept_unknown_remote_write_content_type"`
+	LabelCardinalityWindow              time.Duration                `yaml:"label_cardinality_window"`
 
 	// Distributors ring
 	DistributorRing RingConfig `yaml:"ring"`
@@ -219,6 +228,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.PoolConfig.RegisterFlags(f)
 	cfg.HATrackerConfig.RegisterFlagsWithPrefix("distributor.", "", f)
 	cfg.DistributorRing.RegisterFlags(f)
+	cfg.TenantMigration.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "remote_write API max receive message size (bytes).")
 	f.IntVar(&cfg.OTLPMaxRecvMsgSize, "distributor.otlp-max-recv-msg-size", 100<<20, "Maximum OTLP request size in bytes that the Distributor can accept.")
@@ -234,6 +244,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.NumPushWorkers, "distributor.num-push-workers", 0, "EXPERIMENTAL: Number of go routines to handle push calls from distributors to ingesters. When no workers are available, a new goroutine will be spawned automatically. If set to 0 (default), workers are disabled, and a new goroutine will be created for each push request.")
 	f.IntVar(&cfg.NumQueryWorkers, "distributor.num-query-workers", 0, "EXPERIMENTAL: Number of go routines to handle query fan-out calls from distributors (queriers and rulers) to ingesters. When no workers are available, a new goroutine will be spawned automatically. If set to 0 (default), workers are disabled, and a new goroutine will be created for each query request.")
 	f.BoolVar(&cfg.RemoteWriteV2Enabled, "distributor.remote-writev2-enabled", false, "EXPERIMENTAL: If true, accept prometheus remote write v2 protocol push request.")
+	f.DurationVar(&cfg.LabelCardinalityWindow, "distributor.label-cardinality-window", time.Hour, "EXPERIMENTAL: Time window over which the distinct values of each label are counted to enforce -distributor.max-label-cardinality. A label exceeding the limit stays limited until the end of the next window.")
 	f.BoolVar(&cfg.AcceptUnknownRemoteWriteContentType, "distributor.accept-unknown-remote-write-content-type", false, "If true, treat requests with unknown or invalid Content-Type header as remote write v1 (legacy behavior). If false, return 415 Unsupported Media Type for non-standard content types.")
 
 	f.Float64Var(&cfg.InstanceLimits.MaxIngestionRate, "distributor.instance-limits.max-ingestion-rate", 0, "Max ingestion rate (samples/sec) that this distributor will accept. This limit is per-distributor, not per-tenant. Additional push requests will be rejected. Current ingestion rate is computed as exponentially weighted moving average, updated every second. 0 = unlimited.")
@@ -261,6 +272,14 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return err
 	}
 
+	if err := cfg.TenantMigration.Validate(cfg.ShardingStrategy); err != nil {
+		return err
+	}
+
+	if cfg.LabelCardinalityWindow <= 0 {
+		return errInvalidLabelCardinalityWindow
+	}
+
 	return nil
 }
 
@@ -289,8 +308,15 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		return nil, err
 	}
 
+	tenantMigrations, err := newTenantMigrations(cfg.TenantMigration, cfg.DistributorRing.InstanceID, limits, prometheus.WrapRegistererWithPrefix("cortex_", reg), log)
+	if err != nil {
+		return nil, err
+	}
+
+	labelCardinality := newLabelCardinalityTracker(cfg.LabelCardinalityWindow, limits, reg)
+
 	subservices := []services.Service(nil)
-	subservices = append(subservices, haTracker)
+	subservices = append(subservices, haTracker, tenantMigrations, labelCardinality)
 
 	// Create the configured ingestion rate limit strategy (local or global). In case
 	// it's an internal dependency and can't join the distributors ring, we skip rate
@@ -333,6 +359,8 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		ingestionRateLimiter:                limiter.NewRateLimiter(ingestionRateStrategy, 10*time.Second),
 		nativeHistogramIngestionRateLimiter: limiter.NewRateLimiter(nativeHistogramIngestionRateStrategy, 10*time.Second),
 		HATracker:                           haTracker,
+		tenantMigrations:                    tenantMigrations,
+		labelCardinality:                    labelCardinality,
 		ingestionRate:                       util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
 
 		queryDuration: instrument.NewHistogramCollector(promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
@@ -495,6 +523,7 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 
 	d.replicationFactor.Set(float64(ingestersRing.ReplicationFactor()))
 	d.activeUsers = users.NewActiveUsersCleanupWithDefaultValues(d.cleanupInactiveUser)
+	d.tenantMigrations.runMigration = d.runTenantMigration
 
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
 	d.subservices, err = services.NewManager(subservices...)
@@ -547,6 +576,7 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.ingestersRing.CleanupShuffleShardCache(userID)
 
 	d.HATracker.CleanupHATrackerMetricsForUser(userID)
+	d.labelCardinality.cleanupUser(userID)
 
 	d.receivedSamples.DeleteLabelValues(userID, sampleMetricTypeFloat)
 	d.receivedSamples.DeleteLabelValues(userID, sampleMetricTypeHistogram)
@@ -883,7 +913,7 @@ func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*co
 
 	// Obtain a subring if required.
 	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
-		subRing = d.ingestersRing.ShuffleShard(userID, limits.IngestionTenantShardSize)
+		subRing = d.ingestersRing.ShuffleShard(userID, d.tenantMigrations.writeShardSize(userID, limits.IngestionTenantShardSize))
 	}
 
 	keys := append(seriesKeys, metadataKeys...)
@@ -1163,6 +1193,17 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 			removeLabel(labelName, &ts.Labels)
 		}
 
+		if limits.MaxLabelCardinality > 0 {
+			if err := d.applyLabelCardinalityLimit(userID, limits, ts); err != nil {
+				if firstPartialErr == nil {
+					firstPartialErr = httpgrpc.Errorf(http.StatusBadRequest, "%s", err.Error())
+				}
+				d.validateMetrics.DiscardedSamples.WithLabelValues(validation.LabelCardinalityLimitExceeded, userID).Add(float64(len(ts.Samples) + len(ts.Histograms)))
+				d.validateMetrics.DiscardedExemplars.WithLabelValues(validation.LabelCardinalityLimitExceeded, userID).Add(float64(len(ts.Exemplars)))
+				continue
+			}
+		}
+
 		// Reject series with missing or empty metric name before removeEmptyLabels (which would strip __name__="").
 		if validationErr, reason := validation.ValidateMetricName(limits, ts.Labels, d.cfg.NameValidationScheme); reason != "" {
 			samplesCount := float64(len(ts.Samples) + len(ts.Histograms))
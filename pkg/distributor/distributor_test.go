@@ -3263,6 +3263,8 @@ type prepConfig struct {
 	useStreamPush                bool
 	nameValidationScheme         model.ValidationScheme
 	remoteTimeout                time.Duration
+	tenantMigrationEnabled       bool
+	tenantMigrationSwitchDelay   time.Duration
 }
 
 type prepState struct {
@@ -3354,6 +3356,13 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 		return ingestersByAddr[addr], nil
 	}
 
+	var tenantMigrationsStore kv.Client
+	if cfg.tenantMigrationEnabled {
+		var closer io.Closer
+		tenantMigrationsStore, closer = consul.NewInMemoryClient(GetTenantMigrationDescCodec(), log.NewNopLogger(), nil)
+		tb.Cleanup(func() { assert.NoError(tb, closer.Close()) })
+	}
+
 	distributors := make([]*Distributor, 0, cfg.numDistributors)
 	registries := make([]*prometheus.Registry, 0, cfg.numDistributors)
 	for i := 0; i < cfg.numDistributors; i++ {
@@ -3408,6 +3417,16 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 			cfg.limits.HATrackerFailoverTimeout = model.Duration(time.Hour)
 		}
 
+		if cfg.tenantMigrationEnabled {
+			distributorCfg.TenantMigration = TenantMigrationConfig{
+				Enabled:          true,
+				SwitchDelay:      cfg.tenantMigrationSwitchDelay,
+				HeartbeatPeriod:  time.Second,
+				HeartbeatTimeout: time.Minute,
+				KVStore:          kv.Config{Mock: tenantMigrationsStore},
+			}
+		}
+
 		distributorCfg.RemoteWriteV2Enabled = cfg.remoteWriteV2Enabled
 
 		overrides := validation.NewOverrides(*cfg.limits, nil)
@@ -3707,6 +3726,7 @@ type mockIngester struct {
 	calls                 map[string]int
 	lblsValues            []string
 	lastDiscardOutOfOrder bool
+	imported              map[string]int
 }
 
 func newMockIngester(id int, ps *prepState, cfg prepConfig) *mockIngester {
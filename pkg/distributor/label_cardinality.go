@@ -0,0 +1,389 @@
+package distributor
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	// labelCardinalityReplacementValue is the value of the labels exceeding the max label
+	// cardinality, when the replace action is configured.
+	labelCardinalityReplacementValue = "__cardinality_exceeded__"
+
+	// labelCardinalityRotateInterval is how often the tracker checks for expired windows.
+	labelCardinalityRotateInterval = time.Minute
+)
+
+type labelCardinalityKey struct {
+	metricName string
+	labelName  string
+}
+
+// labelCardinality tracks the distinct values of a label of a metric, using a HyperLogLog sketch.
+type labelCardinality struct {
+	mtx sync.Mutex
+
+	current     *hyperloglog.Sketch
+	windowStart time.Time
+
+	// Estimated cardinality of the previous window, so that a label exceeding the limit
+	// stays limited until the end of the next window.
+	previousEstimate uint64
+
+	// Estimated cardinality of the current window as of the last estimation, and number of
+	// values observed since then. The estimation is expensive, so the sketch is estimated
+	// only when the limit could have been exceeded.
+	estimate uint64
+	pending  uint64
+
+	exceeded      bool
+	exceededSince time.Time
+	limitedSeries uint64
+}
+
+func newLabelCardinality(now time.Time) *labelCardinality {
+	return &labelCardinality{
+		current:     hyperloglog.New14(),
+		windowStart: now,
+	}
+}
+
+// observe adds the value to the sketch, and returns whether the label exceeds the limit.
+func (c *labelCardinality) observe(value string, limit int, now time.Time) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.current.InsertHash(xxhash.Sum64String(value))
+
+	// Within a window the estimation can only grow, so once exceeded
+	// the label stays limited until the window is rotated.
+	if !c.exceeded {
+		c.pending++
+		if c.previousEstimate > uint64(limit) || c.estimate+c.pending > uint64(limit) {
+			c.estimate = c.current.Estimate()
+			c.pending = 0
+
+			if max(c.estimate, c.previousEstimate) > uint64(limit) {
+				c.exceeded = true
+				c.exceededSince = now
+			}
+		}
+	}
+
+	if c.exceeded {
+		c.limitedSeries++
+	}
+	return c.exceeded
+}
+
+// rotate starts a new window if the current one has expired, and returns whether no value
+// has been observed during the expired window.
+func (c *labelCardinality) rotate(now time.Time, window time.Duration, limit int) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if now.Sub(c.windowStart) < window {
+		return false
+	}
+
+	c.previousEstimate = c.current.Estimate()
+	c.current = hyperloglog.New14()
+	c.windowStart = now
+	c.estimate = 0
+	c.pending = 0
+
+	c.exceeded = limit > 0 && c.previousEstimate > uint64(limit)
+	if !c.exceeded {
+		c.exceededSince = time.Time{}
+		c.limitedSeries = 0
+	}
+
+	return c.previousEstimate == 0
+}
+
+type userLabelCardinality struct {
+	mtx    sync.RWMutex
+	labels map[labelCardinalityKey]*labelCardinality
+}
+
+// labelCardinalityTracker tracks, for each tenant with a max label cardinality, the number of distinct
+// values of each label name per metric name observed over a window.
+type labelCardinalityTracker struct {
+	services.Service
+
+	window time.Duration
+	limits *validation.Overrides
+
+	mtx   sync.RWMutex
+	users map[string]*userLabelCardinality
+
+	limitedSeries *prometheus.CounterVec
+}
+
+func newLabelCardinalityTracker(window time.Duration, limits *validation.Overrides, reg prometheus.Registerer) *labelCardinalityTracker {
+	t := &labelCardinalityTracker{
+		window: window,
+		limits: limits,
+		users:  map[string]*userLabelCardinality{},
+
+		limitedSeries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_label_cardinality_limited_series_total",
+			Help: "The total number of series with a label exceeding the max label cardinality, by applied action.",
+		}, []string{"user", "action"}),
+	}
+
+	t.Service = services.NewTimerService(min(window, labelCardinalityRotateInterval), nil, t.iteration, nil).WithName("label cardinality tracker")
+	return t
+}
+
+func (t *labelCardinalityTracker) iteration(_ context.Context) error {
+	t.rotate(time.Now())
+	return nil
+}
+
+// rotate starts a new window for the labels with an expired one, and removes the labels
+// and tenants without values observed during their last window.
+func (t *labelCardinalityTracker) rotate(now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for userID, u := range t.users {
+		limit := t.limits.MaxLabelCardinality(userID)
+
+		u.mtx.Lock()
+		for key, c := range u.labels {
+			if c.rotate(now, t.window, limit) {
+				delete(u.labels, key)
+			}
+		}
+		empty := len(u.labels) == 0
+		u.mtx.Unlock()
+
+		if empty {
+			delete(t.users, userID)
+		}
+	}
+}
+
+// observe adds the label value to the tracked ones, and returns whether the label exceeds the limit.
+func (t *labelCardinalityTracker) observe(userID, metricName, labelName, value string, limit int, now time.Time) bool {
+	key := labelCardinalityKey{metricName: metricName, labelName: labelName}
+	u := t.getOrCreateUser(userID)
+
+	u.mtx.RLock()
+	c := u.labels[key]
+	u.mtx.RUnlock()
+
+	if c == nil {
+		u.mtx.Lock()
+		if c = u.labels[key]; c == nil {
+			c = newLabelCardinality(now)
+			// The label names reference the request buffer, which is reused.
+			u.labels[labelCardinalityKey{metricName: strings.Clone(metricName), labelName: strings.Clone(labelName)}] = c
+		}
+		u.mtx.Unlock()
+	}
+
+	return c.observe(value, limit, now)
+}
+
+func (t *labelCardinalityTracker) getOrCreateUser(userID string) *userLabelCardinality {
+	t.mtx.RLock()
+	u := t.users[userID]
+	t.mtx.RUnlock()
+
+	if u != nil {
+		return u
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if u = t.users[userID]; u == nil {
+		u = &userLabelCardinality{labels: map[labelCardinalityKey]*labelCardinality{}}
+		t.users[userID] = u
+	}
+	return u
+}
+
+func (t *labelCardinalityTracker) cleanupUser(userID string) {
+	t.mtx.Lock()
+	delete(t.users, userID)
+	t.mtx.Unlock()
+
+	for _, action := range []string{validation.LabelCardinalityActionDrop, validation.LabelCardinalityActionReplace, validation.LabelCardinalityActionReject} {
+		t.limitedSeries.DeleteLabelValues(userID, action)
+	}
+}
+
+type labelCardinalityViolation struct {
+	UserID               string    `json:"user_id"`
+	MetricName           string    `json:"metric_name"`
+	LabelName            string    `json:"label_name"`
+	EstimatedCardinality uint64    `json:"estimated_cardinality"`
+	Limit                int       `json:"limit"`
+	Action               string    `json:"action"`
+	ExceededSince        time.Time `json:"exceeded_since"`
+	LimitedSeries        uint64    `json:"limited_series"`
+}
+
+// violations returns the labels currently exceeding the max label cardinality, optionally filtered by tenant.
+func (t *labelCardinalityTracker) violations(userID string) []labelCardinalityViolation {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	violations := []labelCardinalityViolation{}
+	for user, u := range t.users {
+		if userID != "" && user != userID {
+			continue
+		}
+
+		u.mtx.RLock()
+		for key, c := range u.labels {
+			c.mtx.Lock()
+			if c.exceeded {
+				violations = append(violations, labelCardinalityViolation{
+					UserID:               user,
+					MetricName:           key.metricName,
+					LabelName:            key.labelName,
+					EstimatedCardinality: max(c.current.Estimate(), c.previousEstimate),
+					Limit:                t.limits.MaxLabelCardinality(user),
+					Action:               t.limits.LabelCardinalityAction(user),
+					ExceededSince:        c.exceededSince,
+					LimitedSeries:        c.limitedSeries,
+				})
+			}
+			c.mtx.Unlock()
+		}
+		u.mtx.RUnlock()
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		if a.MetricName != b.MetricName {
+			return a.MetricName < b.MetricName
+		}
+		return a.LabelName < b.LabelName
+	})
+	return violations
+}
+
+// applyLabelCardinalityLimit observes the values of the series labels, and applies the tenant's action
+// to the labels exceeding the max label cardinality. It returns an error if the series must be rejected.
+func (d *Distributor) applyLabelCardinalityLimit(userID string, limits *validation.Limits, ts *cortexpb.PreallocTimeseries) error {
+	metricName := ""
+	for _, l := range ts.Labels {
+		if l.Name == labels.MetricName {
+			metricName = l.Value
+			break
+		}
+	}
+	if metricName == "" {
+		// The series is rejected by the validation.
+		return nil
+	}
+
+	now := time.Now()
+	limited := false
+
+	for i := 0; i < len(ts.Labels); i++ {
+		l := ts.Labels[i]
+		if l.Name == labels.MetricName || !d.labelCardinality.observe(userID, metricName, l.Name, l.Value, limits.MaxLabelCardinality, now) {
+			continue
+		}
+		limited = true
+
+		switch limits.LabelCardinalityAction {
+		case validation.LabelCardinalityActionReject:
+			d.labelCardinality.limitedSeries.WithLabelValues(userID, validation.LabelCardinalityActionReject).Inc()
+			return fmt.Errorf("label %s of metric %s exceeds the max label cardinality of %d distinct values", l.Name, metricName, limits.MaxLabelCardinality)
+		case validation.LabelCardinalityActionReplace:
+			ts.Labels[i].Value = labelCardinalityReplacementValue
+		default:
+			ts.Labels = append(ts.Labels[:i], ts.Labels[i+1:]...)
+			i--
+		}
+	}
+
+	if limited {
+		d.labelCardinality.limitedSeries.WithLabelValues(userID, limits.LabelCardinalityAction).Inc()
+	}
+	return nil
+}
+
+const labelCardinalityViolationsTpl = `
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Cortex Label Cardinality Violations</title>
+	</head>
+	<body>
+		<h1>Cortex Label Cardinality Violations</h1>
+		<p>Current time: {{ .Now }}</p>
+		<p>Labels exceeding the max label cardinality over the last {{ .Window }}, as observed by this distributor.</p>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>User ID</th>
+					<th>Metric Name</th>
+					<th>Label Name</th>
+					<th>Estimated Cardinality</th>
+					<th>Limit</th>
+					<th>Action</th>
+					<th>Exceeded Since</th>
+					<th>Limited Series</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .Violations }}
+				<tr>
+					<td>{{ .UserID }}</td>
+					<td>{{ .MetricName }}</td>
+					<td>{{ .LabelName }}</td>
+					<td>{{ .EstimatedCardinality }}</td>
+					<td>{{ .Limit }}</td>
+					<td>{{ .Action }}</td>
+					<td>{{ .ExceededSince }}</td>
+					<td>{{ .LimitedSeries }}</td>
+				</tr>
+				{{ end }}
+			</tbody>
+		</table>
+	</body>
+</html>`
+
+var labelCardinalityViolationsTmpl = template.Must(template.New("label-cardinality-violations").Parse(labelCardinalityViolationsTpl))
+
+// LabelCardinalityViolationsHandler shows the labels exceeding the max label cardinality.
+func (d *Distributor) LabelCardinalityViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	util.RenderHTTPResponse(w, struct {
+		Now        time.Time                   `json:"now"`
+		Window     time.Duration               `json:"window"`
+		Violations []labelCardinalityViolation `json:"violations"`
+	}{
+		Now:        time.Now(),
+		Window:     d.cfg.LabelCardinalityWindow,
+		Violations: d.labelCardinality.violations(r.FormValue("tenant")),
+	}, labelCardinalityViolationsTmpl, r)
+}
@@ -0,0 +1,203 @@
+package distributor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestLabelCardinality_ObserveAndRotate(t *testing.T) {
+	now := time.Now()
+	c := newLabelCardinality(now)
+
+	for i := range 10 {
+		assert.False(t, c.observe(fmt.Sprintf("value-%d", i), 10, now))
+	}
+
+	// Observing again the same values doesn't exceed the limit.
+	for i := range 10 {
+		assert.False(t, c.observe(fmt.Sprintf("value-%d", i), 10, now))
+	}
+
+	assert.True(t, c.observe("value-10", 10, now))
+	assert.True(t, c.observe("value-0", 10, now))
+	assert.Equal(t, now, c.exceededSince)
+	assert.Equal(t, uint64(2), c.limitedSeries)
+
+	// The window hasn't expired yet.
+	assert.False(t, c.rotate(now.Add(time.Minute), time.Hour, 10))
+	assert.True(t, c.exceeded)
+
+	// The label stays limited during the window following the one which exceeded the limit.
+	assert.False(t, c.rotate(now.Add(time.Hour), time.Hour, 10))
+	assert.True(t, c.exceeded)
+	assert.Equal(t, now, c.exceededSince)
+	assert.True(t, c.observe("value-0", 10, now.Add(time.Hour)))
+
+	// A single value has been observed during the last window.
+	assert.False(t, c.rotate(now.Add(2*time.Hour), time.Hour, 10))
+	assert.False(t, c.exceeded)
+	assert.True(t, c.exceededSince.IsZero())
+	assert.Equal(t, uint64(0), c.limitedSeries)
+	assert.False(t, c.observe("value-0", 10, now.Add(2*time.Hour)))
+
+	// A single value has been observed during the last window, then nothing.
+	assert.False(t, c.rotate(now.Add(3*time.Hour), time.Hour, 10))
+	assert.True(t, c.rotate(now.Add(4*time.Hour), time.Hour, 10))
+}
+
+func TestLabelCardinalityTracker_Rotate(t *testing.T) {
+	overrides := validation.NewOverrides(validation.Limits{MaxLabelCardinality: 1, LabelCardinalityAction: validation.LabelCardinalityActionDrop}, nil)
+	tracker := newLabelCardinalityTracker(time.Hour, overrides, prometheus.NewPedanticRegistry())
+
+	now := time.Now()
+	assert.False(t, tracker.observe("user-1", "metric", "id", "1", 1, now))
+	assert.True(t, tracker.observe("user-1", "metric", "id", "2", 1, now))
+	assert.False(t, tracker.observe("user-2", "metric", "id", "1", 1, now))
+
+	violations := tracker.violations("")
+	require.Len(t, violations, 1)
+	assert.Equal(t, labelCardinalityViolation{
+		UserID:               "user-1",
+		MetricName:           "metric",
+		LabelName:            "id",
+		EstimatedCardinality: 2,
+		Limit:                1,
+		Action:               validation.LabelCardinalityActionDrop,
+		ExceededSince:        now,
+		LimitedSeries:        1,
+	}, violations[0])
+	assert.Empty(t, tracker.violations("user-2"))
+
+	// user-2 is removed once no value is observed for a whole window.
+	tracker.rotate(now.Add(time.Hour))
+	assert.True(t, tracker.observe("user-1", "metric", "id", "1", 1, now.Add(time.Hour)))
+	tracker.rotate(now.Add(2 * time.Hour))
+
+	tracker.mtx.RLock()
+	assert.Len(t, tracker.users, 1)
+	assert.Contains(t, tracker.users, "user-1")
+	tracker.mtx.RUnlock()
+
+	tracker.rotate(now.Add(3 * time.Hour))
+
+	tracker.mtx.RLock()
+	assert.Empty(t, tracker.users)
+	tracker.mtx.RUnlock()
+}
+
+func TestDistributor_Push_LabelCardinalityLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		action         string
+		expectedSeries []labels.Labels
+		expectedErr    error
+	}{
+		"drop": {
+			action: validation.LabelCardinalityActionDrop,
+			expectedSeries: []labels.Labels{
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "1", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "2", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "job", "test"),
+			},
+		},
+		"replace": {
+			action: validation.LabelCardinalityActionReplace,
+			expectedSeries: []labels.Labels{
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "1", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "2", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "id", labelCardinalityReplacementValue, "job", "test"),
+			},
+		},
+		"reject": {
+			action: validation.LabelCardinalityActionReject,
+			expectedSeries: []labels.Labels{
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "1", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "2", "job", "test"),
+			},
+			expectedErr: httpgrpc.Errorf(http.StatusBadRequest, "label id of metric metric_1 exceeds the max label cardinality of 2 distinct values"),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.MaxLabelCardinality = 2
+			limits.LabelCardinalityAction = testData.action
+
+			ds, ingesters, regs, _ := prepare(t, prepConfig{
+				numIngesters:     2,
+				happyIngesters:   2,
+				numDistributors:  1,
+				shardByAllLabels: true,
+				limits:           &limits,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "user")
+			req := mockWriteRequest([]labels.Labels{
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "1", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "2", "job", "test"),
+				labels.FromStrings(labels.MetricName, "metric_1", "id", "3", "job", "test"),
+			}, 1, 1, false)
+			_, err := ds[0].Push(ctx, req)
+			if testData.expectedErr != nil {
+				require.Equal(t, testData.expectedErr, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var actualSeries []labels.Labels
+			for _, series := range ingesters[0].series() {
+				actualSeries = append(actualSeries, cortexpb.FromLabelAdaptersToLabels(series.Labels))
+			}
+			assert.ElementsMatch(t, testData.expectedSeries, actualSeries)
+
+			expectedMetrics := fmt.Sprintf(`
+				# HELP cortex_distributor_label_cardinality_limited_series_total The total number of series with a label exceeding the max label cardinality, by applied action.
+				# TYPE cortex_distributor_label_cardinality_limited_series_total counter
+				cortex_distributor_label_cardinality_limited_series_total{action="%s",user="user"} 1
+			`, testData.action)
+			require.NoError(t, testutil.GatherAndCompare(regs[0], bytes.NewBufferString(expectedMetrics), "cortex_distributor_label_cardinality_limited_series_total"))
+
+			// The violation is listed by the distributor.
+			r := httptest.NewRequest(http.MethodGet, "/distributor/cardinality_violations", nil)
+			r.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			ds[0].LabelCardinalityViolationsHandler(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp struct {
+				Violations []labelCardinalityViolation `json:"violations"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.Len(t, resp.Violations, 1)
+			assert.Equal(t, "user", resp.Violations[0].UserID)
+			assert.Equal(t, "metric_1", resp.Violations[0].MetricName)
+			assert.Equal(t, "id", resp.Violations[0].LabelName)
+			assert.Equal(t, uint64(3), resp.Violations[0].EstimatedCardinality)
+			assert.Equal(t, 2, resp.Violations[0].Limit)
+			assert.Equal(t, testData.action, resp.Violations[0].Action)
+		})
+	}
+}
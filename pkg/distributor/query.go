@@ -91,7 +91,7 @@ func (d *Distributor) GetIngestersForQuery(ctx context.Context, matchers ...*lab
 	// If shuffle sharding is enabled we should only query ingesters which are
 	// part of the tenant's subring.
 	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
-		shardSize := d.limits.IngestionTenantShardSize(userID)
+		shardSize := d.tenantMigrations.readShardSize(userID, d.limits.IngestionTenantShardSize(userID))
 		lookbackPeriod := d.limits.ShuffleShardingIngestersLookbackPeriod(userID)
 
 		if shardSize > 0 && lookbackPeriod > 0 {
@@ -122,7 +122,7 @@ func (d *Distributor) GetIngestersForMetadata(ctx context.Context) (ring.Replica
 	// If shuffle sharding is enabled we should only query ingesters which are
 	// part of the tenant's subring.
 	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
-		shardSize := d.limits.IngestionTenantShardSize(userID)
+		shardSize := d.tenantMigrations.readShardSize(userID, d.limits.IngestionTenantShardSize(userID))
 		lookbackPeriod := d.limits.ShuffleShardingIngestersLookbackPeriod(userID)
 
 		if shardSize > 0 && lookbackPeriod > 0 {
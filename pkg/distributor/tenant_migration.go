@@ -0,0 +1,792 @@
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/weaveworks/common/user"
+
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+var (
+	errTenantMigrationInProgress = errors.New("a migration of the tenant is already in progress")
+	errTenantMigrationNotFound   = errors.New("no migration found for the tenant")
+	errTenantMigrationNoop       = errors.New("the tenant is already using the requested shard size")
+	errTenantMigrationAborted    = errors.New("the tenant migration has been deleted or restarted")
+	errTenantMigrationStopping   = errors.New("the distributor is stopping")
+)
+
+// TenantMigrationConfig configures the online migration of tenants between ingester shards.
+type TenantMigrationConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	SwitchDelay      time.Duration `yaml:"switch_delay"`
+	BlocksLoadDelay  time.Duration `yaml:"blocks_load_delay"`
+	HeartbeatPeriod  time.Duration `yaml:"heartbeat_period"`
+	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout"`
+	RetryInterval    time.Duration `yaml:"retry_interval"`
+	KVStore          kv.Config     `yaml:"kvstore" doc:"description=Backend storage to use for the tenant migrations. Supported backends are consul, etcd and multi."`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *TenantMigrationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.tenant-migration.enabled", false, "[Experimental] Enable the online migration of tenants to a different ingesters shard size, through the /distributor/tenant_migrations API. Requires the shuffle-sharding strategy.")
+	f.DurationVar(&cfg.SwitchDelay, "distributor.tenant-migration.switch-delay", time.Minute, "How long to wait after a migration is started before copying the series to the new shard. It must be long enough for all the distributors to observe the migration and write to the new shard.")
+	f.DurationVar(&cfg.BlocksLoadDelay, "distributor.tenant-migration.blocks-load-delay", 70*time.Second, "How long to wait after the series have been copied before completing a migration. It must be longer than the interval at which the ingesters TSDB reload their blocks (1m), so that the copied series are queryable when the queries stop going to the previous shard.")
+	f.DurationVar(&cfg.HeartbeatPeriod, "distributor.tenant-migration.heartbeat-period", 15*time.Second, "How frequently the distributor running a migration updates its heartbeat, and how frequently the distributors check for migrations to resume.")
+	f.DurationVar(&cfg.HeartbeatTimeout, "distributor.tenant-migration.heartbeat-timeout", time.Minute, "A migration whose heartbeat has not been updated for longer than this timeout, e.g. because its distributor has been restarted, is resumed by another distributor.")
+	f.DurationVar(&cfg.RetryInterval, "distributor.tenant-migration.retry-interval", 10*time.Minute, "How long to wait before retrying a failed migration. 0 disables the retries: a failed migration is then only retried when restarted through the API.")
+
+	cfg.KVStore.RegisterFlagsWithPrefix("distributor.tenant-migration.", "tenant-migrations/", f)
+}
+
+// Validate config and returns error on failure.
+func (cfg *TenantMigrationConfig) Validate(shardingStrategy string) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if shardingStrategy != util.ShardingStrategyShuffle {
+		return errors.New("tenant migration requires the shuffle-sharding strategy")
+	}
+
+	if cfg.HeartbeatPeriod <= 0 || cfg.HeartbeatTimeout <= cfg.HeartbeatPeriod {
+		return errors.New("tenant migration heartbeat period must be positive and lower than the heartbeat timeout")
+	}
+
+	// The migrations are not mergeable, so memberlist is not supported.
+	storeAllowedList := []string{"consul", "etcd", "multi"}
+	if !slices.Contains(storeAllowedList, cfg.KVStore.Store) {
+		return fmt.Errorf("invalid tenant migration KV store type: %s", cfg.KVStore.Store)
+	}
+
+	return nil
+}
+
+// ProtoTenantMigrationDescFactory makes new TenantMigrationDescs.
+func ProtoTenantMigrationDescFactory() proto.Message {
+	return &TenantMigrationDesc{}
+}
+
+// GetTenantMigrationDescCodec returns the codec of the tenant migrations stored in the KV store.
+func GetTenantMigrationDescCodec() codec.Proto {
+	return codec.NewProtoCodec("tenantMigrationDesc", ProtoTenantMigrationDescFactory)
+}
+
+type runningTenantMigration struct {
+	startedAt int64
+	cancel    context.CancelCauseFunc
+}
+
+// tenantMigrations keeps a local copy of the tenant migrations stored in the KV store, and
+// runs the migrations coordinated by this distributor.
+type tenantMigrations struct {
+	services.Service
+
+	cfg        TenantMigrationConfig
+	instanceID string
+	limits     *validation.Overrides
+	client     kv.Client
+	logger     log.Logger
+
+	// Runs a migration coordinated by this distributor, until it's completed or failed.
+	runMigration func(ctx context.Context, desc TenantMigrationDesc)
+
+	mtx        sync.RWMutex
+	migrations map[string]TenantMigrationDesc
+
+	// Migrations coordinated by this distributor.
+	runningMtx sync.Mutex
+	running    map[string]runningTenantMigration
+	runningWg  sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+}
+
+func newTenantMigrations(cfg TenantMigrationConfig, instanceID string, limits *validation.Overrides, reg prometheus.Registerer, logger log.Logger) (*tenantMigrations, error) {
+	m := &tenantMigrations{
+		cfg:        cfg,
+		instanceID: instanceID,
+		limits:     limits,
+		logger:     logger,
+		migrations: map[string]TenantMigrationDesc{},
+		running:    map[string]runningTenantMigration{},
+	}
+	m.ctx, m.cancel = context.WithCancelCause(context.Background())
+
+	if cfg.Enabled {
+		client, err := kv.NewClient(cfg.KVStore, GetTenantMigrationDescCodec(), kv.RegistererWithKVName(reg, "distributor-tenant-migrations"), logger)
+		if err != nil {
+			return nil, err
+		}
+		m.client = client
+	}
+
+	m.Service = services.NewBasicService(m.starting, m.loop, m.stopping)
+	return m, nil
+}
+
+// starting fetches the existing migrations, so that the distributor uses the right shard
+// sizes as soon as it's running.
+func (m *tenantMigrations) starting(ctx context.Context) error {
+	if !m.cfg.Enabled {
+		return nil
+	}
+
+	keys, err := m.client.List(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to list tenant migrations")
+	}
+
+	for _, key := range keys {
+		val, err := m.client.Get(ctx, key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get tenant migration %s", key)
+		}
+		if desc, ok := val.(*TenantMigrationDesc); ok && desc != nil {
+			m.update(key, desc)
+		}
+	}
+
+	return nil
+}
+
+func (m *tenantMigrations) loop(ctx context.Context) error {
+	if !m.cfg.Enabled {
+		<-ctx.Done()
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		// The KV client is prefixed, so we can pass empty string here.
+		m.client.WatchPrefix(ctx, "", func(key string, value any) bool {
+			if desc, ok := value.(*TenantMigrationDesc); ok && desc != nil {
+				m.update(key, desc)
+			}
+			return true
+		})
+	}()
+
+	ticker := time.NewTicker(m.cfg.HeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.resumeMigrations(ctx)
+			m.removeCompletedMigrations(ctx)
+		}
+	}
+}
+
+// resumable returns whether the migration is left over by its coordinator, either because it failed
+// long enough ago to be retried, or because the coordinator stopped updating its heartbeat.
+func (m *tenantMigrations) resumable(desc TenantMigrationDesc, now time.Time) bool {
+	if desc.DeletedAt > 0 {
+		return false
+	}
+
+	idle := now.Sub(time.UnixMilli(desc.UpdatedAt))
+	switch desc.State {
+	case TenantMigrationState_COPYING:
+		return idle > m.cfg.HeartbeatTimeout
+	case TenantMigrationState_FAILED:
+		return m.cfg.RetryInterval > 0 && idle > m.cfg.RetryInterval
+	default:
+		return false
+	}
+}
+
+// resumeMigrations takes over the migrations left over by their coordinator, and runs them. The series
+// are copied again from the beginning: the ingesters only add the copied series once the import stream
+// is complete, so an interrupted copy leaves nothing behind.
+func (m *tenantMigrations) resumeMigrations(ctx context.Context) {
+	now := time.Now()
+
+	for _, desc := range m.list() {
+		if !m.resumable(desc, now) || m.isRunning(desc.UserId) {
+			continue
+		}
+
+		var resumed TenantMigrationDesc
+		err := m.client.CAS(ctx, desc.UserId, func(in any) (out any, retry bool, err error) {
+			existing, ok := in.(*TenantMigrationDesc)
+			if !ok || existing == nil || existing.StartedAt != desc.StartedAt || !m.resumable(*existing, time.Now()) {
+				// Another distributor took over the migration, or it has been updated meanwhile.
+				return nil, false, nil
+			}
+
+			existing.State = TenantMigrationState_COPYING
+			existing.Coordinator = m.instanceID
+			existing.UpdatedAt = time.Now().UnixMilli()
+			existing.SourcesTotal = 0
+			existing.SourcesDone = 0
+			existing.SeriesCopied = 0
+			existing.SamplesCopied = 0
+			existing.Error = ""
+			resumed = *existing
+			return existing, true, nil
+		})
+		if err != nil {
+			level.Warn(m.logger).Log("msg", "failed to resume tenant migration", "user", desc.UserId, "err", err)
+			continue
+		}
+		if resumed.Coordinator != m.instanceID {
+			continue
+		}
+
+		level.Info(m.logger).Log("msg", "resuming tenant migration", "user", desc.UserId, "previous_coordinator", desc.Coordinator, "previous_state", desc.State.String())
+		m.run(resumed, func(ctx context.Context) {
+			m.runMigration(ctx, resumed)
+		})
+	}
+}
+
+// removeCompletedMigrations removes the completed migrations of the tenants whose shard size limit has
+// been updated to the new shard size: the limit applies again from then on.
+func (m *tenantMigrations) removeCompletedMigrations(ctx context.Context) {
+	for _, desc := range m.list() {
+		if desc.State != TenantMigrationState_COMPLETED || int(desc.ToShardSize) != m.limits.IngestionTenantShardSize(desc.UserId) {
+			continue
+		}
+
+		err := m.client.CAS(ctx, desc.UserId, func(in any) (out any, retry bool, err error) {
+			existing, ok := in.(*TenantMigrationDesc)
+			if !ok || existing == nil || existing.DeletedAt > 0 || existing.StartedAt != desc.StartedAt || existing.State != TenantMigrationState_COMPLETED {
+				return nil, false, nil
+			}
+
+			existing.DeletedAt = time.Now().UnixMilli()
+			return existing, true, nil
+		})
+		if err != nil {
+			level.Warn(m.logger).Log("msg", "failed to remove completed tenant migration", "user", desc.UserId, "err", err)
+		}
+	}
+}
+
+func (m *tenantMigrations) isRunning(userID string) bool {
+	m.runningMtx.Lock()
+	defer m.runningMtx.Unlock()
+
+	_, ok := m.running[userID]
+	return ok
+}
+
+func (m *tenantMigrations) stopping(_ error) error {
+	m.cancel(errTenantMigrationStopping)
+	m.runningWg.Wait()
+	return nil
+}
+
+func (m *tenantMigrations) update(userID string, desc *TenantMigrationDesc) {
+	m.mtx.Lock()
+	if desc.DeletedAt > 0 {
+		delete(m.migrations, userID)
+	} else {
+		m.migrations[userID] = *desc
+	}
+	m.mtx.Unlock()
+
+	// Stop the migration run by this distributor if it has been deleted, restarted or taken over.
+	m.runningMtx.Lock()
+	defer m.runningMtx.Unlock()
+
+	if r, ok := m.running[userID]; ok && (desc.DeletedAt > 0 || desc.StartedAt != r.startedAt || desc.Coordinator != m.instanceID) {
+		r.cancel(errTenantMigrationAborted)
+	}
+}
+
+func (m *tenantMigrations) get(userID string) (TenantMigrationDesc, bool) {
+	if !m.cfg.Enabled {
+		return TenantMigrationDesc{}, false
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	desc, ok := m.migrations[userID]
+	return desc, ok
+}
+
+func (m *tenantMigrations) list() []TenantMigrationDesc {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	descs := make([]TenantMigrationDesc, 0, len(m.migrations))
+	for _, desc := range m.migrations {
+		descs = append(descs, desc)
+	}
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].UserId < descs[j].UserId
+	})
+	return descs
+}
+
+// writeShardSize returns the ingesters shard size to write the tenant series to: as soon as a migration
+// is started, the writes go to the new shard. A completed migration keeps overriding the limit until the
+// limit is updated to the new shard size, then the migration is removed.
+func (m *tenantMigrations) writeShardSize(userID string, limit int) int {
+	desc, ok := m.get(userID)
+	if !ok {
+		return limit
+	}
+	return int(desc.ToShardSize)
+}
+
+// readShardSize returns the ingesters shard size to query the tenant series from. Until the series have
+// been copied to the new shard, the queries go to both the previous and the new shard: thanks to the
+// shuffle-sharding consistency, the largest shard includes the smallest one.
+func (m *tenantMigrations) readShardSize(userID string, limit int) int {
+	desc, ok := m.get(userID)
+	if !ok {
+		return limit
+	}
+	if desc.State == TenantMigrationState_COMPLETED {
+		return int(desc.ToShardSize)
+	}
+	if desc.FromShardSize == 0 || desc.ToShardSize == 0 {
+		return 0
+	}
+	return int(max(desc.FromShardSize, desc.ToShardSize))
+}
+
+// run runs f in background until it returns, the migration is deleted or restarted, or the distributor stops.
+func (m *tenantMigrations) run(desc TenantMigrationDesc, f func(ctx context.Context)) {
+	ctx, cancel := context.WithCancelCause(m.ctx)
+
+	m.runningMtx.Lock()
+	if r, ok := m.running[desc.UserId]; ok {
+		r.cancel(errTenantMigrationAborted)
+	}
+	m.running[desc.UserId] = runningTenantMigration{startedAt: desc.StartedAt, cancel: cancel}
+	m.runningMtx.Unlock()
+
+	m.runningWg.Add(1)
+	go func() {
+		defer m.runningWg.Done()
+		defer func() {
+			m.runningMtx.Lock()
+			if r, ok := m.running[desc.UserId]; ok && r.startedAt == desc.StartedAt {
+				delete(m.running, desc.UserId)
+			}
+			m.runningMtx.Unlock()
+			cancel(nil)
+		}()
+
+		f(ctx)
+	}()
+}
+
+// StartTenantMigration starts the migration of the tenant to the given ingesters shard size, and returns it.
+func (d *Distributor) StartTenantMigration(ctx context.Context, userID string, shardSize int) (TenantMigrationDesc, error) {
+	var desc TenantMigrationDesc
+
+	err := d.tenantMigrations.client.CAS(ctx, userID, func(in any) (out any, retry bool, err error) {
+		from := int32(d.limits.IngestionTenantShardSize(userID))
+		if existing, ok := in.(*TenantMigrationDesc); ok && existing != nil && existing.DeletedAt == 0 {
+			switch existing.State {
+			case TenantMigrationState_COPYING:
+				return nil, false, errTenantMigrationInProgress
+			case TenantMigrationState_FAILED:
+				// The series of the previous shard haven't been fully copied.
+				from = existing.FromShardSize
+			case TenantMigrationState_COMPLETED:
+				from = existing.ToShardSize
+			}
+		}
+		if from == int32(shardSize) {
+			return nil, false, errTenantMigrationNoop
+		}
+
+		now := time.Now().UnixMilli()
+		desc = TenantMigrationDesc{
+			UserId:        userID,
+			FromShardSize: from,
+			ToShardSize:   int32(shardSize),
+			State:         TenantMigrationState_COPYING,
+			StartedAt:     now,
+			UpdatedAt:     now,
+			Coordinator:   d.cfg.DistributorRing.InstanceID,
+		}
+		return &desc, true, nil
+	})
+	if err != nil {
+		return TenantMigrationDesc{}, err
+	}
+
+	d.tenantMigrations.run(desc, func(ctx context.Context) {
+		d.runTenantMigration(ctx, desc)
+	})
+	return desc, nil
+}
+
+// DeleteTenantMigration deletes the migration of the tenant, stopping it if in progress. The tenant
+// is then sharded according to its limits.
+func (d *Distributor) DeleteTenantMigration(ctx context.Context, userID string) error {
+	return d.tenantMigrations.client.CAS(ctx, userID, func(in any) (out any, retry bool, err error) {
+		existing, ok := in.(*TenantMigrationDesc)
+		if !ok || existing == nil || existing.DeletedAt > 0 {
+			return nil, false, errTenantMigrationNotFound
+		}
+
+		existing.DeletedAt = time.Now().UnixMilli()
+		return existing, true, nil
+	})
+}
+
+// runTenantMigration copies the tenant series to their new owners, then marks the migration as completed or failed.
+// The heartbeat of the migration is updated meanwhile, so that another distributor resumes it if this one stops.
+func (d *Distributor) runTenantMigration(ctx context.Context, desc TenantMigrationDesc) {
+	logger := log.With(d.log, "user", desc.UserId, "from_shard_size", desc.FromShardSize, "to_shard_size", desc.ToShardSize)
+	level.Info(logger).Log("msg", "tenant migration started")
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		d.tenantMigrationHeartbeat(heartbeatCtx, desc, cancel, logger)
+	}()
+
+	err := d.copyTenantSeries(ctx, desc)
+	if err == nil {
+		// Wait until the ingesters have loaded the imported blocks.
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(d.cfg.TenantMigration.BlocksLoadDelay):
+		}
+	}
+
+	stopHeartbeat()
+	<-heartbeatDone
+
+	cause := context.Cause(ctx)
+	if errors.Is(err, errTenantMigrationAborted) || errors.Is(cause, errTenantMigrationAborted) {
+		level.Warn(logger).Log("msg", "tenant migration aborted", "err", errTenantMigrationAborted)
+		return
+	}
+	if cause != nil {
+		err = cause
+	}
+
+	// Use a new context, because the migration context may be canceled.
+	updateCtx, cancelUpdate := context.WithTimeout(context.Background(), d.cfg.RemoteTimeout)
+	defer cancelUpdate()
+
+	updateErr := d.updateTenantMigration(updateCtx, desc, func(m *TenantMigrationDesc) {
+		if err != nil {
+			m.State = TenantMigrationState_FAILED
+			m.Error = err.Error()
+			return
+		}
+		m.State = TenantMigrationState_COMPLETED
+		m.CompletedAt = time.Now().UnixMilli()
+	})
+	if updateErr != nil {
+		level.Warn(logger).Log("msg", "failed to update tenant migration state", "err", updateErr)
+	}
+
+	if err != nil {
+		level.Error(logger).Log("msg", "tenant migration failed", "err", err)
+		return
+	}
+	level.Info(logger).Log("msg", "tenant migration completed")
+}
+
+// tenantMigrationHeartbeat periodically updates the heartbeat of the migration until the context is done,
+// and cancels the migration if it has been deleted, restarted or taken over.
+func (d *Distributor) tenantMigrationHeartbeat(ctx context.Context, desc TenantMigrationDesc, cancel context.CancelCauseFunc, logger log.Logger) {
+	ticker := time.NewTicker(d.cfg.TenantMigration.HeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.updateTenantMigration(ctx, desc, func(*TenantMigrationDesc) {})
+			if errors.Is(err, errTenantMigrationAborted) {
+				cancel(errTenantMigrationAborted)
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				level.Warn(logger).Log("msg", "failed to update tenant migration heartbeat", "err", err)
+			}
+		}
+	}
+}
+
+// copyTenantSeries streams the tenant series from the ingesters of the previous shard to the ingesters
+// of the new shard which don't own them yet. Each series is copied by a single one of its previous owners.
+func (d *Distributor) copyTenantSeries(ctx context.Context, desc TenantMigrationDesc) error {
+	// Wait until all the distributors write to the new shard, so that the series
+	// copied from the previous shard are not written to after the copy.
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d.cfg.TenantMigration.SwitchDelay):
+	}
+
+	userID := desc.UserId
+	ctx = user.InjectOrgID(ctx, userID)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fromRing := d.ingestersRing.ShuffleShard(userID, int(desc.FromShardSize))
+	toRing := d.ingestersRing.ShuffleShard(userID, int(desc.ToShardSize))
+
+	sources, err := fromRing.GetAllHealthy(ring.Read)
+	if err != nil {
+		return err
+	}
+	if err := d.updateTenantMigration(ctx, desc, func(m *TenantMigrationDesc) {
+		m.SourcesTotal = int32(len(sources.Instances))
+	}); err != nil {
+		return err
+	}
+
+	// Copy all the series the ingesters hold, including the ones of the blocks not shipped yet or which
+	// haven't been loaded by the store-gateways yet.
+	req := &ingester_client.ExportTenantRequest{StartTimestampMs: math.MinInt64, EndTimestampMs: time.Now().UnixMilli()}
+
+	var (
+		targets                            = map[string]ingester_client.Ingester_ImportTenantClient{}
+		fromDescs, fromHosts, fromZones    = ring.MakeBuffersForGet()
+		toDescs, toHosts, toZones          = ring.MakeBuffersForGet()
+		seriesCopied, seriesCopiedAtUpdate int64
+	)
+
+	for _, source := range sources.Instances {
+		c, err := d.ingesterPool.GetClientFor(source.Addr)
+		if err != nil {
+			return err
+		}
+		stream, err := c.(ingester_client.IngesterClient).ExportTenant(ctx, req)
+		if err != nil {
+			return errors.Wrapf(err, "export from %s", source.Addr)
+		}
+
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return errors.Wrapf(err, "export from %s", source.Addr)
+			}
+
+			batches := map[string][]ingester_client.TimeSeriesChunk{}
+			for _, series := range resp.Chunkseries {
+				token, err := d.tokenForLabels(userID, series.Labels)
+				if err != nil {
+					return err
+				}
+				prevOwners, err := fromRing.Get(token, ring.WriteNoExtend, fromDescs, fromHosts, fromZones)
+				if err != nil {
+					return err
+				}
+				if idx := slices.IndexFunc(prevOwners.Instances, func(i ring.InstanceDesc) bool { return sources.Includes(i.Addr) }); idx < 0 || prevOwners.Instances[idx].Addr != source.Addr {
+					continue
+				}
+				newOwners, err := toRing.Get(token, ring.WriteNoExtend, toDescs, toHosts, toZones)
+				if err != nil {
+					return err
+				}
+				for _, owner := range newOwners.Instances {
+					if !prevOwners.Includes(owner.Addr) {
+						batches[owner.Addr] = append(batches[owner.Addr], series)
+					}
+				}
+			}
+
+			for addr, batch := range batches {
+				target, ok := targets[addr]
+				if !ok {
+					c, err := d.ingesterPool.GetClientFor(addr)
+					if err != nil {
+						return err
+					}
+					target, err = c.(ingester_client.IngesterClient).ImportTenant(ctx)
+					if err != nil {
+						return errors.Wrapf(err, "import to %s", addr)
+					}
+					targets[addr] = target
+				}
+				if err := target.Send(&ingester_client.ImportTenantRequest{Chunkseries: batch}); err != nil {
+					return errors.Wrapf(err, "import to %s", addr)
+				}
+				seriesCopied += int64(len(batch))
+			}
+		}
+
+		if err := d.updateTenantMigration(ctx, desc, func(m *TenantMigrationDesc) {
+			m.SourcesDone++
+			m.SeriesCopied += seriesCopied - seriesCopiedAtUpdate
+		}); err != nil {
+			return err
+		}
+		seriesCopiedAtUpdate = seriesCopied
+	}
+
+	var numSeries, numSamples int64
+	for addr, target := range targets {
+		resp, err := target.CloseAndRecv()
+		if err != nil {
+			return errors.Wrapf(err, "import to %s", addr)
+		}
+		numSeries += resp.Series
+		numSamples += resp.Samples
+	}
+
+	return d.updateTenantMigration(ctx, desc, func(m *TenantMigrationDesc) {
+		m.SeriesCopied = numSeries
+		m.SamplesCopied = numSamples
+	})
+}
+
+// updateTenantMigration applies f to the migration stored in the KV store, unless it has been deleted or restarted since desc.
+func (d *Distributor) updateTenantMigration(ctx context.Context, desc TenantMigrationDesc, f func(m *TenantMigrationDesc)) error {
+	return d.tenantMigrations.client.CAS(ctx, desc.UserId, func(in any) (out any, retry bool, err error) {
+		existing, ok := in.(*TenantMigrationDesc)
+		if !ok || existing == nil || existing.DeletedAt > 0 || existing.StartedAt != desc.StartedAt || existing.Coordinator != desc.Coordinator {
+			return nil, false, errTenantMigrationAborted
+		}
+
+		f(existing)
+		existing.UpdatedAt = time.Now().UnixMilli()
+		return existing, true, nil
+	})
+}
+
+type tenantMigrationStatus struct {
+	UserID        string     `json:"user_id"`
+	FromShardSize int32      `json:"from_shard_size"`
+	ToShardSize   int32      `json:"to_shard_size"`
+	State         string     `json:"state"`
+	Coordinator   string     `json:"coordinator"`
+	StartedAt     time.Time  `json:"started_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	SourcesTotal  int32      `json:"sources_total"`
+	SourcesDone   int32      `json:"sources_done"`
+	SeriesCopied  int64      `json:"series_copied"`
+	SamplesCopied int64      `json:"samples_copied"`
+	Error         string     `json:"error,omitempty"`
+}
+
+func newTenantMigrationStatus(desc TenantMigrationDesc) tenantMigrationStatus {
+	s := tenantMigrationStatus{
+		UserID:        desc.UserId,
+		FromShardSize: desc.FromShardSize,
+		ToShardSize:   desc.ToShardSize,
+		State:         desc.State.String(),
+		Coordinator:   desc.Coordinator,
+		StartedAt:     time.UnixMilli(desc.StartedAt).UTC(),
+		UpdatedAt:     time.UnixMilli(desc.UpdatedAt).UTC(),
+		SourcesTotal:  desc.SourcesTotal,
+		SourcesDone:   desc.SourcesDone,
+		SeriesCopied:  desc.SeriesCopied,
+		SamplesCopied: desc.SamplesCopied,
+		Error:         desc.Error,
+	}
+	if desc.CompletedAt > 0 {
+		completedAt := time.UnixMilli(desc.CompletedAt).UTC()
+		s.CompletedAt = &completedAt
+	}
+	return s
+}
+
+// TenantMigrationHandler lists (GET), starts (POST) and deletes (DELETE) the tenant migrations.
+func (d *Distributor) TenantMigrationHandler(w http.ResponseWriter, r *http.Request) {
+	if !d.cfg.TenantMigration.Enabled {
+		http.Error(w, "tenant migration is disabled", http.StatusNotFound)
+		return
+	}
+
+	userID := r.FormValue("tenant")
+
+	switch r.Method {
+	case http.MethodGet:
+		statuses := []tenantMigrationStatus{}
+		for _, desc := range d.tenantMigrations.list() {
+			if userID == "" || desc.UserId == userID {
+				statuses = append(statuses, newTenantMigrationStatus(desc))
+			}
+		}
+		util.WriteJSONResponse(w, statuses)
+
+	case http.MethodPost:
+		if userID == "" {
+			http.Error(w, "missing tenant", http.StatusBadRequest)
+			return
+		}
+		shardSize, err := strconv.Atoi(r.FormValue("shard_size"))
+		if err != nil || shardSize < 0 {
+			http.Error(w, "invalid shard_size: must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+
+		desc, err := d.StartTenantMigration(r.Context(), userID, shardSize)
+		switch {
+		case errors.Is(err, errTenantMigrationInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errTenantMigrationNoop):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			util.WriteJSONResponse(w, newTenantMigrationStatus(desc))
+		}
+
+	case http.MethodDelete:
+		if userID == "" {
+			http.Error(w, "missing tenant", http.StatusBadRequest)
+			return
+		}
+
+		err := d.DeleteTenantMigration(r.Context(), userID)
+		switch {
+		case errors.Is(err, errTenantMigrationNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,1021 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: tenant_migration.proto
+
+package distributor
+
+import (
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strconv "strconv"
+	strings "strings"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type TenantMigrationState int32
+
+const (
+	// The writes go to the new shard, the queries go to both the previous and the new shard
+	// while the series are copied to their new owners.
+	TenantMigrationState_COPYING TenantMigrationState = 0
+	// The writes and the queries go to the new shard.
+	TenantMigrationState_COMPLETED TenantMigrationState = 1
+	// The copy failed: the writes go to the new shard, the queries go to both shards.
+	TenantMigrationState_FAILED TenantMigrationState = 2
+)
+
+var TenantMigrationState_name = map[int32]string{
+	0: "COPYING",
+	1: "COMPLETED",
+	2: "FAILED",
+}
+
+var TenantMigrationState_value = map[string]int32{
+	"COPYING":   0,
+	"COMPLETED": 1,
+	"FAILED":    2,
+}
+
+func (TenantMigrationState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0aaa9f2a575959a4, []int{0}
+}
+
+// TenantMigrationDesc is the state of the migration of a tenant to a different ingesters shard.
+type TenantMigrationDesc struct {
+	UserId        string               `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FromShardSize int32                `protobuf:"varint,2,opt,name=from_shard_size,json=fromShardSize,proto3" json:"from_shard_size,omitempty"`
+	ToShardSize   int32                `protobuf:"varint,3,opt,name=to_shard_size,json=toShardSize,proto3" json:"to_shard_size,omitempty"`
+	State         TenantMigrationState `protobuf:"varint,4,opt,name=state,proto3,enum=distributor.TenantMigrationState" json:"state,omitempty"`
+	// Unix timestamps in milliseconds.
+	StartedAt   int64 `protobuf:"varint,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	UpdatedAt   int64 `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CompletedAt int64 `protobuf:"varint,7,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	// Instance ID of the distributor copying the series.
+	Coordinator string `protobuf:"bytes,8,opt,name=coordinator,proto3" json:"coordinator,omitempty"`
+	// Progress of the series copy.
+	SourcesTotal  int32  `protobuf:"varint,9,opt,name=sources_total,json=sourcesTotal,proto3" json:"sources_total,omitempty"`
+	SourcesDone   int32  `protobuf:"varint,10,opt,name=sources_done,json=sourcesDone,proto3" json:"sources_done,omitempty"`
+	SeriesCopied  int64  `protobuf:"varint,11,opt,name=series_copied,json=seriesCopied,proto3" json:"series_copied,omitempty"`
+	SamplesCopied int64  `protobuf:"varint,12,opt,name=samples_copied,json=samplesCopied,proto3" json:"samples_copied,omitempty"`
+	Error         string `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
+	// Unix timestamp in milliseconds when this entry was marked for deletion. The entry is
+	// marked rather than deleted, because the deletion doesn't trigger the watch notification
+	// in all KV stores.
+	DeletedAt int64 `protobuf:"varint,14,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+}
+
+func (m *TenantMigrationDesc) Reset()      { *m = TenantMigrationDesc{} }
+func (*TenantMigrationDesc) ProtoMessage() {}
+func (*TenantMigrationDesc) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0aaa9f2a575959a4, []int{0}
+}
+func (m *TenantMigrationDesc) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TenantMigrationDesc) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TenantMigrationDesc.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TenantMigrationDesc) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantMigrationDesc.Merge(m, src)
+}
+func (m *TenantMigrationDesc) XXX_Size() int {
+	return m.Size()
+}
+func (m *TenantMigrationDesc) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantMigrationDesc.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantMigrationDesc proto.InternalMessageInfo
+
+func (m *TenantMigrationDesc) GetUserId() string {
+	if m != nil {
+		return m.UserId
+	}
+	return ""
+}
+
+func (m *TenantMigrationDesc) GetFromShardSize() int32 {
+	if m != nil {
+		return m.FromShardSize
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetToShardSize() int32 {
+	if m != nil {
+		return m.ToShardSize
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetState() TenantMigrationState {
+	if m != nil {
+		return m.State
+	}
+	return TenantMigrationState_COPYING
+}
+
+func (m *TenantMigrationDesc) GetStartedAt() int64 {
+	if m != nil {
+		return m.StartedAt
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetCompletedAt() int64 {
+	if m != nil {
+		return m.CompletedAt
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetCoordinator() string {
+	if m != nil {
+		return m.Coordinator
+	}
+	return ""
+}
+
+func (m *TenantMigrationDesc) GetSourcesTotal() int32 {
+	if m != nil {
+		return m.SourcesTotal
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetSourcesDone() int32 {
+	if m != nil {
+		return m.SourcesDone
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetSeriesCopied() int64 {
+	if m != nil {
+		return m.SeriesCopied
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetSamplesCopied() int64 {
+	if m != nil {
+		return m.SamplesCopied
+	}
+	return 0
+}
+
+func (m *TenantMigrationDesc) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *TenantMigrationDesc) GetDeletedAt() int64 {
+	if m != nil {
+		return m.DeletedAt
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("distributor.TenantMigrationState", TenantMigrationState_name, TenantMigrationState_value)
+	proto.RegisterType((*TenantMigrationDesc)(nil), "distributor.TenantMigrationDesc")
+}
+
+func init() { proto.RegisterFile("tenant_migration.proto", fileDescriptor_0aaa9f2a575959a4) }
+
+var fileDescriptor_0aaa9f2a575959a4 = []byte{
+	// 468 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x92, 0x31, 0x6f, 0xd3, 0x4c,
+	0x18, 0xc7, 0x7d, 0x4d, 0x93, 0xbc, 0x39, 0xc7, 0x79, 0xa3, 0x23, 0x02, 0xab, 0x12, 0x27, 0xb7,
+	0x08, 0x14, 0x31, 0x04, 0x09, 0x90, 0x98, 0x4d, 0x1c, 0x50, 0xa4, 0x96, 0x56, 0x49, 0x16, 0x26,
+	0xeb, 0xea, 0x3b, 0xc2, 0x49, 0x8d, 0x2f, 0xba, 0x7b, 0xb2, 0x74, 0x62, 0x64, 0xe4, 0x3b, 0xb0,
+	0xf0, 0x51, 0x18, 0x33, 0x76, 0x24, 0xce, 0xc2, 0xd8, 0x6f, 0x00, 0xba, 0xb3, 0x1b, 0x22, 0xc4,
+	0xe6, 0xfb, 0x3d, 0xbf, 0xb3, 0xff, 0xcf, 0x5f, 0xc6, 0xf7, 0x41, 0xe4, 0x2c, 0x87, 0x74, 0x21,
+	0xe7, 0x9a, 0x81, 0x54, 0xf9, 0x60, 0xa9, 0x15, 0x28, 0xe2, 0x73, 0x69, 0x40, 0xcb, 0xcb, 0x15,
+	0x28, 0x7d, 0xd4, 0x9b, 0xab, 0xb9, 0x72, 0xfc, 0x99, 0x7d, 0x2a, 0x95, 0x93, 0x5f, 0x35, 0x7c,
+	0x6f, 0xe6, 0x6e, 0x9f, 0xdd, 0x5d, 0x4e, 0x84, 0xc9, 0xc8, 0x03, 0xdc, 0x5c, 0x19, 0xa1, 0x53,
+	0xc9, 0x43, 0x14, 0xa1, 0x7e, 0x6b, 0xd2, 0xb0, 0xc7, 0x31, 0x27, 0x4f, 0xf0, 0xff, 0x1f, 0xb4,
+	0x5a, 0xa4, 0xe6, 0x23, 0xd3, 0x3c, 0x35, 0xf2, 0x5a, 0x84, 0x07, 0x11, 0xea, 0xd7, 0x27, 0x81,
+	0xc5, 0x53, 0x4b, 0xa7, 0xf2, 0x5a, 0x90, 0x13, 0x1c, 0x80, 0xda, 0xb7, 0x6a, 0xce, 0xf2, 0x41,
+	0xfd, 0x71, 0x5e, 0xe1, 0xba, 0x01, 0x06, 0x22, 0x3c, 0x8c, 0x50, 0xbf, 0xf3, 0xfc, 0x78, 0xb0,
+	0x97, 0x77, 0xf0, 0x57, 0xaa, 0xa9, 0x15, 0x27, 0xa5, 0x4f, 0x1e, 0x62, 0x6c, 0x80, 0x69, 0x10,
+	0x3c, 0x65, 0x10, 0xd6, 0x23, 0xd4, 0xaf, 0x4d, 0x5a, 0x15, 0x89, 0xc1, 0x8e, 0x57, 0x4b, 0xce,
+	0xaa, 0x71, 0xa3, 0x1c, 0x57, 0x24, 0x06, 0x72, 0x8c, 0xdb, 0x99, 0x5a, 0x2c, 0xaf, 0x44, 0x25,
+	0x34, 0x9d, 0xe0, 0xef, 0x58, 0x0c, 0x24, 0xc2, 0x7e, 0xa6, 0x94, 0xe6, 0x32, 0x67, 0xa0, 0x74,
+	0xf8, 0x9f, 0xab, 0x60, 0x1f, 0x91, 0x47, 0x38, 0x30, 0x6a, 0xa5, 0x33, 0x61, 0x52, 0x50, 0xc0,
+	0xae, 0xc2, 0x96, 0xdb, 0xaf, 0x5d, 0xc1, 0x99, 0x65, 0xf6, 0x4b, 0x77, 0x12, 0x57, 0xb9, 0x08,
+	0x71, 0xd9, 0x41, 0xc5, 0x12, 0x95, 0x0b, 0xf7, 0x1e, 0xa1, 0xa5, 0x30, 0x69, 0xa6, 0x96, 0x52,
+	0xf0, 0xd0, 0x77, 0x69, 0xda, 0x25, 0x1c, 0x3a, 0x46, 0x1e, 0xe3, 0x8e, 0x61, 0x36, 0xdd, 0xce,
+	0x6a, 0x3b, 0x2b, 0xa8, 0x68, 0xa5, 0xf5, 0x70, 0x5d, 0x68, 0xad, 0x74, 0x18, 0xb8, 0xbc, 0xe5,
+	0xc1, 0xb6, 0xc1, 0xc5, 0x6e, 0xd9, 0x4e, 0xd9, 0x46, 0x45, 0x62, 0x78, 0x9a, 0xe0, 0xde, 0xbf,
+	0xaa, 0x26, 0x3e, 0x6e, 0x0e, 0xcf, 0x2f, 0xde, 0x8f, 0xdf, 0xbd, 0xed, 0x7a, 0x24, 0xc0, 0xad,
+	0xe1, 0xf9, 0xd9, 0xc5, 0xe9, 0x68, 0x36, 0x4a, 0xba, 0x88, 0x60, 0xdc, 0x78, 0x13, 0x8f, 0x4f,
+	0x47, 0x49, 0xf7, 0xe0, 0xe8, 0xf0, 0xf3, 0x57, 0x8a, 0x5e, 0xbf, 0x5c, 0x6f, 0xa8, 0x77, 0xb3,
+	0xa1, 0xde, 0xed, 0x86, 0xa2, 0x4f, 0x05, 0x45, 0xdf, 0x0a, 0x8a, 0xbe, 0x17, 0x14, 0xad, 0x0b,
+	0x8a, 0x7e, 0x14, 0x14, 0xfd, 0x2c, 0xa8, 0x77, 0x5b, 0x50, 0xf4, 0x65, 0x4b, 0xbd, 0xf5, 0x96,
+	0x7a, 0x37, 0x5b, 0xea, 0x5d, 0x36, 0xdc, 0x4f, 0xf8, 0xe2, 0xf7, 0x00, 0x20, 0x29, 0xb7, 0xdb,
+	0xc1, 0x02, 0x00, 0x00,
+}
+
+func (x TenantMigrationState) String() string {
+	s, ok := TenantMigrationState_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
+func (this *TenantMigrationDesc) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TenantMigrationDesc)
+	if !ok {
+		that2, ok := that.(TenantMigrationDesc)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.UserId != that1.UserId {
+		return false
+	}
+	if this.FromShardSize != that1.FromShardSize {
+		return false
+	}
+	if this.ToShardSize != that1.ToShardSize {
+		return false
+	}
+	if this.State != that1.State {
+		return false
+	}
+	if this.StartedAt != that1.StartedAt {
+		return false
+	}
+	if this.UpdatedAt != that1.UpdatedAt {
+		return false
+	}
+	if this.CompletedAt != that1.CompletedAt {
+		return false
+	}
+	if this.Coordinator != that1.Coordinator {
+		return false
+	}
+	if this.SourcesTotal != that1.SourcesTotal {
+		return false
+	}
+	if this.SourcesDone != that1.SourcesDone {
+		return false
+	}
+	if this.SeriesCopied != that1.SeriesCopied {
+		return false
+	}
+	if this.SamplesCopied != that1.SamplesCopied {
+		return false
+	}
+	if this.Error != that1.Error {
+		return false
+	}
+	if this.DeletedAt != that1.DeletedAt {
+		return false
+	}
+	return true
+}
+func (this *TenantMigrationDesc) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 18)
+	s = append(s, "&distributor.TenantMigrationDesc{")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	s = append(s, "FromShardSize: "+fmt.Sprintf("%#v", this.FromShardSize)+",\n")
+	s = append(s, "ToShardSize: "+fmt.Sprintf("%#v", this.ToShardSize)+",\n")
+	s = append(s, "State: "+fmt.Sprintf("%#v", this.State)+",\n")
+	s = append(s, "StartedAt: "+fmt.Sprintf("%#v", this.StartedAt)+",\n")
+	s = append(s, "UpdatedAt: "+fmt.Sprintf("%#v", this.UpdatedAt)+",\n")
+	s = append(s, "CompletedAt: "+fmt.Sprintf("%#v", this.CompletedAt)+",\n")
+	s = append(s, "Coordinator: "+fmt.Sprintf("%#v", this.Coordinator)+",\n")
+	s = append(s, "SourcesTotal: "+fmt.Sprintf("%#v", this.SourcesTotal)+",\n")
+	s = append(s, "SourcesDone: "+fmt.Sprintf("%#v", this.SourcesDone)+",\n")
+	s = append(s, "SeriesCopied: "+fmt.Sprintf("%#v", this.SeriesCopied)+",\n")
+	s = append(s, "SamplesCopied: "+fmt.Sprintf("%#v", this.SamplesCopied)+",\n")
+	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "DeletedAt: "+fmt.Sprintf("%#v", this.DeletedAt)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringTenantMigration(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+func (m *TenantMigrationDesc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TenantMigrationDesc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TenantMigrationDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.DeletedAt != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.DeletedAt))
+		i--
+		dAtA[i] = 0x70
+	}
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintTenantMigration(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x6a
+	}
+	if m.SamplesCopied != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.SamplesCopied))
+		i--
+		dAtA[i] = 0x60
+	}
+	if m.SeriesCopied != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.SeriesCopied))
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.SourcesDone != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.SourcesDone))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.SourcesTotal != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.SourcesTotal))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.Coordinator) > 0 {
+		i -= len(m.Coordinator)
+		copy(dAtA[i:], m.Coordinator)
+		i = encodeVarintTenantMigration(dAtA, i, uint64(len(m.Coordinator)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.CompletedAt != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.CompletedAt))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.UpdatedAt != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.UpdatedAt))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.StartedAt != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.StartedAt))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.State != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.State))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.ToShardSize != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.ToShardSize))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.FromShardSize != 0 {
+		i = encodeVarintTenantMigration(dAtA, i, uint64(m.FromShardSize))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintTenantMigration(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintTenantMigration(dAtA []byte, offset int, v uint64) int {
+	offset -= sovTenantMigration(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *TenantMigrationDesc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.UserId)
+	if l > 0 {
+		n += 1 + l + sovTenantMigration(uint64(l))
+	}
+	if m.FromShardSize != 0 {
+		n += 1 + sovTenantMigration(uint64(m.FromShardSize))
+	}
+	if m.ToShardSize != 0 {
+		n += 1 + sovTenantMigration(uint64(m.ToShardSize))
+	}
+	if m.State != 0 {
+		n += 1 + sovTenantMigration(uint64(m.State))
+	}
+	if m.StartedAt != 0 {
+		n += 1 + sovTenantMigration(uint64(m.StartedAt))
+	}
+	if m.UpdatedAt != 0 {
+		n += 1 + sovTenantMigration(uint64(m.UpdatedAt))
+	}
+	if m.CompletedAt != 0 {
+		n += 1 + sovTenantMigration(uint64(m.CompletedAt))
+	}
+	l = len(m.Coordinator)
+	if l > 0 {
+		n += 1 + l + sovTenantMigration(uint64(l))
+	}
+	if m.SourcesTotal != 0 {
+		n += 1 + sovTenantMigration(uint64(m.SourcesTotal))
+	}
+	if m.SourcesDone != 0 {
+		n += 1 + sovTenantMigration(uint64(m.SourcesDone))
+	}
+	if m.SeriesCopied != 0 {
+		n += 1 + sovTenantMigration(uint64(m.SeriesCopied))
+	}
+	if m.SamplesCopied != 0 {
+		n += 1 + sovTenantMigration(uint64(m.SamplesCopied))
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovTenantMigration(uint64(l))
+	}
+	if m.DeletedAt != 0 {
+		n += 1 + sovTenantMigration(uint64(m.DeletedAt))
+	}
+	return n
+}
+
+func sovTenantMigration(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozTenantMigration(x uint64) (n int) {
+	return sovTenantMigration(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *TenantMigrationDesc) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TenantMigrationDesc{`,
+		`UserId:` + fmt.Sprintf("%v", this.UserId) + `,`,
+		`FromShardSize:` + fmt.Sprintf("%v", this.FromShardSize) + `,`,
+		`ToShardSize:` + fmt.Sprintf("%v", this.ToShardSize) + `,`,
+		`State:` + fmt.Sprintf("%v", this.State) + `,`,
+		`StartedAt:` + fmt.Sprintf("%v", this.StartedAt) + `,`,
+		`UpdatedAt:` + fmt.Sprintf("%v", this.UpdatedAt) + `,`,
+		`CompletedAt:` + fmt.Sprintf("%v", this.CompletedAt) + `,`,
+		`Coordinator:` + fmt.Sprintf("%v", this.Coordinator) + `,`,
+		`SourcesTotal:` + fmt.Sprintf("%v", this.SourcesTotal) + `,`,
+		`SourcesDone:` + fmt.Sprintf("%v", this.SourcesDone) + `,`,
+		`SeriesCopied:` + fmt.Sprintf("%v", this.SeriesCopied) + `,`,
+		`SamplesCopied:` + fmt.Sprintf("%v", this.SamplesCopied) + `,`,
+		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`DeletedAt:` + fmt.Sprintf("%v", this.DeletedAt) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringTenantMigration(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *TenantMigrationDesc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTenantMigration
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TenantMigrationDesc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TenantMigrationDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromShardSize", wireType)
+			}
+			m.FromShardSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FromShardSize |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ToShardSize", wireType)
+			}
+			m.ToShardSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ToShardSize |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			m.State = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.State |= TenantMigrationState(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartedAt", wireType)
+			}
+			m.StartedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UpdatedAt", wireType)
+			}
+			m.UpdatedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UpdatedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompletedAt", wireType)
+			}
+			m.CompletedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CompletedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Coordinator", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Coordinator = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourcesTotal", wireType)
+			}
+			m.SourcesTotal = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SourcesTotal |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourcesDone", wireType)
+			}
+			m.SourcesDone = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SourcesDone |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCopied", wireType)
+			}
+			m.SeriesCopied = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesCopied |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplesCopied", wireType)
+			}
+			m.SamplesCopied = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SamplesCopied |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeletedAt", wireType)
+			}
+			m.DeletedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeletedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTenantMigration(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthTenantMigration
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipTenantMigration(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowTenantMigration
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowTenantMigration
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthTenantMigration
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthTenantMigration
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowTenantMigration
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipTenantMigration(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthTenantMigration
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthTenantMigration = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowTenantMigration   = fmt.Errorf("proto: integer overflow")
+)
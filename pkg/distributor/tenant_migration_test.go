@@ -0,0 +1,462 @@
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+
+	promchunk "github.com/cortexproject/cortex/pkg/chunk/encoding"
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestTenantMigrations_ShardSizes(t *testing.T) {
+	tests := map[string]struct {
+		desc              *TenantMigrationDesc
+		expectedWriteSize int
+		expectedReadSize  int
+	}{
+		"no migration": {
+			expectedWriteSize: 3,
+			expectedReadSize:  3,
+		},
+		"copying to a larger shard": {
+			desc:              &TenantMigrationDesc{FromShardSize: 3, ToShardSize: 6, State: TenantMigrationState_COPYING},
+			expectedWriteSize: 6,
+			expectedReadSize:  6,
+		},
+		"copying to a smaller shard": {
+			desc:              &TenantMigrationDesc{FromShardSize: 6, ToShardSize: 4, State: TenantMigrationState_COPYING},
+			expectedWriteSize: 4,
+			expectedReadSize:  6,
+		},
+		"copying from the whole ring": {
+			desc:              &TenantMigrationDesc{FromShardSize: 0, ToShardSize: 4, State: TenantMigrationState_COPYING},
+			expectedWriteSize: 4,
+			expectedReadSize:  0,
+		},
+		"failed": {
+			desc:              &TenantMigrationDesc{FromShardSize: 6, ToShardSize: 4, State: TenantMigrationState_FAILED},
+			expectedWriteSize: 4,
+			expectedReadSize:  6,
+		},
+		"completed": {
+			desc:              &TenantMigrationDesc{FromShardSize: 6, ToShardSize: 4, State: TenantMigrationState_COMPLETED},
+			expectedWriteSize: 4,
+			expectedReadSize:  4,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			m := &tenantMigrations{
+				cfg:        TenantMigrationConfig{Enabled: true},
+				migrations: map[string]TenantMigrationDesc{},
+				running:    map[string]runningTenantMigration{},
+			}
+			if testData.desc != nil {
+				m.update("user", testData.desc)
+			}
+
+			assert.Equal(t, testData.expectedWriteSize, m.writeShardSize("user", 3))
+			assert.Equal(t, testData.expectedReadSize, m.readShardSize("user", 3))
+		})
+	}
+}
+
+func TestTenantMigrations_ResumeMigrations(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	store, closer := consul.NewInMemoryClient(GetTenantMigrationDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	cfg := TenantMigrationConfig{
+		Enabled:          true,
+		HeartbeatPeriod:  time.Second,
+		HeartbeatTimeout: time.Minute,
+		RetryInterval:    10 * time.Minute,
+		KVStore:          kv.Config{Mock: store},
+	}
+	m, err := newTenantMigrations(cfg, "distributor-2", nil, nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	resumed := make(chan string, 10)
+	m.runMigration = func(_ context.Context, desc TenantMigrationDesc) {
+		resumed <- desc.UserId
+	}
+
+	descs := map[string]*TenantMigrationDesc{
+		"stale-copying":  {State: TenantMigrationState_COPYING, UpdatedAt: now.Add(-2 * time.Minute).UnixMilli(), SourcesDone: 1},
+		"copying":        {State: TenantMigrationState_COPYING, UpdatedAt: now.UnixMilli()},
+		"failed":         {State: TenantMigrationState_FAILED, UpdatedAt: now.Add(-time.Hour).UnixMilli(), Error: "failed"},
+		"recent-failed":  {State: TenantMigrationState_FAILED, UpdatedAt: now.Add(-time.Minute).UnixMilli()},
+		"completed":      {State: TenantMigrationState_COMPLETED, UpdatedAt: now.Add(-time.Hour).UnixMilli()},
+		"deleted-failed": {State: TenantMigrationState_FAILED, UpdatedAt: now.Add(-time.Hour).UnixMilli(), DeletedAt: now.UnixMilli()},
+	}
+	for userID, desc := range descs {
+		desc.UserId = userID
+		desc.FromShardSize = 3
+		desc.ToShardSize = 6
+		desc.StartedAt = now.Add(-time.Hour).UnixMilli()
+		desc.Coordinator = "distributor-1"
+		require.NoError(t, store.CAS(ctx, userID, func(any) (any, bool, error) { return desc, true, nil }))
+	}
+	require.NoError(t, m.starting(ctx))
+
+	m.resumeMigrations(ctx)
+	m.runningWg.Wait()
+	close(resumed)
+
+	var resumedUsers []string
+	for userID := range resumed {
+		resumedUsers = append(resumedUsers, userID)
+	}
+	assert.ElementsMatch(t, []string{"stale-copying", "failed"}, resumedUsers)
+
+	for userID, desc := range descs {
+		val, err := store.Get(ctx, userID)
+		require.NoError(t, err)
+		stored := val.(*TenantMigrationDesc)
+
+		if slices.Contains(resumedUsers, userID) {
+			assert.Equal(t, "distributor-2", stored.Coordinator, userID)
+			assert.Equal(t, TenantMigrationState_COPYING, stored.State, userID)
+			assert.Zero(t, stored.SourcesDone, userID)
+			assert.Empty(t, stored.Error, userID)
+		} else {
+			assert.Equal(t, *desc, *stored, userID)
+		}
+	}
+}
+
+func TestTenantMigrations_RemoveCompletedMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	store, closer := consul.NewInMemoryClient(GetTenantMigrationDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.IngestionTenantShardSize = 6
+	overrides := validation.NewOverrides(limits, nil)
+
+	cfg := TenantMigrationConfig{Enabled: true, KVStore: kv.Config{Mock: store}}
+	m, err := newTenantMigrations(cfg, "distributor-1", overrides, nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	// Only the completed migrations to the shard size set in the limits are removed.
+	for userID, desc := range map[string]*TenantMigrationDesc{
+		"limit-updated":     {FromShardSize: 3, ToShardSize: 6, State: TenantMigrationState_COMPLETED},
+		"limit-not-updated": {FromShardSize: 6, ToShardSize: 9, State: TenantMigrationState_COMPLETED},
+		"copying":           {FromShardSize: 3, ToShardSize: 6, State: TenantMigrationState_COPYING},
+	} {
+		desc.UserId = userID
+		require.NoError(t, store.CAS(ctx, userID, func(any) (any, bool, error) { return desc, true, nil }))
+	}
+	require.NoError(t, m.starting(ctx))
+
+	m.removeCompletedMigrations(ctx)
+
+	for userID, expectedDeleted := range map[string]bool{
+		"limit-updated":     true,
+		"limit-not-updated": false,
+		"copying":           false,
+	} {
+		val, err := store.Get(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, expectedDeleted, val.(*TenantMigrationDesc).DeletedAt > 0, userID)
+	}
+}
+
+func TestDistributor_TenantMigration(t *testing.T) {
+	const numSeries = 100
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:           6,
+		happyIngesters:         6,
+		numDistributors:        1,
+		shardByAllLabels:       true,
+		shuffleShardEnabled:    true,
+		shuffleShardSize:       3,
+		tenantMigrationEnabled: true,
+	})
+	d := ds[0]
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	// The request is reused once pushed, so keep a copy of the series labels.
+	req := &cortexpb.WriteRequest{}
+	seriesLabels := make([][]cortexpb.LabelAdapter, 0, numSeries)
+	for i := range numSeries {
+		lbls := []cortexpb.LabelAdapter{
+			{Name: labels.MetricName, Value: "foo"},
+			{Name: "series", Value: fmt.Sprintf("%d", i)},
+			{Name: "zone", Value: "a"}, // Spreads the series tokens.
+		}
+		seriesLabels = append(seriesLabels, slices.Clone(lbls))
+		req.Timeseries = append(req.Timeseries, makeWriteRequestTimeseries(lbls, 1000, int64(i), false))
+	}
+	_, err := d.Push(ctx, req)
+	require.NoError(t, err)
+
+	replicationSet, err := d.GetIngestersForQuery(ctx)
+	require.NoError(t, err)
+	require.Len(t, replicationSet.Instances, 3)
+
+	// The push returns once the quorum is reached: wait until all the replicas got the series.
+	test.Poll(t, time.Second, 3*numSeries, func() any {
+		total := 0
+		for _, ing := range ingesters {
+			total += len(ing.series())
+		}
+		return total
+	})
+
+	rec := httptest.NewRecorder()
+	d.TenantMigrationHandler(rec, httptest.NewRequest(http.MethodPost, "/distributor/tenant_migrations?tenant=user&shard_size=6", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	test.Poll(t, 5*time.Second, TenantMigrationState_COMPLETED.String(), func() any {
+		statuses := getTenantMigrationStatuses(t, d)
+		if len(statuses) != 1 {
+			return nil
+		}
+		return statuses[0].State
+	})
+
+	status := getTenantMigrationStatuses(t, d)[0]
+	assert.Equal(t, int32(3), status.FromShardSize)
+	assert.Equal(t, int32(6), status.ToShardSize)
+	assert.Equal(t, int32(3), status.SourcesTotal)
+	assert.Equal(t, int32(3), status.SourcesDone)
+	assert.Empty(t, status.Error)
+
+	// Each new owner has received the series once, either pushed or copied.
+	fromRing := d.ingestersRing.ShuffleShard("user", 3)
+	toRing := d.ingestersRing.ShuffleShard("user", 6)
+	ingestersByAddr := map[string]*mockIngester{}
+	for idx, ing := range ingesters {
+		ingestersByAddr[fmt.Sprintf("ip-ingester-%d", idx)] = ing
+	}
+
+	expectedCopies := 0
+	for _, lbls := range seriesLabels {
+		token, err := d.tokenForLabels("user", lbls)
+		require.NoError(t, err)
+		prevOwners, err := fromRing.Get(token, ring.WriteNoExtend, nil, nil, nil)
+		require.NoError(t, err)
+		newOwners, err := toRing.Get(token, ring.WriteNoExtend, nil, nil, nil)
+		require.NoError(t, err)
+
+		for _, owner := range newOwners.Instances {
+			ing := ingestersByAddr[owner.Addr]
+			_, pushed := ing.series()[shardByAllLabels("user", lbls)]
+			imported := ing.importedSeries(lbls)
+
+			assert.Equal(t, prevOwners.Includes(owner.Addr), pushed)
+			assert.True(t, pushed != (imported == 1), "series %v on %s: pushed %v, imported %d times", lbls, owner.Addr, pushed, imported)
+			if !pushed {
+				expectedCopies++
+			}
+		}
+	}
+	require.Greater(t, expectedCopies, 0)
+	assert.Equal(t, int64(expectedCopies), status.SeriesCopied)
+	assert.Equal(t, int64(expectedCopies), status.SamplesCopied)
+
+	replicationSet, err = d.GetIngestersForQuery(ctx)
+	require.NoError(t, err)
+	assert.Len(t, replicationSet.Instances, 6)
+}
+
+func TestDistributor_TenantMigrationHandler(t *testing.T) {
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:               6,
+		happyIngesters:             6,
+		numDistributors:            1,
+		shardByAllLabels:           true,
+		shuffleShardEnabled:        true,
+		shuffleShardSize:           3,
+		tenantMigrationEnabled:     true,
+		tenantMigrationSwitchDelay: time.Hour, // Long enough to not be reached during the test.
+	})
+	d := ds[0]
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	request := func(method, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		d.TenantMigrationHandler(rec, httptest.NewRequest(method, "/distributor/tenant_migrations?"+query, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "shard_size=6").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "tenant=user&shard_size=-1").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "tenant=user&shard_size=3").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "tenant=user").Code)
+
+	require.Equal(t, http.StatusOK, request(http.MethodPost, "tenant=user&shard_size=4").Code)
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "tenant=user&shard_size=5").Code)
+
+	// The writes go to the new shard, and the queries to both shards.
+	test.Poll(t, time.Second, 4, func() any {
+		return d.tenantMigrations.writeShardSize("user", 3)
+	})
+	replicationSet, err := d.GetIngestersForQuery(ctx)
+	require.NoError(t, err)
+	assert.Len(t, replicationSet.Instances, 4)
+
+	statuses := getTenantMigrationStatuses(t, d)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "user", statuses[0].UserID)
+	assert.Equal(t, TenantMigrationState_COPYING.String(), statuses[0].State)
+	assert.Equal(t, "0", statuses[0].Coordinator)
+
+	// Deleting the migration stops it, and restores the shard size from the limits.
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "tenant=user").Code)
+	test.Poll(t, time.Second, 0, func() any {
+		return len(getTenantMigrationStatuses(t, d))
+	})
+	test.Poll(t, time.Second, 0, func() any {
+		d.tenantMigrations.runningMtx.Lock()
+		defer d.tenantMigrations.runningMtx.Unlock()
+		return len(d.tenantMigrations.running)
+	})
+	assert.Equal(t, 3, d.tenantMigrations.writeShardSize("user", 3))
+
+	// The migration can be started again once deleted.
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "tenant=user&shard_size=5").Code)
+}
+
+func TestDistributor_TenantMigrationHandler_Disabled(t *testing.T) {
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+	})
+
+	rec := httptest.NewRecorder()
+	ds[0].TenantMigrationHandler(rec, httptest.NewRequest(http.MethodGet, "/distributor/tenant_migrations", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func getTenantMigrationStatuses(t *testing.T, d *Distributor) []tenantMigrationStatus {
+	rec := httptest.NewRecorder()
+	d.TenantMigrationHandler(rec, httptest.NewRequest(http.MethodGet, "/distributor/tenant_migrations", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var statuses []tenantMigrationStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &statuses))
+	return statuses
+}
+
+func (i *mockIngester) ExportTenant(ctx context.Context, req *client.ExportTenantRequest, opts ...grpc.CallOption) (client.Ingester_ExportTenantClient, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("ExportTenant")
+
+	stream := &exportTenantStream{}
+	for _, ts := range i.timeseries {
+		c := chunkenc.NewXORChunk()
+		appender, err := c.Appender()
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range ts.Samples {
+			appender.Append(sample.TimestampMs, sample.Value)
+		}
+
+		e, err := promchunk.FromPromChunkEncoding(c.Encoding())
+		if err != nil {
+			return nil, err
+		}
+		stream.results = append(stream.results, &client.ExportTenantResponse{
+			Chunkseries: []client.TimeSeriesChunk{{
+				Labels: ts.Labels,
+				Chunks: []client.Chunk{{Encoding: int32(e), Data: c.Bytes()}},
+			}},
+		})
+	}
+	return stream, nil
+}
+
+func (i *mockIngester) ImportTenant(ctx context.Context, opts ...grpc.CallOption) (client.Ingester_ImportTenantClient, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("ImportTenant")
+	return &importTenantStream{ingester: i}, nil
+}
+
+func (i *mockIngester) importedSeries(lbls []cortexpb.LabelAdapter) int {
+	i.Lock()
+	defer i.Unlock()
+
+	return i.imported[cortexpb.FromLabelAdaptersToLabels(lbls).String()]
+}
+
+type exportTenantStream struct {
+	grpc.ClientStream
+	i       int
+	results []*client.ExportTenantResponse
+}
+
+func (s *exportTenantStream) Recv() (*client.ExportTenantResponse, error) {
+	if s.i >= len(s.results) {
+		return nil, io.EOF
+	}
+	result := s.results[s.i]
+	s.i++
+	return result, nil
+}
+
+type importTenantStream struct {
+	grpc.ClientStream
+	ingester *mockIngester
+	resp     client.ImportTenantResponse
+}
+
+func (s *importTenantStream) Send(req *client.ImportTenantRequest) error {
+	s.ingester.Lock()
+	defer s.ingester.Unlock()
+
+	if s.ingester.imported == nil {
+		s.ingester.imported = map[string]int{}
+	}
+	for _, series := range req.Chunkseries {
+		s.ingester.imported[cortexpb.FromLabelAdaptersToLabels(series.Labels).String()]++
+		s.resp.Series++
+		for _, c := range series.Chunks {
+			chk, err := chunkenc.FromData(chunkenc.EncXOR, c.Data)
+			if err != nil {
+				return err
+			}
+			s.resp.Samples += int64(chk.NumSamples())
+		}
+	}
+	return nil
+}
+
+func (s *importTenantStream) CloseAndRecv() (*client.ImportTenantResponse, error) {
+	return &s.resp, nil
+}
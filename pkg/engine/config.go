@@ -46,6 +46,24 @@ func (cfg *ThanosEngineConfig) Validate() error {
 	return nil
 }
 
+// ShadowEngineConfig contains the alternate engine configuration used by the queries requesting the shadow engine type.
+type ShadowEngineConfig struct {
+	Enabled      bool               `yaml:"enabled"`
+	ThanosEngine ThanosEngineConfig `yaml:"thanos_engine"`
+}
+
+func (cfg *ShadowEngineConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Experimental. Execute the queries requesting the shadow engine type with the shadow engine configuration. Used by the query-frontend shadow mode to compare the results of different engine configurations.")
+	cfg.ThanosEngine.RegisterFlagsWithPrefix(prefix, f)
+}
+
+func (cfg *ShadowEngineConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	return cfg.ThanosEngine.Validate()
+}
+
 func getOptimizer(name string) ([]logicalplan.Optimizer, error) {
 	switch name {
 	case "default":
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -24,13 +25,17 @@ type Type string
 const (
 	Prometheus Type = "prometheus"
 	Thanos     Type = "thanos"
-	None       Type = "none"
+	// Shadow executes the query with the alternate configuration of the shadow engine, to compare its results.
+	Shadow Type = "shadow"
+	None   Type = "none"
 )
 
+var errShadowEngineDisabled = errors.New("the shadow engine is not enabled")
+
 func AddEngineTypeToContext(ctx context.Context, r *http.Request) context.Context {
 	ng := Type(r.Header.Get(TypeHeader))
 	switch ng {
-	case Prometheus, Thanos:
+	case Prometheus, Thanos, Shadow:
 		return context.WithValue(ctx, engineKey, ng)
 	default:
 		return context.WithValue(ctx, engineKey, None)
@@ -53,6 +58,7 @@ type QueryEngine interface {
 type Engine struct {
 	prometheusEngine *promql.Engine
 	thanosEngine     *thanosengine.Engine
+	shadowEngine     *Engine
 
 	fallbackQueriesTotal     prometheus.Counter
 	engineSwitchQueriesTotal *prometheus.CounterVec
@@ -86,7 +92,37 @@ func New(opts promql.EngineOpts, thanosEngineCfg ThanosEngineConfig, reg prometh
 	}
 }
 
+// NewWithShadow creates an engine which executes the queries requesting the Shadow engine type
+// with the shadow engine configuration.
+func NewWithShadow(opts promql.EngineOpts, thanosEngineCfg, shadowEngineCfg ThanosEngineConfig, reg prometheus.Registerer) *Engine {
+	qf := New(opts, thanosEngineCfg, reg)
+
+	// The metrics of the shadow engine are not registered, as they would collide with the ones of the main engine.
+	shadowOpts := opts
+	shadowOpts.Reg = nil
+	qf.shadowEngine = New(shadowOpts, shadowEngineCfg, nil)
+	return qf
+}
+
+// shadow returns the shadow engine, or an error if the shadow engine is not enabled. The engine type
+// is cleared from the returned context, so that the shadow engine uses its own configuration.
+func (qf *Engine) shadow(ctx context.Context) (*Engine, context.Context, error) {
+	qf.engineSwitchQueriesTotal.WithLabelValues(string(Shadow)).Inc()
+	if qf.shadowEngine == nil {
+		return nil, ctx, errShadowEngineDisabled
+	}
+	return qf.shadowEngine, context.WithValue(ctx, engineKey, None), nil
+}
+
 func (qf *Engine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	if GetEngineType(ctx) == Shadow {
+		shadow, ctx, err := qf.shadow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return shadow.NewInstantQuery(ctx, q, opts, qs, ts)
+	}
+
 	if engineType := GetEngineType(ctx); engineType == Prometheus {
 		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
 		goto prom
@@ -112,6 +148,14 @@ prom:
 }
 
 func (qf *Engine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	if GetEngineType(ctx) == Shadow {
+		shadow, ctx, err := qf.shadow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return shadow.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	}
+
 	if engineType := GetEngineType(ctx); engineType == Prometheus {
 		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
 		goto prom
@@ -136,6 +180,14 @@ prom:
 }
 
 func (qf *Engine) MakeInstantQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, ts time.Time, qs string) (promql.Query, error) {
+	if GetEngineType(ctx) == Shadow {
+		shadow, ctx, err := qf.shadow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return shadow.MakeInstantQueryFromPlan(ctx, q, opts, root, ts, qs)
+	}
+
 	if engineType := GetEngineType(ctx); engineType == Prometheus {
 		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
 	} else if engineType == Thanos {
@@ -160,6 +212,14 @@ prom:
 }
 
 func (qf *Engine) MakeRangeQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, start time.Time, end time.Time, interval time.Duration, qs string) (promql.Query, error) {
+	if GetEngineType(ctx) == Shadow {
+		shadow, ctx, err := qf.shadow(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return shadow.MakeRangeQueryFromPlan(ctx, q, opts, root, start, end, interval, qs)
+	}
+
 	if engineType := GetEngineType(ctx); engineType == Prometheus {
 		qf.engineSwitchQueriesTotal.WithLabelValues(string(Prometheus)).Inc()
 	} else if engineType == Thanos {
@@ -102,6 +102,57 @@ func TestEngine_Switch(t *testing.T) {
 	`), "cortex_engine_switch_queries_total"))
 }
 
+func TestEngine_Shadow(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+
+	now := time.Now()
+	start := time.Now().Add(-time.Minute * 5)
+	step := time.Minute
+	queryable := promqltest.LoadedStorage(t, "")
+	opts := promql.EngineOpts{
+		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
+		Reg:    reg,
+	}
+	queryEngine := NewWithShadow(opts, ThanosEngineConfig{Enabled: true}, ThanosEngineConfig{Enabled: true, EnableXFunctions: true}, reg)
+
+	// The x-functions are only enabled in the shadow engine.
+	_, err := queryEngine.NewInstantQuery(ctx, queryable, nil, "xrate(foo[1m])", now)
+	require.Error(t, err)
+
+	r := &http.Request{Header: http.Header{}}
+	r.Header.Set(TypeHeader, string(Shadow))
+	ctx = AddEngineTypeToContext(ctx, r)
+	_, err = queryEngine.NewInstantQuery(ctx, queryable, nil, "xrate(foo[1m])", now)
+	require.NoError(t, err)
+	_, err = queryEngine.NewRangeQuery(ctx, queryable, nil, "xrate(foo[1m])", start, now, step)
+	require.NoError(t, err)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_engine_switch_queries_total Total number of queries where engine_type is set explicitly
+		# TYPE cortex_engine_switch_queries_total counter
+		cortex_engine_switch_queries_total{engine_type="shadow"} 2
+	`), "cortex_engine_switch_queries_total"))
+}
+
+func TestEngine_ShadowDisabled(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+
+	queryable := promqltest.LoadedStorage(t, "")
+	opts := promql.EngineOpts{
+		Logger: utillog.GoKitLogToSlog(log.NewNopLogger()),
+		Reg:    reg,
+	}
+	queryEngine := New(opts, ThanosEngineConfig{Enabled: true}, reg)
+
+	r := &http.Request{Header: http.Header{}}
+	r.Header.Set(TypeHeader, string(Shadow))
+	ctx = AddEngineTypeToContext(ctx, r)
+	_, err := queryEngine.NewInstantQuery(ctx, queryable, nil, "foo", time.Now())
+	require.ErrorIs(t, err, errShadowEngineDisabled)
+}
+
 func TestEngine_XFunctions(t *testing.T) {
 	ctx := context.Background()
 	reg := prometheus.NewRegistry()
@@ -0,0 +1,406 @@
+package engine
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/prometheus/prometheus/util/stats"
+	"github.com/thanos-io/promql-engine/logicalplan"
+	"go.uber.org/atomic"
+
+	querier_series "github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/util/users"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	// MemoryLimitActionFail fails the query as soon as it exceeds its memory limit.
+	MemoryLimitActionFail = "fail"
+	// MemoryLimitActionSpill spills the series selected by the query to disk once it exceeds its memory limit.
+	MemoryLimitActionSpill = "spill"
+
+	ErrMaxQueryMemoryHit = "the query hit the max memory limit (limit: %d bytes)"
+
+	// Estimated bytes retained by the engine for each float sample.
+	floatSampleBytes = 16
+)
+
+var supportedMemoryLimitActions = []string{MemoryLimitActionFail, MemoryLimitActionSpill}
+
+// MemoryLimitConfig configures what to do when a query exceeds the per-tenant memory limit.
+type MemoryLimitConfig struct {
+	Action   string `yaml:"action"`
+	SpillDir string `yaml:"spill_directory"`
+}
+
+func (cfg *MemoryLimitConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Action, prefix+"action", MemoryLimitActionFail, "Experimental. Action taken when a query exceeds the max query memory limit. Supported values: "+strings.Join(supportedMemoryLimitActions, ", ")+". When set to spill, series selected by the query after the limit has been reached are written to a temporary file and read back while evaluating the query.")
+	f.StringVar(&cfg.SpillDir, prefix+"spill-directory", "", "Experimental. Directory used to store temporary files when series are spilled to disk. Defaults to the OS temporary directory.")
+}
+
+func (cfg *MemoryLimitConfig) Validate() error {
+	if !slices.Contains(supportedMemoryLimitActions, cfg.Action) {
+		return fmt.Errorf("unsupported query memory limit action %q, supported values: %s", cfg.Action, strings.Join(supportedMemoryLimitActions, ", "))
+	}
+	return nil
+}
+
+// Compile-time check that MemoryLimitedEngine implements QueryEngine.
+var _ QueryEngine = (*MemoryLimitedEngine)(nil)
+
+// MemoryLimitedEngine wraps a QueryEngine to account the memory retained by each query, in terms of
+// series and samples selected from storage and of the query result. Queries exceeding the per-tenant
+// limit either fail or spill the series they select to disk, depending on the configured action.
+type MemoryLimitedEngine struct {
+	inner   QueryEngine
+	cfg     MemoryLimitConfig
+	limitFn func(userID string) int
+
+	limitHits    *prometheus.CounterVec
+	spilledBytes prometheus.Counter
+}
+
+// NewMemoryLimitedEngine wraps the given engine. Queries of tenants without a memory limit are passed through.
+func NewMemoryLimitedEngine(inner QueryEngine, cfg MemoryLimitConfig, limitFn func(userID string) int, reg prometheus.Registerer) *MemoryLimitedEngine {
+	return &MemoryLimitedEngine{
+		inner:   inner,
+		cfg:     cfg,
+		limitFn: limitFn,
+		limitHits: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_memory_limit_hit_total",
+			Help: "Total number of queries which exceeded the max query memory limit, partitioned by the action taken.",
+		}, []string{"action"}),
+		spilledBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_spilled_bytes_total",
+			Help: "Total number of bytes spilled to disk by queries which exceeded the max query memory limit.",
+		}),
+	}
+}
+
+func (e *MemoryLimitedEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	return e.wrap(ctx, q, func(q storage.Queryable) (promql.Query, error) {
+		return e.inner.NewInstantQuery(ctx, q, opts, qs, ts)
+	})
+}
+
+func (e *MemoryLimitedEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	return e.wrap(ctx, q, func(q storage.Queryable) (promql.Query, error) {
+		return e.inner.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	})
+}
+
+func (e *MemoryLimitedEngine) MakeInstantQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, ts time.Time, qs string) (promql.Query, error) {
+	return e.wrap(ctx, q, func(q storage.Queryable) (promql.Query, error) {
+		return e.inner.MakeInstantQueryFromPlan(ctx, q, opts, root, ts, qs)
+	})
+}
+
+func (e *MemoryLimitedEngine) MakeRangeQueryFromPlan(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, root logicalplan.Node, start time.Time, end time.Time, interval time.Duration, qs string) (promql.Query, error) {
+	return e.wrap(ctx, q, func(q storage.Queryable) (promql.Query, error) {
+		return e.inner.MakeRangeQueryFromPlan(ctx, q, opts, root, start, end, interval, qs)
+	})
+}
+
+func (e *MemoryLimitedEngine) wrap(ctx context.Context, q storage.Queryable, newQuery func(storage.Queryable) (promql.Query, error)) (promql.Query, error) {
+	// Queries without a tenant (eg. internal ones) are not limited.
+	tenantIDs, err := users.TenantIDs(ctx)
+	if err != nil {
+		return newQuery(q)
+	}
+
+	limit := validation.SmallestPositiveIntPerTenant(tenantIDs, e.limitFn)
+	if limit <= 0 {
+		return newQuery(q)
+	}
+
+	accountant := newMemoryAccountant(int64(limit), e.cfg, e.limitHits, e.spilledBytes)
+	query, err := newQuery(&memoryAccountingQueryable{Queryable: q, accountant: accountant})
+	if err != nil {
+		accountant.close()
+		return nil, err
+	}
+	return &memoryLimitedQuery{Query: query, accountant: accountant}, nil
+}
+
+// memoryAccountant tracks the bytes retained by a single query.
+type memoryAccountant struct {
+	limit  int64
+	action string
+	used   atomic.Int64
+
+	limitHits    *prometheus.CounterVec
+	spilledBytes prometheus.Counter
+	limitHitOnce sync.Once
+
+	spillDir  string
+	spillMtx  sync.Mutex
+	spillFile *spillFile
+}
+
+func newMemoryAccountant(limit int64, cfg MemoryLimitConfig, limitHits *prometheus.CounterVec, spilledBytes prometheus.Counter) *memoryAccountant {
+	return &memoryAccountant{
+		limit:        limit,
+		action:       cfg.Action,
+		spillDir:     cfg.SpillDir,
+		limitHits:    limitHits,
+		spilledBytes: spilledBytes,
+	}
+}
+
+// add accounts the given bytes and returns an error if the query exceeded its limit and is not allowed to spill.
+func (a *memoryAccountant) add(bytes int64) error {
+	if a.used.Add(bytes) <= a.limit {
+		return nil
+	}
+
+	a.limitHit()
+	if a.action == MemoryLimitActionSpill {
+		return nil
+	}
+	return validation.LimitError(fmt.Sprintf(ErrMaxQueryMemoryHit, a.limit))
+}
+
+func (a *memoryAccountant) limitHit() {
+	a.limitHitOnce.Do(func() {
+		a.limitHits.WithLabelValues(a.action).Inc()
+	})
+}
+
+// shouldSpill returns whether a series of the given size should be spilled instead of being kept in memory.
+func (a *memoryAccountant) shouldSpill(bytes int64) bool {
+	return a.action == MemoryLimitActionSpill && a.used.Load()+bytes > a.limit
+}
+
+// spill writes all the remaining series of the set to the query spill file, and returns a set reading them back.
+func (a *memoryAccountant) spill(set storage.SeriesSet) storage.SeriesSet {
+	a.limitHit()
+
+	a.spillMtx.Lock()
+	defer a.spillMtx.Unlock()
+
+	if a.spillFile == nil {
+		f, err := newSpillFile(a.spillDir)
+		if err != nil {
+			return storage.ErrSeriesSet(err)
+		}
+		a.spillFile = f
+	}
+
+	spilled, written, err := a.spillFile.write(set, a)
+	a.spilledBytes.Add(float64(written))
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	return spilled
+}
+
+func (a *memoryAccountant) close() {
+	a.spillMtx.Lock()
+	defer a.spillMtx.Unlock()
+
+	if a.spillFile != nil {
+		a.spillFile.close()
+		a.spillFile = nil
+	}
+}
+
+type memoryAccountingQueryable struct {
+	storage.Queryable
+	accountant *memoryAccountant
+}
+
+func (q *memoryAccountingQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	querier, err := q.Queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryAccountingQuerier{Querier: querier, accountant: q.accountant}, nil
+}
+
+type memoryAccountingQuerier struct {
+	storage.Querier
+	accountant *memoryAccountant
+}
+
+func (q *memoryAccountingQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	set := q.Querier.Select(ctx, sortSeries, hints, matchers...)
+	if q.accountant.shouldSpill(0) {
+		return q.accountant.spill(set)
+	}
+	return &memoryAccountingSeriesSet{SeriesSet: set, accountant: q.accountant}
+}
+
+// memoryAccountingSeriesSet accounts the labels and samples of each series. When spilling is enabled and
+// the next series would exceed the limit, that series and all the remaining ones are spilled to disk.
+type memoryAccountingSeriesSet struct {
+	storage.SeriesSet
+	accountant *memoryAccountant
+
+	spilled storage.SeriesSet
+	err     error
+}
+
+func (s *memoryAccountingSeriesSet) Next() bool {
+	if s.spilled != nil {
+		return s.spilled.Next()
+	}
+	if s.err != nil || !s.SeriesSet.Next() {
+		return false
+	}
+
+	series := s.SeriesSet.At()
+	bytes, err := seriesBytes(series)
+	if err != nil {
+		s.err = err
+		return false
+	}
+
+	if s.accountant.shouldSpill(bytes) {
+		s.spilled = s.accountant.spill(&prependedSeriesSet{first: series, SeriesSet: s.SeriesSet})
+		// Samples are read back from disk, so the wrapped set can be garbage collected.
+		s.SeriesSet = nil
+		return s.spilled.Next()
+	}
+	if err := s.accountant.add(bytes); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+func (s *memoryAccountingSeriesSet) At() storage.Series {
+	if s.spilled != nil {
+		return s.spilled.At()
+	}
+	return s.SeriesSet.At()
+}
+
+func (s *memoryAccountingSeriesSet) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.spilled != nil {
+		return s.spilled.Err()
+	}
+	return s.SeriesSet.Err()
+}
+
+func (s *memoryAccountingSeriesSet) Warnings() annotations.Annotations {
+	if s.spilled != nil {
+		return s.spilled.Warnings()
+	}
+	return s.SeriesSet.Warnings()
+}
+
+// prependedSeriesSet returns the given series before the ones of the wrapped set.
+type prependedSeriesSet struct {
+	storage.SeriesSet
+	first storage.Series
+	state int // 0: before first, 1: at first, 2: iterating the wrapped set.
+}
+
+func (s *prependedSeriesSet) Next() bool {
+	if s.state < 2 {
+		s.state++
+		if s.state == 1 {
+			return true
+		}
+	}
+	return s.SeriesSet.Next()
+}
+
+func (s *prependedSeriesSet) At() storage.Series {
+	if s.state == 1 {
+		return s.first
+	}
+	return s.SeriesSet.At()
+}
+
+// seriesBytes estimates the bytes retained by the engine for the labels and samples of the series. The size of
+// the encoded chunks is used when known, otherwise the samples are decoded to estimate it.
+func seriesBytes(series storage.Series) (int64, error) {
+	bytes := int64(series.Labels().ByteSize())
+	if cs, ok := series.(querier_series.ChunkBytesSeries); ok {
+		return bytes + int64(cs.ChunkBytes()), nil
+	}
+
+	it := series.Iterator(nil)
+	for vt := it.Next(); vt != chunkenc.ValNone; vt = it.Next() {
+		switch vt {
+		case chunkenc.ValFloat:
+			bytes += floatSampleBytes
+		case chunkenc.ValHistogram, chunkenc.ValFloatHistogram:
+			_, fh := it.AtFloatHistogram(nil)
+			bytes += int64(fh.Size())
+		}
+	}
+	return bytes, it.Err()
+}
+
+// memoryLimitedQuery accounts the query result and removes spilled data once the query is closed.
+type memoryLimitedQuery struct {
+	promql.Query
+	accountant *memoryAccountant
+}
+
+func (q *memoryLimitedQuery) Exec(ctx context.Context) *promql.Result {
+	res := q.Query.Exec(ctx)
+	if res.Err != nil {
+		return res
+	}
+
+	if err := q.accountant.add(resultBytes(res.Value)); err != nil {
+		return &promql.Result{Err: err, Warnings: res.Warnings}
+	}
+	return res
+}
+
+func (q *memoryLimitedQuery) Statement() parser.Statement {
+	return q.Query.Statement()
+}
+
+func (q *memoryLimitedQuery) Stats() *stats.Statistics {
+	return q.Query.Stats()
+}
+
+func (q *memoryLimitedQuery) Close() {
+	q.Query.Close()
+	q.accountant.close()
+}
+
+// resultBytes estimates the bytes retained by a query result.
+func resultBytes(v parser.Value) int64 {
+	var bytes int64
+	switch r := v.(type) {
+	case promql.Matrix:
+		for _, s := range r {
+			bytes += int64(s.Metric.ByteSize()) + int64(len(s.Floats))*floatSampleBytes
+			for _, h := range s.Histograms {
+				bytes += floatSampleBytes + int64(h.H.Size())
+			}
+		}
+	case promql.Vector:
+		for _, s := range r {
+			bytes += int64(s.Metric.ByteSize()) + floatSampleBytes
+			if s.H != nil {
+				bytes += int64(s.H.Size())
+			}
+		}
+	case promql.Scalar:
+		bytes = floatSampleBytes
+	}
+	return bytes
+}
@@ -0,0 +1,231 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/promqltest"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	querier_series "github.com/cortexproject/cortex/pkg/querier/series"
+	utillog "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const memoryLimitTestData = `
+load 1m
+	http_requests_total{job="api", instance="0"} 0+10x60
+	http_requests_total{job="api", instance="1"} 0+20x60
+	http_requests_total{job="db", instance="0"} 0+30x60
+	http_requests_total{job="db", instance="1"} 0+40x60
+	http_requests_total{job="web", instance="0"} 0+50x60
+	http_request_duration_seconds{job="web"} {{schema:0 sum:5 count:4 buckets:[1 2 1]}}x60
+`
+
+func TestMemoryLimitedEngine(t *testing.T) {
+	queryable := promqltest.LoadedStorage(t, memoryLimitTestData)
+	t.Cleanup(func() { _ = queryable.Close() })
+
+	opts := promql.EngineOpts{
+		Logger:     utillog.GoKitLogToSlog(log.NewNopLogger()),
+		MaxSamples: 1e6,
+		Timeout:    time.Minute,
+	}
+	start := time.Unix(0, 0)
+	end := start.Add(time.Hour)
+	qs := `topk(3, rate(http_requests_total[5m]))`
+
+	expected := func() *promql.Result {
+		q, err := promql.NewEngine(opts).NewRangeQuery(context.Background(), queryable, nil, qs, start, end, time.Minute)
+		require.NoError(t, err)
+		return q.Exec(context.Background())
+	}()
+	require.NoError(t, expected.Err)
+
+	for _, tc := range []struct {
+		name          string
+		limit         int
+		action        string
+		expectedError bool
+		expectedSpill bool
+	}{
+		{
+			name:   "no limit",
+			action: MemoryLimitActionFail,
+		},
+		{
+			name:   "limit not reached",
+			limit:  1 << 30,
+			action: MemoryLimitActionFail,
+		},
+		{
+			name:          "limit reached, fail",
+			limit:         1024,
+			action:        MemoryLimitActionFail,
+			expectedError: true,
+		},
+		{
+			name:          "limit reached, spill",
+			limit:         1024,
+			action:        MemoryLimitActionSpill,
+			expectedSpill: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			spillDir := t.TempDir()
+			eng := NewMemoryLimitedEngine(
+				New(opts, ThanosEngineConfig{}, reg),
+				MemoryLimitConfig{Action: tc.action, SpillDir: spillDir},
+				func(string) int { return tc.limit },
+				reg,
+			)
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			q, err := eng.NewRangeQuery(ctx, queryable, nil, qs, start, end, time.Minute)
+			require.NoError(t, err)
+
+			res := q.Exec(ctx)
+			if tc.expectedError {
+				require.Error(t, res.Err)
+				require.True(t, validation.IsLimitError(res.Err))
+				require.Contains(t, res.Err.Error(), "the query hit the max memory limit (limit: 1024 bytes)")
+				q.Close()
+				return
+			}
+
+			require.NoError(t, res.Err)
+			matrix, err := res.Matrix()
+			require.NoError(t, err)
+			expectedMatrix, err := expected.Matrix()
+			require.NoError(t, err)
+			require.Equal(t, expectedMatrix, matrix)
+
+			entries, err := os.ReadDir(spillDir)
+			require.NoError(t, err)
+			if tc.expectedSpill {
+				require.Len(t, entries, 1)
+				require.Greater(t, testutil.ToFloat64(eng.spilledBytes), float64(0))
+			} else {
+				require.Empty(t, entries)
+				require.Equal(t, float64(0), testutil.ToFloat64(eng.spilledBytes))
+			}
+
+			// Spilled data is removed once the query is closed.
+			q.Close()
+			entries, err = os.ReadDir(spillDir)
+			require.NoError(t, err)
+			require.Empty(t, entries)
+		})
+	}
+}
+
+func TestMemoryLimitedEngine_SpilledSeriesMatchOriginal(t *testing.T) {
+	queryable := promqltest.LoadedStorage(t, memoryLimitTestData)
+	t.Cleanup(func() { _ = queryable.Close() })
+
+	querier, err := queryable.Querier(0, time.Hour.Milliseconds())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = querier.Close() })
+
+	reg := prometheus.NewPedanticRegistry()
+	eng := NewMemoryLimitedEngine(nil, MemoryLimitConfig{Action: MemoryLimitActionSpill, SpillDir: t.TempDir()}, nil, reg)
+	accountant := newMemoryAccountant(1, eng.cfg, eng.limitHits, eng.spilledBytes)
+	t.Cleanup(accountant.close)
+
+	matcher := labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")
+	spilled := accountant.spill(querier.Select(context.Background(), true, nil, matcher))
+	original := querier.Select(context.Background(), true, nil, matcher)
+
+	count := 0
+	for original.Next() {
+		require.True(t, spilled.Next())
+		require.Equal(t, original.At().Labels(), spilled.At().Labels())
+
+		expectedSamples, err := storage.ExpandSamples(original.At().Iterator(nil), nil)
+		require.NoError(t, err)
+		actualSamples, err := storage.ExpandSamples(spilled.At().Iterator(nil), nil)
+		require.NoError(t, err)
+		require.Equal(t, expectedSamples, actualSamples)
+		count++
+	}
+	require.False(t, spilled.Next())
+	require.NoError(t, spilled.Err())
+	require.Equal(t, 6, count)
+	require.Equal(t, float64(1), testutil.ToFloat64(eng.limitHits.WithLabelValues(MemoryLimitActionSpill)))
+}
+
+func TestMemoryAccountingSeriesSet_SpillReleasesSeries(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	eng := NewMemoryLimitedEngine(nil, MemoryLimitConfig{Action: MemoryLimitActionSpill, SpillDir: t.TempDir()}, nil, reg)
+	accountant := newMemoryAccountant(1, eng.cfg, eng.limitHits, eng.spilledBytes)
+	t.Cleanup(accountant.close)
+
+	// Series are returned in reverse order, and are expected to be read back sorted once spilled.
+	set := &memoryAccountingSeriesSet{SeriesSet: newLargeSeriesSet(100, 10000), accountant: accountant}
+	retainedBefore := heapAllocBytes()
+
+	var lsets []labels.Labels
+	for set.Next() {
+		lsets = append(lsets, set.At().Labels())
+	}
+	require.NoError(t, set.Err())
+
+	// The samples of the spilled series are read back from disk, and are no longer retained in memory.
+	retainedAfter := heapAllocBytes()
+	runtime.KeepAlive(set)
+	require.Less(t, retainedAfter, retainedBefore/2)
+
+	require.Len(t, lsets, 100)
+	require.True(t, slices.IsSortedFunc(lsets, labels.Compare))
+	require.Less(t, accountant.used.Load(), int64(100*1024))
+}
+
+func TestSeriesBytes(t *testing.T) {
+	s := storage.NewListSeries(labels.FromStrings(labels.MetricName, "up"), chunks.GenerateSamples(0, 100))
+	lsetBytes := int64(s.Labels().ByteSize())
+
+	bytes, err := seriesBytes(s)
+	require.NoError(t, err)
+	require.Equal(t, lsetBytes+100*floatSampleBytes, bytes)
+
+	// The size of the encoded chunks is used when known.
+	bytes, err = seriesBytes(querier_series.NewChunkBytesSeries(s, 123))
+	require.NoError(t, err)
+	require.Equal(t, lsetBytes+123, bytes)
+}
+
+func newLargeSeriesSet(numSeries, numSamples int) storage.SeriesSet {
+	series := make([]storage.Series, 0, numSeries)
+	for i := numSeries - 1; i >= 0; i-- {
+		lset := labels.FromStrings(labels.MetricName, "large", "series", fmt.Sprintf("%03d", i))
+		series = append(series, storage.NewListSeries(lset, chunks.GenerateSamples(0, numSamples)))
+	}
+	return querier_series.NewConcreteSeriesSet(false, series)
+}
+
+func heapAllocBytes() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func TestMemoryLimitConfig_Validate(t *testing.T) {
+	require.NoError(t, (&MemoryLimitConfig{Action: MemoryLimitActionFail}).Validate())
+	require.NoError(t, (&MemoryLimitConfig{Action: MemoryLimitActionSpill}).Validate())
+	require.Error(t, (&MemoryLimitConfig{Action: "unknown"}).Validate())
+}
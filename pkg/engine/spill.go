@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/util/annotations"
+)
+
+// spillFile is a temporary file storing the samples of series spilled to disk by a query. Each spilled series set
+// is appended as a batch of series encoded as chunks, which is read back sorted by labels. Labels are kept in memory,
+// while samples are read back from disk each time a series is iterated.
+type spillFile struct {
+	f    *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	f, err := os.CreateTemp(dir, "cortex-query-spill-")
+	if err != nil {
+		return nil, fmt.Errorf("create query spill file: %w", err)
+	}
+	return &spillFile{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// write spills all the remaining series of the set and returns a set reading them back from disk, together with
+// the number of bytes written. The labels of spilled series are still accounted to the query.
+func (f *spillFile) write(set storage.SeriesSet, accountant *memoryAccountant) (storage.SeriesSet, int64, error) {
+	var (
+		spilled = &spilledSeriesSet{}
+		written int64
+		buf     []byte
+	)
+
+	for set.Next() {
+		series := set.At()
+		if err := accountant.add(int64(series.Labels().ByteSize())); err != nil {
+			return nil, written, err
+		}
+
+		buf = buf[:0]
+		chkIt := storage.NewSeriesToChunkEncoder(series).Iterator(nil)
+		for chkIt.Next() {
+			chk := chkIt.At().Chunk
+			buf = append(buf, byte(chk.Encoding()))
+			buf = binary.AppendUvarint(buf, uint64(len(chk.Bytes())))
+			buf = append(buf, chk.Bytes()...)
+		}
+		if err := chkIt.Err(); err != nil {
+			return nil, written, err
+		}
+
+		if _, err := f.w.Write(buf); err != nil {
+			return nil, written, fmt.Errorf("write query spill file: %w", err)
+		}
+		spilled.series = append(spilled.series, &spilledSeries{
+			lset:   series.Labels(),
+			file:   f.f,
+			offset: f.size,
+			length: int64(len(buf)),
+		})
+		f.size += int64(len(buf))
+		written += int64(len(buf))
+	}
+	if err := set.Err(); err != nil {
+		return nil, written, err
+	}
+
+	// Series are read back with ReadAt, so all buffered data must reach the file first.
+	if err := f.w.Flush(); err != nil {
+		return nil, written, fmt.Errorf("flush query spill file: %w", err)
+	}
+
+	// Series are spilled as soon as they are returned by the storage, and are sorted once the batch is complete.
+	slices.SortFunc(spilled.series, func(a, b *spilledSeries) int {
+		return labels.Compare(a.lset, b.lset)
+	})
+	spilled.warnings = set.Warnings()
+	return spilled, written, nil
+}
+
+func (f *spillFile) close() {
+	_ = f.f.Close()
+	_ = os.Remove(f.f.Name())
+}
+
+type spilledSeriesSet struct {
+	series   []*spilledSeries
+	cur      int
+	warnings annotations.Annotations
+}
+
+func (s *spilledSeriesSet) Next() bool {
+	if s.cur >= len(s.series) {
+		return false
+	}
+	s.cur++
+	return true
+}
+
+func (s *spilledSeriesSet) At() storage.Series {
+	return s.series[s.cur-1]
+}
+
+func (s *spilledSeriesSet) Err() error {
+	return nil
+}
+
+func (s *spilledSeriesSet) Warnings() annotations.Annotations {
+	return s.warnings
+}
+
+type spilledSeries struct {
+	lset   labels.Labels
+	file   io.ReaderAt
+	offset int64
+	length int64
+}
+
+func (s *spilledSeries) Labels() labels.Labels {
+	return s.lset
+}
+
+// Iterator reads the chunks of the series from disk and iterates over them.
+func (s *spilledSeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	buf := make([]byte, s.length)
+	if _, err := s.file.ReadAt(buf, s.offset); err != nil {
+		return newErrIterator(fmt.Errorf("read query spill file: %w", err))
+	}
+
+	var chks []chunkenc.Iterable
+	for len(buf) > 0 {
+		enc := chunkenc.Encoding(buf[0])
+		size, n := binary.Uvarint(buf[1:])
+		if n <= 0 || uint64(len(buf)-1-n) < size {
+			return newErrIterator(fmt.Errorf("corrupted query spill file"))
+		}
+		data := buf[1+n : 1+n+int(size)]
+		buf = buf[1+n+int(size):]
+
+		chk, err := chunkenc.FromData(enc, data)
+		if err != nil {
+			return newErrIterator(err)
+		}
+		chks = append(chks, chk)
+	}
+	return storage.ChainSampleIteratorFromIterables(it, chks)
+}
+
+// errIterator is an iterator without samples, reporting the error which prevented reading the series.
+type errIterator struct {
+	chunkenc.Iterator
+	err error
+}
+
+func newErrIterator(err error) chunkenc.Iterator {
+	return errIterator{Iterator: chunkenc.NewNopIterator(), err: err}
+}
+
+func (e errIterator) Err() error { return e.err }
@@ -89,6 +89,7 @@ type HandlerConfig struct {
 	MaxBodySize               int64         `yaml:"max_body_size"`
 	QueryStatsEnabled         bool          `yaml:"query_stats_enabled"`
 	EnabledRulerQueryStatsLog bool          `yaml:"enabled_ruler_query_stats_log"`
+	QueryShadow               ShadowConfig  `yaml:"query_shadow"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
@@ -96,6 +97,12 @@ func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Int64Var(&cfg.MaxBodySize, "frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "frontend.query-stats-enabled", false, "True to enable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
 	f.BoolVar(&cfg.EnabledRulerQueryStatsLog, "frontend.enabled-ruler-query-stats", false, "If enabled, report the query stats log for queries coming from the ruler to evaluate rules. It only takes effect when '-ruler.frontend-address' is configured.")
+
+	cfg.QueryShadow.RegisterFlags(f)
+}
+
+func (cfg *HandlerConfig) Validate() error {
+	return cfg.QueryShadow.Validate()
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can log slow queries,
@@ -493,6 +500,8 @@ func (f *Handler) reportQueryStats(r *http.Request, source, userID string, query
 	numDataBytes := stats.LoadFetchedDataBytes()
 	numStoreGatewayTouchedPostings := stats.LoadStoreGatewayTouchedPostings()
 	numStoreGatewayTouchedPostingBytes := stats.LoadStoreGatewayTouchedPostingBytes()
+	numStoreGatewayHedgedRequests := stats.LoadStoreGatewayHedgedRequests()
+	numStoreGatewayHedgedRequestsWon := stats.LoadStoreGatewayHedgedRequestsWon()
 	splitQueries := stats.LoadSplitQueries()
 	dataSelectMaxTime := stats.LoadDataSelectMaxTime()
 	dataSelectMinTime := stats.LoadDataSelectMinTime()
@@ -542,6 +551,11 @@ func (f *Handler) reportQueryStats(r *http.Request, source, userID string, query
 		logMessage = append(logMessage, "store_gateway_touched_posting_bytes", numStoreGatewayTouchedPostingBytes)
 	}
 
+	if numStoreGatewayHedgedRequests > 0 {
+		logMessage = append(logMessage, "store_gateway_hedged_requests", numStoreGatewayHedgedRequests)
+		logMessage = append(logMessage, "store_gateway_hedged_requests_won", numStoreGatewayHedgedRequestsWon)
+	}
+
 	grafanaFields := formatGrafanaStatsFields(r)
 	if len(grafanaFields) > 0 {
 		logMessage = append(logMessage, grafanaFields...)
@@ -0,0 +1,197 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/cortexproject/cortex/pkg/engine"
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/requestmeta"
+	"github.com/cortexproject/cortex/pkg/util/responsecompare"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+const (
+	shadowResultMatch    = "match"
+	shadowResultMismatch = "mismatch"
+	shadowResultFailed   = "failed"
+	shadowResultSkipped  = "skipped"
+)
+
+// ShadowConfig configures the shadow mode of the query-frontend.
+type ShadowConfig struct {
+	SampleRatio   float64       `yaml:"sample_ratio"`
+	Tolerance     float64       `yaml:"tolerance"`
+	Timeout       time.Duration `yaml:"timeout"`
+	MaxConcurrent int           `yaml:"max_concurrent"`
+}
+
+func (cfg *ShadowConfig) RegisterFlags(f *flag.FlagSet) {
+	f.Float64Var(&cfg.SampleRatio, "frontend.query-shadow.sample-ratio", 0, "Experimental. Ratio of the successful instant and range queries executed a second time by the queriers with the shadow engine configuration (see -querier.shadow-engine.*). The results are compared and mismatches are logged and counted per tenant, without affecting the response. 0 to disable the shadow mode.")
+	f.Float64Var(&cfg.Tolerance, "frontend.query-shadow.tolerance", 0.000001, "Experimental. The tolerance to apply when comparing floating point values of the query results in shadow mode. 0 to require exact match.")
+	f.DurationVar(&cfg.Timeout, "frontend.query-shadow.timeout", 2*time.Minute, "Experimental. Timeout of the queries executed in shadow mode.")
+	f.IntVar(&cfg.MaxConcurrent, "frontend.query-shadow.max-concurrent", 10, "Experimental. Maximum number of queries executed in shadow mode concurrently. Sampled queries exceeding the limit are not executed in shadow mode.")
+}
+
+func (cfg *ShadowConfig) Validate() error {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return errors.New("frontend.query-shadow.sample-ratio must be between 0 and 1")
+	}
+	if cfg.SampleRatio > 0 && cfg.MaxConcurrent <= 0 {
+		return errors.New("frontend.query-shadow.max-concurrent must be greater than 0 when the shadow mode is enabled")
+	}
+	return nil
+}
+
+// shadowRoundTripper executes a sampled fraction of the queries a second time with the shadow engine,
+// and compares the results with the ones returned to the user.
+type shadowRoundTripper struct {
+	cfg        ShadowConfig
+	next       http.RoundTripper
+	log        log.Logger
+	comparator *responsecompare.SamplesComparator
+	inflight   chan struct{}
+
+	comparisons *prometheus.CounterVec
+	activeUsers *users.ActiveUsersCleanupService
+}
+
+// NewShadowRoundTripper wraps the round tripper to execute queries in shadow mode. The round tripper is returned
+// as is when the shadow mode is disabled.
+func NewShadowRoundTripper(cfg ShadowConfig, next http.RoundTripper, logger log.Logger, reg prometheus.Registerer) http.RoundTripper {
+	if cfg.SampleRatio <= 0 {
+		return next
+	}
+
+	s := &shadowRoundTripper{
+		cfg:        cfg,
+		next:       next,
+		log:        logger,
+		comparator: responsecompare.NewSamplesComparator(cfg.Tolerance),
+		inflight:   make(chan struct{}, cfg.MaxConcurrent),
+		comparisons: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_shadow_queries_total",
+			Help: "Total number of queries executed in shadow mode, partitioned by the result of the comparison with the response returned to the user.",
+		}, []string{"user", "result"}),
+	}
+	s.activeUsers = users.NewActiveUsersCleanupWithDefaultValues(s.cleanupMetricsForInactiveUser)
+	// If cleaner stops or fail, we will simply not clean the metrics for inactive users.
+	_ = s.activeUsers.StartAsync(context.Background())
+	return s
+}
+
+func (s *shadowRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := s.next.RoundTrip(r)
+	if err != nil || resp.StatusCode != http.StatusOK || !s.shouldShadow(r) {
+		return resp, err
+	}
+
+	tenantID, err := users.TenantID(r.Context())
+	if err != nil {
+		return resp, nil
+	}
+
+	// The response is buffered, as it is also compared to the shadow one.
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.activeUsers.UpdateUserTimestamp(tenantID, time.Now())
+	select {
+	case s.inflight <- struct{}{}:
+	default:
+		s.comparisons.WithLabelValues(tenantID, shadowResultSkipped).Inc()
+		return resp, nil
+	}
+
+	shadowReq, cancel := s.shadowRequest(r)
+	go func() {
+		defer func() { <-s.inflight }()
+		defer cancel()
+		s.compare(shadowReq, tenantID, body)
+	}()
+
+	return resp, nil
+}
+
+func (s *shadowRoundTripper) shouldShadow(r *http.Request) bool {
+	isQuery := strings.HasSuffix(r.URL.Path, "/query") || strings.HasSuffix(r.URL.Path, "/query_range")
+	return isQuery && rand.Float64() < s.cfg.SampleRatio
+}
+
+// shadowRequest creates the request executing the query with the shadow engine. The engine type is propagated to
+// the queriers through the request metadata, and the results cache is bypassed so that the query is actually executed.
+func (s *shadowRoundTripper) shadowRequest(r *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), s.cfg.Timeout)
+	// The statistics of the shadow query must not be added to the ones of the original query.
+	_, ctx = querier_stats.ContextWithEmptyStats(ctx)
+
+	metadata := map[string]string{}
+	maps.Copy(metadata, requestmeta.MapFromContext(ctx))
+	metadata[engine.TypeHeader] = string(engine.Shadow)
+	ctx = requestmeta.ContextWithRequestMetadataMap(ctx, metadata)
+
+	shadowReq := r.Clone(ctx)
+	shadowReq.Header.Set(engine.TypeHeader, string(engine.Shadow))
+	shadowReq.Header.Set("Cache-Control", "no-store")
+	return shadowReq, cancel
+}
+
+func (s *shadowRoundTripper) compare(shadowReq *http.Request, tenantID string, expected []byte) {
+	logger := log.With(s.log, "user", tenantID, "path", shadowReq.URL.Path, "query", shadowReq.FormValue("query"), "start", shadowReq.FormValue("start"), "end", shadowReq.FormValue("end"), "time", shadowReq.FormValue("time"), "step", shadowReq.FormValue("step"))
+
+	actual, err := s.execute(shadowReq)
+	if err != nil {
+		s.comparisons.WithLabelValues(tenantID, shadowResultFailed).Inc()
+		level.Warn(logger).Log("msg", "failed to execute query in shadow mode", "err", err)
+		return
+	}
+
+	if err := s.comparator.Compare(expected, actual); err != nil {
+		s.comparisons.WithLabelValues(tenantID, shadowResultMismatch).Inc()
+		level.Warn(logger).Log("msg", "query result mismatch in shadow mode", "err", err)
+		return
+	}
+	s.comparisons.WithLabelValues(tenantID, shadowResultMatch).Inc()
+}
+
+func (s *shadowRoundTripper) execute(r *http.Request) ([]byte, error) {
+	resp, err := s.next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+func (s *shadowRoundTripper) cleanupMetricsForInactiveUser(user string) {
+	if err := util.DeleteMatchingLabels(s.comparisons, map[string]string{"user": user}); err != nil {
+		level.Warn(s.log).Log("msg", "failed to remove cortex_frontend_shadow_queries_total metric for user", "user", user, "err", err)
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/engine"
+	"github.com/cortexproject/cortex/pkg/util/requestmeta"
+)
+
+const (
+	shadowTestExpectedResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"test"},"value":[1,"1"]}]}}`
+	shadowTestMismatchResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"test"},"value":[1,"2"]}]}}`
+)
+
+func TestShadowRoundTripper(t *testing.T) {
+	tests := map[string]struct {
+		path           string
+		shadowStatus   int
+		shadowResponse string
+		expectedResult string
+	}{
+		"should count a match when the shadow results are equal": {
+			path:           "/api/v1/query",
+			shadowStatus:   http.StatusOK,
+			shadowResponse: shadowTestExpectedResponse,
+			expectedResult: shadowResultMatch,
+		},
+		"should count a mismatch when the shadow results differ": {
+			path:           "/api/v1/query_range",
+			shadowStatus:   http.StatusOK,
+			shadowResponse: shadowTestMismatchResponse,
+			expectedResult: shadowResultMismatch,
+		},
+		"should count a failure when the shadow query fails": {
+			path:           "/api/v1/query",
+			shadowStatus:   http.StatusInternalServerError,
+			shadowResponse: `{"status":"error","error":"boom"}`,
+			expectedResult: shadowResultFailed,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			next := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if r.Header.Get(engine.TypeHeader) != string(engine.Shadow) {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(shadowTestExpectedResponse))}, nil
+				}
+
+				// The shadow query must bypass the results cache and propagate the engine type to the queriers.
+				assert.Equal(t, "no-store", r.Header.Get("Cache-Control"))
+				assert.Equal(t, string(engine.Shadow), requestmeta.MapFromContext(r.Context())[engine.TypeHeader])
+				return &http.Response{StatusCode: testData.shadowStatus, Body: io.NopCloser(strings.NewReader(testData.shadowResponse))}, nil
+			})
+
+			reg := prometheus.NewPedanticRegistry()
+			rt := NewShadowRoundTripper(ShadowConfig{SampleRatio: 1, Timeout: time.Minute, MaxConcurrent: 1}, next, log.NewNopLogger(), reg)
+
+			req := httptest.NewRequest(http.MethodGet, testData.path+"?query=up", nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+
+			// The response returned to the user is not affected by the shadow query.
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, shadowTestExpectedResponse, string(body))
+
+			require.Eventually(t, func() bool {
+				return promtest.ToFloat64(rt.(*shadowRoundTripper).comparisons.WithLabelValues("user-1", testData.expectedResult)) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestShadowRoundTripper_ShouldNotShadowNonQueryRequests(t *testing.T) {
+	calls := 0
+	next := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	rt := NewShadowRoundTripper(ShadowConfig{SampleRatio: 1, Timeout: time.Minute, MaxConcurrent: 1}, next, log.NewNopLogger(), reg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/series?match[]=up", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+	_, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, calls)
+	require.NoError(t, promtest.GatherAndCompare(reg, bytes.NewBufferString(""), "cortex_frontend_shadow_queries_total"))
+}
+
+func TestShadowRoundTripper_ShouldSkipWhenMaxConcurrentReached(t *testing.T) {
+	release := make(chan struct{})
+	next := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get(engine.TypeHeader) == string(engine.Shadow) {
+			<-release
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(shadowTestExpectedResponse))}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	rt := NewShadowRoundTripper(ShadowConfig{SampleRatio: 1, Timeout: time.Minute, MaxConcurrent: 1}, next, log.NewNopLogger(), reg)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+		_, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+	}
+	close(release)
+
+	require.Eventually(t, func() bool {
+		return promtest.ToFloat64(rt.(*shadowRoundTripper).comparisons.WithLabelValues("user-1", shadowResultMatch)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, float64(1), promtest.ToFloat64(rt.(*shadowRoundTripper).comparisons.WithLabelValues("user-1", shadowResultSkipped)))
+}
+
+func TestShadowRoundTripper_ShouldReturnNextWhenDisabled(t *testing.T) {
+	next := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	rt := NewShadowRoundTripper(ShadowConfig{}, next, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	_, ok := rt.(roundTripperFunc)
+	require.True(t, ok)
+}
@@ -126,8 +126,21 @@ func (f *frontendSchedulerWorkers) getWorkersCount() int {
 	return len(f.workers)
 }
 
+// Get clients for all schedulers this frontend is connected to, keyed by scheduler address.
+func (f *frontendSchedulerWorkers) getSchedulerClients() map[string]schedulerpb.SchedulerForFrontendClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	clients := make(map[string]schedulerpb.SchedulerForFrontendClient, len(f.workers))
+	for addr, w := range f.workers {
+		clients[addr] = schedulerpb.NewSchedulerForFrontendClient(w.conn)
+	}
+	return clients
+}
+
 func (f *frontendSchedulerWorkers) connectToScheduler(address string) (*grpc.ClientConn, error) {
-	// Because we only use single long-running method, it doesn't make sense to inject user ID, send over tracing or add metrics.
+	// Besides the single long-running method, this connection is only used by the operator facing running queries API,
+	// so it doesn't make sense to inject user ID, send over tracing or add metrics.
 	opts, err := f.cfg.GRPCClientConfig.DialOption(nil, nil)
 	if err != nil {
 		return nil, err
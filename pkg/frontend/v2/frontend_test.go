@@ -268,6 +268,9 @@ type mockScheduler struct {
 	mu           sync.Mutex
 	frontendAddr map[string]int
 	msgs         []*schedulerpb.FrontendToScheduler
+
+	runningQueries []*schedulerpb.RunningQuery
+	canceled       []*schedulerpb.CancelRunningQueryRequest
 }
 
 func newMockScheduler(t *testing.T, f *Frontend, replyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend) *mockScheduler {
@@ -316,3 +319,23 @@ func (m *mockScheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_F
 		}
 	}
 }
+
+func (m *mockScheduler) GetRunningQueries(_ context.Context, _ *schedulerpb.GetRunningQueriesRequest) (*schedulerpb.GetRunningQueriesResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return &schedulerpb.GetRunningQueriesResponse{Queries: m.runningQueries}, nil
+}
+
+func (m *mockScheduler) CancelRunningQuery(_ context.Context, req *schedulerpb.CancelRunningQueryRequest) (*schedulerpb.CancelRunningQueryResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, q := range m.runningQueries {
+		if q.FrontendAddress == req.FrontendAddress && q.QueryID == req.QueryID {
+			m.canceled = append(m.canceled, req)
+			return &schedulerpb.CancelRunningQueryResponse{Canceled: true}, nil
+		}
+	}
+	return &schedulerpb.CancelRunningQueryResponse{Canceled: false}, nil
+}
@@ -0,0 +1,239 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+
+	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_api "github.com/cortexproject/cortex/pkg/util/api"
+)
+
+const (
+	runningQueryStateQueued  = "queued"
+	runningQueryStateRunning = "running"
+)
+
+// RunningQuery describes a query tracked by the query-schedulers, aggregated across all its fragments.
+type RunningQuery struct {
+	ID             string    `json:"id"`
+	Tenant         string    `json:"tenant"`
+	Query          string    `json:"query"`
+	State          string    `json:"state"`
+	EnqueueTime    time.Time `json:"enqueue_time"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	FetchedBytes   uint64    `json:"fetched_bytes"`
+	Frontend       string    `json:"frontend"`
+	Schedulers     []string  `json:"schedulers"`
+	Queriers       []string  `json:"queriers,omitempty"`
+}
+
+// RunningQueriesHandler lists the queries currently queued or running in the cluster, as tracked by all
+// query-schedulers this frontend is connected to. Results can be filtered with the "tenant" parameter.
+func (f *Frontend) RunningQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := r.FormValue("tenant")
+
+	queries, warnings, err := f.getRunningQueries(r.Context())
+	if err != nil {
+		util_api.RespondError(f.log, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if tenant != "" {
+		filtered := queries[:0]
+		for _, q := range queries {
+			if q.Tenant == tenant {
+				filtered = append(filtered, q)
+			}
+		}
+		queries = filtered
+	}
+
+	util.WriteJSONResponse(w, util_api.Response{
+		Status:   "success",
+		Data:     queries,
+		Warnings: warnings,
+	})
+}
+
+// CancelRunningQueryHandler cancels the query with the given ID, as returned by RunningQueriesHandler.
+func (f *Frontend) CancelRunningQueryHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	frontendAddr, queryID, err := parseRunningQueryID(id)
+	if err != nil {
+		util_api.RespondError(f.log, w, v1.ErrBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	canceled, err := f.cancelRunningQuery(r.Context(), frontendAddr, queryID)
+	if err != nil {
+		util_api.RespondError(f.log, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !canceled {
+		util_api.RespondError(f.log, w, v1.ErrBadData, fmt.Sprintf("query %s not found", id), http.StatusNotFound)
+		return
+	}
+
+	util.WriteJSONResponse(w, util_api.Response{Status: "success"})
+}
+
+// getRunningQueries collects the running queries from all connected schedulers. Errors from individual
+// schedulers are returned as warnings, unless no scheduler could be reached.
+func (f *Frontend) getRunningQueries(ctx context.Context) ([]*RunningQuery, []string, error) {
+	clients := f.schedulerWorkers.getSchedulerClients()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		warnings []string
+		byID     = map[string]*RunningQuery{}
+	)
+
+	for addr, c := range clients {
+		wg.Go(func() {
+			resp, err := c.GetRunningQueries(ctx, &schedulerpb.GetRunningQueriesRequest{})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				level.Warn(f.log).Log("msg", "failed to get running queries from scheduler", "addr", addr, "err", err)
+				warnings = append(warnings, fmt.Sprintf("failed to get running queries from scheduler %s: %v", addr, err))
+				return
+			}
+
+			for _, q := range resp.Queries {
+				mergeRunningQuery(byID, addr, q)
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(clients) > 0 && len(warnings) == len(clients) {
+		return nil, nil, fmt.Errorf("failed to get running queries from all %d schedulers", len(clients))
+	}
+
+	now := time.Now()
+	queries := make([]*RunningQuery, 0, len(byID))
+	for _, q := range byID {
+		q.ElapsedSeconds = now.Sub(q.EnqueueTime).Seconds()
+		sort.Strings(q.Schedulers)
+		sort.Strings(q.Queriers)
+		queries = append(queries, q)
+	}
+
+	// Show the longest running queries first.
+	sort.Slice(queries, func(i, j int) bool {
+		if !queries[i].EnqueueTime.Equal(queries[j].EnqueueTime) {
+			return queries[i].EnqueueTime.Before(queries[j].EnqueueTime)
+		}
+		return queries[i].ID < queries[j].ID
+	})
+
+	return queries, warnings, nil
+}
+
+// mergeRunningQuery adds a single query fragment reported by a scheduler to the per-query aggregation.
+func mergeRunningQuery(byID map[string]*RunningQuery, schedulerAddr string, q *schedulerpb.RunningQuery) {
+	id := formatRunningQueryID(q.FrontendAddress, q.QueryID)
+	enqueueTime := time.UnixMilli(q.EnqueueTimeMs)
+
+	rq, ok := byID[id]
+	if !ok {
+		rq = &RunningQuery{
+			ID:          id,
+			Tenant:      q.UserID,
+			Query:       q.Query,
+			State:       runningQueryStateQueued,
+			EnqueueTime: enqueueTime,
+			Frontend:    q.FrontendAddress,
+		}
+		byID[id] = rq
+	}
+
+	if enqueueTime.Before(rq.EnqueueTime) {
+		rq.EnqueueTime = enqueueTime
+	}
+	if !slices.Contains(rq.Schedulers, schedulerAddr) {
+		rq.Schedulers = append(rq.Schedulers, schedulerAddr)
+	}
+	if q.QuerierAddress != "" {
+		rq.State = runningQueryStateRunning
+		if !slices.Contains(rq.Queriers, q.QuerierAddress) {
+			rq.Queriers = append(rq.Queriers, q.QuerierAddress)
+		}
+	}
+	rq.FetchedBytes += q.FetchedBytes
+}
+
+// cancelRunningQuery asks all connected schedulers to cancel the query. Only the scheduler tracking
+// the query will cancel it.
+func (f *Frontend) cancelRunningQuery(ctx context.Context, frontendAddr string, queryID uint64) (bool, error) {
+	clients := f.schedulerWorkers.getSchedulerClients()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		canceled bool
+		failures int
+		lastErr  error
+	)
+
+	for addr, c := range clients {
+		wg.Go(func() {
+			resp, err := c.CancelRunningQuery(ctx, &schedulerpb.CancelRunningQueryRequest{
+				FrontendAddress: frontendAddr,
+				QueryID:         queryID,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				level.Warn(f.log).Log("msg", "failed to cancel running query on scheduler", "addr", addr, "err", err)
+				failures++
+				lastErr = err
+				return
+			}
+			canceled = canceled || resp.Canceled
+		})
+	}
+	wg.Wait()
+
+	if !canceled && failures > 0 {
+		return false, lastErr
+	}
+	return canceled, nil
+}
+
+// The running query ID is made of the query ID and the address of the frontend which enqueued it,
+// because each frontend manages its own query IDs.
+func formatRunningQueryID(frontendAddr string, queryID uint64) string {
+	return strconv.FormatUint(queryID, 10) + "@" + frontendAddr
+}
+
+func parseRunningQueryID(id string) (string, uint64, error) {
+	rawQueryID, frontendAddr, ok := strings.Cut(id, "@")
+	if !ok || frontendAddr == "" {
+		return "", 0, fmt.Errorf("invalid query id %q", id)
+	}
+
+	queryID, err := strconv.ParseUint(rawQueryID, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid query id %q", id)
+	}
+	return frontendAddr, queryID, nil
+}
@@ -0,0 +1,135 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
+)
+
+func TestFrontendRunningQueriesHandler(t *testing.T) {
+	f, ms := setupFrontend(t, nil, 0)
+
+	now := time.Now()
+	ms.checkWithLock(func() {
+		ms.runningQueries = []*schedulerpb.RunningQuery{
+			// Two fragments of the same query, running on different queriers.
+			{FrontendAddress: "frontend-1:9095", QueryID: 10, FragmentID: 1, UserID: "user-1", Query: "sum(up)", EnqueueTimeMs: now.Add(-time.Minute).UnixMilli(), QuerierAddress: "querier-1:9095", FetchedBytes: 100},
+			{FrontendAddress: "frontend-1:9095", QueryID: 10, FragmentID: 2, UserID: "user-1", Query: "sum(up)", EnqueueTimeMs: now.Add(-time.Minute).UnixMilli(), QuerierAddress: "querier-2:9095", FetchedBytes: 50},
+			// A query still waiting in the queue.
+			{FrontendAddress: "frontend-2:9095", QueryID: 10, UserID: "user-2", Query: "up", EnqueueTimeMs: now.Add(-time.Second).UnixMilli()},
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		url         string
+		expectedIDs []string
+	}{
+		"all tenants": {
+			url:         "/api/v1/status/running_queries",
+			expectedIDs: []string{"10@frontend-1:9095", "10@frontend-2:9095"},
+		},
+		"filtered by tenant": {
+			url:         "/api/v1/status/running_queries?tenant=user-2",
+			expectedIDs: []string{"10@frontend-2:9095"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			f.RunningQueriesHandler(rec, httptest.NewRequest(http.MethodGet, tc.url, nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var resp struct {
+				Status string          `json:"status"`
+				Data   []*RunningQuery `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Equal(t, "success", resp.Status)
+
+			ids := make([]string, 0, len(resp.Data))
+			for _, q := range resp.Data {
+				ids = append(ids, q.ID)
+			}
+			require.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	f.RunningQueriesHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status/running_queries", nil))
+
+	var resp struct {
+		Data []*RunningQuery `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	running := resp.Data[0]
+	require.Equal(t, "user-1", running.Tenant)
+	require.Equal(t, "sum(up)", running.Query)
+	require.Equal(t, runningQueryStateRunning, running.State)
+	require.Equal(t, uint64(150), running.FetchedBytes)
+	require.Equal(t, []string{"querier-1:9095", "querier-2:9095"}, running.Queriers)
+	require.GreaterOrEqual(t, running.ElapsedSeconds, 60.0)
+
+	queued := resp.Data[1]
+	require.Equal(t, runningQueryStateQueued, queued.State)
+	require.Empty(t, queued.Queriers)
+}
+
+func TestFrontendCancelRunningQueryHandler(t *testing.T) {
+	f, ms := setupFrontend(t, nil, 0)
+
+	ms.checkWithLock(func() {
+		ms.runningQueries = []*schedulerpb.RunningQuery{
+			{FrontendAddress: "frontend-1:9095", QueryID: 10, UserID: "user-1", Query: "sum(up)"},
+		}
+	})
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/status/running_queries/{id}").Methods(http.MethodDelete).HandlerFunc(f.CancelRunningQueryHandler)
+
+	for name, tc := range map[string]struct {
+		id           string
+		expectedCode int
+	}{
+		"invalid id": {
+			id:           "frontend-1:9095",
+			expectedCode: http.StatusBadRequest,
+		},
+		"unknown query": {
+			id:           "11@frontend-1:9095",
+			expectedCode: http.StatusNotFound,
+		},
+		"running query": {
+			id:           "10@frontend-1:9095",
+			expectedCode: http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/status/running_queries/"+tc.id, nil))
+			require.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	ms.checkWithLock(func() {
+		require.Equal(t, []*schedulerpb.CancelRunningQueryRequest{{FrontendAddress: "frontend-1:9095", QueryID: 10}}, ms.canceled)
+	})
+}
+
+func TestParseRunningQueryID(t *testing.T) {
+	addr, queryID, err := parseRunningQueryID(formatRunningQueryID("10.0.0.1:9095", 12345))
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:9095", addr)
+	require.Equal(t, uint64(12345), queryID)
+
+	for _, id := range []string{"", "12345", "12345@", "abc@10.0.0.1:9095"} {
+		_, _, err := parseRunningQueryID(id)
+		require.Error(t, err, id)
+	}
+}
@@ -81,3 +81,13 @@ func (m *IngesterServerMock) MetricsMetadata(ctx context.Context, r *MetricsMeta
 	args := m.Called(ctx, r)
 	return args.Get(0).(*MetricsMetadataResponse), args.Error(1)
 }
+
+func (m *IngesterServerMock) ExportTenant(r *ExportTenantRequest, s Ingester_ExportTenantServer) error {
+	args := m.Called(r, s)
+	return args.Error(0)
+}
+
+func (m *IngesterServerMock) ImportTenant(s Ingester_ImportTenantServer) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
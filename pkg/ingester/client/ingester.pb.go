@@ -398,6 +398,194 @@ func (m *ExemplarQueryResponse) GetTimeseries() []cortexpb.TimeSeries {
 	return nil
 }
 
+type ExportTenantRequest struct {
+	StartTimestampMs int64 `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
+	EndTimestampMs   int64 `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
+}
+
+func (m *ExportTenantRequest) Reset()      { *m = ExportTenantRequest{} }
+func (*ExportTenantRequest) ProtoMessage() {}
+func (*ExportTenantRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{7}
+}
+func (m *ExportTenantRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExportTenantRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExportTenantRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExportTenantRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportTenantRequest.Merge(m, src)
+}
+func (m *ExportTenantRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExportTenantRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportTenantRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportTenantRequest proto.InternalMessageInfo
+
+func (m *ExportTenantRequest) GetStartTimestampMs() int64 {
+	if m != nil {
+		return m.StartTimestampMs
+	}
+	return 0
+}
+
+func (m *ExportTenantRequest) GetEndTimestampMs() int64 {
+	if m != nil {
+		return m.EndTimestampMs
+	}
+	return 0
+}
+
+type ExportTenantResponse struct {
+	Chunkseries []TimeSeriesChunk `protobuf:"bytes,1,rep,name=chunkseries,proto3" json:"chunkseries"`
+}
+
+func (m *ExportTenantResponse) Reset()      { *m = ExportTenantResponse{} }
+func (*ExportTenantResponse) ProtoMessage() {}
+func (*ExportTenantResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{8}
+}
+func (m *ExportTenantResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExportTenantResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExportTenantResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExportTenantResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportTenantResponse.Merge(m, src)
+}
+func (m *ExportTenantResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExportTenantResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportTenantResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportTenantResponse proto.InternalMessageInfo
+
+func (m *ExportTenantResponse) GetChunkseries() []TimeSeriesChunk {
+	if m != nil {
+		return m.Chunkseries
+	}
+	return nil
+}
+
+type ImportTenantRequest struct {
+	Chunkseries []TimeSeriesChunk `protobuf:"bytes,1,rep,name=chunkseries,proto3" json:"chunkseries"`
+}
+
+func (m *ImportTenantRequest) Reset()      { *m = ImportTenantRequest{} }
+func (*ImportTenantRequest) ProtoMessage() {}
+func (*ImportTenantRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{9}
+}
+func (m *ImportTenantRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ImportTenantRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ImportTenantRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ImportTenantRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportTenantRequest.Merge(m, src)
+}
+func (m *ImportTenantRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ImportTenantRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportTenantRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportTenantRequest proto.InternalMessageInfo
+
+func (m *ImportTenantRequest) GetChunkseries() []TimeSeriesChunk {
+	if m != nil {
+		return m.Chunkseries
+	}
+	return nil
+}
+
+type ImportTenantResponse struct {
+	Series  int64 `protobuf:"varint,1,opt,name=series,proto3" json:"series,omitempty"`
+	Samples int64 `protobuf:"varint,2,opt,name=samples,proto3" json:"samples,omitempty"`
+}
+
+func (m *ImportTenantResponse) Reset()      { *m = ImportTenantResponse{} }
+func (*ImportTenantResponse) ProtoMessage() {}
+func (*ImportTenantResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{10}
+}
+func (m *ImportTenantResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ImportTenantResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ImportTenantResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ImportTenantResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportTenantResponse.Merge(m, src)
+}
+func (m *ImportTenantResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ImportTenantResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportTenantResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportTenantResponse proto.InternalMessageInfo
+
+func (m *ImportTenantResponse) GetSeries() int64 {
+	if m != nil {
+		return m.Series
+	}
+	return 0
+}
+
+func (m *ImportTenantResponse) GetSamples() int64 {
+	if m != nil {
+		return m.Samples
+	}
+	return 0
+}
+
 type LabelValuesRequest struct {
 	LabelName        string         `protobuf:"bytes,1,opt,name=label_name,json=labelName,proto3" json:"label_name,omitempty"`
 	StartTimestampMs int64          `protobuf:"varint,2,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
@@ -409,7 +597,7 @@ type LabelValuesRequest struct {
 func (m *LabelValuesRequest) Reset()      { *m = LabelValuesRequest{} }
 func (*LabelValuesRequest) ProtoMessage() {}
 func (*LabelValuesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{7}
+	return fileDescriptor_60f6df4f3586b478, []int{11}
 }
 func (m *LabelValuesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -480,7 +668,7 @@ type LabelValuesResponse struct {
 func (m *LabelValuesResponse) Reset()      { *m = LabelValuesResponse{} }
 func (*LabelValuesResponse) ProtoMessage() {}
 func (*LabelValuesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{8}
+	return fileDescriptor_60f6df4f3586b478, []int{12}
 }
 func (m *LabelValuesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -523,7 +711,7 @@ type LabelValuesStreamResponse struct {
 func (m *LabelValuesStreamResponse) Reset()      { *m = LabelValuesStreamResponse{} }
 func (*LabelValuesStreamResponse) ProtoMessage() {}
 func (*LabelValuesStreamResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{9}
+	return fileDescriptor_60f6df4f3586b478, []int{13}
 }
 func (m *LabelValuesStreamResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -569,7 +757,7 @@ type LabelNamesRequest struct {
 func (m *LabelNamesRequest) Reset()      { *m = LabelNamesRequest{} }
 func (*LabelNamesRequest) ProtoMessage() {}
 func (*LabelNamesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{10}
+	return fileDescriptor_60f6df4f3586b478, []int{14}
 }
 func (m *LabelNamesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -633,7 +821,7 @@ type LabelNamesResponse struct {
 func (m *LabelNamesResponse) Reset()      { *m = LabelNamesResponse{} }
 func (*LabelNamesResponse) ProtoMessage() {}
 func (*LabelNamesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{11}
+	return fileDescriptor_60f6df4f3586b478, []int{15}
 }
 func (m *LabelNamesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -676,7 +864,7 @@ type LabelNamesStreamResponse struct {
 func (m *LabelNamesStreamResponse) Reset()      { *m = LabelNamesStreamResponse{} }
 func (*LabelNamesStreamResponse) ProtoMessage() {}
 func (*LabelNamesStreamResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{12}
+	return fileDescriptor_60f6df4f3586b478, []int{16}
 }
 func (m *LabelNamesStreamResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -718,7 +906,7 @@ type UserStatsRequest struct {
 func (m *UserStatsRequest) Reset()      { *m = UserStatsRequest{} }
 func (*UserStatsRequest) ProtoMessage() {}
 func (*UserStatsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{13}
+	return fileDescriptor_60f6df4f3586b478, []int{17}
 }
 func (m *UserStatsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -759,7 +947,7 @@ type UserStatsResponse struct {
 func (m *UserStatsResponse) Reset()      { *m = UserStatsResponse{} }
 func (*UserStatsResponse) ProtoMessage() {}
 func (*UserStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{14}
+	return fileDescriptor_60f6df4f3586b478, []int{18}
 }
 func (m *UserStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -838,7 +1026,7 @@ type UserIDStatsResponse struct {
 func (m *UserIDStatsResponse) Reset()      { *m = UserIDStatsResponse{} }
 func (*UserIDStatsResponse) ProtoMessage() {}
 func (*UserIDStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{15}
+	return fileDescriptor_60f6df4f3586b478, []int{19}
 }
 func (m *UserIDStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -888,7 +1076,7 @@ type UsersStatsResponse struct {
 func (m *UsersStatsResponse) Reset()      { *m = UsersStatsResponse{} }
 func (*UsersStatsResponse) ProtoMessage() {}
 func (*UsersStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{16}
+	return fileDescriptor_60f6df4f3586b478, []int{20}
 }
 func (m *UsersStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -934,7 +1122,7 @@ type MetricsForLabelMatchersRequest struct {
 func (m *MetricsForLabelMatchersRequest) Reset()      { *m = MetricsForLabelMatchersRequest{} }
 func (*MetricsForLabelMatchersRequest) ProtoMessage() {}
 func (*MetricsForLabelMatchersRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{17}
+	return fileDescriptor_60f6df4f3586b478, []int{21}
 }
 func (m *MetricsForLabelMatchersRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -998,7 +1186,7 @@ type MetricsForLabelMatchersResponse struct {
 func (m *MetricsForLabelMatchersResponse) Reset()      { *m = MetricsForLabelMatchersResponse{} }
 func (*MetricsForLabelMatchersResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{18}
+	return fileDescriptor_60f6df4f3586b478, []int{22}
 }
 func (m *MetricsForLabelMatchersResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1041,7 +1229,7 @@ type MetricsForLabelMatchersStreamResponse struct {
 func (m *MetricsForLabelMatchersStreamResponse) Reset()      { *m = MetricsForLabelMatchersStreamResponse{} }
 func (*MetricsForLabelMatchersStreamResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersStreamResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{19}
+	return fileDescriptor_60f6df4f3586b478, []int{23}
 }
 func (m *MetricsForLabelMatchersStreamResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1086,7 +1274,7 @@ type MetricsMetadataRequest struct {
 func (m *MetricsMetadataRequest) Reset()      { *m = MetricsMetadataRequest{} }
 func (*MetricsMetadataRequest) ProtoMessage() {}
 func (*MetricsMetadataRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{20}
+	return fileDescriptor_60f6df4f3586b478, []int{24}
 }
 func (m *MetricsMetadataRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1143,7 +1331,7 @@ type MetricsMetadataResponse struct {
 func (m *MetricsMetadataResponse) Reset()      { *m = MetricsMetadataResponse{} }
 func (*MetricsMetadataResponse) ProtoMessage() {}
 func (*MetricsMetadataResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{21}
+	return fileDescriptor_60f6df4f3586b478, []int{25}
 }
 func (m *MetricsMetadataResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1189,7 +1377,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{22}
+	return fileDescriptor_60f6df4f3586b478, []int{26}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1249,7 +1437,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{23}
+	return fileDescriptor_60f6df4f3586b478, []int{27}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1313,7 +1501,7 @@ type LabelMatchers struct {
 func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
 func (*LabelMatchers) ProtoMessage() {}
 func (*LabelMatchers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{24}
+	return fileDescriptor_60f6df4f3586b478, []int{28}
 }
 func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1358,7 +1546,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{25}
+	return fileDescriptor_60f6df4f3586b478, []int{29}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1418,7 +1606,7 @@ type TimeSeriesFile struct {
 func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
 func (*TimeSeriesFile) ProtoMessage() {}
 func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+	return fileDescriptor_60f6df4f3586b478, []int{30}
 }
 func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1484,6 +1672,10 @@ func init() {
 	proto.RegisterType((*ExemplarQueryRequest)(nil), "cortex.ExemplarQueryRequest")
 	proto.RegisterType((*QueryStreamResponse)(nil), "cortex.QueryStreamResponse")
 	proto.RegisterType((*ExemplarQueryResponse)(nil), "cortex.ExemplarQueryResponse")
+	proto.RegisterType((*ExportTenantRequest)(nil), "cortex.ExportTenantRequest")
+	proto.RegisterType((*ExportTenantResponse)(nil), "cortex.ExportTenantResponse")
+	proto.RegisterType((*ImportTenantRequest)(nil), "cortex.ImportTenantRequest")
+	proto.RegisterType((*ImportTenantResponse)(nil), "cortex.ImportTenantResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "cortex.LabelValuesRequest")
 	proto.RegisterType((*LabelValuesResponse)(nil), "cortex.LabelValuesResponse")
 	proto.RegisterType((*LabelValuesStreamResponse)(nil), "cortex.LabelValuesStreamResponse")
@@ -1509,97 +1701,103 @@ func init() {
 func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
 
 var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1439 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x4b, 0x73, 0x13, 0x47,
-	0x10, 0xd6, 0xea, 0x65, 0xa9, 0xf5, 0x40, 0x1e, 0x1b, 0x2c, 0x44, 0x58, 0xc1, 0x52, 0x24, 0xaa,
-	0x24, 0xc8, 0xe0, 0x24, 0x55, 0x90, 0x07, 0x94, 0x05, 0x06, 0x0c, 0x18, 0xc3, 0xda, 0x40, 0x2a,
-	0x95, 0xd4, 0xd6, 0x5a, 0x1a, 0xcb, 0x1b, 0x76, 0xb5, 0xcb, 0xce, 0x88, 0x02, 0x4e, 0x49, 0xe5,
-	0x07, 0x24, 0x87, 0xfc, 0x81, 0xdc, 0x72, 0x4d, 0x55, 0x7e, 0x04, 0x47, 0x1f, 0x72, 0xa0, 0x38,
-	0xb8, 0x82, 0xb8, 0x24, 0x37, 0xf2, 0x0f, 0x52, 0x3b, 0x33, 0xfb, 0xb4, 0xfc, 0x20, 0x05, 0xb9,
-	0x69, 0xbb, 0xbf, 0xe9, 0xe9, 0xfe, 0xe6, 0x9b, 0xe9, 0xb6, 0xa1, 0x6a, 0x0c, 0xfa, 0x98, 0x50,
-	0xec, 0xb6, 0x1d, 0xd7, 0xa6, 0x36, 0xca, 0x77, 0x6d, 0x97, 0xe2, 0x47, 0x8d, 0xe9, 0xbe, 0xdd,
-	0xb7, 0x99, 0x69, 0xd6, 0xfb, 0xc5, 0xbd, 0x8d, 0x73, 0x7d, 0x83, 0x6e, 0x0c, 0xd7, 0xda, 0x5d,
-	0xdb, 0x9a, 0xe5, 0x40, 0xc7, 0xb5, 0xbf, 0xc5, 0x5d, 0x2a, 0xbe, 0x66, 0x9d, 0xfb, 0x7d, 0xdf,
-	0xb1, 0x26, 0x7e, 0xf0, 0xa5, 0xca, 0x17, 0x50, 0x52, 0xb1, 0xde, 0x53, 0xf1, 0x83, 0x21, 0x26,
-	0x14, 0xb5, 0x61, 0xe2, 0xc1, 0x10, 0xbb, 0x06, 0x26, 0x75, 0xe9, 0x58, 0xa6, 0x55, 0x9a, 0x9b,
-	0x6e, 0x0b, 0xf8, 0xed, 0x21, 0x76, 0x1f, 0x0b, 0x98, 0xea, 0x83, 0x94, 0x0b, 0x50, 0xe6, 0xcb,
-	0x89, 0x63, 0x0f, 0x08, 0x46, 0xb3, 0x30, 0xe1, 0x62, 0x32, 0x34, 0xa9, 0xbf, 0xfe, 0x60, 0x62,
-	0x3d, 0xc7, 0xa9, 0x3e, 0x4a, 0xb9, 0x0e, 0x95, 0x98, 0x07, 0x7d, 0x0a, 0x40, 0x0d, 0x0b, 0x93,
-	0x71, 0x49, 0x38, 0x6b, 0xed, 0x55, 0xc3, 0xc2, 0x2b, 0xcc, 0xd7, 0xc9, 0x3e, 0xdd, 0x6a, 0xa6,
-	0xd4, 0x08, 0x5a, 0xf9, 0x39, 0x0d, 0xe5, 0x68, 0x9e, 0xe8, 0x43, 0x40, 0x84, 0xea, 0x2e, 0xd5,
-	0x18, 0x88, 0xea, 0x96, 0xa3, 0x59, 0x5e, 0x50, 0xa9, 0x95, 0x51, 0x6b, 0xcc, 0xb3, 0xea, 0x3b,
-	0x96, 0x08, 0x6a, 0x41, 0x0d, 0x0f, 0x7a, 0x71, 0x6c, 0x9a, 0x61, 0xab, 0x78, 0xd0, 0x8b, 0x22,
-	0x4f, 0x43, 0xc1, 0xd2, 0x69, 0x77, 0x03, 0xbb, 0xa4, 0x9e, 0x89, 0xf3, 0x74, 0x43, 0x5f, 0xc3,
-	0xe6, 0x12, 0x77, 0xaa, 0x01, 0x0a, 0x3d, 0x81, 0x8c, 0x8a, 0xd7, 0xeb, 0x7f, 0x4f, 0x1c, 0x93,
-	0x5a, 0xa5, 0xb9, 0x23, 0x61, 0x41, 0x4b, 0x98, 0x10, 0xbd, 0x8f, 0xef, 0x19, 0x74, 0xa3, 0x33,
-	0x5c, 0x57, 0xf1, 0x7a, 0xe7, 0x9a, 0x57, 0xd7, 0xe6, 0x56, 0x53, 0x7a, 0xbe, 0xd5, 0x3c, 0xff,
-	0x3a, 0x27, 0xbb, 0x3d, 0x96, 0xea, 0x6d, 0xaa, 0xfc, 0x22, 0xc1, 0xf4, 0xc2, 0x23, 0x6c, 0x39,
-	0xa6, 0xee, 0xfe, 0x2f, 0xf4, 0x9c, 0xd9, 0x46, 0xcf, 0xc1, 0x71, 0xf4, 0x90, 0x90, 0x1f, 0xe5,
-	0x6b, 0x98, 0x62, 0xa9, 0xad, 0x50, 0x17, 0xeb, 0x56, 0xa0, 0x86, 0x0b, 0x50, 0xea, 0x6e, 0x0c,
-	0x07, 0xf7, 0x63, 0x72, 0x98, 0xf1, 0x83, 0x85, 0x62, 0xb8, 0xe8, 0x81, 0x84, 0x22, 0xa2, 0x2b,
-	0xae, 0x65, 0x0b, 0xe9, 0x5a, 0x46, 0x59, 0x81, 0x83, 0x09, 0x02, 0xde, 0x80, 0xda, 0xfe, 0x90,
-	0x00, 0xb1, 0x72, 0xee, 0xea, 0xe6, 0x10, 0x13, 0x9f, 0xd4, 0xa3, 0x00, 0xa6, 0x67, 0xd5, 0x06,
-	0xba, 0x85, 0x19, 0x99, 0x45, 0xb5, 0xc8, 0x2c, 0x37, 0x75, 0x0b, 0xef, 0xc0, 0x79, 0xfa, 0x35,
-	0x38, 0xcf, 0xec, 0xc9, 0x79, 0x96, 0x89, 0x6c, 0x2f, 0xce, 0xd1, 0x34, 0xe4, 0x4c, 0xc3, 0x32,
-	0x68, 0x3d, 0xc7, 0x22, 0xf2, 0x0f, 0xe5, 0x2c, 0x4c, 0xc5, 0xaa, 0x12, 0x4c, 0x1d, 0x87, 0x32,
-	0x2f, 0xeb, 0x21, 0xb3, 0x33, 0xae, 0x8a, 0x6a, 0xc9, 0x0c, 0xa1, 0xca, 0x79, 0x38, 0x1c, 0x59,
-	0x99, 0x38, 0xc9, 0x7d, 0xac, 0xff, 0x5d, 0x82, 0xc9, 0x1b, 0x3e, 0x51, 0xe4, 0x6d, 0x8b, 0x34,
-	0xa8, 0x3e, 0x13, 0xa9, 0xfe, 0x3f, 0xd0, 0xa8, 0x7c, 0x22, 0x64, 0x20, 0xb2, 0x16, 0xf5, 0x36,
-	0xa1, 0x14, 0xca, 0xc0, 0x2f, 0x17, 0x02, 0x1d, 0x10, 0xe5, 0x33, 0xa8, 0x87, 0xcb, 0x12, 0x64,
-	0xed, 0xb9, 0x18, 0x41, 0xed, 0x0e, 0xc1, 0xee, 0x0a, 0xd5, 0xa9, 0x4f, 0x94, 0xf2, 0x7d, 0x1a,
-	0x26, 0x23, 0x46, 0x11, 0xea, 0xa4, 0xdf, 0x4b, 0x0c, 0x7b, 0xa0, 0xb9, 0x3a, 0xe5, 0x92, 0x94,
-	0xd4, 0x4a, 0x60, 0x55, 0x75, 0x8a, 0x3d, 0xd5, 0x0e, 0x86, 0x96, 0x26, 0x2e, 0x82, 0xc7, 0x58,
-	0x56, 0x2d, 0x0e, 0x86, 0x16, 0x57, 0xbf, 0x77, 0x08, 0xba, 0x63, 0x68, 0x89, 0x48, 0x19, 0x16,
-	0xa9, 0xa6, 0x3b, 0xc6, 0x62, 0x2c, 0x58, 0x1b, 0xa6, 0xdc, 0xa1, 0x89, 0x93, 0xf0, 0x2c, 0x83,
-	0x4f, 0x7a, 0xae, 0x38, 0xfe, 0x04, 0x54, 0xf4, 0x2e, 0x35, 0x1e, 0x62, 0x7f, 0xff, 0x1c, 0xdb,
-	0xbf, 0xcc, 0x8d, 0x22, 0x85, 0x13, 0x50, 0x31, 0x6d, 0xbd, 0x87, 0x7b, 0xda, 0x9a, 0x69, 0x77,
-	0xef, 0x93, 0x7a, 0x9e, 0x83, 0xb8, 0xb1, 0xc3, 0x6c, 0xca, 0x37, 0x30, 0xe5, 0x51, 0xb0, 0x78,
-	0x29, 0x4e, 0xc2, 0x0c, 0x4c, 0x0c, 0x09, 0x76, 0x35, 0xa3, 0x27, 0x2e, 0x64, 0xde, 0xfb, 0x5c,
-	0xec, 0xa1, 0x53, 0x90, 0xed, 0xe9, 0x54, 0x67, 0x05, 0x97, 0xe6, 0x0e, 0xfb, 0x47, 0xbd, 0x8d,
-	0x46, 0x95, 0xc1, 0x94, 0x2b, 0x80, 0x3c, 0x17, 0x89, 0x47, 0x3f, 0x03, 0x39, 0xe2, 0x19, 0xc4,
-	0xfb, 0x71, 0x24, 0x1a, 0x25, 0x91, 0x89, 0xca, 0x91, 0xca, 0x53, 0x09, 0xe4, 0x25, 0x4c, 0x5d,
-	0xa3, 0x4b, 0x2e, 0xdb, 0x6e, 0x5c, 0x59, 0x6f, 0x59, 0xf7, 0x67, 0xa1, 0xec, 0x4b, 0x57, 0x23,
-	0x98, 0xee, 0xfe, 0x40, 0x97, 0x7c, 0xe8, 0x0a, 0xa6, 0xe1, 0x8d, 0xc9, 0x46, 0xdf, 0x8b, 0xeb,
-	0xd0, 0xdc, 0xb1, 0x12, 0x41, 0x50, 0x0b, 0xf2, 0x16, 0x83, 0x08, 0x86, 0x6a, 0xd1, 0xf6, 0xe7,
-	0xd9, 0x55, 0xe1, 0x57, 0x6e, 0xc3, 0xc9, 0x1d, 0x82, 0x25, 0x6e, 0xc8, 0xfe, 0x43, 0x3a, 0x70,
-	0x48, 0x84, 0x5c, 0xc2, 0x54, 0xf7, 0x8e, 0xd1, 0x67, 0x38, 0xa8, 0x47, 0x8a, 0xbe, 0x00, 0x2d,
-	0xa8, 0xb1, 0x1f, 0x9a, 0x83, 0x5d, 0x4d, 0xec, 0x21, 0x98, 0x64, 0xf6, 0x5b, 0xd8, 0xe5, 0xf1,
-	0xd0, 0xa1, 0x20, 0x87, 0x0c, 0x17, 0x95, 0xd8, 0x71, 0x19, 0x66, 0xb6, 0xed, 0x28, 0xd2, 0xfe,
-	0x18, 0x0a, 0x96, 0xb0, 0x89, 0xc4, 0xeb, 0xc9, 0xc4, 0x83, 0x35, 0x01, 0x52, 0xf9, 0x47, 0x82,
-	0x03, 0x89, 0x5e, 0xe7, 0xa5, 0xb9, 0xee, 0xda, 0x96, 0xe6, 0x0f, 0x8a, 0xa1, 0xb6, 0xab, 0x9e,
-	0x7d, 0x51, 0x98, 0x17, 0x7b, 0x51, 0xf1, 0xa7, 0x63, 0xe2, 0x1f, 0x40, 0x9e, 0x3d, 0x29, 0x7e,
-	0x93, 0x9e, 0x0a, 0x53, 0x61, 0xd4, 0xdf, 0xd2, 0x0d, 0xb7, 0x33, 0xef, 0xf5, 0xbd, 0xe7, 0x5b,
-	0xcd, 0xd7, 0x9a, 0x31, 0xf9, 0xfa, 0xf9, 0x9e, 0xee, 0x50, 0xec, 0xaa, 0x62, 0x17, 0xf4, 0x01,
-	0xe4, 0x79, 0x6b, 0xae, 0x67, 0xd9, 0x7e, 0x15, 0x5f, 0x73, 0xd1, 0xee, 0x2d, 0x20, 0xca, 0x8f,
-	0x12, 0xe4, 0x78, 0xa5, 0x6f, 0xeb, 0x22, 0x34, 0xa0, 0x80, 0x07, 0x5d, 0xbb, 0x67, 0x0c, 0xfa,
-	0xec, 0x00, 0x73, 0x6a, 0xf0, 0x8d, 0x90, 0x78, 0x17, 0x3c, 0xa5, 0x97, 0xc5, 0xe5, 0x9f, 0x87,
-	0x4a, 0x4c, 0x91, 0xb1, 0x29, 0x50, 0xda, 0xcf, 0x14, 0xa8, 0x68, 0x50, 0x8e, 0x7a, 0xd0, 0x49,
-	0xc8, 0xd2, 0xc7, 0x0e, 0x7f, 0x92, 0xab, 0x73, 0x93, 0xfe, 0x6a, 0xe6, 0x5e, 0x7d, 0xec, 0x60,
-	0x95, 0xb9, 0xbd, 0x6c, 0xd8, 0x30, 0xc1, 0x8f, 0x8f, 0xfd, 0xf6, 0xc4, 0xcb, 0x3a, 0xa9, 0xd0,
-	0x1e, 0xff, 0x50, 0x7e, 0x90, 0xa0, 0x1a, 0x2a, 0xe5, 0xb2, 0x61, 0xe2, 0x37, 0x21, 0x94, 0x06,
-	0x14, 0xd6, 0x0d, 0x13, 0xb3, 0x1c, 0xf8, 0x76, 0xc1, 0xf7, 0x38, 0xa6, 0xde, 0xbf, 0x06, 0xc5,
-	0xa0, 0x04, 0x54, 0x84, 0xdc, 0xc2, 0xed, 0x3b, 0xf3, 0x37, 0x6a, 0x29, 0x54, 0x81, 0xe2, 0xcd,
-	0xe5, 0x55, 0x8d, 0x7f, 0x4a, 0xe8, 0x00, 0x94, 0xd4, 0x85, 0x2b, 0x0b, 0x5f, 0x6a, 0x4b, 0xf3,
-	0xab, 0x17, 0xaf, 0xd6, 0xd2, 0x08, 0x41, 0x95, 0x1b, 0x6e, 0x2e, 0x0b, 0x5b, 0x66, 0xee, 0xb7,
-	0x02, 0x14, 0xfc, 0x1c, 0xd1, 0x39, 0xc8, 0xde, 0x1a, 0x92, 0x0d, 0x74, 0x28, 0x54, 0xea, 0x3d,
-	0xd7, 0xa0, 0x58, 0xdc, 0xe8, 0xc6, 0xcc, 0x36, 0x3b, 0xbf, 0x77, 0x4a, 0x0a, 0x2d, 0x02, 0x78,
-	0x4b, 0xf9, 0x33, 0x82, 0xde, 0x09, 0x81, 0xdc, 0xb2, 0xcf, 0x30, 0x2d, 0xe9, 0xb4, 0x84, 0x2e,
-	0x41, 0x29, 0x32, 0xab, 0xa2, 0xb1, 0x7f, 0x22, 0x35, 0x8e, 0xc4, 0xac, 0xf1, 0xd7, 0x4b, 0x49,
-	0x9d, 0x96, 0xd0, 0x32, 0x54, 0x99, 0xcb, 0x1f, 0x4c, 0x49, 0x90, 0x54, 0x7b, 0xdc, 0xb0, 0xde,
-	0x38, 0xba, 0x83, 0x37, 0xa8, 0xf0, 0x2a, 0x94, 0x22, 0xe3, 0x17, 0x6a, 0xc4, 0xb4, 0x18, 0x9b,
-	0x51, 0xc3, 0xe4, 0xc6, 0x4c, 0x7a, 0x4a, 0x0a, 0xdd, 0x15, 0x73, 0x58, 0x74, 0x90, 0xdb, 0x35,
-	0xde, 0xf1, 0x31, 0xbe, 0x31, 0x25, 0x2f, 0x00, 0x84, 0x23, 0x0f, 0x3a, 0x1c, 0x5b, 0x14, 0x9d,
-	0xf9, 0x1a, 0x8d, 0x71, 0xae, 0x20, 0xbd, 0x15, 0xa8, 0x25, 0x27, 0xa7, 0xdd, 0x82, 0x1d, 0xdb,
-	0xee, 0x1a, 0x93, 0x5b, 0x07, 0x8a, 0x41, 0xd7, 0x47, 0xf5, 0x31, 0x83, 0x00, 0x0f, 0xb6, 0xf3,
-	0x88, 0xa0, 0xa4, 0xd0, 0x65, 0x28, 0xcf, 0x9b, 0xe6, 0x7e, 0xc2, 0x34, 0xa2, 0x1e, 0x92, 0x8c,
-	0x63, 0x06, 0x0d, 0x24, 0xd9, 0x05, 0xd1, 0xbb, 0xc1, 0x1b, 0xb1, 0xeb, 0xf4, 0xd0, 0x78, 0x6f,
-	0x4f, 0x5c, 0xb0, 0xdb, 0x13, 0x38, 0xba, 0x6b, 0xcf, 0xdd, 0xf7, 0x9e, 0xa7, 0xf6, 0xc0, 0x8d,
-	0x61, 0x7d, 0x15, 0x0e, 0x24, 0x5a, 0x25, 0x92, 0x13, 0x51, 0x12, 0x5d, 0xbb, 0xd1, 0xdc, 0xd1,
-	0xef, 0xc7, 0xed, 0x7c, 0xbe, 0xf9, 0x42, 0x4e, 0x3d, 0x7b, 0x21, 0xa7, 0x5e, 0xbd, 0x90, 0xa5,
-	0xef, 0x46, 0xb2, 0xf4, 0xeb, 0x48, 0x96, 0x9e, 0x8e, 0x64, 0x69, 0x73, 0x24, 0x4b, 0x7f, 0x8e,
-	0x64, 0xe9, 0xaf, 0x91, 0x9c, 0x7a, 0x35, 0x92, 0xa5, 0x9f, 0x5e, 0xca, 0xa9, 0xcd, 0x97, 0x72,
-	0xea, 0xd9, 0x4b, 0x39, 0xf5, 0x55, 0xbe, 0x6b, 0x1a, 0x78, 0x40, 0xd7, 0xf2, 0xec, 0x3f, 0x23,
-	0x1f, 0xfd, 0x1b, 0x00, 0x00, 0xff, 0xff, 0x3c, 0x76, 0xeb, 0xb3, 0x84, 0x11, 0x00, 0x00,
+	// 1530 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x72, 0x13, 0x47,
+	0x10, 0xd6, 0x5a, 0xb2, 0x6c, 0xb5, 0x64, 0x23, 0x8f, 0x0d, 0x16, 0x6b, 0x58, 0x9b, 0xa5, 0x48,
+	0x5c, 0x49, 0xb0, 0xc1, 0x49, 0xaa, 0x20, 0x3f, 0x50, 0x36, 0x18, 0x10, 0x20, 0x0c, 0x6b, 0x03,
+	0xa9, 0x54, 0x52, 0x5b, 0x6b, 0x69, 0x2c, 0x6f, 0xd8, 0x3f, 0x76, 0x47, 0x14, 0x70, 0x4a, 0x2a,
+	0x0f, 0x90, 0x1c, 0xf2, 0x02, 0xb9, 0xe5, 0x01, 0xf2, 0x10, 0xdc, 0xe2, 0x43, 0x0e, 0x14, 0x07,
+	0x57, 0x10, 0x97, 0xe4, 0x46, 0xde, 0x20, 0xb5, 0x33, 0xb3, 0xbf, 0x5a, 0xd9, 0x22, 0xc1, 0xb9,
+	0x69, 0xba, 0xbf, 0xf9, 0xa6, 0xbb, 0xe7, 0x9b, 0x99, 0x5e, 0xc1, 0xb8, 0x6e, 0xb5, 0xb1, 0x47,
+	0xb0, 0xbb, 0xe0, 0xb8, 0x36, 0xb1, 0x51, 0xb1, 0x69, 0xbb, 0x04, 0x3f, 0x16, 0xa7, 0xda, 0x76,
+	0xdb, 0xa6, 0xa6, 0x45, 0xff, 0x17, 0xf3, 0x8a, 0xe7, 0xdb, 0x3a, 0xd9, 0xee, 0x6c, 0x2e, 0x34,
+	0x6d, 0x73, 0x91, 0x01, 0x1d, 0xd7, 0xfe, 0x06, 0x37, 0x09, 0x1f, 0x2d, 0x3a, 0x0f, 0xda, 0x81,
+	0x63, 0x93, 0xff, 0x60, 0x53, 0xe5, 0xcf, 0xa1, 0xac, 0x60, 0xad, 0xa5, 0xe0, 0x87, 0x1d, 0xec,
+	0x11, 0xb4, 0x00, 0x23, 0x0f, 0x3b, 0xd8, 0xd5, 0xb1, 0x57, 0x13, 0xe6, 0xf2, 0xf3, 0xe5, 0xa5,
+	0xa9, 0x05, 0x0e, 0xbf, 0xd3, 0xc1, 0xee, 0x13, 0x0e, 0x53, 0x02, 0x90, 0x7c, 0x11, 0x2a, 0x6c,
+	0xba, 0xe7, 0xd8, 0x96, 0x87, 0xd1, 0x22, 0x8c, 0xb8, 0xd8, 0xeb, 0x18, 0x24, 0x98, 0x7f, 0x38,
+	0x35, 0x9f, 0xe1, 0x94, 0x00, 0x25, 0xdf, 0x80, 0xb1, 0x84, 0x07, 0x7d, 0x02, 0x40, 0x74, 0x13,
+	0x7b, 0x59, 0x41, 0x38, 0x9b, 0x0b, 0x1b, 0xba, 0x89, 0xd7, 0xa9, 0x6f, 0xa5, 0xf0, 0x6c, 0x77,
+	0x36, 0xa7, 0xc4, 0xd0, 0xf2, 0x4f, 0x43, 0x50, 0x89, 0xc7, 0x89, 0x3e, 0x00, 0xe4, 0x11, 0xcd,
+	0x25, 0x2a, 0x05, 0x11, 0xcd, 0x74, 0x54, 0xd3, 0x27, 0x15, 0xe6, 0xf3, 0x4a, 0x95, 0x7a, 0x36,
+	0x02, 0x47, 0xc3, 0x43, 0xf3, 0x50, 0xc5, 0x56, 0x2b, 0x89, 0x1d, 0xa2, 0xd8, 0x71, 0x6c, 0xb5,
+	0xe2, 0xc8, 0x33, 0x30, 0x6a, 0x6a, 0xa4, 0xb9, 0x8d, 0x5d, 0xaf, 0x96, 0x4f, 0xd6, 0xe9, 0xa6,
+	0xb6, 0x89, 0x8d, 0x06, 0x73, 0x2a, 0x21, 0x0a, 0x3d, 0x85, 0xbc, 0x82, 0xb7, 0x6a, 0x7f, 0x8d,
+	0xcc, 0x09, 0xf3, 0xe5, 0xa5, 0x99, 0x28, 0xa1, 0x06, 0xf6, 0x3c, 0xad, 0x8d, 0xef, 0xeb, 0x64,
+	0x7b, 0xa5, 0xb3, 0xa5, 0xe0, 0xad, 0x95, 0xeb, 0x7e, 0x5e, 0x3b, 0xbb, 0xb3, 0xc2, 0x8b, 0xdd,
+	0xd9, 0x0b, 0x6f, 0xb2, 0xb3, 0xbd, 0x5c, 0x8a, 0xbf, 0xa8, 0xfc, 0xb3, 0x00, 0x53, 0xab, 0x8f,
+	0xb1, 0xe9, 0x18, 0x9a, 0xfb, 0xbf, 0x94, 0xe7, 0x6c, 0x4f, 0x79, 0x0e, 0x67, 0x95, 0xc7, 0x8b,
+	0xea, 0x23, 0x7f, 0x05, 0x93, 0x34, 0xb4, 0x75, 0xe2, 0x62, 0xcd, 0x0c, 0xd5, 0x70, 0x11, 0xca,
+	0xcd, 0xed, 0x8e, 0xf5, 0x20, 0x21, 0x87, 0xe9, 0x80, 0x2c, 0x12, 0xc3, 0x25, 0x1f, 0xc4, 0x15,
+	0x11, 0x9f, 0x71, 0xbd, 0x30, 0x3a, 0x54, 0xcd, 0xcb, 0xeb, 0x70, 0x38, 0x55, 0x80, 0xb7, 0xa0,
+	0x36, 0x13, 0x26, 0x57, 0x1f, 0x3b, 0xb6, 0x4b, 0x36, 0xb0, 0xa5, 0x59, 0xe4, 0x80, 0x8b, 0x2a,
+	0xdf, 0x87, 0xa9, 0xe4, 0x72, 0x6f, 0xa9, 0x44, 0xf2, 0x3d, 0x98, 0xac, 0x9b, 0xbd, 0x79, 0xfc,
+	0x67, 0xde, 0x6b, 0x30, 0x55, 0x37, 0x33, 0x02, 0x3e, 0x02, 0xc5, 0x90, 0xd3, 0x4f, 0x94, 0x8f,
+	0x50, 0x0d, 0x46, 0x3c, 0xcd, 0x74, 0x0c, 0x1c, 0x54, 0x20, 0x18, 0xca, 0xbf, 0x0b, 0x80, 0xa8,
+	0x70, 0xee, 0x69, 0x46, 0x07, 0x7b, 0x41, 0x84, 0xc7, 0x01, 0x0c, 0xdf, 0xaa, 0x5a, 0x9a, 0x89,
+	0x29, 0x59, 0x49, 0x29, 0x51, 0xcb, 0x2d, 0xcd, 0xc4, 0x7d, 0x36, 0x62, 0xe8, 0x0d, 0x36, 0x22,
+	0xbf, 0xaf, 0xba, 0x0b, 0x73, 0xc2, 0x00, 0xea, 0x46, 0x53, 0x30, 0x6c, 0xe8, 0xa6, 0x4e, 0x6a,
+	0xc3, 0x94, 0x91, 0x0d, 0xe4, 0x73, 0x30, 0x99, 0xc8, 0x8a, 0xd7, 0xe7, 0x04, 0x54, 0x58, 0x5a,
+	0x8f, 0xa8, 0x9d, 0x56, 0xbe, 0xa4, 0x94, 0x8d, 0x08, 0x2a, 0x5f, 0x80, 0xa3, 0xb1, 0x99, 0xa9,
+	0x33, 0x33, 0xc0, 0xfc, 0x5f, 0x05, 0x98, 0xb8, 0x19, 0x14, 0xca, 0x3b, 0xe8, 0xeb, 0x20, 0xcc,
+	0x3e, 0x1f, 0xcb, 0xfe, 0x5f, 0x94, 0x51, 0xfe, 0x18, 0x50, 0x3c, 0x6a, 0x9e, 0xef, 0x2c, 0x94,
+	0x23, 0x19, 0x04, 0xe9, 0x42, 0xa8, 0x03, 0x4f, 0xfe, 0x14, 0x6a, 0xd1, 0xb4, 0x54, 0xb1, 0xf6,
+	0x9d, 0x8c, 0xa0, 0x7a, 0xd7, 0xc3, 0xee, 0x3a, 0xd1, 0x48, 0x50, 0x28, 0xf9, 0xbb, 0x21, 0x98,
+	0x88, 0x19, 0x39, 0xd5, 0xa9, 0xe0, 0xd5, 0xd6, 0x6d, 0x4b, 0x75, 0x35, 0xc2, 0x24, 0x29, 0x28,
+	0x63, 0xa1, 0x55, 0xd1, 0x08, 0xf6, 0x55, 0x6b, 0x75, 0x4c, 0x95, 0x1f, 0x01, 0xbf, 0x62, 0x05,
+	0xa5, 0x64, 0x75, 0x4c, 0x76, 0x9a, 0xfc, 0x4d, 0xd0, 0x1c, 0x5d, 0x4d, 0x31, 0xe5, 0x29, 0x53,
+	0x55, 0x73, 0xf4, 0x7a, 0x82, 0x6c, 0x01, 0x26, 0xdd, 0x8e, 0x81, 0xd3, 0xf0, 0x02, 0x85, 0x4f,
+	0xf8, 0xae, 0x24, 0xfe, 0x24, 0x8c, 0x69, 0x4d, 0xa2, 0x3f, 0xc2, 0xc1, 0xfa, 0xc3, 0x74, 0xfd,
+	0x0a, 0x33, 0xf2, 0x10, 0x4e, 0xc2, 0x98, 0x61, 0x6b, 0x2d, 0xdc, 0x52, 0x37, 0x0d, 0xbb, 0xf9,
+	0xc0, 0xab, 0x15, 0x19, 0x88, 0x19, 0x57, 0xa8, 0x4d, 0xfe, 0x1a, 0x26, 0xfd, 0x12, 0xd4, 0x2f,
+	0x27, 0x8b, 0x30, 0x0d, 0x23, 0x1d, 0x0f, 0xbb, 0xaa, 0xde, 0xe2, 0x07, 0xb2, 0xe8, 0x0f, 0xeb,
+	0x2d, 0x74, 0x1a, 0x0a, 0x2d, 0x8d, 0x68, 0x34, 0xe1, 0xf2, 0xd2, 0xd1, 0x60, 0xab, 0x7b, 0xca,
+	0xa8, 0x50, 0x98, 0x7c, 0x15, 0x90, 0xef, 0xf2, 0x92, 0xec, 0x67, 0x61, 0xd8, 0xf3, 0x0d, 0xfc,
+	0x36, 0x9a, 0x89, 0xb3, 0xa4, 0x22, 0x51, 0x18, 0x52, 0x7e, 0x26, 0x80, 0xd4, 0xc0, 0xc4, 0xd5,
+	0x9b, 0xde, 0x15, 0xdb, 0x4d, 0x2a, 0xeb, 0x80, 0x75, 0x7f, 0x0e, 0x2a, 0x81, 0x74, 0x55, 0x0f,
+	0x93, 0xbd, 0x9f, 0xc2, 0x72, 0x00, 0x5d, 0xc7, 0x24, 0x3a, 0x31, 0x85, 0xf8, 0x7d, 0x71, 0x03,
+	0x66, 0xfb, 0x66, 0xc2, 0x0b, 0x34, 0x0f, 0x45, 0x93, 0x42, 0x78, 0x85, 0xaa, 0xf1, 0x46, 0xc3,
+	0xb7, 0x2b, 0xdc, 0x2f, 0xdf, 0x81, 0x53, 0x7d, 0xc8, 0x52, 0x27, 0x64, 0x70, 0x4a, 0x07, 0x8e,
+	0x70, 0xca, 0x06, 0x26, 0x9a, 0xbf, 0x8d, 0x41, 0x85, 0xc3, 0x7c, 0x84, 0xf8, 0x0d, 0x30, 0x0f,
+	0x55, 0xfa, 0x43, 0x75, 0xb0, 0xab, 0xf2, 0x35, 0x78, 0x25, 0xa9, 0xfd, 0x36, 0x76, 0x19, 0x9f,
+	0xff, 0x64, 0x70, 0x7f, 0x9e, 0x89, 0x8a, 0xaf, 0xb8, 0x06, 0xd3, 0x3d, 0x2b, 0xf2, 0xb0, 0x3f,
+	0x82, 0x51, 0x93, 0xdb, 0x78, 0xe0, 0xb5, 0x74, 0xe0, 0xe1, 0x9c, 0x10, 0x29, 0xff, 0x2d, 0xc0,
+	0xa1, 0xd4, 0xd3, 0xe6, 0x87, 0xb9, 0xe5, 0xda, 0xa6, 0x1a, 0xb4, 0xe4, 0x91, 0xb6, 0xc7, 0x7d,
+	0x7b, 0x9d, 0x9b, 0xeb, 0xad, 0xb8, 0xf8, 0x87, 0x12, 0xe2, 0xb7, 0xa0, 0x48, 0xaf, 0x94, 0xa0,
+	0x1d, 0x9a, 0x8c, 0x42, 0xa1, 0xa5, 0xbf, 0xad, 0xe9, 0xee, 0xca, 0xb2, 0xff, 0x84, 0xbe, 0xd8,
+	0x9d, 0x7d, 0xa3, 0x6e, 0x9e, 0xcd, 0x5f, 0x6e, 0x69, 0x0e, 0xc1, 0xae, 0xc2, 0x57, 0x41, 0xef,
+	0x43, 0x91, 0xbd, 0xc4, 0xb5, 0x02, 0x5d, 0x6f, 0x2c, 0xd0, 0x5c, 0xfc, 0xb1, 0xe6, 0x10, 0xf9,
+	0x07, 0x01, 0x86, 0x59, 0xa6, 0x07, 0x75, 0x10, 0x44, 0x18, 0xc5, 0x56, 0xd3, 0x6e, 0xe9, 0x56,
+	0x9b, 0x6e, 0xe0, 0xb0, 0x12, 0x8e, 0x11, 0xe2, 0xf7, 0x82, 0xaf, 0xf4, 0x0a, 0x3f, 0xfc, 0xcb,
+	0x30, 0x96, 0x50, 0x64, 0xa2, 0xdf, 0x16, 0x06, 0xe9, 0xb7, 0x65, 0x15, 0x2a, 0x71, 0x0f, 0x3a,
+	0x05, 0x05, 0xf2, 0xc4, 0x61, 0x57, 0xf2, 0xf8, 0xd2, 0x44, 0x30, 0x9b, 0xba, 0x37, 0x9e, 0x38,
+	0x58, 0xa1, 0x6e, 0x3f, 0x1a, 0xda, 0x4c, 0xb0, 0xed, 0xa3, 0xbf, 0x7d, 0xf1, 0xd2, 0x97, 0x94,
+	0x6b, 0x8f, 0x0d, 0xe4, 0xef, 0x05, 0x18, 0x8f, 0x94, 0x72, 0x45, 0x37, 0xf0, 0xdb, 0x10, 0x8a,
+	0x08, 0xa3, 0x5b, 0xba, 0x81, 0x69, 0x0c, 0x6c, 0xb9, 0x70, 0x9c, 0x55, 0xa9, 0xf7, 0xae, 0x43,
+	0x29, 0x4c, 0x01, 0x95, 0x60, 0x78, 0xf5, 0xce, 0xdd, 0xe5, 0x9b, 0xd5, 0x1c, 0x1a, 0x83, 0xd2,
+	0xad, 0xb5, 0x0d, 0x95, 0x0d, 0x05, 0x74, 0x08, 0xca, 0xca, 0xea, 0xd5, 0xd5, 0x2f, 0xd4, 0xc6,
+	0xf2, 0xc6, 0xa5, 0x6b, 0xd5, 0x21, 0x84, 0x60, 0x9c, 0x19, 0x6e, 0xad, 0x71, 0x5b, 0x7e, 0xe9,
+	0xb7, 0x12, 0x8c, 0x06, 0x31, 0xa2, 0xf3, 0x50, 0xb8, 0xdd, 0xf1, 0xb6, 0xd1, 0x91, 0x48, 0xa9,
+	0xf7, 0x5d, 0x9d, 0x60, 0x7e, 0xa2, 0xc5, 0xe9, 0x1e, 0x3b, 0x3b, 0x77, 0x72, 0x0e, 0xd5, 0x01,
+	0xfc, 0xa9, 0xec, 0x1a, 0x41, 0xc7, 0x22, 0x20, 0xb3, 0x0c, 0x48, 0x33, 0x2f, 0x9c, 0x11, 0xd0,
+	0x65, 0x28, 0xc7, 0xbe, 0x0a, 0x50, 0xe6, 0xc7, 0xa8, 0x38, 0x93, 0xb0, 0x26, 0x6f, 0x2f, 0x39,
+	0x77, 0x46, 0x40, 0x6b, 0x30, 0x4e, 0x5d, 0xc1, 0x27, 0x80, 0x17, 0x06, 0xb5, 0x90, 0xf5, 0x59,
+	0x24, 0x1e, 0xef, 0xe3, 0x0d, 0x33, 0xbc, 0x06, 0xe5, 0x58, 0xfb, 0x85, 0xc4, 0x84, 0x16, 0x13,
+	0x3d, 0xaa, 0x38, 0x93, 0xe9, 0x0b, 0x99, 0xee, 0xc1, 0x44, 0xcc, 0xc1, 0xd3, 0xdc, 0x8b, 0xef,
+	0x44, 0x86, 0x2f, 0x23, 0xe5, 0x55, 0x80, 0xa8, 0xe5, 0x41, 0x47, 0x13, 0x93, 0xe2, 0x3d, 0x9f,
+	0x28, 0x66, 0xb9, 0xc2, 0xf0, 0xd6, 0xa1, 0x9a, 0xee, 0x9c, 0xf6, 0x22, 0x9b, 0xeb, 0x75, 0x65,
+	0xc4, 0xb6, 0x02, 0xa5, 0xf0, 0xd5, 0x47, 0xb5, 0x8c, 0x46, 0x80, 0x91, 0xf5, 0x6f, 0x11, 0xe4,
+	0x1c, 0xba, 0x02, 0x95, 0x65, 0xc3, 0x18, 0x84, 0x46, 0x8c, 0x7b, 0xbc, 0x34, 0x8f, 0x01, 0xd3,
+	0x7d, 0x5e, 0x41, 0xf4, 0x4e, 0x78, 0x47, 0xec, 0xd9, 0x3d, 0x88, 0xef, 0xee, 0x8b, 0x0b, 0x57,
+	0x7b, 0x0a, 0xc7, 0xf7, 0x7c, 0x73, 0x07, 0x5e, 0xf3, 0xf4, 0x3e, 0xb8, 0x8c, 0xaa, 0x6f, 0xc0,
+	0xa1, 0xd4, 0x53, 0x89, 0xa4, 0x14, 0x4b, 0xea, 0xd5, 0x16, 0x67, 0xfb, 0xfa, 0xc3, 0x8c, 0x1a,
+	0x50, 0x89, 0x7f, 0x94, 0xa2, 0x99, 0xe8, 0xe8, 0xf4, 0x7c, 0x51, 0x8a, 0xc7, 0xb2, 0x9d, 0xb1,
+	0x20, 0x1b, 0x50, 0xa9, 0x9b, 0x59, 0x74, 0x75, 0x73, 0x0f, 0xba, 0xac, 0xaf, 0x4c, 0xff, 0x02,
+	0x59, 0xf9, 0x6c, 0xe7, 0xa5, 0x94, 0x7b, 0xfe, 0x52, 0xca, 0xbd, 0x7e, 0x29, 0x09, 0xdf, 0x76,
+	0x25, 0xe1, 0x97, 0xae, 0x24, 0x3c, 0xeb, 0x4a, 0xc2, 0x4e, 0x57, 0x12, 0xfe, 0xe8, 0x4a, 0xc2,
+	0x9f, 0x5d, 0x29, 0xf7, 0xba, 0x2b, 0x09, 0x3f, 0xbe, 0x92, 0x72, 0x3b, 0xaf, 0xa4, 0xdc, 0xf3,
+	0x57, 0x52, 0xee, 0xcb, 0x62, 0xd3, 0xd0, 0xb1, 0x45, 0x36, 0x8b, 0xf4, 0x1f, 0xb2, 0x0f, 0xff,
+	0x19, 0x00, 0x0a, 0x5e, 0xd6, 0xc5, 0x8c, 0x13, 0x00, 0x00,
 }
 
 func (x MatchType) String() string {
@@ -1827,14 +2025,14 @@ func (this *ExemplarQueryResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *LabelValuesRequest) Equal(that interface{}) bool {
+func (this *ExportTenantRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesRequest)
+	that1, ok := that.(*ExportTenantRequest)
 	if !ok {
-		that2, ok := that.(LabelValuesRequest)
+		that2, ok := that.(ExportTenantRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1846,31 +2044,22 @@ func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.LabelName != that1.LabelName {
-		return false
-	}
 	if this.StartTimestampMs != that1.StartTimestampMs {
 		return false
 	}
 	if this.EndTimestampMs != that1.EndTimestampMs {
 		return false
 	}
-	if !this.Matchers.Equal(that1.Matchers) {
-		return false
-	}
-	if this.Limit != that1.Limit {
-		return false
-	}
 	return true
 }
-func (this *LabelValuesResponse) Equal(that interface{}) bool {
+func (this *ExportTenantResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesResponse)
+	that1, ok := that.(*ExportTenantResponse)
 	if !ok {
-		that2, ok := that.(LabelValuesResponse)
+		that2, ok := that.(ExportTenantResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1882,24 +2071,24 @@ func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelValues) != len(that1.LabelValues) {
+	if len(this.Chunkseries) != len(that1.Chunkseries) {
 		return false
 	}
-	for i := range this.LabelValues {
-		if this.LabelValues[i] != that1.LabelValues[i] {
+	for i := range this.Chunkseries {
+		if !this.Chunkseries[i].Equal(&that1.Chunkseries[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelValuesStreamResponse) Equal(that interface{}) bool {
+func (this *ImportTenantRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesStreamResponse)
+	that1, ok := that.(*ImportTenantRequest)
 	if !ok {
-		that2, ok := that.(LabelValuesStreamResponse)
+		that2, ok := that.(ImportTenantRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1911,24 +2100,24 @@ func (this *LabelValuesStreamResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelValues) != len(that1.LabelValues) {
+	if len(this.Chunkseries) != len(that1.Chunkseries) {
 		return false
 	}
-	for i := range this.LabelValues {
-		if this.LabelValues[i] != that1.LabelValues[i] {
+	for i := range this.Chunkseries {
+		if !this.Chunkseries[i].Equal(&that1.Chunkseries[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelNamesRequest) Equal(that interface{}) bool {
+func (this *ImportTenantResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesRequest)
+	that1, ok := that.(*ImportTenantResponse)
 	if !ok {
-		that2, ok := that.(LabelNamesRequest)
+		that2, ok := that.(ImportTenantResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1940,28 +2129,22 @@ func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.StartTimestampMs != that1.StartTimestampMs {
-		return false
-	}
-	if this.EndTimestampMs != that1.EndTimestampMs {
-		return false
-	}
-	if this.Limit != that1.Limit {
+	if this.Series != that1.Series {
 		return false
 	}
-	if !this.Matchers.Equal(that1.Matchers) {
+	if this.Samples != that1.Samples {
 		return false
 	}
 	return true
 }
-func (this *LabelNamesResponse) Equal(that interface{}) bool {
+func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesResponse)
+	that1, ok := that.(*LabelValuesRequest)
 	if !ok {
-		that2, ok := that.(LabelNamesResponse)
+		that2, ok := that.(LabelValuesRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1973,24 +2156,31 @@ func (this *LabelNamesResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelNames) != len(that1.LabelNames) {
+	if this.LabelName != that1.LabelName {
 		return false
 	}
-	for i := range this.LabelNames {
-		if this.LabelNames[i] != that1.LabelNames[i] {
-			return false
-		}
+	if this.StartTimestampMs != that1.StartTimestampMs {
+		return false
+	}
+	if this.EndTimestampMs != that1.EndTimestampMs {
+		return false
+	}
+	if !this.Matchers.Equal(that1.Matchers) {
+		return false
+	}
+	if this.Limit != that1.Limit {
+		return false
 	}
 	return true
 }
-func (this *LabelNamesStreamResponse) Equal(that interface{}) bool {
+func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesStreamResponse)
+	that1, ok := that.(*LabelValuesResponse)
 	if !ok {
-		that2, ok := that.(LabelNamesStreamResponse)
+		that2, ok := that.(LabelValuesResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2002,24 +2192,24 @@ func (this *LabelNamesStreamResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.LabelNames) != len(that1.LabelNames) {
+	if len(this.LabelValues) != len(that1.LabelValues) {
 		return false
 	}
-	for i := range this.LabelNames {
-		if this.LabelNames[i] != that1.LabelNames[i] {
+	for i := range this.LabelValues {
+		if this.LabelValues[i] != that1.LabelValues[i] {
 			return false
 		}
 	}
 	return true
 }
-func (this *UserStatsRequest) Equal(that interface{}) bool {
+func (this *LabelValuesStreamResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UserStatsRequest)
+	that1, ok := that.(*LabelValuesStreamResponse)
 	if !ok {
-		that2, ok := that.(UserStatsRequest)
+		that2, ok := that.(LabelValuesStreamResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2031,16 +2221,136 @@ func (this *UserStatsRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if len(this.LabelValues) != len(that1.LabelValues) {
+		return false
+	}
+	for i := range this.LabelValues {
+		if this.LabelValues[i] != that1.LabelValues[i] {
+			return false
+		}
+	}
 	return true
 }
-func (this *UserStatsResponse) Equal(that interface{}) bool {
+func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UserStatsResponse)
+	that1, ok := that.(*LabelNamesRequest)
 	if !ok {
-		that2, ok := that.(UserStatsResponse)
+		that2, ok := that.(LabelNamesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.StartTimestampMs != that1.StartTimestampMs {
+		return false
+	}
+	if this.EndTimestampMs != that1.EndTimestampMs {
+		return false
+	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if !this.Matchers.Equal(that1.Matchers) {
+		return false
+	}
+	return true
+}
+func (this *LabelNamesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelNamesResponse)
+	if !ok {
+		that2, ok := that.(LabelNamesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.LabelNames) != len(that1.LabelNames) {
+		return false
+	}
+	for i := range this.LabelNames {
+		if this.LabelNames[i] != that1.LabelNames[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *LabelNamesStreamResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelNamesStreamResponse)
+	if !ok {
+		that2, ok := that.(LabelNamesStreamResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.LabelNames) != len(that1.LabelNames) {
+		return false
+	}
+	for i := range this.LabelNames {
+		if this.LabelNames[i] != that1.LabelNames[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *UserStatsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*UserStatsRequest)
+	if !ok {
+		that2, ok := that.(UserStatsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *UserStatsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*UserStatsResponse)
+	if !ok {
+		that2, ok := that.(UserStatsResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2552,6 +2862,60 @@ func (this *ExemplarQueryResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *ExportTenantRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.ExportTenantRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExportTenantResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ExportTenantResponse{")
+	if this.Chunkseries != nil {
+		vs := make([]*TimeSeriesChunk, len(this.Chunkseries))
+		for i := range vs {
+			vs[i] = &this.Chunkseries[i]
+		}
+		s = append(s, "Chunkseries: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ImportTenantRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.ImportTenantRequest{")
+	if this.Chunkseries != nil {
+		vs := make([]*TimeSeriesChunk, len(this.Chunkseries))
+		for i := range vs {
+			vs[i] = &this.Chunkseries[i]
+		}
+		s = append(s, "Chunkseries: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ImportTenantResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.ImportTenantResponse{")
+	s = append(s, "Series: "+fmt.Sprintf("%#v", this.Series)+",\n")
+	s = append(s, "Samples: "+fmt.Sprintf("%#v", this.Samples)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *LabelValuesRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -2838,6 +3202,11 @@ type IngesterClient interface {
 	MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error)
 	MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error)
 	MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error)
+	// ExportTenant streams all the series of the tenant in the time range. It's used to copy
+	// the series to the new owners when migrating the tenant to a different shard.
+	ExportTenant(ctx context.Context, in *ExportTenantRequest, opts ...grpc.CallOption) (Ingester_ExportTenantClient, error)
+	// ImportTenant writes the streamed series of the tenant into a local block.
+	ImportTenant(ctx context.Context, opts ...grpc.CallOption) (Ingester_ImportTenantClient, error)
 }
 
 type ingesterClient struct {
@@ -3079,6 +3448,72 @@ func (c *ingesterClient) MetricsMetadata(ctx context.Context, in *MetricsMetadat
 	return out, nil
 }
 
+func (c *ingesterClient) ExportTenant(ctx context.Context, in *ExportTenantRequest, opts ...grpc.CallOption) (Ingester_ExportTenantClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[5], "/cortex.Ingester/ExportTenant", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterExportTenantClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ingester_ExportTenantClient interface {
+	Recv() (*ExportTenantResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterExportTenantClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterExportTenantClient) Recv() (*ExportTenantResponse, error) {
+	m := new(ExportTenantResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ingesterClient) ImportTenant(ctx context.Context, opts ...grpc.CallOption) (Ingester_ImportTenantClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[6], "/cortex.Ingester/ImportTenant", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterImportTenantClient{stream}
+	return x, nil
+}
+
+type Ingester_ImportTenantClient interface {
+	Send(*ImportTenantRequest) error
+	CloseAndRecv() (*ImportTenantResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterImportTenantClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterImportTenantClient) Send(m *ImportTenantRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ingesterImportTenantClient) CloseAndRecv() (*ImportTenantResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportTenantResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // IngesterServer is the server API for Ingester service.
 type IngesterServer interface {
 	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
@@ -3094,6 +3529,11 @@ type IngesterServer interface {
 	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
 	MetricsForLabelMatchersStream(*MetricsForLabelMatchersRequest, Ingester_MetricsForLabelMatchersStreamServer) error
 	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
+	// ExportTenant streams all the series of the tenant in the time range. It's used to copy
+	// the series to the new owners when migrating the tenant to a different shard.
+	ExportTenant(*ExportTenantRequest, Ingester_ExportTenantServer) error
+	// ImportTenant writes the streamed series of the tenant into a local block.
+	ImportTenant(Ingester_ImportTenantServer) error
 }
 
 // UnimplementedIngesterServer can be embedded to have forward compatible implementations.
@@ -3139,6 +3579,12 @@ func (*UnimplementedIngesterServer) MetricsForLabelMatchersStream(req *MetricsFo
 func (*UnimplementedIngesterServer) MetricsMetadata(ctx context.Context, req *MetricsMetadataRequest) (*MetricsMetadataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MetricsMetadata not implemented")
 }
+func (*UnimplementedIngesterServer) ExportTenant(req *ExportTenantRequest, srv Ingester_ExportTenantServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportTenant not implemented")
+}
+func (*UnimplementedIngesterServer) ImportTenant(srv Ingester_ImportTenantServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportTenant not implemented")
+}
 
 func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
 	s.RegisterService(&_Ingester_serviceDesc, srv)
@@ -3398,6 +3844,53 @@ func _Ingester_MetricsMetadata_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ingester_ExportTenant_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTenantRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IngesterServer).ExportTenant(m, &ingesterExportTenantServer{stream})
+}
+
+type Ingester_ExportTenantServer interface {
+	Send(*ExportTenantResponse) error
+	grpc.ServerStream
+}
+
+type ingesterExportTenantServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterExportTenantServer) Send(m *ExportTenantResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ingester_ImportTenant_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IngesterServer).ImportTenant(&ingesterImportTenantServer{stream})
+}
+
+type Ingester_ImportTenantServer interface {
+	SendAndClose(*ImportTenantResponse) error
+	Recv() (*ImportTenantRequest, error)
+	grpc.ServerStream
+}
+
+type ingesterImportTenantServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterImportTenantServer) SendAndClose(m *ImportTenantResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ingesterImportTenantServer) Recv() (*ImportTenantRequest, error) {
+	m := new(ImportTenantRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _Ingester_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "cortex.Ingester",
 	HandlerType: (*IngesterServer)(nil),
@@ -3462,6 +3955,16 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Ingester_MetricsForLabelMatchersStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ExportTenant",
+			Handler:       _Ingester_ExportTenant_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportTenant",
+			Handler:       _Ingester_ImportTenant_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "ingester.proto",
 }
@@ -3757,7 +4260,7 @@ func (m *ExemplarQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
+func (m *ExportTenantRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -3767,25 +4270,165 @@ func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *ExportTenantRequest) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *ExportTenantRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Limit != 0 {
-		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+	if m.EndTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EndTimestampMs))
 		i--
-		dAtA[i] = 0x28
+		dAtA[i] = 0x10
 	}
-	if m.Matchers != nil {
-		{
-			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
+	if m.StartTimestampMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StartTimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExportTenantResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExportTenantResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExportTenantResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Chunkseries) > 0 {
+		for iNdEx := len(m.Chunkseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Chunkseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ImportTenantRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ImportTenantRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ImportTenantRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Chunkseries) > 0 {
+		for iNdEx := len(m.Chunkseries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Chunkseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ImportTenantResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ImportTenantResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ImportTenantResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Samples != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Samples))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Series != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Series))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Matchers != nil {
+		{
+			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
 				return 0, err
 			}
 			i -= size
@@ -4723,6 +5366,66 @@ func (m *ExemplarQueryResponse) Size() (n int) {
 	return n
 }
 
+func (m *ExportTenantRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StartTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.StartTimestampMs))
+	}
+	if m.EndTimestampMs != 0 {
+		n += 1 + sovIngester(uint64(m.EndTimestampMs))
+	}
+	return n
+}
+
+func (m *ExportTenantResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Chunkseries) > 0 {
+		for _, e := range m.Chunkseries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ImportTenantRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Chunkseries) > 0 {
+		for _, e := range m.Chunkseries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ImportTenantResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Series != 0 {
+		n += 1 + sovIngester(uint64(m.Series))
+	}
+	if m.Samples != 0 {
+		n += 1 + sovIngester(uint64(m.Samples))
+	}
+	return n
+}
+
 func (m *LabelValuesRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -5214,6 +5917,58 @@ func (this *ExemplarQueryResponse) String() string {
 	}, "")
 	return s
 }
+func (this *ExportTenantRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ExportTenantRequest{`,
+		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
+		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExportTenantResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForChunkseries := "[]TimeSeriesChunk{"
+	for _, f := range this.Chunkseries {
+		repeatedStringForChunkseries += strings.Replace(strings.Replace(f.String(), "TimeSeriesChunk", "TimeSeriesChunk", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForChunkseries += "}"
+	s := strings.Join([]string{`&ExportTenantResponse{`,
+		`Chunkseries:` + repeatedStringForChunkseries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ImportTenantRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForChunkseries := "[]TimeSeriesChunk{"
+	for _, f := range this.Chunkseries {
+		repeatedStringForChunkseries += strings.Replace(strings.Replace(f.String(), "TimeSeriesChunk", "TimeSeriesChunk", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForChunkseries += "}"
+	s := strings.Join([]string{`&ImportTenantRequest{`,
+		`Chunkseries:` + repeatedStringForChunkseries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ImportTenantResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ImportTenantResponse{`,
+		`Series:` + fmt.Sprintf("%v", this.Series) + `,`,
+		`Samples:` + fmt.Sprintf("%v", this.Samples) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *LabelValuesRequest) String() string {
 	if this == nil {
 		return "nil"
@@ -6203,6 +6958,362 @@ func (m *ExemplarQueryResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ExportTenantRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExportTenantRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExportTenantRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExportTenantResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExportTenantResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExportTenantResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunkseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Chunkseries = append(m.Chunkseries, TimeSeriesChunk{})
+			if err := m.Chunkseries[len(m.Chunkseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ImportTenantRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ImportTenantRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ImportTenantRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunkseries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Chunkseries = append(m.Chunkseries, TimeSeriesChunk{})
+			if err := m.Chunkseries[len(m.Chunkseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ImportTenantResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ImportTenantResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ImportTenantResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+			}
+			m.Series = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Series |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Samples", wireType)
+			}
+			m.Samples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Samples |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -5,6 +5,16 @@ import (
 	"net/http"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/httpgrpc"
+)
+
+const (
+	// Limits tracked by the cortex_ingester_queries_limited_total metric.
+	maxFetchedSeriesPerQueryLimit     = "max_fetched_series_per_query"
+	maxFetchedChunkBytesPerQueryLimit = "max_fetched_chunk_bytes_per_query"
+
+	errMaxFetchedSeriesPerQueryHit     = "the query hit the max number of series fetched from a single ingester (limit: %d series)"
+	errMaxFetchedChunkBytesPerQueryHit = "the query hit the aggregated chunks size fetched from a single ingester limit (limit: %d bytes)"
 )
 
 type validationError struct {
@@ -41,6 +51,12 @@ func (e *validationError) Error() string {
 	return fmt.Sprintf("%s for series %s", e.err.Error(), e.labels.String())
 }
 
+// makeQueryLimitError returns the error of a query exceeding an ingester query limit. The error
+// has a 4xx status code, so that the querier returns it to the client as a limit error.
+func makeQueryLimitError(format string, limit int) error {
+	return httpgrpc.Errorf(http.StatusUnprocessableEntity, format, limit)
+}
+
 // wrapWithUser prepends the user to the error. It does not retain a reference to err.
 func wrapWithUser(err error, userID string) error {
 	return fmt.Errorf("user=%s: %s", userID, err)
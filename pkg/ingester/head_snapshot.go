@@ -0,0 +1,419 @@
+package ingester
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	logutil "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	// headSnapshotsDir is the directory, within the tenant prefix in the storage, where the ingesters
+	// upload the snapshots of the TSDB head.
+	headSnapshotsDir = "ingester-head-snapshots"
+
+	// headSnapshotManifestFilename is the name of the file listing the files of a head snapshot.
+	// It's uploaded last, so the files it lists have all been uploaded.
+	headSnapshotManifestFilename = "manifest.json"
+
+	headSnapshotManifestVersion1 = 1
+)
+
+// headSnapshotManifest lists the files of a TSDB head snapshot.
+type headSnapshotManifest struct {
+	Version int                `json:"version"`
+	Files   []headSnapshotFile `json:"files"`
+}
+
+// headSnapshotFile is a file of a TSDB head snapshot.
+type headSnapshotFile struct {
+	// Path of the file relative to the TSDB dir, slash separated.
+	Name string `json:"name"`
+	// Size of the file when it was uploaded.
+	Size int64 `json:"size"`
+}
+
+// headSnapshotsPrefix returns the storage prefix, within the tenant prefix, of the head snapshot uploaded
+// by the given ingester. The files are stored at their path relative to the TSDB dir.
+func headSnapshotsPrefix(ingesterID string) string {
+	return path.Join(headSnapshotsDir, ingesterID)
+}
+
+// uploadHeadSnapshots uploads a snapshot of the head of each TSDB to the storage. Errors are logged and
+// never returned, to not stop the timer service running it.
+func (i *Ingester) uploadHeadSnapshots(ctx context.Context) error {
+	// Do not upload the snapshots while the ingester is PENDING or JOINING, because
+	// the TSDBs may have not been opened yet.
+	if i.lifecycler != nil {
+		if ingesterState := i.lifecycler.GetState(); ingesterState == ring.PENDING || ingesterState == ring.JOINING {
+			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB head snapshots upload has been skipped because of the current ingester state", "state", ingesterState)
+			return nil
+		}
+	}
+
+	// The snapshots are deleted when the TSDB is closed, but the ones of the tenants whose TSDB
+	// hasn't been opened on startup, e.g. because the restore failed, are left over.
+	if !i.TSDBState.staleHeadSnapshotsDeleted {
+		if err := i.deleteStaleHeadSnapshots(ctx); err != nil {
+			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to delete the TSDB head snapshots of the tenants without a TSDB", "err", err)
+		} else {
+			i.TSDBState.staleHeadSnapshotsDeleted = true
+		}
+	}
+
+	_ = concurrency.ForEachUser(ctx, i.getTSDBUsers(), i.cfg.BlocksStorageConfig.TSDB.ShipConcurrency, func(ctx context.Context, userID string) error {
+		userDB, err := i.getTSDB(userID)
+		if err != nil || userDB == nil || userDB.deletionMarkFound.Load() {
+			return nil
+		}
+
+		// Prevent the TSDB from being closed while its files are uploaded.
+		if err := userDB.acquireReadLock(); err != nil {
+			return nil
+		}
+		defer userDB.releaseReadLock()
+
+		if err := i.uploadHeadSnapshot(ctx, userID, userDB); err != nil {
+			i.TSDBState.headSnapshotUploadsFailed.Inc()
+			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to upload TSDB head snapshot", "user", userID, "err", err)
+			return nil
+		}
+
+		i.TSDBState.headSnapshotUploads.Inc()
+		return nil
+	})
+
+	return nil
+}
+
+// uploadHeadSnapshot uploads the files required to restore the head of the TSDB which changed since the
+// previous snapshot, then the manifest, and finally deletes the files not listed in the manifest anymore.
+// Files are compared by name and size: WAL segments, head chunks files and checkpoints are never rewritten,
+// so they're uploaded once, while the segments and files being written are uploaded again once they grew.
+// Files being written are uploaded as they are: the TSDB repairs the torn records and chunks at the end of
+// the WAL and head chunks when restored.
+func (i *Ingester) uploadHeadSnapshot(ctx context.Context, userID string, db *userTSDB) error {
+	dir := db.db.Dir()
+
+	// The chunk snapshot allows to restore the head without replaying the whole WAL.
+	if _, err := db.Head().ChunkSnapshot(); err != nil {
+		return errors.Wrap(err, "create chunk snapshot")
+	}
+
+	files, err := listHeadSnapshotFiles(dir)
+	if err != nil {
+		return errors.Wrap(err, "list head files")
+	}
+
+	userBkt := bucket.NewUserBucketClient(userID, i.TSDBState.bucket, i.limits)
+	prefix := headSnapshotsPrefix(i.TSDBState.shipperIngesterID)
+
+	// After a restart, the files uploaded before are known from the latest manifest.
+	if db.headSnapshotFiles == nil {
+		db.headSnapshotFiles = map[string]int64{}
+
+		manifest, err := readHeadSnapshotManifest(ctx, userBkt, prefix)
+		if err != nil && !userBkt.IsObjNotFoundErr(errors.Cause(err)) {
+			return errors.Wrap(err, "read head snapshot manifest")
+		}
+		if manifest != nil {
+			for _, f := range manifest.Files {
+				db.headSnapshotFiles[f.Name] = f.Size
+			}
+		}
+	}
+
+	uploaded := 0
+	for _, f := range files {
+		if size, ok := db.headSnapshotFiles[f.Name]; ok && size == f.Size {
+			continue
+		}
+
+		if err := objstore.UploadFile(ctx, i.logger, userBkt, filepath.Join(dir, filepath.FromSlash(f.Name)), path.Join(prefix, f.Name)); err != nil {
+			return err
+		}
+		uploaded++
+	}
+
+	data, err := json.Marshal(headSnapshotManifest{Version: headSnapshotManifestVersion1, Files: files})
+	if err != nil {
+		return errors.Wrap(err, "encode head snapshot manifest")
+	}
+	if err := userBkt.Upload(ctx, path.Join(prefix, headSnapshotManifestFilename), bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "upload head snapshot manifest")
+	}
+
+	db.headSnapshotFiles = make(map[string]int64, len(files))
+	for _, f := range files {
+		db.headSnapshotFiles[f.Name] = f.Size
+	}
+
+	level.Debug(logutil.WithContext(ctx, i.logger)).Log("msg", "uploaded TSDB head snapshot", "user", userID, "files", len(files), "uploaded", uploaded)
+
+	// Delete the files of the previous snapshots not required anymore, e.g. the truncated WAL segments.
+	return userBkt.Iter(ctx, prefix+objstore.DirDelim, func(name string) error {
+		relName := strings.TrimPrefix(name, prefix+objstore.DirDelim)
+		if _, ok := db.headSnapshotFiles[relName]; ok || relName == headSnapshotManifestFilename {
+			return nil
+		}
+		return errors.Wrapf(userBkt.Delete(ctx, name), "delete head snapshot file %s", relName)
+	}, objstore.WithRecursiveIter())
+}
+
+// deleteHeadSnapshots deletes the head snapshot of the tenant uploaded by this ingester.
+func (i *Ingester) deleteHeadSnapshots(ctx context.Context, userID string) error {
+	userBkt := bucket.NewUserBucketClient(userID, i.TSDBState.bucket, i.limits)
+
+	// Delete the manifest first, so that a partially deleted snapshot is never restored.
+	if err := userBkt.Delete(ctx, path.Join(headSnapshotsPrefix(i.TSDBState.shipperIngesterID), headSnapshotManifestFilename)); err != nil && !userBkt.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "delete head snapshot manifest")
+	}
+
+	_, err := bucket.DeletePrefix(ctx, userBkt, headSnapshotsPrefix(i.TSDBState.shipperIngesterID), i.logger, 1)
+	return err
+}
+
+// deleteStaleHeadSnapshots deletes the head snapshots uploaded by this ingester for the tenants it has
+// no TSDB for.
+func (i *Ingester) deleteStaleHeadSnapshots(ctx context.Context) error {
+	userIDs, err := i.listBucketTenants(ctx)
+	if err != nil {
+		return errors.Wrap(err, "list tenants")
+	}
+
+	ownedUserIDs := map[string]struct{}{}
+	for _, userID := range i.getTSDBUsers() {
+		ownedUserIDs[userID] = struct{}{}
+	}
+
+	var staleUserIDs []string
+	for _, userID := range userIDs {
+		if _, ok := ownedUserIDs[userID]; !ok {
+			staleUserIDs = append(staleUserIDs, userID)
+		}
+	}
+
+	return concurrency.ForEachUser(ctx, staleUserIDs, i.cfg.BlocksStorageConfig.TSDB.ShipConcurrency, func(ctx context.Context, userID string) error {
+		return errors.Wrapf(i.deleteHeadSnapshots(ctx, userID), "delete head snapshot of user %s", userID)
+	})
+}
+
+// restoreHeadSnapshots downloads the head snapshot uploaded by this ingester for each tenant when the
+// TSDB dir is empty, e.g. after the node has been replaced. Restoring is best effort: the TSDB of a
+// tenant failing to be restored is removed, and starts empty.
+func (i *Ingester) restoreHeadSnapshots(ctx context.Context) {
+	logger := logutil.WithContext(ctx, i.logger)
+	tsdbDir := i.cfg.BlocksStorageConfig.TSDB.Dir
+
+	empty, err := isEmptyDir(tsdbDir)
+	if err != nil {
+		level.Warn(logger).Log("msg", "unable to check whether the TSDB dir is empty, skipping the restore of the TSDB head snapshots", "dir", tsdbDir, "err", err)
+		return
+	}
+	if !empty {
+		return
+	}
+
+	userIDs, err := i.listBucketTenants(ctx)
+	if err != nil {
+		level.Warn(logger).Log("msg", "unable to list the tenants in the storage, skipping the restore of the TSDB head snapshots", "err", err)
+		return
+	}
+
+	level.Info(logger).Log("msg", "TSDB dir is empty, restoring the TSDB head snapshots from the storage", "dir", tsdbDir)
+
+	_ = concurrency.ForEachUser(ctx, userIDs, i.cfg.BlocksStorageConfig.TSDB.MaxTSDBOpeningConcurrencyOnStartup, func(ctx context.Context, userID string) error {
+		restored, err := i.restoreHeadSnapshot(ctx, userID)
+		if err != nil {
+			i.TSDBState.headSnapshotRestoresFailed.Inc()
+			level.Warn(logger).Log("msg", "failed to restore TSDB head snapshot", "user", userID, "err", err)
+
+			if err := os.RemoveAll(i.cfg.BlocksStorageConfig.TSDB.BlocksDir(userID)); err != nil {
+				level.Error(logger).Log("msg", "failed to delete partially restored TSDB", "user", userID, "err", err)
+			}
+			return nil
+		}
+
+		if restored {
+			i.TSDBState.headSnapshotRestores.Inc()
+			level.Info(logger).Log("msg", "restored TSDB head snapshot", "user", userID)
+		}
+		return nil
+	})
+}
+
+// restoreHeadSnapshot downloads the head snapshot of the tenant into its TSDB dir. Returns false if this
+// ingester never uploaded a complete snapshot for the tenant.
+func (i *Ingester) restoreHeadSnapshot(ctx context.Context, userID string) (bool, error) {
+	userBkt := bucket.NewUserBucketClient(userID, i.TSDBState.bucket, i.limits)
+	prefix := headSnapshotsPrefix(i.TSDBState.shipperIngesterID)
+
+	manifest, err := readHeadSnapshotManifest(ctx, userBkt, prefix)
+	if userBkt.IsObjNotFoundErr(errors.Cause(err)) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "read head snapshot manifest")
+	}
+
+	dir := i.cfg.BlocksStorageConfig.TSDB.BlocksDir(userID)
+	for _, f := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) {
+			return false, errors.Errorf("invalid file %q in head snapshot", f.Name)
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return false, err
+		}
+		if err := objstore.DownloadFile(ctx, i.logger, userBkt, path.Join(prefix, f.Name), dst); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// listBucketTenants returns the IDs of the tenants found in the storage.
+func (i *Ingester) listBucketTenants(ctx context.Context) ([]string, error) {
+	var userIDs []string
+
+	err := i.TSDBState.bucket.Iter(ctx, "", func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			userIDs = append(userIDs, strings.TrimSuffix(name, objstore.DirDelim))
+		}
+		return nil
+	})
+
+	return userIDs, err
+}
+
+func readHeadSnapshotManifest(ctx context.Context, userBkt objstore.Bucket, prefix string) (*headSnapshotManifest, error) {
+	r, err := userBkt.Get(ctx, path.Join(prefix, headSnapshotManifestFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	manifest := &headSnapshotManifest{}
+	if err := json.NewDecoder(r).Decode(manifest); err != nil {
+		return nil, errors.Wrap(err, "decode head snapshot manifest")
+	}
+	if manifest.Version != headSnapshotManifestVersion1 {
+		return nil, errors.Errorf("unsupported head snapshot manifest version %d", manifest.Version)
+	}
+
+	return manifest, nil
+}
+
+// listHeadSnapshotFiles returns the files required to restore the head of the TSDB in the given dir: the
+// latest chunk snapshot, the head chunks, the latest WAL checkpoint along with the WAL segments following
+// it, and the out-of-order WAL segments. The files are listed in the order they're written, so the files
+// written last are uploaded last.
+func listHeadSnapshotFiles(dir string) ([]headSnapshotFile, error) {
+	var files []headSnapshotFile
+
+	snapshotDir, _, _, err := tsdb.LastChunkSnapshot(dir)
+	if err == nil {
+		if files, err = appendDirFiles(files, dir, filepath.Base(snapshotDir), nil); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, record.ErrNotFound) {
+		return nil, err
+	}
+
+	if files, err = appendDirFiles(files, dir, "chunks_head", nil); err != nil {
+		return nil, err
+	}
+
+	walDir := filepath.Join(dir, "wal")
+	checkpointIdx := -1
+	if _, err := os.Stat(walDir); err == nil {
+		checkpointDir, idx, err := wlog.LastCheckpoint(walDir)
+		if err == nil {
+			checkpointIdx = idx
+			if files, err = appendDirFiles(files, dir, filepath.Join("wal", filepath.Base(checkpointDir)), nil); err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, record.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	files, err = appendDirFiles(files, dir, "wal", func(name string) bool {
+		idx, err := strconv.Atoi(name)
+		return err == nil && idx > checkpointIdx
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return appendDirFiles(files, dir, wlog.WblDirName, func(name string) bool {
+		_, err := strconv.Atoi(name)
+		return err == nil
+	})
+}
+
+// appendDirFiles appends the regular files in the sub dir of root matching the filter, sorted by name,
+// with their path relative to root. A non existing sub dir is skipped.
+func appendDirFiles(files []headSnapshotFile, root, sub string, filter func(name string) bool) ([]headSnapshotFile, error) {
+	entries, err := os.ReadDir(filepath.Join(root, sub))
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Segments are named with their zero padded index, so they're sorted by index.
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || (filter != nil && !filter(entry.Name())) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, headSnapshotFile{Name: filepath.ToSlash(filepath.Join(sub, entry.Name())), Size: info.Size()})
+	}
+
+	return files, nil
+}
+
+// isEmptyDir returns whether the dir doesn't exist or has no entries.
+func isEmptyDir(dir string) (bool, error) {
+	f, err := os.Open(dir)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	if _, err := f.Readdirnames(1); err != nil {
+		if err == io.EOF {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,213 @@
+package ingester
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestIngester_HeadSnapshotUploadAndRestore(t *testing.T) {
+	for testName, memorySnapshotOnShutdown := range map[string]bool{
+		"memory snapshot on shutdown disabled": false,
+		"memory snapshot on shutdown enabled":  true,
+	} {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+			cfg.BlocksStorageConfig.TSDB.ShipConcurrency = 1
+			cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval = time.Hour // Long enough to not be reached during the test.
+			cfg.BlocksStorageConfig.TSDB.MemorySnapshotOnShutdown = memorySnapshotOnShutdown
+
+			i1, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, i1))
+			test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+				return i1.lifecycler.GetState()
+			})
+
+			pushSingleSampleWithMetadata(t, i1)
+
+			// The snapshot of a tenant without TSDB in this ingester is deleted.
+			staleManifest := path.Join("user-2", headSnapshotsPrefix("localhost"), headSnapshotManifestFilename)
+			require.NoError(t, i1.TSDBState.bucket.Upload(ctx, staleManifest, strings.NewReader(`{"version":1,"files":[]}`)))
+
+			require.NoError(t, i1.uploadHeadSnapshots(ctx))
+			assert.Equal(t, float64(1), testutil.ToFloat64(i1.TSDBState.headSnapshotUploads))
+			assert.Equal(t, float64(0), testutil.ToFloat64(i1.TSDBState.headSnapshotUploadsFailed))
+
+			exists, err := i1.TSDBState.bucket.Exists(ctx, staleManifest)
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			prefix := path.Join(userID, headSnapshotsPrefix("localhost"))
+			manifest, err := readHeadSnapshotManifest(ctx, i1.TSDBState.bucket, prefix)
+			require.NoError(t, err)
+			assert.Contains(t, manifestFileNames(manifest), "wal/00000000")
+
+			// The chunk snapshot is always uploaded, so that the WAL is not fully replayed when restored.
+			hasChunkSnapshot := false
+			for _, f := range manifest.Files {
+				hasChunkSnapshot = hasChunkSnapshot || strings.HasPrefix(f.Name, "chunk_snapshot.")
+			}
+			assert.True(t, hasChunkSnapshot)
+
+			// The files which didn't change are not uploaded again, while the files not listed anymore are deleted.
+			require.NoError(t, i1.TSDBState.bucket.Upload(ctx, path.Join(prefix, "wal", "00000000"), strings.NewReader("unchanged")))
+			require.NoError(t, i1.TSDBState.bucket.Upload(ctx, path.Join(prefix, "wal", "checkpoint.00000000", "00000000"), strings.NewReader("truncated")))
+			require.NoError(t, i1.uploadHeadSnapshots(ctx))
+			assert.Equal(t, "unchanged", readBucketObject(t, i1, path.Join(prefix, "wal", "00000000")))
+
+			exists, err = i1.TSDBState.bucket.Exists(ctx, path.Join(prefix, "wal", "checkpoint.00000000", "00000000"))
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			// The WAL segment being written is uploaded again once it grew.
+			pushSingleSampleWithMetadata(t, i1)
+			require.NoError(t, i1.uploadHeadSnapshots(ctx))
+			assert.NotEqual(t, "unchanged", readBucketObject(t, i1, path.Join(prefix, "wal", "00000000")))
+			assert.Equal(t, float64(3), testutil.ToFloat64(i1.TSDBState.headSnapshotUploads))
+
+			require.NoError(t, services.StopAndAwaitTerminated(ctx, i1))
+
+			// Start a new ingester with the same ID and an empty disk.
+			i2, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+			require.NoError(t, err)
+			i2.TSDBState.bucket = i1.TSDBState.bucket
+			require.NoError(t, services.StartAndAwaitRunning(ctx, i2))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i2)
+			})
+
+			assert.Equal(t, float64(1), testutil.ToFloat64(i2.TSDBState.headSnapshotRestores))
+			assert.Equal(t, float64(0), testutil.ToFloat64(i2.TSDBState.headSnapshotRestoresFailed))
+
+			db, err := i2.getTSDB(userID)
+			require.NoError(t, err)
+			require.NotNil(t, db)
+			assert.Equal(t, uint64(1), db.Head().NumSeries())
+		})
+	}
+}
+
+func TestIngester_RestoreHeadSnapshots(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval = time.Hour
+
+	t.Run("should skip incomplete snapshots", func(t *testing.T) {
+		i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+		require.NoError(t, err)
+
+		// The snapshot of the second user has no manifest, so it's not restored.
+		require.NoError(t, i.TSDBState.bucket.Upload(ctx, path.Join(userID, headSnapshotsPrefix("localhost"), "wal", "00000000"), strings.NewReader("complete")))
+		require.NoError(t, i.TSDBState.bucket.Upload(ctx, path.Join(userID, headSnapshotsPrefix("localhost"), headSnapshotManifestFilename), strings.NewReader(`{"version":1,"files":[{"name":"wal/00000000","size":8}]}`)))
+		require.NoError(t, i.TSDBState.bucket.Upload(ctx, path.Join("user-2", headSnapshotsPrefix("localhost"), "wal", "00000000"), strings.NewReader("incomplete")))
+
+		i.restoreHeadSnapshots(ctx)
+		assert.Equal(t, float64(1), testutil.ToFloat64(i.TSDBState.headSnapshotRestores))
+
+		data, err := os.ReadFile(filepath.Join(i.cfg.BlocksStorageConfig.TSDB.Dir, userID, "wal", "00000000"))
+		require.NoError(t, err)
+		assert.Equal(t, "complete", string(data))
+		assert.NoDirExists(t, filepath.Join(i.cfg.BlocksStorageConfig.TSDB.Dir, "user-2"))
+	})
+
+	t.Run("should not restore if the TSDB dir is not empty", func(t *testing.T) {
+		dataDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dataDir, "another-user"), os.ModePerm))
+
+		i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, defaultLimitsTestConfig(), nil, dataDir, prometheus.NewPedanticRegistry())
+		require.NoError(t, err)
+
+		prefix := path.Join(userID, headSnapshotsPrefix("localhost"))
+		require.NoError(t, i.TSDBState.bucket.Upload(ctx, path.Join(prefix, "wal", "00000000"), strings.NewReader("data")))
+		require.NoError(t, i.TSDBState.bucket.Upload(ctx, path.Join(prefix, headSnapshotManifestFilename), strings.NewReader(`{"version":1,"files":[{"name":"wal/00000000","size":4}]}`)))
+
+		i.restoreHeadSnapshots(ctx)
+		assert.Equal(t, float64(0), testutil.ToFloat64(i.TSDBState.headSnapshotRestores))
+		assert.NoDirExists(t, filepath.Join(dataDir, userID))
+	})
+
+	t.Run("should remove the tenant TSDB if the snapshot is invalid", func(t *testing.T) {
+		i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+		require.NoError(t, err)
+
+		prefix := path.Join(userID, headSnapshotsPrefix("localhost"))
+		require.NoError(t, i.TSDBState.bucket.Upload(ctx, path.Join(prefix, headSnapshotManifestFilename), strings.NewReader(`{"version":1,"files":[{"name":"wal/00000000","size":4}]}`)))
+
+		i.restoreHeadSnapshots(ctx)
+		assert.Equal(t, float64(1), testutil.ToFloat64(i.TSDBState.headSnapshotRestoresFailed))
+		assert.NoDirExists(t, filepath.Join(i.cfg.BlocksStorageConfig.TSDB.Dir, userID))
+	})
+}
+
+func TestListHeadSnapshotFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"chunk_snapshot.000001.0000000010/00000000",
+		"chunk_snapshot.000002.0000000020/00000000",
+		"chunks_head/000001",
+		"chunks_head/000002",
+		"wal/checkpoint.00000001/00000000",
+		"wal/checkpoint.00000002/00000000",
+		"wal/00000002",
+		"wal/00000003",
+		"wal/00000004",
+		"wbl/00000000",
+		"01HXXXXXXXXXXXXXXXXXXXXXXX/meta.json",
+		"lock",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, f), nil, os.ModePerm))
+	}
+
+	// The WAL segment being written.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wal", "00000004"), []byte("data"), os.ModePerm))
+
+	files, err := listHeadSnapshotFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []headSnapshotFile{
+		{Name: "chunk_snapshot.000002.0000000020/00000000"},
+		{Name: "chunks_head/000001"},
+		{Name: "chunks_head/000002"},
+		{Name: "wal/checkpoint.00000002/00000000"},
+		{Name: "wal/00000003"},
+		{Name: "wal/00000004", Size: 4},
+		{Name: "wbl/00000000"},
+	}, files)
+}
+
+func manifestFileNames(manifest *headSnapshotManifest) []string {
+	names := make([]string, 0, len(manifest.Files))
+	for _, f := range manifest.Files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func readBucketObject(t *testing.T, i *Ingester, name string) string {
+	r, err := i.TSDBState.bucket.Get(context.Background(), name)
+	require.NoError(t, err)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -313,6 +313,9 @@ type Ingester struct {
 	resourceBasedLimiter *limiter.ResourceBasedLimiter
 	subservicesWatcher   *services.FailureWatcher
 
+	// Per-tenant rate limiter of the series creation.
+	seriesCreationRateLimiter *limiter.RateLimiter
+
 	stoppedMtx sync.RWMutex // protects stopped
 	stopped    bool         // protected by stoppedMtx
 
@@ -335,6 +338,8 @@ type Ingester struct {
 	expandedPostingsCacheFactory *cortex_tsdb.ExpandedPostingsCacheFactory
 
 	activeQueriedSeriesService *ActiveQueriedSeriesService
+
+	prepareShutdown prepareShutdownState
 }
 
 // Shipper interface is used to have an easy way to mock it in tests.
@@ -383,6 +388,8 @@ type userTSDB struct {
 	labelSetCounter     *labelSetCounter
 	limiter             *Limiter
 
+	seriesCreationRateLimiter *limiter.RateLimiter
+
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
 	instanceLimitsFn    func() *InstanceLimits
 
@@ -413,6 +420,10 @@ type userTSDB struct {
 	shippedBlocksMtx sync.Mutex
 	shippedBlocks    map[ulid.ULID]struct{}
 
+	// Size of the files of the latest head snapshot uploaded to the storage, by path relative
+	// to the TSDB dir. Only accessed by the head snapshots upload.
+	headSnapshotFiles map[string]int64
+
 	// Used to dedup strings and keep a single reference in memory
 	labelsStringInterningEnabled bool
 	interner                     util.Interner
@@ -546,6 +557,12 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 		return err
 	}
 
+	// Series creation rate limit. Checked last, so that series rejected by
+	// the other limits don't consume the tenant's rate.
+	if u.seriesCreationRateLimiter != nil && !u.seriesCreationRateLimiter.AllowN(time.Now(), u.userID, 1) {
+		return errMaxSeriesCreationRateLimitExceeded
+	}
+
 	if u.labelsStringInterningEnabled {
 		metric.InternStrings(u.interner.Intern)
 	}
@@ -732,6 +749,16 @@ type TSDBState struct {
 	appenderAddDuration    prometheus.Histogram
 	appenderCommitDuration prometheus.Histogram
 	idleTsdbChecks         *prometheus.CounterVec
+
+	// Head snapshots metrics.
+	headSnapshotUploads        prometheus.Counter
+	headSnapshotUploadsFailed  prometheus.Counter
+	headSnapshotRestores       prometheus.Counter
+	headSnapshotRestoresFailed prometheus.Counter
+
+	// Whether the head snapshots of the tenants without a TSDB have been deleted since startup.
+	// Only accessed by the head snapshots upload.
+	staleHeadSnapshotsDeleted bool
 }
 
 type requestWithUsersAndCallback struct {
@@ -789,6 +816,23 @@ func newTSDBState(bucketClient objstore.Bucket, registerer prometheus.Registerer
 		}),
 
 		idleTsdbChecks: idleTsdbChecks,
+
+		headSnapshotUploads: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_head_snapshot_uploads_total",
+			Help: "Total number of TSDB head snapshots uploaded to the storage.",
+		}),
+		headSnapshotUploadsFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_head_snapshot_uploads_failed_total",
+			Help: "Total number of TSDB head snapshots that failed to be uploaded to the storage.",
+		}),
+		headSnapshotRestores: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_head_snapshot_restores_total",
+			Help: "Total number of TSDB head snapshots restored from the storage on startup.",
+		}),
+		headSnapshotRestoresFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_tsdb_head_snapshot_restores_failed_total",
+			Help: "Total number of TSDB head snapshots that failed to be restored from the storage on startup.",
+		}),
 	}
 }
 
@@ -874,6 +918,7 @@ func New(cfg Config, limits *validation.Overrides, registerer prometheus.Registe
 		cfg.LifecyclerConfig.RingConfig.ZoneAwarenessEnabled,
 		cfg.AdminLimitMessage,
 	)
+	i.seriesCreationRateLimiter = limiter.NewRateLimiter(newSeriesCreationRateStrategy(i.limiter), 10*time.Second)
 
 	i.TSDBState.shipperIngesterID = i.lifecycler.ID
 
@@ -1003,6 +1048,11 @@ func (i *Ingester) starting(ctx context.Context) error {
 		servs = append(servs, closeIdleService)
 	}
 
+	if i.cfg.BlocksStorageConfig.TSDB.IsHeadSnapshotUploadEnabled() {
+		interval := util.DurationWithJitter(i.cfg.BlocksStorageConfig.TSDB.HeadSnapshotUploadInterval, 0.05)
+		servs = append(servs, services.NewTimerService(interval, nil, i.uploadHeadSnapshots, nil))
+	}
+
 	if i.expandedPostingsCacheFactory != nil {
 		interval := i.cfg.BlocksStorageConfig.TSDB.ExpandedCachingExpireInterval
 		if interval == 0 {
@@ -1403,6 +1453,7 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		newValueForTimestampCount              = 0
 		perUserSeriesLimitCount                = 0
 		perUserNativeHistogramSeriesLimitCount = 0
+		perUserSeriesCreationRateLimitCount    = 0
 		perLabelSetSeriesLimitCount            = 0
 		perMetricSeriesLimitCount              = 0
 		discardedNativeHistogramCount          = 0
@@ -1452,6 +1503,13 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 					return makeLimitError(perUserSeriesLimit, i.limiter.FormatError(userID, cause, copiedLabels))
 				})
 
+			case errors.Is(cause, errMaxSeriesCreationRateLimitExceeded):
+				perUserSeriesCreationRateLimitCount++
+				i.validateMetrics.DiscardedSeriesTracker.Track(perUserSeriesCreationRateLimit, userID, copiedLabels.Hash())
+				updateFirstPartial(func() error {
+					return makeLimitError(perUserSeriesCreationRateLimit, i.limiter.FormatError(userID, cause, copiedLabels))
+				})
+
 			case errors.Is(cause, errMaxSeriesPerMetricLimitExceeded):
 				perMetricSeriesLimitCount++
 				i.validateMetrics.DiscardedSeriesTracker.Track(perMetricSeriesLimit, userID, copiedLabels.Hash())
@@ -1738,6 +1796,9 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	if perUserNativeHistogramSeriesLimitCount > 0 {
 		i.validateMetrics.DiscardedSamples.WithLabelValues(perUserNativeHistogramSeriesLimit, userID).Add(float64(perUserNativeHistogramSeriesLimitCount))
 	}
+	if perUserSeriesCreationRateLimitCount > 0 {
+		i.validateMetrics.DiscardedSamples.WithLabelValues(perUserSeriesCreationRateLimit, userID).Add(float64(perUserSeriesCreationRateLimitCount))
+	}
 	if perMetricSeriesLimitCount > 0 {
 		i.validateMetrics.DiscardedSamples.WithLabelValues(perMetricSeriesLimit, userID).Add(float64(perMetricSeriesLimitCount))
 	}
@@ -2696,6 +2757,34 @@ func (i *Ingester) checkRegexMatcherLimits(ctx context.Context, userID string, d
 	return nil
 }
 
+// toClientChunk converts a chunk returned by the TSDB chunk querier to a client chunk.
+func toClientChunk(meta chunks.Meta) (client.Chunk, error) {
+	// It is not guaranteed that chunk returned by iterator is populated.
+	// For now just return error. We could also try to figure out how to read the chunk.
+	if meta.Chunk == nil {
+		return client.Chunk{}, errors.Errorf("unfilled chunk returned from TSDB chunk querier")
+	}
+
+	ch := client.Chunk{
+		StartTimestampMs: meta.MinTime,
+		EndTimestampMs:   meta.MaxTime,
+		Data:             meta.Chunk.Bytes(),
+	}
+
+	switch meta.Chunk.Encoding() {
+	case chunkenc.EncXOR:
+		ch.Encoding = int32(encoding.PrometheusXorChunk)
+	case chunkenc.EncHistogram:
+		ch.Encoding = int32(encoding.PrometheusHistogramChunk)
+	case chunkenc.EncFloatHistogram:
+		ch.Encoding = int32(encoding.PrometheusFloatHistogramChunk)
+	default:
+		return client.Chunk{}, errors.Errorf("unknown chunk encoding from TSDB chunk querier: %v", meta.Chunk.Encoding())
+	}
+
+	return ch, nil
+}
+
 // queryStreamChunks streams metrics from a TSDB. This implements the client.IngesterServer interface
 func (i *Ingester) queryStreamChunks(ctx context.Context, userID string, db *userTSDB, from, through int64, matchers []*labels.Matcher, sm *storepb.ShardMatcher, stream client.Ingester_QueryStreamServer) (numSeries, numSamples, totalBatchSizeBytes, numChunks int, _ error) {
 	q, err := db.ChunkQuerier(from, through)
@@ -2736,6 +2825,14 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, userID string, db *use
 	batchSizeBytes := 0
 	var it chunks.Iterator
 
+	// The limits are checked while iterating, so that the query is rejected before the whole response is built.
+	maxFetchedSeries := i.limits.IngesterMaxFetchedSeriesPerQuery(userID)
+	maxFetchedChunkBytes := i.limits.IngesterMaxFetchedChunkBytesPerQuery(userID)
+	chunkBytes := 0
+	defer func() {
+		i.metrics.queriedChunkBytesTotal.WithLabelValues(userID).Add(float64(chunkBytes))
+	}()
+
 	now := time.Now()
 	// Check sampling decision early to avoid calculating hashes if batch will be skipped
 	var queriedSeriesHashes []uint64
@@ -2759,6 +2856,11 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, userID string, db *use
 			continue
 		}
 
+		if maxFetchedSeries > 0 && numSeries >= maxFetchedSeries {
+			i.metrics.queriesLimitedTotal.WithLabelValues(userID, maxFetchedSeriesPerQueryLimit).Inc()
+			return 0, 0, 0, 0, makeQueryLimitError(errMaxFetchedSeriesPerQueryHit, maxFetchedSeries)
+		}
+
 		// Collect hash for batched tracking (only if sampling decision allows)
 		if sampled {
 			hash := lbls.Hash()
@@ -2775,27 +2877,15 @@ func (i *Ingester) queryStreamChunks(ctx context.Context, userID string, db *use
 			// Chunks are ordered by min time.
 			meta := it.At()
 
-			// It is not guaranteed that chunk returned by iterator is populated.
-			// For now just return error. We could also try to figure out how to read the chunk.
-			if meta.Chunk == nil {
-				return 0, 0, 0, 0, errors.Errorf("unfilled chunk returned from TSDB chunk querier")
-			}
-
-			ch := client.Chunk{
-				StartTimestampMs: meta.MinTime,
-				EndTimestampMs:   meta.MaxTime,
-				Data:             meta.Chunk.Bytes(),
+			ch, err := toClientChunk(meta)
+			if err != nil {
+				return 0, 0, 0, 0, err
 			}
 
-			switch meta.Chunk.Encoding() {
-			case chunkenc.EncXOR:
-				ch.Encoding = int32(encoding.PrometheusXorChunk)
-			case chunkenc.EncHistogram:
-				ch.Encoding = int32(encoding.PrometheusHistogramChunk)
-			case chunkenc.EncFloatHistogram:
-				ch.Encoding = int32(encoding.PrometheusFloatHistogramChunk)
-			default:
-				return 0, 0, 0, 0, errors.Errorf("unknown chunk encoding from TSDB chunk querier: %v", meta.Chunk.Encoding())
+			chunkBytes += ch.Size()
+			if maxFetchedChunkBytes > 0 && chunkBytes > maxFetchedChunkBytes {
+				i.metrics.queriesLimitedTotal.WithLabelValues(userID, maxFetchedChunkBytesPerQueryLimit).Inc()
+				return 0, 0, 0, 0, makeQueryLimitError(errMaxFetchedChunkBytesPerQueryHit, maxFetchedChunkBytes)
 			}
 
 			ts.Chunks = append(ts.Chunks, ch)
@@ -3039,7 +3129,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		IsolationDisabled:              true,
 		MaxExemplars:                   maxExemplarsForUser,
 		HeadChunksWriteQueueSize:       i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteQueueSize,
-		EnableMemorySnapshotOnShutdown: i.cfg.BlocksStorageConfig.TSDB.MemorySnapshotOnShutdown,
+		EnableMemorySnapshotOnShutdown: i.cfg.BlocksStorageConfig.TSDB.MemorySnapshotOnShutdown || i.cfg.BlocksStorageConfig.TSDB.IsHeadSnapshotUploadEnabled(),
 		OutOfOrderTimeWindow:           time.Duration(oooTimeWindow).Milliseconds(),
 		OutOfOrderCapMax:               i.cfg.BlocksStorageConfig.TSDB.OutOfOrderCapMax,
 		EnableOverlappingCompaction:    false, // Always let compactors handle overlapped blocks, e.g. OOO blocks.
@@ -3066,6 +3156,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 	// We set the limiter here because we don't want to limit
 	// series during WAL replay.
 	userDB.limiter = i.limiter
+	userDB.seriesCreationRateLimiter = i.seriesCreationRateLimiter
 
 	if db.Head().NumSeries() > 0 {
 		// If there are series in the head, use max time from head. If this time is too old,
@@ -3148,6 +3239,11 @@ func (i *Ingester) closeAllTSDB() {
 func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 	level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "opening existing TSDBs")
 
+	// The flusher doesn't join the ring, so it only flushes the TSDBs found on the disk.
+	if i.lifecycler != nil && i.cfg.BlocksStorageConfig.TSDB.IsHeadSnapshotUploadEnabled() {
+		i.restoreHeadSnapshots(ctx)
+	}
+
 	queue := make(chan string)
 	group, groupCtx := errgroup.WithContext(ctx)
 
@@ -3568,6 +3664,13 @@ func (i *Ingester) closeAndDeleteUserTSDBIfIdle(userID string) tsdbCloseCheckRes
 
 	validation.DeletePerUserValidationMetrics(i.validateMetrics, userID, i.logger)
 
+	// Delete the head snapshots, so that the closed TSDB is not restored on startup.
+	if i.cfg.BlocksStorageConfig.TSDB.IsHeadSnapshotUploadEnabled() {
+		if err := i.deleteHeadSnapshots(context.Background(), userID); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to delete TSDB head snapshots", "user", userID, "err", err)
+		}
+	}
+
 	// And delete local data.
 	if err := os.RemoveAll(dir); err != nil {
 		level.Error(i.logger).Log("msg", "failed to delete local TSDB", "user", userID, "err", err)
@@ -3728,66 +3831,75 @@ func (i *Ingester) flushHandler(w http.ResponseWriter, r *http.Request) {
 
 	allowedUsers := users.NewAllowedTenants(tenants, nil)
 	run := func() {
-		ingCtx := i.ServiceContext()
-		if ingCtx == nil || ingCtx.Err() != nil {
-			level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: ingester not running, ignoring flush request")
-			return
-		}
-
-		compactionCallbackCh := make(chan struct{})
+		_ = i.flushTSDBBlocks(r.Context(), allowedUsers)
+	}
 
-		level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: triggering compaction")
-		select {
-		case i.TSDBState.forceCompactTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: compactionCallbackCh}:
-			// Compacting now.
-		case <-ingCtx.Done():
-			level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
-			return
-		}
+	if len(r.Form[waitParam]) > 0 && r.Form[waitParam][0] == "true" {
+		// Run synchronously. This simplifies and speeds up tests.
+		run()
+	} else {
+		go run()
+	}
 
-		// Wait until notified about compaction being finished.
-		select {
-		case <-compactionCallbackCh:
-			level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "finished compacting TSDB blocks")
-		case <-ingCtx.Done():
-			level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
-			return
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
-			shippingCallbackCh := make(chan struct{}) // must be new channel, as compactionCallbackCh is closed now.
+// flushTSDBBlocks force-compacts the head of the allowed tenants and ships their blocks to the storage, waiting
+// for both to complete. Returns an error if the ingester stopped running before the flush completed.
+func (i *Ingester) flushTSDBBlocks(ctx context.Context, allowedUsers *users.AllowedTenants) error {
+	logger := logutil.WithContext(ctx, i.logger)
 
-			level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: triggering shipping")
+	ingCtx := i.ServiceContext()
+	if ingCtx == nil || ingCtx.Err() != nil {
+		level.Info(logger).Log("msg", "flushing TSDB blocks: ingester not running, ignoring flush request")
+		return errIngesterStopping
+	}
 
-			select {
-			case i.TSDBState.shipTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: shippingCallbackCh}:
-				// shipping now
-			case <-ingCtx.Done():
-				level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
-				return
-			}
+	compactionCallbackCh := make(chan struct{})
 
-			// Wait until shipping finished.
-			select {
-			case <-shippingCallbackCh:
-				level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "shipping of TSDB blocks finished")
-			case <-ingCtx.Done():
-				level.Warn(logutil.WithContext(r.Context(), i.logger)).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
-				return
-			}
-		}
+	level.Info(logger).Log("msg", "flushing TSDB blocks: triggering compaction")
+	select {
+	case i.TSDBState.forceCompactTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: compactionCallbackCh}:
+		// Compacting now.
+	case <-ingCtx.Done():
+		level.Warn(logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+		return errors.Wrap(errIngesterStopping, "compact TSDB blocks")
+	}
 
-		level.Info(logutil.WithContext(r.Context(), i.logger)).Log("msg", "flushing TSDB blocks: finished")
+	// Wait until notified about compaction being finished.
+	select {
+	case <-compactionCallbackCh:
+		level.Info(logger).Log("msg", "finished compacting TSDB blocks")
+	case <-ingCtx.Done():
+		level.Warn(logger).Log("msg", "failed to compact TSDB blocks, ingester not running anymore")
+		return errors.Wrap(errIngesterStopping, "compact TSDB blocks")
 	}
 
-	if len(r.Form[waitParam]) > 0 && r.Form[waitParam][0] == "true" {
-		// Run synchronously. This simplifies and speeds up tests.
-		run()
-	} else {
-		go run()
+	if i.cfg.BlocksStorageConfig.TSDB.IsBlocksShippingEnabled() {
+		shippingCallbackCh := make(chan struct{}) // must be new channel, as compactionCallbackCh is closed now.
+
+		level.Info(logger).Log("msg", "flushing TSDB blocks: triggering shipping")
+
+		select {
+		case i.TSDBState.shipTrigger <- requestWithUsersAndCallback{users: allowedUsers, callback: shippingCallbackCh}:
+			// shipping now
+		case <-ingCtx.Done():
+			level.Warn(logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
+			return errors.Wrap(errIngesterStopping, "ship TSDB blocks")
+		}
+
+		// Wait until shipping finished.
+		select {
+		case <-shippingCallbackCh:
+			level.Info(logger).Log("msg", "shipping of TSDB blocks finished")
+		case <-ingCtx.Done():
+			level.Warn(logger).Log("msg", "failed to ship TSDB blocks, ingester not running anymore")
+			return errors.Wrap(errIngesterStopping, "ship TSDB blocks")
+		}
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	level.Info(logger).Log("msg", "flushing TSDB blocks: finished")
+	return nil
 }
 
 // ModeHandler Change mode of ingester.
@@ -870,6 +870,66 @@ func TestIngesterUserLimitExceeded(t *testing.T) {
 
 }
 
+func TestIngesterSeriesCreationRateLimitExceeded(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.MaxSeriesCreationRate = 0.0001 // Low enough to not refill during the test.
+	limits.MaxSeriesCreationBurst = 2
+
+	userID := "1"
+	labels1 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "bar")
+	labels2 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "baz")
+	labels3 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "biz")
+	labels4 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "boz")
+	labels5 := labels.FromStrings(labels.MetricName, "testmetric", "foo", "buz")
+
+	blocksDir := t.TempDir()
+	ingGenerator := func(reg prometheus.Registerer) *Ingester {
+		ing, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, nil, blocksDir, reg)
+		require.NoError(t, err)
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+		// Wait until it's ACTIVE
+		test.Poll(t, time.Second, ring.ACTIVE, func() any {
+			return ing.lifecycler.GetState()
+		})
+
+		return ing
+	}
+
+	reg := prometheus.NewRegistry()
+	ing := ingGenerator(reg)
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	// Create series up to the burst, expect no error.
+	_, err := ing.Push(ctx, cortexpb.ToWriteRequest([]labels.Labels{labels1, labels2}, []cortexpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 0, Value: 2}}, nil, nil, cortexpb.API))
+	require.NoError(t, err)
+
+	// Append to the existing series and create a new one, expect the new series to be rejected.
+	_, err = ing.Push(ctx, cortexpb.ToWriteRequest([]labels.Labels{labels1, labels3}, []cortexpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 1, Value: 3}}, nil, nil, cortexpb.API))
+	httpResp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok, "returned error is not an httpgrpc response")
+	assert.Equal(t, http.StatusBadRequest, int(httpResp.Code))
+	assert.Equal(t, wrapWithUser(makeLimitError(perUserSeriesCreationRateLimit, ing.limiter.FormatError(userID, errMaxSeriesCreationRateLimitExceeded, labels3)), userID).Error(), string(httpResp.Body))
+
+	res, _, err := runTestQuery(ctx, t, ing, labels.MatchEqual, model.MetricNameLabel, "testmetric")
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Len(t, res[0].Values, 2)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+		# TYPE cortex_discarded_samples_total counter
+		cortex_discarded_samples_total{reason="per_user_series_creation_rate_limit",user="1"} 1
+	`), "cortex_discarded_samples_total"))
+
+	// The series replayed from the WAL at restart don't consume the rate.
+	services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+	ing = ingGenerator(prometheus.NewRegistry())
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	_, err = ing.Push(ctx, cortexpb.ToWriteRequest([]labels.Labels{labels4, labels5}, []cortexpb.Sample{{TimestampMs: 2, Value: 4}, {TimestampMs: 2, Value: 5}}, nil, nil, cortexpb.API))
+	require.NoError(t, err)
+}
+
 func TestIngesterUserLimitExceededForNativeHistogram(t *testing.T) {
 	limits := defaultLimitsTestConfig()
 	limits.EnableNativeHistograms = true
@@ -4092,6 +4152,82 @@ func TestIngester_QueryStream(t *testing.T) {
 	}
 }
 
+func TestIngester_QueryStream_ShouldEnforceIngesterQueryLimits(t *testing.T) {
+	tests := map[string]struct {
+		maxFetchedSeries     int
+		maxFetchedChunkBytes int
+		expectedErr          error
+		expectedLimit        string
+	}{
+		"should succeed with no limits": {},
+		"should succeed if the series limit is not exceeded": {
+			maxFetchedSeries: 3,
+		},
+		"should fail if the series limit is exceeded": {
+			maxFetchedSeries: 2,
+			expectedErr:      httpgrpc.Errorf(http.StatusUnprocessableEntity, errMaxFetchedSeriesPerQueryHit, 2),
+			expectedLimit:    maxFetchedSeriesPerQueryLimit,
+		},
+		"should succeed if the chunk bytes limit is not exceeded": {
+			maxFetchedChunkBytes: 1024 * 1024,
+		},
+		"should fail if the chunk bytes limit is exceeded": {
+			maxFetchedChunkBytes: 1,
+			expectedErr:          httpgrpc.Errorf(http.StatusUnprocessableEntity, errMaxFetchedChunkBytesPerQueryHit, 1),
+			expectedLimit:        maxFetchedChunkBytesPerQueryLimit,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := defaultLimitsTestConfig()
+			limits.IngesterMaxFetchedSeriesPerQuery = testData.maxFetchedSeries
+			limits.IngesterMaxFetchedChunkBytesPerQuery = testData.maxFetchedChunkBytes
+
+			reg := prometheus.NewPedanticRegistry()
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, nil, "", reg)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			ctx := user.InjectOrgID(context.Background(), userID)
+			for _, series := range []string{"1", "2", "3"} {
+				req, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "foo", "series", series), 1, 100000)
+				_, err := i.Push(ctx, req)
+				require.NoError(t, err)
+			}
+
+			s := &mockQueryStreamServer{ctx: ctx}
+			err = i.QueryStream(&client.QueryRequest{
+				StartTimestampMs: 0,
+				EndTimestampMs:   200000,
+				Matchers: []*client.LabelMatcher{{
+					Type:  client.EQUAL,
+					Name:  model.MetricNameLabel,
+					Value: "foo",
+				}},
+			}, s)
+
+			expectedMetrics := `
+				# HELP cortex_ingester_queries_limited_total The total number of queries rejected because they exceeded an ingester query limit, per user and limit.
+				# TYPE cortex_ingester_queries_limited_total counter
+			`
+			if testData.expectedErr != nil {
+				require.Equal(t, testData.expectedErr, err)
+				expectedMetrics += fmt.Sprintf("cortex_ingester_queries_limited_total{limit=%q,user=%q} 1\n", testData.expectedLimit, userID)
+			} else {
+				require.NoError(t, err)
+				set, err := seriesSetFromResponseStream(s)
+				require.NoError(t, err)
+				r, err := client.SeriesSetToQueryResponse(set)
+				require.NoError(t, err)
+				require.Len(t, r.Timeseries, 3)
+			}
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_ingester_queries_limited_total"))
+		})
+	}
+}
+
 func TestIngester_QueryStreamManySamplesChunks(t *testing.T) {
 	// Create ingester.
 	cfg := defaultIngesterTestConfig(t)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/time/rate"
 
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/validation"
@@ -17,6 +18,7 @@ var (
 	errMaxSeriesPerUserLimitExceeded                = errors.New("per-user series limit exceeded")
 	errMaxNativeHistogramSeriesPerUserLimitExceeded = errors.New("per-user native histogram series limit exceeded")
 	errMaxMetadataPerUserLimitExceeded              = errors.New("per-user metric metadata limit exceeded")
+	errMaxSeriesCreationRateLimitExceeded           = errors.New("per-user series creation rate limit exceeded")
 )
 
 type errMaxSeriesPerLabelSetLimitExceeded struct {
@@ -149,6 +151,8 @@ func (l *Limiter) FormatError(userID string, err error, lbls labels.Labels) erro
 		return l.formatMaxNativeHistogramsSeriesPerUserError(userID)
 	case errors.Is(err, errMaxSeriesPerMetricLimitExceeded):
 		return l.formatMaxSeriesPerMetricError(userID, lbls.Get(labels.MetricName))
+	case errors.Is(err, errMaxSeriesCreationRateLimitExceeded):
+		return l.formatMaxSeriesCreationRateError(userID)
 	case errors.Is(err, errMaxMetadataPerUserLimitExceeded):
 		return l.formatMaxMetadataPerUserError(userID)
 	case errors.Is(err, errMaxMetadataPerMetricLimitExceeded):
@@ -180,6 +184,15 @@ func (l *Limiter) formatMaxNativeHistogramsSeriesPerUserError(userID string) err
 		minNonZero(localLimit, globalLimit), l.AdminLimitMessage, localLimit, globalLimit, actualLimit)
 }
 
+func (l *Limiter) formatMaxSeriesCreationRateError(userID string) error {
+	actualLimit := l.maxSeriesCreationRate(userID)
+	limit := l.limits.MaxSeriesCreationRate(userID)
+	burst := l.limits.MaxSeriesCreationBurst(userID)
+
+	return fmt.Errorf("per-user series creation rate limit of %v series/s (burst: %d) exceeded, %s (actual local limit: %v series/s)",
+		limit, burst, l.AdminLimitMessage, actualLimit)
+}
+
 func (l *Limiter) formatMaxSeriesPerMetricError(userID string, metric string) error {
 	actualLimit := l.maxSeriesPerMetric(userID)
 	localLimit := l.limits.MaxLocalSeriesPerMetric(userID)
@@ -286,6 +299,30 @@ func (l *Limiter) maxMetadataPerUser(userID string) int {
 	)
 }
 
+// maxSeriesCreationRate returns the max number of series per second the tenant can create in
+// this ingester. When the global ingestion rate strategy is used, the configured limit is shared
+// across the ingesters the tenant's series are written to.
+func (l *Limiter) maxSeriesCreationRate(userID string) float64 {
+	limit := l.limits.MaxSeriesCreationRate(userID)
+	if limit <= 0 {
+		return float64(rate.Inf)
+	}
+
+	if l.limits.IngestionRateStrategy() != validation.GlobalIngestionRateStrategy || !l.shardByAllLabels {
+		return limit
+	}
+
+	numIngesters := l.numIngesters(userID)
+
+	// May happen because the number of ingesters is asynchronously updated.
+	// If happens, we just temporarily ignore the global limit.
+	if numIngesters == 0 {
+		return float64(rate.Inf)
+	}
+
+	return (limit / float64(numIngesters)) * float64(l.replicationFactor)
+}
+
 func (l *Limiter) maxByLocalAndGlobal(userID string, localLimitFn, globalLimitFn func(string) int) int {
 	localLimit := localLimitFn(userID)
 
@@ -317,7 +354,7 @@ func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int
 	// topology changes) and we prefer to always be in favor of the tenant,
 	// we can use a per-ingester limit equal to:
 	// (global limit / number of ingesters) * replication factor
-	numIngesters := l.ring.HealthyInstancesCount()
+	numIngesters := l.numIngesters(userID)
 
 	// May happen because the number of ingesters is asynchronously updated.
 	// If happens, we just temporarily ignore the global limit.
@@ -325,6 +362,16 @@ func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int
 		return 0
 	}
 
+	return int((float64(globalLimit) / float64(numIngesters)) * float64(l.replicationFactor))
+}
+
+// numIngesters returns the number of ingesters the tenant's series are written to.
+func (l *Limiter) numIngesters(userID string) int {
+	numIngesters := l.ring.HealthyInstancesCount()
+	if numIngesters == 0 {
+		return 0
+	}
+
 	// If the number of available ingesters is greater than the tenant's shard
 	// size, then we should honor the shard size because series/metadata won't
 	// be written to more ingesters than it.
@@ -333,7 +380,25 @@ func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int
 		numIngesters = min(numIngesters, util.ShuffleShardExpectedInstances(shardSize, l.getNumZones()))
 	}
 
-	return int((float64(globalLimit) / float64(numIngesters)) * float64(l.replicationFactor))
+	return numIngesters
+}
+
+// seriesCreationRateStrategy is the limiter.RateLimiterStrategy of the per-tenant series creation rate limit.
+type seriesCreationRateStrategy struct {
+	limiter *Limiter
+}
+
+func newSeriesCreationRateStrategy(limiter *Limiter) *seriesCreationRateStrategy {
+	return &seriesCreationRateStrategy{limiter: limiter}
+}
+
+func (s *seriesCreationRateStrategy) Limit(userID string) float64 {
+	return s.limiter.maxSeriesCreationRate(userID)
+}
+
+func (s *seriesCreationRateStrategy) Burst(userID string) int {
+	// The burst is applied as is by each ingester, like the distributors do for the ingestion rate.
+	return s.limiter.limits.MaxSeriesCreationBurst(userID)
 }
 
 func (l *Limiter) getShardSize(userID string) int {
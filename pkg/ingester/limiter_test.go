@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/time/rate"
 
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/validation"
@@ -79,6 +80,96 @@ func TestLimiter_maxMetadataPerUser(t *testing.T) {
 	runLimiterMaxFunctionTest(t, applyLimits, runMaxFn, false)
 }
 
+func TestLimiter_maxSeriesCreationRate(t *testing.T) {
+	tests := map[string]struct {
+		limit                 float64
+		strategy              string
+		shardByAllLabels      bool
+		ringReplicationFactor int
+		ringIngesterCount     int
+		shardSize             int
+		expectedDefault       float64
+		expectedShuffle       float64
+	}{
+		"limit is disabled": {
+			limit:                 0,
+			strategy:              validation.GlobalIngestionRateStrategy,
+			shardByAllLabels:      true,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     10,
+			expectedDefault:       float64(rate.Inf),
+			expectedShuffle:       float64(rate.Inf),
+		},
+		"local strategy": {
+			limit:                 100,
+			strategy:              validation.LocalIngestionRateStrategy,
+			shardByAllLabels:      true,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     10,
+			shardSize:             5,
+			expectedDefault:       100,
+			expectedShuffle:       100,
+		},
+		"global strategy with shard-by-all-labels=false": {
+			limit:                 100,
+			strategy:              validation.GlobalIngestionRateStrategy,
+			shardByAllLabels:      false,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     10,
+			shardSize:             5,
+			expectedDefault:       100,
+			expectedShuffle:       100,
+		},
+		"global strategy with shard-by-all-labels=true": {
+			limit:                 100,
+			strategy:              validation.GlobalIngestionRateStrategy,
+			shardByAllLabels:      true,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     10,
+			shardSize:             5,
+			expectedDefault:       30,
+			expectedShuffle:       60,
+		},
+		"global strategy and no healthy ingesters": {
+			limit:                 100,
+			strategy:              validation.GlobalIngestionRateStrategy,
+			shardByAllLabels:      true,
+			ringReplicationFactor: 3,
+			ringIngesterCount:     0,
+			shardSize:             5,
+			expectedDefault:       float64(rate.Inf),
+			expectedShuffle:       float64(rate.Inf),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// Mock the ring
+			ring := &ringCountMock{}
+			ring.On("HealthyInstancesCount").Return(testData.ringIngesterCount)
+			ring.On("ZonesCount").Return(1)
+
+			// Mock limits
+			overrides := validation.NewOverrides(validation.Limits{
+				IngestionRateStrategy:    testData.strategy,
+				IngestionTenantShardSize: testData.shardSize,
+				MaxSeriesCreationRate:    testData.limit,
+				MaxSeriesCreationBurst:   10,
+			}, nil)
+
+			limiter := NewLimiter(overrides, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			strategy := newSeriesCreationRateStrategy(limiter)
+			assert.Equal(t, testData.expectedDefault, strategy.Limit("test"))
+			assert.Equal(t, 10, strategy.Burst("test"))
+
+			limiter = NewLimiter(overrides, ring, util.ShardingStrategyShuffle, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			strategy = newSeriesCreationRateStrategy(limiter)
+			assert.Equal(t, testData.expectedShuffle, strategy.Limit("test"))
+			assert.Equal(t, 10, strategy.Burst("test"))
+		})
+	}
+}
+
 func runLimiterMaxFunctionTest(
 	t *testing.T,
 	applyLimits func(limits *validation.Limits, localLimit, globalLimit int),
@@ -646,6 +737,8 @@ func TestLimiter_FormatError(t *testing.T) {
 		MaxGlobalSeriesPerMetric:              20,
 		MaxGlobalMetricsWithMetadataPerUser:   10,
 		MaxGlobalMetadataPerMetric:            3,
+		MaxSeriesCreationRate:                 10,
+		MaxSeriesCreationBurst:                20,
 	}, nil)
 
 	limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, true, 3, false, "please contact administrator to raise it")
@@ -666,6 +759,9 @@ func TestLimiter_FormatError(t *testing.T) {
 	actual = limiter.FormatError("user-1", errMaxMetadataPerMetricLimitExceeded, lbls)
 	assert.EqualError(t, actual, "per-metric metadata limit of 3 exceeded for metric testMetric, please contact administrator to raise it (local limit: 0 global limit: 3 actual local limit: 3)")
 
+	actual = limiter.FormatError("user-1", errMaxSeriesCreationRateLimitExceeded, lbls)
+	assert.EqualError(t, actual, "per-user series creation rate limit of 10 series/s (burst: 20) exceeded, please contact administrator to raise it (actual local limit: 10 series/s)")
+
 	input := errors.New("unknown error")
 	actual = limiter.FormatError("user-1", input, lbls)
 	assert.Equal(t, input, actual)
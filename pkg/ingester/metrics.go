@@ -48,6 +48,8 @@ type ingesterMetrics struct {
 	queriedExemplars         prometheus.Histogram
 	queriedSeries            prometheus.Histogram
 	queriedChunks            prometheus.Histogram
+	queriedChunkBytesTotal   *prometheus.CounterVec
+	queriesLimitedTotal      *prometheus.CounterVec
 	memSeries                prometheus.Gauge
 	memMetadata              prometheus.Gauge
 	memUsers                 prometheus.Gauge
@@ -181,6 +183,14 @@ func newIngesterMetrics(r prometheus.Registerer,
 			// A small number of chunks per series - 10*(8^(7-1)) = 2.6m.
 			Buckets: prometheus.ExponentialBuckets(10, 8, 7),
 		}),
+		queriedChunkBytesTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_queried_chunk_bytes_total",
+			Help: "The total size of chunks in bytes returned from queries, per user.",
+		}, []string{"user"}),
+		queriesLimitedTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_queries_limited_total",
+			Help: "The total number of queries rejected because they exceeded an ingester query limit, per user and limit.",
+		}, []string{"user", "limit"}),
 		memSeries: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_ingester_memory_series",
 			Help: "The current number of series in memory.",
@@ -401,6 +411,8 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 	m.limitsPerLabelSet.DeletePartialMatch(prometheus.Labels{"user": userID})
 	m.pushErrorsTotal.DeletePartialMatch(prometheus.Labels{"user": userID})
 	m.ingestedHistogramBuckets.DeleteLabelValues(userID)
+	m.queriedChunkBytesTotal.DeleteLabelValues(userID)
+	m.queriesLimitedTotal.DeletePartialMatch(prometheus.Labels{"user": userID})
 
 	if m.memSeriesCreatedTotal != nil {
 		m.memSeriesCreatedTotal.DeleteLabelValues(userID)
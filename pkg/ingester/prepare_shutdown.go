@@ -0,0 +1,245 @@
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util"
+	logutil "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	prepareShutdownNotStarted = "not_started"
+	prepareShutdownFlushing   = "flushing"
+	prepareShutdownWaiting    = "waiting"
+	prepareShutdownReady      = "ready"
+	prepareShutdownFailed     = "failed"
+)
+
+// prepareShutdownState tracks the progress of the prepare shutdown workflow.
+type prepareShutdownState struct {
+	mtx       sync.Mutex
+	startedAt time.Time // Zero if the workflow has not been started.
+	flushed   bool
+	flushErr  error // Set if the flush failed, until the workflow is started again or aborted.
+	cancel    context.CancelFunc
+}
+
+// PrepareShutdownStatus is the response of the prepare shutdown endpoint.
+type PrepareShutdownStatus struct {
+	Status    string                        `json:"status"`
+	Ready     bool                          `json:"ready"`
+	StartedAt *time.Time                    `json:"started_at,omitempty"`
+	Error     string                        `json:"error,omitempty"`
+	Tenants   []PrepareShutdownTenantStatus `json:"tenants"`
+}
+
+// PrepareShutdownTenantStatus reports whether the data of a tenant is safe to be removed from the ingester.
+type PrepareShutdownTenantStatus struct {
+	UserID          string    `json:"user_id"`
+	Ready           bool      `json:"ready"`
+	HeadSeries      uint64    `json:"head_series"`
+	UnshippedBlocks int       `json:"unshipped_blocks"`
+	ReadyAt         time.Time `json:"ready_at"`
+}
+
+// PrepareShutdownHandler prepares the ingester to be scaled down:
+//   - POST switches the ingester to READONLY, so that it doesn't receive writes anymore, and flushes the blocks of
+//     all tenants to the storage. If the flush failed, POST retries it.
+//   - GET reports whether the data of each tenant has been shipped to the storage and is not queried from the
+//     ingesters anymore, ie. query_ingesters_within (or the shuffle sharding lookback period, if longer) has
+//     elapsed since the ingester switched to READONLY.
+//   - DELETE aborts the workflow and switches the ingester back to ACTIVE.
+func (i *Ingester) PrepareShutdownHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logutil.WithContext(r.Context(), i.logger)
+
+	switch r.Method {
+	case http.MethodPost:
+		if err := i.startPrepareShutdown(r.Context()); err != nil {
+			level.Warn(logger).Log("msg", "failed to prepare ingester shutdown", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level.Info(logger).Log("msg", "preparing ingester shutdown")
+
+	case http.MethodDelete:
+		if err := i.abortPrepareShutdown(r.Context()); err != nil {
+			level.Warn(logger).Log("msg", "failed to abort ingester shutdown preparation", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level.Info(logger).Log("msg", "aborted ingester shutdown preparation")
+	}
+
+	util.WriteJSONResponse(w, i.prepareShutdownStatus(time.Now()))
+}
+
+func (i *Ingester) startPrepareShutdown(ctx context.Context) error {
+	if err := i.checkRunning(); err != nil {
+		return fmt.Errorf("ingester is not running: %w", err)
+	}
+
+	i.prepareShutdown.mtx.Lock()
+	defer i.prepareShutdown.mtx.Unlock()
+
+	if !i.prepareShutdown.startedAt.IsZero() && i.prepareShutdown.flushErr == nil {
+		return nil
+	}
+
+	// With shuffle sharding, queriers stop querying the ingester as soon as the window has elapsed, so a zero
+	// window would report the ingester as ready while it's still part of the tenant shard.
+	if i.cfg.DistributorShardingStrategy == util.ShardingStrategyShuffle {
+		for _, userID := range i.getTSDBUsers() {
+			if i.prepareShutdownWindow(userID) <= 0 {
+				return fmt.Errorf("tenant %s: query_ingesters_within or shuffle_sharding_ingesters_lookback_period must be set to prepare the shutdown when shuffle sharding is enabled", userID)
+			}
+		}
+	}
+
+	if i.lifecycler.GetState() != ring.READONLY {
+		if err := i.lifecycler.ChangeState(ctx, ring.READONLY); err != nil {
+			return fmt.Errorf("failed to change state: %w", err)
+		}
+	}
+
+	// A retried flush keeps the time the ingester switched to READONLY at.
+	flushCtx, cancel := context.WithCancel(i.ServiceContext())
+	if i.prepareShutdown.startedAt.IsZero() {
+		i.prepareShutdown.startedAt = time.Now()
+	}
+	i.prepareShutdown.flushed = false
+	i.prepareShutdown.flushErr = nil
+	i.prepareShutdown.cancel = cancel
+
+	go func() {
+		defer cancel()
+
+		// Tenants are flushed even if the workflow has been aborted meanwhile, as it's harmless.
+		err := i.flushTSDBBlocks(flushCtx, nil)
+		if err != nil {
+			level.Warn(i.logger).Log("msg", "failed to flush TSDB blocks while preparing ingester shutdown", "err", err)
+		}
+
+		i.prepareShutdown.mtx.Lock()
+		defer i.prepareShutdown.mtx.Unlock()
+		if flushCtx.Err() != nil {
+			return
+		}
+		if err != nil {
+			i.prepareShutdown.flushErr = err
+			return
+		}
+		i.prepareShutdown.flushed = true
+	}()
+	return nil
+}
+
+func (i *Ingester) abortPrepareShutdown(ctx context.Context) error {
+	i.prepareShutdown.mtx.Lock()
+	defer i.prepareShutdown.mtx.Unlock()
+
+	if i.prepareShutdown.startedAt.IsZero() {
+		return nil
+	}
+
+	if i.lifecycler.GetState() == ring.READONLY {
+		if err := i.lifecycler.ChangeState(ctx, ring.ACTIVE); err != nil {
+			return fmt.Errorf("failed to change state: %w", err)
+		}
+	}
+
+	i.prepareShutdown.cancel()
+	i.prepareShutdown.startedAt = time.Time{}
+	i.prepareShutdown.flushed = false
+	i.prepareShutdown.flushErr = nil
+	i.prepareShutdown.cancel = nil
+	return nil
+}
+
+func (i *Ingester) prepareShutdownStatus(now time.Time) PrepareShutdownStatus {
+	i.prepareShutdown.mtx.Lock()
+	startedAt, flushed, flushErr := i.prepareShutdown.startedAt, i.prepareShutdown.flushed, i.prepareShutdown.flushErr
+	i.prepareShutdown.mtx.Unlock()
+
+	res := PrepareShutdownStatus{
+		Status:  prepareShutdownNotStarted,
+		Tenants: []PrepareShutdownTenantStatus{},
+	}
+	if startedAt.IsZero() {
+		return res
+	}
+	res.StartedAt = &startedAt
+	if flushErr != nil {
+		res.Error = flushErr.Error()
+	}
+
+	res.Ready = flushed
+	for _, userID := range i.getTSDBUsers() {
+		userDB, err := i.getTSDB(userID)
+		if err != nil || userDB == nil {
+			continue
+		}
+
+		tenant := PrepareShutdownTenantStatus{
+			UserID:          userID,
+			HeadSeries:      userDB.Head().NumSeries(),
+			UnshippedBlocks: userDB.countUnshippedBlocks(),
+			ReadyAt:         startedAt.Add(i.prepareShutdownWindow(userID)),
+		}
+		tenant.Ready = flushed && tenant.HeadSeries == 0 && tenant.UnshippedBlocks == 0 && !now.Before(tenant.ReadyAt)
+
+		res.Ready = res.Ready && tenant.Ready
+		res.Tenants = append(res.Tenants, tenant)
+	}
+	sort.Slice(res.Tenants, func(i, j int) bool {
+		return res.Tenants[i].UserID < res.Tenants[j].UserID
+	})
+
+	switch {
+	case flushErr != nil:
+		res.Status = prepareShutdownFailed
+	case !flushed:
+		res.Status = prepareShutdownFlushing
+	case res.Ready:
+		res.Status = prepareShutdownReady
+	default:
+		res.Status = prepareShutdownWaiting
+	}
+	return res
+}
+
+// prepareShutdownWindow returns how long the data of the tenant may still be queried from the ingester after it
+// switched to READONLY. With shuffle sharding, queriers keep querying the ingesters which were part of the tenant
+// shard within the lookback period.
+func (i *Ingester) prepareShutdownWindow(userID string) time.Duration {
+	window := i.limits.QueryIngestersWithin(userID)
+	if i.cfg.DistributorShardingStrategy == util.ShardingStrategyShuffle {
+		window = max(window, i.limits.ShuffleShardingIngestersLookbackPeriod(userID))
+	}
+	return window
+}
+
+// countUnshippedBlocks returns the number of TSDB blocks not shipped to the storage yet. All the blocks
+// are considered unshipped when the shipping is disabled, as they would be lost with the ingester.
+func (u *userTSDB) countUnshippedBlocks() int {
+	blocks := u.Blocks()
+	if u.shipper == nil {
+		return len(blocks)
+	}
+
+	shippedBlocks := u.getCachedShippedBlocks()
+	count := 0
+	for _, b := range blocks {
+		if _, ok := shippedBlocks[b.Meta().ULID]; !ok {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,242 @@
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestIngester_PrepareShutdownHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.BlocksStorageConfig.TSDB.ShipConcurrency = 1
+	cfg.BlocksStorageConfig.TSDB.ShipInterval = 10 * time.Minute // Long enough to not be reached during the test.
+
+	limits := defaultLimitsTestConfig()
+	limits.QueryIngestersWithin = model.Duration(time.Hour)
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	pushSingleSampleWithMetadata(t, i)
+
+	call := func(method string) PrepareShutdownStatus {
+		response := httptest.NewRecorder()
+		i.PrepareShutdownHandler(response, httptest.NewRequest(method, "/ingester/prepare_shutdown", nil))
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var status PrepareShutdownStatus
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &status))
+		return status
+	}
+
+	// Not started yet.
+	status := call(http.MethodGet)
+	assert.Equal(t, prepareShutdownNotStarted, status.Status)
+	assert.False(t, status.Ready)
+	assert.Empty(t, status.Tenants)
+
+	// Start the preparation: the ingester switches to READONLY, and the blocks are flushed.
+	status = call(http.MethodPost)
+	assert.Equal(t, ring.READONLY, i.lifecycler.GetState())
+	require.NotNil(t, status.StartedAt)
+
+	test.Poll(t, 5*time.Second, prepareShutdownWaiting, func() any {
+		return call(http.MethodGet).Status
+	})
+	verifyCompactedHead(t, i, true)
+
+	// The data has been shipped, but it's still queried from the ingester.
+	status = call(http.MethodGet)
+	require.Len(t, status.Tenants, 1)
+	assert.Equal(t, PrepareShutdownTenantStatus{
+		UserID:          userID,
+		Ready:           false,
+		HeadSeries:      0,
+		UnshippedBlocks: 0,
+		ReadyAt:         status.StartedAt.Add(time.Hour),
+	}, status.Tenants[0])
+
+	// Once query_ingesters_within has elapsed, the ingester is ready to be removed.
+	status = i.prepareShutdownStatus(status.StartedAt.Add(time.Hour))
+	assert.Equal(t, prepareShutdownReady, status.Status)
+	assert.True(t, status.Ready)
+	assert.True(t, status.Tenants[0].Ready)
+
+	// Starting again doesn't restart the preparation.
+	assert.True(t, status.StartedAt.Equal(*call(http.MethodPost).StartedAt))
+
+	// Abort the preparation.
+	status = call(http.MethodDelete)
+	assert.Equal(t, prepareShutdownNotStarted, status.Status)
+	assert.Equal(t, ring.ACTIVE, i.lifecycler.GetState())
+}
+
+func TestIngester_PrepareShutdownHandler_ShouldReportFlushFailure(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	limits := defaultLimitsTestConfig()
+	limits.QueryIngestersWithin = model.Duration(time.Hour)
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	pushSingleSampleWithMetadata(t, i)
+
+	call := func(method string) PrepareShutdownStatus {
+		response := httptest.NewRecorder()
+		i.PrepareShutdownHandler(response, httptest.NewRequest(method, "/ingester/prepare_shutdown", nil))
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var status PrepareShutdownStatus
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &status))
+		return status
+	}
+
+	startedAt := *call(http.MethodPost).StartedAt
+	test.Poll(t, 5*time.Second, prepareShutdownWaiting, func() any {
+		return call(http.MethodGet).Status
+	})
+
+	// Simulate a failed flush.
+	i.prepareShutdown.mtx.Lock()
+	i.prepareShutdown.flushed = false
+	i.prepareShutdown.flushErr = errIngesterStopping
+	i.prepareShutdown.mtx.Unlock()
+
+	status := call(http.MethodGet)
+	assert.Equal(t, prepareShutdownFailed, status.Status)
+	assert.Equal(t, errIngesterStopping.Error(), status.Error)
+	assert.False(t, status.Ready)
+
+	// Starting again retries the flush, without resetting the time the ingester switched to READONLY at.
+	status = call(http.MethodPost)
+	assert.True(t, startedAt.Equal(*status.StartedAt))
+	assert.Empty(t, status.Error)
+	test.Poll(t, 5*time.Second, prepareShutdownWaiting, func() any {
+		return call(http.MethodGet).Status
+	})
+}
+
+func TestIngester_PrepareShutdownHandler_ShouldReportUnshippedBlocks(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+		return i.lifecycler.GetState()
+	})
+
+	// Blocks are not uploaded by the mocked shipper.
+	m := mockUserShipper(t, i)
+	m.On("Sync", mock.Anything).Return(0, nil)
+
+	pushSingleSampleWithMetadata(t, i)
+	require.NoError(t, i.startPrepareShutdown(context.Background()))
+
+	test.Poll(t, 5*time.Second, prepareShutdownWaiting, func() any {
+		return i.prepareShutdownStatus(time.Now()).Status
+	})
+
+	status := i.prepareShutdownStatus(time.Now().Add(time.Hour))
+	require.Len(t, status.Tenants, 1)
+	assert.Equal(t, 1, status.Tenants[0].UnshippedBlocks)
+	assert.False(t, status.Tenants[0].Ready)
+	assert.False(t, status.Ready)
+}
+
+func TestIngester_PrepareShutdownHandler_ShuffleSharding(t *testing.T) {
+	for name, tc := range map[string]struct {
+		queryIngestersWithin time.Duration
+		lookbackPeriod       time.Duration
+		expectedWindow       time.Duration
+		expectedErr          bool
+	}{
+		"lookback period longer than query ingesters within": {
+			queryIngestersWithin: time.Hour,
+			lookbackPeriod:       2 * time.Hour,
+			expectedWindow:       2 * time.Hour,
+		},
+		"query ingesters within longer than lookback period": {
+			queryIngestersWithin: 3 * time.Hour,
+			lookbackPeriod:       2 * time.Hour,
+			expectedWindow:       3 * time.Hour,
+		},
+		"zero window": {
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+			cfg.DistributorShardingStrategy = util.ShardingStrategyShuffle
+
+			limits := defaultLimitsTestConfig()
+			limits.QueryIngestersWithin = model.Duration(tc.queryIngestersWithin)
+			limits.ShuffleShardingIngestersLookbackPeriod = model.Duration(tc.lookbackPeriod)
+
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewPedanticRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			t.Cleanup(func() {
+				_ = services.StopAndAwaitTerminated(context.Background(), i)
+			})
+
+			test.Poll(t, 1*time.Second, ring.ACTIVE, func() any {
+				return i.lifecycler.GetState()
+			})
+
+			pushSingleSampleWithMetadata(t, i)
+
+			response := httptest.NewRecorder()
+			i.PrepareShutdownHandler(response, httptest.NewRequest(http.MethodPost, "/ingester/prepare_shutdown", nil))
+			if tc.expectedErr {
+				assert.Equal(t, http.StatusBadRequest, response.Code)
+				assert.Equal(t, ring.ACTIVE, i.lifecycler.GetState())
+				return
+			}
+			require.Equal(t, http.StatusOK, response.Code)
+
+			status := i.prepareShutdownStatus(time.Now())
+			require.Len(t, status.Tenants, 1)
+			assert.Equal(t, status.StartedAt.Add(tc.expectedWindow), status.Tenants[0].ReadyAt)
+		})
+	}
+}
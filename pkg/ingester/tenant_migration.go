@@ -0,0 +1,299 @@
+package ingester
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/thanos-io/thanos/pkg/shipper"
+
+	"github.com/cortexproject/cortex/pkg/chunk/encoding"
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	logutil "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+const (
+	// importBlockSize is the chunk range of the head used to build the imported block. The imported
+	// series are appended one after the other, so it must be large enough to accept samples of any
+	// age, and the whole imported time range ends up in a single block.
+	importBlockSize = math.MaxInt64 / 2
+
+	// importStateRetryInterval is how often the ingester retries to lock the TSDB shipping while
+	// adding an imported block.
+	importStateRetryInterval = 100 * time.Millisecond
+)
+
+// ExportTenant implements client.IngesterServer.
+func (i *Ingester) ExportTenant(req *client.ExportTenantRequest, stream client.Ingester_ExportTenantServer) (err error) {
+	defer recoverIngester(i.logger, &err)
+
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	userID, err := users.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	db, err := i.getTSDB(userID)
+	if err != nil || db == nil {
+		return nil
+	}
+
+	if err := db.acquireReadLock(); err != nil {
+		return nil
+	}
+	defer db.releaseReadLock()
+
+	q, err := db.ChunkQuerier(req.StartTimestampMs, req.EndTimestampMs)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	hints := &storage.SelectHints{
+		Start:           req.StartTimestampMs,
+		End:             req.EndTimestampMs,
+		DisableTrimming: true,
+	}
+	ss := q.Select(ctx, false, hints, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+"))
+
+	var (
+		batch          = make([]client.TimeSeriesChunk, 0, queryStreamBatchSize)
+		batchSizeBytes int
+		numSeries      int
+		it             chunks.Iterator
+	)
+
+	for ss.Next() {
+		series := ss.At()
+		ts := client.TimeSeriesChunk{
+			Labels: cortexpb.FromLabelsToLabelAdapters(series.Labels()),
+		}
+
+		it = series.Iterator(it)
+		for it.Next() {
+			ch, err := toClientChunk(it.At())
+			if err != nil {
+				return err
+			}
+			ts.Chunks = append(ts.Chunks, ch)
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		tsSize := ts.Size()
+		if (batchSizeBytes > 0 && batchSizeBytes+tsSize > queryStreamBatchMessageSize) || len(batch) >= queryStreamBatchSize {
+			if err := stream.Send(&client.ExportTenantResponse{Chunkseries: batch}); err != nil {
+				return err
+			}
+			batch = batch[:0]
+			batchSizeBytes = 0
+		}
+
+		batch = append(batch, ts)
+		batchSizeBytes += tsSize
+		numSeries++
+	}
+	if err := ss.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		if err := stream.Send(&client.ExportTenantResponse{Chunkseries: batch}); err != nil {
+			return err
+		}
+	}
+
+	level.Info(i.logger).Log("msg", "exported tenant series", "user", userID, "series", numSeries, "mint", req.StartTimestampMs, "maxt", req.EndTimestampMs)
+	return nil
+}
+
+// ImportTenant implements client.IngesterServer.
+func (i *Ingester) ImportTenant(stream client.Ingester_ImportTenantServer) (err error) {
+	defer recoverIngester(i.logger, &err)
+
+	if err := i.checkRunning(); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	userID, err := users.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	db, err := i.getOrCreateTSDB(userID, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to create TSDB")
+	}
+
+	// Prevent the TSDB from being closed while the block is imported.
+	if err := db.acquireReadLock(); err != nil {
+		return err
+	}
+	defer db.releaseReadLock()
+
+	// The block is built in a temporary dir within the TSDB dir, so that it can be atomically moved
+	// into the TSDB dir once complete. The TSDB deletes the leftovers of failed imports when opened.
+	stagingDir, err := os.MkdirTemp(db.db.Dir(), "import-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir) //nolint:errcheck
+
+	w, err := tsdb.NewBlockWriter(logutil.GoKitLogToSlog(i.logger), stagingDir, importBlockSize)
+	if err != nil {
+		return err
+	}
+	defer w.Close() //nolint:errcheck
+
+	var numSeries, numSamples int64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Commit each batch, so that the appender doesn't buffer all the imported samples.
+		app := w.Appender(ctx)
+		for _, series := range req.Chunkseries {
+			n, err := appendChunkSeries(app, series)
+			if err != nil {
+				_ = app.Rollback()
+				return errors.Wrapf(err, "import series %s", cortexpb.FromLabelAdaptersToLabels(series.Labels).String())
+			}
+			numSeries++
+			numSamples += n
+		}
+		if err := app.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if numSamples > 0 {
+		blockID, err := w.Flush(ctx)
+		if err != nil {
+			return errors.Wrap(err, "flush imported block")
+		}
+		if err := i.addImportedBlock(ctx, db, stagingDir, blockID); err != nil {
+			return errors.Wrap(err, "add imported block")
+		}
+
+		level.Info(i.logger).Log("msg", "imported tenant series", "user", userID, "block", blockID, "series", numSeries, "samples", numSamples)
+	}
+
+	return stream.SendAndClose(&client.ImportTenantResponse{Series: numSeries, Samples: numSamples})
+}
+
+// appendChunkSeries appends the samples of the series chunks, and returns the number of appended samples.
+// Chunks may overlap, so samples not after the last appended one are skipped.
+func appendChunkSeries(app storage.Appender, series client.TimeSeriesChunk) (int64, error) {
+	// The labels are retained by the appender, while the request buffer is reused.
+	lbls := cortexpb.FromLabelAdaptersToLabelsWithCopy(series.Labels)
+
+	var (
+		ref      storage.SeriesRef
+		appended int64
+		lastT    int64 = math.MinInt64
+		it       chunkenc.Iterator
+	)
+
+	for _, c := range series.Chunks {
+		enc := encoding.Encoding(byte(c.Encoding)).PromChunkEncoding()
+		if enc == chunkenc.EncNone {
+			return appended, errors.Errorf("unknown chunk encoding: %v", c.Encoding)
+		}
+		chk, err := chunkenc.FromData(enc, c.Data)
+		if err != nil {
+			return appended, err
+		}
+
+		it = chk.Iterator(it)
+		for vt := it.Next(); vt != chunkenc.ValNone; vt = it.Next() {
+			t := it.AtT()
+			if t <= lastT {
+				continue
+			}
+
+			switch vt {
+			case chunkenc.ValFloat:
+				_, v := it.At()
+				ref, err = app.Append(ref, lbls, t, v)
+			case chunkenc.ValHistogram:
+				_, h := it.AtHistogram(nil)
+				ref, err = app.AppendHistogram(ref, lbls, t, h, nil)
+			case chunkenc.ValFloatHistogram:
+				_, fh := it.AtFloatHistogram(nil)
+				ref, err = app.AppendHistogram(ref, lbls, t, nil, fh)
+			default:
+				err = errors.Errorf("unknown sample type: %v", vt)
+			}
+			if err != nil {
+				return appended, err
+			}
+
+			lastT = t
+			appended++
+		}
+		if err := it.Err(); err != nil {
+			return appended, err
+		}
+	}
+
+	return appended, nil
+}
+
+// addImportedBlock moves the imported block from the staging dir into the TSDB dir. The block is marked
+// as shipped beforehand, because its data has already been shipped by the previous owners of the series:
+// this way it's not uploaded again, and it's deleted once the blocks retention period expires. The TSDB
+// loads the block at its next periodic blocks reload.
+func (i *Ingester) addImportedBlock(ctx context.Context, db *userTSDB, stagingDir string, blockID ulid.ULID) error {
+	if db.shipper != nil {
+		// Prevent the shipper from concurrently rewriting its meta file.
+		for !db.casState(active, activeShipping) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(importStateRetryInterval):
+			}
+		}
+		defer db.casState(activeShipping, active)
+
+		meta, err := shipper.ReadMetaFile(db.shipperMetadataFilePath)
+		if os.IsNotExist(err) || os.IsNotExist(errors.Cause(err)) {
+			meta = &shipper.Meta{Version: shipper.MetaVersion1}
+		} else if err != nil {
+			return err
+		}
+
+		meta.Uploaded = append(meta.Uploaded, blockID)
+		if err := shipper.WriteMetaFile(i.logger, db.shipperMetadataFilePath, meta); err != nil {
+			return err
+		}
+		if err := db.updateCachedShippedBlocks(); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(filepath.Join(stagingDir, blockID.String()), filepath.Join(db.db.Dir(), blockID.String()))
+}
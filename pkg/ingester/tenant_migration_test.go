@@ -0,0 +1,141 @@
+package ingester
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/shipper"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+func TestIngester_ExportAndImportTenant(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.ShipInterval = time.Hour // Long enough to not be reached during the test.
+
+	source, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), source))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), source)
+	})
+
+	target, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), target))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), target)
+	})
+
+	now := time.Now().UnixMilli()
+	for _, ts := range []int64{now - 2000, now - 1000, now} {
+		req, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test_float", "series", "1"), 1, ts)
+		_, err := source.Push(ctx, req)
+		require.NoError(t, err)
+
+		req, _ = mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test_float", "series", "2"), 2, ts)
+		_, err = source.Push(ctx, req)
+		require.NoError(t, err)
+
+		req, _ = mockHistogramWriteRequest(t, labels.FromStrings(labels.MetricName, "test_histogram"), 3, ts, false)
+		_, err = source.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	exportStream := &mockExportTenantServer{ctx: ctx}
+	require.NoError(t, source.ExportTenant(&client.ExportTenantRequest{StartTimestampMs: now - 1500, EndTimestampMs: now}, exportStream))
+	require.Len(t, exportStream.series, 3)
+
+	importStream := &mockImportTenantServer{ctx: ctx, batches: [][]client.TimeSeriesChunk{exportStream.series[:1], exportStream.series[1:]}}
+	require.NoError(t, target.ImportTenant(importStream))
+	require.NotNil(t, importStream.resp)
+
+	// The exported chunks are not trimmed, so all the samples are imported.
+	assert.Equal(t, int64(3), importStream.resp.Series)
+	assert.Equal(t, int64(9), importStream.resp.Samples)
+
+	// The imported block is in the TSDB dir, and marked as shipped.
+	db, err := target.getTSDB(userID)
+	require.NoError(t, err)
+	require.NotNil(t, db)
+
+	meta, err := shipper.ReadMetaFile(db.shipperMetadataFilePath)
+	require.NoError(t, err)
+	require.Len(t, meta.Uploaded, 1)
+
+	blockMeta, err := metadata.ReadFromDir(filepath.Join(db.db.Dir(), meta.Uploaded[0].String()))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), blockMeta.Stats.NumSeries)
+	assert.Equal(t, uint64(9), blockMeta.Stats.NumSamples)
+	assert.Equal(t, now-2000, blockMeta.MinTime)
+}
+
+func TestIngester_ExportTenant_ShouldReturnNothingForUnknownTenant(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), i)
+	})
+
+	stream := &mockExportTenantServer{ctx: user.InjectOrgID(context.Background(), "unknown")}
+	require.NoError(t, i.ExportTenant(&client.ExportTenantRequest{StartTimestampMs: 0, EndTimestampMs: time.Now().UnixMilli()}, stream))
+	assert.Empty(t, stream.series)
+}
+
+type mockExportTenantServer struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	series []client.TimeSeriesChunk
+}
+
+func (m *mockExportTenantServer) Send(response *client.ExportTenantResponse) error {
+	m.series = append(m.series, response.Chunkseries...)
+	return nil
+}
+
+func (m *mockExportTenantServer) Context() context.Context {
+	return m.ctx
+}
+
+type mockImportTenantServer struct {
+	grpc.ServerStream
+	ctx context.Context
+
+	batches [][]client.TimeSeriesChunk
+	resp    *client.ImportTenantResponse
+}
+
+func (m *mockImportTenantServer) Recv() (*client.ImportTenantRequest, error) {
+	if len(m.batches) == 0 {
+		return nil, io.EOF
+	}
+	req := &client.ImportTenantRequest{Chunkseries: m.batches[0]}
+	m.batches = m.batches[1:]
+	return req, nil
+}
+
+func (m *mockImportTenantServer) SendAndClose(resp *client.ImportTenantResponse) error {
+	m.resp = resp
+	return nil
+}
+
+func (m *mockImportTenantServer) Context() context.Context {
+	return m.ctx
+}
@@ -0,0 +1,33 @@
+package ingester
+
+import (
+	"net/http"
+
+	"github.com/go-kit/log/level"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	logutil "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// TokenRebalanceHandler controls the rebalancing of the ingester tokens:
+//   - POST starts taking over tokens from the ingesters owning the largest token ranges, until the token range
+//     owned by this ingester is close to the expected one.
+//   - GET reports the status of the token rebalance.
+//   - DELETE stops the token rebalance. The tokens already taken over are kept.
+func (i *Ingester) TokenRebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	logger := logutil.WithContext(r.Context(), i.logger)
+
+	switch r.Method {
+	case http.MethodPost:
+		if err := i.lifecycler.StartTokenRebalance(); err != nil {
+			level.Warn(logger).Log("msg", "failed to start token rebalance", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	case http.MethodDelete:
+		i.lifecycler.StopTokenRebalance()
+	}
+
+	util.WriteJSONResponse(w, i.lifecycler.TokenRebalanceStatus())
+}
@@ -18,6 +18,7 @@ import (
 const (
 	perUserSeriesLimit                = "per_user_series_limit"
 	perUserNativeHistogramSeriesLimit = "per_user_native_histogram_series_limit"
+	perUserSeriesCreationRateLimit    = "per_user_series_creation_rate_limit"
 	perMetricSeriesLimit              = "per_metric_series_limit"
 	perLabelsetSeriesLimit            = "per_labelset_series_limit"
 )
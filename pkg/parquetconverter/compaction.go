@@ -0,0 +1,416 @@
+package parquetconverter
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/parquet-common/convert"
+	"github.com/prometheus-community/parquet-common/schema"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/ring"
+	cortex_parquet "github.com/cortexproject/cortex/pkg/storage/parquet"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// compactionSource is a block whose parquet files can be compacted.
+type compactionSource struct {
+	id      ulid.ULID
+	minTime int64
+	maxTime int64
+	shards  int
+}
+
+// compactionPlan is a set of blocks whose parquet files are compacted into a single parquet-only block.
+type compactionPlan struct {
+	minTime int64
+	maxTime int64
+	sources []compactionSource
+}
+
+func (p compactionPlan) key() string {
+	return fmt.Sprintf("parquet-compaction-%d-%d", p.minTime, p.maxTime)
+}
+
+// planParquetCompaction groups the sources by the first of the block ranges with sources to compact. A group
+// is compacted once the range is complete, i.e. there are blocks after it, if it has at least two sources which
+// don't overlap each other. Sources not shorter than the range or not aligned to it are left untouched.
+func planParquetCompaction(sources []compactionSource, blockRanges []int64) []compactionPlan {
+	var maxTime int64
+	for _, s := range sources {
+		maxTime = max(maxTime, s.maxTime)
+	}
+
+	for _, r := range blockRanges {
+		groups := map[int64][]compactionSource{}
+		for _, s := range sources {
+			start := s.minTime - s.minTime%r
+			if s.maxTime-s.minTime >= r || s.maxTime > start+r {
+				continue
+			}
+			groups[start] = append(groups[start], s)
+		}
+
+		var plans []compactionPlan
+		for start, group := range groups {
+			if len(group) < 2 || start+r > maxTime {
+				continue
+			}
+			sort.Slice(group, func(i, j int) bool {
+				return group[i].minTime < group[j].minTime
+			})
+			overlapping := false
+			for i := 1; i < len(group); i++ {
+				if group[i].minTime < group[i-1].maxTime {
+					overlapping = true
+					break
+				}
+			}
+			if overlapping {
+				continue
+			}
+			plans = append(plans, compactionPlan{
+				minTime: group[0].minTime,
+				maxTime: group[len(group)-1].maxTime,
+				sources: group,
+			})
+		}
+
+		if len(plans) > 0 {
+			sort.Slice(plans, func(i, j int) bool {
+				return plans[i].minTime < plans[j].minTime
+			})
+			return plans
+		}
+	}
+	return nil
+}
+
+// compactUser compacts the parquet files of the converted blocks of the user into parquet-only blocks.
+func (c *Converter) compactUser(ctx context.Context, logger log.Logger, ring ring.ReadRing, userID string, uBucket objstore.InstrumentedBucket, metas []*metadata.Meta) error {
+	sources, compacted, err := c.listCompactionSources(ctx, logger, userID, uBucket, metas)
+	if err != nil {
+		return errors.Wrap(err, "list parquet compaction sources")
+	}
+
+	// Blocks already compacted into a parquet-only block are left behind if the converter
+	// failed to mark them for deletion, so we do it now.
+	deleteSources := c.limits.ParquetConverterCompactionDeleteSourceBlocks(userID)
+	if deleteSources {
+		for _, id := range compacted {
+			if err := block.MarkForDeletion(ctx, logger, uBucket, id, "source of parquet compaction", c.metrics.compactionBlocksReplaced); err != nil {
+				level.Error(logger).Log("msg", "failed to mark compacted block for deletion", "block", id.String(), "err", err)
+			}
+		}
+	}
+
+	for _, plan := range planParquetCompaction(sources, c.cfg.CompactionBlockRanges.ToMilliseconds()) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ok, err := c.ownBlock(ring, plan.key())
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to check if parquet compaction is owned", "min_time", plan.minTime, "max_time", plan.maxTime, "err", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if err := c.compactParquetBlocks(ctx, logger, userID, uBucket, plan, deleteSources); err != nil {
+			level.Error(logger).Log("msg", "failed to compact parquet blocks", "min_time", plan.minTime, "max_time", plan.maxTime, "err", err)
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || c.isCausedByPermissionDenied(err) {
+				return err
+			}
+			c.metrics.compactionFailures.WithLabelValues(userID).Inc()
+		}
+	}
+	return nil
+}
+
+// listCompactionSources returns the converted blocks of the largest compactor block range, and the
+// parquet-only blocks not marked for deletion. Blocks which are sources of a parquet-only block are
+// returned separately, and are never compacted again. The parquet-only blocks are looked up in the
+// bucket index if enabled and already written, otherwise the whole tenant bucket is listed.
+func (c *Converter) listCompactionSources(ctx context.Context, logger log.Logger, userID string, uBucket objstore.InstrumentedBucket, metas []*metadata.Meta) ([]compactionSource, []ulid.ULID, error) {
+	var idx *bucketindex.Index
+	if c.storageCfg.BucketStore.BucketIndex.Enabled {
+		var err error
+		idx, err = bucketindex.ReadIndex(ctx, c.bkt, userID, c.limits, logger)
+		if err != nil && !errors.Is(err, bucketindex.ErrIndexNotFound) {
+			return nil, nil, errors.Wrap(err, "read bucket index")
+		}
+	}
+
+	var largestRange int64
+	if len(c.blockRanges) > 0 {
+		largestRange = c.blockRanges[len(c.blockRanges)-1]
+	}
+
+	indexed := map[ulid.ULID]*bucketindex.Block{}
+	if idx != nil {
+		for _, b := range idx.Blocks {
+			indexed[b.ID] = b
+		}
+	}
+
+	var candidates []compactionSource
+	for _, m := range metas {
+		if m.MaxTime-m.MinTime < largestRange {
+			continue
+		}
+		var mark *cortex_parquet.ConverterMarkMeta
+		if b, ok := indexed[m.ULID]; ok {
+			mark = b.Parquet
+		}
+		shards, err := parquetShards(ctx, logger, uBucket, m.ULID, mark)
+		if err != nil {
+			return nil, nil, err
+		}
+		if shards > 0 {
+			candidates = append(candidates, compactionSource{id: m.ULID, minTime: m.MinTime, maxTime: m.MaxTime, shards: shards})
+		}
+	}
+
+	var (
+		parquetOnly []compactionSource
+		compacted   map[ulid.ULID]struct{}
+		err         error
+	)
+	if idx != nil {
+		parquetOnly, compacted, err = c.listParquetOnlyBlocksFromIndex(ctx, logger, userID, uBucket, idx)
+	} else {
+		parquetOnly, compacted, err = c.listParquetOnlyBlocks(ctx, logger, userID, uBucket, metas)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates = append(candidates, parquetOnly...)
+
+	var (
+		sources  []compactionSource
+		replaced []ulid.ULID
+	)
+	for _, s := range candidates {
+		if _, ok := compacted[s.id]; ok {
+			replaced = append(replaced, s.id)
+			continue
+		}
+		sources = append(sources, s)
+	}
+	return sources, replaced, nil
+}
+
+// listParquetOnlyBlocksFromIndex returns the parquet-only blocks of the bucket index not marked for deletion,
+// and the sources of all the parquet-only blocks, including the ones compacted by this converter which are
+// not in the bucket index yet.
+func (c *Converter) listParquetOnlyBlocksFromIndex(ctx context.Context, logger log.Logger, userID string, uBucket objstore.InstrumentedBucket, idx *bucketindex.Index) ([]compactionSource, map[ulid.ULID]struct{}, error) {
+	deleted := map[ulid.ULID]struct{}{}
+	for _, id := range idx.BlockDeletionMarks.GetULIDs() {
+		deleted[id] = struct{}{}
+	}
+
+	var (
+		sources   []compactionSource
+		compacted = map[ulid.ULID]struct{}{}
+		recent    = c.compactedBlocks[userID]
+	)
+	for _, b := range idx.ParquetOnlyBlocks() {
+		delete(recent, b.ID)
+		for _, src := range b.ParquetSources {
+			compacted[src] = struct{}{}
+		}
+		if _, ok := deleted[b.ID]; ok {
+			continue
+		}
+
+		shards, err := parquetShards(ctx, logger, uBucket, b.ID, b.Parquet)
+		if err != nil {
+			return nil, nil, err
+		}
+		if shards > 0 {
+			sources = append(sources, compactionSource{id: b.ID, minTime: b.MinTime, maxTime: b.MaxTime, shards: shards})
+		}
+	}
+
+	// The parquet-only blocks compacted since the bucket index has been updated are not compacted
+	// again until they're in the bucket index, but their sources must not be compacted twice.
+	for _, srcs := range recent {
+		for _, src := range srcs {
+			compacted[src] = struct{}{}
+		}
+	}
+	return sources, compacted, nil
+}
+
+// listParquetOnlyBlocks lists the tenant bucket to find the parquet-only blocks not marked for deletion,
+// and the sources of all the parquet-only blocks.
+func (c *Converter) listParquetOnlyBlocks(ctx context.Context, logger log.Logger, userID string, uBucket objstore.InstrumentedBucket, metas []*metadata.Meta) ([]compactionSource, map[ulid.ULID]struct{}, error) {
+	// The listing finds the blocks compacted by this converter too.
+	delete(c.compactedBlocks, userID)
+
+	known := make(map[ulid.ULID]struct{}, len(metas))
+	for _, m := range metas {
+		known[m.ULID] = struct{}{}
+	}
+
+	// Parquet-only blocks have no meta.json, so they're not returned by the metadata fetcher.
+	var unknown []ulid.ULID
+	if err := uBucket.Iter(ctx, "", func(name string) error {
+		if id, ok := block.IsBlockDir(name); ok {
+			if _, ok := known[id]; !ok {
+				unknown = append(unknown, id)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		sources   []compactionSource
+		compacted = map[ulid.ULID]struct{}{}
+	)
+	for _, id := range unknown {
+		m, err := cortex_parquet.ReadCompactionMeta(ctx, id, uBucket, logger)
+		if errors.Is(err, cortex_parquet.ErrCompactionMetaNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, src := range m.Sources {
+			compacted[src] = struct{}{}
+		}
+
+		err = metadata.ReadMarker(ctx, logger, uBucket, id.String(), &metadata.DeletionMark{})
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, metadata.ErrorMarkerNotFound) {
+			return nil, nil, err
+		}
+
+		shards, err := parquetShards(ctx, logger, uBucket, id, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if shards > 0 {
+			sources = append(sources, compactionSource{id: id, minTime: m.MinTime, maxTime: m.MaxTime, shards: shards})
+		}
+	}
+	return sources, compacted, nil
+}
+
+// parquetShards returns the number of parquet shards of a block, or 0 if the block has no valid converter
+// mark. The converter mark is read from the bucket if the one of the bucket index is not provided.
+func parquetShards(ctx context.Context, logger log.Logger, uBucket objstore.InstrumentedBucket, id ulid.ULID, mark *cortex_parquet.ConverterMarkMeta) (int, error) {
+	if mark == nil {
+		m, err := cortex_parquet.ReadConverterMark(ctx, id, uBucket, logger)
+		if err != nil {
+			return 0, err
+		}
+		mark = &cortex_parquet.ConverterMarkMeta{Version: m.Version, Shards: m.Shards}
+	}
+	if !cortex_parquet.ValidConverterMarkVersion(mark.Version) {
+		return 0, nil
+	}
+	return max(mark.Shards, 1), nil
+}
+
+// compactParquetBlocks merges the parquet files of the planned blocks into a new parquet-only block and,
+// if deleteSources is set, marks the planned blocks for deletion.
+func (c *Converter) compactParquetBlocks(ctx context.Context, logger log.Logger, userID string, uBucket objstore.InstrumentedBucket, plan compactionPlan, deleteSources bool) error {
+	if err := os.RemoveAll(c.compactRootDir()); err != nil {
+		return errors.Wrap(err, "remove work directory")
+	}
+	dir := c.compactDirForUser(userID)
+
+	var blocks []*parquetBlock
+	defer func() {
+		for _, b := range blocks {
+			_ = b.Close()
+		}
+	}()
+
+	convertibles := make([]convert.Convertible, 0, len(plan.sources))
+	sourceIDs := make([]ulid.ULID, 0, len(plan.sources))
+	for _, s := range plan.sources {
+		sourceIDs = append(sourceIDs, s.id)
+		if err := os.MkdirAll(filepath.Join(dir, s.id.String()), os.ModePerm); err != nil {
+			return errors.Wrap(err, "create work directory")
+		}
+		for shard := 0; shard < s.shards; shard++ {
+			for _, name := range []string{schema.LabelsPfileNameForShard(s.id.String(), shard), schema.ChunksPfileNameForShard(s.id.String(), shard)} {
+				if err := objstore.DownloadFile(ctx, logger, uBucket, name, filepath.Join(dir, name)); err != nil {
+					return errors.Wrapf(err, "download parquet file %s", name)
+				}
+			}
+
+			b, err := openParquetBlock(dir, tsdb.BlockMeta{ULID: s.id, MinTime: s.minTime, MaxTime: s.maxTime}, shard, c.pool)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, b)
+			convertibles = append(convertibles, b)
+		}
+	}
+
+	id := ulid.MustNew(ulid.Now(), rand.Reader)
+	level.Info(logger).Log("msg", "compacting parquet blocks", "block", id.String(), "sources", fmt.Sprintf("%v", sourceIDs))
+	start := time.Now()
+
+	converterOpts := append(c.baseConverterOptions, convert.WithName(id.String()), convert.WithSortBy(c.sortColumns(userID)...))
+	if c.cfg.FileBufferEnabled {
+		converterOpts = append(converterOpts, convert.WithColumnPageBuffers(parquet.NewFileBufferPool(dir, "buffers.*")))
+	}
+
+	numShards, err := convert.ConvertTSDBBlock(ctx, uBucket, plan.minTime, plan.maxTime, convertibles, util_log.GoKitLogToSlog(logger), converterOpts...)
+	if err != nil {
+		return errors.Wrap(err, "convert parquet blocks")
+	}
+
+	// The compaction meta is uploaded last, because it makes the block visible in the bucket index.
+	if err := cortex_parquet.WriteConverterMark(ctx, id, uBucket, numShards); err != nil {
+		return errors.Wrap(err, "write parquet converter marker")
+	}
+	if err := cortex_parquet.WriteCompactionMeta(ctx, cortex_parquet.CompactionMeta{
+		ULID:    id,
+		MinTime: plan.minTime,
+		MaxTime: plan.maxTime,
+		Sources: sourceIDs,
+	}, uBucket); err != nil {
+		return errors.Wrap(err, "write parquet compaction meta")
+	}
+	c.metrics.compactions.WithLabelValues(userID).Inc()
+	if c.compactedBlocks[userID] == nil {
+		c.compactedBlocks[userID] = map[ulid.ULID][]ulid.ULID{}
+	}
+	c.compactedBlocks[userID][id] = sourceIDs
+	level.Info(logger).Log("msg", "successfully compacted parquet blocks", "block", id.String(), "duration", time.Since(start), "shards", numShards)
+
+	if !deleteSources {
+		return nil
+	}
+	for _, s := range plan.sources {
+		if err := block.MarkForDeletion(ctx, logger, uBucket, s.id, "source of parquet compaction", c.metrics.compactionBlocksReplaced); err != nil {
+			return errors.Wrapf(err, "mark block %s for deletion", s.id.String())
+		}
+	}
+	return nil
+}
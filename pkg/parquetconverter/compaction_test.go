@@ -0,0 +1,377 @@
+package parquetconverter
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/objstore/providers/filesystem"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/integration/e2e"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/parquet"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestPlanParquetCompaction(t *testing.T) {
+	const day = int64(24 * time.Hour / time.Millisecond)
+
+	source := func(minDay, maxDay int64) compactionSource {
+		return compactionSource{id: ulid.MustNew(uint64(minDay*1000+maxDay), nil), minTime: minDay * day, maxTime: maxDay * day, shards: 1}
+	}
+
+	tests := map[string]struct {
+		sources     []compactionSource
+		blockRanges []int64
+		expected    [][]compactionSource
+	}{
+		"no sources": {
+			blockRanges: []int64{7 * day},
+		},
+		"should not compact an incomplete range": {
+			sources:     []compactionSource{source(0, 1), source(1, 2), source(2, 3)},
+			blockRanges: []int64{7 * day},
+		},
+		"should compact a complete range": {
+			sources:     []compactionSource{source(0, 1), source(1, 2), source(6, 7), source(7, 8)},
+			blockRanges: []int64{7 * day},
+			expected:    [][]compactionSource{{source(0, 1), source(1, 2), source(6, 7)}},
+		},
+		"should not compact a range with a single source": {
+			sources:     []compactionSource{source(0, 1), source(7, 8), source(8, 9), source(14, 15)},
+			blockRanges: []int64{7 * day},
+			expected:    [][]compactionSource{{source(7, 8), source(8, 9)}},
+		},
+		"should not compact a range with overlapping sources": {
+			sources:     []compactionSource{source(0, 1), source(0, 2), source(7, 8)},
+			blockRanges: []int64{7 * day},
+		},
+		"should not compact sources crossing the range boundary": {
+			sources:     []compactionSource{source(5, 6), source(6, 8), source(8, 9), source(9, 10), source(14, 15)},
+			blockRanges: []int64{7 * day},
+			expected:    [][]compactionSource{{source(8, 9), source(9, 10)}},
+		},
+		"should compact the smallest range first": {
+			sources:     []compactionSource{source(0, 1), source(1, 2), source(7, 14), source(14, 21), source(28, 29)},
+			blockRanges: []int64{7 * day, 28 * day},
+			expected:    [][]compactionSource{{source(0, 1), source(1, 2)}},
+		},
+		"should compact the larger range once the smaller ranges are compacted": {
+			sources:     []compactionSource{source(0, 7), source(7, 14), source(14, 21), source(28, 29)},
+			blockRanges: []int64{7 * day, 28 * day},
+			expected:    [][]compactionSource{{source(0, 7), source(7, 14), source(14, 21)}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			plans := planParquetCompaction(tc.sources, tc.blockRanges)
+			require.Len(t, plans, len(tc.expected))
+			for i, plan := range plans {
+				assert.Equal(t, tc.expected[i], plan.sources)
+				assert.Equal(t, tc.expected[i][0].minTime, plan.minTime)
+				assert.Equal(t, tc.expected[i][len(tc.expected[i])-1].maxTime, plan.maxTime)
+			}
+		})
+	}
+}
+
+func TestConverter_ShouldCompactParquetBlocks(t *testing.T) {
+	for _, deleteSources := range []bool{false, true} {
+		t.Run(fmt.Sprintf("delete source blocks=%t", deleteSources), func(t *testing.T) {
+			cfg := prepareConfig()
+			cfg.CompactionBlockRanges = cortex_tsdb.DurationList{48 * time.Hour}
+			user := "user"
+			ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+			dir := t.TempDir()
+
+			cfg.Ring.InstanceID = "parquet-converter-1"
+			cfg.Ring.InstanceAddr = "1.2.3.4"
+			cfg.Ring.KVStore.Mock = ringStore
+			bucketClient, err := filesystem.NewBucket(t.TempDir())
+			require.NoError(t, err)
+			userBucket := objstore.WithNoopInstr(bucket.NewPrefixedBucketClient(bucketClient, user))
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.ParquetConverterEnabled = true
+			limits.ParquetConverterCompactionEnabled = true
+			limits.ParquetConverterCompactionDeleteSourceBlocks = deleteSources
+
+			c, logger, _ := prepare(t, cfg, objstore.WithNoopInstr(bucketClient), limits, nil)
+
+			ctx := context.Background()
+			series := []labels.Labels{
+				labels.FromStrings("__name__", "test", "job", "a"),
+				labels.FromStrings("__name__", "test", "job", "b"),
+				labels.FromStrings("__name__", "other", "job", "a", "instance", "1"),
+			}
+			rnd := rand.New(rand.NewSource(time.Now().Unix()))
+
+			// Create three 24h blocks. Only the first two ones are compacted, because the 48h range
+			// of the third one is not complete yet.
+			day := 24 * time.Hour.Milliseconds()
+			var blockIDs []ulid.ULID
+			for i := int64(0); i < 3; i++ {
+				id, err := e2e.CreateBlock(ctx, rnd, dir, series, 120, i*day, (i+1)*day, time.Minute.Milliseconds(), 10)
+				require.NoError(t, err)
+				require.NoError(t, block.Upload(ctx, logger, userBucket, path.Join(dir, id.String()), metadata.NoneFunc))
+				blockIDs = append(blockIDs, id)
+			}
+
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+			defer services.StopAndAwaitTerminated(ctx, c) // nolint:errcheck
+
+			var compactedID ulid.ULID
+			test.Poll(t, 3*time.Minute, true, func() any {
+				found := false
+				require.NoError(t, userBucket.Iter(ctx, "", func(name string) error {
+					id, ok := block.IsBlockDir(name)
+					if !ok {
+						return nil
+					}
+					if _, err := parquet.ReadCompactionMeta(ctx, id, userBucket, logger); err == nil {
+						compactedID, found = id, true
+					}
+					return nil
+				}))
+				return found
+			})
+
+			meta, err := parquet.ReadCompactionMeta(ctx, compactedID, userBucket, logger)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), meta.MinTime)
+			assert.Equal(t, 2*day, meta.MaxTime)
+			assert.ElementsMatch(t, blockIDs[:2], meta.Sources)
+
+			mark, err := parquet.ReadConverterMark(ctx, compactedID, userBucket, logger)
+			require.NoError(t, err)
+			assert.Equal(t, parquet.CurrentVersion, mark.Version)
+
+			// The parquet-only block has no TSDB files.
+			ok, err := userBucket.Exists(ctx, path.Join(compactedID.String(), metadata.MetaFilename))
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// The sources are marked for deletion only if enabled, while the incomplete range is left untouched.
+			if deleteSources {
+				test.Poll(t, time.Minute, true, func() any {
+					for _, id := range blockIDs[:2] {
+						if ok, err := userBucket.Exists(ctx, path.Join(id.String(), metadata.DeletionMarkFilename)); err != nil || !ok {
+							return false
+						}
+					}
+					return true
+				})
+			} else {
+				for _, id := range blockIDs[:2] {
+					ok, err := userBucket.Exists(ctx, path.Join(id.String(), metadata.DeletionMarkFilename))
+					require.NoError(t, err)
+					assert.False(t, ok)
+				}
+			}
+			ok, err = userBucket.Exists(ctx, path.Join(blockIDs[2].String(), metadata.DeletionMarkFilename))
+			require.NoError(t, err)
+			assert.False(t, ok)
+			assert.Equal(t, 1.0, testutil.ToFloat64(c.metrics.compactions.WithLabelValues(user)))
+			assert.Equal(t, 0.0, testutil.ToFloat64(c.metrics.compactionFailures.WithLabelValues(user)))
+
+			// The parquet-only block contains the samples of both sources.
+			expected := map[string][]testSample{}
+			for _, id := range blockIDs[:2] {
+				b, err := tsdb.OpenBlock(nil, filepath.Join(dir, id.String()), nil, nil)
+				require.NoError(t, err)
+				for lbls, samples := range readSamples(t, b) {
+					expected[lbls] = append(expected[lbls], samples...)
+				}
+				require.NoError(t, b.Close())
+			}
+
+			require.Len(t, expected, len(series))
+
+			downloadDir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(downloadDir, compactedID.String()), os.ModePerm))
+			for _, name := range []string{compactedID.String() + "/0.labels.parquet", compactedID.String() + "/0.chunks.parquet"} {
+				require.NoError(t, objstore.DownloadFile(ctx, logger, userBucket, name, filepath.Join(downloadDir, name)))
+			}
+			pb, err := openParquetBlock(downloadDir, tsdb.BlockMeta{ULID: compactedID, MinTime: meta.MinTime, MaxTime: meta.MaxTime}, 0, chunkenc.NewPool())
+			require.NoError(t, err)
+			defer pb.Close()
+			assert.Equal(t, expected, readSamples(t, pb))
+		})
+	}
+}
+
+func TestConverter_ListCompactionSourcesFromBucketIndex(t *testing.T) {
+	ctx := context.Background()
+	user := "user"
+	bucketClient, err := filesystem.NewBucket(t.TempDir())
+	require.NoError(t, err)
+	userBucket := objstore.WithNoopInstr(bucket.NewPrefixedBucketClient(bucketClient, user))
+
+	cfg := prepareConfig()
+	cfg.CompactionBlockRanges = cortex_tsdb.DurationList{48 * time.Hour}
+	c, logger, _ := prepare(t, cfg, objstore.WithNoopInstr(bucketClient), nil, nil)
+
+	day := 24 * time.Hour.Milliseconds()
+	block1, block2, block3 := ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil)
+	parquetOnly, deletedParquetOnly, recentParquetOnly := ulid.MustNew(4, nil), ulid.MustNew(5, nil), ulid.MustNew(6, nil)
+	mark := &parquet.ConverterMarkMeta{Version: parquet.CurrentVersion, Shards: 2}
+
+	// The bucket only contains the bucket index, so the blocks and their converter marks
+	// can only be found in the bucket index.
+	idx := &bucketindex.Index{
+		Version: bucketindex.IndexVersion1,
+		Blocks: bucketindex.Blocks{
+			{ID: block1, MinTime: 0, MaxTime: day, Parquet: mark},
+			{ID: block2, MinTime: day, MaxTime: 2 * day, Parquet: mark},
+			{ID: block3, MinTime: 2 * day, MaxTime: 3 * day, Parquet: mark},
+			{ID: parquetOnly, MinTime: 4 * day, MaxTime: 6 * day, Parquet: mark, ParquetOnly: true, ParquetSources: []ulid.ULID{block2}},
+			{ID: deletedParquetOnly, MinTime: 6 * day, MaxTime: 8 * day, Parquet: mark, ParquetOnly: true},
+		},
+		BlockDeletionMarks: bucketindex.BlockDeletionMarks{{ID: deletedParquetOnly}},
+	}
+	require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, user, nil, idx))
+
+	metas := []*metadata.Meta{
+		{BlockMeta: tsdb.BlockMeta{ULID: block1, MinTime: 0, MaxTime: day}},
+		{BlockMeta: tsdb.BlockMeta{ULID: block2, MinTime: day, MaxTime: 2 * day}},
+		{BlockMeta: tsdb.BlockMeta{ULID: block3, MinTime: 2 * day, MaxTime: 3 * day}},
+	}
+
+	sources, replaced, err := c.listCompactionSources(ctx, logger, user, userBucket, metas)
+	require.NoError(t, err)
+	assert.Equal(t, []compactionSource{
+		{id: block1, minTime: 0, maxTime: day, shards: 2},
+		{id: block3, minTime: 2 * day, maxTime: 3 * day, shards: 2},
+		{id: parquetOnly, minTime: 4 * day, maxTime: 6 * day, shards: 2},
+	}, sources)
+	assert.Equal(t, []ulid.ULID{block2}, replaced)
+
+	// The sources of a block compacted since the bucket index has been updated are not compacted again.
+	c.compactedBlocks[user] = map[ulid.ULID][]ulid.ULID{recentParquetOnly: {block1}}
+	sources, replaced, err = c.listCompactionSources(ctx, logger, user, userBucket, metas)
+	require.NoError(t, err)
+	assert.Equal(t, []compactionSource{
+		{id: block3, minTime: 2 * day, maxTime: 3 * day, shards: 2},
+		{id: parquetOnly, minTime: 4 * day, maxTime: 6 * day, shards: 2},
+	}, sources)
+	assert.Equal(t, []ulid.ULID{block1, block2}, replaced)
+
+	// Once the block is in the bucket index, it's compacted like any other parquet-only block.
+	idx.Blocks = append(idx.Blocks, &bucketindex.Block{ID: recentParquetOnly, MinTime: 0, MaxTime: day, Parquet: mark, ParquetOnly: true, ParquetSources: []ulid.ULID{block1}})
+	require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, user, nil, idx))
+	sources, replaced, err = c.listCompactionSources(ctx, logger, user, userBucket, metas)
+	require.NoError(t, err)
+	assert.Equal(t, []compactionSource{
+		{id: block3, minTime: 2 * day, maxTime: 3 * day, shards: 2},
+		{id: parquetOnly, minTime: 4 * day, maxTime: 6 * day, shards: 2},
+		{id: recentParquetOnly, minTime: 0, maxTime: day, shards: 2},
+	}, sources)
+	assert.Equal(t, []ulid.ULID{block1, block2}, replaced)
+	assert.Empty(t, c.compactedBlocks[user])
+}
+
+type testSample struct {
+	t int64
+	f float64
+}
+
+func readSamples(t *testing.T, b interface {
+	Index() (tsdb.IndexReader, error)
+	Chunks() (tsdb.ChunkReader, error)
+	Meta() tsdb.BlockMeta
+}) map[string][]testSample {
+	indexr, err := b.Index()
+	require.NoError(t, err)
+	defer indexr.Close()
+	chunkr, err := b.Chunks()
+	require.NoError(t, err)
+	defer chunkr.Close()
+
+	name, value := index.AllPostingsKey()
+	postings, err := indexr.Postings(context.Background(), name, value)
+	require.NoError(t, err)
+
+	ss := tsdb.NewBlockChunkSeriesSet(b.Meta().ULID, indexr, chunkr, tombstones.NewMemTombstones(), postings, b.Meta().MinTime, b.Meta().MaxTime, false)
+	res := map[string][]testSample{}
+	for ss.Next() {
+		s := ss.At()
+		it := s.Iterator(nil)
+		for it.Next() {
+			cit := it.At().Chunk.Iterator(nil)
+			for cit.Next() == chunkenc.ValFloat {
+				ts, v := cit.At()
+				res[s.Labels().String()] = append(res[s.Labels().String()], testSample{t: ts, f: v})
+			}
+			require.NoError(t, cit.Err())
+		}
+		require.NoError(t, it.Err())
+	}
+	require.NoError(t, ss.Err())
+	return res
+}
+
+func TestConfig_Validate(t *testing.T) {
+	compactorBlockRanges := cortex_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}
+
+	tests := map[string]struct {
+		compactionBlockRanges cortex_tsdb.DurationList
+		expectedErr           string
+	}{
+		"parquet compaction disabled": {},
+		"valid compaction block ranges": {
+			compactionBlockRanges: cortex_tsdb.DurationList{7 * 24 * time.Hour, 28 * 24 * time.Hour},
+		},
+		"compaction block range not greater than the largest compactor block range": {
+			compactionBlockRanges: cortex_tsdb.DurationList{24 * time.Hour},
+			expectedErr:           "parquet compaction block range 24h0m0s should be greater than and a multiple of the largest compactor block range 24h0m0s",
+		},
+		"compaction block range not a multiple of the largest compactor block range": {
+			compactionBlockRanges: cortex_tsdb.DurationList{36 * time.Hour},
+			expectedErr:           "parquet compaction block range 36h0m0s should be greater than and a multiple of the largest compactor block range 24h0m0s",
+		},
+		"compaction block ranges not in increasing order": {
+			compactionBlockRanges: cortex_tsdb.DurationList{28 * 24 * time.Hour, 7 * 24 * time.Hour},
+			expectedErr:           "parquet compaction block ranges should be in increasing order, but 168h0m0s is not greater than 672h0m0s",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := prepareConfig()
+			cfg.CompactionBlockRanges = tc.compactionBlockRanges
+			err := cfg.Validate(compactorBlockRanges)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
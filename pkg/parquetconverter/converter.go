@@ -16,6 +16,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid/v2"
 	"github.com/parquet-go/parquet-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus-community/parquet-common/convert"
@@ -49,6 +50,8 @@ const (
 	ringKey = "parquet-converter"
 
 	converterMetaPrefix = "converter-meta-"
+
+	errInvalidCompactionBlockRange = "parquet compaction block range %s should be greater than and a multiple of the largest compactor block range %s"
 )
 
 var RingOp = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
@@ -59,6 +62,8 @@ type Config struct {
 	MaxRowsPerRowGroup  int           `yaml:"max_rows_per_row_group"`
 	FileBufferEnabled   bool          `yaml:"file_buffer_enabled"`
 
+	CompactionBlockRanges cortex_tsdb.DurationList `yaml:"compaction_block_ranges"`
+
 	DataDir string `yaml:"data_dir"`
 
 	Ring RingConfig `yaml:"ring"`
@@ -99,6 +104,10 @@ type Converter struct {
 	// Keep track of the last owned users.
 	// This is not thread safe now.
 	lastOwnedUsers map[string]struct{}
+
+	// Sources of the parquet-only blocks compacted by this converter, per user and block,
+	// until the blocks are found in the bucket index. This is not thread safe now.
+	compactedBlocks map[string]map[ulid.ULID][]ulid.ULID
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -109,10 +118,31 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxRowsPerRowGroup, "parquet-converter.max-rows-per-row-group", 1e6, "Maximum number of time series per parquet row group. Larger values improve compression but may reduce performance during reads.")
 	f.DurationVar(&cfg.ConversionInterval, "parquet-converter.conversion-interval", time.Minute, "How often to check for new TSDB blocks to convert to parquet format.")
 	f.BoolVar(&cfg.FileBufferEnabled, "parquet-converter.file-buffer-enabled", true, "Enable disk-based write buffering to reduce memory consumption during parquet file generation.")
+	f.Var(&cfg.CompactionBlockRanges, "parquet-converter.compaction-block-ranges", "EXPERIMENTAL: Comma separated list of block ranges at which the parquet files of already converted blocks are compacted together into parquet-only blocks, without compacting the TSDB blocks. Each range must be a multiple of the largest compactor block range. Parquet-only blocks are queried by the parquet queryable only. Compaction is enabled per tenant with -parquet-converter.compaction-enabled. Empty disables parquet compaction.")
+}
+
+func (cfg *Config) Validate(compactorBlockRanges cortex_tsdb.DurationList) error {
+	if len(cfg.CompactionBlockRanges) == 0 {
+		return nil
+	}
+	if len(compactorBlockRanges) == 0 {
+		return errors.New("parquet compaction requires the compactor block ranges to be configured")
+	}
+
+	largest := compactorBlockRanges[len(compactorBlockRanges)-1]
+	for i, r := range cfg.CompactionBlockRanges {
+		if r <= largest || r%largest != 0 {
+			return errors.Errorf(errInvalidCompactionBlockRange, r.String(), largest.String())
+		}
+		if i > 0 && r <= cfg.CompactionBlockRanges[i-1] {
+			return errors.Errorf("parquet compaction block ranges should be in increasing order, but %s is not greater than %s", r.String(), cfg.CompactionBlockRanges[i-1].String())
+		}
+	}
+	return nil
 }
 
 func NewConverter(cfg Config, storageCfg cortex_tsdb.BlocksStorageConfig, blockRanges []int64, logger log.Logger, registerer prometheus.Registerer, limits *validation.Overrides) (*Converter, error) {
-	bkt, err := bucket.NewClient(context.Background(), storageCfg.Bucket, nil, "parquet-converter", logger, registerer)
+	bkt, err := cortex_tsdb.NewBucketClient(context.Background(), storageCfg, nil, "parquet-converter", logger, registerer)
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +172,7 @@ func newConverter(cfg Config, bkt objstore.InstrumentedBucket, storageCfg cortex
 			convert.WithColDuration(time.Hour * 8),
 			convert.WithRowGroupSize(cfg.MaxRowsPerRowGroup),
 		},
+		compactedBlocks: map[string]map[ulid.ULID][]ulid.ULID{},
 	}
 
 	c.Service = services.NewBasicService(c.starting, c.running, c.stopping)
@@ -257,6 +288,11 @@ func (c *Converter) running(ctx context.Context) error {
 				}
 			}
 			c.lastOwnedUsers = ownedUsers
+			for userID := range c.compactedBlocks {
+				if _, owned := ownedUsers[userID]; !owned {
+					delete(c.compactedBlocks, userID)
+				}
+			}
 			c.metrics.ownedUsers.Set(float64(len(ownedUsers)))
 
 			// Delete local files for unowned tenants, if there are any. This cleans up
@@ -429,11 +465,7 @@ func (c *Converter) convertUser(ctx context.Context, logger log.Logger, ring rin
 		start := time.Now()
 
 		converterOpts := append(c.baseConverterOptions, convert.WithName(b.ULID.String()))
-
-		sortColumns := []string{labels.MetricName}
-		userConfiguredSortColumns := c.limits.ParquetConverterSortColumns(userID)
-		sortColumns = append(sortColumns, userConfiguredSortColumns...)
-		converterOpts = append(converterOpts, convert.WithSortBy(sortColumns...))
+		converterOpts = append(converterOpts, convert.WithSortBy(c.sortColumns(userID)...))
 
 		if c.cfg.FileBufferEnabled {
 			converterOpts = append(converterOpts, convert.WithColumnPageBuffers(parquet.NewFileBufferPool(bdir, "buffers.*")))
@@ -483,9 +515,18 @@ func (c *Converter) convertUser(ctx context.Context, logger log.Logger, ring rin
 		c.metrics.convertParquetBlockDelay.Observe(delayMinutes)
 	}
 
+	if len(c.cfg.CompactionBlockRanges) > 0 && c.limits.ParquetConverterCompactionEnabled(userID) {
+		return c.compactUser(ctx, logger, ring, userID, uBucket, blocks)
+	}
 	return nil
 }
 
+// sortColumns returns the columns the parquet files of the user are sorted by.
+func (c *Converter) sortColumns(userID string) []string {
+	sortColumns := []string{labels.MetricName}
+	return append(sortColumns, c.limits.ParquetConverterSortColumns(userID)...)
+}
+
 func (c *Converter) checkConvertError(userID string, err error) (terminate bool) {
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || c.isCausedByPermissionDenied(err) {
 		terminate = true
@@ -11,6 +11,10 @@ type metrics struct {
 	convertBlockDuration     *prometheus.GaugeVec
 	convertParquetBlockDelay prometheus.Histogram
 	ownedUsers               prometheus.Gauge
+
+	compactions              *prometheus.CounterVec
+	compactionFailures       *prometheus.CounterVec
+	compactionBlocksReplaced prometheus.Counter
 }
 
 func newMetrics(reg prometheus.Registerer) *metrics {
@@ -36,6 +40,18 @@ func newMetrics(reg prometheus.Registerer) *metrics {
 			Name: "cortex_parquet_converter_users_owned",
 			Help: "Number of users that the parquet converter owns.",
 		}),
+		compactions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_parquet_converter_compactions_total",
+			Help: "Total number of parquet-only blocks compacted from the parquet files of other blocks per user.",
+		}, []string{"user"}),
+		compactionFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_parquet_converter_compaction_failures_total",
+			Help: "Total number of failed parquet compactions per user.",
+		}, []string{"user"}),
+		compactionBlocksReplaced: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_parquet_converter_compaction_source_blocks_marked_for_deletion_total",
+			Help: "Total number of blocks marked for deletion after their parquet files have been compacted.",
+		}),
 	}
 }
 
@@ -43,4 +59,6 @@ func (m *metrics) deleteMetricsForTenant(userID string) {
 	m.convertedBlocks.DeleteLabelValues(userID)
 	m.convertBlockFailures.DeleteLabelValues(userID)
 	m.convertBlockDuration.DeleteLabelValues(userID)
+	m.compactions.DeleteLabelValues(userID)
+	m.compactionFailures.DeleteLabelValues(userID)
 }
@@ -0,0 +1,411 @@
+package parquetconverter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus-community/parquet-common/convert"
+	"github.com/prometheus-community/parquet-common/schema"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+)
+
+var _ convert.Convertible = &parquetBlock{}
+
+// parquetBlock exposes a shard of the parquet files of a block through the TSDB readers expected by the
+// parquet converter, so that parquet files can be compacted without the TSDB index and chunks of the block.
+// The labels of all series are kept in memory, while the chunks are read from the chunks file on demand.
+type parquetBlock struct {
+	meta tsdb.BlockMeta
+	pool chunkenc.Pool
+
+	chunksFile  *os.File
+	chunksPFile *parquet.File
+
+	// series holds the labels of all series sorted by labels, the series ref being the position in the slice.
+	series []labels.Labels
+	// rows holds the row of each series in the parquet files.
+	rows       []int64
+	labelNames []string
+}
+
+// openParquetBlock opens the parquet files of the shard of a block previously downloaded to dir.
+func openParquetBlock(dir string, meta tsdb.BlockMeta, shard int, pool chunkenc.Pool) (*parquetBlock, error) {
+	labelsFile, labelsPFile, err := openParquetFile(filepath.Join(dir, schema.LabelsPfileNameForShard(meta.ULID.String(), shard)))
+	if err != nil {
+		return nil, err
+	}
+	defer labelsFile.Close()
+
+	b := &parquetBlock{meta: meta, pool: pool}
+	if err := b.loadLabels(labelsPFile); err != nil {
+		return nil, errors.Wrapf(err, "load labels of block %s shard %d", meta.ULID.String(), shard)
+	}
+
+	b.chunksFile, b.chunksPFile, err = openParquetFile(filepath.Join(dir, schema.ChunksPfileNameForShard(meta.ULID.String(), shard)))
+	if err != nil {
+		return nil, err
+	}
+	if b.chunksPFile.NumRows() != int64(len(b.series)) {
+		_ = b.Close()
+		return nil, errors.Errorf("mismatching number of rows in labels and chunks files of block %s shard %d", meta.ULID.String(), shard)
+	}
+	return b, nil
+}
+
+func openParquetFile(name string) (*os.File, *parquet.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	pf, err := parquet.OpenFile(f, stat.Size(), parquet.SkipBloomFilters(true))
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, errors.Wrapf(err, "open parquet file %s", name)
+	}
+	return f, pf, nil
+}
+
+func (b *parquetBlock) loadLabels(f *parquet.File) error {
+	labelColumns := map[int]string{}
+	for i, path := range f.Schema().Columns() {
+		if name, ok := schema.ExtractLabelFromColumn(path[0]); ok {
+			labelColumns[i] = name
+			b.labelNames = append(b.labelNames, name)
+		}
+	}
+	sort.Strings(b.labelNames)
+
+	rows := parquet.MultiRowGroup(f.RowGroups()...).Rows()
+	defer rows.Close()
+
+	var (
+		buf     = make([]parquet.Row, 128)
+		builder = labels.NewScratchBuilder(len(labelColumns))
+	)
+	for {
+		n, err := rows.ReadRows(buf)
+		for _, row := range buf[:n] {
+			builder.Reset()
+			for _, v := range row {
+				name, ok := labelColumns[v.Column()]
+				if !ok || v.IsNull() || len(v.ByteArray()) == 0 {
+					continue
+				}
+				builder.Add(name, string(v.ByteArray()))
+			}
+			builder.Sort()
+			b.rows = append(b.rows, int64(len(b.series)))
+			b.series = append(b.series, builder.Labels())
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Sort(b)
+	return nil
+}
+
+// Len, Less and Swap sort the series by labels, keeping track of their row.
+func (b *parquetBlock) Len() int           { return len(b.series) }
+func (b *parquetBlock) Less(i, j int) bool { return labels.Compare(b.series[i], b.series[j]) < 0 }
+func (b *parquetBlock) Swap(i, j int) {
+	b.series[i], b.series[j] = b.series[j], b.series[i]
+	b.rows[i], b.rows[j] = b.rows[j], b.rows[i]
+}
+
+func (b *parquetBlock) Meta() tsdb.BlockMeta {
+	return b.meta
+}
+
+func (b *parquetBlock) Index() (tsdb.IndexReader, error) {
+	return &parquetIndexReader{b: b}, nil
+}
+
+func (b *parquetBlock) Chunks() (tsdb.ChunkReader, error) {
+	dataColumns := map[int]struct{}{}
+	for i, path := range b.chunksPFile.Schema().Columns() {
+		if schema.IsDataColumn(path[0]) {
+			dataColumns[i] = struct{}{}
+		}
+	}
+	return &parquetChunkReader{
+		b:           b,
+		rows:        parquet.MultiRowGroup(b.chunksPFile.RowGroups()...).Rows(),
+		buf:         make([]parquet.Row, 1),
+		dataColumns: dataColumns,
+		decoder:     schema.NewPrometheusParquetChunksDecoder(b.pool),
+	}, nil
+}
+
+func (b *parquetBlock) Tombstones() (tombstones.Reader, error) {
+	return tombstones.NewMemTombstones(), nil
+}
+
+func (b *parquetBlock) Close() error {
+	return b.chunksFile.Close()
+}
+
+// parquetIndexReader is an in-memory tsdb.IndexReader over the series of a parquetBlock. Each series
+// has a single chunk meta, spanning the whole block, whose ref is the series ref.
+type parquetIndexReader struct {
+	b *parquetBlock
+}
+
+func (r *parquetIndexReader) Symbols() index.StringIter {
+	symbols := map[string]struct{}{}
+	for _, lbls := range r.b.series {
+		lbls.Range(func(l labels.Label) {
+			symbols[l.Name] = struct{}{}
+			symbols[l.Value] = struct{}{}
+		})
+	}
+	res := make([]string, 0, len(symbols))
+	for s := range symbols {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return index.NewStringListIter(res)
+}
+
+func (r *parquetIndexReader) SortedLabelValues(ctx context.Context, name string, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, error) {
+	values, err := r.LabelValues(ctx, name, hints, matchers...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func (r *parquetIndexReader) LabelValues(_ context.Context, name string, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, error) {
+	values := map[string]struct{}{}
+	for _, lbls := range r.b.series {
+		if v := lbls.Get(name); v != "" && matchesAll(lbls, matchers) {
+			values[v] = struct{}{}
+		}
+	}
+	res := make([]string, 0, len(values))
+	for v := range values {
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+func (r *parquetIndexReader) Postings(_ context.Context, name string, values ...string) (index.Postings, error) {
+	allName, allValue := index.AllPostingsKey()
+	if name == allName && len(values) == 1 && values[0] == allValue {
+		return r.filterPostings(func(labels.Labels) bool { return true }), nil
+	}
+	return r.filterPostings(func(lbls labels.Labels) bool {
+		v := lbls.Get(name)
+		return v != "" && slices.Contains(values, v)
+	}), nil
+}
+
+func (r *parquetIndexReader) PostingsForLabelMatching(_ context.Context, name string, match func(value string) bool) index.Postings {
+	return r.filterPostings(func(lbls labels.Labels) bool {
+		v := lbls.Get(name)
+		return v != "" && match(v)
+	})
+}
+
+func (r *parquetIndexReader) PostingsForAllLabelValues(_ context.Context, name string) index.Postings {
+	return r.filterPostings(func(lbls labels.Labels) bool {
+		return lbls.Has(name)
+	})
+}
+
+// SortedPostings returns the postings as they are, because series refs are assigned in labels order.
+func (r *parquetIndexReader) SortedPostings(p index.Postings) index.Postings {
+	return p
+}
+
+func (r *parquetIndexReader) ShardedPostings(p index.Postings, shardIndex, shardCount uint64) index.Postings {
+	var refs []storage.SeriesRef
+	for p.Next() {
+		if labels.StableHash(r.b.series[p.At()])%shardCount == shardIndex {
+			refs = append(refs, p.At())
+		}
+	}
+	if err := p.Err(); err != nil {
+		return index.ErrPostings(err)
+	}
+	return index.NewListPostings(refs)
+}
+
+func (r *parquetIndexReader) Series(ref storage.SeriesRef, builder *labels.ScratchBuilder, chks *[]chunks.Meta) error {
+	if int(ref) >= len(r.b.series) {
+		return storage.ErrNotFound
+	}
+	builder.Assign(r.b.series[ref])
+	*chks = append((*chks)[:0], chunks.Meta{
+		Ref:     chunks.ChunkRef(ref),
+		MinTime: r.b.meta.MinTime,
+		MaxTime: r.b.meta.MaxTime - 1,
+	})
+	return nil
+}
+
+func (r *parquetIndexReader) LabelNames(_ context.Context, matchers ...*labels.Matcher) ([]string, error) {
+	if len(matchers) == 0 {
+		return r.b.labelNames, nil
+	}
+	names := map[string]struct{}{}
+	for _, lbls := range r.b.series {
+		if !matchesAll(lbls, matchers) {
+			continue
+		}
+		lbls.Range(func(l labels.Label) {
+			names[l.Name] = struct{}{}
+		})
+	}
+	res := make([]string, 0, len(names))
+	for n := range names {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (r *parquetIndexReader) LabelValueFor(_ context.Context, id storage.SeriesRef, label string) (string, error) {
+	if int(id) >= len(r.b.series) {
+		return "", storage.ErrNotFound
+	}
+	v := r.b.series[id].Get(label)
+	if v == "" {
+		return "", storage.ErrNotFound
+	}
+	return v, nil
+}
+
+func (r *parquetIndexReader) LabelNamesFor(_ context.Context, postings index.Postings) ([]string, error) {
+	names := map[string]struct{}{}
+	for postings.Next() {
+		if int(postings.At()) >= len(r.b.series) {
+			return nil, storage.ErrNotFound
+		}
+		r.b.series[postings.At()].Range(func(l labels.Label) {
+			names[l.Name] = struct{}{}
+		})
+	}
+	if err := postings.Err(); err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(names))
+	for n := range names {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (r *parquetIndexReader) Close() error {
+	return nil
+}
+
+func (r *parquetIndexReader) filterPostings(keep func(labels.Labels) bool) index.Postings {
+	refs := make([]storage.SeriesRef, 0, len(r.b.series))
+	for i, lbls := range r.b.series {
+		if keep(lbls) {
+			refs = append(refs, storage.SeriesRef(i))
+		}
+	}
+	return index.NewListPostings(refs)
+}
+
+func matchesAll(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parquetChunkReader reads the chunks of a series from its row in the chunks file. Rows are read with
+// a cursor, which is cheap as long as series are read in the same order they have been written in.
+type parquetChunkReader struct {
+	b           *parquetBlock
+	rows        parquet.Rows
+	next        int64
+	buf         []parquet.Row
+	dataColumns map[int]struct{}
+	decoder     *schema.PrometheusParquetChunksDecoder
+}
+
+func (r *parquetChunkReader) ChunkOrIterable(meta chunks.Meta) (chunkenc.Chunk, chunkenc.Iterable, error) {
+	ref := int(meta.Ref)
+	if ref >= len(r.b.rows) {
+		return nil, nil, storage.ErrNotFound
+	}
+
+	row := r.b.rows[ref]
+	if row != r.next {
+		if err := r.rows.SeekToRow(row); err != nil {
+			return nil, nil, errors.Wrapf(err, "seek to row %d", row)
+		}
+	}
+	n, err := r.rows.ReadRows(r.buf)
+	if n == 0 {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = storage.ErrNotFound
+		}
+		return nil, nil, errors.Wrapf(err, "read row %d", row)
+	}
+	r.next = row + 1
+
+	var metas []chunks.Meta
+	for _, v := range r.buf[0] {
+		if _, ok := r.dataColumns[v.Column()]; !ok || v.IsNull() || len(v.ByteArray()) == 0 {
+			continue
+		}
+		// The decoded chunks reference the data, which is only valid until the next read.
+		decoded, err := r.decoder.Decode(bytes.Clone(v.ByteArray()), r.b.meta.MinTime, r.b.meta.MaxTime)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "decode chunks of row %d", row)
+		}
+		metas = append(metas, decoded...)
+	}
+
+	// Chunks with different encodings are not necessarily sorted by time.
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].MinTime < metas[j].MinTime
+	})
+	iterables := make(chunksIterable, 0, len(metas))
+	for _, m := range metas {
+		iterables = append(iterables, m.Chunk)
+	}
+	return nil, iterables, nil
+}
+
+func (r *parquetChunkReader) Close() error {
+	return r.rows.Close()
+}
+
+// chunksIterable iterates the samples of chunks sorted by time.
+type chunksIterable []chunkenc.Iterable
+
+func (c chunksIterable) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	return storage.ChainSampleIteratorFromIterables(it, c)
+}
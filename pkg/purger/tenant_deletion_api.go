@@ -119,7 +119,7 @@ func (api *TenantDeletionAPI) isBlocksForUserDeleted(ctx context.Context, userID
 }
 
 func createBucketClient(cfg cortex_tsdb.BlocksStorageConfig, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
-	bucketClient, err := bucket.NewClient(context.Background(), cfg.Bucket, nil, "purger", logger, reg)
+	bucketClient, err := cortex_tsdb.NewBucketClient(context.Background(), cfg, nil, "purger", logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "create bucket client")
 	}
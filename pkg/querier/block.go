@@ -4,8 +4,10 @@ import (
 	"context"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 
+	"github.com/cortexproject/cortex/pkg/querier/series"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 )
 
@@ -76,3 +78,26 @@ func (*storeSeriesSet) Err() error {
 func (s *storeSeriesSet) At() (labels.Labels, []storepb.AggrChunk) {
 	return s.series[s.i].PromLabels(), s.series[s.i].Chunks
 }
+
+// chunkBytesSeriesSet annotates the series converted from the given store series with the size of their chunks.
+type chunkBytesSeriesSet struct {
+	storage.SeriesSet
+	series []*storepb.Series
+	i      int
+}
+
+func newChunkBytesSeriesSet(set storage.SeriesSet, s []*storepb.Series) *chunkBytesSeriesSet {
+	return &chunkBytesSeriesSet{SeriesSet: set, series: s, i: -1}
+}
+
+func (s *chunkBytesSeriesSet) Next() bool {
+	if !s.SeriesSet.Next() {
+		return false
+	}
+	s.i++
+	return true
+}
+
+func (s *chunkBytesSeriesSet) At() storage.Series {
+	return series.NewChunkBytesSeries(s.SeriesSet.At(), countChunkBytes(s.series[s.i]))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/oklog/ulid/v2"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/thanos-io/objstore"
 
@@ -37,6 +38,8 @@ type BucketIndexBlocksFinder struct {
 
 	cfg    BucketIndexBlocksFinderConfig
 	loader *bucketindex.Loader
+
+	blocksSkippedByStats prometheus.Counter
 }
 
 func NewBucketIndexBlocksFinder(cfg BucketIndexBlocksFinderConfig, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *BucketIndexBlocksFinder {
@@ -46,11 +49,15 @@ func NewBucketIndexBlocksFinder(cfg BucketIndexBlocksFinderConfig, bkt objstore.
 		cfg:     cfg,
 		loader:  loader,
 		Service: loader,
+		blocksSkippedByStats: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_blocks_skipped_by_stats_total",
+			Help: "Total number of blocks not queried because their label statistics in the bucket index guarantee they don't match the query.",
+		}),
 	}
 }
 
 // GetBlocks implements BlocksFinder.
-func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string, minT, maxT int64, _ []*labels.Matcher) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error) {
+func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string, minT, maxT int64, matchers []*labels.Matcher) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error) {
 	if f.State() != services.Running {
 		return nil, nil, errBucketIndexBlocksFinderNotRunning
 	}
@@ -93,6 +100,17 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 			continue
 		}
 
+		// Skip blocks found corrupted by the compactor's block scrubber.
+		if block.Quarantined {
+			continue
+		}
+
+		// Skip blocks which can't match the query, according to the stats computed by the compactor.
+		if !block.Stats.MayMatch(matchers) {
+			f.blocksSkippedByStats.Inc()
+			continue
+		}
+
 		matchingBlocks[block.ID] = block
 	}
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
@@ -130,6 +131,88 @@ func TestBucketIndexBlocksFinder_GetBlocks(t *testing.T) {
 	}
 }
 
+func TestBucketIndexBlocksFinder_GetBlocks_ShouldSkipBlocksNotMatchingStats(t *testing.T) {
+	t.Parallel()
+
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+
+	// Mock a bucket index.
+	block1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 20}
+	block2 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 20, Stats: &bucketindex.BlockStats{
+		Labels: map[string]bucketindex.LabelValuesRange{"cluster": {Min: "eu-1", Max: "eu-2"}},
+	}}
+	block3 := &bucketindex.Block{ID: ulid.MustNew(3, nil), MinTime: 10, MaxTime: 20, Stats: &bucketindex.BlockStats{
+		Labels: map[string]bucketindex.LabelValuesRange{"cluster": {Min: "us-1", Max: "us-2"}},
+	}}
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{block1, block2, block3},
+		UpdatedAt: time.Now().Unix(),
+	}))
+
+	finder := prepareBucketIndexBlocksFinder(t, bkt)
+
+	tests := map[string]struct {
+		matchers       []*labels.Matcher
+		expectedBlocks bucketindex.Blocks
+	}{
+		"no matchers": {
+			expectedBlocks: bucketindex.Blocks{block1, block2, block3},
+		},
+		"equality matcher matching a block stats": {
+			matchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "cluster", "us-1")},
+			expectedBlocks: bucketindex.Blocks{block1, block3},
+		},
+		"equality matcher matching no block stats": {
+			matchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "cluster", "ap-1")},
+			expectedBlocks: bucketindex.Blocks{block1},
+		},
+		"regexp matcher": {
+			matchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "cluster", "ap-1")},
+			expectedBlocks: bucketindex.Blocks{block1, block2, block3},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			blocks, _, err := finder.GetBlocks(ctx, userID, 0, 30, testData.matchers)
+			require.NoError(t, err)
+			require.ElementsMatch(t, testData.expectedBlocks, blocks)
+		})
+	}
+}
+
+func TestBucketIndexBlocksFinder_GetBlocks_ShouldSkipQuarantinedBlocks(t *testing.T) {
+	t.Parallel()
+
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+
+	// Mock a bucket index.
+	block1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 20}
+	block2 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 20, Quarantined: true}
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{block1, block2},
+		UpdatedAt: time.Now().Unix(),
+	}))
+
+	finder := prepareBucketIndexBlocksFinder(t, bkt)
+
+	blocks, _, err := finder.GetBlocks(ctx, userID, 0, 30, nil)
+	require.NoError(t, err)
+	require.Equal(t, bucketindex.Blocks{block1}, blocks)
+}
+
 func BenchmarkBucketIndexBlocksFinder_GetBlocks(b *testing.B) {
 	const (
 		numBlocks        = 1000
@@ -92,6 +92,29 @@ func (s *blocksStoreBalancedSet) GetClientsFor(_ string, blockIDs []ulid.ULID, e
 	return clients, nil
 }
 
+// GetHedgingClientFor implements hedgingBlocksStoreSet. Given the blocks are not sharded,
+// any store-gateway instance can be used.
+func (s *blocksStoreBalancedSet) GetHedgingClientFor(_ string, _ []ulid.ULID, exclude []string) (BlocksStoreClient, error) {
+	addresses := s.dnsProvider.Addresses()
+
+	// Randomize the list of addresses to not always hedge to the same address.
+	rand.Shuffle(len(addresses), func(i, j int) {
+		addresses[i], addresses[j] = addresses[j], addresses[i]
+	})
+
+	addr := getFirstNonExcludedAddr(addresses, exclude)
+	if addr == "" {
+		return nil, errNoStoreGatewayHedgingReplica
+	}
+
+	c, err := s.clientsPool.GetClientFor(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get store-gateway client for %s", addr)
+	}
+
+	return c.(BlocksStoreClient), nil
+}
+
 func getFirstNonExcludedAddr(addresses, exclude []string) string {
 	for _, addr := range addresses {
 		if !slices.Contains(exclude, addr) {
@@ -0,0 +1,281 @@
+package querier
+
+import (
+	"context"
+	"flag"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/caio/go-tdigest"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/storegateway/storegatewaypb"
+)
+
+var (
+	errInvalidStoreGatewayHedgedRequestQuantile = errors.New("store gateway hedged request quantile must be greater than 0 and less than or equal to 1")
+	errInvalidStoreGatewayHedgedRequestMinDelay = errors.New("store gateway hedged request min delay must be greater than 0")
+	errNoStoreGatewayHedgingReplica             = errors.New("no store-gateway replica owning all the blocks left")
+)
+
+type StoreGatewayHedgedRequestConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Quantile float64       `yaml:"quantile"`
+	MinDelay time.Duration `yaml:"min_delay"`
+}
+
+func (cfg *StoreGatewayHedgedRequestConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "[Experimental] If true, the series requests sent to a store-gateway which doesn't start responding within the hedging delay are sent to another store-gateway replica owning the same blocks, and the first replica to respond is used. It can help with reducing tail latency.")
+	f.Float64Var(&cfg.Quantile, prefix+"quantile", 0.9, "[Experimental] The quantile of the time taken by the store-gateways to start responding to series requests during the last 5 to 10 minutes, used as hedging delay. For example, a request is hedged when the store-gateway doesn't respond within the 90th percentile.")
+	f.DurationVar(&cfg.MinDelay, prefix+"min-delay", 100*time.Millisecond, "[Experimental] The minimum hedging delay of the series requests sent to store-gateways.")
+}
+
+func (cfg *StoreGatewayHedgedRequestConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Quantile <= 0 || cfg.Quantile > 1 {
+		return errInvalidStoreGatewayHedgedRequestQuantile
+	}
+	if cfg.MinDelay <= 0 {
+		return errInvalidStoreGatewayHedgedRequestMinDelay
+	}
+	return nil
+}
+
+// hedgingBlocksStoreSet is implemented by the BlocksStoreSet which can find another
+// store-gateway replica to hedge a request to.
+type hedgingBlocksStoreSet interface {
+	// GetHedgingClientFor returns the client of a store-gateway owning all the blocks
+	// in input, except the ones whose address is in exclude.
+	GetHedgingClientFor(userID string, blockIDs []ulid.ULID, exclude []string) (BlocksStoreClient, error)
+}
+
+// seriesHedgingWindow is the period after which the latency observed by seriesHedging is rotated.
+const seriesHedgingWindow = 5 * time.Minute
+
+// seriesHedging tracks the time taken by the store-gateways to start responding to series
+// requests, in order to compute the delay after which a request is hedged.
+//
+// The latency is tracked by two digests, rotated every seriesHedgingWindow, so that the delay
+// follows the recent latency of the store-gateways. Observations are added to both digests, and
+// the delay is computed from the previous one, which holds the observations of the last one to
+// two windows.
+type seriesHedging struct {
+	quantile float64
+	minDelay time.Duration
+	now      func() time.Time
+
+	mtx       sync.Mutex
+	previous  *tdigest.TDigest
+	current   *tdigest.TDigest
+	rotatedAt time.Time
+
+	hedgedRequests    prometheus.Counter
+	hedgedRequestsWon prometheus.Counter
+}
+
+func newSeriesHedging(cfg StoreGatewayHedgedRequestConfig, reg prometheus.Registerer) (*seriesHedging, error) {
+	previous, err := tdigest.New()
+	if err != nil {
+		return nil, errors.Wrap(err, "create series hedging digest")
+	}
+	current, err := tdigest.New()
+	if err != nil {
+		return nil, errors.Wrap(err, "create series hedging digest")
+	}
+
+	return &seriesHedging{
+		quantile:  cfg.Quantile,
+		minDelay:  cfg.MinDelay,
+		now:       time.Now,
+		previous:  previous,
+		current:   current,
+		rotatedAt: time.Now(),
+		hedgedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_storegateway_hedged_requests_total",
+			Help: "Total number of series requests sent to another store-gateway replica because the first one didn't start responding within the hedging delay.",
+		}),
+		hedgedRequestsWon: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_storegateway_hedged_requests_won_total",
+			Help: "Total number of hedged series requests whose store-gateway replica started responding first.",
+		}),
+	}, nil
+}
+
+// delay returns the time to wait for a store-gateway to start responding before hedging the request.
+func (h *seriesHedging) delay() time.Duration {
+	h.mtx.Lock()
+	h.rotate()
+	quantile := h.previous.Quantile(h.quantile)
+	h.mtx.Unlock()
+
+	if math.IsNaN(quantile) {
+		return h.minDelay
+	}
+	return max(h.minDelay, time.Duration(quantile*float64(time.Millisecond)))
+}
+
+func (h *seriesHedging) observe(d time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.rotate()
+
+	// The digests only fail on invalid values, so we can safely ignore the error.
+	_ = h.previous.Add(float64(d) / float64(time.Millisecond))
+	_ = h.current.Add(float64(d) / float64(time.Millisecond))
+}
+
+// rotate replaces the previous digest with the current one and starts a new current digest, if
+// the window has elapsed. It must be called with the lock held.
+func (h *seriesHedging) rotate() {
+	now := h.now()
+	if now.Sub(h.rotatedAt) < seriesHedgingWindow {
+		return
+	}
+
+	digest, err := tdigest.New()
+	if err != nil {
+		return
+	}
+	// If no observation has been added for more than a window, the previous digest is outdated too.
+	if now.Sub(h.rotatedAt) >= 2*seriesHedgingWindow {
+		h.current = digest
+		if digest, err = tdigest.New(); err != nil {
+			return
+		}
+	}
+	h.previous, h.current = h.current, digest
+	h.rotatedAt = now
+}
+
+// firstResponseSeriesStream is a series stream whose first response has already been received.
+type firstResponseSeriesStream struct {
+	storegatewaypb.StoreGateway_SeriesClient
+
+	first    *storepb.SeriesResponse
+	firstErr error
+	received bool
+}
+
+func (s *firstResponseSeriesStream) Recv() (*storepb.SeriesResponse, error) {
+	if !s.received {
+		s.received = true
+		return s.first, s.firstErr
+	}
+	return s.StoreGateway_SeriesClient.Recv()
+}
+
+type seriesStreamResult struct {
+	client BlocksStoreClient
+	stream storegatewaypb.StoreGateway_SeriesClient
+	err    error
+}
+
+// successful returns whether the store-gateway started responding to the request.
+func (r seriesStreamResult) successful() bool {
+	if r.err != nil {
+		return false
+	}
+	s := r.stream.(*firstResponseSeriesStream)
+	return s.firstErr == nil || errors.Is(s.firstErr, io.EOF)
+}
+
+// startSeriesStream sends the series request to the store-gateway and waits for its first response
+// in background. The returned cancel function cancels the request.
+func (q *blocksStoreQuerier) startSeriesStream(ctx context.Context, c BlocksStoreClient, req *storepb.SeriesRequest) (<-chan seriesStreamResult, context.CancelFunc) {
+	resCh := make(chan seriesStreamResult, 1)
+	streamCtx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		begin := time.Now()
+
+		stream, err := c.Series(streamCtx, req)
+		if err != nil {
+			resCh <- seriesStreamResult{client: c, err: err}
+			return
+		}
+
+		first, firstErr := stream.Recv()
+		res := seriesStreamResult{
+			client: c,
+			stream: &firstResponseSeriesStream{StoreGateway_SeriesClient: stream, first: first, firstErr: firstErr},
+		}
+		if res.successful() {
+			q.hedging.observe(time.Since(begin))
+		}
+		resCh <- res
+	}()
+
+	return resCh, cancel
+}
+
+// openSeriesStream sends the series request to the store-gateway. If hedging is enabled and the
+// store-gateway doesn't start responding within the hedging delay, the request is sent to another
+// store-gateway replica owning the same blocks too, and the stream of the first replica to respond
+// is returned. The request is never hedged to the store-gateways in exclude, which are the ones
+// already attempted for the blocks. The hedging replica is returned too, if the request has been
+// hedged, whether it succeeded or not. The returned cancel function must be called once the stream
+// has been consumed.
+func (q *blocksStoreQuerier) openSeriesStream(ctx context.Context, userID string, c BlocksStoreClient, blockIDs []ulid.ULID, exclude []string, req *storepb.SeriesRequest) (storegatewaypb.StoreGateway_SeriesClient, BlocksStoreClient, BlocksStoreClient, context.CancelFunc, error) {
+	if q.hedging == nil {
+		stream, err := c.Series(ctx, req)
+		return stream, c, nil, func() {}, err
+	}
+
+	primaryCh, primaryCancel := q.startSeriesStream(ctx, c, req)
+
+	timer := time.NewTimer(q.hedging.delay())
+	defer timer.Stop()
+
+	select {
+	case res := <-primaryCh:
+		return res.stream, res.client, nil, primaryCancel, res.err
+	case <-timer.C:
+	}
+
+	var hedgingClient BlocksStoreClient
+	if hedgingSet, ok := q.stores.(hedgingBlocksStoreSet); ok {
+		hedgingClient, _ = hedgingSet.GetHedgingClientFor(userID, blockIDs, append([]string{c.RemoteAddress()}, exclude...))
+	}
+	if hedgingClient == nil {
+		res := <-primaryCh
+		return res.stream, res.client, nil, primaryCancel, res.err
+	}
+
+	q.hedging.hedgedRequests.Inc()
+	stats.FromContext(ctx).AddStoreGatewayHedgedRequests(1)
+	hedgeCh, hedgeCancel := q.startSeriesStream(ctx, hedgingClient, req)
+
+	// Use the first replica which starts responding. If both fail, the error of the
+	// primary replica is returned, in order to retry it as if the request wasn't hedged.
+	var primary *seriesStreamResult
+	for pending := 2; pending > 0; pending-- {
+		select {
+		case res := <-primaryCh:
+			if res.successful() {
+				hedgeCancel()
+				return res.stream, res.client, hedgingClient, primaryCancel, res.err
+			}
+			primary = &res
+		case res := <-hedgeCh:
+			if res.successful() {
+				q.hedging.hedgedRequestsWon.Inc()
+				stats.FromContext(ctx).AddStoreGatewayHedgedRequestsWon(1)
+				primaryCancel()
+				return res.stream, res.client, hedgingClient, hedgeCancel, res.err
+			}
+		}
+	}
+
+	hedgeCancel()
+	return primary.stream, primary.client, hedgingClient, primaryCancel, primary.err
+}
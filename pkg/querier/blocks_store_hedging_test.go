@@ -0,0 +1,388 @@
+package querier
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	"github.com/cortexproject/cortex/pkg/storegateway/storegatewaypb"
+)
+
+func TestStoreGatewayHedgedRequestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg         StoreGatewayHedgedRequestConfig
+		expectedErr error
+	}{
+		"should pass if disabled": {
+			cfg: StoreGatewayHedgedRequestConfig{Enabled: false},
+		},
+		"should pass with valid config": {
+			cfg: StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: time.Millisecond},
+		},
+		"should fail with invalid quantile": {
+			cfg:         StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 1.5, MinDelay: time.Millisecond},
+			expectedErr: errInvalidStoreGatewayHedgedRequestQuantile,
+		},
+		"should fail with invalid min delay": {
+			cfg:         StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9},
+			expectedErr: errInvalidStoreGatewayHedgedRequestMinDelay,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expectedErr, testData.cfg.Validate())
+		})
+	}
+}
+
+func TestSeriesHedging_Delay(t *testing.T) {
+	h, err := newSeriesHedging(StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: 10 * time.Millisecond}, nil)
+	require.NoError(t, err)
+
+	// The min delay is used until the latency has been observed.
+	assert.Equal(t, 10*time.Millisecond, h.delay())
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Second)
+	}
+	assert.InDelta(t, float64(90*time.Second), float64(h.delay()), float64(2*time.Second))
+
+	// The delay is never lower than the min delay.
+	h, err = newSeriesHedging(StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: time.Minute}, nil)
+	require.NoError(t, err)
+	h.observe(time.Second)
+	assert.Equal(t, time.Minute, h.delay())
+}
+
+func TestSeriesHedging_DelayShouldFollowRecentLatency(t *testing.T) {
+	h, err := newSeriesHedging(StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: 10 * time.Millisecond}, nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	h.now = func() time.Time { return now }
+	h.rotatedAt = now
+
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Second)
+	}
+	assert.InDelta(t, float64(90*time.Second), float64(h.delay()), float64(2*time.Second))
+
+	// The latency observed in the previous window is still used after a rotation.
+	now = now.Add(seriesHedgingWindow)
+	for i := 0; i < 100; i++ {
+		h.observe(time.Second)
+	}
+	assert.Greater(t, h.delay(), 10*time.Second)
+
+	// The old latency is forgotten after another rotation.
+	now = now.Add(seriesHedgingWindow)
+	assert.Equal(t, time.Second, h.delay())
+
+	// The whole latency is forgotten if nothing has been observed for two windows.
+	now = now.Add(2 * seriesHedgingWindow)
+	assert.Equal(t, 10*time.Millisecond, h.delay())
+}
+
+func TestBlocksStoreQuerier_SelectWithHedging(t *testing.T) {
+	t.Parallel()
+
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	block1 := ulid.MustNew(1, nil)
+	series1 := labels.FromStrings(labels.MetricName, metricName, "series", "1")
+
+	newClient := func(addr string, value float64, delay time.Duration) *delayedStoreGatewayClientMock {
+		return &delayedStoreGatewayClientMock{
+			storeGatewayClientMock: &storeGatewayClientMock{
+				remoteAddr: addr,
+				mockedSeriesResponses: []*storepb.SeriesResponse{
+					mockSeriesResponse(series1, []cortexpb.Sample{{Value: value, TimestampMs: minT}}, nil, nil),
+					mockHintsResponse(block1),
+				},
+			},
+			delay: delay,
+		}
+	}
+
+	tests := map[string]struct {
+		primaryDelay      time.Duration
+		hedgeDelay        time.Duration
+		hedgingAvailable  bool
+		expectedValue     float64
+		expectedHedged    uint64
+		expectedHedgedWon uint64
+	}{
+		"should not hedge the request if the store-gateway responds within the hedging delay": {
+			hedgingAvailable: true,
+			expectedValue:    1,
+		},
+		"should use the hedged request if the other replica responds first": {
+			primaryDelay:      10 * time.Second,
+			hedgingAvailable:  true,
+			expectedValue:     2,
+			expectedHedged:    1,
+			expectedHedgedWon: 1,
+		},
+		"should use the first request if the store-gateway responds before the other replica": {
+			primaryDelay:     200 * time.Millisecond,
+			hedgeDelay:       10 * time.Second,
+			hedgingAvailable: true,
+			expectedValue:    1,
+			expectedHedged:   1,
+		},
+		"should wait for the store-gateway if no other replica owns the blocks": {
+			primaryDelay:  200 * time.Millisecond,
+			expectedValue: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			primary := newClient("1.1.1.1", 1, testData.primaryDelay)
+			hedge := newClient("2.2.2.2", 2, testData.hedgeDelay)
+
+			stores := &hedgingBlocksStoreSetMock{
+				blocksStoreSetMock: &blocksStoreSetMock{mockedResponses: []any{
+					map[BlocksStoreClient][]ulid.ULID{primary: {block1}},
+				}},
+			}
+			if testData.hedgingAvailable {
+				stores.hedgingClient = hedge
+			}
+
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{{ID: block1}}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+			reg := prometheus.NewPedanticRegistry()
+			hedging, err := newSeriesHedging(StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: 50 * time.Millisecond}, reg)
+			require.NoError(t, err)
+
+			q := &blocksStoreQuerier{
+				minT:        minT,
+				maxT:        maxT,
+				finder:      finder,
+				stores:      stores,
+				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(reg),
+				limits:      &blocksStoreLimitsMock{},
+				hedging:     hedging,
+
+				storeGatewayConsistencyCheckMaxAttempts: 3,
+			}
+
+			queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+			begin := time.Now()
+
+			set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+			require.True(t, set.Next())
+			it := set.At().Iterator(nil)
+			require.Equal(t, chunkenc.ValFloat, it.Next())
+			_, v := it.At()
+			assert.Equal(t, testData.expectedValue, v)
+			require.False(t, set.Next())
+			require.NoError(t, set.Err())
+
+			// The query never waits for the slowest replica.
+			assert.Less(t, time.Since(begin), 5*time.Second)
+
+			assert.Equal(t, testData.expectedHedged, queryStats.LoadStoreGatewayHedgedRequests())
+			assert.Equal(t, testData.expectedHedgedWon, queryStats.LoadStoreGatewayHedgedRequestsWon())
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_querier_storegateway_hedged_requests_total Total number of series requests sent to another store-gateway replica because the first one didn't start responding within the hedging delay.
+				# TYPE cortex_querier_storegateway_hedged_requests_total counter
+				cortex_querier_storegateway_hedged_requests_total `+strconv.FormatUint(testData.expectedHedged, 10)+`
+				# HELP cortex_querier_storegateway_hedged_requests_won_total Total number of hedged series requests whose store-gateway replica started responding first.
+				# TYPE cortex_querier_storegateway_hedged_requests_won_total counter
+				cortex_querier_storegateway_hedged_requests_won_total `+strconv.FormatUint(testData.expectedHedgedWon, 10)+`
+			`), "cortex_querier_storegateway_hedged_requests_total", "cortex_querier_storegateway_hedged_requests_won_total"))
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_SelectWithHedgingShouldExcludeAttemptedStoreGateways(t *testing.T) {
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	series1 := labels.FromStrings(labels.MetricName, metricName, "series", "1")
+	series2 := labels.FromStrings(labels.MetricName, metricName, "series", "2")
+
+	// The first store-gateway misses a block, which is retried on a slow store-gateway
+	// and then hedged to another replica.
+	first := &storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+		mockSeriesResponse(series1, []cortexpb.Sample{{Value: 1, TimestampMs: minT}}, nil, nil),
+		mockHintsResponse(block1),
+	}}
+	slow := &delayedStoreGatewayClientMock{storeGatewayClientMock: &storeGatewayClientMock{remoteAddr: "2.2.2.2"}, delay: 10 * time.Second}
+	hedge := &storeGatewayClientMock{remoteAddr: "3.3.3.3", mockedSeriesResponses: []*storepb.SeriesResponse{
+		mockSeriesResponse(series2, []cortexpb.Sample{{Value: 2, TimestampMs: minT}}, nil, nil),
+		mockHintsResponse(block2),
+	}}
+
+	stores := &hedgingBlocksStoreSetMock{
+		blocksStoreSetMock: &blocksStoreSetMock{mockedResponses: []any{
+			map[BlocksStoreClient][]ulid.ULID{first: {block1, block2}},
+			map[BlocksStoreClient][]ulid.ULID{slow: {block2}},
+		}},
+		hedgingClient: hedge,
+	}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{{ID: block1}, {ID: block2}}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	reg := prometheus.NewPedanticRegistry()
+	hedging, err := newSeriesHedging(StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: 50 * time.Millisecond}, reg)
+	require.NoError(t, err)
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(reg),
+		limits:      &blocksStoreLimitsMock{},
+		hedging:     hedging,
+
+		storeGatewayConsistencyCheckMaxAttempts: 3,
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+	for set.Next() {
+	}
+	require.NoError(t, set.Err())
+
+	require.Len(t, stores.hedgingExcludes, 1)
+	assert.ElementsMatch(t, []string{"2.2.2.2", "1.1.1.1"}, stores.hedgingExcludes[0])
+}
+
+func TestBlocksStoreQuerier_SelectWithHedgingShouldNotRetryFailedHedgingStoreGateway(t *testing.T) {
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	block1 := ulid.MustNew(1, nil)
+	series1 := labels.FromStrings(labels.MetricName, metricName, "series", "1")
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	// Both the slow store-gateway and the hedging replica fail, so the block is retried.
+	slow := &delayedStoreGatewayClientMock{storeGatewayClientMock: &storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesErr: unavailable}, delay: 200 * time.Millisecond}
+	hedge := &storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedSeriesErr: unavailable}
+	retry := &storeGatewayClientMock{remoteAddr: "3.3.3.3", mockedSeriesResponses: []*storepb.SeriesResponse{
+		mockSeriesResponse(series1, []cortexpb.Sample{{Value: 1, TimestampMs: minT}}, nil, nil),
+		mockHintsResponse(block1),
+	}}
+
+	stores := &hedgingBlocksStoreSetMock{
+		blocksStoreSetMock: &blocksStoreSetMock{mockedResponses: []any{
+			map[BlocksStoreClient][]ulid.ULID{slow: {block1}},
+			map[BlocksStoreClient][]ulid.ULID{retry: {block1}},
+		}},
+		hedgingClient: hedge,
+	}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{{ID: block1}}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	reg := prometheus.NewPedanticRegistry()
+	hedging, err := newSeriesHedging(StoreGatewayHedgedRequestConfig{Enabled: true, Quantile: 0.9, MinDelay: 50 * time.Millisecond}, reg)
+	require.NoError(t, err)
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(reg),
+		limits:      &blocksStoreLimitsMock{},
+		hedging:     hedging,
+
+		storeGatewayConsistencyCheckMaxAttempts: 3,
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	set := q.Select(ctx, true, nil, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName))
+	require.True(t, set.Next())
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+
+	// The retry excludes both the store-gateway and the failed hedging replica.
+	require.Len(t, stores.clientsExcludes, 2)
+	assert.ElementsMatch(t, []string{"1.1.1.1", "2.2.2.2"}, stores.clientsExcludes[1][block1])
+}
+
+type hedgingBlocksStoreSetMock struct {
+	*blocksStoreSetMock
+
+	hedgingClient   BlocksStoreClient
+	hedgingExcludes [][]string
+	clientsExcludes []map[ulid.ULID][]string
+}
+
+func (m *hedgingBlocksStoreSetMock) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, attemptedBlocksZones map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error) {
+	m.clientsExcludes = append(m.clientsExcludes, maps.Clone(exclude))
+	return m.blocksStoreSetMock.GetClientsFor(userID, blockIDs, exclude, attemptedBlocksZones)
+}
+
+func (m *hedgingBlocksStoreSetMock) GetHedgingClientFor(_ string, _ []ulid.ULID, exclude []string) (BlocksStoreClient, error) {
+	m.hedgingExcludes = append(m.hedgingExcludes, exclude)
+	if m.hedgingClient == nil {
+		return nil, errNoStoreGatewayHedgingReplica
+	}
+	return m.hedgingClient, nil
+}
+
+// delayedStoreGatewayClientMock is a storeGatewayClientMock which waits before responding to series requests.
+type delayedStoreGatewayClientMock struct {
+	*storeGatewayClientMock
+
+	delay time.Duration
+}
+
+func (m *delayedStoreGatewayClientMock) Series(ctx context.Context, in *storepb.SeriesRequest, opts ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return m.storeGatewayClientMock.Series(ctx, in, opts...)
+}
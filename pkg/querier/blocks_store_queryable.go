@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -146,6 +147,9 @@ type BlocksStoreQueryable struct {
 	storeGatewayConsistencyCheckMaxAttempts int
 	storeGatewaySeriesBatchSize             int64
 
+	// Tracks the latency of the store-gateways to hedge the series requests. Nil if hedging is disabled.
+	hedging *seriesHedging
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -179,6 +183,13 @@ func NewBlocksStoreQueryable(
 		storeGatewaySeriesBatchSize:             config.StoreGatewaySeriesBatchSize,
 	}
 
+	if config.StoreGatewayHedgedRequest.Enabled {
+		q.hedging, err = newSeriesHedging(config.StoreGatewayHedgedRequest, reg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	q.Service = services.NewBasicService(q.starting, q.running, q.stopping)
 
 	return q, nil
@@ -308,6 +319,7 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		storeGatewayQueryStatsEnabled:           q.storeGatewayQueryStatsEnabled,
 		storeGatewayConsistencyCheckMaxAttempts: q.storeGatewayConsistencyCheckMaxAttempts,
 		storeGatewaySeriesBatchSize:             q.storeGatewaySeriesBatchSize,
+		hedging:                                 q.hedging,
 		nowFn:                                   time.Now,
 	}, nil
 }
@@ -331,6 +343,9 @@ type blocksStoreQuerier struct {
 	// The maximum number of series to be batched in a single gRPC response message from Store Gateways.
 	storeGatewaySeriesBatchSize int64
 
+	// Tracks the latency of the store-gateways to hedge the series requests. Nil if hedging is disabled.
+	hedging *seriesHedging
+
 	nowFn func() time.Time
 }
 
@@ -362,7 +377,7 @@ func (q *blocksStoreQuerier) LabelNames(ctx context.Context, hints *storage.Labe
 		convertedMatchers = convertMatchersToLabelMatcher(matchers)
 	)
 
-	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error, error) {
+	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, _ map[ulid.ULID][]string, minT, maxT int64) ([]ulid.ULID, error, error) {
 		nameSets, warnings, queriedBlocks, err, retryableError := q.fetchLabelNamesFromStore(spanCtx, userID, clients, minT, maxT, limit, convertedMatchers)
 		if err != nil {
 			return nil, err, retryableError
@@ -405,7 +420,7 @@ func (q *blocksStoreQuerier) LabelValues(ctx context.Context, name string, hints
 		resultMtx sync.Mutex
 	)
 
-	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error, error) {
+	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, _ map[ulid.ULID][]string, minT, maxT int64) ([]ulid.ULID, error, error) {
 		valueSets, warnings, queriedBlocks, err, retryableError := q.fetchLabelValuesFromStore(spanCtx, userID, name, clients, minT, maxT, limit, matchers...)
 		if err != nil {
 			return nil, err, retryableError
@@ -454,8 +469,8 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 		resultMtx sync.Mutex
 	)
 
-	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error, error) {
-		seriesSets, queriedBlocks, warnings, numChunks, err, retryableError := q.fetchSeriesFromStores(spanCtx, sp, userID, clients, minT, maxT, limit, matchers, maxChunksLimit, leftChunksLimit)
+	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, attemptedBlocks map[ulid.ULID][]string, minT, maxT int64) ([]ulid.ULID, error, error) {
+		seriesSets, queriedBlocks, warnings, numChunks, err, retryableError := q.fetchSeriesFromStores(spanCtx, sp, userID, clients, attemptedBlocks, minT, maxT, limit, matchers, maxChunksLimit, leftChunksLimit)
 		if err != nil {
 			return nil, err, retryableError
 		}
@@ -484,12 +499,41 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 	}
 
 	return series.NewSeriesSetWithWarnings(
-		storage.NewMergeSeriesSet(resSeriesSets, int(limit), storage.ChainedSeriesMerge),
+		storage.NewMergeSeriesSet(resSeriesSets, int(limit), series.ChainedSeriesMerge),
 		resWarnings)
 }
 
+// removeParquetOnlyBlocks removes the parquet-only blocks from the input blocks. It returns the
+// parquet-only blocks whose source blocks are not all in the input blocks, because their data is
+// not available in any block which can be queried from store-gateways.
+func removeParquetOnlyBlocks(blocks bucketindex.Blocks) (bucketindex.Blocks, []ulid.ULID) {
+	known := make(map[ulid.ULID]struct{}, len(blocks))
+	for _, b := range blocks {
+		known[b.ID] = struct{}{}
+	}
+
+	var uncovered []ulid.ULID
+	blocks = slices.DeleteFunc(blocks, func(b *bucketindex.Block) bool {
+		if !b.ParquetOnly {
+			return false
+		}
+		covered := len(b.ParquetSources) > 0
+		for _, id := range b.ParquetSources {
+			if _, ok := known[id]; !ok {
+				covered = false
+				break
+			}
+		}
+		if !covered {
+			uncovered = append(uncovered, b.ID)
+		}
+		return true
+	})
+	return blocks, uncovered
+}
+
 func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, matchers []*labels.Matcher,
-	userID string, queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error, error)) error {
+	userID string, queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, attemptedBlocks map[ulid.ULID][]string, minT, maxT int64) ([]ulid.ULID, error, error)) error {
 	queryStoreAfter := q.limits.QueryStoreAfter(userID)
 	// If queryStoreAfter is enabled, we do manipulate the query maxt to query samples up until
 	// now - queryStoreAfter, because the most recent time range is covered by ingesters. This
@@ -522,6 +566,14 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		return err
 	}
 
+	// Parquet-only blocks have no TSDB index and chunks, so they can't be queried from
+	// store-gateways. They're queried by the parquet queryable instead, so we fail the
+	// query if they're not covered by their source blocks.
+	knownBlocks, uncoveredBlocks := removeParquetOnlyBlocks(knownBlocks)
+	if len(uncoveredBlocks) > 0 {
+		return fmt.Errorf("some blocks are only available in parquet format and can't be queried from store-gateways: %s", strings.Join(convertULIDsToString(uncoveredBlocks), " "))
+	}
+
 	if len(knownBlocks) == 0 {
 		q.metrics.storesHit.Observe(0)
 		level.Debug(logger).Log("msg", "no blocks found")
@@ -561,7 +613,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 
 		// Fetch series from stores. If an error occur we do not retry because retries
 		// are only meant to cover missing blocks.
-		queriedBlocks, err, retryableError = queryFunc(clients, minT, maxT)
+		queriedBlocks, err, retryableError = queryFunc(clients, attemptedBlocks, minT, maxT)
 		if err != nil {
 			return err
 		}
@@ -610,6 +662,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 	sp *storage.SelectHints,
 	userID string,
 	clients map[BlocksStoreClient][]ulid.ULID,
+	attemptedBlocks map[ulid.ULID][]string,
 	minT int64,
 	maxT int64,
 	limit int64,
@@ -640,7 +693,16 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 
 	// Concurrently fetch series from all clients.
 	for c, blockIDs := range clients {
-		// Change variables scope since it will be used in a goroutine.
+		// The requests are never hedged to the store-gateways already attempted for the blocks. The
+		// attempted blocks are updated by the requests already started, so they're read under lock.
+		var exclude []string
+		if q.hedging != nil {
+			mtx.Lock()
+			for _, blockID := range blockIDs {
+				exclude = append(exclude, attemptedBlocks[blockID]...)
+			}
+			mtx.Unlock()
+		}
 
 		g.Go(func() error {
 			// See: https://github.com/prometheus/prometheus/pull/8050
@@ -659,7 +721,18 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			}
 
 			begin := time.Now()
-			stream, err := c.Series(gCtx, req)
+			stream, c, hedgingClient, cancelStream, err := q.openSeriesStream(gCtx, userID, c, blockIDs, exclude, req)
+			defer cancelStream()
+
+			// The blocks are attempted on the primary store-gateway by the caller, while the hedging
+			// replica is tracked here, even if it failed, so that it's not picked again on retry.
+			if hedgingClient != nil {
+				mtx.Lock()
+				for _, blockID := range blockIDs {
+					attemptedBlocks[blockID] = append(attemptedBlocks[blockID], hedgingClient.RemoteAddress())
+				}
+				mtx.Unlock()
+			}
 			if err != nil {
 				if isRetryableError(err) {
 					level.Warn(spanLog).Log("err", errors.Wrapf(err, "failed to fetch series from %s due to retryable error", c.RemoteAddress()))
@@ -840,7 +913,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			// Store the result.
 			mtx.Lock()
 			// TODO: change other aggregations when downsampling is enabled.
-			seriesSets = append(seriesSets, thanosquery.NewPromSeriesSet(newStoreSeriesSet(mySeries), minT, maxT, defaultAggrs, nil))
+			seriesSets = append(seriesSets, newChunkBytesSeriesSet(thanosquery.NewPromSeriesSet(newStoreSeriesSet(mySeries), minT, maxT, defaultAggrs, nil), mySeries))
 			warnings.Merge(myWarnings)
 			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
 			mtx.Unlock()
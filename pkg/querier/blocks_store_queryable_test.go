@@ -1793,6 +1793,55 @@ func TestOverrideBlockDiscovery(t *testing.T) {
 	require.NoError(t, ss.Err())
 }
 
+func TestRemoveParquetOnlyBlocks(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	block4 := ulid.MustNew(4, nil)
+
+	tests := map[string]struct {
+		blocks            bucketindex.Blocks
+		expectedBlocks    bucketindex.Blocks
+		expectedUncovered []ulid.ULID
+	}{
+		"no parquet-only blocks": {
+			blocks:         bucketindex.Blocks{{ID: block1}, {ID: block2}},
+			expectedBlocks: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+		},
+		"parquet-only block covered by its source blocks": {
+			blocks:         bucketindex.Blocks{{ID: block1}, {ID: block2}, {ID: block3, ParquetOnly: true, ParquetSources: []ulid.ULID{block1, block2}}},
+			expectedBlocks: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+		},
+		"parquet-only block covered by another parquet-only block and a source block": {
+			blocks: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+				{ID: block3, ParquetOnly: true, ParquetSources: []ulid.ULID{block1, block2}},
+				{ID: block4, ParquetOnly: true, ParquetSources: []ulid.ULID{block3}},
+			},
+			expectedBlocks: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+		},
+		"parquet-only block with deleted source blocks": {
+			blocks:            bucketindex.Blocks{{ID: block2}, {ID: block3, ParquetOnly: true, ParquetSources: []ulid.ULID{block1, block2}}},
+			expectedBlocks:    bucketindex.Blocks{{ID: block2}},
+			expectedUncovered: []ulid.ULID{block3},
+		},
+		"parquet-only block with unknown source blocks": {
+			blocks:            bucketindex.Blocks{{ID: block1}, {ID: block3, ParquetOnly: true}},
+			expectedBlocks:    bucketindex.Blocks{{ID: block1}},
+			expectedUncovered: []ulid.ULID{block3},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			blocks, uncovered := removeParquetOnlyBlocks(tc.blocks)
+			assert.Equal(t, tc.expectedBlocks, blocks)
+			assert.Equal(t, tc.expectedUncovered, uncovered)
+		})
+	}
+}
+
 func TestBlocksStoreQuerier_Labels(t *testing.T) {
 	t.Parallel()
 
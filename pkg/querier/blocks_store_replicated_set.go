@@ -158,6 +158,57 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 	return clients, nil
 }
 
+// GetHedgingClientFor implements hedgingBlocksStoreSet.
+func (s *blocksStoreReplicationSet) GetHedgingClientFor(userID string, blockIDs []ulid.ULID, exclude []string) (BlocksStoreClient, error) {
+	var userRing ring.ReadRing
+	if s.shardingStrategy == util.ShardingStrategyShuffle {
+		userRing = storegateway.GetShuffleShardingSubring(s.storesRing, userID, s.limits, s.zoneStableShuffleSharding)
+	} else {
+		userRing = s.storesRing
+	}
+
+	// Find the store-gateway instances owning all the blocks.
+	var candidates []string
+	for i, blockID := range blockIDs {
+		bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+
+		set, err := userRing.Get(cortex_tsdb.HashBlockID(blockID), storegateway.BlocksRead, bufDescs, bufHosts, bufZones)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get store-gateway replication set owning the block %s", blockID.String())
+		}
+
+		addrs := set.GetAddresses()
+		if i == 0 {
+			candidates = slices.DeleteFunc(addrs, func(addr string) bool {
+				return slices.Contains(exclude, addr)
+			})
+		} else {
+			candidates = slices.DeleteFunc(candidates, func(addr string) bool {
+				return !slices.Contains(addrs, addr)
+			})
+		}
+
+		if len(candidates) == 0 {
+			return nil, errNoStoreGatewayHedgingReplica
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errNoStoreGatewayHedgingReplica
+	}
+
+	addr := candidates[0]
+	if s.balancingStrategy == randomLoadBalancing {
+		addr = candidates[rand.Intn(len(candidates))]
+	}
+
+	c, err := s.clientsPool.GetClientFor(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get store-gateway client for %s", addr)
+	}
+
+	return c.(BlocksStoreClient), nil
+}
+
 func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy, zoneAwarenessEnabled bool, attemptedZones map[string]int) ring.InstanceDesc {
 	if balancingStrategy == randomLoadBalancing {
 		// Randomize the list of instances to not always query the same one.
@@ -758,3 +758,81 @@ func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[s
 	}
 	return addrs
 }
+
+func TestBlocksStoreReplicationSet_GetHedgingClientFor(t *testing.T) {
+	t.Parallel()
+
+	block1 := ulid.MustNew(4, nil) // hash: 122298081
+	block2 := ulid.MustNew(1, nil) // hash: 283204220
+	block3 := ulid.MustNew(2, nil) // hash: 444110359
+	block4 := ulid.MustNew(12, nil)
+
+	registeredAt := time.Now()
+
+	tests := map[string]struct {
+		queryBlocks  []ulid.ULID
+		exclude      []string
+		expectedAddr string
+		expectedErr  error
+	}{
+		"should return the other replica owning all the blocks": {
+			queryBlocks:  []ulid.ULID{block1, block2},
+			exclude:      []string{"127.0.0.1"},
+			expectedAddr: "127.0.0.2",
+		},
+		"should fail if the replica owning all the blocks is excluded": {
+			queryBlocks: []ulid.ULID{block1, block2},
+			exclude:     []string{"127.0.0.2"},
+			expectedErr: errNoStoreGatewayHedgingReplica,
+		},
+		"should fail if no replica owns all the blocks": {
+			queryBlocks: []ulid.ULID{block1, block3},
+			expectedErr: errNoStoreGatewayHedgingReplica,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			require.NoError(t, ringStore.CAS(ctx, "test", func(in any) (any, bool, error) {
+				d := ring.NewDesc()
+				d.AddIngester("instance-1", "127.0.0.1", "", []uint32{cortex_tsdb.HashBlockID(block1) + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-2", "127.0.0.2", "", []uint32{cortex_tsdb.HashBlockID(block2) + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-3", "127.0.0.3", "", []uint32{cortex_tsdb.HashBlockID(block3) + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-4", "127.0.0.4", "", []uint32{cortex_tsdb.HashBlockID(block4) + 1}, ring.ACTIVE, registeredAt)
+				return d, true, nil
+			}))
+
+			ringCfg := ring.Config{}
+			flagext.DefaultValues(&ringCfg)
+			ringCfg.ReplicationFactor = 2
+			ringCfg.HeartbeatTimeout = time.Hour
+
+			r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, nil)
+			require.NoError(t, err)
+
+			s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, noLoadBalancing, &blocksStoreLimitsMock{}, ClientConfig{}, log.NewNopLogger(), nil, false, false)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+			defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+			// Wait until the ring client has initialised the state.
+			test.Poll(t, time.Second, true, func() any {
+				all, err := r.GetAllHealthy(ring.Read)
+				return err == nil && len(all.Instances) > 0
+			})
+
+			c, err := s.GetHedgingClientFor("user-1", testData.queryBlocks, testData.exclude)
+			require.Equal(t, testData.expectedErr, err)
+			if testData.expectedErr == nil {
+				assert.Equal(t, testData.expectedAddr, c.RemoteAddress())
+			}
+		})
+	}
+}
@@ -193,12 +193,17 @@ func (q *distributorQuerier) streamingSelect(ctx context.Context, sortSeries, pa
 			return storage.ErrSeriesSet(err)
 		}
 
-		serieses = append(serieses, &storage.SeriesEntry{
+		chunkBytes := 0
+		for _, c := range result.Chunks {
+			chunkBytes += len(c.Data)
+		}
+
+		serieses = append(serieses, series.NewChunkBytesSeries(&storage.SeriesEntry{
 			Lset: ls,
 			SampleIteratorFn: func(it chunkenc.Iterator) chunkenc.Iterator {
 				return q.chunkIterFn(it, chunks, model.Time(minT), model.Time(maxT))
 			},
-		})
+		}, chunkBytes))
 	}
 
 	if len(serieses) == 0 {
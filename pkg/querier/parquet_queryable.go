@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
 	"github.com/opentracing/opentracing-go"
 	"github.com/parquet-go/parquet-go"
 	"github.com/pkg/errors"
@@ -625,11 +626,28 @@ func (q *parquetQuerierWithFallback) getBlocks(ctx context.Context, minT, maxT i
 		return nil, nil, err
 	}
 
+	// The source blocks of a parquet-only block may not have been deleted once compacted. The
+	// parquet-only block supersedes them, so they're not queried.
+	superseded := map[ulid.ULID]struct{}{}
+	for _, b := range blocks {
+		if b.ParquetOnly && b.Parquet != nil {
+			for _, id := range b.ParquetSources {
+				superseded[id] = struct{}{}
+			}
+		}
+	}
+
 	useParquet := getBlockStoreType(ctx, q.defaultBlockStoreType) == parquetBlockStore
 	parquetBlocks := make([]*bucketindex.Block, 0, len(blocks))
 	remaining := make([]*bucketindex.Block, 0, len(blocks))
 	for _, b := range blocks {
-		if useParquet && b.Parquet != nil {
+		if _, ok := superseded[b.ID]; ok {
+			continue
+		}
+		// Parquet-only blocks can't be queried from store-gateways, so they're always queried in Parquet format.
+		// If their parquet files are unknown, they're left to the blocks store querier, which fails the query
+		// unless their source blocks are queried instead.
+		if (useParquet || b.ParquetOnly) && b.Parquet != nil {
 			parquetBlocks = append(parquetBlocks, b)
 			continue
 		}
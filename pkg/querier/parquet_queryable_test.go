@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"strconv"
 	"sync"
@@ -16,10 +17,13 @@ import (
 	"github.com/prometheus-community/parquet-common/schema"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
 	"github.com/prometheus/prometheus/util/annotations"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -284,6 +288,90 @@ func TestParquetQueryableFallbackLogic(t *testing.T) {
 		})
 	})
 
+	t.Run("should always query parquet-only blocks in parquet format", func(t *testing.T) {
+		finder := &blocksFinderMock{}
+		stores := createStore()
+
+		q := &blocksStoreQuerier{
+			minT:        minT,
+			maxT:        maxT,
+			finder:      finder,
+			stores:      stores,
+			consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+			logger:      log.NewNopLogger(),
+			metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+			limits:      &blocksStoreLimitsMock{},
+
+			storeGatewayConsistencyCheckMaxAttempts: 3,
+		}
+
+		mParquetQuerier := &mockParquetQuerier{}
+		pq := &parquetQuerierWithFallback{
+			minT:                  minT,
+			maxT:                  maxT,
+			finder:                finder,
+			blocksStoreQuerier:    q,
+			parquetQuerier:        mParquetQuerier,
+			metrics:               newParquetQueryableFallbackMetrics(prometheus.NewRegistry()),
+			limits:                defaultOverrides(t, 0),
+			logger:                log.NewNopLogger(),
+			defaultBlockStoreType: tsdbBlockStore,
+		}
+
+		finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
+			&bucketindex.Block{ID: block1, ParquetOnly: true, Parquet: &parquet.ConverterMarkMeta{Version: parquet.ParquetConverterMarkVersion2}},
+			&bucketindex.Block{ID: block2, Parquet: &parquet.ConverterMarkMeta{Version: parquet.ParquetConverterMarkVersion2}},
+		}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+		ss := pq.Select(ctx, true, nil, matchers...)
+		require.NoError(t, ss.Err())
+		require.Len(t, stores.queriedBlocks, 1)
+		require.Len(t, mParquetQuerier.queriedBlocks, 1)
+		require.Equal(t, block1, mParquetQuerier.queriedBlocks[0].ID)
+	})
+
+	t.Run("should not query the source blocks superseded by parquet-only blocks", func(t *testing.T) {
+		finder := &blocksFinderMock{}
+		stores := createStore()
+
+		q := &blocksStoreQuerier{
+			minT:        minT,
+			maxT:        maxT,
+			finder:      finder,
+			stores:      stores,
+			consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+			logger:      log.NewNopLogger(),
+			metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+			limits:      &blocksStoreLimitsMock{},
+
+			storeGatewayConsistencyCheckMaxAttempts: 3,
+		}
+
+		mParquetQuerier := &mockParquetQuerier{}
+		pq := &parquetQuerierWithFallback{
+			minT:                  minT,
+			maxT:                  maxT,
+			finder:                finder,
+			blocksStoreQuerier:    q,
+			parquetQuerier:        mParquetQuerier,
+			metrics:               newParquetQueryableFallbackMetrics(prometheus.NewRegistry()),
+			limits:                defaultOverrides(t, 0),
+			logger:                log.NewNopLogger(),
+			defaultBlockStoreType: tsdbBlockStore,
+		}
+
+		finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(bucketindex.Blocks{
+			&bucketindex.Block{ID: block1, ParquetOnly: true, ParquetSources: []ulid.ULID{block2}, Parquet: &parquet.ConverterMarkMeta{Version: parquet.ParquetConverterMarkVersion2}},
+			&bucketindex.Block{ID: block2, Parquet: &parquet.ConverterMarkMeta{Version: parquet.ParquetConverterMarkVersion2}},
+		}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+		ss := pq.Select(ctx, true, nil, matchers...)
+		require.NoError(t, ss.Err())
+		require.Len(t, stores.queriedBlocks, 0)
+		require.Len(t, mParquetQuerier.queriedBlocks, 1)
+		require.Equal(t, block1, mParquetQuerier.queriedBlocks[0].ID)
+	})
+
 	t.Run("Default query TSDB block store even if parquet blocks available. Override with ctx", func(t *testing.T) {
 		finder := &blocksFinderMock{}
 		stores := createStore()
@@ -555,6 +643,192 @@ func TestParquetQueryable_Limits(t *testing.T) {
 	}
 }
 
+func TestParquetQueryable_ShouldReturnTheSameSamplesAsTSDB(t *testing.T) {
+	t.Parallel()
+
+	const (
+		metricName = "test_metric"
+		minT       = int64(0)
+		maxT       = int64(1000)
+	)
+
+	appendFloats := func(from, to int64) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for ts := from; ts < to; ts += 10 {
+				_, err := app.Append(0, lbls, ts, float64(ts))
+				require.NoError(t, err)
+			}
+		}
+	}
+	appendHistograms := func(from, to int64) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for ts := from; ts < to; ts += 10 {
+				_, err := app.AppendHistogram(0, lbls, ts, tsdbutil.GenerateTestHistogram(ts), nil)
+				require.NoError(t, err)
+			}
+		}
+	}
+	appendFloatHistograms := func(from, to int64) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for ts := from; ts < to; ts += 10 {
+				_, err := app.AppendHistogram(0, lbls, ts, nil, tsdbutil.GenerateTestFloatHistogram(ts))
+				require.NoError(t, err)
+			}
+		}
+	}
+
+	tests := map[string]struct {
+		appendInOrder    []func(*testing.T, storage.Appender, labels.Labels)
+		appendOutOfOrder []func(*testing.T, storage.Appender, labels.Labels)
+	}{
+		"native histograms": {
+			appendInOrder: []func(*testing.T, storage.Appender, labels.Labels){appendHistograms(minT, maxT)},
+		},
+		"float native histograms": {
+			appendInOrder: []func(*testing.T, storage.Appender, labels.Labels){appendFloatHistograms(minT, maxT)},
+		},
+		"series switching between float and native histogram samples": {
+			appendInOrder: []func(*testing.T, storage.Appender, labels.Labels){appendFloats(0, 300), appendHistograms(300, 600), appendFloats(600, 800), appendFloatHistograms(800, 1000)},
+		},
+		"overlapping out-of-order block": {
+			appendInOrder:    []func(*testing.T, storage.Appender, labels.Labels){appendFloats(minT, maxT)},
+			appendOutOfOrder: []func(*testing.T, storage.Appender, labels.Labels){appendFloats(minT+5, maxT)},
+		},
+		"overlapping out-of-order block of native histograms": {
+			appendInOrder:    []func(*testing.T, storage.Appender, labels.Labels){appendHistograms(minT, maxT)},
+			appendOutOfOrder: []func(*testing.T, storage.Appender, labels.Labels){appendHistograms(minT+5, maxT)},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			bkt, tempDir := cortex_testutil.PrepareFilesystemBucket(t)
+			storageCfg := cortex_tsdb.BlocksStorageConfig{
+				Bucket: bucket.Config{
+					Backend:    "filesystem",
+					Filesystem: filesystem.Config{Directory: tempDir},
+				},
+			}
+			config := Config{
+				ParquetShardCache:                 parquetutil.CacheConfig{ParquetShardCacheSize: 100},
+				ParquetQueryableDefaultBlockStore: "parquet",
+			}
+
+			// Write the in-order samples to a block and, if any, compact the out-of-order ones to another block.
+			opts := tsdb.DefaultOptions()
+			opts.OutOfOrderTimeWindow = time.Hour.Milliseconds()
+			db, err := tsdb.Open(t.TempDir(), promslog.NewNopLogger(), nil, opts, nil)
+			require.NoError(t, err)
+			lbls := labels.FromStrings(labels.MetricName, metricName)
+			for _, appendSamples := range [][]func(*testing.T, storage.Appender, labels.Labels){testData.appendInOrder, testData.appendOutOfOrder} {
+				app := db.Appender(context.Background())
+				for _, fn := range appendSamples {
+					fn(t, app, lbls)
+				}
+				require.NoError(t, app.Commit())
+			}
+			if len(testData.appendOutOfOrder) > 0 {
+				require.NoError(t, db.CompactOOOHead(context.Background()))
+			}
+			blocksDir := t.TempDir()
+			require.NoError(t, db.Snapshot(blocksDir, true))
+			require.NoError(t, db.Close())
+
+			ctx := context.Background()
+			userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+			var blocks bucketindex.Blocks
+			entries, err := os.ReadDir(blocksDir)
+			require.NoError(t, err)
+			for _, entry := range entries {
+				blockID, err := ulid.Parse(entry.Name())
+				if err != nil {
+					continue
+				}
+				blockDir := filepath.Join(blocksDir, entry.Name())
+				require.NoError(t, convertBlockToParquet(t, ctx, userBkt, blockID, blockDir))
+				blocks = append(blocks, &bucketindex.Block{ID: blockID, Parquet: &parquet.ConverterMarkMeta{Version: parquet.CurrentVersion}})
+			}
+			if len(testData.appendOutOfOrder) > 0 {
+				require.Len(t, blocks, 2)
+			}
+
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT, mock.Anything).Return(blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+			mockBlocksStoreQueryable := &BlocksStoreQueryable{finder: finder, Service: services.NewIdleService(func(_ context.Context) error {
+				return nil
+			}, func(_ error) error {
+				return nil
+			})}
+
+			parquetQueryable, err := NewParquetQueryable(config, storageCfg, defaultOverrides(t, 0), mockBlocksStoreQueryable, log.NewNopLogger(), prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, parquetQueryable.(*parquetQueryableWithFallback)))
+			t.Cleanup(func() {
+				require.NoError(t, services.StopAndAwaitTerminated(ctx, parquetQueryable.(*parquetQueryableWithFallback)))
+			})
+
+			// Query the same blocks through TSDB.
+			require.NoError(t, os.Mkdir(filepath.Join(blocksDir, "wal"), os.ModePerm))
+			tsdbDB, err := tsdb.OpenDBReadOnly(blocksDir, "", promslog.NewNopLogger())
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, tsdbDB.Close()) })
+
+			matcher := labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName)
+			hints := &storage.SelectHints{Start: minT, End: maxT}
+
+			tsdbQuerier, err := tsdbDB.Querier(minT, maxT)
+			require.NoError(t, err)
+			defer tsdbQuerier.Close()
+			expected := readSamplesFromSeriesSet(t, tsdbQuerier.Select(ctx, true, hints, matcher))
+			require.Len(t, expected, 1)
+
+			parquetQuerier, err := parquetQueryable.Querier(minT, maxT)
+			require.NoError(t, err)
+			defer parquetQuerier.Close()
+			actual := readSamplesFromSeriesSet(t, parquetQuerier.Select(user.InjectOrgID(ctx, "user-1"), true, hints, matcher))
+
+			require.Equal(t, expected, actual)
+		})
+	}
+}
+
+type querierHistogramSample struct {
+	t  int64
+	f  float64
+	h  *histogram.Histogram
+	fh *histogram.FloatHistogram
+}
+
+func readSamplesFromSeriesSet(t *testing.T, set storage.SeriesSet) map[string][]querierHistogramSample {
+	result := map[string][]querierHistogramSample{}
+	for set.Next() {
+		s := set.At()
+		it := s.Iterator(nil)
+		var samples []querierHistogramSample
+		for valType := it.Next(); valType != chunkenc.ValNone; valType = it.Next() {
+			sample := querierHistogramSample{t: it.AtT()}
+			switch valType {
+			case chunkenc.ValFloat:
+				_, sample.f = it.At()
+			case chunkenc.ValHistogram:
+				_, sample.h = it.AtHistogram(nil)
+				// The counter reset hint depends on how the samples have been split in chunks.
+				sample.h.CounterResetHint = histogram.UnknownCounterReset
+			case chunkenc.ValFloatHistogram:
+				_, sample.fh = it.AtFloatHistogram(nil)
+				sample.fh.CounterResetHint = histogram.UnknownCounterReset
+			}
+			samples = append(samples, sample)
+		}
+		require.NoError(t, it.Err())
+		result[s.Labels().String()] = samples
+	}
+	require.NoError(t, set.Err())
+	return result
+}
+
 // convertBlockToParquet converts a TSDB block to parquet and uploads it to the bucket
 func convertBlockToParquet(t *testing.T, ctx context.Context, userBucketClient objstore.Bucket, blockID ulid.ULID, blockDir string) error {
 	tsdbBlock, err := tsdb.OpenBlock(nil, blockDir, chunkenc.NewPool(), tsdb.DefaultPostingsDecoderFactory)
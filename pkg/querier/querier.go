@@ -27,6 +27,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/batch"
 	"github.com/cortexproject/cortex/pkg/querier/lazyquery"
 	"github.com/cortexproject/cortex/pkg/querier/partialdata"
+	"github.com/cortexproject/cortex/pkg/querier/series"
 	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -84,11 +85,20 @@ type Config struct {
 	// The maximum number of series to be batched in a single gRPC response message from Store Gateways.
 	StoreGatewaySeriesBatchSize int64 `yaml:"store_gateway_series_batch_size"`
 
+	// Hedging of the series requests sent to Store Gateways.
+	StoreGatewayHedgedRequest StoreGatewayHedgedRequestConfig `yaml:"store_gateway_hedged_request"`
+
 	// The maximum number of times we attempt fetching data from Ingesters.
 	IngesterQueryMaxAttempts int `yaml:"ingester_query_max_attempts"`
 
 	ThanosEngine engine.ThanosEngineConfig `yaml:"thanos_engine"`
 
+	// Alternate engine configuration, used to compare the results of queries executed by the query-frontend in shadow mode.
+	ShadowEngine engine.ShadowEngineConfig `yaml:"shadow_engine"`
+
+	// Action taken when a query exceeds the per-tenant max query memory limit.
+	QueryMemoryLimit engine.MemoryLimitConfig `yaml:"query_memory_limit"`
+
 	// Ignore max query length check at Querier.
 	IgnoreMaxQueryLength              bool `yaml:"ignore_max_query_length"`
 	EnablePromQLExperimentalFunctions bool `yaml:"enable_promql_experimental_functions"`
@@ -99,7 +109,8 @@ type Config struct {
 	ParquetQueryableDefaultBlockStore string                  `yaml:"parquet_queryable_default_block_store"`
 	ParquetQueryableFallbackDisabled  bool                    `yaml:"parquet_queryable_fallback_disabled"`
 
-	DistributedExecEnabled bool `yaml:"distributed_exec_enabled" doc:"hidden"`
+	DistributedExecEnabled             bool `yaml:"distributed_exec_enabled" doc:"hidden"`
+	DistributedExecAggregationPartials int  `yaml:"distributed_exec_aggregation_partials" doc:"hidden"`
 
 	HonorProjectionHints bool `yaml:"honor_projection_hints"`
 
@@ -129,6 +140,8 @@ var (
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.ThanosEngine.RegisterFlagsWithPrefix("querier.", f)
+	cfg.ShadowEngine.RegisterFlagsWithPrefix("querier.shadow-engine.", f)
+	cfg.QueryMemoryLimit.RegisterFlagsWithPrefix("querier.query-memory-limit.", f)
 
 	//lint:ignore faillint Need to pass the global logger like this for warning on deprecated methods
 	flagext.DeprecatedFlag(f, "querier.ingester-streaming", "Deprecated: Use streaming RPCs to query ingester. QueryStream is always enabled and the flag is not effective anymore.", util_log.Logger)
@@ -140,6 +153,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	flagext.DeprecatedFlag(f, "querier.query-store-for-labels-enabled", "Deprecated: Querying long-term store is always enabled.", util_log.Logger)
 
 	cfg.StoreGatewayClient.RegisterFlagsWithPrefix("querier.store-gateway-client", f)
+	cfg.StoreGatewayHedgedRequest.RegisterFlagsWithPrefix("querier.store-gateway-hedged-request.", f)
 	f.IntVar(&cfg.MaxConcurrent, "querier.max-concurrent", 20, "The maximum number of concurrent queries.")
 	f.DurationVar(&cfg.Timeout, "querier.timeout", 2*time.Minute, "The timeout for a query.")
 	f.BoolVar(&cfg.IngesterMetadataStreaming, "querier.ingester-metadata-streaming", true, "Deprecated (This feature will be always on after v1.18): Use streaming RPCs for metadata APIs from ingester.")
@@ -164,6 +178,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.ParquetQueryableDefaultBlockStore, "querier.parquet-queryable-default-block-store", string(parquetBlockStore), "[Experimental] Parquet queryable's default block store to query. Valid options are tsdb and parquet. If it is set to tsdb, parquet queryable always fallback to store gateway.")
 	f.BoolVar(&cfg.HonorProjectionHints, "querier.honor-projection-hints", false, "[Experimental] If true, querier will honor projection hints and only materialize requested labels. Today, projection is only effective when Parquet Queryable is enabled. Projection is only applied when not querying mixed block types (parquet and non-parquet) and not querying ingesters.")
 	f.BoolVar(&cfg.DistributedExecEnabled, "querier.distributed-exec-enabled", false, "Experimental: Enables distributed execution of queries by passing logical query plan fragments to downstream components.")
+	f.IntVar(&cfg.DistributedExecAggregationPartials, "querier.distributed-exec-aggregation-partials", 0, "Experimental: Number of partial aggregates computed by different queriers for each sum, count, min, max, topk and bottomk aggregation when distributed execution is enabled. The partials are merged by the querier executing the parent fragment. 0 or 1 to not distribute aggregations.")
 	f.BoolVar(&cfg.ParquetQueryableFallbackDisabled, "querier.parquet-queryable-fallback-disabled", false, "[Experimental] Disable Parquet queryable to fallback queries to Store Gateway if the block is not available as Parquet files but available in TSDB. Setting this to true will disable the fallback and users can remove Store Gateway. But need to make sure Parquet files are created before it is queryable.")
 	f.BoolVar(&cfg.TimeoutClassificationEnabled, "querier.timeout-classification-enabled", false, "If true, classify query timeouts as 4XX (user error) or 5XX (system error) based on phase timing.")
 	f.DurationVar(&cfg.TimeoutClassificationDeadline, "querier.timeout-classification-deadline", time.Minute+59*time.Second, "The total time before the querier proactively cancels a query for timeout classification. Set this a few seconds less than the querier timeout.")
@@ -190,6 +205,10 @@ func (cfg *Config) Validate(monitoredResources flagext.StringSliceCSV) error {
 		return errInvalidIngesterQueryMaxAttempts
 	}
 
+	if err := cfg.StoreGatewayHedgedRequest.Validate(); err != nil {
+		return err
+	}
+
 	if cfg.EnableParquetQueryable {
 		if !slices.Contains(validBlockStoreTypes, blockStoreType(cfg.ParquetQueryableDefaultBlockStore)) {
 			return errInvalidParquetQueryableDefaultBlockStore
@@ -215,6 +234,14 @@ func (cfg *Config) Validate(monitoredResources flagext.StringSliceCSV) error {
 		return err
 	}
 
+	if err := cfg.ShadowEngine.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.QueryMemoryLimit.Validate(); err != nil {
+		return err
+	}
+
 	if err := cfg.QueryProtection.Validate(monitoredResources); err != nil {
 		return err
 	}
@@ -317,12 +344,19 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 			return cfg.DefaultEvaluationInterval.Milliseconds()
 		},
 	}
-	queryEngine := engine.New(opts, cfg.ThanosEngine, reg)
+	var queryEngine *engine.Engine
+	if cfg.ShadowEngine.Enabled {
+		queryEngine = engine.NewWithShadow(opts, cfg.ThanosEngine, cfg.ShadowEngine.ThanosEngine, reg)
+	} else {
+		queryEngine = engine.New(opts, cfg.ThanosEngine, reg)
+	}
+
+	// Account the memory retained by each query, enforcing the per-tenant max query memory limit.
+	var eng engine.QueryEngine = engine.NewMemoryLimitedEngine(queryEngine, cfg.QueryMemoryLimit, limits.MaxQueryMemoryBytes, reg)
 
 	// Wrap the engine with eviction support if the registry was created.
-	var eng engine.QueryEngine = queryEngine
 	if queryRegistry != nil {
-		eng = queryeviction.NewResourceEvictingEngine(queryEngine, queryRegistry)
+		eng = queryeviction.NewResourceEvictingEngine(eng, queryRegistry)
 	}
 
 	// Return the evictor as a service so the caller can manage its lifecycle.
@@ -519,7 +553,7 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 	// For series queries without specifying the start time, we prefer to
 	// only query ingesters and not to query maxQueryLength to avoid OOM kill.
 	if getSeries && startMs == 0 {
-		return metadataQuerier.Select(ctx, true, sp, matchers...)
+		return newRetentionRulesSeriesSet(metadataQuerier.Select(ctx, true, sp, matchers...), q.limits.RetentionRules(userID), q.now, endMs)
 	}
 
 	startTime := model.Time(startMs)
@@ -545,7 +579,7 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 	}
 
 	if len(queriers) == 1 {
-		return queriers[0].Select(ctx, sortSeries, sp, matchers...)
+		return newRetentionRulesSeriesSet(queriers[0].Select(ctx, sortSeries, sp, matchers...), q.limits.RetentionRules(userID), q.now, endMs)
 	}
 
 	sets := make(chan storage.SeriesSet, len(queriers))
@@ -566,7 +600,7 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 		}
 	}
 
-	return storage.NewMergeSeriesSet(result, 0, storage.ChainedSeriesMerge)
+	return newRetentionRulesSeriesSet(storage.NewMergeSeriesSet(result, 0, series.ChainedSeriesMerge), q.limits.RetentionRules(userID), q.now, endMs)
 }
 
 // LabelValues implements storage.Querier.
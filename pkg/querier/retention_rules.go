@@ -0,0 +1,89 @@
+package querier
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	querier_series "github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// retentionRulesSeriesSet filters out the samples expired according to the retention rules, which
+// may still be stored in blocks not compacted since they expired. Series are only removed if their
+// retention period covers the whole queried time range, as their samples are not read to find out
+// whether any is left: series requests select the series without reading their samples.
+type retentionRulesSeriesSet struct {
+	storage.SeriesSet
+
+	rules validation.RetentionRulesConfig
+	now   time.Time
+	maxt  int64
+
+	curr storage.Series
+}
+
+func newRetentionRulesSeriesSet(set storage.SeriesSet, rules validation.RetentionRulesConfig, now time.Time, maxt int64) storage.SeriesSet {
+	if len(rules) == 0 {
+		return set
+	}
+	return &retentionRulesSeriesSet{SeriesSet: set, rules: rules, now: now, maxt: maxt}
+}
+
+func (s *retentionRulesSeriesSet) Next() bool {
+	for s.SeriesSet.Next() {
+		series := s.SeriesSet.At()
+
+		period := s.rules.RetentionPeriod(series.Labels())
+		if period == 0 {
+			s.curr = series
+			return true
+		}
+
+		// Samples at the retention boundary are dropped, like the compactor does.
+		mint := s.now.Add(-period).UnixMilli() + 1
+		if mint > s.maxt {
+			continue
+		}
+		s.curr = querier_series.WithChunkBytesOf(&retentionRulesSeries{Series: series, mint: mint}, series)
+		return true
+	}
+	return false
+}
+
+func (s *retentionRulesSeriesSet) At() storage.Series {
+	return s.curr
+}
+
+// retentionRulesSeries is a series whose samples before mint are filtered out.
+type retentionRulesSeries struct {
+	storage.Series
+
+	mint int64
+}
+
+func (s *retentionRulesSeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	return &minTimeIterator{Iterator: s.Series.Iterator(it), mint: s.mint}
+}
+
+// minTimeIterator skips the samples of the wrapped iterator before mint.
+type minTimeIterator struct {
+	chunkenc.Iterator
+
+	mint    int64
+	started bool
+}
+
+func (it *minTimeIterator) Next() chunkenc.ValueType {
+	if !it.started {
+		it.started = true
+		return it.Iterator.Seek(it.mint)
+	}
+	return it.Iterator.Next()
+}
+
+func (it *minTimeIterator) Seek(t int64) chunkenc.ValueType {
+	it.started = true
+	return it.Iterator.Seek(max(t, it.mint))
+}
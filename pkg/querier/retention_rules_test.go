@@ -0,0 +1,95 @@
+package querier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/querier/series"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestRetentionRulesSeriesSet(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rules := validation.RetentionRulesConfig{
+		{Matchers: `{__name__="debug_metric"}`, Period: model.Duration(100 * time.Second)},
+		{Matchers: `{__name__="old_metric"}`, Period: model.Duration(500 * time.Second)},
+	}
+	require.NoError(t, rules.Validate())
+
+	samples := func(ts ...int64) []model.SamplePair {
+		res := make([]model.SamplePair, 0, len(ts))
+		for _, t := range ts {
+			res = append(res, model.SamplePair{Timestamp: model.Time(t * 1000), Value: 1})
+		}
+		return res
+	}
+
+	tests := map[string]struct {
+		maxt     int64
+		expected map[string][]int64
+	}{
+		"should filter out the expired samples of the matching series": {
+			maxt: now.UnixMilli(),
+			expected: map[string][]int64{
+				`{__name__="debug_metric"}`: {950, 1000},
+				`{__name__="old_metric"}`:   {600, 800, 950, 1000},
+				`{__name__="slo_metric"}`:   {200, 600, 800, 950, 1000},
+			},
+		},
+		"should remove the matching series if all the queried samples are expired": {
+			maxt: 700 * 1000,
+			expected: map[string][]int64{
+				`{__name__="old_metric"}`: {600, 800, 950, 1000},
+				`{__name__="slo_metric"}`: {200, 600, 800, 950, 1000},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			set := series.NewConcreteSeriesSet(true, []storage.Series{
+				series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "debug_metric"), samples(200, 600, 800, 950, 1000)),
+				series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "old_metric"), samples(200, 600, 800, 950, 1000)),
+				series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "slo_metric"), samples(200, 600, 800, 950, 1000)),
+			})
+
+			actual := map[string][]int64{}
+			filtered := newRetentionRulesSeriesSet(set, rules, now, testData.maxt)
+			for filtered.Next() {
+				var ts []int64
+				it := filtered.At().Iterator(nil)
+				for it.Next() != chunkenc.ValNone {
+					t, _ := it.At()
+					ts = append(ts, t/1000)
+				}
+				require.NoError(t, it.Err())
+				actual[filtered.At().Labels().String()] = ts
+			}
+			require.NoError(t, filtered.Err())
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestRetentionRulesSeries_Seek(t *testing.T) {
+	s := &retentionRulesSeries{
+		Series: series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "debug_metric"), []model.SamplePair{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}, {Timestamp: 3, Value: 3}}),
+		mint:   2,
+	}
+
+	it := s.Iterator(nil)
+	require.Equal(t, chunkenc.ValFloat, it.Seek(0))
+	ts, _ := it.At()
+	require.Equal(t, int64(2), ts)
+	require.Equal(t, chunkenc.ValFloat, it.Next())
+	ts, _ = it.At()
+	require.Equal(t, int64(3), ts)
+	require.Equal(t, chunkenc.ValNone, it.Next())
+}
@@ -237,3 +237,53 @@ func (s seriesSetWithWarnings) Warnings() annotations.Annotations {
 	w := s.wrapped.Warnings()
 	return w.Merge(s.warnings)
 }
+
+// ChunkBytesSeries is a series backed by encoded chunks, whose size is known without decoding them.
+type ChunkBytesSeries interface {
+	storage.Series
+
+	// ChunkBytes returns the total size of the encoded chunks of the series.
+	ChunkBytes() int
+}
+
+type chunkBytesSeries struct {
+	storage.Series
+	chunkBytes int
+}
+
+func (s *chunkBytesSeries) ChunkBytes() int {
+	return s.chunkBytes
+}
+
+// NewChunkBytesSeries annotates the series with the size of its encoded chunks.
+func NewChunkBytesSeries(s storage.Series, chunkBytes int) ChunkBytesSeries {
+	return &chunkBytesSeries{Series: s, chunkBytes: chunkBytes}
+}
+
+// WithChunkBytesOf annotates the series with the size of the chunks of the original series, if known.
+// It's used by series wrapping another one without changing its chunks.
+func WithChunkBytesOf(s, original storage.Series) storage.Series {
+	if o, ok := original.(ChunkBytesSeries); ok {
+		return NewChunkBytesSeries(s, o.ChunkBytes())
+	}
+	return s
+}
+
+// ChainedSeriesMerge works like storage.ChainedSeriesMerge, and keeps the total size of the chunks of the
+// merged series when it's known for all of them.
+func ChainedSeriesMerge(series ...storage.Series) storage.Series {
+	merged := storage.ChainedSeriesMerge(series...)
+	if merged == nil {
+		return nil
+	}
+
+	chunkBytes := 0
+	for _, s := range series {
+		cs, ok := s.(ChunkBytesSeries)
+		if !ok {
+			return merged
+		}
+		chunkBytes += cs.ChunkBytes()
+	}
+	return NewChunkBytesSeries(merged, chunkBytes)
+}
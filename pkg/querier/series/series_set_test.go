@@ -48,3 +48,25 @@ func TestMatrixToSeriesSetSortsMetricLabels(t *testing.T) {
 	l := ss.At().Labels()
 	require.Equal(t, labels.FromStrings(labels.MetricName, "testmetric", "a", "b", "c", "d", "e", "f", "g", "h"), l)
 }
+
+func TestChainedSeriesMerge(t *testing.T) {
+	t.Parallel()
+	lset := labels.FromStrings("foo", "bar")
+	series1 := &ConcreteSeries{labels: lset, samples: []model.SamplePair{{Value: 1, Timestamp: 2}}}
+	series2 := &ConcreteSeries{labels: lset, samples: []model.SamplePair{{Value: 3, Timestamp: 4}}}
+
+	// The size of the chunks is kept when known for all the merged series.
+	merged := ChainedSeriesMerge(NewChunkBytesSeries(series1, 10), NewChunkBytesSeries(series2, 20))
+	require.Equal(t, lset, merged.Labels())
+	cs, ok := merged.(ChunkBytesSeries)
+	require.True(t, ok)
+	require.Equal(t, 30, cs.ChunkBytes())
+
+	samples, err := storage.ExpandSamples(merged.Iterator(nil), nil)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+
+	merged = ChainedSeriesMerge(NewChunkBytesSeries(series1, 10), series2)
+	_, ok = merged.(ChunkBytesSeries)
+	require.False(t, ok)
+}
@@ -547,6 +547,38 @@ func (s *QueryStats) LoadStoreGatewayTouchedPostingBytes() uint64 {
 	return atomic.LoadUint64(&s.StoreGatewayTouchedPostingBytes)
 }
 
+func (s *QueryStats) AddStoreGatewayHedgedRequests(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.StoreGatewayHedgedRequests, count)
+}
+
+func (s *QueryStats) LoadStoreGatewayHedgedRequests() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.StoreGatewayHedgedRequests)
+}
+
+func (s *QueryStats) AddStoreGatewayHedgedRequestsWon(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.StoreGatewayHedgedRequestsWon, count)
+}
+
+func (s *QueryStats) LoadStoreGatewayHedgedRequestsWon() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.StoreGatewayHedgedRequestsWon)
+}
+
 func (s *QueryStats) AddScannedSamples(count uint64) {
 	if s == nil {
 		return
@@ -602,6 +634,8 @@ func (s *QueryStats) Merge(other *QueryStats) {
 	s.AddFetchedChunks(other.LoadFetchedChunks())
 	s.AddStoreGatewayTouchedPostings(other.LoadStoreGatewayTouchedPostings())
 	s.AddStoreGatewayTouchedPostingBytes(other.LoadStoreGatewayTouchedPostingBytes())
+	s.AddStoreGatewayHedgedRequests(other.LoadStoreGatewayHedgedRequests())
+	s.AddStoreGatewayHedgedRequestsWon(other.LoadStoreGatewayHedgedRequestsWon())
 	s.AddScannedSamples(other.LoadScannedSamples())
 	s.SetPeakSamples(max(s.LoadPeakSamples(), other.LoadPeakSamples()))
 	s.AddExtraFields(other.LoadExtraFields()...)
@@ -71,6 +71,12 @@ type Stats struct {
 	MaxEvalTime      time.Duration `protobuf:"bytes,16,opt,name=max_eval_time,json=maxEvalTime,proto3,stdduration" json:"max_eval_time"`
 	MaxQueueWaitTime time.Duration `protobuf:"bytes,17,opt,name=max_queue_wait_time,json=maxQueueWaitTime,proto3,stdduration" json:"max_queue_wait_time"`
 	MaxTotalTime     time.Duration `protobuf:"bytes,18,opt,name=max_total_time,json=maxTotalTime,proto3,stdduration" json:"max_total_time"`
+	// The number of hedged series requests sent to another store gateway replica
+	// because the first one didn't respond within the hedging delay.
+	StoreGatewayHedgedRequests uint64 `protobuf:"varint,19,opt,name=store_gateway_hedged_requests,json=storeGatewayHedgedRequests,proto3" json:"store_gateway_hedged_requests,omitempty"`
+	// The number of hedged series requests whose response has been used instead of
+	// the one of the first store gateway replica.
+	StoreGatewayHedgedRequestsWon uint64 `protobuf:"varint,20,opt,name=store_gateway_hedged_requests_won,json=storeGatewayHedgedRequestsWon,proto3" json:"store_gateway_hedged_requests_won,omitempty"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -231,6 +237,20 @@ func (m *Stats) GetMaxTotalTime() time.Duration {
 	return 0
 }
 
+func (m *Stats) GetStoreGatewayHedgedRequests() uint64 {
+	if m != nil {
+		return m.StoreGatewayHedgedRequests
+	}
+	return 0
+}
+
+func (m *Stats) GetStoreGatewayHedgedRequestsWon() uint64 {
+	if m != nil {
+		return m.StoreGatewayHedgedRequestsWon
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
 	proto.RegisterMapType((map[string]string)(nil), "stats.Stats.ExtraFieldsEntry")
@@ -239,48 +259,51 @@ func init() {
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 656 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x4d, 0x4f, 0x13, 0x4f,
-	0x18, 0xdf, 0x01, 0xca, 0x9f, 0x9d, 0x2d, 0x50, 0x96, 0xfe, 0xe3, 0x42, 0xe2, 0x50, 0xc4, 0xc4,
-	0x1e, 0xcc, 0x62, 0xf0, 0x62, 0x34, 0x31, 0xa4, 0xbc, 0x69, 0x62, 0x8c, 0xb4, 0x24, 0x24, 0x5c,
-	0x26, 0x43, 0x3b, 0x2c, 0x13, 0xf6, 0xa5, 0x74, 0x67, 0xa1, 0xbd, 0x19, 0x3f, 0x81, 0x47, 0x3f,
-	0x82, 0x1f, 0x85, 0x23, 0x47, 0x4e, 0x28, 0xcb, 0xc5, 0x23, 0x1f, 0xc1, 0xcc, 0x33, 0xb3, 0xa0,
-	0x24, 0x9a, 0x7a, 0xdb, 0x79, 0x7e, 0x2f, 0x99, 0xdf, 0xef, 0xc9, 0x0e, 0x76, 0x52, 0xc9, 0x64,
-	0xea, 0x77, 0x7b, 0x89, 0x4c, 0xdc, 0x12, 0x1c, 0xe6, 0xab, 0x41, 0x12, 0x24, 0x30, 0x59, 0x56,
-	0x5f, 0x1a, 0x9c, 0x27, 0x41, 0x92, 0x04, 0x21, 0x5f, 0x86, 0xd3, 0x7e, 0x76, 0xb0, 0xdc, 0xc9,
-	0x7a, 0x4c, 0x8a, 0x24, 0x36, 0xf8, 0xdc, 0x7d, 0x9c, 0xc5, 0x03, 0x0d, 0x3d, 0xfa, 0x64, 0xe3,
-	0x52, 0x4b, 0x59, 0xbb, 0xab, 0xd8, 0x3e, 0x65, 0x61, 0x48, 0xa5, 0x88, 0xb8, 0x87, 0x6a, 0xa8,
-	0xee, 0xac, 0xcc, 0xf9, 0x5a, 0xe8, 0x17, 0x42, 0x7f, 0xdd, 0x18, 0x37, 0x26, 0xce, 0x2e, 0x17,
-	0xac, 0x2f, 0xdf, 0x16, 0x50, 0x73, 0x42, 0xa9, 0x76, 0x44, 0xc4, 0xdd, 0x67, 0xb8, 0x7a, 0xc0,
-	0x65, 0xfb, 0x90, 0x77, 0x68, 0xca, 0x7b, 0x82, 0xa7, 0xb4, 0x9d, 0x64, 0xb1, 0xf4, 0x46, 0x6a,
-	0xa8, 0x3e, 0xd6, 0x74, 0x0d, 0xd6, 0x02, 0x68, 0x4d, 0x21, 0xae, 0x8f, 0x67, 0x0b, 0x45, 0xfb,
-	0x30, 0x8b, 0x8f, 0xe8, 0xfe, 0x40, 0xf2, 0xd4, 0x1b, 0x05, 0xc1, 0x8c, 0x81, 0xd6, 0x14, 0xd2,
-	0x50, 0x80, 0xfb, 0x14, 0x17, 0x2e, 0xb4, 0xc3, 0x24, 0x33, 0xf4, 0x31, 0xa0, 0x57, 0x0c, 0xb2,
-	0xce, 0x24, 0xd3, 0xec, 0x55, 0x5c, 0xe6, 0x7d, 0xd9, 0x63, 0xf4, 0x40, 0xf0, 0xb0, 0x93, 0x7a,
-	0xa5, 0xda, 0x68, 0xdd, 0x59, 0x79, 0xe8, 0xeb, 0x5e, 0x21, 0xb5, 0xbf, 0xa1, 0x08, 0x9b, 0x80,
-	0x6f, 0xc4, 0xb2, 0x37, 0x68, 0x3a, 0xfc, 0x6e, 0xf2, 0x6b, 0x22, 0xb8, 0x5f, 0x91, 0x68, 0xfc,
-	0xb7, 0x44, 0x70, 0x41, 0x93, 0x68, 0x05, 0xff, 0x7f, 0xdb, 0x01, 0x8b, 0xba, 0xe1, 0x6d, 0x09,
-	0xff, 0x81, 0xa4, 0x88, 0xdb, 0xd2, 0x98, 0xd6, 0x2c, 0x62, 0x3b, 0x14, 0x91, 0x90, 0xf4, 0x50,
-	0x48, 0x6f, 0xa2, 0x86, 0xea, 0x76, 0x63, 0xec, 0xec, 0x52, 0x55, 0x0b, 0xe3, 0x37, 0x42, 0xba,
-	0x4b, 0x78, 0x32, 0xed, 0x86, 0x42, 0xd2, 0xe3, 0x0c, 0xea, 0xf3, 0x6c, 0xb0, 0x2b, 0xc3, 0x70,
-	0x5b, 0xcf, 0xdc, 0x3d, 0xfc, 0x40, 0xc1, 0x03, 0x9a, 0xca, 0xa4, 0xc7, 0x02, 0x4e, 0xef, 0xf6,
-	0x89, 0x87, 0xdf, 0x67, 0x15, 0x3c, 0x5a, 0xda, 0x62, 0xb7, 0xd8, 0xed, 0x7b, 0xfc, 0x58, 0xb9,
-	0x72, 0x1a, 0x30, 0xc9, 0x4f, 0xd9, 0x80, 0xca, 0x24, 0x83, 0x94, 0xdd, 0x24, 0x95, 0x22, 0x0e,
-	0x8a, 0x98, 0x0e, 0xdc, 0xab, 0x06, 0xdc, 0x2d, 0x4d, 0xdd, 0xd1, 0xcc, 0x0f, 0x86, 0xa8, 0x33,
-	0xbf, 0xc3, 0x4b, 0x7f, 0xf5, 0x33, 0xab, 0x2d, 0x83, 0xdd, 0xc2, 0x9f, 0xed, 0xf4, 0xa6, 0x9f,
-	0xe0, 0xe9, 0xb4, 0xcd, 0xe2, 0xf8, 0xae, 0x75, 0x6f, 0x12, 0x94, 0x53, 0x66, 0x6c, 0xfa, 0x76,
-	0x17, 0x71, 0xb9, 0xcb, 0xd9, 0xd1, 0x2d, 0x6b, 0x0a, 0x58, 0x8e, 0x9a, 0x15, 0x94, 0xb7, 0x78,
-	0x2a, 0x62, 0x7d, 0x0a, 0x8b, 0xd2, 0xe5, 0x4d, 0x0f, 0x5f, 0x5e, 0x39, 0x62, 0xfd, 0x4d, 0xa5,
-	0x84, 0xd2, 0xb6, 0xf0, 0xa4, 0xb2, 0xe2, 0x27, 0xcc, 0xac, 0xa1, 0x32, 0xbc, 0x93, 0x13, 0xb1,
-	0xfe, 0xc6, 0x09, 0xd3, 0xed, 0x37, 0xf1, 0xac, 0x32, 0x3a, 0xce, 0x78, 0xa6, 0xb6, 0x2a, 0xa4,
-	0xb6, 0x9b, 0x19, 0xde, 0xae, 0x12, 0xb1, 0xfe, 0xb6, 0x92, 0xef, 0x32, 0x21, 0xc1, 0xd3, 0xe4,
-	0x94, 0x89, 0x2c, 0x6e, 0xe7, 0xfe, 0x5b, 0xce, 0x1d, 0xa5, 0x54, 0x56, 0xf3, 0xaf, 0x71, 0xe5,
-	0xfe, 0x7f, 0xe4, 0x56, 0xf0, 0xe8, 0x11, 0x1f, 0xc0, 0x43, 0x62, 0x37, 0xd5, 0xa7, 0x5b, 0xc5,
-	0xa5, 0x13, 0x16, 0x66, 0x1c, 0xde, 0x03, 0xbb, 0xa9, 0x0f, 0x2f, 0x47, 0x5e, 0xa0, 0xc6, 0xab,
-	0xf3, 0x2b, 0x62, 0x5d, 0x5c, 0x11, 0xeb, 0xe6, 0x8a, 0xa0, 0x8f, 0x39, 0x41, 0x5f, 0x73, 0x82,
-	0xce, 0x72, 0x82, 0xce, 0x73, 0x82, 0xbe, 0xe7, 0x04, 0xfd, 0xc8, 0x89, 0x75, 0x93, 0x13, 0xf4,
-	0xf9, 0x9a, 0x58, 0xe7, 0xd7, 0xc4, 0xba, 0xb8, 0x26, 0xd6, 0x9e, 0x7e, 0x12, 0xf7, 0xc7, 0xe1,
-	0x9e, 0xcf, 0x7f, 0x06, 0x00, 0x00, 0xff, 0xff, 0x5b, 0x42, 0x1f, 0xae, 0x2f, 0x05, 0x00, 0x00,
+	// 697 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x4d, 0x4f, 0xdb, 0x30,
+	0x18, 0x6e, 0x80, 0x32, 0xea, 0x16, 0x28, 0xa1, 0xd3, 0x42, 0x25, 0x4c, 0x19, 0x93, 0xd6, 0xc3,
+	0x14, 0x26, 0x76, 0x99, 0x36, 0x69, 0x62, 0xe5, 0x73, 0xd2, 0x34, 0x8d, 0x14, 0x09, 0x89, 0x8b,
+	0x65, 0x5a, 0x93, 0x46, 0x24, 0x71, 0x49, 0x1c, 0x68, 0x6f, 0xfb, 0x07, 0xdb, 0x71, 0x3f, 0x61,
+	0x3f, 0x85, 0x23, 0x47, 0x4e, 0x6c, 0x84, 0xcb, 0x8e, 0xfc, 0x84, 0xc9, 0xaf, 0x1d, 0xbe, 0x24,
+	0x50, 0x77, 0x8b, 0xdf, 0xe7, 0x43, 0x7e, 0x9e, 0x57, 0x31, 0x2a, 0xc6, 0x82, 0x8a, 0xd8, 0xee,
+	0x46, 0x5c, 0x70, 0x33, 0x0f, 0x87, 0x6a, 0xc5, 0xe5, 0x2e, 0x87, 0xc9, 0xa2, 0xfc, 0x52, 0x60,
+	0x15, 0xbb, 0x9c, 0xbb, 0x3e, 0x5b, 0x84, 0xd3, 0x5e, 0xb2, 0xbf, 0xd8, 0x4e, 0x22, 0x2a, 0x3c,
+	0x1e, 0x6a, 0x7c, 0xe6, 0x3e, 0x4e, 0xc3, 0xbe, 0x82, 0x9e, 0x7f, 0x47, 0x28, 0xdf, 0x94, 0xd6,
+	0xe6, 0x32, 0x2a, 0x1c, 0x53, 0xdf, 0x27, 0xc2, 0x0b, 0x98, 0x65, 0xd4, 0x8c, 0x7a, 0x71, 0x69,
+	0xc6, 0x56, 0x42, 0x3b, 0x13, 0xda, 0xab, 0xda, 0xb8, 0x31, 0x76, 0x72, 0x3e, 0x97, 0xfb, 0xf9,
+	0x7b, 0xce, 0x70, 0xc6, 0xa4, 0x6a, 0xdb, 0x0b, 0x98, 0xf9, 0x1a, 0x55, 0xf6, 0x99, 0x68, 0x75,
+	0x58, 0x9b, 0xc4, 0x2c, 0xf2, 0x58, 0x4c, 0x5a, 0x3c, 0x09, 0x85, 0x35, 0x54, 0x33, 0xea, 0x23,
+	0x8e, 0xa9, 0xb1, 0x26, 0x40, 0x2b, 0x12, 0x31, 0x6d, 0x34, 0x9d, 0x29, 0x5a, 0x9d, 0x24, 0x3c,
+	0x20, 0x7b, 0x7d, 0xc1, 0x62, 0x6b, 0x18, 0x04, 0x53, 0x1a, 0x5a, 0x91, 0x48, 0x43, 0x02, 0xe6,
+	0x2b, 0x94, 0xb9, 0x90, 0x36, 0x15, 0x54, 0xd3, 0x47, 0x80, 0x5e, 0xd6, 0xc8, 0x2a, 0x15, 0x54,
+	0xb1, 0x97, 0x51, 0x89, 0xf5, 0x44, 0x44, 0xc9, 0xbe, 0xc7, 0xfc, 0x76, 0x6c, 0xe5, 0x6b, 0xc3,
+	0xf5, 0xe2, 0xd2, 0xac, 0xad, 0x7a, 0x85, 0xd4, 0xf6, 0x9a, 0x24, 0xac, 0x03, 0xbe, 0x16, 0x8a,
+	0xa8, 0xef, 0x14, 0xd9, 0xcd, 0xe4, 0x76, 0x22, 0xb8, 0x5f, 0x96, 0x68, 0xf4, 0x4e, 0x22, 0xb8,
+	0xa0, 0x4e, 0xb4, 0x84, 0x9e, 0x5e, 0x77, 0x40, 0x83, 0xae, 0x7f, 0x5d, 0xc2, 0x13, 0x90, 0x64,
+	0x71, 0x9b, 0x0a, 0x53, 0x9a, 0x79, 0x54, 0xf0, 0xbd, 0xc0, 0x13, 0xa4, 0xe3, 0x09, 0x6b, 0xac,
+	0x66, 0xd4, 0x0b, 0x8d, 0x91, 0x93, 0x73, 0x59, 0x2d, 0x8c, 0x37, 0x3d, 0x61, 0x2e, 0xa0, 0xf1,
+	0xb8, 0xeb, 0x7b, 0x82, 0x1c, 0x26, 0x50, 0x9f, 0x55, 0x00, 0xbb, 0x12, 0x0c, 0xb7, 0xd4, 0xcc,
+	0xdc, 0x45, 0xcf, 0x24, 0xdc, 0x27, 0xb1, 0xe0, 0x11, 0x75, 0x19, 0xb9, 0xd9, 0x27, 0x1a, 0x7c,
+	0x9f, 0x15, 0xf0, 0x68, 0x2a, 0x8b, 0x9d, 0x6c, 0xb7, 0x5f, 0xd0, 0x0b, 0xe9, 0xca, 0x88, 0x4b,
+	0x05, 0x3b, 0xa6, 0x7d, 0x22, 0x78, 0x02, 0x29, 0xbb, 0x3c, 0x16, 0x5e, 0xe8, 0x66, 0x31, 0x8b,
+	0x70, 0xaf, 0x1a, 0x70, 0x37, 0x14, 0x75, 0x5b, 0x31, 0xbf, 0x6a, 0xa2, 0xca, 0xfc, 0x19, 0x2d,
+	0x3c, 0xea, 0xa7, 0x57, 0x5b, 0x02, 0xbb, 0xb9, 0x87, 0xed, 0xd4, 0xa6, 0x5f, 0xa2, 0xc9, 0xb8,
+	0x45, 0xc3, 0xf0, 0xa6, 0x75, 0x6b, 0x1c, 0x94, 0x13, 0x7a, 0xac, 0xfb, 0x36, 0xe7, 0x51, 0xa9,
+	0xcb, 0xe8, 0xc1, 0x35, 0x6b, 0x02, 0x58, 0x45, 0x39, 0xcb, 0x28, 0x9f, 0xd0, 0x44, 0x40, 0x7b,
+	0x04, 0x16, 0xa5, 0xca, 0x9b, 0x1c, 0xbc, 0xbc, 0x52, 0x40, 0x7b, 0xeb, 0x52, 0x09, 0xa5, 0x6d,
+	0xa0, 0x71, 0x69, 0xc5, 0x8e, 0xa8, 0x5e, 0x43, 0x79, 0x70, 0xa7, 0x62, 0x40, 0x7b, 0x6b, 0x47,
+	0x54, 0xb5, 0xef, 0xa0, 0x69, 0x69, 0x74, 0x98, 0xb0, 0x44, 0x6e, 0xd5, 0x13, 0xca, 0x6e, 0x6a,
+	0x70, 0xbb, 0x72, 0x40, 0x7b, 0x5b, 0x52, 0xbe, 0x43, 0x3d, 0x01, 0x9e, 0x3a, 0xa7, 0xe0, 0x22,
+	0xbb, 0x9d, 0xf9, 0x7f, 0x39, 0xb7, 0xa5, 0x12, 0xac, 0x3e, 0xa2, 0xd9, 0xbb, 0xcb, 0xec, 0xb0,
+	0xb6, 0xcb, 0xda, 0x24, 0x62, 0x87, 0x09, 0x8b, 0x45, 0x6c, 0x4d, 0x43, 0xcd, 0xd5, 0xdb, 0x6b,
+	0xdc, 0x04, 0x8a, 0xa3, 0x19, 0xe6, 0x26, 0x9a, 0x7f, 0xd4, 0x82, 0x1c, 0xf3, 0xd0, 0xaa, 0x80,
+	0xcd, 0xec, 0xc3, 0x36, 0x3b, 0x3c, 0xac, 0x7e, 0x40, 0xe5, 0xfb, 0x3f, 0xb5, 0x59, 0x46, 0xc3,
+	0x07, 0xac, 0x0f, 0xaf, 0x5a, 0xc1, 0x91, 0x9f, 0x66, 0x05, 0xe5, 0x8f, 0xa8, 0x9f, 0x30, 0x78,
+	0x9c, 0x0a, 0x8e, 0x3a, 0xbc, 0x1b, 0x7a, 0x6b, 0x34, 0xde, 0x9f, 0x5e, 0xe0, 0xdc, 0xd9, 0x05,
+	0xce, 0x5d, 0x5d, 0x60, 0xe3, 0x5b, 0x8a, 0x8d, 0x5f, 0x29, 0x36, 0x4e, 0x52, 0x6c, 0x9c, 0xa6,
+	0xd8, 0xf8, 0x93, 0x62, 0xe3, 0x6f, 0x8a, 0x73, 0x57, 0x29, 0x36, 0x7e, 0x5c, 0xe2, 0xdc, 0xe9,
+	0x25, 0xce, 0x9d, 0x5d, 0xe2, 0xdc, 0xae, 0x7a, 0x9f, 0xf7, 0x46, 0xa1, 0xb4, 0x37, 0xff, 0x06,
+	0x00, 0x77, 0x14, 0xbf, 0x1b, 0xbc, 0x05, 0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -361,13 +384,19 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.MaxTotalTime != that1.MaxTotalTime {
 		return false
 	}
+	if this.StoreGatewayHedgedRequests != that1.StoreGatewayHedgedRequests {
+		return false
+	}
+	if this.StoreGatewayHedgedRequestsWon != that1.StoreGatewayHedgedRequestsWon {
+		return false
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 22)
+	s := make([]string, 0, 24)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
@@ -399,6 +428,8 @@ func (this *Stats) GoString() string {
 	s = append(s, "MaxEvalTime: "+fmt.Sprintf("%#v", this.MaxEvalTime)+",\n")
 	s = append(s, "MaxQueueWaitTime: "+fmt.Sprintf("%#v", this.MaxQueueWaitTime)+",\n")
 	s = append(s, "MaxTotalTime: "+fmt.Sprintf("%#v", this.MaxTotalTime)+",\n")
+	s = append(s, "StoreGatewayHedgedRequests: "+fmt.Sprintf("%#v", this.StoreGatewayHedgedRequests)+",\n")
+	s = append(s, "StoreGatewayHedgedRequestsWon: "+fmt.Sprintf("%#v", this.StoreGatewayHedgedRequestsWon)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -430,6 +461,20 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.StoreGatewayHedgedRequestsWon != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.StoreGatewayHedgedRequestsWon))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa0
+	}
+	if m.StoreGatewayHedgedRequests != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.StoreGatewayHedgedRequests))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x98
+	}
 	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.MaxTotalTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.MaxTotalTime):])
 	if err1 != nil {
 		return 0, err1
@@ -634,6 +679,12 @@ func (m *Stats) Size() (n int) {
 	n += 2 + l + sovStats(uint64(l))
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.MaxTotalTime)
 	n += 2 + l + sovStats(uint64(l))
+	if m.StoreGatewayHedgedRequests != 0 {
+		n += 2 + sovStats(uint64(m.StoreGatewayHedgedRequests))
+	}
+	if m.StoreGatewayHedgedRequestsWon != 0 {
+		n += 2 + sovStats(uint64(m.StoreGatewayHedgedRequestsWon))
+	}
 	return n
 }
 
@@ -676,6 +727,8 @@ func (this *Stats) String() string {
 		`MaxEvalTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.MaxEvalTime), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
 		`MaxQueueWaitTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.MaxQueueWaitTime), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
 		`MaxTotalTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.MaxTotalTime), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
+		`StoreGatewayHedgedRequests:` + fmt.Sprintf("%v", this.StoreGatewayHedgedRequests) + `,`,
+		`StoreGatewayHedgedRequestsWon:` + fmt.Sprintf("%v", this.StoreGatewayHedgedRequestsWon) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1264,6 +1317,44 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 19:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreGatewayHedgedRequests", wireType)
+			}
+			m.StoreGatewayHedgedRequests = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreGatewayHedgedRequests |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreGatewayHedgedRequestsWon", wireType)
+			}
+			m.StoreGatewayHedgedRequestsWon = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreGatewayHedgedRequestsWon |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -19,6 +19,7 @@ func Middlewares(
 	lookbackDelta time.Duration,
 	defaultEvaluationInterval time.Duration,
 	distributedExecEnabled bool,
+	distributedAggregationPartials int,
 	localOptimizers []logicalplan.Optimizer,
 ) ([]tripperware.Middleware, error) {
 	m := []tripperware.Middleware{
@@ -29,7 +30,7 @@ func Middlewares(
 	if distributedExecEnabled {
 		m = append(m,
 			tripperware.DistributedQueryMiddleware(defaultEvaluationInterval, lookbackDelta,
-				append(localOptimizers, &distributed_execution.DistributedOptimizer{})))
+				append(localOptimizers, &distributed_execution.DistributedOptimizer{AggregationPartials: distributedAggregationPartials})))
 	}
 
 	return m, nil
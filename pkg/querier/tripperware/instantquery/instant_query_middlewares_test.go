@@ -63,6 +63,7 @@ func TestRoundTrip(t *testing.T) {
 		5*time.Minute,
 		time.Minute,
 		false,
+		0,
 		logicalplan.DefaultOptimizers,
 	)
 	require.NoError(t, err)
@@ -176,6 +177,7 @@ func TestRoundTripWithAndWithoutDistributedExec(t *testing.T) {
 				5*time.Minute,
 				time.Minute,
 				tc.distributedEnabled,
+				0,
 				logicalplan.DefaultOptimizers,
 			)
 			require.NoError(t, err)
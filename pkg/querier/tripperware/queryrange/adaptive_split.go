@@ -0,0 +1,331 @@
+package queryrange
+
+import (
+	"context"
+	"flag"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/querysharding"
+
+	cortexparser "github.com/cortexproject/cortex/pkg/parser"
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/util/users"
+)
+
+const (
+	// Weight given to the latest observation when updating the moving average of the query cost.
+	adaptiveSplitsObservationWeight = 0.3
+
+	adaptiveSplitDecisionDefault = "default"
+	adaptiveSplitDecisionCheap   = "cheap"
+	adaptiveSplitDecisionHeavy   = "heavy"
+)
+
+type AdaptiveQuerySplitsConfig struct {
+	Enabled                      bool          `yaml:"enabled"`
+	CheapQueryMaxFetchedSamples  int64         `yaml:"cheap_query_max_fetched_samples"`
+	TargetFetchedSamplesPerShard int64         `yaml:"target_fetched_samples_per_shard"`
+	MinSplitInterval             time.Duration `yaml:"min_split_interval"`
+	MaxTrackedQueries            int           `yaml:"max_tracked_queries"`
+	StatsTTL                     time.Duration `yaml:"stats_ttl"`
+}
+
+// RegisterFlags registers flags for adaptive query splits
+func (cfg *AdaptiveQuerySplitsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "querier.adaptive-query-splits.enabled", false, "[EXPERIMENTAL] Choose the split interval and vertical shard size of range queries based on the cost observed for previous executions of the same query and tenant. Requires query statistics to be enabled in the query-frontend.")
+	f.Int64Var(&cfg.CheapQueryMaxFetchedSamples, "querier.adaptive-query-splits.cheap-query-max-fetched-samples", 1000000, "[EXPERIMENTAL] Queries expected to fetch at most this number of samples are executed without splitting and vertical sharding.")
+	f.Int64Var(&cfg.TargetFetchedSamplesPerShard, "querier.adaptive-query-splits.target-fetched-samples-per-shard", 50000000, "[EXPERIMENTAL] Target number of samples fetched by each query shard. Queries expected to fetch more samples per shard are split by an interval smaller than the split interval, down to the minimum split interval.")
+	f.DurationVar(&cfg.MinSplitInterval, "querier.adaptive-query-splits.min-split-interval", time.Hour, "[EXPERIMENTAL] Minimum split interval used for heavy queries.")
+	f.IntVar(&cfg.MaxTrackedQueries, "querier.adaptive-query-splits.max-tracked-queries", 10000, "[EXPERIMENTAL] Maximum number of distinct queries for which execution statistics are kept. The same limit applies separately to the number of tenants for which statistics are kept. The least recently used entries are evicted first.")
+	f.DurationVar(&cfg.StatsTTL, "querier.adaptive-query-splits.stats-ttl", 24*time.Hour, "[EXPERIMENTAL] How long execution statistics of a query are kept after it was last executed.")
+}
+
+func (cfg *AdaptiveQuerySplitsConfig) Validate(splitInterval time.Duration) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if splitInterval <= 0 {
+		return errors.New("adaptive-query-splits requires that a value for split-queries-by-interval is set.")
+	}
+	if cfg.MinSplitInterval <= 0 || cfg.MinSplitInterval > splitInterval {
+		return errors.New("adaptive-query-splits.min-split-interval must be greater than 0 and not greater than split-queries-by-interval.")
+	}
+	if cfg.TargetFetchedSamplesPerShard <= 0 {
+		return errors.New("adaptive-query-splits.target-fetched-samples-per-shard must be greater than 0.")
+	}
+	if cfg.MaxTrackedQueries <= 0 {
+		return errors.New("adaptive-query-splits.max-tracked-queries must be greater than 0.")
+	}
+	return nil
+}
+
+// queryCostTracker keeps a moving average of the samples fetched per hour of query range, both per
+// tenant and per query fingerprint. The per-tenant average is used for queries which have not been
+// observed yet. Tenants and queries are tracked in separate caches, so that a large number of distinct
+// queries doesn't evict the per-tenant averages.
+type queryCostTracker struct {
+	mu      sync.Mutex
+	tenants *expirable.LRU[string, float64]
+	queries *expirable.LRU[string, float64]
+}
+
+func newQueryCostTracker(cfg AdaptiveQuerySplitsConfig) *queryCostTracker {
+	return &queryCostTracker{
+		tenants: expirable.NewLRU[string, float64](cfg.MaxTrackedQueries, nil, cfg.StatsTTL),
+		queries: expirable.NewLRU[string, float64](cfg.MaxTrackedQueries, nil, cfg.StatsTTL),
+	}
+}
+
+// estimate returns the expected number of fetched samples for the request, and whether there
+// are enough statistics to estimate it.
+func (t *queryCostTracker) estimate(tenantID, fingerprint string, r tripperware.Request) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cost, ok := t.queries.Get(queryCostKey(tenantID, fingerprint))
+	if !ok {
+		cost, ok = t.tenants.Get(tenantID)
+	}
+	if !ok {
+		return 0, false
+	}
+	return cost * queryRangeHours(r), true
+}
+
+// observe updates the statistics with the number of samples fetched while executing the given
+// number of hours of query range.
+func (t *queryCostTracker) observe(tenantID, fingerprint string, executedHours float64, fetchedSamples uint64) {
+	cost := float64(fetchedSamples) / executedHours
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	updateMovingAverage(t.queries, queryCostKey(tenantID, fingerprint), cost)
+	updateMovingAverage(t.tenants, tenantID, cost)
+}
+
+func updateMovingAverage(costs *expirable.LRU[string, float64], key string, cost float64) {
+	if prev, ok := costs.Get(key); ok {
+		costs.Add(key, adaptiveSplitsObservationWeight*cost+(1-adaptiveSplitsObservationWeight)*prev)
+	} else {
+		costs.Add(key, cost)
+	}
+}
+
+func queryCostKey(tenantID, fingerprint string) string {
+	return tenantID + ":" + fingerprint
+}
+
+// queryRangeHours returns the time range covered by the request in hours. The step is included so that
+// the range is never zero.
+func queryRangeHours(r tripperware.Request) float64 {
+	return time.Duration(queryRangeMillis(r) * int64(time.Millisecond)).Hours()
+}
+
+func queryRangeMillis(r tripperware.Request) int64 {
+	return r.GetEnd() - r.GetStart() + r.GetStep()
+}
+
+// queryFingerprint identifies the query regardless of its formatting and time range.
+func queryFingerprint(query string) (string, error) {
+	expr, err := cortexparser.ParseExpr(query)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(xxhash.Sum64String(expr.String()), 16), nil
+}
+
+type adaptiveQueryStateContextKey int
+
+const adaptiveQueryStateKey adaptiveQueryStateContextKey = 0
+
+// adaptiveQueryState is shared by the middlewares handling a range query. The fingerprint is computed
+// once when the query enters the adaptive split middleware, and the executed range is accumulated by the
+// requests which are not served by the results cache.
+type adaptiveQueryState struct {
+	fingerprint    string
+	executedMillis atomic.Int64
+}
+
+func newAdaptiveQueryState(query string) (*adaptiveQueryState, error) {
+	fingerprint, err := queryFingerprint(query)
+	if err != nil {
+		return nil, err
+	}
+	return &adaptiveQueryState{fingerprint: fingerprint}, nil
+}
+
+func (s *adaptiveQueryState) executedHours() float64 {
+	return time.Duration(s.executedMillis.Load() * int64(time.Millisecond)).Hours()
+}
+
+func contextWithAdaptiveQueryState(ctx context.Context, s *adaptiveQueryState) context.Context {
+	return context.WithValue(ctx, adaptiveQueryStateKey, s)
+}
+
+func adaptiveQueryStateFromContext(ctx context.Context) *adaptiveQueryState {
+	s, _ := ctx.Value(adaptiveQueryStateKey).(*adaptiveQueryState)
+	return s
+}
+
+type adaptiveQuerySplits struct {
+	cfg           Config
+	limits        tripperware.Limits
+	queryAnalyzer querysharding.Analyzer
+	tracker       *queryCostTracker
+
+	decisions *prometheus.CounterVec
+}
+
+func newAdaptiveQuerySplits(cfg Config, limits tripperware.Limits, queryAnalyzer querysharding.Analyzer, registerer prometheus.Registerer) *adaptiveQuerySplits {
+	return &adaptiveQuerySplits{
+		cfg:           cfg,
+		limits:        limits,
+		queryAnalyzer: queryAnalyzer,
+		tracker:       newQueryCostTracker(cfg.AdaptiveQuerySplitsConfig),
+		decisions: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "frontend_adaptive_query_split_decisions_total",
+			Help:      "Total number of range queries split by interval, partitioned by the decision taken by adaptive query splitting.",
+		}, []string{"decision"}),
+	}
+}
+
+// intervalFn wraps the given interval function, overriding its split interval and vertical shard size
+// for queries whose observed cost is far from the target cost per shard.
+func (a *adaptiveQuerySplits) intervalFn(next IntervalFn) IntervalFn {
+	return func(ctx context.Context, r tripperware.Request) (context.Context, time.Duration, error) {
+		ctx, interval, err := next(ctx, r)
+		if err != nil {
+			return ctx, interval, err
+		}
+
+		// The state is missing if the query couldn't be fingerprinted, keep the default split.
+		state := adaptiveQueryStateFromContext(ctx)
+		if state == nil {
+			return ctx, interval, nil
+		}
+
+		tenantID, err := users.TenantID(ctx)
+		if err != nil {
+			return ctx, interval, err
+		}
+
+		decision := adaptiveSplitDecisionDefault
+		estimate, ok := a.tracker.estimate(tenantID, state.fingerprint, r)
+
+		switch {
+		case !ok:
+			// No statistics yet, keep the default split.
+		case estimate <= float64(a.cfg.AdaptiveQuerySplitsConfig.CheapQueryMaxFetchedSamples):
+			decision = adaptiveSplitDecisionCheap
+			interval = getIntervalFromMaxSplits(r, a.cfg.SplitQueriesByInterval, 1)
+			ctx = tripperware.InjectVerticalShardSizeToContext(ctx, 1)
+		default:
+			verticalShardSize, isShardable, err := getMaxVerticalShardSize(ctx, r, a.limits, a.queryAnalyzer)
+			if err != nil {
+				return ctx, interval, err
+			}
+			if heavyInterval, isHeavy := a.heavyQueryInterval(r, interval, verticalShardSize, estimate); isHeavy {
+				decision = adaptiveSplitDecisionHeavy
+				interval = heavyInterval
+				if isShardable && verticalShardSize > 1 {
+					ctx = tripperware.InjectVerticalShardSizeToContext(ctx, verticalShardSize)
+				}
+			}
+		}
+
+		a.decisions.WithLabelValues(decision).Inc()
+		stats := querier_stats.FromContext(ctx)
+		stats.AddExtraFields("split_by_interval.adaptive_decision", decision)
+		if ok {
+			stats.AddExtraFields("split_by_interval.estimated_fetched_samples", int64(estimate))
+		}
+		return ctx, interval, nil
+	}
+}
+
+// heavyQueryInterval halves the split interval until each shard is expected to fetch at most the target
+// number of samples, without going below the minimum split interval, the query step or the max number of shards.
+func (a *adaptiveQuerySplits) heavyQueryInterval(r tripperware.Request, interval time.Duration, verticalShardSize int, estimate float64) (time.Duration, bool) {
+	cfg := a.cfg.AdaptiveQuerySplitsConfig
+	targetShards := int(math.Ceil(estimate / float64(cfg.TargetFetchedSamplesPerShard)))
+	maxShards := a.cfg.DynamicQuerySplitsConfig.MaxShardsPerQuery
+	step := time.Duration(r.GetStep()) * time.Millisecond
+
+	candidate := interval
+	for getExpectedTotalShards(r.GetStart(), r.GetEnd(), candidate, verticalShardSize) < targetShards {
+		next := candidate / 2
+		if next < cfg.MinSplitInterval || next < step {
+			break
+		}
+		if maxShards > 0 && getExpectedTotalShards(r.GetStart(), r.GetEnd(), next, verticalShardSize) > maxShards {
+			break
+		}
+		candidate = next
+	}
+
+	return candidate, candidate < interval
+}
+
+// Middleware fingerprints each request and records its cost once it has been executed by the next handlers.
+func (a *adaptiveQuerySplits) Middleware() tripperware.Middleware {
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+			state, err := newAdaptiveQueryState(r.GetQuery())
+			if err != nil {
+				// Let the next handlers report the invalid query.
+				return next.Do(ctx, r)
+			}
+
+			resp, err := next.Do(contextWithAdaptiveQueryState(ctx, state), r)
+			if err == nil {
+				a.observe(ctx, state)
+			}
+			return resp, err
+		})
+	})
+}
+
+// ExecutedRangeMiddleware accumulates the range of the requests reaching it. It is placed below the results
+// cache, so that the cost is only attributed to the part of the query which was actually executed.
+func (a *adaptiveQuerySplits) ExecutedRangeMiddleware() tripperware.Middleware {
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+			if state := adaptiveQueryStateFromContext(ctx); state != nil {
+				state.executedMillis.Add(queryRangeMillis(r))
+			}
+			return next.Do(ctx, r)
+		})
+	})
+}
+
+func (a *adaptiveQuerySplits) observe(ctx context.Context, state *adaptiveQueryState) {
+	// Statistics are only available when query stats are enabled in the frontend.
+	stats := querier_stats.FromContext(ctx)
+	if stats == nil {
+		return
+	}
+
+	// Nothing was executed when the whole query was served by the results cache.
+	executedHours := state.executedHours()
+	if executedHours <= 0 {
+		return
+	}
+
+	tenantID, err := users.TenantID(ctx)
+	if err != nil {
+		return
+	}
+	a.tracker.observe(tenantID, state.fingerprint, executedHours, stats.LoadFetchedSamples())
+}
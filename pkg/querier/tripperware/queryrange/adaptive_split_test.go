@@ -0,0 +1,242 @@
+package queryrange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/querysharding"
+	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func Test_adaptiveQuerySplits(t *testing.T) {
+	t.Parallel()
+
+	thirtyDayRequest := &tripperware.PrometheusRequest{
+		Start: 0,
+		End:   30*24*3600*seconds - 1,
+		Step:  5 * 60 * seconds,
+		Query: "sum(rate(up[5m])) by (cluster)",
+	}
+
+	for _, tc := range []struct {
+		name                      string
+		observedTenant            string
+		observedQuery             string
+		observedSamples           uint64
+		maxShardsPerQuery         int
+		expectedInterval          time.Duration
+		expectedVerticalShardSize int
+		expectedDecision          string
+	}{
+		{
+			name:             "no statistics, expect default split interval",
+			expectedInterval: day,
+			expectedDecision: adaptiveSplitDecisionDefault,
+		},
+		{
+			name:                      "cheap query, expect no splitting and no vertical sharding",
+			observedTenant:            "1",
+			observedQuery:             thirtyDayRequest.Query,
+			observedSamples:           100,
+			expectedInterval:          30 * day,
+			expectedVerticalShardSize: 1,
+			expectedDecision:          adaptiveSplitDecisionCheap,
+		},
+		{
+			name:                      "heavy query, expect split by 6 hours and max vertical shards",
+			observedTenant:            "1",
+			observedQuery:             thirtyDayRequest.Query,
+			observedSamples:           30 * 4 * 3 * 1000,
+			expectedInterval:          6 * time.Hour,
+			expectedVerticalShardSize: 3,
+			expectedDecision:          adaptiveSplitDecisionHeavy,
+		},
+		{
+			name:                      "heavy query limited by max shards, expect split by 12 hours",
+			observedTenant:            "1",
+			observedQuery:             thirtyDayRequest.Query,
+			observedSamples:           30 * 4 * 3 * 1000,
+			maxShardsPerQuery:         200,
+			expectedInterval:          12 * time.Hour,
+			expectedVerticalShardSize: 3,
+			expectedDecision:          adaptiveSplitDecisionHeavy,
+		},
+		{
+			name:                      "unknown query, expect tenant statistics to be used",
+			observedTenant:            "1",
+			observedQuery:             "sum(up)",
+			observedSamples:           100,
+			expectedInterval:          30 * day,
+			expectedVerticalShardSize: 1,
+			expectedDecision:          adaptiveSplitDecisionCheap,
+		},
+		{
+			name:             "statistics from another tenant, expect default split interval",
+			observedTenant:   "2",
+			observedQuery:    thirtyDayRequest.Query,
+			observedSamples:  100,
+			expectedInterval: day,
+			expectedDecision: adaptiveSplitDecisionDefault,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := Config{
+				SplitQueriesByInterval: day,
+				DynamicQuerySplitsConfig: DynamicQuerySplitsConfig{
+					MaxShardsPerQuery: tc.maxShardsPerQuery,
+				},
+				AdaptiveQuerySplitsConfig: AdaptiveQuerySplitsConfig{
+					Enabled:                      true,
+					CheapQueryMaxFetchedSamples:  1000,
+					TargetFetchedSamplesPerShard: 1000,
+					MinSplitInterval:             time.Hour,
+					MaxTrackedQueries:            10,
+					StatsTTL:                     time.Hour,
+				},
+			}
+			a := newAdaptiveQuerySplits(cfg, mockLimits{queryVerticalShardSize: 3}, querysharding.NewQueryAnalyzer(), prometheus.NewPedanticRegistry())
+
+			if tc.observedTenant != "" {
+				stats, ctx := querier_stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), tc.observedTenant))
+				stats.AddFetchedSamples(tc.observedSamples)
+				state, err := newAdaptiveQueryState(tc.observedQuery)
+				require.NoError(t, err)
+				state.executedMillis.Store(queryRangeMillis(thirtyDayRequest))
+				a.observe(ctx, state)
+			}
+
+			state, err := newAdaptiveQueryState(thirtyDayRequest.Query)
+			require.NoError(t, err)
+			stats, ctx := querier_stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "1"))
+			ctx = contextWithAdaptiveQueryState(ctx, state)
+			ctx, interval, err := a.intervalFn(staticIntervalFn(cfg))(ctx, thirtyDayRequest)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedInterval, interval)
+
+			verticalShardSize, ok := tripperware.VerticalShardSizeFromContext(ctx)
+			if tc.expectedVerticalShardSize > 0 {
+				require.True(t, ok)
+				require.Equal(t, tc.expectedVerticalShardSize, verticalShardSize)
+			} else {
+				require.False(t, ok)
+			}
+
+			extraFields := map[any]any{}
+			fields := stats.LoadExtraFields()
+			for i := 0; i+1 < len(fields); i += 2 {
+				extraFields[fields[i]] = fields[i+1]
+			}
+			require.Equal(t, tc.expectedDecision, extraFields["split_by_interval.adaptive_decision"])
+		})
+	}
+}
+
+func Test_adaptiveQuerySplits_Middleware(t *testing.T) {
+	t.Parallel()
+
+	request := &tripperware.PrometheusRequest{
+		Start: 0,
+		End:   10*3600*seconds - 1,
+		Step:  seconds,
+		Query: "sum(up)",
+	}
+
+	for _, tc := range []struct {
+		name             string
+		executedRequests []tripperware.Request
+		expectedCost     float64
+		expectObserved   bool
+	}{
+		{
+			name:           "query fully served by the results cache is not observed",
+			expectObserved: false,
+		},
+		{
+			name:             "query fully executed, expect cost per hour of the whole range",
+			executedRequests: []tripperware.Request{request},
+			expectedCost:     100,
+			expectObserved:   true,
+		},
+		{
+			name: "query partially served by the results cache, expect cost per hour of the executed range",
+			executedRequests: []tripperware.Request{
+				request.WithStartEnd(0, 3600*seconds-1),
+				request.WithStartEnd(9*3600*seconds, 10*3600*seconds-1),
+			},
+			expectedCost:   1000,
+			expectObserved: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := Config{
+				SplitQueriesByInterval: day,
+				AdaptiveQuerySplitsConfig: AdaptiveQuerySplitsConfig{
+					Enabled:                      true,
+					CheapQueryMaxFetchedSamples:  1000,
+					TargetFetchedSamplesPerShard: 1000,
+					MinSplitInterval:             time.Hour,
+					MaxTrackedQueries:            10,
+					StatsTTL:                     time.Hour,
+				},
+			}
+			a := newAdaptiveQuerySplits(cfg, mockLimits{}, querysharding.NewQueryAnalyzer(), prometheus.NewPedanticRegistry())
+
+			// The downstream handler simulates the results cache, which only forwards the
+			// requests it couldn't serve.
+			downstream := a.ExecutedRangeMiddleware().Wrap(tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+				querier_stats.FromContext(ctx).AddFetchedSamples(1000)
+				return &tripperware.PrometheusResponse{}, nil
+			}))
+			cache := tripperware.HandlerFunc(func(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+				for _, req := range tc.executedRequests {
+					if _, err := downstream.Do(ctx, req); err != nil {
+						return nil, err
+					}
+				}
+				return &tripperware.PrometheusResponse{}, nil
+			})
+
+			_, ctx := querier_stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "1"))
+			_, err := a.Middleware().Wrap(cache).Do(ctx, request)
+			require.NoError(t, err)
+
+			fingerprint, err := queryFingerprint(request.Query)
+			require.NoError(t, err)
+			cost, ok := a.tracker.queries.Get(queryCostKey("1", fingerprint))
+			require.Equal(t, tc.expectObserved, ok)
+			tenantCost, tenantOk := a.tracker.tenants.Get("1")
+			require.Equal(t, tc.expectObserved, tenantOk)
+			if tc.expectObserved {
+				require.InEpsilon(t, tc.expectedCost, cost, 0.01)
+				require.InEpsilon(t, tc.expectedCost, tenantCost, 0.01)
+			}
+		})
+	}
+}
+
+func TestAdaptiveQuerySplitsConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	valid := AdaptiveQuerySplitsConfig{
+		Enabled:                      true,
+		TargetFetchedSamplesPerShard: 1000,
+		MinSplitInterval:             time.Hour,
+		MaxTrackedQueries:            10,
+	}
+	require.NoError(t, valid.Validate(day))
+	require.Error(t, valid.Validate(0))
+	require.Error(t, valid.Validate(30*time.Minute))
+
+	disabled := AdaptiveQuerySplitsConfig{}
+	require.NoError(t, disabled.Validate(0))
+}
@@ -38,8 +38,9 @@ const day = 24 * time.Hour
 // Config for query_range middleware chain.
 type Config struct {
 	// Query splits config
-	SplitQueriesByInterval   time.Duration            `yaml:"split_queries_by_interval"`
-	DynamicQuerySplitsConfig DynamicQuerySplitsConfig `yaml:"dynamic_query_splits"`
+	SplitQueriesByInterval    time.Duration             `yaml:"split_queries_by_interval"`
+	DynamicQuerySplitsConfig  DynamicQuerySplitsConfig  `yaml:"dynamic_query_splits"`
+	AdaptiveQuerySplitsConfig AdaptiveQuerySplitsConfig `yaml:"adaptive_query_splits"`
 
 	AlignQueriesWithStep bool `yaml:"align_queries_with_step"`
 	ResultsCacheConfig   `yaml:"results_cache"`
@@ -61,6 +62,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&cfg.ForwardHeaders, "frontend.forward-headers-list", "List of headers forwarded by the query Frontend to downstream querier.")
 	cfg.ResultsCacheConfig.RegisterFlags(f)
 	cfg.DynamicQuerySplitsConfig.RegisterFlags(f)
+	cfg.AdaptiveQuerySplitsConfig.RegisterFlags(f)
 }
 
 // Validate validates the config.
@@ -78,6 +80,9 @@ func (cfg *Config) Validate(qCfg querier.Config) error {
 			return errors.New("configs under dynamic-query-splits requires that a value for split-queries-by-interval is set.")
 		}
 	}
+	if err := cfg.AdaptiveQuerySplitsConfig.Validate(cfg.SplitQueriesByInterval); err != nil {
+		return errors.Wrap(err, "invalid adaptive-query-splits config")
+	}
 	return nil
 }
 
@@ -107,6 +112,7 @@ func Middlewares(
 	lookbackDelta time.Duration,
 	defaultEvaluationInterval time.Duration,
 	distributedExecEnabled bool,
+	distributedAggregationPartials int,
 	localOptimizers []logicalplan.Optimizer,
 	tenantResolverFn func() users.Resolver,
 ) ([]tripperware.Middleware, cache.Cache, error) {
@@ -117,11 +123,17 @@ func Middlewares(
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("step_align", metrics), StepAlignMiddleware)
 	}
+	var adaptiveSplits *adaptiveQuerySplits
 	if cfg.SplitQueriesByInterval != 0 {
 		intervalFn := staticIntervalFn(cfg)
 		if cfg.DynamicQuerySplitsConfig.MaxShardsPerQuery > 0 || cfg.DynamicQuerySplitsConfig.MaxFetchedDataDurationPerQuery > 0 {
 			intervalFn = dynamicIntervalFn(cfg, limits, queryAnalyzer, lookbackDelta)
 		}
+		if cfg.AdaptiveQuerySplitsConfig.Enabled {
+			adaptiveSplits = newAdaptiveQuerySplits(cfg, limits, queryAnalyzer, registerer)
+			intervalFn = adaptiveSplits.intervalFn(intervalFn)
+			queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("adaptive_split", metrics), adaptiveSplits.Middleware())
+		}
 		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("split_by_interval", metrics), SplitByIntervalMiddleware(intervalFn, limits, prometheusCodec, registerer, lookbackDelta))
 	}
 
@@ -142,13 +154,17 @@ func Middlewares(
 		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("results_cache", metrics), queryCacheMiddleware)
 	}
 
+	if adaptiveSplits != nil {
+		queryRangeMiddleware = append(queryRangeMiddleware, adaptiveSplits.ExecutedRangeMiddleware())
+	}
+
 	queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("shardBy", metrics), tripperware.ShardByMiddleware(log, limits, shardedPrometheusCodec, queryAnalyzer))
 
 	if distributedExecEnabled {
 		queryRangeMiddleware = append(queryRangeMiddleware,
 			tripperware.InstrumentMiddleware("range_logical_plan_gen", metrics),
 			tripperware.DistributedQueryMiddleware(defaultEvaluationInterval, lookbackDelta,
-				append(localOptimizers, &distributed_execution.DistributedOptimizer{})))
+				append(localOptimizers, &distributed_execution.DistributedOptimizer{AggregationPartials: distributedAggregationPartials})))
 	}
 
 	return queryRangeMiddleware, c, nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -48,6 +49,7 @@ func newSchedulerProcessor(cfg Config, handler RequestHandler, log log.Logger, r
 			Buckets: prometheus.ExponentialBuckets(0.001, 4, 6),
 		}, []string{"operation", "status_code"}),
 		querierAddress: querierAddress,
+		running:        map[runningRequestKey]*runningRequest{},
 	}
 
 	frontendClientsGauge := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
@@ -79,6 +81,21 @@ type schedulerProcessor struct {
 
 	targetHeaders          []string
 	schedulerClientFactory func(conn *grpc.ClientConn) schedulerpb.SchedulerForQuerierClient
+
+	// Requests currently executed by this querier, reported to query-schedulers on demand.
+	runningMu sync.Mutex
+	running   map[runningRequestKey]*runningRequest
+}
+
+type runningRequestKey struct {
+	frontendAddress string
+	queryID         uint64
+	fragmentID      uint64
+}
+
+type runningRequest struct {
+	userID string
+	stats  *querier_stats.QueryStats
 }
 
 // notifyShutdown implements processor.
@@ -158,7 +175,7 @@ func (sp *schedulerProcessor) querierLoop(c schedulerpb.SchedulerForQuerier_Quer
 			if request.StatsEnabled {
 				level.Info(logger).Log("msg", "started running request")
 			}
-			sp.runRequest(ctx, logger, request.QueryID, request.FrontendAddress, request.StatsEnabled, request.HttpRequest)
+			sp.runRequest(ctx, logger, request)
 
 			if err = ctx.Err(); err != nil {
 				return
@@ -172,13 +189,19 @@ func (sp *schedulerProcessor) querierLoop(c schedulerpb.SchedulerForQuerier_Quer
 	}
 }
 
-func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger, queryID uint64, frontendAddress string, statsEnabled bool, request *httpgrpc.HTTPRequest) {
+func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger, msg *schedulerpb.SchedulerToQuerier) {
+	queryID, frontendAddress, statsEnabled, request := msg.QueryID, msg.FrontendAddress, msg.StatsEnabled, msg.HttpRequest
+
 	var stats *querier_stats.QueryStats
 	if statsEnabled {
 		stats, ctx = querier_stats.ContextWithEmptyStats(ctx)
 		querier_stats.ExtractQueueTimeHeader(request, stats)
 	}
 
+	key := runningRequestKey{frontendAddress: frontendAddress, queryID: queryID, fragmentID: msg.FragmentID}
+	sp.trackRunningRequest(key, &runningRequest{userID: msg.UserID, stats: stats})
+	defer sp.untrackRunningRequest(key)
+
 	response, err := sp.handler.Handle(ctx, request)
 	if err != nil {
 		var ok bool
@@ -230,6 +253,44 @@ func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger,
 	}
 }
 
+func (sp *schedulerProcessor) trackRunningRequest(key runningRequestKey, req *runningRequest) {
+	sp.runningMu.Lock()
+	defer sp.runningMu.Unlock()
+
+	sp.running[key] = req
+}
+
+func (sp *schedulerProcessor) untrackRunningRequest(key runningRequestKey) {
+	sp.runningMu.Lock()
+	defer sp.runningMu.Unlock()
+
+	delete(sp.running, key)
+}
+
+// GetRunningQueries implements schedulerpb.QuerierForSchedulerServer.
+func (sp *schedulerProcessor) GetRunningQueries(_ context.Context, _ *schedulerpb.GetRunningQueriesRequest) (*schedulerpb.GetRunningQueriesResponse, error) {
+	sp.runningMu.Lock()
+	defer sp.runningMu.Unlock()
+
+	resp := &schedulerpb.GetRunningQueriesResponse{
+		Queries: make([]*schedulerpb.RunningQuery, 0, len(sp.running)),
+	}
+	for key, req := range sp.running {
+		resp.Queries = append(resp.Queries, &schedulerpb.RunningQuery{
+			FrontendAddress: key.frontendAddress,
+			QueryID:         key.queryID,
+			FragmentID:      key.fragmentID,
+			UserID:          req.userID,
+			QuerierID:       sp.querierID,
+			QuerierAddress:  sp.querierAddress,
+			// Safe if stats is nil.
+			FetchedBytes: req.stats.LoadFetchedChunkBytes() + req.stats.LoadFetchedDataBytes(),
+		})
+	}
+
+	return resp, nil
+}
+
 func (sp *schedulerProcessor) createFrontendClient(addr string) (client.PoolClient, error) {
 	opts, err := sp.grpcConfig.DialOption([]grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
@@ -173,3 +173,56 @@ func Test_ToShowNotPanic_RelatedIssue6599(t *testing.T) {
 
 	sp.processQueriesOnSingleStream(ctx, nil, lis.Addr().String())
 }
+
+func TestSchedulerProcessor_GetRunningQueries(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	requestHandler := &mockRequestHandler{}
+	requestHandler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stats.FromContext(args.Get(0).(context.Context)).AddFetchedChunkBytes(100)
+		stats.FromContext(args.Get(0).(context.Context)).AddFetchedDataBytes(20)
+		close(started)
+		<-release
+	}).Return(&httpgrpc.HTTPResponse{}, nil)
+
+	sp, _ := newSchedulerProcessor(Config{QuerierID: "querier-1"}, requestHandler, log.NewNopLogger(), nil, "10.0.0.1:9095")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sp.runRequest(ctx, log.NewNopLogger(), &schedulerpb.SchedulerToQuerier{
+			QueryID:         1,
+			FragmentID:      2,
+			UserID:          "user-1",
+			FrontendAddress: "frontend-1:9095",
+			StatsEnabled:    true,
+			HttpRequest:     &httpgrpc.HTTPRequest{},
+		})
+	}()
+
+	<-started
+	resp, err := sp.GetRunningQueries(context.Background(), &schedulerpb.GetRunningQueriesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []*schedulerpb.RunningQuery{{
+		FrontendAddress: "frontend-1:9095",
+		QueryID:         1,
+		FragmentID:      2,
+		UserID:          "user-1",
+		QuerierID:       "querier-1",
+		QuerierAddress:  "10.0.0.1:9095",
+		FetchedBytes:    120,
+	}}, resp.Queries)
+
+	// Cancel the context so that no response is sent to the (non-existing) frontend.
+	cancel()
+	close(release)
+	<-done
+
+	resp, err = sp.GetRunningQueries(context.Background(), &schedulerpb.GetRunningQueriesRequest{})
+	require.NoError(t, err)
+	require.Empty(t, resp.Queries)
+}
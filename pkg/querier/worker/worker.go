@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 	"github.com/cortexproject/cortex/pkg/util/grpcclient"
@@ -174,6 +175,15 @@ func newQuerierWorkerWithProcessor(cfg Config, log log.Logger, processor process
 	return f, nil
 }
 
+// GetRunningQueries implements schedulerpb.QuerierForSchedulerServer. Running queries are only
+// tracked when the querier is connected to query-schedulers.
+func (w *querierWorker) GetRunningQueries(ctx context.Context, req *schedulerpb.GetRunningQueriesRequest) (*schedulerpb.GetRunningQueriesResponse, error) {
+	if s, ok := w.processor.(schedulerpb.QuerierForSchedulerServer); ok {
+		return s.GetRunningQueries(ctx, req)
+	}
+	return &schedulerpb.GetRunningQueriesResponse{}, nil
+}
+
 func (w *querierWorker) starting(ctx context.Context) error {
 	if w.subservices == nil {
 		return nil
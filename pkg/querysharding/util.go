@@ -31,18 +31,13 @@ func InjectShardingInfo(query string, shardInfo *storepb.ShardInfo) (string, err
 	if err != nil {
 		return "", err
 	}
-	b, err := shardInfo.Marshal()
+	matcher, err := ShardingInfoMatcher(shardInfo)
 	if err != nil {
 		return "", err
 	}
-	eShardInfo := base64.StdEncoding.EncodeToString(b)
 	parser.Inspect(expr, func(n parser.Node, _ []parser.Node) error {
 		if selector, ok := n.(*parser.VectorSelector); ok {
-			selector.LabelMatchers = append(selector.LabelMatchers, &labels.Matcher{
-				Type:  labels.MatchEqual,
-				Name:  CortexShardByLabel,
-				Value: eShardInfo,
-			})
+			selector.LabelMatchers = append(selector.LabelMatchers, matcher)
 		}
 		return nil
 	})
@@ -50,6 +45,20 @@ func InjectShardingInfo(query string, shardInfo *storepb.ShardInfo) (string, err
 	return expr.String(), err
 }
 
+// ShardingInfoMatcher returns the label matcher carrying the sharding info down to the storage,
+// where it is extracted by ExtractShardingInfo.
+func ShardingInfoMatcher(shardInfo *storepb.ShardInfo) (*labels.Matcher, error) {
+	b, err := shardInfo.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &labels.Matcher{
+		Type:  labels.MatchEqual,
+		Name:  CortexShardByLabel,
+		Value: base64.StdEncoding.EncodeToString(b),
+	}, nil
+}
+
 func ExtractShardingInfo(matchers []*labels.Matcher) ([]*labels.Matcher, *storepb.ShardInfo, error) {
 	r := make([]*labels.Matcher, 0, len(matchers))
 
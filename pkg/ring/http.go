@@ -7,7 +7,9 @@ import (
 	"html/template"
 	"math"
 	"net/http"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -212,7 +214,7 @@ func (r *Ring) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 // RenderHTTPResponse either responds with json or a rendered html page using the passed in template
 // by checking the Accepts header
-func renderHTTPResponse(w http.ResponseWriter, v httpResponse, t *template.Template, r *http.Request) {
+func renderHTTPResponse(w http.ResponseWriter, v any, t *template.Template, r *http.Request) {
 	accept := r.Header.Get("Accept")
 	if strings.Contains(accept, "application/json") {
 		writeJSONResponse(w, v)
@@ -226,7 +228,7 @@ func renderHTTPResponse(w http.ResponseWriter, v httpResponse, t *template.Templ
 }
 
 // WriteJSONResponse writes some JSON as a HTTP response.
-func writeJSONResponse(w http.ResponseWriter, v httpResponse) {
+func writeJSONResponse(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
 
 	data, err := json.Marshal(v)
@@ -240,3 +242,414 @@ func writeJSONResponse(w http.ResponseWriter, v httpResponse) {
 	// Also this isn't internal error, but error communicating with client.
 	_, _ = w.Write(data)
 }
+
+const ownershipPageContent = `
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Ring Ownership</title>
+	</head>
+	<body>
+		<h1>Ring Ownership</h1>
+		<p>Current time: {{ .Now }}</p>
+		<p>Zone-awareness enabled: {{ .ZoneAwarenessEnabled }}</p>
+		<p>Tenants with a cached shuffle-shard: {{ .CachedShuffleShardTenants }}</p>
+		<form action="" method="GET">
+			Simulate adding <input type="number" name="add" min="0" value="{{ .Add }}"> and removing <input type="number" name="remove" min="0" value="{{ .Remove }}"> instances
+			<input type="submit" value="Simulate">
+		</form>
+
+		<h2>Current</h2>
+		{{ template "report" .Current }}
+
+		{{ with .WhatIf }}
+		<h2>What-if</h2>
+		<p>Tokens generator: {{ .TokensGenerator }}, tokens per added instance: {{ .TokensPerInstance }}</p>
+		<p>Added instances: {{ range .AddedInstances }}{{ . }} {{ end }}</p>
+		<p>Removed instances: {{ range .RemovedInstances }}{{ . }} {{ end }}</p>
+		{{ template "report" .Report }}
+		{{ end }}
+	</body>
+</html>
+
+{{ define "report" }}
+		<p>Max ownership diff from expected: {{ printf "%.2f" .MaxDiffOwnership }}%</p>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>Availability Zone</th>
+					<th>Instances</th>
+					<th>Tokens</th>
+					<th>Ownership</th>
+					<th>Expected Ownership</th>
+					<th>Min Instance Ownership</th>
+					<th>Max Instance Ownership</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .Zones }}
+				<tr>
+					<td>{{ .Zone }}</td>
+					<td>{{ .Instances }}</td>
+					<td>{{ .NumTokens }}</td>
+					<td>{{ printf "%.2f" .Ownership }}%</td>
+					<td>{{ printf "%.2f" .ExpectedOwnership }}%</td>
+					<td>{{ printf "%.2f" .MinInstanceOwnership }}%</td>
+					<td>{{ printf "%.2f" .MaxInstanceOwnership }}%</td>
+				</tr>
+				{{ end }}
+			</tbody>
+		</table>
+		<br>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>Instance ID</th>
+					<th>Availability Zone</th>
+					<th>State</th>
+					<th>Tokens</th>
+					<th>Ownership</th>
+					<th>Expected Ownership</th>
+					<th>Ownership Diff From Expected</th>
+					<th>Cached Shuffle-Shard Tenants</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .Instances }}
+				<tr>
+					<td>{{ .ID }}</td>
+					<td>{{ .Zone }}</td>
+					<td>{{ .State }}</td>
+					<td>{{ .NumTokens }}</td>
+					<td>{{ printf "%.2f" .Ownership }}%</td>
+					<td>{{ printf "%.2f" .ExpectedOwnership }}%</td>
+					<td>{{ printf "%.2f" .DiffOwnership }}%</td>
+					<td>{{ .CachedShuffleShardTenants }}</td>
+				</tr>
+				{{ end }}
+			</tbody>
+		</table>
+{{ end }}`
+
+var ownershipPageTemplate = template.Must(template.New("ownership").Parse(ownershipPageContent))
+
+const (
+	// maxWhatIfInstances is the max number of instances which can be added by the what-if simulation.
+	maxWhatIfInstances = 1000
+
+	// maxWhatIfTokens is the max number of tokens which can be generated by the what-if simulation,
+	// which runs while serving the request.
+	maxWhatIfTokens = 100000
+)
+
+type instanceOwnership struct {
+	ID                string  `json:"id"`
+	Zone              string  `json:"zone"`
+	State             string  `json:"state"`
+	NumTokens         int     `json:"tokens"`
+	Ownership         float64 `json:"ownership_percent"`
+	ExpectedOwnership float64 `json:"expected_ownership_percent"`
+	DiffOwnership     float64 `json:"ownership_diff_percent"`
+	// Number of tenants whose shuffle-shard includes the instance, among the tenants whose shuffle-shard
+	// is cached by the ring client serving the request. The cache only includes the tenants recently
+	// written or queried through this client, and it's reset whenever the ring changes.
+	CachedShuffleShardTenants int `json:"cached_shuffle_shard_tenants"`
+}
+
+type zoneOwnership struct {
+	Zone                 string  `json:"zone"`
+	Instances            int     `json:"instances"`
+	NumTokens            int     `json:"tokens"`
+	Ownership            float64 `json:"ownership_percent"`
+	ExpectedOwnership    float64 `json:"expected_ownership_percent"`
+	MinInstanceOwnership float64 `json:"min_instance_ownership_percent"`
+	MaxInstanceOwnership float64 `json:"max_instance_ownership_percent"`
+}
+
+type ownershipReport struct {
+	Instances        []instanceOwnership `json:"instances"`
+	Zones            []zoneOwnership     `json:"zones"`
+	MaxDiffOwnership float64             `json:"max_ownership_diff_percent"`
+}
+
+type whatIfOwnershipReport struct {
+	TokensGenerator   string          `json:"tokens_generator"`
+	TokensPerInstance int             `json:"tokens_per_instance"`
+	AddedInstances    []string        `json:"added_instances"`
+	RemovedInstances  []string        `json:"removed_instances"`
+	Report            ownershipReport `json:"report"`
+}
+
+type ownershipResponse struct {
+	Now                       time.Time              `json:"now"`
+	ZoneAwarenessEnabled      bool                   `json:"zone_awareness_enabled"`
+	CachedShuffleShardTenants int                    `json:"cached_shuffle_shard_tenants"`
+	Current                   ownershipReport        `json:"current"`
+	WhatIf                    *whatIfOwnershipReport `json:"what_if,omitempty"`
+	Add                       int                    `json:"-"`
+	Remove                    int                    `json:"-"`
+}
+
+// OwnershipHandler shows the token ownership of each instance and zone, compared to the expected
+// one, and how many tenants' shuffle-shards each instance belongs to. When the "add" or "remove"
+// query parameters are set, it also shows the ownership after adding or removing that number of
+// instances, generating the tokens of the added instances with the configured tokens generator.
+func (r *Ring) OwnershipHandler(w http.ResponseWriter, req *http.Request) {
+	add, remove, numTokens, err := parseWhatIfParams(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mtx.RLock()
+	ringDesc := r.ringDesc
+	tenantsByInstance, numTenants := r.shuffleShardTenantsByInstance()
+	r.mtx.RUnlock()
+
+	if remove > len(ringDesc.Ingesters) {
+		http.Error(w, fmt.Sprintf("cannot remove %d instances from a ring of %d instances", remove, len(ringDesc.Ingesters)), http.StatusBadRequest)
+		return
+	}
+
+	resp := ownershipResponse{
+		Now:                       time.Now(),
+		ZoneAwarenessEnabled:      r.cfg.ZoneAwarenessEnabled,
+		CachedShuffleShardTenants: numTenants,
+		Current:                   computeOwnershipReport(ringDesc, r.cfg.ZoneAwarenessEnabled, tenantsByInstance),
+		Add:                       add,
+		Remove:                    remove,
+	}
+
+	if add > 0 || remove > 0 {
+		if numTokens == 0 {
+			for _, instance := range ringDesc.Ingesters {
+				numTokens = max(numTokens, len(instance.Tokens))
+			}
+		}
+		if add*numTokens > maxWhatIfTokens {
+			http.Error(w, fmt.Sprintf("cannot generate more than %d tokens: %d instances with %d tokens each requested", maxWhatIfTokens, add, numTokens), http.StatusBadRequest)
+			return
+		}
+
+		strategy := randomTokenStrategy
+		if strings.EqualFold(r.cfg.TokensGeneratorStrategy, minimizeSpreadTokenStrategy) {
+			strategy = minimizeSpreadTokenStrategy
+		}
+
+		simulated, added, removed := simulateRingChanges(ringDesc, add, remove, numTokens, NewTokenGenerator(strategy), r.cfg.ZoneAwarenessEnabled)
+		resp.WhatIf = &whatIfOwnershipReport{
+			TokensGenerator:   strategy,
+			TokensPerInstance: numTokens,
+			AddedInstances:    added,
+			RemovedInstances:  removed,
+			Report:            computeOwnershipReport(simulated, r.cfg.ZoneAwarenessEnabled, nil),
+		}
+	}
+
+	renderHTTPResponse(w, resp, ownershipPageTemplate, req)
+}
+
+func parseWhatIfParams(req *http.Request) (add, remove, numTokens int, err error) {
+	parse := func(name string) (int, error) {
+		value := req.URL.Query().Get(name)
+		if value == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s parameter: %q", name, value)
+		}
+		return n, nil
+	}
+
+	if add, err = parse("add"); err != nil {
+		return
+	}
+	if add > maxWhatIfInstances {
+		return 0, 0, 0, fmt.Errorf("cannot add more than %d instances", maxWhatIfInstances)
+	}
+	if remove, err = parse("remove"); err != nil {
+		return
+	}
+	numTokens, err = parse("tokens")
+	return
+}
+
+// shuffleShardTenantsByInstance returns, for each instance, the number of tenants whose cached
+// shuffle-shard includes the instance, and the total number of tenants with a cached shuffle-shard.
+// The ring read lock must be already taken when calling this function.
+func (r *Ring) shuffleShardTenantsByInstance() (map[string]int, int) {
+	tenantsByInstance := map[string]map[string]struct{}{}
+	tenants := map[string]struct{}{}
+
+	for key, cached := range r.shuffledSubringCache {
+		tenants[key.identifier] = struct{}{}
+
+		cached.ring.mtx.RLock()
+		for id := range cached.ring.ringDesc.Ingesters {
+			if tenantsByInstance[id] == nil {
+				tenantsByInstance[id] = map[string]struct{}{}
+			}
+			tenantsByInstance[id][key.identifier] = struct{}{}
+		}
+		cached.ring.mtx.RUnlock()
+	}
+
+	counts := make(map[string]int, len(tenantsByInstance))
+	for id, instanceTenants := range tenantsByInstance {
+		counts[id] = len(instanceTenants)
+	}
+	return counts, len(tenants)
+}
+
+// computeOwnershipReport computes the percentage of the token ranges owned by each instance and zone
+// of the ring. When zone-awareness is enabled, the ownership of an instance is computed within its zone.
+func computeOwnershipReport(ringDesc *Desc, zoneAwarenessEnabled bool, tenantsByInstance map[string]int) ownershipReport {
+	instanceByToken := ringDesc.getTokensInfo()
+	owned := map[string]int64{}
+	countOwned := func(tokens []uint32) {
+		for i := 1; i <= len(tokens); i++ {
+			index := i % len(tokens)
+			owned[instanceByToken[tokens[index]].InstanceID] += tokenDistance(tokens[i-1], tokens[index])
+		}
+	}
+
+	if zoneAwarenessEnabled {
+		for _, tokens := range ringDesc.getTokensByZone() {
+			countOwned(tokens)
+		}
+	} else {
+		countOwned(ringDesc.GetTokens())
+	}
+
+	instancesByZone := map[string]int{}
+	for _, instance := range ringDesc.Ingesters {
+		instancesByZone[instance.Zone]++
+	}
+
+	report := ownershipReport{}
+	zones := map[string]*zoneOwnership{}
+	for id, instance := range ringDesc.Ingesters {
+		ownership := float64(owned[id]) / float64(math.MaxUint32+1) * 100
+		expected := 100 / float64(len(ringDesc.Ingesters))
+		if zoneAwarenessEnabled {
+			expected = 100 / float64(instancesByZone[instance.Zone])
+		}
+		diff := (ownership/expected - 1) * 100
+
+		report.Instances = append(report.Instances, instanceOwnership{
+			ID:                        id,
+			Zone:                      instance.Zone,
+			State:                     instance.State.String(),
+			NumTokens:                 len(instance.Tokens),
+			Ownership:                 ownership,
+			ExpectedOwnership:         expected,
+			DiffOwnership:             diff,
+			CachedShuffleShardTenants: tenantsByInstance[id],
+		})
+		report.MaxDiffOwnership = max(report.MaxDiffOwnership, math.Abs(diff))
+
+		zone, ok := zones[instance.Zone]
+		if !ok {
+			zone = &zoneOwnership{Zone: instance.Zone, MinInstanceOwnership: ownership}
+			zones[instance.Zone] = zone
+		}
+		zone.Instances++
+		zone.NumTokens += len(instance.Tokens)
+		zone.Ownership += ownership
+		zone.ExpectedOwnership += expected
+		zone.MinInstanceOwnership = min(zone.MinInstanceOwnership, ownership)
+		zone.MaxInstanceOwnership = max(zone.MaxInstanceOwnership, ownership)
+	}
+
+	for _, zone := range zones {
+		report.Zones = append(report.Zones, *zone)
+	}
+
+	sort.Slice(report.Instances, func(i, j int) bool {
+		return report.Instances[i].ID < report.Instances[j].ID
+	})
+	sort.Slice(report.Zones, func(i, j int) bool {
+		return report.Zones[i].Zone < report.Zones[j].Zone
+	})
+	return report
+}
+
+// simulateRingChanges returns a copy of the ring with the given number of instances removed and
+// added. The most recently registered instances are removed first, as a scale down would do, and
+// the instances are added with tokens generated by the tokens generator. When zone-awareness is
+// enabled, instances are removed from the largest zones and added to the smallest ones.
+func simulateRingChanges(ringDesc *Desc, add, remove, numTokens int, tg TokenGenerator, zoneAwarenessEnabled bool) (*Desc, []string, []string) {
+	simulated := ringDesc.Clone().(*Desc)
+	if simulated.Ingesters == nil {
+		simulated.Ingesters = map[string]InstanceDesc{}
+	}
+
+	instancesByZone := func() map[string][]string {
+		out := map[string][]string{}
+		for id, instance := range simulated.Ingesters {
+			zone := ""
+			if zoneAwarenessEnabled {
+				zone = instance.Zone
+			}
+			out[zone] = append(out[zone], id)
+		}
+		return out
+	}
+
+	var removed []string
+	for range remove {
+		// Pick the largest zone, breaking ties by zone name to get a stable simulation.
+		var zoneInstances []string
+		zoneName := ""
+		for zone, ids := range instancesByZone() {
+			if len(ids) > len(zoneInstances) || (len(ids) == len(zoneInstances) && zone < zoneName) {
+				zoneName, zoneInstances = zone, ids
+			}
+		}
+
+		sort.Slice(zoneInstances, func(i, j int) bool {
+			a, b := simulated.Ingesters[zoneInstances[i]], simulated.Ingesters[zoneInstances[j]]
+			if a.RegisteredTimestamp != b.RegisteredTimestamp {
+				return a.RegisteredTimestamp > b.RegisteredTimestamp
+			}
+			return zoneInstances[i] > zoneInstances[j]
+		})
+
+		simulated.RemoveIngester(zoneInstances[0])
+		removed = append(removed, zoneInstances[0])
+	}
+
+	var zones []string
+	if zoneAwarenessEnabled {
+		for _, instance := range ringDesc.Ingesters {
+			if !slices.Contains(zones, instance.Zone) {
+				zones = append(zones, instance.Zone)
+			}
+		}
+		sort.Strings(zones)
+	}
+	if len(zones) == 0 {
+		zones = []string{""}
+	}
+
+	var added []string
+	for n := range add {
+		// Pick the smallest zone.
+		byZone := instancesByZone()
+		zone := zones[0]
+		for _, z := range zones[1:] {
+			if len(byZone[z]) < len(byZone[zone]) {
+				zone = z
+			}
+		}
+
+		id := fmt.Sprintf("what-if-instance-%d", n+1)
+		tokens := tg.GenerateTokens(simulated, id, zone, numTokens, true)
+		simulated.AddIngester(id, "", zone, tokens, ACTIVE, time.Now())
+		added = append(added, id)
+	}
+
+	return simulated, added, removed
+}
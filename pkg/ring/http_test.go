@@ -0,0 +1,199 @@
+package ring
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeOwnershipReport(t *testing.T) {
+	t.Parallel()
+
+	// Tokens are placed so that each instance owns the range preceding its tokens.
+	quarter := uint32(math.MaxUint32/4 + 1)
+	ringDesc := &Desc{Ingesters: map[string]InstanceDesc{
+		"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{quarter}, time.Now()),
+		"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{4 * (quarter - 1)}, time.Now()),
+		"instance-3": generateRingInstanceWithInfo("instance-3", "zone-b", []uint32{2 * quarter}, time.Now()),
+	}}
+
+	tests := map[string]struct {
+		zoneAwarenessEnabled bool
+		expectedOwnership    map[string]float64
+		expectedExpected     map[string]float64
+		expectedZones        map[string]float64
+	}{
+		"zone-awareness disabled": {
+			expectedOwnership: map[string]float64{"instance-1": 25, "instance-2": 50, "instance-3": 25},
+			expectedExpected:  map[string]float64{"instance-1": 100.0 / 3, "instance-2": 100.0 / 3, "instance-3": 100.0 / 3},
+			expectedZones:     map[string]float64{"zone-a": 75, "zone-b": 25},
+		},
+		"zone-awareness enabled": {
+			zoneAwarenessEnabled: true,
+			expectedOwnership:    map[string]float64{"instance-1": 25, "instance-2": 75, "instance-3": 100},
+			expectedExpected:     map[string]float64{"instance-1": 50, "instance-2": 50, "instance-3": 100},
+			expectedZones:        map[string]float64{"zone-a": 100, "zone-b": 100},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			report := computeOwnershipReport(ringDesc, testData.zoneAwarenessEnabled, map[string]int{"instance-1": 3})
+
+			require.Len(t, report.Instances, 3)
+			maxDiff := 0.0
+			for _, instance := range report.Instances {
+				assert.InDelta(t, testData.expectedOwnership[instance.ID], instance.Ownership, 0.001, instance.ID)
+				assert.InDelta(t, testData.expectedExpected[instance.ID], instance.ExpectedOwnership, 0.001, instance.ID)
+				assert.Equal(t, 1, instance.NumTokens)
+				maxDiff = max(maxDiff, math.Abs(instance.DiffOwnership))
+			}
+			assert.Equal(t, 3, report.Instances[0].CachedShuffleShardTenants)
+			assert.Equal(t, 0, report.Instances[1].CachedShuffleShardTenants)
+			assert.Equal(t, maxDiff, report.MaxDiffOwnership)
+
+			require.Len(t, report.Zones, 2)
+			for _, zone := range report.Zones {
+				assert.InDelta(t, testData.expectedZones[zone.Zone], zone.Ownership, 0.001, zone.Zone)
+			}
+			assert.Equal(t, 2, report.Zones[0].Instances)
+			assert.InDelta(t, testData.expectedOwnership["instance-2"]-testData.expectedOwnership["instance-1"], report.Zones[0].MaxInstanceOwnership-report.Zones[0].MinInstanceOwnership, 0.001)
+		})
+	}
+}
+
+func TestSimulateRingChanges(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	g := NewRandomTokenGenerator()
+	ringDesc := &Desc{Ingesters: map[string]InstanceDesc{
+		"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", g.GenerateTokens(NewDesc(), "instance-1", "zone-a", 64, true), now.Add(-time.Hour)),
+		"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", g.GenerateTokens(NewDesc(), "instance-2", "zone-a", 64, true), now),
+		"instance-3": generateRingInstanceWithInfo("instance-3", "zone-b", g.GenerateTokens(NewDesc(), "instance-3", "zone-b", 64, true), now),
+	}}
+
+	simulated, added, removed := simulateRingChanges(ringDesc, 2, 1, 32, g, true)
+
+	// The most recent instance of the largest zone is removed, then instances are added to the smallest zones.
+	assert.Equal(t, []string{"instance-2"}, removed)
+	assert.Equal(t, []string{"what-if-instance-1", "what-if-instance-2"}, added)
+	require.Len(t, simulated.Ingesters, 4)
+	assert.NotContains(t, simulated.Ingesters, "instance-2")
+	assert.Equal(t, "zone-a", simulated.Ingesters["what-if-instance-1"].Zone)
+	assert.Equal(t, "zone-b", simulated.Ingesters["what-if-instance-2"].Zone)
+	assert.Len(t, simulated.Ingesters["what-if-instance-1"].Tokens, 32)
+
+	// The original ring is not modified.
+	assert.Len(t, ringDesc.Ingesters, 3)
+	assert.Contains(t, ringDesc.Ingesters, "instance-2")
+}
+
+func TestRing_OwnershipHandler(t *testing.T) {
+	t.Parallel()
+
+	ringDesc := &Desc{Ingesters: generateRingInstances(6, 3, 128)}
+	ring := Ring{
+		cfg: Config{
+			HeartbeatTimeout:        time.Hour,
+			ZoneAwarenessEnabled:    true,
+			ReplicationFactor:       3,
+			TokensGeneratorStrategy: minimizeSpreadTokenStrategy,
+		},
+		ringDesc:             ringDesc,
+		ringTokens:           ringDesc.GetTokens(),
+		ringTokensByZone:     ringDesc.getTokensByZone(),
+		ringInstanceByToken:  ringDesc.getTokensInfo(),
+		ringZones:            getZones(ringDesc.getTokensByZone()),
+		strategy:             NewDefaultReplicationStrategy(),
+		KVClient:             &MockClient{},
+		shuffledSubringCache: map[subringCacheKey]*cachedSubring{},
+	}
+
+	// Populate the shuffle-shards cache.
+	for _, userID := range []string{"user-1", "user-2"} {
+		ring.ShuffleShard(userID, 3)
+	}
+
+	tests := map[string]struct {
+		query              string
+		expectedStatusCode int
+		expectedAdded      int
+		expectedRemoved    int
+	}{
+		"current ownership only": {
+			expectedStatusCode: http.StatusOK,
+		},
+		"what-if adding and removing instances": {
+			query:              "?add=3&remove=1",
+			expectedStatusCode: http.StatusOK,
+			expectedAdded:      3,
+			expectedRemoved:    1,
+		},
+		"invalid add parameter": {
+			query:              "?add=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"too many instances to add": {
+			query:              "?add=1001",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"too many tokens to generate": {
+			query:              "?add=100&tokens=1001",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"too many instances to remove": {
+			query:              "?remove=7",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/ingester/ring_ownership"+testData.query, nil)
+			req.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			ring.OwnershipHandler(w, req)
+			require.Equal(t, testData.expectedStatusCode, w.Code)
+			if testData.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var resp ownershipResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.True(t, resp.ZoneAwarenessEnabled)
+			assert.Equal(t, 2, resp.CachedShuffleShardTenants)
+			require.Len(t, resp.Current.Instances, 6)
+			require.Len(t, resp.Current.Zones, 3)
+
+			// Each tenant's shuffle-shard includes one instance per zone.
+			shuffleShardTenants := 0
+			for _, instance := range resp.Current.Instances {
+				shuffleShardTenants += instance.CachedShuffleShardTenants
+			}
+			assert.Equal(t, 6, shuffleShardTenants)
+
+			if testData.expectedAdded == 0 && testData.expectedRemoved == 0 {
+				assert.Nil(t, resp.WhatIf)
+				return
+			}
+
+			require.NotNil(t, resp.WhatIf)
+			assert.Equal(t, minimizeSpreadTokenStrategy, resp.WhatIf.TokensGenerator)
+			assert.Equal(t, 128, resp.WhatIf.TokensPerInstance)
+			assert.Len(t, resp.WhatIf.AddedInstances, testData.expectedAdded)
+			assert.Len(t, resp.WhatIf.RemovedInstances, testData.expectedRemoved)
+			assert.Len(t, resp.WhatIf.Report.Instances, 6+testData.expectedAdded-testData.expectedRemoved)
+		})
+	}
+}
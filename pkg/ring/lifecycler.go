@@ -57,6 +57,8 @@ type LifecyclerConfig struct {
 	UnregisterOnShutdown     bool          `yaml:"unregister_on_shutdown"`
 	ReadinessCheckRingHealth bool          `yaml:"readiness_check_ring_health"`
 
+	TokenRebalance TokenRebalanceConfig `yaml:"token_rebalance"`
+
 	// For testing, you can override the address and ID of this ingester
 	Addr string `yaml:"address" doc:"hidden"`
 	Port int    `doc:"hidden"`
@@ -103,6 +105,7 @@ func (cfg *LifecyclerConfig) RegisterFlagsWithPrefix(prefix string, f *flag.Flag
 	f.StringVar(&cfg.Zone, prefix+"availability-zone", "", "The availability zone where this instance is running.")
 	f.BoolVar(&cfg.UnregisterOnShutdown, prefix+"unregister-on-shutdown", true, "Unregister from the ring upon clean shutdown. It can be useful to disable for rolling restarts with consistent naming in conjunction with -distributor.extend-writes=false.")
 	f.BoolVar(&cfg.ReadinessCheckRingHealth, prefix+"readiness-check-ring-health", true, "When enabled the readiness probe succeeds only after all instances are ACTIVE and healthy in the ring, otherwise only the instance itself is checked. This option should be disabled if in your cluster multiple instances can be rolled out simultaneously, otherwise rolling updates may be slowed down.")
+	cfg.TokenRebalance.RegisterFlagsWithPrefix(prefix, f)
 }
 
 func (cfg *LifecyclerConfig) Validate() error {
@@ -110,7 +113,11 @@ func (cfg *LifecyclerConfig) Validate() error {
 		return errInvalidTokensGeneratorStrategy
 	}
 
-	return nil
+	if err := cfg.TokenRebalance.Validate(); err != nil {
+		return err
+	}
+
+	return cfg.RingConfig.Validate()
 }
 
 // Lifecycler is responsible for managing the lifecycle of entries in the ring.
@@ -122,6 +129,11 @@ type Lifecycler struct {
 	KVStore         kv.Client
 	delegate        LifecyclerDelegate
 
+	// Client of the zone rollout lease. Only set when the zone rollout coordination is enabled.
+	zoneRolloutKVStore kv.Client
+
+	tokenRebalance tokenRebalanceState
+
 	actorChan    chan func()
 	autojoinChan chan struct{}
 
@@ -207,22 +219,32 @@ func NewLifecycler(
 
 	zone := cfg.Zone
 
+	var zoneRolloutStore kv.Client
+	if cfg.RingConfig.ZoneRollout.Enabled && zone != "" {
+		zoneRolloutStore, err = kv.NewClient(
+			cfg.RingConfig.KVStore,
+			GetZoneRolloutCodec(),
+			kv.RegistererWithKVName(reg, ringName+"-lifecycler-zone-rollout"),
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// We do allow a nil FlushTransferer, but to keep the ring logic easier we assume
 	// it's always set, so we use a noop FlushTransferer
 	if flushTransferer == nil {
 		flushTransferer = NewNoopFlushTransferer()
 	}
 
-	tg := NewRandomTokenGenerator()
-
-	if strings.EqualFold(cfg.TokensGeneratorStrategy, minimizeSpreadTokenStrategy) {
-		tg = NewMinimizeSpreadTokenGenerator()
-	}
+	tg := NewTokenGenerator(cfg.TokensGeneratorStrategy)
 
 	l := &Lifecycler{
 		cfg:                  cfg,
 		flushTransferer:      flushTransferer,
 		KVStore:              store,
+		zoneRolloutKVStore:   zoneRolloutStore,
 		Addr:                 fmt.Sprintf("%s:%d", addr, port),
 		ID:                   cfg.ID,
 		RingName:             ringName,
@@ -557,6 +579,9 @@ func (i *Lifecycler) loop(ctx context.Context) error {
 		setAutoJoinAfter(i.cfg.JoinAfter)
 	}
 
+	// The instance may have acquired the zone rollout lease before being restarted.
+	releaseZoneRollout := i.zoneRolloutKVStore != nil
+
 	var heartbeatTickerChan <-chan time.Time
 	startHeartbeat := func() {
 		if uint64(i.cfg.HeartbeatPeriod) > 0 {
@@ -641,6 +666,16 @@ func (i *Lifecycler) loop(ctx context.Context) error {
 
 		case <-heartbeatTickerChan:
 			i.heartbeat(ctx)
+
+			// Release the zone rollout lease, possibly acquired before the restart,
+			// once this instance is ACTIVE again.
+			if releaseZoneRollout && i.GetState() == ACTIVE {
+				releaseZoneRollout = !i.releaseZoneRollout(ctx)
+			}
+
+			if i.cfg.TokenRebalance.LookbackPeriod > 0 {
+				i.rebalanceTokens(ctx, time.Now())
+			}
 		case f := <-i.actorChan:
 			f()
 
@@ -680,6 +715,12 @@ func (i *Lifecycler) stopping(runningError error) error {
 		i.setPreviousState(currentState)
 	}
 
+	// Wait for the rollout of any other zone to complete before leaving, so that only
+	// the instances of a single zone are restarted at the same time.
+	if i.zoneRolloutKVStore != nil && i.GetState() == ACTIVE {
+		i.acquireZoneRollout(heartbeatTickerChan)
+	}
+
 	// We dont need to mark us as leaving if READONLY. There is not request sent to us.
 	// Also important to avoid this change so we dont have resharding(for querier) happen when READONLY restart as we extended shard on READONLY but not on LEAVING
 	// Query also keeps calling pods on LEAVING or JOINING not causing any difference if left on READONLY
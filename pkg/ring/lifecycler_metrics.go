@@ -9,6 +9,7 @@ type LifecyclerMetrics struct {
 	consulHeartbeats prometheus.Counter
 	tokensOwned      prometheus.Gauge
 	tokensToOwn      prometheus.Gauge
+	tokensRebalanced *prometheus.CounterVec
 	shutdownDuration *prometheus.HistogramVec
 }
 
@@ -29,6 +30,11 @@ func NewLifecyclerMetrics(ringName string, reg prometheus.Registerer) *Lifecycle
 			Help:        "The number of tokens to own in the ring.",
 			ConstLabels: prometheus.Labels{"name": ringName},
 		}),
+		tokensRebalanced: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "member_ring_tokens_rebalanced_total",
+			Help:        "The total number of tokens taken over from other instances or dropped by the token rebalance.",
+			ConstLabels: prometheus.Labels{"name": ringName},
+		}, []string{"op"}),
 		shutdownDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 			Name:        "shutdown_duration_seconds",
 			Help:        "Duration (in seconds) of shutdown procedure (ie transfer or flush).",
@@ -148,10 +148,15 @@ type Config struct {
 	ZoneAwarenessEnabled   bool                   `yaml:"zone_awareness_enabled"`
 	ExcludedZones          flagext.StringSliceCSV `yaml:"excluded_zones"`
 	DetailedMetricsEnabled bool                   `yaml:"detailed_metrics_enabled"`
+	ZoneRollout            ZoneRolloutConfig      `yaml:"zone_rollout"`
 
 	// Whether the shuffle-sharding subring cache is disabled. This option is set
 	// internally and never exposed to the user.
 	SubringCacheDisabled bool `yaml:"-"`
+
+	// The strategy used to generate the tokens of the instances simulated by the ownership
+	// report. This option is set internally and never exposed to the user.
+	TokensGeneratorStrategy string `yaml:"-"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet with a specified prefix
@@ -168,6 +173,13 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.IntVar(&cfg.ReplicationFactor, prefix+"distributor.replication-factor", 3, "The number of ingesters to write to and read from.")
 	f.BoolVar(&cfg.ZoneAwarenessEnabled, prefix+"distributor.zone-awareness-enabled", false, "True to enable the zone-awareness and replicate ingested samples across different availability zones.")
 	f.Var(&cfg.ExcludedZones, prefix+"distributor.excluded-zones", "Comma-separated list of zones to exclude from the ring. Instances in excluded zones will be filtered out from the ring.")
+
+	cfg.ZoneRollout.RegisterFlagsWithPrefix(prefix, f)
+}
+
+// Validate the ring config.
+func (cfg *Config) Validate() error {
+	return cfg.ZoneRollout.Validate(*cfg)
 }
 
 type instanceInfo struct {
@@ -216,6 +228,13 @@ type Ring struct {
 	duplicateTokensGauge    prometheus.Gauge
 	reportedOwners          map[string]struct{}
 
+	// Only set when the zone rollout coordination is enabled. The zoneRolloutMtx protects the
+	// zone rollout lease and the last ring received from the KV store, before applying the lease.
+	zoneRolloutKVClient kv.Client
+	zoneRolloutMtx      sync.Mutex
+	zoneRollout         *ZoneRolloutDesc
+	zoneRolloutRingDesc *Desc
+
 	logger log.Logger
 }
 
@@ -254,7 +273,24 @@ func New(cfg Config, name, key string, logger log.Logger, reg prometheus.Registe
 		return nil, err
 	}
 
-	return NewWithStoreClientAndStrategy(cfg, name, key, store, NewDefaultReplicationStrategy(), reg, logger)
+	r, err := NewWithStoreClientAndStrategy(cfg, name, key, store, NewDefaultReplicationStrategy(), reg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ZoneRollout.Enabled {
+		r.zoneRolloutKVClient, err = kv.NewClient(
+			cfg.KVStore,
+			GetZoneRolloutCodec(),
+			kv.RegistererWithKVName(reg, name+"-ring-zone-rollout"),
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
 }
 
 func NewWithStoreClientAndStrategy(cfg Config, name, key string, store kv.Client, strategy ReplicationStrategy, reg prometheus.Registerer, logger log.Logger) (*Ring, error) {
@@ -317,6 +353,15 @@ func (r *Ring) starting(ctx context.Context) error {
 	} else {
 		level.Info(r.logger).Log("msg", "ring doesn't exist in KV store yet")
 	}
+
+	if r.zoneRolloutKVClient != nil {
+		value, err := r.zoneRolloutKVClient.Get(ctx, zoneRolloutKey(r.key))
+		if err != nil {
+			return errors.Wrap(err, "unable to initialise zone rollout state")
+		}
+		lease, _ := value.(*ZoneRolloutDesc)
+		r.updateZoneRollout(lease)
+	}
 	return nil
 }
 
@@ -326,6 +371,16 @@ func (r *Ring) loop(ctx context.Context) error {
 	r.updateRingMetrics(Different)
 	r.mtx.Unlock()
 
+	if r.zoneRolloutKVClient != nil {
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.watchZoneRollout(ctx)
+		}()
+		defer wg.Wait()
+	}
+
 	r.KVClient.WatchKey(ctx, r.key, func(value any) bool {
 		if value == nil {
 			level.Info(r.logger).Log("msg", "ring doesn't exist in KV store yet")
@@ -339,6 +394,20 @@ func (r *Ring) loop(ctx context.Context) error {
 }
 
 func (r *Ring) updateRingState(ringDesc *Desc) {
+	if r.zoneRolloutKVClient != nil {
+		r.zoneRolloutMtx.Lock()
+		defer r.zoneRolloutMtx.Unlock()
+
+		// Keep the ring as received from the KV store, so that the zone rollout lease
+		// can be re-applied to it whenever the lease changes.
+		r.zoneRolloutRingDesc = ringDesc
+		ringDesc = r.applyZoneRollout(ringDesc, time.Now())
+	}
+
+	r.setRingState(ringDesc)
+}
+
+func (r *Ring) setRingState(ringDesc *Desc) {
 	r.mtx.RLock()
 	prevRing := r.ringDesc
 	r.mtx.RUnlock()
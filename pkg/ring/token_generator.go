@@ -29,6 +29,15 @@ type TokenGenerator interface {
 	GenerateTokens(ring *Desc, id, zone string, numTokens int, force bool) []uint32
 }
 
+// NewTokenGenerator returns the TokenGenerator of the given strategy. The random strategy
+// is used if the strategy is empty or unknown.
+func NewTokenGenerator(strategy string) TokenGenerator {
+	if strings.EqualFold(strategy, minimizeSpreadTokenStrategy) {
+		return NewMinimizeSpreadTokenGenerator()
+	}
+	return NewRandomTokenGenerator()
+}
+
 type RandomTokenGenerator struct{}
 
 func NewRandomTokenGenerator() TokenGenerator {
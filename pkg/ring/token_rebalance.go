@@ -0,0 +1,313 @@
+package ring
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+)
+
+var (
+	errTokenRebalanceInvalidLookbackPeriod   = errors.New("the token rebalance lookback period must be greater than or equal to 0")
+	errTokenRebalanceInvalidMaxOwnershipDiff = errors.New("the token rebalance max ownership diff must be greater than 0 and lower than 100")
+	errTokenRebalanceDisabled                = errors.New("the token rebalance is disabled, set the lookback period to enable it")
+	errTokenRebalanceNotActive               = errors.New("only ACTIVE instances can rebalance their tokens")
+)
+
+// TokenRebalanceConfig configures the rebalancing of the tokens of ACTIVE instances.
+type TokenRebalanceConfig struct {
+	LookbackPeriod   time.Duration `yaml:"lookback_period"`
+	MaxOwnershipDiff float64       `yaml:"max_ownership_diff"`
+}
+
+// RegisterFlagsWithPrefix registers the flags with the given prefix.
+func (cfg *TokenRebalanceConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&cfg.LookbackPeriod, prefix+"token-rebalance.lookback-period", 0, "EXPERIMENTAL: Period during which an instance taking over tokens from another instance is handled as just registered by the shuffle-sharding lookback, before the other instance drops the tokens. It must be greater than or equal to the highest shuffle-sharding ingesters lookback period of the tenants. 0 to disable the token rebalance.")
+	f.Float64Var(&cfg.MaxOwnershipDiff, prefix+"token-rebalance.max-ownership-diff", 5, "EXPERIMENTAL: The token rebalance of an instance completes once the token ranges owned by the instance are within this percentage of the expected ownership.")
+}
+
+func (cfg *TokenRebalanceConfig) Validate() error {
+	if cfg.LookbackPeriod < 0 {
+		return errTokenRebalanceInvalidLookbackPeriod
+	}
+	if cfg.LookbackPeriod > 0 && (cfg.MaxOwnershipDiff <= 0 || cfg.MaxOwnershipDiff >= 100) {
+		return errTokenRebalanceInvalidMaxOwnershipDiff
+	}
+	return nil
+}
+
+// TokenRebalanceStatus is the status of the token rebalance of a Lifecycler.
+type TokenRebalanceStatus struct {
+	Enabled       bool       `json:"enabled"`
+	Running       bool       `json:"running"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	TakenTokens   int        `json:"taken_tokens"`
+	DroppedTokens int        `json:"dropped_tokens"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// tokenRebalanceState tracks the progress of the token rebalance of a Lifecycler.
+type tokenRebalanceState struct {
+	mtx           sync.Mutex
+	running       bool
+	startedAt     time.Time
+	completedAt   time.Time
+	takenTokens   int
+	droppedTokens int
+	lastErr       error
+}
+
+// StartTokenRebalance starts rebalancing the tokens of this instance: at every heartbeat, the instance takes
+// over one token from the ACTIVE instance owning the largest token range in its zone, until the token range
+// owned by this instance is within the configured max ownership diff of the expected one.
+//
+// A token T is taken over by registering the token T-1, so that the instance owns the range previously owned
+// by T, and by resetting the registered timestamp of the instance. This way the shuffle-sharding lookback keeps
+// including the instance owning T in the tenants' shards, like it does when a new instance joins the ring. The
+// instance owning T drops it once the lookback period has elapsed since the last token taken over.
+func (i *Lifecycler) StartTokenRebalance() error {
+	if i.cfg.TokenRebalance.LookbackPeriod <= 0 {
+		return errTokenRebalanceDisabled
+	}
+	if i.GetState() != ACTIVE {
+		return errTokenRebalanceNotActive
+	}
+
+	i.tokenRebalance.mtx.Lock()
+	defer i.tokenRebalance.mtx.Unlock()
+
+	if i.tokenRebalance.running {
+		return nil
+	}
+
+	i.tokenRebalance.running = true
+	i.tokenRebalance.startedAt = time.Now()
+	i.tokenRebalance.completedAt = time.Time{}
+	i.tokenRebalance.takenTokens = 0
+	i.tokenRebalance.lastErr = nil
+	level.Info(i.logger).Log("msg", "starting token rebalance", "ring", i.RingName)
+	return nil
+}
+
+// StopTokenRebalance stops rebalancing the tokens of this instance. Tokens already taken over are kept.
+func (i *Lifecycler) StopTokenRebalance() {
+	i.tokenRebalance.mtx.Lock()
+	defer i.tokenRebalance.mtx.Unlock()
+
+	if i.tokenRebalance.running {
+		level.Info(i.logger).Log("msg", "stopping token rebalance", "ring", i.RingName, "taken_tokens", i.tokenRebalance.takenTokens)
+	}
+	i.tokenRebalance.running = false
+}
+
+// TokenRebalanceStatus returns the status of the token rebalance of this instance.
+func (i *Lifecycler) TokenRebalanceStatus() TokenRebalanceStatus {
+	i.tokenRebalance.mtx.Lock()
+	defer i.tokenRebalance.mtx.Unlock()
+
+	status := TokenRebalanceStatus{
+		Enabled:       i.cfg.TokenRebalance.LookbackPeriod > 0,
+		Running:       i.tokenRebalance.running,
+		TakenTokens:   i.tokenRebalance.takenTokens,
+		DroppedTokens: i.tokenRebalance.droppedTokens,
+	}
+	if !i.tokenRebalance.startedAt.IsZero() {
+		startedAt := i.tokenRebalance.startedAt
+		status.StartedAt = &startedAt
+	}
+	if !i.tokenRebalance.completedAt.IsZero() {
+		completedAt := i.tokenRebalance.completedAt
+		status.CompletedAt = &completedAt
+	}
+	if i.tokenRebalance.lastErr != nil {
+		status.LastError = i.tokenRebalance.lastErr.Error()
+	}
+	return status
+}
+
+// rebalanceTokens drops the tokens taken over by other instances once the lookback period has elapsed and,
+// if the token rebalance is running, takes over one token from another instance. NB this must be called
+// from loop()!
+func (i *Lifecycler) rebalanceTokens(ctx context.Context, now time.Time) {
+	i.tokenRebalance.mtx.Lock()
+	running := i.tokenRebalance.running
+	i.tokenRebalance.mtx.Unlock()
+
+	var (
+		tokens   Tokens
+		dropped  []uint32
+		taken    bool
+		balanced bool
+	)
+
+	err := i.KVStore.CAS(ctx, i.RingKey, func(in any) (out any, retry bool, err error) {
+		tokens, dropped, taken, balanced = nil, nil, false, false
+		if in == nil {
+			return nil, false, nil
+		}
+
+		ringDesc := in.(*Desc)
+		instance, ok := ringDesc.Ingesters[i.ID]
+		if !ok {
+			return nil, false, nil
+		}
+
+		tokens = slices.Clone(instance.Tokens)
+		dropped = settledRebalancedTokens(ringDesc, i.ID, i.cfg.TokenRebalance.LookbackPeriod, now)
+		if len(dropped) > 0 {
+			tokens = slices.DeleteFunc(tokens, func(token uint32) bool {
+				return slices.Contains(dropped, token)
+			})
+			instance.Tokens = tokens
+			ringDesc.Ingesters[i.ID] = instance
+		}
+
+		if running && instance.State == ACTIVE {
+			var token uint32
+			token, balanced = pickTokenToRebalance(ringDesc, i.ID, i.cfg.RingConfig.ZoneAwarenessEnabled, i.cfg.RingConfig.HeartbeatTimeout, i.cfg.TokenRebalance.MaxOwnershipDiff, now)
+			if !balanced {
+				tokens = append(tokens, token)
+				sort.Sort(tokens)
+				taken = true
+
+				// Resetting the registered timestamp extends the shuffle-sharding lookback to the instance
+				// previously owning the token range.
+				instance.Tokens = tokens
+				instance.RegisteredTimestamp = now.Unix()
+			}
+		}
+
+		if len(dropped) == 0 && !taken {
+			return nil, false, nil
+		}
+
+		instance.Timestamp = now.Unix()
+		ringDesc.Ingesters[i.ID] = instance
+		return ringDesc, true, nil
+	})
+
+	i.tokenRebalance.mtx.Lock()
+	defer i.tokenRebalance.mtx.Unlock()
+
+	if err != nil {
+		level.Error(i.logger).Log("msg", "failed to rebalance tokens", "ring", i.RingName, "err", err)
+		i.tokenRebalance.lastErr = err
+		return
+	}
+
+	if len(dropped) > 0 || taken {
+		i.setTokens(tokens)
+	}
+	if len(dropped) > 0 {
+		level.Info(i.logger).Log("msg", "dropped tokens taken over by other instances", "ring", i.RingName, "count", len(dropped))
+		i.tokenRebalance.droppedTokens += len(dropped)
+		i.lifecyclerMetrics.tokensRebalanced.WithLabelValues("dropped").Add(float64(len(dropped)))
+	}
+	if taken {
+		i.setRegisteredAt(now)
+		i.tokenRebalance.takenTokens++
+		i.lifecyclerMetrics.tokensRebalanced.WithLabelValues("taken").Inc()
+	}
+	if balanced && i.tokenRebalance.running {
+		level.Info(i.logger).Log("msg", "token rebalance completed", "ring", i.RingName, "taken_tokens", i.tokenRebalance.takenTokens)
+		i.tokenRebalance.running = false
+		i.tokenRebalance.completedAt = now
+	}
+}
+
+// settledRebalancedTokens returns the tokens of the instance which have been taken over by another instance
+// at least lookbackPeriod ago. A token T has been taken over when another instance owns the token T-1.
+func settledRebalancedTokens(ringDesc *Desc, instanceID string, lookbackPeriod time.Duration, now time.Time) []uint32 {
+	owners := ringDesc.getTokensInfo()
+	lookbackUntil := now.Add(-lookbackPeriod).Unix()
+
+	var settled []uint32
+	for _, token := range ringDesc.Ingesters[instanceID].Tokens {
+		owner, ok := owners[token-1]
+		if !ok || owner.InstanceID == instanceID {
+			continue
+		}
+		if receiver := ringDesc.Ingesters[owner.InstanceID]; receiver.State == ACTIVE && receiver.RegisteredTimestamp <= lookbackUntil {
+			settled = append(settled, token)
+		}
+	}
+	return settled
+}
+
+// pickTokenToRebalance returns the token the instance should register to take over a token range from the
+// ACTIVE instance owning the largest token range in its zone (or in the whole ring if zone-awareness is
+// disabled). It returns true if the instance ownership is already within maxOwnershipDiff percent of the
+// expected one, or if no token range can be taken over without exceeding the expected ownership.
+func pickTokenToRebalance(ringDesc *Desc, instanceID string, zoneAwarenessEnabled bool, heartbeatTimeout time.Duration, maxOwnershipDiff float64, now time.Time) (uint32, bool) {
+	instance := ringDesc.Ingesters[instanceID]
+
+	var tokens []uint32
+	if zoneAwarenessEnabled {
+		tokens = ringDesc.getTokensByZone()[instance.Zone]
+	} else {
+		tokens = ringDesc.GetTokens()
+	}
+	if len(tokens) == 0 {
+		return 0, true
+	}
+
+	// Compute the token range owned by each token and instance.
+	owners := ringDesc.getTokensInfo()
+	owned := map[string]int64{}
+	ranges := make([]int64, len(tokens))
+	for p, token := range tokens {
+		ranges[p] = tokenDistance(tokens[(p+len(tokens)-1)%len(tokens)], token)
+		owned[owners[token].InstanceID] += ranges[p]
+	}
+
+	expected := float64(maxTokenValue+1) / float64(len(owned))
+	missing := expected - float64(owned[instanceID])
+	if missing <= expected*maxOwnershipDiff/100 {
+		return 0, true
+	}
+
+	donorID := ""
+	for id, ownership := range owned {
+		donor := ringDesc.Ingesters[id]
+		if id == instanceID || donor.State != ACTIVE || !donor.IsHeartbeatHealthy(heartbeatTimeout, now) {
+			continue
+		}
+		if donorID == "" || ownership > owned[donorID] || (ownership == owned[donorID] && id < donorID) {
+			donorID = id
+		}
+	}
+	if donorID == "" {
+		return 0, true
+	}
+
+	// Take over the largest token range which doesn't make the instance own more than expected,
+	// nor the donor own less than expected.
+	maxRange := int64(min(missing, float64(owned[donorID])-expected))
+	found, picked := false, 0
+	for p, token := range tokens {
+		if owners[token].InstanceID != donorID || ranges[p] <= 1 || ranges[p] > maxRange {
+			continue
+		}
+		// Skip the tokens taken over by the donor itself which haven't been settled yet.
+		if next, ok := owners[token+1]; ok && next.InstanceID != donorID {
+			continue
+		}
+		if _, ok := owners[token-1]; ok {
+			continue
+		}
+		if !found || ranges[p] > ranges[picked] {
+			found, picked = true, p
+		}
+	}
+	if !found {
+		return 0, true
+	}
+
+	return tokens[picked] - 1, false
+}
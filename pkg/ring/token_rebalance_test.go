@@ -0,0 +1,205 @@
+package ring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestTokenRebalanceConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cfg         TokenRebalanceConfig
+		expectedErr error
+	}{
+		"should pass if disabled": {
+			cfg: TokenRebalanceConfig{},
+		},
+		"should pass if enabled": {
+			cfg: TokenRebalanceConfig{LookbackPeriod: time.Hour, MaxOwnershipDiff: 5},
+		},
+		"should fail with a negative lookback period": {
+			cfg:         TokenRebalanceConfig{LookbackPeriod: -time.Hour},
+			expectedErr: errTokenRebalanceInvalidLookbackPeriod,
+		},
+		"should fail if enabled with no max ownership diff": {
+			cfg:         TokenRebalanceConfig{LookbackPeriod: time.Hour},
+			expectedErr: errTokenRebalanceInvalidMaxOwnershipDiff,
+		},
+		"should fail if enabled with a max ownership diff of 100%": {
+			cfg:         TokenRebalanceConfig{LookbackPeriod: time.Hour, MaxOwnershipDiff: 100},
+			expectedErr: errTokenRebalanceInvalidMaxOwnershipDiff,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testData.expectedErr, testData.cfg.Validate())
+		})
+	}
+}
+
+func TestPickTokenToRebalance(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := map[string]struct {
+		instances            map[string]InstanceDesc
+		zoneAwarenessEnabled bool
+		expectedToken        uint32
+		expectedBalanced     bool
+	}{
+		"should take over the largest range of the instance owning the most": {
+			instances: map[string]InstanceDesc{
+				"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{3<<30 + 1<<28}, now),
+				"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{1 << 30, 1 << 31, 3 << 30}, now),
+			},
+			expectedToken: 1<<30 - 1,
+		},
+		"should skip the ranges exceeding the expected ownership": {
+			instances: map[string]InstanceDesc{
+				"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{3 << 29}, now),
+				"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{0, 1 << 29}, now),
+			},
+			expectedToken: 1<<29 - 1,
+		},
+		"should not take over ranges from instances which are not ACTIVE": {
+			instances: map[string]InstanceDesc{
+				"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{1 << 29}, now),
+				"instance-2": {Addr: "instance-2", Zone: "zone-a", State: READONLY, Timestamp: now.Unix(), Tokens: []uint32{1 << 28, 1 << 30, 1 << 31}},
+			},
+			expectedBalanced: true,
+		},
+		"should not take over tokens which the donor hasn't settled yet": {
+			instances: map[string]InstanceDesc{
+				"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{1 << 28}, now),
+				"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{1<<30 - 1, 1 << 31, 3 << 30}, now),
+				"instance-3": generateRingInstanceWithInfo("instance-3", "zone-a", []uint32{1 << 30, 15 << 28}, now),
+			},
+			expectedBalanced: true,
+		},
+		"should complete once the ownership is within the max diff": {
+			instances: map[string]InstanceDesc{
+				"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{1 << 30, 2<<30 + 1<<25}, now),
+				"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{0, 3 << 30}, now),
+			},
+			expectedBalanced: true,
+		},
+		"should only consider the instance zone when zone-awareness is enabled": {
+			instances: map[string]InstanceDesc{
+				"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{3<<30 + 1<<28}, now),
+				"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{1 << 30, 1 << 31, 3 << 30}, now),
+				"instance-3": generateRingInstanceWithInfo("instance-3", "zone-b", []uint32{1 << 29}, now),
+			},
+			zoneAwarenessEnabled: true,
+			expectedToken:        1<<30 - 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			token, balanced := pickTokenToRebalance(&Desc{Ingesters: testData.instances}, "instance-1", testData.zoneAwarenessEnabled, time.Minute, 5, now)
+			assert.Equal(t, testData.expectedBalanced, balanced)
+			if !testData.expectedBalanced {
+				assert.Equal(t, testData.expectedToken, token)
+			}
+		})
+	}
+}
+
+func TestSettledRebalancedTokens(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ringDesc := &Desc{Ingesters: map[string]InstanceDesc{
+		"instance-1": generateRingInstanceWithInfo("instance-1", "zone-a", []uint32{100, 200, 300, 400}, now.Add(-2*time.Hour)),
+		"instance-2": generateRingInstanceWithInfo("instance-2", "zone-a", []uint32{199, 500}, now.Add(-2*time.Hour)),
+		"instance-3": generateRingInstanceWithInfo("instance-3", "zone-a", []uint32{299}, now.Add(-time.Minute)),
+		"instance-4": {Addr: "instance-4", Zone: "zone-a", State: LEAVING, Tokens: []uint32{399}, RegisteredTimestamp: now.Add(-2 * time.Hour).Unix()},
+	}}
+
+	// Only the token taken over by the ACTIVE instance registered before the lookback period is settled.
+	assert.Equal(t, []uint32{200}, settledRebalancedTokens(ringDesc, "instance-1", time.Hour, now))
+	assert.Empty(t, settledRebalancedTokens(ringDesc, "instance-2", time.Hour, now))
+}
+
+func TestLifecycler_TokenRebalance(t *testing.T) {
+	ringStore, closer := consul.NewInMemoryClient(GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	var ringConfig Config
+	flagext.DefaultValues(&ringConfig)
+	ringConfig.KVStore.Mock = ringStore
+
+	// instance-2 owns almost the whole ring, split in 64 ranges of the same size.
+	registeredAt := time.Now().Add(-time.Hour)
+	var donorTokens []uint32
+	for i := range 64 {
+		donorTokens = append(donorTokens, uint32(i)<<26)
+	}
+	require.NoError(t, ringStore.CAS(context.Background(), ringKey, func(any) (any, bool, error) {
+		return &Desc{Ingesters: map[string]InstanceDesc{
+			"instance-1": generateRingInstanceWithInfo("instance-1", "zone1", []uint32{10<<26 + 1<<25}, registeredAt),
+			"instance-2": generateRingInstanceWithInfo("instance-2", "zone1", donorTokens, registeredAt),
+		}}, true, nil
+	}))
+
+	startLifecycler := func(id string) *Lifecycler {
+		cfg := testLifecyclerConfig(ringConfig, id)
+		cfg.TokenRebalance = TokenRebalanceConfig{LookbackPeriod: time.Second, MaxOwnershipDiff: 5}
+
+		l, err := NewLifecycler(cfg, &nopFlushTransferer{}, "ingester", ringKey, true, true, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), l))
+		t.Cleanup(func() {
+			assert.NoError(t, services.StopAndAwaitTerminated(context.Background(), l))
+		})
+		return l
+	}
+
+	receiver := startLifecycler("instance-1")
+	donor := startLifecycler("instance-2")
+	test.Poll(t, time.Second, ACTIVE, func() any { return receiver.GetState() })
+
+	require.NoError(t, receiver.StartTokenRebalance())
+
+	// The receiver takes over one token per heartbeat, until it owns about half of the ring.
+	test.Poll(t, 10*time.Second, false, func() any {
+		return receiver.TokenRebalanceStatus().Running
+	})
+	status := receiver.TokenRebalanceStatus()
+	require.NotNil(t, status.CompletedAt)
+	assert.Equal(t, 30, status.TakenTokens)
+	assert.Len(t, receiver.getTokens(), 31)
+
+	getRingDesc := func() *Desc {
+		value, err := ringStore.Get(context.Background(), ringKey)
+		require.NoError(t, err)
+		return value.(*Desc)
+	}
+	report := computeOwnershipReport(getRingDesc(), false, nil)
+	assert.InDelta(t, 50, report.Instances[0].Ownership, 2.5)
+
+	// The donor drops its tokens once the lookback period has elapsed since the last token taken over.
+	test.Poll(t, 5*time.Second, 64-30, func() any {
+		return len(donor.getTokens())
+	})
+	assert.Equal(t, 30, donor.TokenRebalanceStatus().DroppedTokens)
+	assert.Len(t, getRingDesc().Ingesters["instance-2"].Tokens, 64-30)
+
+	// Dropping the tokens doesn't change the ownership.
+	assert.InDelta(t, report.Instances[0].Ownership, computeOwnershipReport(getRingDesc(), false, nil).Instances[0].Ownership, 0.001)
+}
@@ -0,0 +1,335 @@
+package ring
+
+import (
+	"context"
+	"flag"
+	"html/template"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+)
+
+var (
+	errZoneRolloutZoneAwarenessDisabled = errors.New("zone rollout requires zone-awareness to be enabled")
+	errZoneRolloutInvalidKVStore        = errors.New("zone rollout requires the ring KV store to be consul, etcd or multi")
+	errZoneRolloutInvalidAcquireTimeout = errors.New("zone rollout acquire timeout must be greater than 0")
+	errZoneRolloutInvalidLeaseTimeout   = errors.New("zone rollout lease timeout must be greater than 0")
+
+	// zoneRolloutRetryPeriod is how frequently a stopping instance retries to acquire the
+	// zone rollout lease while it's held by another zone. Overridden by tests.
+	zoneRolloutRetryPeriod = time.Second
+)
+
+// ZoneRolloutConfig configures the coordination of rollouts between zones. When enabled, an
+// ACTIVE instance being shut down acquires the rollout lease of its zone before leaving the ring,
+// waiting for the rollout of any other zone to complete first. While a zone holds the lease, the
+// ring clients consider all its ACTIVE instances as READONLY, so writes go to the other zones.
+type ZoneRolloutConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	AcquireTimeout time.Duration `yaml:"acquire_timeout"`
+	LeaseTimeout   time.Duration `yaml:"lease_timeout"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet with a specified prefix.
+func (cfg *ZoneRolloutConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"ring.zone-rollout.enabled", false, "EXPERIMENTAL: True to coordinate rollouts between zones. An instance being shut down waits until no other zone is being rolled out, and the ring clients handle all the instances of the zone being rolled out as READONLY. Requires zone-awareness, and the ring KV store to be consul, etcd or multi.")
+	f.DurationVar(&cfg.AcquireTimeout, prefix+"ring.zone-rollout.acquire-timeout", 10*time.Minute, "EXPERIMENTAL: How long an instance being shut down waits for another zone's rollout to complete. Once elapsed, the instance shuts down anyway.")
+	f.DurationVar(&cfg.LeaseTimeout, prefix+"ring.zone-rollout.lease-timeout", 30*time.Minute, "EXPERIMENTAL: How long the rollout of a zone is considered in progress after the last of its instances has been shut down, if they don't become ACTIVE again in the meanwhile.")
+}
+
+// Validate the zone rollout config, given the config of the ring it coordinates.
+func (cfg *ZoneRolloutConfig) Validate(ringCfg Config) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !ringCfg.ZoneAwarenessEnabled {
+		return errZoneRolloutZoneAwarenessDisabled
+	}
+	if !slices.Contains([]string{"consul", "etcd", "multi"}, ringCfg.KVStore.Store) {
+		return errZoneRolloutInvalidKVStore
+	}
+	if cfg.AcquireTimeout <= 0 {
+		return errZoneRolloutInvalidAcquireTimeout
+	}
+	if cfg.LeaseTimeout <= 0 {
+		return errZoneRolloutInvalidLeaseTimeout
+	}
+	return nil
+}
+
+// ProtoZoneRolloutDescFactory makes new ZoneRolloutDesc.
+func ProtoZoneRolloutDescFactory() proto.Message {
+	return &ZoneRolloutDesc{}
+}
+
+// GetZoneRolloutCodec returns the codec used to encode and decode the zone rollout lease.
+func GetZoneRolloutCodec() codec.Codec {
+	return codec.NewProtoCodec("zoneRolloutDesc", ProtoZoneRolloutDescFactory)
+}
+
+// zoneRolloutKey returns the KV store key of the zone rollout lease for the ring stored at ringKey.
+func zoneRolloutKey(ringKey string) string {
+	return ringKey + "-zone-rollout"
+}
+
+// isActive returns whether the lease is held by a zone and hasn't expired yet.
+func (d *ZoneRolloutDesc) isActive(now time.Time, leaseTimeout time.Duration) bool {
+	return d != nil && d.Zone != "" && now.Before(time.Unix(d.UpdatedAt, 0).Add(leaseTimeout))
+}
+
+// acquire adds the instance to the lease of the zone. It returns false, without modifying the
+// lease, if the lease is currently held by another zone.
+func (d *ZoneRolloutDesc) acquire(zone, instanceID string, now time.Time, leaseTimeout time.Duration) bool {
+	active := d.isActive(now, leaseTimeout)
+	if active && d.Zone != zone {
+		return false
+	}
+
+	if !active {
+		d.Zone = zone
+		d.Instances = map[string]int64{}
+		d.AcquiredAt = now.Unix()
+	}
+	if d.Instances == nil {
+		d.Instances = map[string]int64{}
+	}
+	d.Instances[instanceID] = now.Unix()
+	d.UpdatedAt = now.Unix()
+	return true
+}
+
+// release removes the instance from the lease, and releases the lease once no instance of the
+// zone holds it anymore. It returns false if the instance doesn't hold the lease.
+func (d *ZoneRolloutDesc) release(zone, instanceID string, now time.Time) bool {
+	if d.Zone != zone {
+		return false
+	}
+	if _, ok := d.Instances[instanceID]; !ok {
+		return false
+	}
+
+	delete(d.Instances, instanceID)
+	if len(d.Instances) == 0 {
+		*d = ZoneRolloutDesc{}
+		return true
+	}
+
+	d.UpdatedAt = now.Unix()
+	return true
+}
+
+// acquireZoneRollout waits until the zone rollout lease is acquired by the instance, or the
+// acquire timeout expires. The instance keeps heartbeating the ring while waiting.
+func (i *Lifecycler) acquireZoneRollout(heartbeatTickerChan <-chan time.Time) {
+	acquireTimeout := time.NewTimer(i.cfg.RingConfig.ZoneRollout.AcquireTimeout)
+	defer acquireTimeout.Stop()
+
+	retryTicker := time.NewTicker(zoneRolloutRetryPeriod)
+	defer retryTicker.Stop()
+
+	waiting := false
+	for {
+		holder, err := i.tryAcquireZoneRollout(context.Background())
+		switch {
+		case err != nil:
+			level.Warn(i.logger).Log("msg", "failed to acquire the zone rollout lease", "ring", i.RingName, "err", err)
+		case holder == i.Zone:
+			level.Info(i.logger).Log("msg", "zone rollout lease acquired", "ring", i.RingName, "zone", i.Zone)
+			return
+		case !waiting:
+			level.Info(i.logger).Log("msg", "waiting for the rollout of another zone to complete before shutting down", "ring", i.RingName, "zone", i.Zone, "rollout_zone", holder)
+			waiting = true
+		}
+
+		select {
+		case <-heartbeatTickerChan:
+			i.lifecyclerMetrics.consulHeartbeats.Inc()
+			if err := i.updateConsul(context.Background()); err != nil {
+				level.Error(i.logger).Log("msg", "failed to write to the KV store, sleeping", "ring", i.RingName, "err", err)
+			}
+		case <-retryTicker.C:
+		case <-acquireTimeout.C:
+			level.Warn(i.logger).Log("msg", "timed out waiting for the rollout of another zone to complete, shutting down anyway", "ring", i.RingName, "zone", i.Zone, "rollout_zone", holder)
+			return
+		}
+	}
+}
+
+// tryAcquireZoneRollout tries to acquire the zone rollout lease for the instance, and returns
+// the zone holding the lease.
+func (i *Lifecycler) tryAcquireZoneRollout(ctx context.Context) (string, error) {
+	var holder string
+
+	err := i.zoneRolloutKVStore.CAS(ctx, zoneRolloutKey(i.RingKey), func(in any) (out any, retry bool, err error) {
+		lease, _ := in.(*ZoneRolloutDesc)
+		if lease == nil {
+			lease = &ZoneRolloutDesc{}
+		}
+
+		if !lease.acquire(i.Zone, i.ID, time.Now(), i.cfg.RingConfig.ZoneRollout.LeaseTimeout) {
+			holder = lease.Zone
+			return nil, false, nil
+		}
+
+		holder = i.Zone
+		return lease, true, nil
+	})
+
+	return holder, err
+}
+
+// releaseZoneRollout releases the zone rollout lease held by the instance, if any. It returns
+// whether the lease doesn't need to be released anymore.
+func (i *Lifecycler) releaseZoneRollout(ctx context.Context) bool {
+	released := false
+
+	err := i.zoneRolloutKVStore.CAS(ctx, zoneRolloutKey(i.RingKey), func(in any) (out any, retry bool, err error) {
+		lease, _ := in.(*ZoneRolloutDesc)
+		if lease == nil || !lease.release(i.Zone, i.ID, time.Now()) {
+			return nil, false, nil
+		}
+
+		released = true
+		return lease, true, nil
+	})
+	if err != nil {
+		level.Warn(i.logger).Log("msg", "failed to release the zone rollout lease", "ring", i.RingName, "err", err)
+		return false
+	}
+
+	if released {
+		level.Info(i.logger).Log("msg", "zone rollout lease released", "ring", i.RingName, "zone", i.Zone)
+	}
+	return true
+}
+
+// watchZoneRollout keeps the zone rollout lease updated until the context is canceled.
+func (r *Ring) watchZoneRollout(ctx context.Context) {
+	r.zoneRolloutKVClient.WatchKey(ctx, zoneRolloutKey(r.key), func(value any) bool {
+		lease, _ := value.(*ZoneRolloutDesc)
+		r.updateZoneRollout(lease)
+		return true
+	})
+}
+
+// updateZoneRollout updates the zone rollout lease, and re-applies it to the last ring received
+// from the KV store.
+func (r *Ring) updateZoneRollout(lease *ZoneRolloutDesc) {
+	r.zoneRolloutMtx.Lock()
+	defer r.zoneRolloutMtx.Unlock()
+
+	r.zoneRollout = lease
+	if r.zoneRolloutRingDesc != nil {
+		r.setRingState(r.applyZoneRollout(r.zoneRolloutRingDesc, time.Now()))
+	}
+}
+
+// applyZoneRollout returns the ring with all the ACTIVE instances of the zone being rolled out
+// set to READONLY. The input ring is never modified. Must be called with the zoneRolloutMtx held.
+func (r *Ring) applyZoneRollout(ringDesc *Desc, now time.Time) *Desc {
+	if !r.zoneRollout.isActive(now, r.cfg.ZoneRollout.LeaseTimeout) {
+		return ringDesc
+	}
+
+	out := ringDesc.Clone().(*Desc)
+	for id, instance := range out.Ingesters {
+		if instance.Zone == r.zoneRollout.Zone && instance.State == ACTIVE {
+			instance.State = READONLY
+			out.Ingesters[id] = instance
+		}
+	}
+	return out
+}
+
+const zoneRolloutPageContent = `
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Zone Rollout Status</title>
+	</head>
+	<body>
+		<h1>Zone Rollout Status</h1>
+		<p>Current time: {{ .Now }}</p>
+		{{ if not .Enabled }}
+		<p>Zone rollout coordination is disabled.</p>
+		{{ else if not .Active }}
+		<p>No zone is being rolled out.</p>
+		{{ else }}
+		<p>Zone being rolled out: <strong>{{ .Zone }}</strong></p>
+		<p>Acquired at: {{ .AcquiredAt }}</p>
+		<p>Updated at: {{ .UpdatedAt }}</p>
+		<p>Expires at: {{ .ExpiresAt }}</p>
+		<table width="100%" border="1">
+			<thead>
+				<tr>
+					<th>Instance ID</th>
+					<th>Acquired At</th>
+				</tr>
+			</thead>
+			<tbody>
+				{{ range .Instances }}
+				<tr>
+					<td>{{ .ID }}</td>
+					<td>{{ .AcquiredAt }}</td>
+				</tr>
+				{{ end }}
+			</tbody>
+		</table>
+		{{ end }}
+	</body>
+</html>`
+
+var zoneRolloutPageTemplate = template.Must(template.New("zoneRollout").Parse(zoneRolloutPageContent))
+
+type zoneRolloutInstance struct {
+	ID         string    `json:"id"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+type zoneRolloutResponse struct {
+	Enabled    bool                  `json:"enabled"`
+	Active     bool                  `json:"active"`
+	Zone       string                `json:"zone"`
+	AcquiredAt time.Time             `json:"acquired_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+	ExpiresAt  time.Time             `json:"expires_at"`
+	Instances  []zoneRolloutInstance `json:"instances"`
+	Now        time.Time             `json:"now"`
+}
+
+// ZoneRolloutHandler shows the status of the zone rollout.
+func (r *Ring) ZoneRolloutHandler(w http.ResponseWriter, req *http.Request) {
+	now := time.Now()
+	resp := zoneRolloutResponse{
+		Enabled: r.zoneRolloutKVClient != nil,
+		Now:     now,
+	}
+
+	r.zoneRolloutMtx.Lock()
+	lease := r.zoneRollout
+	if lease.isActive(now, r.cfg.ZoneRollout.LeaseTimeout) {
+		resp.Active = true
+		resp.Zone = lease.Zone
+		resp.AcquiredAt = time.Unix(lease.AcquiredAt, 0)
+		resp.UpdatedAt = time.Unix(lease.UpdatedAt, 0)
+		resp.ExpiresAt = resp.UpdatedAt.Add(r.cfg.ZoneRollout.LeaseTimeout)
+		for id, acquiredAt := range lease.Instances {
+			resp.Instances = append(resp.Instances, zoneRolloutInstance{ID: id, AcquiredAt: time.Unix(acquiredAt, 0)})
+		}
+	}
+	r.zoneRolloutMtx.Unlock()
+
+	sort.Slice(resp.Instances, func(i, j int) bool {
+		return resp.Instances[i].ID < resp.Instances[j].ID
+	})
+
+	renderHTTPResponse(w, resp, zoneRolloutPageTemplate, req)
+}
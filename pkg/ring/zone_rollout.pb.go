@@ -0,0 +1,680 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: zone_rollout.proto
+
+package ring
+
+import (
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// ZoneRolloutDesc is the lease held by the zone whose instances are being restarted.
+type ZoneRolloutDesc struct {
+	// The zone holding the lease. Empty if no zone is being rolled out.
+	Zone string `protobuf:"bytes,1,opt,name=zone,proto3" json:"zone,omitempty"`
+	// The instances of the zone which have acquired the lease and haven't
+	// released it yet, mapped to the Unix timestamp (with seconds precision)
+	// at which they acquired it.
+	Instances map[string]int64 `protobuf:"bytes,2,rep,name=instances,proto3" json:"instances,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Unix timestamps (with seconds precision) of when the lease has been
+	// acquired by the zone and last updated.
+	AcquiredAt int64 `protobuf:"varint,3,opt,name=acquired_at,json=acquiredAt,proto3" json:"acquired_at,omitempty"`
+	UpdatedAt  int64 `protobuf:"varint,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (m *ZoneRolloutDesc) Reset()      { *m = ZoneRolloutDesc{} }
+func (*ZoneRolloutDesc) ProtoMessage() {}
+func (*ZoneRolloutDesc) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2d13bc963f559c32, []int{0}
+}
+func (m *ZoneRolloutDesc) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ZoneRolloutDesc) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ZoneRolloutDesc.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ZoneRolloutDesc) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ZoneRolloutDesc.Merge(m, src)
+}
+func (m *ZoneRolloutDesc) XXX_Size() int {
+	return m.Size()
+}
+func (m *ZoneRolloutDesc) XXX_DiscardUnknown() {
+	xxx_messageInfo_ZoneRolloutDesc.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ZoneRolloutDesc proto.InternalMessageInfo
+
+func (m *ZoneRolloutDesc) GetZone() string {
+	if m != nil {
+		return m.Zone
+	}
+	return ""
+}
+
+func (m *ZoneRolloutDesc) GetInstances() map[string]int64 {
+	if m != nil {
+		return m.Instances
+	}
+	return nil
+}
+
+func (m *ZoneRolloutDesc) GetAcquiredAt() int64 {
+	if m != nil {
+		return m.AcquiredAt
+	}
+	return 0
+}
+
+func (m *ZoneRolloutDesc) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*ZoneRolloutDesc)(nil), "ring.ZoneRolloutDesc")
+	proto.RegisterMapType((map[string]int64)(nil), "ring.ZoneRolloutDesc.InstancesEntry")
+}
+
+func init() { proto.RegisterFile("zone_rollout.proto", fileDescriptor_2d13bc963f559c32) }
+
+var fileDescriptor_2d13bc963f559c32 = []byte{
+	// 274 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0xaa, 0xca, 0xcf, 0x4b,
+	0x8d, 0x2f, 0xca, 0xcf, 0xc9, 0xc9, 0x2f, 0x2d, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62,
+	0x29, 0xca, 0xcc, 0x4b, 0x97, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0x0b, 0xe8, 0x83, 0x58, 0x10,
+	0x39, 0xa5, 0xe7, 0x8c, 0x5c, 0xfc, 0x51, 0xf9, 0x79, 0xa9, 0x41, 0x10, 0x1d, 0x2e, 0xa9, 0xc5,
+	0xc9, 0x42, 0x42, 0x5c, 0x2c, 0x20, 0x53, 0x24, 0x18, 0x15, 0x18, 0x35, 0x38, 0x83, 0xc0, 0x6c,
+	0x21, 0x27, 0x2e, 0xce, 0xcc, 0xbc, 0xe2, 0x92, 0xc4, 0xbc, 0xe4, 0xd4, 0x62, 0x09, 0x26, 0x05,
+	0x66, 0x0d, 0x6e, 0x23, 0x15, 0x3d, 0x90, 0xb9, 0x7a, 0x68, 0xba, 0xf5, 0x3c, 0x61, 0xca, 0x5c,
+	0xf3, 0x4a, 0x8a, 0x2a, 0x83, 0x10, 0xda, 0x84, 0xe4, 0xb9, 0xb8, 0x13, 0x93, 0x0b, 0x4b, 0x33,
+	0x8b, 0x52, 0x53, 0xe2, 0x13, 0x4b, 0x24, 0x98, 0x15, 0x18, 0x35, 0x98, 0x83, 0xb8, 0x60, 0x42,
+	0x8e, 0x25, 0x42, 0xb2, 0x5c, 0x5c, 0xa5, 0x05, 0x29, 0x89, 0x25, 0x10, 0x79, 0x16, 0xb0, 0x3c,
+	0x27, 0x54, 0xc4, 0xb1, 0x44, 0xca, 0x86, 0x8b, 0x0f, 0xd5, 0x70, 0x21, 0x01, 0x2e, 0xe6, 0xec,
+	0xd4, 0x4a, 0xa8, 0x43, 0x41, 0x4c, 0x21, 0x11, 0x2e, 0xd6, 0xb2, 0xc4, 0x9c, 0xd2, 0x54, 0x09,
+	0x26, 0xb0, 0x6e, 0x08, 0xc7, 0x8a, 0xc9, 0x82, 0xd1, 0xc9, 0xe4, 0xc2, 0x43, 0x39, 0x86, 0x1b,
+	0x0f, 0xe5, 0x18, 0x3e, 0x3c, 0x94, 0x63, 0x6c, 0x78, 0x24, 0xc7, 0xb8, 0xe2, 0x91, 0x1c, 0xe3,
+	0x89, 0x47, 0x72, 0x8c, 0x17, 0x1e, 0xc9, 0x31, 0x3e, 0x78, 0x24, 0xc7, 0xf8, 0xe2, 0x91, 0x1c,
+	0xc3, 0x87, 0x47, 0x72, 0x8c, 0x13, 0x1e, 0xcb, 0x31, 0x5c, 0x78, 0x2c, 0xc7, 0x70, 0xe3, 0xb1,
+	0x1c, 0x43, 0x12, 0x1b, 0x38, 0x98, 0x8c, 0x01, 0x03, 0x00, 0x49, 0x08, 0x0d, 0xd8, 0x58, 0x01,
+	0x00, 0x00,
+}
+
+func (this *ZoneRolloutDesc) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ZoneRolloutDesc)
+	if !ok {
+		that2, ok := that.(ZoneRolloutDesc)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Zone != that1.Zone {
+		return false
+	}
+	if len(this.Instances) != len(that1.Instances) {
+		return false
+	}
+	for i := range this.Instances {
+		if this.Instances[i] != that1.Instances[i] {
+			return false
+		}
+	}
+	if this.AcquiredAt != that1.AcquiredAt {
+		return false
+	}
+	if this.UpdatedAt != that1.UpdatedAt {
+		return false
+	}
+	return true
+}
+func (this *ZoneRolloutDesc) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&ring.ZoneRolloutDesc{")
+	s = append(s, "Zone: "+fmt.Sprintf("%#v", this.Zone)+",\n")
+	keysForInstances := make([]string, 0, len(this.Instances))
+	for k, _ := range this.Instances {
+		keysForInstances = append(keysForInstances, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForInstances)
+	mapStringForInstances := "map[string]int64{"
+	for _, k := range keysForInstances {
+		mapStringForInstances += fmt.Sprintf("%#v: %#v,", k, this.Instances[k])
+	}
+	mapStringForInstances += "}"
+	if this.Instances != nil {
+		s = append(s, "Instances: "+mapStringForInstances+",\n")
+	}
+	s = append(s, "AcquiredAt: "+fmt.Sprintf("%#v", this.AcquiredAt)+",\n")
+	s = append(s, "UpdatedAt: "+fmt.Sprintf("%#v", this.UpdatedAt)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringZoneRollout(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+func (m *ZoneRolloutDesc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ZoneRolloutDesc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ZoneRolloutDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.UpdatedAt != 0 {
+		i = encodeVarintZoneRollout(dAtA, i, uint64(m.UpdatedAt))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.AcquiredAt != 0 {
+		i = encodeVarintZoneRollout(dAtA, i, uint64(m.AcquiredAt))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Instances) > 0 {
+		for k := range m.Instances {
+			v := m.Instances[k]
+			baseI := i
+			i = encodeVarintZoneRollout(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintZoneRollout(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintZoneRollout(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Zone) > 0 {
+		i -= len(m.Zone)
+		copy(dAtA[i:], m.Zone)
+		i = encodeVarintZoneRollout(dAtA, i, uint64(len(m.Zone)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintZoneRollout(dAtA []byte, offset int, v uint64) int {
+	offset -= sovZoneRollout(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ZoneRolloutDesc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Zone)
+	if l > 0 {
+		n += 1 + l + sovZoneRollout(uint64(l))
+	}
+	if len(m.Instances) > 0 {
+		for k, v := range m.Instances {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovZoneRollout(uint64(len(k))) + 1 + sovZoneRollout(uint64(v))
+			n += mapEntrySize + 1 + sovZoneRollout(uint64(mapEntrySize))
+		}
+	}
+	if m.AcquiredAt != 0 {
+		n += 1 + sovZoneRollout(uint64(m.AcquiredAt))
+	}
+	if m.UpdatedAt != 0 {
+		n += 1 + sovZoneRollout(uint64(m.UpdatedAt))
+	}
+	return n
+}
+
+func sovZoneRollout(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozZoneRollout(x uint64) (n int) {
+	return sovZoneRollout(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *ZoneRolloutDesc) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForInstances := make([]string, 0, len(this.Instances))
+	for k, _ := range this.Instances {
+		keysForInstances = append(keysForInstances, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForInstances)
+	mapStringForInstances := "map[string]int64{"
+	for _, k := range keysForInstances {
+		mapStringForInstances += fmt.Sprintf("%v: %v,", k, this.Instances[k])
+	}
+	mapStringForInstances += "}"
+	s := strings.Join([]string{`&ZoneRolloutDesc{`,
+		`Zone:` + fmt.Sprintf("%v", this.Zone) + `,`,
+		`Instances:` + mapStringForInstances + `,`,
+		`AcquiredAt:` + fmt.Sprintf("%v", this.AcquiredAt) + `,`,
+		`UpdatedAt:` + fmt.Sprintf("%v", this.UpdatedAt) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringZoneRollout(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *ZoneRolloutDesc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowZoneRollout
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ZoneRolloutDesc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ZoneRolloutDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Zone", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowZoneRollout
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthZoneRollout
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthZoneRollout
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Zone = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Instances", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowZoneRollout
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthZoneRollout
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthZoneRollout
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Instances == nil {
+				m.Instances = make(map[string]int64)
+			}
+			var mapkey string
+			var mapvalue int64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowZoneRollout
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowZoneRollout
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthZoneRollout
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthZoneRollout
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowZoneRollout
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipZoneRollout(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthZoneRollout
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Instances[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AcquiredAt", wireType)
+			}
+			m.AcquiredAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowZoneRollout
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AcquiredAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UpdatedAt", wireType)
+			}
+			m.UpdatedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowZoneRollout
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UpdatedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipZoneRollout(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthZoneRollout
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthZoneRollout
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipZoneRollout(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowZoneRollout
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowZoneRollout
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+			return iNdEx, nil
+		case 1:
+			iNdEx += 8
+			return iNdEx, nil
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowZoneRollout
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthZoneRollout
+			}
+			iNdEx += length
+			if iNdEx < 0 {
+				return 0, ErrInvalidLengthZoneRollout
+			}
+			return iNdEx, nil
+		case 3:
+			for {
+				var innerWire uint64
+				var start int = iNdEx
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return 0, ErrIntOverflowZoneRollout
+					}
+					if iNdEx >= l {
+						return 0, io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					innerWire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				innerWireType := int(innerWire & 0x7)
+				if innerWireType == 4 {
+					break
+				}
+				next, err := skipZoneRollout(dAtA[start:])
+				if err != nil {
+					return 0, err
+				}
+				iNdEx = start + next
+				if iNdEx < 0 {
+					return 0, ErrInvalidLengthZoneRollout
+				}
+			}
+			return iNdEx, nil
+		case 4:
+			return iNdEx, nil
+		case 5:
+			iNdEx += 4
+			return iNdEx, nil
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+	}
+	panic("unreachable")
+}
+
+var (
+	ErrInvalidLengthZoneRollout = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowZoneRollout   = fmt.Errorf("proto: integer overflow")
+)
@@ -0,0 +1,251 @@
+package ring
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestZoneRolloutConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		ringCfg     Config
+		expectedErr error
+	}{
+		"should pass if disabled": {
+			ringCfg: Config{KVStore: kv.Config{Store: "memberlist"}},
+		},
+		"should pass if enabled with zone-awareness and consul": {
+			ringCfg: Config{
+				KVStore:              kv.Config{Store: "consul"},
+				ZoneAwarenessEnabled: true,
+				ZoneRollout:          ZoneRolloutConfig{Enabled: true, AcquireTimeout: time.Minute, LeaseTimeout: time.Minute},
+			},
+		},
+		"should fail if enabled without zone-awareness": {
+			ringCfg: Config{
+				KVStore:     kv.Config{Store: "consul"},
+				ZoneRollout: ZoneRolloutConfig{Enabled: true, AcquireTimeout: time.Minute, LeaseTimeout: time.Minute},
+			},
+			expectedErr: errZoneRolloutZoneAwarenessDisabled,
+		},
+		"should fail if enabled with memberlist": {
+			ringCfg: Config{
+				KVStore:              kv.Config{Store: "memberlist"},
+				ZoneAwarenessEnabled: true,
+				ZoneRollout:          ZoneRolloutConfig{Enabled: true, AcquireTimeout: time.Minute, LeaseTimeout: time.Minute},
+			},
+			expectedErr: errZoneRolloutInvalidKVStore,
+		},
+		"should fail if enabled with no acquire timeout": {
+			ringCfg: Config{
+				KVStore:              kv.Config{Store: "etcd"},
+				ZoneAwarenessEnabled: true,
+				ZoneRollout:          ZoneRolloutConfig{Enabled: true, LeaseTimeout: time.Minute},
+			},
+			expectedErr: errZoneRolloutInvalidAcquireTimeout,
+		},
+		"should fail if enabled with no lease timeout": {
+			ringCfg: Config{
+				KVStore:              kv.Config{Store: "etcd"},
+				ZoneAwarenessEnabled: true,
+				ZoneRollout:          ZoneRolloutConfig{Enabled: true, AcquireTimeout: time.Minute},
+			},
+			expectedErr: errZoneRolloutInvalidLeaseTimeout,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testData.expectedErr, testData.ringCfg.Validate())
+		})
+	}
+}
+
+func TestZoneRolloutDesc_AcquireAndRelease(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	lease := &ZoneRolloutDesc{}
+	assert.False(t, lease.isActive(now, time.Minute))
+
+	// The first instance acquires the lease for its zone.
+	require.True(t, lease.acquire("zone-a", "instance-a-1", now, time.Minute))
+	assert.True(t, lease.isActive(now, time.Minute))
+	assert.Equal(t, "zone-a", lease.Zone)
+
+	// Instances of the same zone can join the lease, while other zones can't acquire it.
+	require.True(t, lease.acquire("zone-a", "instance-a-2", now.Add(time.Second), time.Minute))
+	require.False(t, lease.acquire("zone-b", "instance-b-1", now.Add(time.Second), time.Minute))
+	assert.Equal(t, map[string]int64{"instance-a-1": now.Unix(), "instance-a-2": now.Add(time.Second).Unix()}, lease.Instances)
+	assert.Equal(t, now.Unix(), lease.AcquiredAt)
+	assert.Equal(t, now.Add(time.Second).Unix(), lease.UpdatedAt)
+
+	// Instances not holding the lease can't release it.
+	assert.False(t, lease.release("zone-b", "instance-b-1", now))
+	assert.False(t, lease.release("zone-a", "instance-a-3", now))
+
+	// The lease is released once all the instances have released it.
+	require.True(t, lease.release("zone-a", "instance-a-1", now.Add(2*time.Second)))
+	assert.True(t, lease.isActive(now.Add(2*time.Second), time.Minute))
+	require.True(t, lease.release("zone-a", "instance-a-2", now.Add(2*time.Second)))
+	assert.False(t, lease.isActive(now.Add(2*time.Second), time.Minute))
+	assert.Equal(t, ZoneRolloutDesc{}, *lease)
+
+	// An expired lease can be acquired by another zone.
+	require.True(t, lease.acquire("zone-a", "instance-a-1", now, time.Minute))
+	require.False(t, lease.acquire("zone-b", "instance-b-1", now.Add(59*time.Second), time.Minute))
+	require.True(t, lease.acquire("zone-b", "instance-b-1", now.Add(time.Minute), time.Minute))
+	assert.Equal(t, "zone-b", lease.Zone)
+	assert.Equal(t, map[string]int64{"instance-b-1": now.Add(time.Minute).Unix()}, lease.Instances)
+}
+
+func TestRing_ZoneRollout(t *testing.T) {
+	origRetryPeriod := zoneRolloutRetryPeriod
+	zoneRolloutRetryPeriod = 100 * time.Millisecond
+	t.Cleanup(func() { zoneRolloutRetryPeriod = origRetryPeriod })
+
+	ringStore, ringCloser := consul.NewInMemoryClient(GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, ringCloser.Close()) })
+
+	rolloutStore, rolloutCloser := consul.NewInMemoryClient(GetZoneRolloutCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, rolloutCloser.Close()) })
+
+	cfg := Config{
+		KVStore:              kv.Config{Mock: ringStore},
+		HeartbeatTimeout:     time.Minute,
+		ReplicationFactor:    3,
+		ZoneAwarenessEnabled: true,
+		ZoneRollout: ZoneRolloutConfig{
+			Enabled:        true,
+			AcquireTimeout: 10 * time.Second,
+			LeaseTimeout:   time.Minute,
+		},
+	}
+
+	r, err := NewWithStoreClientAndStrategy(cfg, "test", "test", ringStore, NewDefaultReplicationStrategy(), nil, log.NewNopLogger())
+	require.NoError(t, err)
+	r.zoneRolloutKVClient = rolloutStore
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), r))
+	t.Cleanup(func() {
+		_ = services.StopAndAwaitTerminated(context.Background(), r)
+	})
+
+	startZoneRolloutLifecycler := func(id, zone string) *Lifecycler {
+		lc, err := NewLifecycler(LifecyclerConfig{
+			RingConfig:      cfg,
+			NumTokens:       16,
+			HeartbeatPeriod: 100 * time.Millisecond,
+			Zone:            zone,
+			Addr:            id,
+			ID:              id,
+		}, &noopFlushTransferer{}, "test", "test", true, false, log.NewNopLogger(), nil)
+		require.NoError(t, err)
+		lc.zoneRolloutKVStore = rolloutStore
+
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), lc))
+		t.Cleanup(func() {
+			// READONLY instances don't wait for the zone rollout lease when shutting down.
+			if lc.State() == services.Running {
+				_ = lc.ChangeState(context.Background(), READONLY)
+			}
+			_ = services.StopAndAwaitTerminated(context.Background(), lc)
+		})
+		return lc
+	}
+
+	getLease := func() *ZoneRolloutDesc {
+		value, err := rolloutStore.Get(context.Background(), zoneRolloutKey("test"))
+		require.NoError(t, err)
+		lease, _ := value.(*ZoneRolloutDesc)
+		return lease
+	}
+
+	getInstanceState := func(id string) func() any {
+		return func() any {
+			state, err := r.GetInstanceState(id)
+			if err != nil {
+				return err.Error()
+			}
+			return state
+		}
+	}
+
+	lcA := startZoneRolloutLifecycler("instance-a", "zone-a")
+	startZoneRolloutLifecycler("instance-b", "zone-b")
+	startZoneRolloutLifecycler("instance-c", "zone-c")
+
+	for _, id := range []string{"instance-a", "instance-b", "instance-c"} {
+		test.Poll(t, time.Second, ACTIVE, getInstanceState(id))
+	}
+
+	// Simulate the rollout of zone-b, holding the lease.
+	require.NoError(t, rolloutStore.CAS(context.Background(), zoneRolloutKey("test"), func(in any) (out any, retry bool, err error) {
+		lease := &ZoneRolloutDesc{}
+		require.True(t, lease.acquire("zone-b", "instance-b-other", time.Now(), cfg.ZoneRollout.LeaseTimeout))
+		return lease, true, nil
+	}))
+
+	// The ring clients handle all the instances of zone-b as READONLY, so writes go to the other zones.
+	test.Poll(t, time.Second, READONLY, getInstanceState("instance-b"))
+	rs, err := r.Get(0, Write, nil, nil, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"instance-a:0", "instance-c:0"}, rs.GetAddresses())
+	assert.Equal(t, 0, rs.MaxErrors)
+
+	// The rollout status is exposed.
+	req := httptest.NewRequest(http.MethodGet, "/ingester/zone_rollout", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	r.ZoneRolloutHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp zoneRolloutResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Enabled)
+	assert.True(t, resp.Active)
+	assert.Equal(t, "zone-b", resp.Zone)
+	require.Len(t, resp.Instances, 1)
+	assert.Equal(t, "instance-b-other", resp.Instances[0].ID)
+
+	// Stopping an instance of zone-a waits for the rollout of zone-b to complete.
+	lcA.StopAsync()
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, services.Stopping, lcA.State())
+	assert.Equal(t, ACTIVE, lcA.GetState())
+	assert.Equal(t, "zone-b", getLease().Zone)
+
+	require.NoError(t, rolloutStore.CAS(context.Background(), zoneRolloutKey("test"), func(in any) (out any, retry bool, err error) {
+		lease := in.(*ZoneRolloutDesc)
+		require.True(t, lease.release("zone-b", "instance-b-other", time.Now()))
+		return lease, true, nil
+	}))
+
+	// Once zone-b has been rolled out, the instance of zone-a acquires the lease and shuts down.
+	require.NoError(t, lcA.AwaitTerminated(context.Background()))
+	assert.Equal(t, "zone-a", getLease().Zone)
+	assert.Contains(t, getLease().Instances, "instance-a")
+	test.Poll(t, time.Second, ACTIVE, getInstanceState("instance-b"))
+	test.Poll(t, time.Second, LEAVING, getInstanceState("instance-a"))
+
+	// The restarted instance releases the lease once ACTIVE again, and zone-a is not READONLY anymore.
+	startZoneRolloutLifecycler("instance-a", "zone-a")
+	test.Poll(t, 2*time.Second, false, func() any {
+		return getLease().isActive(time.Now(), cfg.ZoneRollout.LeaseTimeout)
+	})
+	test.Poll(t, time.Second, ACTIVE, getInstanceState("instance-a"))
+}
@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	otgrpc "github.com/opentracing-contrib/go-grpc"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/cortexproject/cortex/pkg/ring/client"
+	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
+	util_api "github.com/cortexproject/cortex/pkg/util/api"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
+)
+
+const (
+	// Timeout for collecting statistics of running queries from a single querier.
+	querierRunningQueriesTimeout = 5 * time.Second
+)
+
+func newQuerierPool(cfg grpcclient.Config, logger log.Logger, reg prometheus.Registerer) *client.Pool {
+	clientsGauge := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_querier_clients",
+		Help: "The current number of clients connected to queriers to collect running queries.",
+	})
+
+	poolConfig := client.PoolConfig{
+		CheckInterval:      10 * time.Second,
+		HealthCheckEnabled: true,
+		HealthCheckTimeout: 1 * time.Second,
+	}
+
+	return client.NewPool("querier", poolConfig, nil, func(addr string) (client.PoolClient, error) {
+		opts, err := cfg.DialOption([]grpc.UnaryClientInterceptor{
+			otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := grpc.NewClient(addr, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &querierClient{
+			QuerierForSchedulerClient: schedulerpb.NewQuerierForSchedulerClient(conn),
+			HealthClient:              grpc_health_v1.NewHealthClient(conn),
+			conn:                      conn,
+		}, nil
+	}, clientsGauge, logger)
+}
+
+type querierClient struct {
+	schedulerpb.QuerierForSchedulerClient
+	grpc_health_v1.HealthClient
+	conn *grpc.ClientConn
+}
+
+func (qc *querierClient) Close() error {
+	return qc.conn.Close()
+}
+
+// GetRunningQueries implements schedulerpb.SchedulerForFrontendServer.
+func (s *Scheduler) GetRunningQueries(ctx context.Context, _ *schedulerpb.GetRunningQueriesRequest) (*schedulerpb.GetRunningQueriesResponse, error) {
+	queries, querierAddrs := s.getTrackedQueries()
+
+	fetchedBytes := s.collectFetchedBytesFromQueriers(ctx, querierAddrs)
+	for _, q := range queries {
+		q.FetchedBytes = fetchedBytes[requestKey{queryKey: queryKey{frontendAddr: q.FrontendAddress, queryID: q.QueryID}, fragmentID: q.FragmentID}]
+	}
+
+	return &schedulerpb.GetRunningQueriesResponse{Queries: queries}, nil
+}
+
+// getTrackedQueries returns a snapshot of all tracked requests, together with the addresses
+// of the queriers executing them.
+func (s *Scheduler) getTrackedQueries() ([]*schedulerpb.RunningQuery, []string) {
+	s.trackedRequestsMu.Lock()
+	defer s.trackedRequestsMu.Unlock()
+
+	queries := make([]*schedulerpb.RunningQuery, 0, len(s.trackedRequests))
+	querierAddrs := map[string]struct{}{}
+
+	for key, req := range s.trackedRequests {
+		queries = append(queries, &schedulerpb.RunningQuery{
+			FrontendAddress: req.frontendAddress,
+			QueryID:         req.queryID,
+			FragmentID:      key.fragmentID,
+			UserID:          req.userID,
+			Query:           queryFromHTTPRequest(req.request),
+			EnqueueTimeMs:   req.enqueueTime.UnixMilli(),
+			QuerierID:       req.querierID,
+			QuerierAddress:  req.querierAddress,
+		})
+
+		if req.querierAddress != "" {
+			querierAddrs[req.querierAddress] = struct{}{}
+		}
+	}
+
+	addrs := make([]string, 0, len(querierAddrs))
+	for addr := range querierAddrs {
+		addrs = append(addrs, addr)
+	}
+	return queries, addrs
+}
+
+// collectFetchedBytesFromQueriers asks the given queriers for the statistics of the requests they are running.
+// Queriers which cannot be reached are skipped, so that a single unhealthy querier doesn't break the listing.
+func (s *Scheduler) collectFetchedBytesFromQueriers(ctx context.Context, addrs []string) map[requestKey]uint64 {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = map[requestKey]uint64{}
+	)
+
+	for _, addr := range addrs {
+		wg.Go(func() {
+			c, err := s.querierPool.GetClientFor(addr)
+			if err != nil {
+				level.Warn(s.log).Log("msg", "failed to get client for querier", "querier", addr, "err", err)
+				return
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx, querierRunningQueriesTimeout)
+			defer cancel()
+
+			resp, err := c.(schedulerpb.QuerierForSchedulerClient).GetRunningQueries(reqCtx, &schedulerpb.GetRunningQueriesRequest{})
+			if err != nil {
+				level.Warn(s.log).Log("msg", "failed to get running queries from querier", "querier", addr, "err", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, q := range resp.Queries {
+				result[requestKey{queryKey: queryKey{frontendAddr: q.FrontendAddress, queryID: q.QueryID}, fragmentID: q.FragmentID}] = q.FetchedBytes
+			}
+		})
+	}
+
+	wg.Wait()
+	return result
+}
+
+// CancelRunningQuery implements schedulerpb.SchedulerForFrontendServer.
+func (s *Scheduler) CancelRunningQuery(ctx context.Context, req *schedulerpb.CancelRunningQueryRequest) (*schedulerpb.CancelRunningQueryResponse, error) {
+	key := queryKey{frontendAddr: req.FrontendAddress, queryID: req.QueryID}
+
+	s.trackedRequestsMu.Lock()
+	var tracked *schedulerRequest
+	for _, fragID := range s.queryFragmentRegistry[key] {
+		if r := s.trackedRequests[requestKey{queryKey: key, fragmentID: fragID}]; r != nil {
+			tracked = r
+			break
+		}
+	}
+	s.trackedRequestsMu.Unlock()
+
+	if tracked == nil {
+		return &schedulerpb.CancelRunningQueryResponse{Canceled: false}, nil
+	}
+
+	level.Info(s.log).Log("msg", "canceling running query", "frontend", req.FrontendAddress, "queryID", req.QueryID, "user", tracked.userID)
+
+	// Canceling the request closes the stream to the querier executing it (if any), which
+	// makes the querier abort the query. The frontend still waits for a response, so we report it explicitly.
+	s.cancelRequestAndRemoveFromTracked(req.FrontendAddress, req.QueryID, 0, true)
+	s.forwardResponseToFrontend(ctx, tracked, &httpgrpc.HTTPResponse{
+		Code: util_api.StatusClientClosedRequest,
+		Body: []byte("query was canceled by an operator"),
+	})
+
+	return &schedulerpb.CancelRunningQueryResponse{Canceled: true}, nil
+}
+
+// queryFromHTTPRequest returns the PromQL expression of the request, or the request path
+// if the request doesn't carry an expression (eg. series or labels requests).
+func queryFromHTTPRequest(req *httpgrpc.HTTPRequest) string {
+	if req == nil {
+		return ""
+	}
+
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return req.Url
+	}
+	if q := u.Query().Get("query"); q != "" {
+		return q
+	}
+
+	if req.Method == http.MethodPost && len(req.Body) > 0 {
+		if values, err := url.ParseQuery(string(req.Body)); err == nil {
+			if q := values.Get("query"); q != "" {
+				return q
+			}
+		}
+	}
+	return u.Path
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/distributed_execution/plan_fragments"
 	"github.com/cortexproject/cortex/pkg/frontend/v2/frontendv2pb"
 	"github.com/cortexproject/cortex/pkg/querier/stats" //lint:ignore faillint scheduler needs to retrieve priority from the context
+	"github.com/cortexproject/cortex/pkg/ring/client"
 	"github.com/cortexproject/cortex/pkg/scheduler/fragment_table"
 	"github.com/cortexproject/cortex/pkg/scheduler/queue"
 	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
@@ -79,6 +80,9 @@ type Scheduler struct {
 	fragmenter             plan_fragments.Fragmenter     // Splits logical plans into executable fragments
 	fragmentTable          *fragment_table.FragmentTable // Tracks fragment execution state and querier assignments
 
+	// Pool of clients used to collect statistics of running queries from queriers.
+	querierPool *client.Pool
+
 	// Maps queries to their fragment IDs for efficient query cancellation.
 	// Using this map avoids the need to scan all tracked requests to find
 	// fragments belonging to a specific query.
@@ -105,7 +109,7 @@ type connectedFrontend struct {
 
 type Config struct {
 	QuerierForgetDelay time.Duration     `yaml:"querier_forget_delay"`
-	GRPCClientConfig   grpcclient.Config `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
+	GRPCClientConfig   grpcclient.Config `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend and to collect statistics of running queries from queriers."`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -162,9 +166,10 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 	}, s.getTrackedRequestsMetric)
 
 	s.activeUsers = users.NewActiveUsersCleanupWithDefaultValues(s.cleanupMetricsForInactiveUser)
+	s.querierPool = newQuerierPool(cfg.GRPCClientConfig, log, registerer)
 
 	var err error
-	s.subservices, err = services.NewManager(s.requestQueue, s.activeUsers)
+	s.subservices, err = services.NewManager(s.requestQueue, s.activeUsers, s.querierPool)
 	if err != nil {
 		return nil, err
 	}
@@ -194,6 +199,11 @@ type schedulerRequest struct {
 	ctxCancel context.CancelFunc
 	queueSpan opentracing.Span
 
+	// Querier executing this request. Only set once the request has been dispatched,
+	// and guarded by trackedRequestsMu.
+	querierID      string
+	querierAddress string
+
 	// This is only used for testing.
 	parentSpanContext opentracing.SpanContext
 
@@ -553,6 +563,11 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 			continue
 		}
 
+		s.trackedRequestsMu.Lock()
+		r.querierID = querierID
+		r.querierAddress = resp.GetQuerierAddress()
+		s.trackedRequestsMu.Unlock()
+
 		if err := s.forwardRequestToQuerier(querier, r, resp.GetQuerierAddress()); err != nil {
 			return err
 		}
@@ -631,18 +646,26 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 }
 
 func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRequest, requestErr error) {
+	s.forwardResponseToFrontend(ctx, req, &httpgrpc.HTTPResponse{
+		Code: http.StatusInternalServerError,
+		Body: []byte(requestErr.Error()),
+	})
+}
+
+// forwardResponseToFrontend reports the given response back to the frontend which enqueued the request.
+func (s *Scheduler) forwardResponseToFrontend(ctx context.Context, req *schedulerRequest, resp *httpgrpc.HTTPResponse) {
 	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
 		middleware.ClientUserHeaderInterceptor},
 		nil)
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to report response", "frontend", req.frontendAddress, "err", err, "response", string(resp.Body))
 		return
 	}
 
 	conn, err := grpc.NewClient(req.frontendAddress, opts...)
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to report response", "frontend", req.frontendAddress, "err", err, "response", string(resp.Body))
 		return
 	}
 
@@ -650,19 +673,16 @@ func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRe
 		_ = conn.Close()
 	}()
 
-	client := frontendv2pb.NewFrontendForQuerierClient(conn)
+	frontendClient := frontendv2pb.NewFrontendForQuerierClient(conn)
 
 	userCtx := user.InjectOrgID(ctx, req.userID)
-	_, err = client.QueryResult(userCtx, &frontendv2pb.QueryResultRequest{
-		QueryID: req.queryID,
-		HttpResponse: &httpgrpc.HTTPResponse{
-			Code: http.StatusInternalServerError,
-			Body: []byte(requestErr.Error()),
-		},
+	_, err = frontendClient.QueryResult(userCtx, &frontendv2pb.QueryResultRequest{
+		QueryID:      req.queryID,
+		HttpResponse: resp,
 	})
 
 	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to forward error to frontend", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
+		level.Warn(s.log).Log("msg", "failed to forward response to frontend", "frontend", req.frontendAddress, "err", err, "response", string(resp.Body))
 		return
 	}
 }
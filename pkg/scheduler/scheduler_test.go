@@ -27,6 +27,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/frontend/v2/frontendv2pb"
 	"github.com/cortexproject/cortex/pkg/scheduler/queue"
 	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
+	util_api "github.com/cortexproject/cortex/pkg/util/api"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 	"github.com/cortexproject/cortex/pkg/util/httpgrpcutil"
 	"github.com/cortexproject/cortex/pkg/util/services"
@@ -963,3 +964,144 @@ func TestSchedulerTrackedRequestsMetric(t *testing.T) {
 		return err == nil
 	})
 }
+
+func TestSchedulerGetAndCancelRunningQueries(t *testing.T) {
+	_, frontendClient, querierClient := setupScheduler(t, nil, false)
+
+	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
+	frontendAddress := startGRPCServer(t, func(s *grpc.Server) {
+		frontendv2pb.RegisterFrontendForQuerierServer(s, fm)
+	})
+	querierAddress := startGRPCServer(t, func(s *grpc.Server) {
+		schedulerpb.RegisterQuerierForSchedulerServer(s, &querierForSchedulerMock{queries: []*schedulerpb.RunningQuery{
+			{FrontendAddress: frontendAddress, QueryID: 1, FetchedBytes: 1024},
+		}})
+	})
+
+	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "test",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/api/v1/query?query=up"},
+	})
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     2,
+		UserID:      "another",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "POST", Url: "/api/v1/query_range", Body: []byte("query=sum(rate(foo[1m]))")},
+	})
+
+	// Dispatch the first query to the querier.
+	querierLoop, err := querierClient.QuerierLoop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{QuerierID: "querier-1", QuerierAddress: querierAddress}))
+	msg, err := querierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), msg.QueryID)
+
+	// Wait until the scheduler has recorded the querier executing the first query.
+	test.Poll(t, time.Second, querierAddress, func() any {
+		resp, err := frontendClient.GetRunningQueries(context.Background(), &schedulerpb.GetRunningQueriesRequest{})
+		require.NoError(t, err)
+		for _, q := range resp.Queries {
+			if q.QueryID == 1 {
+				return q.QuerierAddress
+			}
+		}
+		return ""
+	})
+
+	resp, err := frontendClient.GetRunningQueries(context.Background(), &schedulerpb.GetRunningQueriesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Queries, 2)
+
+	byID := map[uint64]*schedulerpb.RunningQuery{}
+	for _, q := range resp.Queries {
+		byID[q.QueryID] = q
+	}
+	require.Equal(t, "test", byID[1].UserID)
+	require.Equal(t, "up", byID[1].Query)
+	require.Equal(t, "querier-1", byID[1].QuerierID)
+	require.Equal(t, uint64(1024), byID[1].FetchedBytes)
+	require.Equal(t, "another", byID[2].UserID)
+	require.Equal(t, "sum(rate(foo[1m]))", byID[2].Query)
+	require.Empty(t, byID[2].QuerierAddress)
+	require.Zero(t, byID[2].FetchedBytes)
+
+	// Canceling an unknown query is a no-op.
+	cancelResp, err := frontendClient.CancelRunningQuery(context.Background(), &schedulerpb.CancelRunningQueryRequest{FrontendAddress: frontendAddress, QueryID: 3})
+	require.NoError(t, err)
+	require.False(t, cancelResp.Canceled)
+
+	// Canceling the running query closes the querier stream and reports the cancellation to the frontend.
+	cancelResp, err = frontendClient.CancelRunningQuery(context.Background(), &schedulerpb.CancelRunningQueryRequest{FrontendAddress: frontendAddress, QueryID: 1})
+	require.NoError(t, err)
+	require.True(t, cancelResp.Canceled)
+
+	_, err = querierLoop.Recv()
+	require.Error(t, err)
+
+	test.Poll(t, 2*time.Second, int32(util_api.StatusClientClosedRequest), func() any {
+		if resp := fm.getRequest(1); resp != nil {
+			return resp.Code
+		}
+		return int32(0)
+	})
+
+	resp, err = frontendClient.GetRunningQueries(context.Background(), &schedulerpb.GetRunningQueriesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Queries, 1)
+	require.Equal(t, uint64(2), resp.Queries[0].QueryID)
+}
+
+func TestQueryFromHTTPRequest(t *testing.T) {
+	for name, tc := range map[string]struct {
+		req      *httpgrpc.HTTPRequest
+		expected string
+	}{
+		"nil request": {
+			req:      nil,
+			expected: "",
+		},
+		"query in URL": {
+			req:      &httpgrpc.HTTPRequest{Method: "GET", Url: "/prometheus/api/v1/query?query=up&time=1"},
+			expected: "up",
+		},
+		"query in body": {
+			req:      &httpgrpc.HTTPRequest{Method: "POST", Url: "/prometheus/api/v1/query_range", Body: []byte("query=rate%28foo%5B1m%5D%29&step=60")},
+			expected: "rate(foo[1m])",
+		},
+		"request without query": {
+			req:      &httpgrpc.HTTPRequest{Method: "GET", Url: "/prometheus/api/v1/labels?start=1"},
+			expected: "/prometheus/api/v1/labels",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, queryFromHTTPRequest(tc.req))
+		})
+	}
+}
+
+func startGRPCServer(t *testing.T, register func(s *grpc.Server)) string {
+	server := grpc.NewServer()
+	register(server)
+
+	l, err := net.Listen("tcp", "")
+	require.NoError(t, err)
+
+	go func() {
+		_ = server.Serve(l)
+	}()
+
+	t.Cleanup(server.Stop)
+	return l.Addr().String()
+}
+
+type querierForSchedulerMock struct {
+	queries []*schedulerpb.RunningQuery
+}
+
+func (m *querierForSchedulerMock) GetRunningQueries(_ context.Context, _ *schedulerpb.GetRunningQueriesRequest) (*schedulerpb.GetRunningQueriesResponse, error) {
+	return &schedulerpb.GetRunningQueriesResponse{Queries: m.queries}, nil
+}
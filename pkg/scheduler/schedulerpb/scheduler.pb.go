@@ -461,6 +461,292 @@ func (m *NotifyQuerierShutdownResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_NotifyQuerierShutdownResponse proto.InternalMessageInfo
 
+type GetRunningQueriesRequest struct {
+}
+
+func (m *GetRunningQueriesRequest) Reset()      { *m = GetRunningQueriesRequest{} }
+func (*GetRunningQueriesRequest) ProtoMessage() {}
+func (*GetRunningQueriesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{6}
+}
+func (m *GetRunningQueriesRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetRunningQueriesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetRunningQueriesRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetRunningQueriesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRunningQueriesRequest.Merge(m, src)
+}
+func (m *GetRunningQueriesRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetRunningQueriesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRunningQueriesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRunningQueriesRequest proto.InternalMessageInfo
+
+type GetRunningQueriesResponse struct {
+	Queries []*RunningQuery `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
+}
+
+func (m *GetRunningQueriesResponse) Reset()      { *m = GetRunningQueriesResponse{} }
+func (*GetRunningQueriesResponse) ProtoMessage() {}
+func (*GetRunningQueriesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{7}
+}
+func (m *GetRunningQueriesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetRunningQueriesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetRunningQueriesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetRunningQueriesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRunningQueriesResponse.Merge(m, src)
+}
+func (m *GetRunningQueriesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetRunningQueriesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRunningQueriesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRunningQueriesResponse proto.InternalMessageInfo
+
+func (m *GetRunningQueriesResponse) GetQueries() []*RunningQuery {
+	if m != nil {
+		return m.Queries
+	}
+	return nil
+}
+
+// RunningQuery describes a single query fragment tracked by the scheduler or executed by a querier.
+type RunningQuery struct {
+	// Frontend address and query ID uniquely identify the query across the cluster.
+	FrontendAddress string `protobuf:"bytes,1,opt,name=frontendAddress,proto3" json:"frontendAddress,omitempty"`
+	QueryID         uint64 `protobuf:"varint,2,opt,name=queryID,proto3" json:"queryID,omitempty"`
+	FragmentID      uint64 `protobuf:"varint,3,opt,name=fragmentID,proto3" json:"fragmentID,omitempty"`
+	UserID          string `protobuf:"bytes,4,opt,name=userID,proto3" json:"userID,omitempty"`
+	// PromQL expression, or request path if the request has no expression.
+	Query string `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	// Time when the request was enqueued to the scheduler, in milliseconds since epoch.
+	EnqueueTimeMs int64 `protobuf:"varint,6,opt,name=enqueueTimeMs,proto3" json:"enqueueTimeMs,omitempty"`
+	// Set when the request has been dispatched to a querier.
+	QuerierID      string `protobuf:"bytes,7,opt,name=querierID,proto3" json:"querierID,omitempty"`
+	QuerierAddress string `protobuf:"bytes,8,opt,name=querierAddress,proto3" json:"querierAddress,omitempty"`
+	// Bytes fetched so far by the querier. Only reported when query statistics are enabled.
+	FetchedBytes uint64 `protobuf:"varint,9,opt,name=fetchedBytes,proto3" json:"fetchedBytes,omitempty"`
+}
+
+func (m *RunningQuery) Reset()      { *m = RunningQuery{} }
+func (*RunningQuery) ProtoMessage() {}
+func (*RunningQuery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{8}
+}
+func (m *RunningQuery) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RunningQuery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RunningQuery.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RunningQuery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunningQuery.Merge(m, src)
+}
+func (m *RunningQuery) XXX_Size() int {
+	return m.Size()
+}
+func (m *RunningQuery) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunningQuery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunningQuery proto.InternalMessageInfo
+
+func (m *RunningQuery) GetFrontendAddress() string {
+	if m != nil {
+		return m.FrontendAddress
+	}
+	return ""
+}
+
+func (m *RunningQuery) GetQueryID() uint64 {
+	if m != nil {
+		return m.QueryID
+	}
+	return 0
+}
+
+func (m *RunningQuery) GetFragmentID() uint64 {
+	if m != nil {
+		return m.FragmentID
+	}
+	return 0
+}
+
+func (m *RunningQuery) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *RunningQuery) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *RunningQuery) GetEnqueueTimeMs() int64 {
+	if m != nil {
+		return m.EnqueueTimeMs
+	}
+	return 0
+}
+
+func (m *RunningQuery) GetQuerierID() string {
+	if m != nil {
+		return m.QuerierID
+	}
+	return ""
+}
+
+func (m *RunningQuery) GetQuerierAddress() string {
+	if m != nil {
+		return m.QuerierAddress
+	}
+	return ""
+}
+
+func (m *RunningQuery) GetFetchedBytes() uint64 {
+	if m != nil {
+		return m.FetchedBytes
+	}
+	return 0
+}
+
+type CancelRunningQueryRequest struct {
+	FrontendAddress string `protobuf:"bytes,1,opt,name=frontendAddress,proto3" json:"frontendAddress,omitempty"`
+	QueryID         uint64 `protobuf:"varint,2,opt,name=queryID,proto3" json:"queryID,omitempty"`
+}
+
+func (m *CancelRunningQueryRequest) Reset()      { *m = CancelRunningQueryRequest{} }
+func (*CancelRunningQueryRequest) ProtoMessage() {}
+func (*CancelRunningQueryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{9}
+}
+func (m *CancelRunningQueryRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CancelRunningQueryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CancelRunningQueryRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CancelRunningQueryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelRunningQueryRequest.Merge(m, src)
+}
+func (m *CancelRunningQueryRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *CancelRunningQueryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelRunningQueryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelRunningQueryRequest proto.InternalMessageInfo
+
+func (m *CancelRunningQueryRequest) GetFrontendAddress() string {
+	if m != nil {
+		return m.FrontendAddress
+	}
+	return ""
+}
+
+func (m *CancelRunningQueryRequest) GetQueryID() uint64 {
+	if m != nil {
+		return m.QueryID
+	}
+	return 0
+}
+
+type CancelRunningQueryResponse struct {
+	// False if the query is not tracked by this scheduler.
+	Canceled bool `protobuf:"varint,1,opt,name=canceled,proto3" json:"canceled,omitempty"`
+}
+
+func (m *CancelRunningQueryResponse) Reset()      { *m = CancelRunningQueryResponse{} }
+func (*CancelRunningQueryResponse) ProtoMessage() {}
+func (*CancelRunningQueryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{10}
+}
+func (m *CancelRunningQueryResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CancelRunningQueryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CancelRunningQueryResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CancelRunningQueryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelRunningQueryResponse.Merge(m, src)
+}
+func (m *CancelRunningQueryResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *CancelRunningQueryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelRunningQueryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CancelRunningQueryResponse proto.InternalMessageInfo
+
+func (m *CancelRunningQueryResponse) GetCanceled() bool {
+	if m != nil {
+		return m.Canceled
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterEnum("schedulerpb.FrontendToSchedulerType", FrontendToSchedulerType_name, FrontendToSchedulerType_value)
 	proto.RegisterEnum("schedulerpb.SchedulerToFrontendStatus", SchedulerToFrontendStatus_name, SchedulerToFrontendStatus_value)
@@ -471,59 +757,78 @@ func init() {
 	proto.RegisterType((*SchedulerToFrontend)(nil), "schedulerpb.SchedulerToFrontend")
 	proto.RegisterType((*NotifyQuerierShutdownRequest)(nil), "schedulerpb.NotifyQuerierShutdownRequest")
 	proto.RegisterType((*NotifyQuerierShutdownResponse)(nil), "schedulerpb.NotifyQuerierShutdownResponse")
+	proto.RegisterType((*GetRunningQueriesRequest)(nil), "schedulerpb.GetRunningQueriesRequest")
+	proto.RegisterType((*GetRunningQueriesResponse)(nil), "schedulerpb.GetRunningQueriesResponse")
+	proto.RegisterType((*RunningQuery)(nil), "schedulerpb.RunningQuery")
+	proto.RegisterType((*CancelRunningQueryRequest)(nil), "schedulerpb.CancelRunningQueryRequest")
+	proto.RegisterType((*CancelRunningQueryResponse)(nil), "schedulerpb.CancelRunningQueryResponse")
 }
 
 func init() { proto.RegisterFile("scheduler.proto", fileDescriptor_2b3fc28395a6d9c5) }
 
 var fileDescriptor_2b3fc28395a6d9c5 = []byte{
-	// 750 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0x4d, 0x4f, 0xdb, 0x4c,
-	0x10, 0xf6, 0xe6, 0x0b, 0x98, 0xf0, 0x82, 0xdf, 0x05, 0xde, 0x37, 0x8d, 0xa8, 0x89, 0xac, 0x0a,
-	0xa5, 0x1c, 0x92, 0x2a, 0x54, 0x2a, 0xaa, 0x50, 0xa5, 0x94, 0x98, 0x12, 0x95, 0x3a, 0xe0, 0x38,
-	0x6a, 0x4b, 0x0f, 0x51, 0x3e, 0x96, 0x24, 0x22, 0xf1, 0x1a, 0x7b, 0x0d, 0xca, 0xad, 0xc7, 0x1e,
-	0xfb, 0x27, 0x2a, 0xf5, 0xa7, 0xf4, 0xc8, 0x91, 0x43, 0x0f, 0xc5, 0x5c, 0x7a, 0xe4, 0x27, 0x54,
-	0x76, 0xec, 0xd4, 0x09, 0x09, 0x70, 0x9b, 0x19, 0x3f, 0x33, 0x3b, 0xf3, 0x3c, 0xb3, 0x5e, 0x58,
-	0x34, 0x1b, 0x6d, 0xd2, 0xb4, 0xba, 0xc4, 0xc8, 0xe8, 0x06, 0x65, 0x14, 0xc7, 0x87, 0x01, 0xbd,
-	0x9e, 0x5c, 0x6e, 0xd1, 0x16, 0x75, 0xe3, 0x59, 0xc7, 0x1a, 0x40, 0x92, 0xcf, 0x5b, 0x1d, 0xd6,
-	0xb6, 0xea, 0x99, 0x06, 0xed, 0x65, 0xcf, 0x49, 0xed, 0x8c, 0x9c, 0x53, 0xe3, 0xc4, 0xcc, 0x36,
-	0x68, 0xaf, 0x47, 0xb5, 0x6c, 0x9b, 0x31, 0xbd, 0x65, 0xe8, 0x8d, 0xa1, 0x31, 0xc8, 0x12, 0x8f,
-	0x00, 0x1f, 0x5a, 0xc4, 0xe8, 0x10, 0x43, 0xa5, 0x65, 0xff, 0x0c, 0xbc, 0x0a, 0x73, 0xa7, 0x83,
-	0x68, 0xb1, 0x90, 0x40, 0x29, 0x94, 0x9e, 0x53, 0xfe, 0x06, 0xf0, 0x3a, 0x2c, 0x78, 0x4e, 0xbe,
-	0xd9, 0x34, 0x88, 0x69, 0x26, 0x42, 0x2e, 0x64, 0x2c, 0x2a, 0x7e, 0x0b, 0x03, 0x1e, 0xd6, 0x54,
-	0xa9, 0x77, 0x0e, 0x4e, 0xc0, 0x8c, 0x03, 0xec, 0x7b, 0xa5, 0x23, 0x8a, 0xef, 0xe2, 0x17, 0x10,
-	0x77, 0xda, 0x53, 0xc8, 0xa9, 0x45, 0x4c, 0xe6, 0x56, 0x8d, 0xe7, 0x56, 0x32, 0xc3, 0x96, 0xf7,
-	0x54, 0xf5, 0xc0, 0xfb, 0xa8, 0x04, 0x91, 0x38, 0x0d, 0x8b, 0xc7, 0x06, 0xd5, 0x18, 0xd1, 0x9a,
-	0x7e, 0x4b, 0x61, 0xb7, 0xa5, 0xf1, 0x30, 0xfe, 0x0f, 0x62, 0x96, 0xe9, 0x8e, 0x15, 0x71, 0x01,
-	0x9e, 0x87, 0x45, 0x98, 0x37, 0x59, 0x8d, 0x99, 0x92, 0x56, 0xab, 0x77, 0x49, 0x33, 0x11, 0x4d,
-	0xa1, 0xf4, 0xac, 0x32, 0x12, 0xc3, 0x02, 0xc0, 0xb1, 0x51, 0x6b, 0xf5, 0x88, 0xc6, 0x8a, 0x85,
-	0x44, 0xcc, 0xed, 0x3d, 0x10, 0xc1, 0x9f, 0x60, 0xa1, 0xd1, 0xee, 0x74, 0x9b, 0xc5, 0x02, 0xa3,
-	0xce, 0x79, 0x66, 0x62, 0x26, 0x15, 0x4e, 0xc7, 0x73, 0x9b, 0x99, 0x80, 0x7a, 0x99, 0xdb, 0x8c,
-	0x64, 0x76, 0x46, 0xb2, 0x24, 0x8d, 0x19, 0x7d, 0x65, 0xac, 0x94, 0xd3, 0x78, 0xc7, 0x54, 0x28,
-	0x65, 0x89, 0x59, 0xb7, 0x35, 0xcf, 0x4b, 0xe6, 0x61, 0x69, 0x42, 0x3a, 0xe6, 0x21, 0x7c, 0x42,
-	0xfa, 0x1e, 0xc1, 0x8e, 0x89, 0x97, 0x21, 0x7a, 0x56, 0xeb, 0x5a, 0xc4, 0x13, 0x6b, 0xe0, 0xbc,
-	0x0c, 0x6d, 0x21, 0xf1, 0x4b, 0x08, 0x96, 0x76, 0x3d, 0x9e, 0x82, 0x5b, 0xb0, 0x05, 0x11, 0xd6,
-	0xd7, 0x89, 0x5b, 0x64, 0x21, 0xf7, 0x64, 0x64, 0x8a, 0x09, 0x78, 0xb5, 0xaf, 0x13, 0xc5, 0xcd,
-	0x98, 0xa4, 0x47, 0x68, 0xb2, 0x1e, 0x81, 0x65, 0x08, 0x8f, 0x2e, 0xc3, 0x34, 0xa5, 0xc6, 0x96,
-	0x24, 0xfa, 0xe0, 0x25, 0x19, 0x97, 0x38, 0x76, 0x5b, 0x62, 0xf1, 0x04, 0x96, 0x02, 0xfa, 0xf8,
-	0x43, 0xe2, 0x57, 0x10, 0x73, 0x60, 0x96, 0xe9, 0x71, 0xb1, 0x3e, 0x4d, 0x51, 0x3f, 0xa3, 0xec,
-	0xa2, 0x15, 0x2f, 0xcb, 0xe1, 0x9e, 0x18, 0x06, 0x35, 0x7c, 0xee, 0x5d, 0x47, 0xdc, 0x86, 0x55,
-	0x99, 0xb2, 0xce, 0x71, 0xdf, 0xdb, 0x83, 0x72, 0xdb, 0x62, 0x4d, 0x7a, 0xae, 0xf9, 0x0d, 0xdf,
-	0x79, 0x0b, 0xc5, 0x35, 0x78, 0x3c, 0x25, 0xdb, 0xd4, 0xa9, 0x66, 0x92, 0x8d, 0x6d, 0xf8, 0x7f,
-	0x8a, 0x4a, 0x78, 0x16, 0x22, 0x45, 0xb9, 0xa8, 0xf2, 0x1c, 0x8e, 0xc3, 0x8c, 0x24, 0x1f, 0x56,
-	0xa4, 0x8a, 0xc4, 0x23, 0x0c, 0x10, 0xdb, 0xc9, 0xcb, 0x3b, 0xd2, 0x3e, 0x1f, 0xda, 0x68, 0xc0,
-	0xa3, 0xa9, 0x73, 0xe1, 0x18, 0x84, 0x4a, 0x6f, 0x79, 0x0e, 0xa7, 0x60, 0x55, 0x2d, 0x95, 0xaa,
-	0xef, 0xf2, 0xf2, 0xc7, 0xaa, 0x22, 0x1d, 0x56, 0xa4, 0xb2, 0x5a, 0xae, 0x1e, 0x48, 0x4a, 0x55,
-	0x95, 0xe4, 0xbc, 0xac, 0xf2, 0x08, 0xcf, 0x41, 0x54, 0x52, 0x94, 0x92, 0xc2, 0x87, 0xf0, 0xbf,
-	0xf0, 0x4f, 0x79, 0xaf, 0xa2, 0xaa, 0x45, 0xf9, 0x4d, 0xb5, 0x50, 0x7a, 0x2f, 0xf3, 0xe1, 0xdc,
-	0x4f, 0x14, 0xe0, 0x7b, 0x97, 0x1a, 0xfe, 0x2f, 0xa2, 0x02, 0x71, 0xcf, 0xdc, 0xa7, 0x54, 0xc7,
-	0x6b, 0x23, 0x74, 0xdf, 0xfe, 0x5f, 0x25, 0xd7, 0xee, 0xb9, 0x61, 0x22, 0x97, 0x46, 0xcf, 0x10,
-	0xd6, 0x60, 0x65, 0x22, 0x65, 0xf8, 0xe9, 0x48, 0xfe, 0x5d, 0xa2, 0x24, 0x37, 0x1e, 0x02, 0x1d,
-	0x28, 0x90, 0xd3, 0x61, 0x39, 0x38, 0xdd, 0x70, 0x9d, 0x3e, 0xc0, 0xbc, 0x6f, 0xbb, 0xf3, 0xa5,
-	0xee, 0xbb, 0x5a, 0xc9, 0xd4, 0x7d, 0x0b, 0x37, 0x98, 0xf0, 0x75, 0xfe, 0xe2, 0x4a, 0xe0, 0x2e,
-	0xaf, 0x04, 0xee, 0xe6, 0x4a, 0x40, 0x9f, 0x6d, 0x01, 0x7d, 0xb7, 0x05, 0xf4, 0xc3, 0x16, 0xd0,
-	0x85, 0x2d, 0xa0, 0x5f, 0xb6, 0x80, 0x7e, 0xdb, 0x02, 0x77, 0x63, 0x0b, 0xe8, 0xeb, 0xb5, 0xc0,
-	0x5d, 0x5c, 0x0b, 0xdc, 0xe5, 0xb5, 0xc0, 0x1d, 0x05, 0x5f, 0x97, 0x7a, 0xcc, 0x7d, 0x18, 0x36,
-	0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0xee, 0xbe, 0x64, 0x48, 0x84, 0x06, 0x00, 0x00,
+	// 962 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0x4f, 0x6f, 0x1a, 0x47,
+	0x14, 0xdf, 0x5d, 0x0c, 0x86, 0x87, 0xe3, 0x90, 0xb1, 0xd3, 0xe2, 0x95, 0xbb, 0x46, 0xab, 0xd4,
+	0xa5, 0x3e, 0xe0, 0x0a, 0x57, 0xaa, 0x55, 0x45, 0x95, 0x88, 0xbd, 0x4e, 0x50, 0x13, 0xb0, 0x87,
+	0x45, 0x6d, 0xd3, 0x03, 0xc2, 0x30, 0x06, 0x64, 0xd8, 0xc1, 0xbb, 0xb3, 0xb1, 0x50, 0x2f, 0x3d,
+	0xf6, 0xd8, 0x2f, 0x51, 0xa9, 0xc7, 0x7e, 0x83, 0x5e, 0x7b, 0xf4, 0x31, 0x87, 0x1e, 0x6a, 0x7c,
+	0xe9, 0x31, 0x1f, 0xa1, 0xda, 0x61, 0x96, 0xec, 0x02, 0x1b, 0xd2, 0x4b, 0x6e, 0xf3, 0xde, 0xfc,
+	0xde, 0x9b, 0xf7, 0xe7, 0xf7, 0xde, 0x2e, 0xdc, 0x77, 0x5a, 0x5d, 0xd2, 0x76, 0xfb, 0xc4, 0x2e,
+	0x0c, 0x6d, 0xca, 0x28, 0x4a, 0x4f, 0x15, 0xc3, 0x73, 0x75, 0xb3, 0x43, 0x3b, 0x94, 0xeb, 0xf7,
+	0xbd, 0xd3, 0x04, 0xa2, 0x7e, 0xd9, 0xe9, 0xb1, 0xae, 0x7b, 0x5e, 0x68, 0xd1, 0xc1, 0xfe, 0x35,
+	0x69, 0xbe, 0x22, 0xd7, 0xd4, 0xbe, 0x74, 0xf6, 0x5b, 0x74, 0x30, 0xa0, 0xd6, 0x7e, 0x97, 0xb1,
+	0x61, 0xc7, 0x1e, 0xb6, 0xa6, 0x87, 0x89, 0x95, 0xfe, 0x12, 0xd0, 0x99, 0x4b, 0xec, 0x1e, 0xb1,
+	0x4d, 0x5a, 0xf3, 0xdf, 0x40, 0xdb, 0x90, 0xba, 0x9a, 0x68, 0xcb, 0xc7, 0x59, 0x39, 0x27, 0xe7,
+	0x53, 0xf8, 0xad, 0x02, 0xed, 0xc2, 0xba, 0x10, 0x4a, 0xed, 0xb6, 0x4d, 0x1c, 0x27, 0xab, 0x70,
+	0xc8, 0x8c, 0x56, 0xff, 0x2d, 0x06, 0x68, 0xea, 0xd3, 0xa4, 0xe2, 0x1d, 0x94, 0x85, 0x55, 0x0f,
+	0x38, 0x12, 0xae, 0x57, 0xb0, 0x2f, 0xa2, 0xaf, 0x20, 0xed, 0x85, 0x87, 0xc9, 0x95, 0x4b, 0x1c,
+	0xc6, 0xbd, 0xa6, 0x8b, 0x0f, 0x0b, 0xd3, 0x90, 0x9f, 0x99, 0xe6, 0xa9, 0xb8, 0xc4, 0x41, 0x24,
+	0xca, 0xc3, 0xfd, 0x0b, 0x9b, 0x5a, 0x8c, 0x58, 0x6d, 0x3f, 0xa4, 0x18, 0x0f, 0x69, 0x56, 0x8d,
+	0x3e, 0x82, 0x84, 0xeb, 0xf0, 0xb4, 0x56, 0x38, 0x40, 0x48, 0x48, 0x87, 0x35, 0x87, 0x35, 0x99,
+	0x63, 0x58, 0xcd, 0xf3, 0x3e, 0x69, 0x67, 0xe3, 0x39, 0x39, 0x9f, 0xc4, 0x21, 0x1d, 0xd2, 0x00,
+	0x2e, 0xec, 0x66, 0x67, 0x40, 0x2c, 0x56, 0x3e, 0xce, 0x26, 0x78, 0xec, 0x01, 0x0d, 0xfa, 0x11,
+	0xd6, 0x5b, 0xdd, 0x5e, 0xbf, 0x5d, 0x3e, 0x66, 0xd4, 0x7b, 0xcf, 0xc9, 0xae, 0xe6, 0x62, 0xf9,
+	0x74, 0xf1, 0xa0, 0x10, 0xe8, 0x5e, 0x61, 0xbe, 0x22, 0x85, 0xa3, 0x90, 0x95, 0x61, 0x31, 0x7b,
+	0x84, 0x67, 0x5c, 0x79, 0x81, 0xf7, 0x1c, 0x4c, 0x29, 0xcb, 0x26, 0x79, 0x68, 0x42, 0x52, 0x4b,
+	0xb0, 0xb1, 0xc0, 0x1c, 0x65, 0x20, 0x76, 0x49, 0x46, 0xa2, 0xc0, 0xde, 0x11, 0x6d, 0x42, 0xfc,
+	0x55, 0xb3, 0xef, 0x12, 0xd1, 0xac, 0x89, 0xf0, 0xb5, 0x72, 0x28, 0xeb, 0xbf, 0x28, 0xb0, 0x71,
+	0x22, 0xea, 0x14, 0x64, 0xc1, 0x21, 0xac, 0xb0, 0xd1, 0x90, 0x70, 0x27, 0xeb, 0xc5, 0x47, 0xa1,
+	0x2c, 0x16, 0xe0, 0xcd, 0xd1, 0x90, 0x60, 0x6e, 0xb1, 0xa8, 0x1f, 0xca, 0xe2, 0x7e, 0x04, 0xc8,
+	0x10, 0x0b, 0x93, 0x21, 0xaa, 0x53, 0x33, 0x24, 0x89, 0xbf, 0x37, 0x49, 0x66, 0x5b, 0x9c, 0x98,
+	0x6f, 0xb1, 0x7e, 0x09, 0x1b, 0x81, 0xfe, 0xf8, 0x49, 0xa2, 0x6f, 0x20, 0xe1, 0xc1, 0x5c, 0x47,
+	0xd4, 0x62, 0x37, 0xaa, 0xa3, 0xbe, 0x45, 0x8d, 0xa3, 0xb1, 0xb0, 0xf2, 0x6a, 0x4f, 0x6c, 0x9b,
+	0xda, 0x7e, 0xed, 0xb9, 0xa0, 0x3f, 0x86, 0xed, 0x0a, 0x65, 0xbd, 0x8b, 0x91, 0xe0, 0x41, 0xad,
+	0xeb, 0xb2, 0x36, 0xbd, 0xb6, 0xfc, 0x80, 0xdf, 0x39, 0x85, 0xfa, 0x0e, 0x7c, 0x12, 0x61, 0xed,
+	0x0c, 0xa9, 0xe5, 0x10, 0x5d, 0x85, 0xec, 0x53, 0xc2, 0xb0, 0x6b, 0x59, 0x3d, 0xab, 0x33, 0x01,
+	0x39, 0xc2, 0xb5, 0x7e, 0x0a, 0x5b, 0x0b, 0xee, 0x26, 0x86, 0xe8, 0x60, 0xd2, 0x93, 0x1e, 0xf1,
+	0xd2, 0xf5, 0x08, 0xbc, 0x15, 0x4a, 0x37, 0x60, 0x35, 0xc2, 0x3e, 0x52, 0xff, 0x43, 0x81, 0xb5,
+	0xe0, 0xcd, 0x22, 0x0e, 0xc8, 0x4b, 0x39, 0xa0, 0x84, 0x39, 0x10, 0x9e, 0xb8, 0xd8, 0xdc, 0xc4,
+	0x45, 0x71, 0x64, 0x13, 0xe2, 0xdc, 0x05, 0x67, 0x47, 0x0a, 0x4f, 0x04, 0xf4, 0x08, 0xee, 0x11,
+	0xeb, 0xca, 0x25, 0x2e, 0x31, 0x7b, 0x03, 0xf2, 0xc2, 0xe1, 0x0c, 0x88, 0xe1, 0xb0, 0x32, 0x5c,
+	0xf5, 0xd5, 0xe5, 0xbb, 0x2f, 0xb9, 0x68, 0xf7, 0x79, 0x64, 0xbb, 0x20, 0xcc, 0xab, 0xda, 0x93,
+	0x11, 0x23, 0x4e, 0x36, 0xc5, 0x63, 0x0f, 0xe9, 0xf4, 0x06, 0x6c, 0x1d, 0x35, 0xad, 0x16, 0xe9,
+	0x87, 0x2a, 0x1a, 0xbd, 0xd2, 0xfe, 0x6f, 0xf9, 0xf4, 0x43, 0x50, 0x17, 0x3d, 0x20, 0xda, 0xac,
+	0x42, 0xb2, 0xc5, 0x6f, 0x49, 0x9b, 0xbb, 0x4e, 0xe2, 0xa9, 0xbc, 0xf7, 0x18, 0x3e, 0x8e, 0x98,
+	0x70, 0x94, 0x84, 0x95, 0x72, 0xa5, 0x6c, 0x66, 0x24, 0x94, 0x86, 0x55, 0xa3, 0x72, 0x56, 0x37,
+	0xea, 0x46, 0x46, 0x46, 0x00, 0x89, 0xa3, 0x52, 0xe5, 0xc8, 0x78, 0x9e, 0x51, 0xf6, 0x5a, 0xb0,
+	0x15, 0x39, 0x13, 0x28, 0x01, 0x4a, 0xf5, 0xdb, 0x8c, 0x84, 0x72, 0xb0, 0x6d, 0x56, 0xab, 0x8d,
+	0x17, 0xa5, 0xca, 0x0f, 0x0d, 0x6c, 0x9c, 0xd5, 0x8d, 0x9a, 0x59, 0x6b, 0x9c, 0x1a, 0xb8, 0x61,
+	0x1a, 0x95, 0x52, 0xc5, 0xcc, 0xc8, 0x28, 0x05, 0x71, 0x03, 0xe3, 0x2a, 0xce, 0x28, 0xe8, 0x01,
+	0xdc, 0xab, 0x3d, 0xab, 0x9b, 0x66, 0xb9, 0xf2, 0xb4, 0x71, 0x5c, 0xfd, 0xae, 0x92, 0x89, 0x15,
+	0xff, 0x96, 0x03, 0xb3, 0x7a, 0x42, 0x6d, 0xff, 0xf3, 0x52, 0x87, 0xb4, 0x38, 0x3e, 0xa7, 0x74,
+	0x88, 0x76, 0x42, 0xdc, 0x9d, 0xff, 0xd6, 0xa9, 0x3b, 0x4b, 0xb6, 0xb3, 0x2e, 0xe5, 0xe5, 0x2f,
+	0x64, 0x64, 0xc1, 0xc3, 0x85, 0xe3, 0x86, 0x3e, 0x0f, 0xd9, 0xbf, 0x6b, 0xa0, 0xd5, 0xbd, 0xf7,
+	0x81, 0x4e, 0xba, 0x53, 0xfc, 0x53, 0x81, 0xcd, 0x60, 0x7a, 0xd3, 0x5d, 0xf4, 0x3d, 0xac, 0xf9,
+	0x67, 0x9e, 0x60, 0x6e, 0xd9, 0x5e, 0x56, 0x73, 0xcb, 0xb6, 0x95, 0x48, 0xb1, 0x0d, 0x0f, 0xe6,
+	0x96, 0x02, 0xfa, 0x34, 0x64, 0x1c, 0xb5, 0x50, 0xd4, 0xdd, 0x65, 0x30, 0xb1, 0x94, 0x24, 0xd4,
+	0x01, 0x34, 0x4f, 0x4a, 0x14, 0xb6, 0x8f, 0x1c, 0x0b, 0xf5, 0xb3, 0xa5, 0x38, 0xff, 0xa1, 0xe2,
+	0x4f, 0xb0, 0x21, 0x8a, 0x7b, 0x42, 0xed, 0xb7, 0x5f, 0xb5, 0x0f, 0x92, 0xe5, 0x93, 0xd2, 0xcd,
+	0xad, 0x26, 0xbd, 0xbe, 0xd5, 0xa4, 0x37, 0xb7, 0x9a, 0xfc, 0xf3, 0x58, 0x93, 0x7f, 0x1f, 0x6b,
+	0xf2, 0x5f, 0x63, 0x4d, 0xbe, 0x19, 0x6b, 0xf2, 0x3f, 0x63, 0x4d, 0xfe, 0x77, 0xac, 0x49, 0x6f,
+	0xc6, 0x9a, 0xfc, 0xeb, 0x9d, 0x26, 0xdd, 0xdc, 0x69, 0xd2, 0xeb, 0x3b, 0x4d, 0x7a, 0x19, 0xfc,
+	0xcd, 0x3b, 0x4f, 0xf0, 0x3f, 0xb4, 0x83, 0xff, 0x06, 0x00, 0x95, 0xa4, 0x77, 0x04, 0x0d, 0x0a,
+	0x00, 0x00,
 }
 
 func (x FrontendToSchedulerType) String() string {
@@ -728,60 +1033,209 @@ func (this *NotifyQuerierShutdownResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *QuerierToScheduler) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *GetRunningQueriesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 6)
-	s = append(s, "&schedulerpb.QuerierToScheduler{")
-	s = append(s, "QuerierID: "+fmt.Sprintf("%#v", this.QuerierID)+",\n")
-	s = append(s, "QuerierAddress: "+fmt.Sprintf("%#v", this.QuerierAddress)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+
+	that1, ok := that.(*GetRunningQueriesRequest)
+	if !ok {
+		that2, ok := that.(GetRunningQueriesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
 }
-func (this *SchedulerToQuerier) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *GetRunningQueriesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 12)
-	s = append(s, "&schedulerpb.SchedulerToQuerier{")
-	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
-	if this.HttpRequest != nil {
-		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
+
+	that1, ok := that.(*GetRunningQueriesResponse)
+	if !ok {
+		that2, ok := that.(GetRunningQueriesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
-	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
-	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
-	s = append(s, "FragmentID: "+fmt.Sprintf("%#v", this.FragmentID)+",\n")
-	keysForChildIDtoAddrs := make([]uint64, 0, len(this.ChildIDtoAddrs))
-	for k, _ := range this.ChildIDtoAddrs {
-		keysForChildIDtoAddrs = append(keysForChildIDtoAddrs, k)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	github_com_gogo_protobuf_sortkeys.Uint64s(keysForChildIDtoAddrs)
-	mapStringForChildIDtoAddrs := "map[uint64]string{"
-	for _, k := range keysForChildIDtoAddrs {
-		mapStringForChildIDtoAddrs += fmt.Sprintf("%#v: %#v,", k, this.ChildIDtoAddrs[k])
+	if len(this.Queries) != len(that1.Queries) {
+		return false
 	}
-	mapStringForChildIDtoAddrs += "}"
-	if this.ChildIDtoAddrs != nil {
-		s = append(s, "ChildIDtoAddrs: "+mapStringForChildIDtoAddrs+",\n")
+	for i := range this.Queries {
+		if !this.Queries[i].Equal(that1.Queries[i]) {
+			return false
+		}
 	}
-	s = append(s, "IsRoot: "+fmt.Sprintf("%#v", this.IsRoot)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *FrontendToScheduler) GoString() string {
-	if this == nil {
-		return "nil"
-	}
-	s := make([]string, 0, 10)
-	s = append(s, "&schedulerpb.FrontendToScheduler{")
-	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
-	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
-	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
-	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
-	if this.HttpRequest != nil {
-		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
+func (this *RunningQuery) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RunningQuery)
+	if !ok {
+		that2, ok := that.(RunningQuery)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.FrontendAddress != that1.FrontendAddress {
+		return false
+	}
+	if this.QueryID != that1.QueryID {
+		return false
+	}
+	if this.FragmentID != that1.FragmentID {
+		return false
+	}
+	if this.UserID != that1.UserID {
+		return false
+	}
+	if this.Query != that1.Query {
+		return false
+	}
+	if this.EnqueueTimeMs != that1.EnqueueTimeMs {
+		return false
+	}
+	if this.QuerierID != that1.QuerierID {
+		return false
+	}
+	if this.QuerierAddress != that1.QuerierAddress {
+		return false
+	}
+	if this.FetchedBytes != that1.FetchedBytes {
+		return false
+	}
+	return true
+}
+func (this *CancelRunningQueryRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CancelRunningQueryRequest)
+	if !ok {
+		that2, ok := that.(CancelRunningQueryRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.FrontendAddress != that1.FrontendAddress {
+		return false
+	}
+	if this.QueryID != that1.QueryID {
+		return false
+	}
+	return true
+}
+func (this *CancelRunningQueryResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CancelRunningQueryResponse)
+	if !ok {
+		that2, ok := that.(CancelRunningQueryResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Canceled != that1.Canceled {
+		return false
+	}
+	return true
+}
+func (this *QuerierToScheduler) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&schedulerpb.QuerierToScheduler{")
+	s = append(s, "QuerierID: "+fmt.Sprintf("%#v", this.QuerierID)+",\n")
+	s = append(s, "QuerierAddress: "+fmt.Sprintf("%#v", this.QuerierAddress)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *SchedulerToQuerier) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 12)
+	s = append(s, "&schedulerpb.SchedulerToQuerier{")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	if this.HttpRequest != nil {
+		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
+	}
+	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
+	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
+	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "FragmentID: "+fmt.Sprintf("%#v", this.FragmentID)+",\n")
+	keysForChildIDtoAddrs := make([]uint64, 0, len(this.ChildIDtoAddrs))
+	for k, _ := range this.ChildIDtoAddrs {
+		keysForChildIDtoAddrs = append(keysForChildIDtoAddrs, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Uint64s(keysForChildIDtoAddrs)
+	mapStringForChildIDtoAddrs := "map[uint64]string{"
+	for _, k := range keysForChildIDtoAddrs {
+		mapStringForChildIDtoAddrs += fmt.Sprintf("%#v: %#v,", k, this.ChildIDtoAddrs[k])
+	}
+	mapStringForChildIDtoAddrs += "}"
+	if this.ChildIDtoAddrs != nil {
+		s = append(s, "ChildIDtoAddrs: "+mapStringForChildIDtoAddrs+",\n")
+	}
+	s = append(s, "IsRoot: "+fmt.Sprintf("%#v", this.IsRoot)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *FrontendToScheduler) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&schedulerpb.FrontendToScheduler{")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
+	if this.HttpRequest != nil {
+		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
 	}
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
 	s = append(s, "}")
@@ -817,6 +1271,66 @@ func (this *NotifyQuerierShutdownResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *GetRunningQueriesRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&schedulerpb.GetRunningQueriesRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *GetRunningQueriesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&schedulerpb.GetRunningQueriesResponse{")
+	if this.Queries != nil {
+		s = append(s, "Queries: "+fmt.Sprintf("%#v", this.Queries)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *RunningQuery) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 13)
+	s = append(s, "&schedulerpb.RunningQuery{")
+	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	s = append(s, "FragmentID: "+fmt.Sprintf("%#v", this.FragmentID)+",\n")
+	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
+	s = append(s, "Query: "+fmt.Sprintf("%#v", this.Query)+",\n")
+	s = append(s, "EnqueueTimeMs: "+fmt.Sprintf("%#v", this.EnqueueTimeMs)+",\n")
+	s = append(s, "QuerierID: "+fmt.Sprintf("%#v", this.QuerierID)+",\n")
+	s = append(s, "QuerierAddress: "+fmt.Sprintf("%#v", this.QuerierAddress)+",\n")
+	s = append(s, "FetchedBytes: "+fmt.Sprintf("%#v", this.FetchedBytes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CancelRunningQueryRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&schedulerpb.CancelRunningQueryRequest{")
+	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CancelRunningQueryResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&schedulerpb.CancelRunningQueryResponse{")
+	s = append(s, "Canceled: "+fmt.Sprintf("%#v", this.Canceled)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringScheduler(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1000,6 +1514,12 @@ type SchedulerForFrontendClient interface {
 	// parties... if connection breaks, frontend can cancel (and possibly retry on different scheduler) all pending
 	// requests sent to this scheduler, while scheduler can cancel queued requests from given frontend.
 	FrontendLoop(ctx context.Context, opts ...grpc.CallOption) (SchedulerForFrontend_FrontendLoopClient, error)
+	// Returns all requests tracked by the scheduler, both queued and running on queriers. Statistics of running
+	// requests are collected from the queriers executing them.
+	GetRunningQueries(ctx context.Context, in *GetRunningQueriesRequest, opts ...grpc.CallOption) (*GetRunningQueriesResponse, error)
+	// Cancels all fragments of a tracked query. Queriers executing the query are notified by closing their stream,
+	// and the frontend which enqueued the query receives an error response.
+	CancelRunningQuery(ctx context.Context, in *CancelRunningQueryRequest, opts ...grpc.CallOption) (*CancelRunningQueryResponse, error)
 }
 
 type schedulerForFrontendClient struct {
@@ -1041,6 +1561,24 @@ func (x *schedulerForFrontendFrontendLoopClient) Recv() (*SchedulerToFrontend, e
 	return m, nil
 }
 
+func (c *schedulerForFrontendClient) GetRunningQueries(ctx context.Context, in *GetRunningQueriesRequest, opts ...grpc.CallOption) (*GetRunningQueriesResponse, error) {
+	out := new(GetRunningQueriesResponse)
+	err := c.cc.Invoke(ctx, "/schedulerpb.SchedulerForFrontend/GetRunningQueries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerForFrontendClient) CancelRunningQuery(ctx context.Context, in *CancelRunningQueryRequest, opts ...grpc.CallOption) (*CancelRunningQueryResponse, error) {
+	out := new(CancelRunningQueryResponse)
+	err := c.cc.Invoke(ctx, "/schedulerpb.SchedulerForFrontend/CancelRunningQuery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SchedulerForFrontendServer is the server API for SchedulerForFrontend service.
 type SchedulerForFrontendServer interface {
 	// After calling this method, both Frontend and Scheduler enter a loop. Frontend will keep sending ENQUEUE and
@@ -1050,6 +1588,12 @@ type SchedulerForFrontendServer interface {
 	// parties... if connection breaks, frontend can cancel (and possibly retry on different scheduler) all pending
 	// requests sent to this scheduler, while scheduler can cancel queued requests from given frontend.
 	FrontendLoop(SchedulerForFrontend_FrontendLoopServer) error
+	// Returns all requests tracked by the scheduler, both queued and running on queriers. Statistics of running
+	// requests are collected from the queriers executing them.
+	GetRunningQueries(context.Context, *GetRunningQueriesRequest) (*GetRunningQueriesResponse, error)
+	// Cancels all fragments of a tracked query. Queriers executing the query are notified by closing their stream,
+	// and the frontend which enqueued the query receives an error response.
+	CancelRunningQuery(context.Context, *CancelRunningQueryRequest) (*CancelRunningQueryResponse, error)
 }
 
 // UnimplementedSchedulerForFrontendServer can be embedded to have forward compatible implementations.
@@ -1059,6 +1603,12 @@ type UnimplementedSchedulerForFrontendServer struct {
 func (*UnimplementedSchedulerForFrontendServer) FrontendLoop(srv SchedulerForFrontend_FrontendLoopServer) error {
 	return status.Errorf(codes.Unimplemented, "method FrontendLoop not implemented")
 }
+func (*UnimplementedSchedulerForFrontendServer) GetRunningQueries(ctx context.Context, req *GetRunningQueriesRequest) (*GetRunningQueriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRunningQueries not implemented")
+}
+func (*UnimplementedSchedulerForFrontendServer) CancelRunningQuery(ctx context.Context, req *CancelRunningQueryRequest) (*CancelRunningQueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelRunningQuery not implemented")
+}
 
 func RegisterSchedulerForFrontendServer(s *grpc.Server, srv SchedulerForFrontendServer) {
 	s.RegisterService(&_SchedulerForFrontend_serviceDesc, srv)
@@ -1090,10 +1640,55 @@ func (x *schedulerForFrontendFrontendLoopServer) Recv() (*FrontendToScheduler, e
 	return m, nil
 }
 
+func _SchedulerForFrontend_GetRunningQueries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunningQueriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerForFrontendServer).GetRunningQueries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schedulerpb.SchedulerForFrontend/GetRunningQueries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerForFrontendServer).GetRunningQueries(ctx, req.(*GetRunningQueriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SchedulerForFrontend_CancelRunningQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRunningQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchedulerForFrontendServer).CancelRunningQuery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schedulerpb.SchedulerForFrontend/CancelRunningQuery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchedulerForFrontendServer).CancelRunningQuery(ctx, req.(*CancelRunningQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _SchedulerForFrontend_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "schedulerpb.SchedulerForFrontend",
 	HandlerType: (*SchedulerForFrontendServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRunningQueries",
+			Handler:    _SchedulerForFrontend_GetRunningQueries_Handler,
+		},
+		{
+			MethodName: "CancelRunningQuery",
+			Handler:    _SchedulerForFrontend_CancelRunningQuery_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "FrontendLoop",
@@ -1105,32 +1700,104 @@ var _SchedulerForFrontend_serviceDesc = grpc.ServiceDesc{
 	Metadata: "scheduler.proto",
 }
 
-func (m *QuerierToScheduler) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+// QuerierForSchedulerClient is the client API for QuerierForScheduler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type QuerierForSchedulerClient interface {
+	GetRunningQueries(ctx context.Context, in *GetRunningQueriesRequest, opts ...grpc.CallOption) (*GetRunningQueriesResponse, error)
+}
+
+type querierForSchedulerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewQuerierForSchedulerClient(cc *grpc.ClientConn) QuerierForSchedulerClient {
+	return &querierForSchedulerClient{cc}
+}
+
+func (c *querierForSchedulerClient) GetRunningQueries(ctx context.Context, in *GetRunningQueriesRequest, opts ...grpc.CallOption) (*GetRunningQueriesResponse, error) {
+	out := new(GetRunningQueriesResponse)
+	err := c.cc.Invoke(ctx, "/schedulerpb.QuerierForScheduler/GetRunningQueries", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *QuerierToScheduler) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// QuerierForSchedulerServer is the server API for QuerierForScheduler service.
+type QuerierForSchedulerServer interface {
+	GetRunningQueries(context.Context, *GetRunningQueriesRequest) (*GetRunningQueriesResponse, error)
 }
 
-func (m *QuerierToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.QuerierAddress) > 0 {
-		i -= len(m.QuerierAddress)
-		copy(dAtA[i:], m.QuerierAddress)
-		i = encodeVarintScheduler(dAtA, i, uint64(len(m.QuerierAddress)))
-		i--
-		dAtA[i] = 0x12
+// UnimplementedQuerierForSchedulerServer can be embedded to have forward compatible implementations.
+type UnimplementedQuerierForSchedulerServer struct {
+}
+
+func (*UnimplementedQuerierForSchedulerServer) GetRunningQueries(ctx context.Context, req *GetRunningQueriesRequest) (*GetRunningQueriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRunningQueries not implemented")
+}
+
+func RegisterQuerierForSchedulerServer(s *grpc.Server, srv QuerierForSchedulerServer) {
+	s.RegisterService(&_QuerierForScheduler_serviceDesc, srv)
+}
+
+func _QuerierForScheduler_GetRunningQueries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRunningQueriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuerierForSchedulerServer).GetRunningQueries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/schedulerpb.QuerierForScheduler/GetRunningQueries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuerierForSchedulerServer).GetRunningQueries(ctx, req.(*GetRunningQueriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _QuerierForScheduler_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "schedulerpb.QuerierForScheduler",
+	HandlerType: (*QuerierForSchedulerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRunningQueries",
+			Handler:    _QuerierForScheduler_GetRunningQueries_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "scheduler.proto",
+}
+
+func (m *QuerierToScheduler) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuerierToScheduler) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuerierToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.QuerierAddress) > 0 {
+		i -= len(m.QuerierAddress)
+		copy(dAtA[i:], m.QuerierAddress)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.QuerierAddress)))
+		i--
+		dAtA[i] = 0x12
 	}
 	if len(m.QuerierID) > 0 {
 		i -= len(m.QuerierID)
@@ -1395,6 +2062,212 @@ func (m *NotifyQuerierShutdownResponse) MarshalToSizedBuffer(dAtA []byte) (int,
 	return len(dAtA) - i, nil
 }
 
+func (m *GetRunningQueriesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRunningQueriesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRunningQueriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *GetRunningQueriesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRunningQueriesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetRunningQueriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Queries) > 0 {
+		for iNdEx := len(m.Queries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Queries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintScheduler(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RunningQuery) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RunningQuery) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RunningQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.FetchedBytes != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.FetchedBytes))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.QuerierAddress) > 0 {
+		i -= len(m.QuerierAddress)
+		copy(dAtA[i:], m.QuerierAddress)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.QuerierAddress)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.QuerierID) > 0 {
+		i -= len(m.QuerierID)
+		copy(dAtA[i:], m.QuerierID)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.QuerierID)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.EnqueueTimeMs != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.EnqueueTimeMs))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Query) > 0 {
+		i -= len(m.Query)
+		copy(dAtA[i:], m.Query)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.Query)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.UserID) > 0 {
+		i -= len(m.UserID)
+		copy(dAtA[i:], m.UserID)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.UserID)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.FragmentID != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.FragmentID))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.QueryID != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.QueryID))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.FrontendAddress) > 0 {
+		i -= len(m.FrontendAddress)
+		copy(dAtA[i:], m.FrontendAddress)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.FrontendAddress)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CancelRunningQueryRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CancelRunningQueryRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CancelRunningQueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.QueryID != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.QueryID))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.FrontendAddress) > 0 {
+		i -= len(m.FrontendAddress)
+		copy(dAtA[i:], m.FrontendAddress)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.FrontendAddress)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CancelRunningQueryResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CancelRunningQueryResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CancelRunningQueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Canceled {
+		i--
+		if m.Canceled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintScheduler(dAtA []byte, offset int, v uint64) int {
 	offset -= sovScheduler(v)
 	base := offset
@@ -1532,34 +2405,127 @@ func (m *NotifyQuerierShutdownResponse) Size() (n int) {
 	return n
 }
 
-func sovScheduler(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozScheduler(x uint64) (n int) {
-	return sovScheduler(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *QuerierToScheduler) String() string {
-	if this == nil {
-		return "nil"
+func (m *GetRunningQueriesRequest) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	s := strings.Join([]string{`&QuerierToScheduler{`,
-		`QuerierID:` + fmt.Sprintf("%v", this.QuerierID) + `,`,
-		`QuerierAddress:` + fmt.Sprintf("%v", this.QuerierAddress) + `,`,
-		`}`,
-	}, "")
-	return s
+	var l int
+	_ = l
+	return n
 }
-func (this *SchedulerToQuerier) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *GetRunningQueriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	keysForChildIDtoAddrs := make([]uint64, 0, len(this.ChildIDtoAddrs))
-	for k, _ := range this.ChildIDtoAddrs {
-		keysForChildIDtoAddrs = append(keysForChildIDtoAddrs, k)
+	var l int
+	_ = l
+	if len(m.Queries) > 0 {
+		for _, e := range m.Queries {
+			l = e.Size()
+			n += 1 + l + sovScheduler(uint64(l))
+		}
 	}
-	github_com_gogo_protobuf_sortkeys.Uint64s(keysForChildIDtoAddrs)
-	mapStringForChildIDtoAddrs := "map[uint64]string{"
-	for _, k := range keysForChildIDtoAddrs {
+	return n
+}
+
+func (m *RunningQuery) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.FrontendAddress)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.QueryID != 0 {
+		n += 1 + sovScheduler(uint64(m.QueryID))
+	}
+	if m.FragmentID != 0 {
+		n += 1 + sovScheduler(uint64(m.FragmentID))
+	}
+	l = len(m.UserID)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = len(m.Query)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.EnqueueTimeMs != 0 {
+		n += 1 + sovScheduler(uint64(m.EnqueueTimeMs))
+	}
+	l = len(m.QuerierID)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = len(m.QuerierAddress)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.FetchedBytes != 0 {
+		n += 1 + sovScheduler(uint64(m.FetchedBytes))
+	}
+	return n
+}
+
+func (m *CancelRunningQueryRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.FrontendAddress)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.QueryID != 0 {
+		n += 1 + sovScheduler(uint64(m.QueryID))
+	}
+	return n
+}
+
+func (m *CancelRunningQueryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Canceled {
+		n += 2
+	}
+	return n
+}
+
+func sovScheduler(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozScheduler(x uint64) (n int) {
+	return sovScheduler(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *QuerierToScheduler) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QuerierToScheduler{`,
+		`QuerierID:` + fmt.Sprintf("%v", this.QuerierID) + `,`,
+		`QuerierAddress:` + fmt.Sprintf("%v", this.QuerierAddress) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SchedulerToQuerier) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForChildIDtoAddrs := make([]uint64, 0, len(this.ChildIDtoAddrs))
+	for k, _ := range this.ChildIDtoAddrs {
+		keysForChildIDtoAddrs = append(keysForChildIDtoAddrs, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Uint64s(keysForChildIDtoAddrs)
+	mapStringForChildIDtoAddrs := "map[uint64]string{"
+	for _, k := range keysForChildIDtoAddrs {
 		mapStringForChildIDtoAddrs += fmt.Sprintf("%v: %v,", k, this.ChildIDtoAddrs[k])
 	}
 	mapStringForChildIDtoAddrs += "}"
@@ -1621,6 +2587,69 @@ func (this *NotifyQuerierShutdownResponse) String() string {
 	}, "")
 	return s
 }
+func (this *GetRunningQueriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&GetRunningQueriesRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *GetRunningQueriesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForQueries := "[]*RunningQuery{"
+	for _, f := range this.Queries {
+		repeatedStringForQueries += strings.Replace(f.String(), "RunningQuery", "RunningQuery", 1) + ","
+	}
+	repeatedStringForQueries += "}"
+	s := strings.Join([]string{`&GetRunningQueriesResponse{`,
+		`Queries:` + repeatedStringForQueries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RunningQuery) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RunningQuery{`,
+		`FrontendAddress:` + fmt.Sprintf("%v", this.FrontendAddress) + `,`,
+		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
+		`FragmentID:` + fmt.Sprintf("%v", this.FragmentID) + `,`,
+		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
+		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
+		`EnqueueTimeMs:` + fmt.Sprintf("%v", this.EnqueueTimeMs) + `,`,
+		`QuerierID:` + fmt.Sprintf("%v", this.QuerierID) + `,`,
+		`QuerierAddress:` + fmt.Sprintf("%v", this.QuerierAddress) + `,`,
+		`FetchedBytes:` + fmt.Sprintf("%v", this.FetchedBytes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CancelRunningQueryRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&CancelRunningQueryRequest{`,
+		`FrontendAddress:` + fmt.Sprintf("%v", this.FrontendAddress) + `,`,
+		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CancelRunningQueryResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&CancelRunningQueryResponse{`,
+		`Canceled:` + fmt.Sprintf("%v", this.Canceled) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringScheduler(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -2044,13 +3073,592 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 					iNdEx += skippy
 				}
 			}
-			m.ChildIDtoAddrs[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 8:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsRoot", wireType)
+			m.ChildIDtoAddrs[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsRoot", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsRoot = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FrontendToScheduler: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FrontendToScheduler: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= FrontendToSchedulerType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FrontendAddress", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FrontendAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HttpRequest", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.HttpRequest == nil {
+				m.HttpRequest = &httpgrpc.HTTPRequest{}
+			}
+			if err := m.HttpRequest.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StatsEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.StatsEnabled = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SchedulerToFrontend: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SchedulerToFrontend: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= SchedulerToFrontendStatus(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NotifyQuerierShutdownRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NotifyQuerierShutdownRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NotifyQuerierShutdownRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuerierID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QuerierID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NotifyQuerierShutdownResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NotifyQuerierShutdownResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NotifyQuerierShutdownResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRunningQueriesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRunningQueriesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRunningQueriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRunningQueriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			var v int
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRunningQueriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRunningQueriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Queries", wireType)
+			}
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowScheduler
@@ -2060,12 +3668,26 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.IsRoot = bool(v != 0)
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Queries = append(m.Queries, &RunningQuery{})
+			if err := m.Queries[len(m.Queries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -2090,7 +3712,7 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
+func (m *RunningQuery) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2113,32 +3735,13 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: FrontendToScheduler: wiretype end group for non-group")
+			return fmt.Errorf("proto: RunningQuery: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: FrontendToScheduler: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RunningQuery: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
-			}
-			m.Type = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowScheduler
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Type |= FrontendToSchedulerType(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field FrontendAddress", wireType)
 			}
@@ -2170,7 +3773,7 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 			}
 			m.FrontendAddress = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
+		case 2:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
 			}
@@ -2189,6 +3792,25 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FragmentID", wireType)
+			}
+			m.FragmentID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FragmentID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		case 4:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field UserID", wireType)
@@ -2223,9 +3845,9 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 			iNdEx = postIndex
 		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HttpRequest", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowScheduler
@@ -2235,33 +3857,29 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthScheduler
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthScheduler
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.HttpRequest == nil {
-				m.HttpRequest = &httpgrpc.HTTPRequest{}
-			}
-			if err := m.HttpRequest.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Query = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 6:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StatsEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EnqueueTimeMs", wireType)
 			}
-			var v int
+			m.EnqueueTimeMs = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowScheduler
@@ -2271,70 +3889,16 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.EnqueueTimeMs |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.StatsEnabled = bool(v != 0)
-		default:
-			iNdEx = preIndex
-			skippy, err := skipScheduler(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthScheduler
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthScheduler
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowScheduler
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: SchedulerToFrontend: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SchedulerToFrontend: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuerierID", wireType)
 			}
-			m.Status = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowScheduler
@@ -2344,14 +3908,27 @@ func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Status |= SchedulerToFrontendStatus(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QuerierID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field QuerierAddress", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2379,8 +3956,27 @@ func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Error = string(dAtA[iNdEx:postIndex])
+			m.QuerierAddress = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedBytes", wireType)
+			}
+			m.FetchedBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -2405,7 +4001,7 @@ func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *NotifyQuerierShutdownRequest) Unmarshal(dAtA []byte) error {
+func (m *CancelRunningQueryRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2428,15 +4024,15 @@ func (m *NotifyQuerierShutdownRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: NotifyQuerierShutdownRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: CancelRunningQueryRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: NotifyQuerierShutdownRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CancelRunningQueryRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field QuerierID", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FrontendAddress", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2464,8 +4060,27 @@ func (m *NotifyQuerierShutdownRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.QuerierID = string(dAtA[iNdEx:postIndex])
+			m.FrontendAddress = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -2490,7 +4105,7 @@ func (m *NotifyQuerierShutdownRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *NotifyQuerierShutdownResponse) Unmarshal(dAtA []byte) error {
+func (m *CancelRunningQueryResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2513,12 +4128,32 @@ func (m *NotifyQuerierShutdownResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: NotifyQuerierShutdownResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: CancelRunningQueryResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: NotifyQuerierShutdownResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CancelRunningQueryResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Canceled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Canceled = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -125,7 +125,7 @@ func NewClient(ctx context.Context, cfg Config, hedgedRoundTripper func(rt http.
 		return nil, err
 	}
 
-	iClient := opentracing.WrapWithTraces(bucketWithMetrics(client, name, reg))
+	iClient := opentracing.WrapWithTraces(bucketWithMetrics(bucketWithTenantMetrics(client, name, reg), name, reg))
 
 	// Wrap the client with any provided middleware
 	for _, wrap := range cfg.Middlewares {
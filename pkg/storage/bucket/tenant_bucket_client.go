@@ -0,0 +1,225 @@
+package bucket
+
+import (
+	"context"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+)
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a context carrying the tenant the bucket operations are run on behalf of.
+func ContextWithTenant(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, userID)
+}
+
+// TenantFromContext returns the tenant the bucket operations are run on behalf of, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(tenantContextKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// TenantBucketClient is a wrapper around a objstore.Bucket that tags the context of every
+// operation with the tenant, so that the operations can be attributed to it.
+type TenantBucketClient struct {
+	userID string
+	bucket objstore.Bucket
+}
+
+// NewTenantBucketClient makes a new TenantBucketClient.
+func NewTenantBucketClient(userID string, bucket objstore.Bucket) *TenantBucketClient {
+	return &TenantBucketClient{
+		userID: userID,
+		bucket: bucket,
+	}
+}
+
+// Close implements objstore.Bucket.
+func (b *TenantBucketClient) Close() error {
+	return b.bucket.Close()
+}
+
+// Upload implements objstore.Bucket.
+func (b *TenantBucketClient) Upload(ctx context.Context, name string, r io.Reader, opts ...objstore.ObjectUploadOption) error {
+	return b.bucket.Upload(ContextWithTenant(ctx, b.userID), name, r, opts...)
+}
+
+// Delete implements objstore.Bucket.
+func (b *TenantBucketClient) Delete(ctx context.Context, name string) error {
+	return b.bucket.Delete(ContextWithTenant(ctx, b.userID), name)
+}
+
+// Name implements objstore.Bucket.
+func (b *TenantBucketClient) Name() string {
+	return b.bucket.Name()
+}
+
+func (b *TenantBucketClient) Provider() objstore.ObjProvider {
+	return b.bucket.Provider()
+}
+
+func (b *TenantBucketClient) IterWithAttributes(ctx context.Context, dir string, f func(attrs objstore.IterObjectAttributes) error, options ...objstore.IterOption) error {
+	return b.bucket.IterWithAttributes(ContextWithTenant(ctx, b.userID), dir, f, options...)
+}
+
+func (b *TenantBucketClient) SupportedIterOptions() []objstore.IterOptionType {
+	return b.bucket.SupportedIterOptions()
+}
+
+// Iter implements objstore.Bucket.
+func (b *TenantBucketClient) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	return b.bucket.Iter(ContextWithTenant(ctx, b.userID), dir, f, options...)
+}
+
+// Get implements objstore.Bucket.
+func (b *TenantBucketClient) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.bucket.Get(ContextWithTenant(ctx, b.userID), name)
+}
+
+// GetRange implements objstore.Bucket.
+func (b *TenantBucketClient) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.bucket.GetRange(ContextWithTenant(ctx, b.userID), name, off, length)
+}
+
+// Exists implements objstore.Bucket.
+func (b *TenantBucketClient) Exists(ctx context.Context, name string) (bool, error) {
+	return b.bucket.Exists(ContextWithTenant(ctx, b.userID), name)
+}
+
+// IsObjNotFoundErr implements objstore.Bucket.
+func (b *TenantBucketClient) IsObjNotFoundErr(err error) bool {
+	return b.bucket.IsObjNotFoundErr(err)
+}
+
+// IsAccessDeniedErr implements objstore.Bucket.
+func (b *TenantBucketClient) IsAccessDeniedErr(err error) bool {
+	return b.bucket.IsAccessDeniedErr(err)
+}
+
+// Attributes implements objstore.Bucket.
+func (b *TenantBucketClient) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	return b.bucket.Attributes(ContextWithTenant(ctx, b.userID), name)
+}
+
+// ReaderWithExpectedErrs implements objstore.Bucket.
+func (b *TenantBucketClient) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs implements objstore.Bucket.
+func (b *TenantBucketClient) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := b.bucket.(objstore.InstrumentedBucket); ok {
+		return &TenantBucketClient{
+			userID: b.userID,
+			bucket: ib.WithExpectedErrs(fn),
+		}
+	}
+
+	return b
+}
+
+// tenantMetricsBucket is a wrapper around a objstore.Bucket tracking the operations run against
+// the storage, and the bytes fetched and uploaded, on behalf of each tenant. Operations which are
+// not run on behalf of a tenant are not tracked.
+type tenantMetricsBucket struct {
+	objstore.Bucket
+
+	operations *prometheus.CounterVec
+	bytes      *prometheus.CounterVec
+}
+
+func bucketWithTenantMetrics(bucketClient objstore.Bucket, name string, reg prometheus.Registerer) objstore.Bucket {
+	if reg == nil {
+		return bucketClient
+	}
+
+	reg = prometheus.WrapRegistererWith(prometheus.Labels{"component": name}, reg)
+	return &tenantMetricsBucket{
+		Bucket: bucketClient,
+		operations: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_tenant_operations_total",
+			Help: "Total number of operations run against the object storage on behalf of a tenant, including the failed ones.",
+		}, []string{"user", "operation"}),
+		bytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_tenant_operation_bytes_total",
+			Help: "Total number of bytes of the objects fetched from and uploaded to the object storage on behalf of a tenant.",
+		}, []string{"user", "operation"}),
+	}
+}
+
+func (b *tenantMetricsBucket) trackOperation(ctx context.Context, op string) (string, bool) {
+	userID, ok := TenantFromContext(ctx)
+	if ok {
+		b.operations.WithLabelValues(userID, op).Inc()
+	}
+	return userID, ok
+}
+
+func (b *tenantMetricsBucket) Upload(ctx context.Context, name string, r io.Reader, opts ...objstore.ObjectUploadOption) error {
+	userID, ok := b.trackOperation(ctx, objstore.OpUpload)
+
+	// The readers are not wrapped to count the bytes, because the clients rely on their type
+	// to find out the object size, so the bytes are the size of the objects instead.
+	size, sizeErr := objstore.TryToGetSize(r)
+	if err := b.Bucket.Upload(ctx, name, r, opts...); err != nil {
+		return err
+	}
+	if ok && sizeErr == nil {
+		b.bytes.WithLabelValues(userID, objstore.OpUpload).Add(float64(size))
+	}
+	return nil
+}
+
+func (b *tenantMetricsBucket) Delete(ctx context.Context, name string) error {
+	b.trackOperation(ctx, objstore.OpDelete)
+	return b.Bucket.Delete(ctx, name)
+}
+
+func (b *tenantMetricsBucket) IterWithAttributes(ctx context.Context, dir string, f func(attrs objstore.IterObjectAttributes) error, options ...objstore.IterOption) error {
+	b.trackOperation(ctx, objstore.OpIter)
+	return b.Bucket.IterWithAttributes(ctx, dir, f, options...)
+}
+
+func (b *tenantMetricsBucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	b.trackOperation(ctx, objstore.OpIter)
+	return b.Bucket.Iter(ctx, dir, f, options...)
+}
+
+func (b *tenantMetricsBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	userID, ok := b.trackOperation(ctx, objstore.OpGet)
+	r, err := b.Bucket.Get(ctx, name)
+	if err == nil && ok {
+		if size, sizeErr := objstore.TryToGetSize(r); sizeErr == nil {
+			b.bytes.WithLabelValues(userID, objstore.OpGet).Add(float64(size))
+		}
+	}
+	return r, err
+}
+
+func (b *tenantMetricsBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	userID, ok := b.trackOperation(ctx, objstore.OpGetRange)
+	r, err := b.Bucket.GetRange(ctx, name, off, length)
+	if err == nil && ok {
+		size, sizeErr := length, error(nil)
+		if size < 0 {
+			size, sizeErr = objstore.TryToGetSize(r)
+		}
+		if sizeErr == nil {
+			b.bytes.WithLabelValues(userID, objstore.OpGetRange).Add(float64(size))
+		}
+	}
+	return r, err
+}
+
+func (b *tenantMetricsBucket) Exists(ctx context.Context, name string) (bool, error) {
+	b.trackOperation(ctx, objstore.OpExists)
+	return b.Bucket.Exists(ctx, name)
+}
+
+func (b *tenantMetricsBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	b.trackOperation(ctx, objstore.OpAttributes)
+	return b.Bucket.Attributes(ctx, name)
+}
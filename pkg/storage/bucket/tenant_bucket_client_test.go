@@ -0,0 +1,85 @@
+package bucket
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := TenantFromContext(ctx)
+	assert.False(t, ok)
+
+	_, ok = TenantFromContext(ContextWithTenant(ctx, ""))
+	assert.False(t, ok)
+
+	userID, ok := TenantFromContext(ContextWithTenant(ctx, "user-1"))
+	assert.True(t, ok)
+	assert.Equal(t, "user-1", userID)
+}
+
+func TestTenantMetricsBucket(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	bkt := bucketWithTenantMetrics(objstore.NewInMemBucket(), "test", reg)
+	userBkt := NewUserBucketClient("user-1", bkt, nil)
+
+	// Operations run on behalf of a tenant are tracked.
+	require.NoError(t, userBkt.Upload(ctx, "object", bytes.NewReader([]byte("content"))))
+
+	r, err := userBkt.Get(ctx, "object")
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	r, err = userBkt.GetRange(ctx, "object", 1, 3)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	_, err = userBkt.Get(ctx, "missing")
+	require.True(t, userBkt.IsObjNotFoundErr(err))
+
+	ok, err := userBkt.Exists(ctx, "object")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = userBkt.Attributes(ctx, "object")
+	require.NoError(t, err)
+
+	require.NoError(t, userBkt.Iter(ctx, "", func(string) error { return nil }))
+	require.NoError(t, userBkt.Delete(ctx, "object"))
+
+	// Operations not run on behalf of a tenant are not tracked.
+	require.NoError(t, bkt.Upload(ctx, "user-2/object", bytes.NewReader([]byte("content"))))
+	_, err = bkt.Exists(ctx, "user-2/object")
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_tenant_operations_total Total number of operations run against the object storage on behalf of a tenant, including the failed ones.
+		# TYPE cortex_bucket_tenant_operations_total counter
+		cortex_bucket_tenant_operations_total{component="test",operation="attributes",user="user-1"} 1
+		cortex_bucket_tenant_operations_total{component="test",operation="delete",user="user-1"} 1
+		cortex_bucket_tenant_operations_total{component="test",operation="exists",user="user-1"} 1
+		cortex_bucket_tenant_operations_total{component="test",operation="get",user="user-1"} 2
+		cortex_bucket_tenant_operations_total{component="test",operation="get_range",user="user-1"} 1
+		cortex_bucket_tenant_operations_total{component="test",operation="iter",user="user-1"} 1
+		cortex_bucket_tenant_operations_total{component="test",operation="upload",user="user-1"} 1
+
+		# HELP cortex_bucket_tenant_operation_bytes_total Total number of bytes of the objects fetched from and uploaded to the object storage on behalf of a tenant.
+		# TYPE cortex_bucket_tenant_operation_bytes_total counter
+		cortex_bucket_tenant_operation_bytes_total{component="test",operation="get",user="user-1"} 7
+		cortex_bucket_tenant_operation_bytes_total{component="test",operation="get_range",user="user-1"} 3
+		cortex_bucket_tenant_operation_bytes_total{component="test",operation="upload",user="user-1"} 7
+	`), "cortex_bucket_tenant_operations_total", "cortex_bucket_tenant_operation_bytes_total"))
+}
@@ -0,0 +1,489 @@
+package bucket
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	tsdb_errors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket/azure"
+	"github.com/cortexproject/cortex/pkg/storage/bucket/filesystem"
+	"github.com/cortexproject/cortex/pkg/storage/bucket/gcs"
+	"github.com/cortexproject/cortex/pkg/storage/bucket/s3"
+	"github.com/cortexproject/cortex/pkg/storage/bucket/swift"
+)
+
+const (
+	// coldBlocksIndexFilename is the name of the tenant's bucket index, which records the storage tier
+	// of the blocks. It's the same as bucketindex.IndexCompressedFilename, which can't be imported here.
+	coldBlocksIndexFilename = "bucket-index.json.gz"
+
+	// coldStorageTier is the storage tier of the blocks moved to the cold bucket in the bucket
+	// index. It's the same as bucketindex.StorageTierCold.
+	coldStorageTier = "cold"
+
+	// ColdBlocksRefreshInterval is the min interval between two reloads of the tenant's cold blocks
+	// from its bucket index, when an object of a block not known to be cold is not found.
+	ColdBlocksRefreshInterval = time.Minute
+)
+
+var errInvalidColdStorageMinBlockAge = errors.New("cold storage min block age must be greater than 0")
+
+// ColdStorageConfig holds the configuration of the cold storage tier, where the blocks
+// older than the min block age are moved to.
+type ColdStorageConfig struct {
+	Backend string `yaml:"backend"`
+	// Backends
+	S3         s3.Config         `yaml:"s3"`
+	GCS        gcs.Config        `yaml:"gcs"`
+	Azure      azure.Config      `yaml:"azure"`
+	Swift      swift.Config      `yaml:"swift"`
+	Filesystem filesystem.Config `yaml:"filesystem"`
+
+	MinBlockAge time.Duration `yaml:"min_block_age"`
+}
+
+func (cfg *ColdStorageConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	cfg.S3.RegisterFlagsWithPrefix(prefix, f)
+	cfg.GCS.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Azure.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Swift.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Filesystem.RegisterFlagsWithPrefix(prefix, f)
+
+	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("[Experimental] Backend storage of the cold storage tier, where the compactor moves the old blocks to. The blocks are read from the cold storage transparently. Supported backends are: %s. Empty to disable the cold storage.", strings.Join(SupportedBackends, ", ")))
+	f.DurationVar(&cfg.MinBlockAge, prefix+"min-block-age", 90*24*time.Hour, "[Experimental] The compactor moves the blocks whose max time is older than this age to the cold storage.")
+}
+
+func (cfg *ColdStorageConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	bucketCfg := cfg.BucketConfig()
+	if err := bucketCfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.MinBlockAge <= 0 {
+		return errInvalidColdStorageMinBlockAge
+	}
+	return nil
+}
+
+// Enabled returns whether the cold storage is configured.
+func (cfg *ColdStorageConfig) Enabled() bool {
+	return cfg.Backend != ""
+}
+
+// BucketConfig returns the config of the cold storage bucket client.
+func (cfg *ColdStorageConfig) BucketConfig() Config {
+	return Config{
+		Backend:    cfg.Backend,
+		S3:         cfg.S3,
+		GCS:        cfg.GCS,
+		Azure:      cfg.Azure,
+		Swift:      cfg.Swift,
+		Filesystem: cfg.Filesystem,
+	}
+}
+
+// TieredBucketClient is a bucket client writing the objects to a hot bucket, and reading
+// them from the hot bucket or from the cold one the blocks have been moved to. The blocks
+// moved to the cold bucket are the ones whose storage tier is cold in the bucket index.
+type TieredBucketClient struct {
+	hot  objstore.InstrumentedBucket
+	cold objstore.InstrumentedBucket
+
+	// Buckets used by the operations. The ones used to look up the hot bucket
+	// first don't track the objects not found as failures.
+	hotBucket        objstore.Bucket
+	coldBucket       objstore.Bucket
+	hotLookupBucket  objstore.Bucket
+	coldLookupBucket objstore.Bucket
+
+	// Blocks known to have been moved to the cold bucket, shared with the
+	// clients returned by WithExpectedErrs().
+	coldBlocks *coldBlocks
+}
+
+// coldBlocks tracks the blocks moved to the cold bucket, per tenant.
+type coldBlocks struct {
+	tenants sync.Map // map[string]*tenantColdBlocks
+}
+
+type tenantColdBlocks struct {
+	mtx      sync.Mutex
+	loadedAt time.Time
+	blocks   map[string]struct{}
+}
+
+func (c *coldBlocks) tenant(userID string) *tenantColdBlocks {
+	t, _ := c.tenants.LoadOrStore(userID, &tenantColdBlocks{blocks: map[string]struct{}{}})
+	return t.(*tenantColdBlocks)
+}
+
+// NewTieredBucketClient returns a new TieredBucketClient.
+func NewTieredBucketClient(hot, cold objstore.InstrumentedBucket) *TieredBucketClient {
+	return newTieredBucketClient(hot, cold, nil, &coldBlocks{})
+}
+
+func newTieredBucketClient(hot, cold objstore.InstrumentedBucket, fn objstore.IsOpFailureExpectedFunc, coldBlocks *coldBlocks) *TieredBucketClient {
+	b := &TieredBucketClient{
+		hot:              hot,
+		cold:             cold,
+		hotBucket:        hot,
+		coldBucket:       cold,
+		hotLookupBucket:  hot.WithExpectedErrs(hot.IsObjNotFoundErr),
+		coldLookupBucket: cold.WithExpectedErrs(cold.IsObjNotFoundErr),
+		coldBlocks:       coldBlocks,
+	}
+
+	if fn != nil {
+		b.hotBucket = hot.WithExpectedErrs(fn)
+		b.coldBucket = cold.WithExpectedErrs(fn)
+		b.hotLookupBucket = hot.WithExpectedErrs(func(err error) bool { return hot.IsObjNotFoundErr(err) || fn(err) })
+		b.coldLookupBucket = cold.WithExpectedErrs(func(err error) bool { return cold.IsObjNotFoundErr(err) || fn(err) })
+	}
+
+	return b
+}
+
+// blockOf returns the tenant and the ID of the block the object belongs to, if any.
+func blockOf(name string) (string, string, bool) {
+	parts := strings.SplitN(name, objstore.DirDelim, 3)
+	if len(parts) < 3 {
+		return "", "", false
+	}
+	if _, err := ulid.Parse(parts[1]); err != nil {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// isColdBlock returns whether the block the object belongs to is known to have been moved to the
+// cold bucket. The cold blocks of the tenant are loaded from its bucket index the first time, and
+// reloaded if they have been loaded more than maxAge ago.
+func (b *TieredBucketClient) isColdBlock(ctx context.Context, name string, maxAge time.Duration) bool {
+	userID, blockID, ok := blockOf(name)
+	if !ok {
+		return false
+	}
+
+	t := b.coldBlocks.tenant(userID)
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.loadedAt.IsZero() || (maxAge > 0 && time.Since(t.loadedAt) > maxAge) {
+		b.loadColdBlocks(ctx, userID, t)
+		t.loadedAt = time.Now()
+	}
+
+	_, cold := t.blocks[blockID]
+	return cold
+}
+
+// loadColdBlocks adds the blocks whose storage tier is cold in the tenant's bucket index to the
+// cold blocks of the tenant. The blocks are never moved back to the hot bucket, so the ones already
+// known to be cold are kept even if the bucket index doesn't record it yet. Errors are ignored: a
+// missing or unreadable bucket index leaves the known cold blocks unchanged.
+func (b *TieredBucketClient) loadColdBlocks(ctx context.Context, userID string, t *tenantColdBlocks) {
+	r, err := b.hotLookupBucket.Get(ctx, userID+objstore.DirDelim+coldBlocksIndexFilename)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return
+	}
+	defer gzipReader.Close()
+
+	idx := struct {
+		Blocks []struct {
+			ID          string `json:"block_id"`
+			StorageTier string `json:"storage_tier"`
+		} `json:"blocks"`
+	}{}
+	if err := json.NewDecoder(gzipReader).Decode(&idx); err != nil {
+		return
+	}
+
+	for _, block := range idx.Blocks {
+		if block.StorageTier == coldStorageTier {
+			t.blocks[block.ID] = struct{}{}
+		}
+	}
+}
+
+func (b *TieredBucketClient) markColdBlock(name string) {
+	userID, blockID, ok := blockOf(name)
+	if !ok {
+		return
+	}
+
+	t := b.coldBlocks.tenant(userID)
+	t.mtx.Lock()
+	t.blocks[blockID] = struct{}{}
+	t.mtx.Unlock()
+}
+
+// tieredRead looks the object up in the cold bucket first if its block is known to have been
+// moved there, otherwise in the hot bucket only. The objects uploaded to the block after it has
+// been moved, like the markers, are in the hot bucket. If the object is not found in the hot
+// bucket, the tenant's cold blocks are reloaded from the bucket index in case the block has
+// been moved in the meanwhile.
+func tieredRead[T any](ctx context.Context, b *TieredBucketClient, name string, read func(objstore.BucketReader) (T, error)) (T, error) {
+	if b.isColdBlock(ctx, name, 0) {
+		res, err := read(b.coldLookupBucket)
+		if err == nil || !b.cold.IsObjNotFoundErr(err) {
+			return res, err
+		}
+		return read(b.hotBucket)
+	}
+
+	res, err := read(b.hotBucket)
+	if err == nil || !b.hot.IsObjNotFoundErr(err) || !b.isColdBlock(ctx, name, ColdBlocksRefreshInterval) {
+		return res, err
+	}
+	return read(b.coldBucket)
+}
+
+// Close implements io.Closer
+func (b *TieredBucketClient) Close() error {
+	return tsdb_errors.NewMulti(b.hot.Close(), b.cold.Close()).Err()
+}
+
+// Upload the contents of the reader as an object into the hot bucket.
+func (b *TieredBucketClient) Upload(ctx context.Context, name string, r io.Reader, opts ...objstore.ObjectUploadOption) error {
+	return b.hotBucket.Upload(ctx, name, r, opts...)
+}
+
+// Delete removes the object with the given name from both buckets.
+func (b *TieredBucketClient) Delete(ctx context.Context, name string) error {
+	hotErr := b.hotLookupBucket.Delete(ctx, name)
+	if hotErr != nil && !b.hot.IsObjNotFoundErr(hotErr) {
+		return hotErr
+	}
+
+	coldErr := b.coldLookupBucket.Delete(ctx, name)
+	if coldErr != nil && !b.cold.IsObjNotFoundErr(coldErr) {
+		return coldErr
+	}
+
+	// The object is not found only if it's in none of the buckets.
+	if hotErr != nil && coldErr != nil {
+		return hotErr
+	}
+	return nil
+}
+
+// Name returns the name of the hot bucket.
+func (b *TieredBucketClient) Name() string { return b.hot.Name() }
+
+// Iter calls f for each entry in the given directory of both buckets.
+func (b *TieredBucketClient) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	seen := map[string]struct{}{}
+	if err := b.hotBucket.Iter(ctx, dir, func(name string) error {
+		seen[name] = struct{}{}
+		return f(name)
+	}, options...); err != nil {
+		return err
+	}
+
+	return b.coldBucket.Iter(ctx, dir, func(name string) error {
+		if _, ok := seen[name]; ok {
+			return nil
+		}
+		return f(name)
+	}, options...)
+}
+
+// IterWithAttributes calls f for each entry in the given directory of both buckets.
+func (b *TieredBucketClient) IterWithAttributes(ctx context.Context, dir string, f func(attrs objstore.IterObjectAttributes) error, options ...objstore.IterOption) error {
+	seen := map[string]struct{}{}
+	if err := b.hotBucket.IterWithAttributes(ctx, dir, func(attrs objstore.IterObjectAttributes) error {
+		seen[attrs.Name] = struct{}{}
+		return f(attrs)
+	}, options...); err != nil {
+		return err
+	}
+
+	return b.coldBucket.IterWithAttributes(ctx, dir, func(attrs objstore.IterObjectAttributes) error {
+		if _, ok := seen[attrs.Name]; ok {
+			return nil
+		}
+		return f(attrs)
+	}, options...)
+}
+
+// SupportedIterOptions returns the iter options supported by both buckets.
+func (b *TieredBucketClient) SupportedIterOptions() []objstore.IterOptionType {
+	coldOptions := b.cold.SupportedIterOptions()
+
+	var options []objstore.IterOptionType
+	for _, opt := range b.hot.SupportedIterOptions() {
+		if slices.Contains(coldOptions, opt) {
+			options = append(options, opt)
+		}
+	}
+	return options
+}
+
+// Get returns a reader for the given object name.
+func (b *TieredBucketClient) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return tieredRead(ctx, b, name, func(r objstore.BucketReader) (io.ReadCloser, error) {
+		return r.Get(ctx, name)
+	})
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *TieredBucketClient) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return tieredRead(ctx, b, name, func(r objstore.BucketReader) (io.ReadCloser, error) {
+		return r.GetRange(ctx, name, off, length)
+	})
+}
+
+// Exists checks if the given object exists in the hot bucket, or in the cold one if its
+// block has been moved there.
+func (b *TieredBucketClient) Exists(ctx context.Context, name string) (bool, error) {
+	if b.isColdBlock(ctx, name, 0) {
+		exists, err := b.coldBucket.Exists(ctx, name)
+		if err != nil || exists {
+			return exists, err
+		}
+		return b.hotBucket.Exists(ctx, name)
+	}
+
+	exists, err := b.hotBucket.Exists(ctx, name)
+	if err != nil || exists || !b.isColdBlock(ctx, name, ColdBlocksRefreshInterval) {
+		return exists, err
+	}
+	return b.coldBucket.Exists(ctx, name)
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *TieredBucketClient) IsObjNotFoundErr(err error) bool {
+	return b.hot.IsObjNotFoundErr(err) || b.cold.IsObjNotFoundErr(err)
+}
+
+// IsAccessDeniedErr returns true if access to object is denied.
+func (b *TieredBucketClient) IsAccessDeniedErr(err error) bool {
+	return b.hot.IsAccessDeniedErr(err) || b.cold.IsAccessDeniedErr(err)
+}
+
+// Attributes returns attributes of the specified object.
+func (b *TieredBucketClient) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	return tieredRead(ctx, b, name, func(r objstore.BucketReader) (objstore.ObjectAttributes, error) {
+		return r.Attributes(ctx, name)
+	})
+}
+
+// ReaderWithExpectedErrs allows to specify a filter that marks certain errors as expected, so it will not increment
+// thanos_objstore_bucket_operation_failures_total metric.
+func (b *TieredBucketClient) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs allows to specify a filter that marks certain errors as expected, so it will not increment
+// thanos_objstore_bucket_operation_failures_total metric.
+func (b *TieredBucketClient) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	return newTieredBucketClient(b.hot, b.cold, fn, b.coldBlocks)
+}
+
+func (b *TieredBucketClient) Provider() objstore.ObjProvider {
+	return b.hot.Provider()
+}
+
+// ExistsInTiers returns whether the given object exists in the hot bucket and in the cold one. If
+// it exists in the cold bucket, its block is known to have been moved there from now on.
+func (b *TieredBucketClient) ExistsInTiers(ctx context.Context, name string) (bool, bool, error) {
+	inHot, err := b.hotBucket.Exists(ctx, name)
+	if err != nil {
+		return false, false, err
+	}
+	inCold, err := b.coldBucket.Exists(ctx, name)
+	if err != nil {
+		return false, false, err
+	}
+	if inCold {
+		b.markColdBlock(name)
+	}
+	return inHot, inCold, nil
+}
+
+// CopyToCold copies all the objects in the given directory from the hot bucket to the cold one,
+// without deleting them from the hot bucket. The other components only read the block from the
+// cold bucket once it's recorded as cold in the bucket index, so the hot copies can only be
+// deleted via DeleteHotCopies once the bucket index has been propagated. Copying a directory
+// which has already been copied is a no-op.
+func (b *TieredBucketClient) CopyToCold(ctx context.Context, dir string) error {
+	dir = strings.TrimSuffix(dir, objstore.DirDelim) + objstore.DirDelim
+
+	var names []string
+	if err := b.hotBucket.Iter(ctx, dir, func(name string) error {
+		names = append(names, name)
+		return nil
+	}, objstore.WithRecursiveIter()); err != nil {
+		return errors.Wrap(err, "list objects to copy to the cold storage")
+	}
+
+	for _, name := range names {
+		if err := b.copyToCold(ctx, name); err != nil {
+			return errors.Wrapf(err, "copy %s to the cold storage", name)
+		}
+		b.markColdBlock(name)
+	}
+
+	return nil
+}
+
+// DeleteHotCopies deletes from the hot bucket the objects in the given directory which have
+// been copied to the cold bucket. The objects uploaded to the hot bucket after the copy, like
+// the markers, are kept.
+func (b *TieredBucketClient) DeleteHotCopies(ctx context.Context, dir string) error {
+	dir = strings.TrimSuffix(dir, objstore.DirDelim) + objstore.DirDelim
+
+	var names []string
+	if err := b.hotBucket.Iter(ctx, dir, func(name string) error {
+		names = append(names, name)
+		return nil
+	}, objstore.WithRecursiveIter()); err != nil {
+		return errors.Wrap(err, "list objects to delete from the hot storage")
+	}
+
+	for _, name := range names {
+		inCold, err := b.coldBucket.Exists(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "check %s in the cold storage", name)
+		}
+		if !inCold {
+			continue
+		}
+		if err := b.hotBucket.Delete(ctx, name); err != nil && !b.hot.IsObjNotFoundErr(err) {
+			return errors.Wrapf(err, "delete %s from the hot storage", name)
+		}
+	}
+
+	return nil
+}
+
+func (b *TieredBucketClient) copyToCold(ctx context.Context, name string) error {
+	r, err := b.hotBucket.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return b.coldBucket.Upload(ctx, name, r)
+}
@@ -0,0 +1,193 @@
+package bucket
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket/filesystem"
+)
+
+func TestColdStorageConfig_Validate(t *testing.T) {
+	cfg := ColdStorageConfig{}
+	assert.False(t, cfg.Enabled())
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Backend = Filesystem
+	cfg.Filesystem.Directory = t.TempDir()
+	cfg.MinBlockAge = 24 * time.Hour
+	assert.True(t, cfg.Enabled())
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, Filesystem, cfg.BucketConfig().Backend)
+
+	cfg.MinBlockAge = 0
+	assert.Equal(t, errInvalidColdStorageMinBlockAge, cfg.Validate())
+}
+
+func prepareTieredBucketClient(t *testing.T) (*TieredBucketClient, string, string) {
+	hotDir, coldDir := t.TempDir(), t.TempDir()
+
+	hot, err := filesystem.NewBucketClient(filesystem.Config{Directory: hotDir})
+	require.NoError(t, err)
+	cold, err := filesystem.NewBucketClient(filesystem.Config{Directory: coldDir})
+	require.NoError(t, err)
+
+	return NewTieredBucketClient(objstore.WithNoopInstr(hot), objstore.WithNoopInstr(cold)), hotDir, coldDir
+}
+
+func readObject(t *testing.T, bkt objstore.BucketReader, name string) string {
+	r, err := bkt.Get(context.Background(), name)
+	require.NoError(t, err)
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func uploadBucketIndex(t *testing.T, bkt objstore.Bucket, userID, content string) {
+	buf := bytes.Buffer{}
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.NoError(t, bkt.Upload(context.Background(), userID+"/"+coldBlocksIndexFilename, &buf))
+}
+
+func TestTieredBucketClient_ShouldReloadColdBlocksWhenObjectNotFound(t *testing.T) {
+	const blockID = "01EQK4QKFHVSZYVJ908Y7HH9E0"
+
+	ctx := context.Background()
+	bkt, _, _ := prepareTieredBucketClient(t)
+	require.NoError(t, bkt.cold.Upload(ctx, "user-1/"+blockID+"/meta.json", strings.NewReader("meta")))
+
+	// The block is not cold in the bucket index yet.
+	uploadBucketIndex(t, bkt, "user-1", `{"blocks":[{"block_id":"`+blockID+`"}]}`)
+	_, err := bkt.Get(ctx, "user-1/"+blockID+"/meta.json")
+	assert.True(t, bkt.IsObjNotFoundErr(err))
+
+	// The cold blocks are reloaded once the refresh interval has elapsed.
+	uploadBucketIndex(t, bkt, "user-1", `{"blocks":[{"block_id":"`+blockID+`","storage_tier":"cold"}]}`)
+	_, err = bkt.Get(ctx, "user-1/"+blockID+"/meta.json")
+	assert.True(t, bkt.IsObjNotFoundErr(err))
+
+	tenant := bkt.coldBlocks.tenant("user-1")
+	tenant.mtx.Lock()
+	tenant.loadedAt = time.Now().Add(-2 * ColdBlocksRefreshInterval)
+	tenant.mtx.Unlock()
+
+	assert.Equal(t, "meta", readObject(t, bkt, "user-1/"+blockID+"/meta.json"))
+}
+
+func TestTieredBucketClient_CopyToColdAndDeleteHotCopies(t *testing.T) {
+	const (
+		blockID = "01EQK4QKFHVSZYVJ908Y7HH9E0"
+		other   = "01EQK4QKFHVSZYVJ908Y7HH9E1"
+	)
+
+	ctx := context.Background()
+	bkt, hotDir, coldDir := prepareTieredBucketClient(t)
+
+	for _, name := range []string{"user-1/" + blockID + "/meta.json", "user-1/" + blockID + "/chunks/000001", "user-1/" + other + "/meta.json"} {
+		require.NoError(t, bkt.Upload(ctx, name, strings.NewReader(name)))
+	}
+
+	require.NoError(t, bkt.CopyToCold(ctx, "user-1/"+blockID))
+
+	// The objects have been copied to the cold bucket, and are still in the hot one.
+	assert.FileExists(t, filepath.Join(hotDir, "user-1", blockID, "meta.json"))
+	assert.FileExists(t, filepath.Join(coldDir, "user-1", blockID, "meta.json"))
+	assert.FileExists(t, filepath.Join(coldDir, "user-1", blockID, "chunks", "000001"))
+	assert.NoDirExists(t, filepath.Join(coldDir, "user-1", other))
+
+	// The objects uploaded after the copy are not deleted from the hot bucket.
+	require.NoError(t, bkt.Upload(ctx, "user-1/"+blockID+"/no-compact-mark.json", strings.NewReader("mark")))
+	require.NoError(t, bkt.DeleteHotCopies(ctx, "user-1/"+blockID))
+	assert.NoFileExists(t, filepath.Join(hotDir, "user-1", blockID, "meta.json"))
+	assert.NoFileExists(t, filepath.Join(hotDir, "user-1", blockID, "chunks", "000001"))
+	assert.FileExists(t, filepath.Join(hotDir, "user-1", blockID, "no-compact-mark.json"))
+	assert.FileExists(t, filepath.Join(hotDir, "user-1", other, "meta.json"))
+	require.NoError(t, bkt.Delete(ctx, "user-1/"+blockID+"/no-compact-mark.json"))
+
+	// Copying the block again is a no-op.
+	require.NoError(t, bkt.CopyToCold(ctx, "user-1/"+blockID))
+	assert.FileExists(t, filepath.Join(coldDir, "user-1", blockID, "meta.json"))
+
+	// The storage tier of an object can be found from the buckets.
+	inHot, inCold, err := NewTieredBucketClient(bkt.hot, bkt.cold).ExistsInTiers(ctx, "user-1/"+blockID+"/meta.json")
+	require.NoError(t, err)
+	assert.False(t, inHot)
+	assert.True(t, inCold)
+
+	// A new client only looks up the cold bucket for the blocks whose storage tier is
+	// cold in the bucket index.
+	notSeeded := NewTieredBucketClient(bkt.hot, bkt.cold)
+	_, err = notSeeded.Get(ctx, "user-1/"+blockID+"/meta.json")
+	assert.True(t, notSeeded.IsObjNotFoundErr(err))
+	ok, err := notSeeded.Exists(ctx, "user-1/"+blockID+"/meta.json")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	uploadBucketIndex(t, bkt, "user-1", `{"blocks":[{"block_id":"`+blockID+`","storage_tier":"cold"},{"block_id":"`+other+`"}]}`)
+
+	// The objects are read from the right bucket transparently, even from a new client
+	// which has seeded the cold blocks from the bucket index.
+	fresh := NewTieredBucketClient(bkt.hot, bkt.cold)
+
+	for _, c := range []*TieredBucketClient{bkt, fresh} {
+		assert.Equal(t, "user-1/"+blockID+"/chunks/000001", readObject(t, c, "user-1/"+blockID+"/chunks/000001"))
+		assert.Equal(t, "user-1/"+other+"/meta.json", readObject(t, c, "user-1/"+other+"/meta.json"))
+
+		ok, err := c.Exists(ctx, "user-1/"+blockID+"/meta.json")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		_, err = c.Attributes(ctx, "user-1/"+blockID+"/meta.json")
+		require.NoError(t, err)
+
+		_, err = c.Get(ctx, "user-1/"+blockID+"/missing")
+		assert.True(t, c.IsObjNotFoundErr(err))
+	}
+
+	// Markers uploaded after the move are stored in the hot bucket, and are still readable.
+	require.NoError(t, bkt.Upload(ctx, "user-1/"+blockID+"/deletion-mark.json", strings.NewReader("mark")))
+	assert.FileExists(t, filepath.Join(hotDir, "user-1", blockID, "deletion-mark.json"))
+	assert.Equal(t, "mark", readObject(t, bkt, "user-1/"+blockID+"/deletion-mark.json"))
+
+	// Listing returns the objects of both buckets, without duplicates.
+	var names []string
+	require.NoError(t, bkt.Iter(ctx, "user-1/"+blockID, func(name string) error {
+		names = append(names, name)
+		return nil
+	}, objstore.WithRecursiveIter()))
+	assert.ElementsMatch(t, []string{
+		"user-1/" + blockID + "/chunks/000001",
+		"user-1/" + blockID + "/deletion-mark.json",
+		"user-1/" + blockID + "/meta.json",
+	}, names)
+
+	names = nil
+	require.NoError(t, bkt.Iter(ctx, "user-1/", func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{"user-1/" + blockID + "/", "user-1/" + other + "/", "user-1/" + coldBlocksIndexFilename}, names)
+
+	// Deleting an object removes it from both buckets.
+	for _, name := range []string{"user-1/" + blockID + "/meta.json", "user-1/" + blockID + "/deletion-mark.json"} {
+		require.NoError(t, bkt.Delete(ctx, name))
+		assert.NoFileExists(t, filepath.Join(hotDir, name))
+		assert.NoFileExists(t, filepath.Join(coldDir, name))
+	}
+	assert.FileExists(t, filepath.Join(coldDir, "user-1", blockID, "chunks", "000001"))
+}
@@ -11,5 +11,8 @@ func NewUserBucketClient(userID string, bucket objstore.Bucket, cfgProvider Tena
 	bucket = NewPrefixedBucketClient(bucket, userID)
 
 	// Inject the SSE config.
-	return NewSSEBucketClient(userID, bucket, cfgProvider)
+	bucket = NewSSEBucketClient(userID, bucket, cfgProvider)
+
+	// Attribute the operations to the tenant.
+	return NewTenantBucketClient(userID, bucket)
 }
@@ -0,0 +1,77 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+
+	"github.com/efficientgo/core/errors"
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// CompactionMetaFileName is the name of the file describing a parquet-only block, compacted
+	// from the parquet files of other blocks. Such a block has no TSDB index, chunks and meta.json,
+	// so it's ignored by the components reading TSDB blocks. The file is uploaded last, once all
+	// the parquet files and the converter mark of the block have been uploaded.
+	CompactionMetaFileName = "parquet-compaction-meta.json"
+
+	CompactionMetaVersion1 = 1
+)
+
+var ErrCompactionMetaNotFound = errors.New("parquet compaction meta not found")
+
+// CompactionMeta describes a parquet-only block.
+type CompactionMeta struct {
+	Version int       `json:"version"`
+	ULID    ulid.ULID `json:"ulid"`
+
+	// MinTime and MaxTime specify the time range all samples in the block are in (millis precision).
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+
+	// Sources are the blocks the parquet files have been compacted from.
+	Sources []ulid.ULID `json:"sources"`
+}
+
+// ReadCompactionMeta reads the compaction meta of a parquet-only block, returning ErrCompactionMetaNotFound
+// if the block is not a parquet-only block.
+func ReadCompactionMeta(ctx context.Context, id ulid.ULID, userBkt objstore.InstrumentedBucket, logger log.Logger) (*CompactionMeta, error) {
+	metaPath := path.Join(id.String(), CompactionMetaFileName)
+	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, metaPath)
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, ErrCompactionMetaNotFound
+		}
+		return nil, errors.Wrapf(err, "get file: %s", metaPath)
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close parquet compaction meta file reader")
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file: %s", metaPath)
+	}
+
+	meta := CompactionMeta{}
+	if err := json.Unmarshal(content, &meta); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal file: %s", metaPath)
+	}
+	if meta.Version != CompactionMetaVersion1 {
+		return nil, errors.Newf("unexpected parquet compaction meta version: %s version: %d", metaPath, meta.Version)
+	}
+	return &meta, nil
+}
+
+func WriteCompactionMeta(ctx context.Context, meta CompactionMeta, userBkt objstore.Bucket) error {
+	meta.Version = CompactionMetaVersion1
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return userBkt.Upload(ctx, path.Join(meta.ULID.String(), CompactionMetaFileName), bytes.NewReader(b))
+}
@@ -0,0 +1,29 @@
+package tsdb
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+// NewBucketClient creates the bucket client of the blocks storage. If the cold storage is
+// enabled, the blocks moved to the cold storage are read from it transparently.
+func NewBucketClient(ctx context.Context, cfg BlocksStorageConfig, hedgedRoundTripper func(rt http.RoundTripper) http.RoundTripper, name string, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
+	hot, err := bucket.NewClient(ctx, cfg.Bucket, hedgedRoundTripper, name, logger, reg)
+	if err != nil || !cfg.ColdStorage.Enabled() {
+		return hot, err
+	}
+
+	cold, err := bucket.NewClient(ctx, cfg.ColdStorage.BucketConfig(), hedgedRoundTripper, name+"-cold-storage", logger, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create cold storage bucket client")
+	}
+
+	return bucket.NewTieredBucketClient(hot, cold), nil
+}
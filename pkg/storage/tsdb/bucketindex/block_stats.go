@@ -0,0 +1,213 @@
+package bucketindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"path"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/util/runutil"
+)
+
+const (
+	// BlockStatsFilename is the name of the file holding the label statistics of a block,
+	// uploaded by the compactor next to the block it has compacted.
+	BlockStatsFilename = "block-stats.json"
+
+	BlockStatsVersion1 = 1
+
+	// The metric names bloom filter is sized for this false positive rate, unless
+	// it would be larger than the max size, in which case the false positive rate is higher.
+	// The bloom filter is stored in the bucket index for each block and doesn't compress, so
+	// the max size is kept small. The bloom filter is omitted if its false positive rate would
+	// be higher than the max one, because it would rarely skip any block.
+	metricNamesBloomFalsePositiveRate    = 0.01
+	metricNamesBloomMaxFalsePositiveRate = 0.5
+	metricNamesBloomMaxBytes             = 2 * 1024
+)
+
+var (
+	ErrBlockStatsNotFound  = errors.New("block stats not found")
+	ErrBlockStatsCorrupted = errors.New("block stats corrupted")
+)
+
+// BlockStats holds the label statistics of a block, used to skip the blocks which can't
+// match the label matchers of a query.
+type BlockStats struct {
+	// MetricNamesBloom is a bloom filter of the metric names of the series in the block, hashed
+	// MetricNamesBloomHashes times. The bloom filter is empty if the block has no series or too
+	// many metric names for the bloom filter to be useful.
+	MetricNamesBloom       []byte `json:"metric_names_bloom,omitempty"`
+	MetricNamesBloomHashes int    `json:"metric_names_bloom_hashes,omitempty"`
+
+	// Labels holds the range of values of the labels the stats have been computed for. A label
+	// with an empty range isn't set by any series in the block.
+	Labels map[string]LabelValuesRange `json:"labels,omitempty"`
+}
+
+// LabelValuesRange is the lexicographical range of values of a label, both ends inclusive.
+type LabelValuesRange struct {
+	Min string `json:"min"`
+	Max string `json:"max"`
+}
+
+type blockStatsFile struct {
+	Version int `json:"version"`
+	BlockStats
+}
+
+// LabelValuesReader is the subset of the TSDB index reader the block stats are computed from.
+type LabelValuesReader interface {
+	SortedLabelValues(ctx context.Context, name string, hints *storage.LabelHints, matchers ...*labels.Matcher) ([]string, error)
+}
+
+// ComputeBlockStats computes the stats of a block from its index, including the range of values
+// of the input label names.
+func ComputeBlockStats(ctx context.Context, r LabelValuesReader, labelNames []string) (*BlockStats, error) {
+	names, err := r.SortedLabelValues(ctx, labels.MetricName, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "read metric names")
+	}
+
+	stats := &BlockStats{}
+	if len(names) > 0 {
+		stats.MetricNamesBloom, stats.MetricNamesBloomHashes = newMetricNamesBloom(names)
+	}
+
+	for _, name := range labelNames {
+		if name == labels.MetricName {
+			continue
+		}
+		values, err := r.SortedLabelValues(ctx, name, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read values of label %s", name)
+		}
+		if stats.Labels == nil {
+			stats.Labels = map[string]LabelValuesRange{}
+		}
+		if len(values) == 0 {
+			stats.Labels[name] = LabelValuesRange{}
+			continue
+		}
+		stats.Labels[name] = LabelValuesRange{Min: values[0], Max: values[len(values)-1]}
+	}
+	return stats, nil
+}
+
+// MayMatch returns false if the stats guarantee no series in the block match the input matchers.
+// Only the equality matchers with a non-empty value are checked.
+func (s *BlockStats) MayMatch(matchers []*labels.Matcher) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, m := range matchers {
+		if m.Type != labels.MatchEqual || m.Value == "" {
+			continue
+		}
+
+		if m.Name == labels.MetricName {
+			if s.MetricNamesBloomHashes > 0 && !bloomContains(s.MetricNamesBloom, s.MetricNamesBloomHashes, m.Value) {
+				return false
+			}
+			continue
+		}
+
+		r, ok := s.Labels[m.Name]
+		if !ok {
+			continue
+		}
+		if r.Min == "" || m.Value < r.Min || m.Value > r.Max {
+			return false
+		}
+	}
+	return true
+}
+
+func newMetricNamesBloom(names []string) ([]byte, int) {
+	n := float64(len(names))
+	bits := math.Ceil(-n * math.Log(metricNamesBloomFalsePositiveRate) / (math.Ln2 * math.Ln2))
+	bits = min(max(bits, 64), metricNamesBloomMaxBytes*8)
+	hashes := int(max(math.Round(bits/n*math.Ln2), 1))
+	if math.Pow(1-math.Exp(-float64(hashes)*n/bits), float64(hashes)) > metricNamesBloomMaxFalsePositiveRate {
+		return nil, 0
+	}
+
+	bloom := make([]byte, int(math.Ceil(bits/8)))
+	for _, name := range names {
+		h1, h2 := bloomHashes(name)
+		for i := range hashes {
+			bit := (h1 + uint64(i)*h2) % uint64(len(bloom)*8)
+			bloom[bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return bloom, hashes
+}
+
+func bloomContains(bloom []byte, hashes int, value string) bool {
+	if len(bloom) == 0 {
+		return false
+	}
+
+	h1, h2 := bloomHashes(value)
+	for i := range hashes {
+		bit := (h1 + uint64(i)*h2) % uint64(len(bloom)*8)
+		if bloom[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes returns the two hashes the bloom filter positions are derived from, using double hashing.
+func bloomHashes(value string) (uint64, uint64) {
+	h := xxhash.Sum64String(value)
+	return h & math.MaxUint32, h >> 32
+}
+
+// ReadBlockStats reads the stats of a block, returning ErrBlockStatsNotFound if the block has no stats.
+func ReadBlockStats(ctx context.Context, bkt objstore.InstrumentedBucket, id ulid.ULID, logger log.Logger) (*BlockStats, error) {
+	statsFile := path.Join(id.String(), BlockStatsFilename)
+
+	r, err := bkt.WithExpectedErrs(bkt.IsObjNotFoundErr).Get(ctx, statsFile)
+	if bkt.IsObjNotFoundErr(err) {
+		return nil, ErrBlockStatsNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get block stats file: %v", statsFile)
+	}
+	defer runutil.CloseWithLogOnErr(logger, r, "close get block stats file")
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read block stats file: %v", statsFile)
+	}
+
+	f := blockStatsFile{}
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, errors.Wrapf(ErrBlockStatsCorrupted, "unmarshal block stats file %s: %v", statsFile, err)
+	}
+	if f.Version != BlockStatsVersion1 {
+		return nil, errors.Errorf("unexpected block stats version: %s version: %d", statsFile, f.Version)
+	}
+	return &f.BlockStats, nil
+}
+
+// WriteBlockStats uploads the stats of a block to the storage.
+func WriteBlockStats(ctx context.Context, bkt objstore.Bucket, id ulid.ULID, stats *BlockStats) error {
+	content, err := json.Marshal(blockStatsFile{Version: BlockStatsVersion1, BlockStats: *stats})
+	if err != nil {
+		return errors.Wrap(err, "marshal block stats")
+	}
+	return bkt.Upload(ctx, path.Join(id.String(), BlockStatsFilename), bytes.NewReader(content))
+}
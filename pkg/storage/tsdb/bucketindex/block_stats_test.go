@@ -0,0 +1,185 @@
+package bucketindex
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid/v2"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/util/testutil"
+)
+
+type mockLabelValuesReader map[string][]string
+
+func (r mockLabelValuesReader) SortedLabelValues(_ context.Context, name string, _ *storage.LabelHints, _ ...*labels.Matcher) ([]string, error) {
+	return r[name], nil
+}
+
+func TestComputeBlockStats(t *testing.T) {
+	ctx := context.Background()
+	r := mockLabelValuesReader{
+		labels.MetricName: {"http_requests_total", "up"},
+		"cluster":         {"eu-1", "us-1", "us-2"},
+	}
+
+	stats, err := ComputeBlockStats(ctx, r, []string{"cluster", "namespace", labels.MetricName})
+	require.NoError(t, err)
+	assert.NotEmpty(t, stats.MetricNamesBloom)
+	assert.Positive(t, stats.MetricNamesBloomHashes)
+	assert.Equal(t, map[string]LabelValuesRange{
+		"cluster":   {Min: "eu-1", Max: "us-2"},
+		"namespace": {},
+	}, stats.Labels)
+
+	// A block without series has no bloom filter.
+	stats, err = ComputeBlockStats(ctx, mockLabelValuesReader{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &BlockStats{}, stats)
+}
+
+func TestBlockStats_MayMatch(t *testing.T) {
+	stats, err := ComputeBlockStats(context.Background(), mockLabelValuesReader{
+		labels.MetricName: {"http_requests_total", "up"},
+		"cluster":         {"eu-1", "us-1", "us-2"},
+	}, []string{"cluster", "namespace"})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		stats    *BlockStats
+		matchers []*labels.Matcher
+		expected bool
+	}{
+		"no stats": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "unknown")},
+			expected: true,
+		},
+		"no matchers": {
+			stats:    stats,
+			expected: true,
+		},
+		"metric name in the block": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up")},
+			expected: true,
+		},
+		"metric name not in the block": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "unknown")},
+			expected: false,
+		},
+		"metric name regexp matcher is not checked": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "unknown")},
+			expected: true,
+		},
+		"label value within the range": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "cluster", "us-0")},
+			expected: true,
+		},
+		"label value lower than the range": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "cluster", "ap-1")},
+			expected: false,
+		},
+		"label value higher than the range": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "cluster", "us-3")},
+			expected: false,
+		},
+		"label not set by any series": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "namespace", "default")},
+			expected: false,
+		},
+		"label empty value matcher is not checked": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "namespace", "")},
+			expected: true,
+		},
+		"label without stats": {
+			stats:    stats,
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "api")},
+			expected: true,
+		},
+		"any matcher can't match": {
+			stats: stats,
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"),
+				labels.MustNewMatcher(labels.MatchEqual, "cluster", "ap-1"),
+			},
+			expected: false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.stats.MayMatch(testData.matchers))
+		})
+	}
+}
+
+func TestBlockStats_MetricNamesBloomHasNoFalseNegatives(t *testing.T) {
+	// Enough metric names for the bloom filter to be capped to the max size.
+	names := make([]string, 5000)
+	for i := range names {
+		names[i] = fmt.Sprintf("metric_%06d", i)
+	}
+
+	stats, err := ComputeBlockStats(context.Background(), mockLabelValuesReader{labels.MetricName: names}, nil)
+	require.NoError(t, err)
+	assert.Len(t, stats.MetricNamesBloom, metricNamesBloomMaxBytes)
+
+	for _, name := range names {
+		require.True(t, stats.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, name)}), name)
+	}
+}
+
+func TestBlockStats_MetricNamesBloomOmittedWithTooManyMetricNames(t *testing.T) {
+	names := make([]string, 50000)
+	for i := range names {
+		names[i] = fmt.Sprintf("metric_%06d", i)
+	}
+
+	stats, err := ComputeBlockStats(context.Background(), mockLabelValuesReader{labels.MetricName: names}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, stats.MetricNamesBloom)
+	assert.Zero(t, stats.MetricNamesBloomHashes)
+	assert.True(t, stats.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "unknown")}))
+}
+
+func TestReadWriteBlockStats(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	id := ulid.MustNew(1, nil)
+
+	_, err := ReadBlockStats(ctx, userBkt, id, log.NewNopLogger())
+	require.ErrorIs(t, err, ErrBlockStatsNotFound)
+
+	stats := &BlockStats{
+		MetricNamesBloom:       []byte{1, 2, 3},
+		MetricNamesBloomHashes: 2,
+		Labels:                 map[string]LabelValuesRange{"cluster": {Min: "a", Max: "b"}},
+	}
+	require.NoError(t, WriteBlockStats(ctx, userBkt, id, stats))
+
+	actual, err := ReadBlockStats(ctx, userBkt, id, log.NewNopLogger())
+	require.NoError(t, err)
+	assert.Equal(t, stats, actual)
+
+	require.NoError(t, userBkt.Upload(ctx, path.Join(id.String(), BlockStatsFilename), strings.NewReader("invalid")))
+	_, err = ReadBlockStats(ctx, userBkt, id, log.NewNopLogger())
+	require.ErrorIs(t, err, ErrBlockStatsCorrupted)
+}
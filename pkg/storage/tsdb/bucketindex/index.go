@@ -27,6 +27,9 @@ const (
 	// SegmentsFormat1Based6Digits defined segments numbered with 6 digits numbers in a sequence starting from number 1
 	// eg. (000001, 000002, 000003).
 	SegmentsFormat1Based6Digits = "1b6d"
+
+	// StorageTierCold is the storage tier of the blocks moved to the cold storage.
+	StorageTierCold = "cold"
 )
 
 // Index contains all known blocks and markers of a tenant.
@@ -85,7 +88,7 @@ func (idx *Index) ParquetBlocks() []*Block {
 func (idx *Index) NonParquetBlocks() []*Block {
 	blocks := make([]*Block, 0, len(idx.Blocks))
 	for _, b := range idx.Blocks {
-		if b.Parquet != nil {
+		if b.Parquet != nil || b.ParquetOnly {
 			continue
 		}
 		blocks = append(blocks, b)
@@ -93,6 +96,17 @@ func (idx *Index) NonParquetBlocks() []*Block {
 	return blocks
 }
 
+// ParquetOnlyBlocks returns all blocks that are only available in Parquet format.
+func (idx *Index) ParquetOnlyBlocks() []*Block {
+	blocks := make([]*Block, 0, len(idx.Blocks))
+	for _, b := range idx.Blocks {
+		if b.ParquetOnly {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
 // Block holds the information about a block in the index.
 type Block struct {
 	// Block ID.
@@ -119,6 +133,34 @@ type Block struct {
 
 	// Parquet metadata if exists. If doesn't exist it will be nil.
 	Parquet *parquet.ConverterMarkMeta `json:"parquet,omitempty"`
+
+	// ParquetOnly is true if the block has been compacted from the parquet files of other
+	// blocks and is only available in Parquet format, without the TSDB index and chunks.
+	ParquetOnly bool `json:"parquet_only,omitempty"`
+
+	// ParquetSources are the IDs of the blocks compacted into a parquet-only block. They're
+	// superseded by the parquet-only block, but may still exist if not deleted once compacted.
+	ParquetSources []ulid.ULID `json:"parquet_sources,omitempty"`
+
+	// Label statistics computed by the compactor, if exist. If don't exist it will be nil.
+	Stats *BlockStats `json:"stats,omitempty"`
+
+	// StorageTier is the storage tier the block has been moved to. Empty if the block
+	// is in the default (hot) storage.
+	StorageTier string `json:"storage_tier,omitempty"`
+
+	// HotCopiesDeleted is true once the objects of a block moved to the cold storage have been
+	// deleted from the hot storage. They're kept until the storage tier of the block has been
+	// recorded in the bucket index for long enough to be known by all the components.
+	HotCopiesDeleted bool `json:"hot_copies_deleted,omitempty"`
+
+	// RetentionRulesChecked is the hash of the per-tenant retention rules the block has last been
+	// checked against by the compactor. Empty if the block has never been checked.
+	RetentionRulesChecked string `json:"retention_rules_checked,omitempty"`
+
+	// Quarantined is true if the block has been found corrupted by the compactor's block
+	// scrubber. Quarantined blocks are not queried.
+	Quarantined bool `json:"quarantined,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
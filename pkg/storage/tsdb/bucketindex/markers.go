@@ -1,8 +1,11 @@
 package bucketindex
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"path"
 	"path/filepath"
 	"strings"
@@ -20,16 +23,43 @@ import (
 
 const (
 	MarkersPathname = "markers"
+
+	// QuarantineMarkFilename is the filename of the marker of a block found corrupted by the
+	// compactor's block scrubber. Quarantined blocks are neither queried nor compacted.
+	QuarantineMarkFilename = "quarantine-mark.json"
+	// QuarantineMarkVersion1 is the current version of the quarantine mark.
+	QuarantineMarkVersion1 = 1
 )
 
 var (
 	MarkersMap = map[string]func(ulid.ULID) string{
 		metadata.DeletionMarkFilename:   BlockDeletionMarkFilepath,
 		metadata.NoCompactMarkFilename:  NoCompactMarkFilenameMarkFilepath,
+		QuarantineMarkFilename:          QuarantineMarkFilepath,
 		parquet.ConverterMarkerFileName: ConverterMarkFilePath,
 	}
+
+	ErrQuarantineMarkNotFound = errors.New("block quarantine mark not found")
 )
 
+// QuarantineMark holds the information about a block found corrupted by the compactor's block scrubber.
+type QuarantineMark struct {
+	// ID of the tsdb block.
+	ID ulid.ULID `json:"id"`
+	// Version of the file.
+	Version int `json:"version"`
+	// Details is a human readable string giving details of the corruption.
+	Details string `json:"details,omitempty"`
+
+	// QuarantineTime is a unix timestamp of when the block was quarantined.
+	QuarantineTime int64 `json:"quarantine_time"`
+
+	// MinTime and MaxTime are the boundaries of the time range affected by the corruption,
+	// in milliseconds.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+}
+
 // BlockDeletionMarkFilepath returns the path, relative to the tenant's bucket location,
 // of a block deletion mark in the bucket markers location.
 func BlockDeletionMarkFilepath(blockID ulid.ULID) string {
@@ -42,6 +72,12 @@ func NoCompactMarkFilenameMarkFilepath(blockID ulid.ULID) string {
 	return fmt.Sprintf("%s/%s-%s", MarkersPathname, blockID.String(), metadata.NoCompactMarkFilename)
 }
 
+// QuarantineMarkFilepath returns the path, relative to the tenant's bucket location,
+// of a block quarantine mark in the bucket markers location.
+func QuarantineMarkFilepath(blockID ulid.ULID) string {
+	return fmt.Sprintf("%s/%s-%s", MarkersPathname, blockID.String(), QuarantineMarkFilename)
+}
+
 func ConverterMarkFilePath(blockID ulid.ULID) string {
 	return fmt.Sprintf("%s/%s-%s", parquet.ConverterMarkerPrefix, blockID.String(), parquet.ConverterMarkerFileName)
 }
@@ -82,6 +118,24 @@ func IsBlockNoCompactMarkFilename(name string) (ulid.ULID, bool) {
 	return id, err == nil
 }
 
+// IsBlockQuarantineMarkFilename returns whether the input filename matches the expected pattern
+// of block quarantine markers stored in the markers location.
+func IsBlockQuarantineMarkFilename(name string) (ulid.ULID, bool) {
+	parts := strings.SplitN(name, "-", 2)
+	if len(parts) != 2 {
+		return ulid.ULID{}, false
+	}
+
+	// Ensure the 2nd part matches the block quarantine mark filename.
+	if parts[1] != QuarantineMarkFilename {
+		return ulid.ULID{}, false
+	}
+
+	// Ensure the 1st part is a valid block ID.
+	id, err := ulid.Parse(filepath.Base(parts[0]))
+	return id, err == nil
+}
+
 // IsBlockParquetConverterMarkFilename returns whether the input filename matches the expected pattern
 // of block parquet converter markers stored in the markers location.
 func IsBlockParquetConverterMarkFilename(name string) (ulid.ULID, bool) {
@@ -100,6 +154,43 @@ func IsBlockParquetConverterMarkFilename(name string) (ulid.ULID, bool) {
 	return id, err == nil
 }
 
+// WriteQuarantineMark uploads the quarantine mark of a block to the block location. The input
+// bucket is expected to be a tenant's bucket, which keeps track of the markers in the global
+// markers location too.
+func WriteQuarantineMark(ctx context.Context, userBkt objstore.Bucket, mark *QuarantineMark) error {
+	data, err := json.Marshal(mark)
+	if err != nil {
+		return errors.Wrap(err, "json encode quarantine mark")
+	}
+
+	return userBkt.Upload(ctx, path.Join(mark.ID.String(), QuarantineMarkFilename), bytes.NewReader(data))
+}
+
+// ReadQuarantineMark reads the quarantine mark of a block from the global markers location.
+func ReadQuarantineMark(ctx context.Context, userBkt objstore.InstrumentedBucket, id ulid.ULID) (*QuarantineMark, error) {
+	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, QuarantineMarkFilepath(id))
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, errors.Wrap(ErrQuarantineMarkNotFound, id.String())
+		}
+		return nil, errors.Wrapf(err, "read quarantine mark of block %s", id.String())
+	}
+
+	data, err := io.ReadAll(reader)
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read quarantine mark of block %s", id.String())
+	}
+
+	mark := &QuarantineMark{}
+	if err := json.Unmarshal(data, mark); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal quarantine mark of block %s", id.String())
+	}
+	return mark, nil
+}
+
 // MigrateBlockDeletionMarksToGlobalLocation list all tenant's blocks and, for each of them, look for
 // a deletion mark in the block location. Found deletion marks are copied to the global markers location.
 // The migration continues on error and returns once all blocks have been checked.
@@ -15,6 +15,7 @@ import (
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
 	cortex_testutil "github.com/cortexproject/cortex/pkg/util/testutil"
 )
 
@@ -41,6 +42,45 @@ func TestIsBlockDeletionMarkFilename(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestIsBlockQuarantineMarkFilename(t *testing.T) {
+	expected := ulid.MustNew(1, nil)
+
+	_, ok := IsBlockQuarantineMarkFilename("xxx-quarantine-mark.json")
+	assert.False(t, ok)
+
+	_, ok = IsBlockQuarantineMarkFilename(expected.String() + "-deletion-mark.json")
+	assert.False(t, ok)
+
+	actual, ok := IsBlockQuarantineMarkFilename(expected.String() + "-quarantine-mark.json")
+	assert.True(t, ok)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, "markers/"+expected.String()+"-quarantine-mark.json", QuarantineMarkFilepath(expected))
+}
+
+func TestWriteAndReadQuarantineMark(t *testing.T) {
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient("user-1", BucketWithGlobalMarkers(bkt), nil)
+	ctx := context.Background()
+
+	id := ulid.MustNew(1, nil)
+	_, err := ReadQuarantineMark(ctx, userBkt, id)
+	assert.ErrorIs(t, err, ErrQuarantineMarkNotFound)
+
+	expected := &QuarantineMark{ID: id, Version: QuarantineMarkVersion1, Details: "corrupted chunk", QuarantineTime: 1, MinTime: 10, MaxTime: 20}
+	require.NoError(t, WriteQuarantineMark(ctx, userBkt, expected))
+
+	// The mark is stored in both the block and the global markers locations.
+	for _, name := range []string{path.Join(id.String(), QuarantineMarkFilename), QuarantineMarkFilepath(id)} {
+		ok, err := userBkt.Exists(ctx, name)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	actual, err := ReadQuarantineMark(ctx, userBkt, id)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
 func TestMigrateBlockDeletionMarksToGlobalLocation(t *testing.T) {
 	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
 	ctx := context.Background()
@@ -30,16 +30,27 @@ var (
 	errBlockMetaKeyAccessDeniedErr = errors.New("block meta file key access denied error")
 )
 
+// The compactor uploads the stats of a block right after the block, so the stats of the blocks
+// uploaded within this period are looked up again if they were missing when the block was added.
+const blockStatsLookupPeriod = time.Hour
+
 // Updater is responsible to generate an update in-memory bucket index.
 type Updater struct {
 	bkt            objstore.InstrumentedBucket
+	userID         string
 	logger         log.Logger
 	parquetEnabled bool
+	statsEnabled   bool
+
+	// coldStorage is used to find the storage tier of the blocks not in the old index.
+	// Nil if the cold storage is disabled.
+	coldStorage *bucket.TieredBucketClient
 }
 
 func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *Updater {
 	return &Updater{
 		bkt:    bucket.NewUserBucketClient(userID, bkt, cfgProvider),
+		userID: userID,
 		logger: util_log.WithUserID(userID, logger),
 	}
 }
@@ -49,6 +60,19 @@ func (w *Updater) EnableParquet() *Updater {
 	return w
 }
 
+// EnableColdStorage enables finding the storage tier of the blocks not in the old index from
+// the buckets of the input tiered bucket client, which must be the one the index is updated from.
+func (w *Updater) EnableColdStorage(coldStorage *bucket.TieredBucketClient) *Updater {
+	w.coldStorage = coldStorage
+	return w
+}
+
+// EnableBlockStats enables reading the label statistics of the blocks uploaded by the compactor.
+func (w *Updater) EnableBlockStats() *Updater {
+	w.statsEnabled = true
+	return w
+}
+
 // UpdateIndex generates the bucket index and returns it, without storing it to the storage.
 // If the old index is not passed in input, then the bucket index will be generated from scratch.
 func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid.ULID]error, int64, error) {
@@ -63,7 +87,7 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 		oldBlockDeletionMarks = old.BlockDeletionMarks
 	}
 
-	blockDeletionMarks, deletedBlocks, totalBlocksBlocksMarkedForNoCompaction, err := w.updateBlockMarks(ctx, oldBlockDeletionMarks)
+	blockDeletionMarks, deletedBlocks, quarantinedBlocks, totalBlocksBlocksMarkedForNoCompaction, err := w.updateBlockMarks(ctx, oldBlockDeletionMarks)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -72,11 +96,22 @@ func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid
 	if err != nil {
 		return nil, nil, 0, err
 	}
+
+	// Quarantine marks can be removed once a block has been manually checked, so the
+	// quarantined blocks are looked up at every update.
+	for _, b := range blocks {
+		_, b.Quarantined = quarantinedBlocks[b.ID]
+	}
 	if w.parquetEnabled {
 		if err := w.updateParquetBlocks(ctx, blocks); err != nil {
 			return nil, nil, 0, err
 		}
 	}
+	if w.statsEnabled {
+		if err := w.updateBlocksStats(ctx, blocks, oldBlocks); err != nil {
+			return nil, nil, 0, err
+		}
+	}
 
 	return &Index{
 		Version:            IndexVersion1,
@@ -124,6 +159,18 @@ func (w *Updater) updateBlocks(ctx context.Context, old []*Block, deletedBlocks
 			continue
 		}
 
+		if errors.Is(err, ErrBlockMetaNotFound) {
+			// The block may be a parquet-only block, which has no meta.json at all. They're recognised
+			// even if parquet is disabled for the tenant, otherwise they'd be tracked as partial blocks.
+			pb, perr := w.updateParquetOnlyBlockIndexEntry(ctx, id)
+			if perr == nil {
+				blocks = append(blocks, pb)
+				continue
+			}
+			if !errors.Is(perr, parquet.ErrCompactionMetaNotFound) {
+				return nil, nil, perr
+			}
+		}
 		if errors.Is(err, ErrBlockMetaNotFound) {
 			partials[id] = err
 			level.Warn(w.logger).Log("msg", "skipped partial block when updating bucket index", "block", id.String())
@@ -148,6 +195,17 @@ func (w *Updater) updateBlocks(ctx context.Context, old []*Block, deletedBlocks
 func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Block, error) {
 	metaFile := path.Join(id.String(), block.MetaFilename)
 
+	// The storage tier of the blocks moved to the cold storage is inferred from the buckets
+	// the block is in, for example when the bucket index is generated from scratch.
+	inHot, inCold := true, false
+	if w.coldStorage != nil {
+		var err error
+		inHot, inCold, err = w.coldStorage.ExistsInTiers(ctx, path.Join(w.userID, metaFile))
+		if err != nil {
+			return nil, errors.Wrapf(err, "find storage tier of block meta file: %v", metaFile)
+		}
+	}
+
 	// Get the block's meta.json file.
 	r, err := w.bkt.ReaderWithExpectedErrs(bucket.IsOneOfTheExpectedErrors(w.bkt.IsObjNotFoundErr, w.bkt.IsAccessDeniedErr)).Get(ctx, metaFile)
 	if w.bkt.IsObjNotFoundErr(err) {
@@ -195,9 +253,41 @@ func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Blo
 	// the block has completed to be uploaded.
 	block.UploadedAt = attrs.LastModified.Unix()
 
+	if inCold {
+		block.StorageTier = StorageTierCold
+		block.HotCopiesDeleted = !inHot
+	}
+
 	return block, nil
 }
 
+// updateParquetOnlyBlockIndexEntry returns the index entry of a parquet-only block, built from
+// its parquet compaction meta, which is the last file uploaded for such a block.
+func (w *Updater) updateParquetOnlyBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Block, error) {
+	m, err := parquet.ReadCompactionMeta(ctx, id, w.bkt, w.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	metaFile := path.Join(id.String(), parquet.CompactionMetaFileName)
+	attrs, err := w.bkt.Attributes(ctx, metaFile)
+	if w.bkt.IsObjNotFoundErr(err) {
+		return nil, parquet.ErrCompactionMetaNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read parquet compaction meta file attributes: %v", metaFile)
+	}
+
+	return &Block{
+		ID:             id,
+		MinTime:        m.MinTime,
+		MaxTime:        m.MaxTime,
+		UploadedAt:     attrs.LastModified.Unix(),
+		ParquetOnly:    true,
+		ParquetSources: m.Sources,
+	}, nil
+}
+
 func (w *Updater) updateParquetBlockIndexEntry(ctx context.Context, id ulid.ULID, block *Block) error {
 	marker, err := parquet.ReadConverterMark(ctx, id, w.bkt, w.logger)
 	if err != nil {
@@ -216,9 +306,42 @@ func (w *Updater) updateParquetBlockIndexEntry(ctx context.Context, id ulid.ULID
 	return nil
 }
 
-func (w *Updater) updateBlockMarks(ctx context.Context, old []*BlockDeletionMark) ([]*BlockDeletionMark, map[ulid.ULID]struct{}, int64, error) {
+func (w *Updater) updateBlocksStats(ctx context.Context, blocks []*Block, old []*Block) error {
+	known := make(map[ulid.ULID]struct{}, len(old))
+	for _, b := range old {
+		known[b.ID] = struct{}{}
+	}
+
+	for _, b := range blocks {
+		// Stats are immutable, so they're read once. Parquet-only blocks have no stats.
+		if b.Stats != nil || b.ParquetOnly {
+			continue
+		}
+		// Blocks added to the index in previous updates are only looked up again if recently uploaded.
+		if _, ok := known[b.ID]; ok && time.Since(b.GetUploadedAt()) > blockStatsLookupPeriod {
+			continue
+		}
+
+		stats, err := ReadBlockStats(ctx, w.bkt, b.ID, w.logger)
+		if errors.Is(err, ErrBlockStatsNotFound) {
+			continue
+		}
+		if errors.Is(err, ErrBlockStatsCorrupted) {
+			level.Warn(w.logger).Log("msg", "skipped corrupted block stats when updating bucket index", "block", b.ID.String(), "err", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		b.Stats = stats
+	}
+	return nil
+}
+
+func (w *Updater) updateBlockMarks(ctx context.Context, old []*BlockDeletionMark) ([]*BlockDeletionMark, map[ulid.ULID]struct{}, map[ulid.ULID]struct{}, int64, error) {
 	out := make([]*BlockDeletionMark, 0, len(old))
 	deletedBlocks := map[ulid.ULID]struct{}{}
+	quarantinedBlocks := map[ulid.ULID]struct{}{}
 	discovered := map[ulid.ULID]struct{}{}
 	totalBlocksBlocksMarkedForNoCompaction := int64(0)
 
@@ -232,10 +355,14 @@ func (w *Updater) updateBlockMarks(ctx context.Context, old []*BlockDeletionMark
 			totalBlocksBlocksMarkedForNoCompaction++
 		}
 
+		if blockID, ok := IsBlockQuarantineMarkFilename(path.Base(name)); ok {
+			quarantinedBlocks[blockID] = struct{}{}
+		}
+
 		return nil
 	})
 	if err != nil {
-		return nil, nil, totalBlocksBlocksMarkedForNoCompaction, errors.Wrap(err, "list block deletion marks")
+		return nil, nil, nil, totalBlocksBlocksMarkedForNoCompaction, errors.Wrap(err, "list block deletion marks")
 	}
 
 	// Since deletion marks are immutable, all markers already existing in the index can just be copied.
@@ -261,13 +388,13 @@ func (w *Updater) updateBlockMarks(ctx context.Context, old []*BlockDeletionMark
 			continue
 		}
 		if err != nil {
-			return nil, nil, totalBlocksBlocksMarkedForNoCompaction, err
+			return nil, nil, nil, totalBlocksBlocksMarkedForNoCompaction, err
 		}
 
 		out = append(out, m)
 	}
 
-	return out, deletedBlocks, totalBlocksBlocksMarkedForNoCompaction, nil
+	return out, deletedBlocks, quarantinedBlocks, totalBlocksBlocksMarkedForNoCompaction, nil
 }
 
 func (w *Updater) updateBlockDeletionMarkIndexEntry(ctx context.Context, id ulid.ULID) (*BlockDeletionMark, error) {
@@ -318,6 +318,41 @@ func TestUpdater_UpdateIndex_ShouldSkipBlockMarkedForDeletionWithMissingGlobalMa
 	assert.Empty(t, nonCompactBlocks)
 }
 
+func TestUpdater_UpdateIndex_ShouldFlagQuarantinedBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	// Mock some blocks in the storage.
+	bkt = BucketWithGlobalMarkers(bkt)
+	block1 := testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	block2 := testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	require.NoError(t, WriteQuarantineMark(ctx, userBkt, &QuarantineMark{ID: block2.ULID, Version: QuarantineMarkVersion1}))
+
+	w := NewUpdater(bkt, userID, nil, logger)
+	idx, _, _, err := w.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 2)
+	for _, b := range idx.Blocks {
+		assert.Equal(t, b.ID == block2.ULID, b.Quarantined, b.ID.String())
+	}
+
+	// Once the quarantine mark is removed, the block is not flagged anymore.
+	require.NoError(t, userBkt.Delete(ctx, path.Join(block2.ULID.String(), QuarantineMarkFilename)))
+
+	idx, _, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ulid.ULID{block1.ULID, block2.ULID}, idx.Blocks.GetULIDs())
+	for _, b := range idx.Blocks {
+		assert.False(t, b.Quarantined)
+	}
+}
+
 func TestUpdater_UpdateIndex_NoTenantInTheBucket(t *testing.T) {
 	const userID = "user-1"
 
@@ -399,6 +434,123 @@ func TestUpdater_UpdateIndex_WithParquet(t *testing.T) {
 		})
 }
 
+func TestUpdater_UpdateIndex_WithParquetOnlyBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt = BucketWithGlobalMarkers(bkt)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	block1 := testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	block2 := testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+	testutil.MockStorageParquetConverterMark(t, bkt, userID, block1, 1)
+
+	// Delete a block's meta.json to simulate a partial block.
+	require.NoError(t, bkt.Delete(ctx, path.Join(userID, block2.ULID.String(), metadata.MetaFilename)))
+
+	// Mock a parquet-only block, which has no meta.json.
+	parquetOnlyID := ulid.MustNew(1, nil)
+	require.NoError(t, userBkt.Upload(ctx, path.Join(parquetOnlyID.String(), "0.labels.parquet"), strings.NewReader("labels")))
+	require.NoError(t, userBkt.Upload(ctx, path.Join(parquetOnlyID.String(), "0.chunks.parquet"), strings.NewReader("chunks")))
+	require.NoError(t, parquet.WriteConverterMark(ctx, parquetOnlyID, userBkt, 2))
+	require.NoError(t, parquet.WriteCompactionMeta(ctx, parquet.CompactionMeta{
+		ULID:    parquetOnlyID,
+		MinTime: 0,
+		MaxTime: 10,
+		Sources: []ulid.ULID{ulid.MustNew(2, nil), ulid.MustNew(3, nil)},
+	}, userBkt))
+
+	// Without parquet, the parquet-only block is recognised but its parquet files are not looked up.
+	idx, partials, _, err := NewUpdater(bkt, userID, nil, logger).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, partials, 1)
+	assert.True(t, errors.Is(partials[block2.ULID], ErrBlockMetaNotFound))
+	require.Len(t, idx.ParquetOnlyBlocks(), 1)
+	assert.Nil(t, idx.ParquetOnlyBlocks()[0].Parquet)
+
+	idx, partials, _, err = NewUpdater(bkt, userID, nil, logger).EnableParquet().UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, partials, 1)
+	assert.True(t, errors.Is(partials[block2.ULID], ErrBlockMetaNotFound))
+
+	require.Len(t, idx.Blocks, 2)
+	require.Len(t, idx.ParquetOnlyBlocks(), 1)
+	assert.Len(t, idx.NonParquetBlocks(), 0)
+
+	b := idx.ParquetOnlyBlocks()[0]
+	assert.Equal(t, parquetOnlyID, b.ID)
+	assert.Equal(t, int64(0), b.MinTime)
+	assert.Equal(t, int64(10), b.MaxTime)
+	assert.NotZero(t, b.UploadedAt)
+	assert.Equal(t, []ulid.ULID{ulid.MustNew(2, nil), ulid.MustNew(3, nil)}, b.ParquetSources)
+	assert.Equal(t, &parquet.ConverterMarkMeta{Version: parquet.CurrentVersion, Shards: 2}, b.Parquet)
+}
+
+func TestUpdater_UpdateIndex_WithBlockStats(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	bkt = BucketWithGlobalMarkers(bkt)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	block1 := testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	block2 := testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+	stats1 := &BlockStats{Labels: map[string]LabelValuesRange{"cluster": {Min: "a", Max: "b"}}}
+	require.NoError(t, WriteBlockStats(ctx, userBkt, block1.ULID, stats1))
+
+	// Without block stats enabled, the stats are not read.
+	idx, _, _, err := NewUpdater(bkt, userID, nil, logger).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		assert.Nil(t, b.Stats)
+	}
+
+	w := NewUpdater(bkt, userID, nil, logger).EnableBlockStats()
+	idx, _, _, err = w.UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	stats := map[ulid.ULID]*BlockStats{}
+	for _, b := range idx.Blocks {
+		stats[b.ID] = b.Stats
+	}
+	assert.Equal(t, map[ulid.ULID]*BlockStats{block1.ULID: stats1, block2.ULID: nil}, stats)
+
+	// Stats uploaded after the block has been added to the index are read, because the block was recently uploaded.
+	stats2 := &BlockStats{Labels: map[string]LabelValuesRange{"cluster": {Min: "c", Max: "d"}}}
+	require.NoError(t, WriteBlockStats(ctx, userBkt, block2.ULID, stats2))
+
+	idx, _, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		stats[b.ID] = b.Stats
+	}
+	assert.Equal(t, map[ulid.ULID]*BlockStats{block1.ULID: stats1, block2.ULID: stats2}, stats)
+
+	// Stats of blocks uploaded long ago are not looked up again.
+	block3 := testutil.MockStorageBlock(t, bkt, userID, 30, 40)
+	idx, _, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		if b.ID == block3.ULID {
+			b.UploadedAt = time.Now().Add(-2 * blockStatsLookupPeriod).Unix()
+		}
+	}
+	require.NoError(t, WriteBlockStats(ctx, userBkt, block3.ULID, stats2))
+
+	idx, _, _, err = w.UpdateIndex(ctx, idx)
+	require.NoError(t, err)
+	for _, b := range idx.Blocks {
+		if b.ID == block3.ULID {
+			assert.Nil(t, b.Stats)
+		}
+	}
+}
+
 func TestUpdater_UpdateParquetBlockIndexEntry(t *testing.T) {
 	const userID = "user-1"
 	ctx := context.Background()
@@ -1,6 +1,7 @@
 package tsdb
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"path/filepath"
@@ -23,11 +24,12 @@ import (
 	"github.com/thanos-io/thanos/pkg/model"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 
+	chunkcache "github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/util/users"
 )
 
 var (
-	supportedBucketCacheBackends = []string{CacheBackendInMemory, CacheBackendMemcached, CacheBackendRedis}
+	supportedBucketCacheBackends = []string{CacheBackendInMemory, CacheBackendMemcached, CacheBackendRedis, CacheBackendDisk}
 
 	errUnsupportedBucketCacheBackend = errors.New("unsupported cache backend")
 	errDuplicatedBucketCacheBackend  = errors.New("duplicated cache backend")
@@ -37,6 +39,7 @@ const (
 	CacheBackendMemcached = "memcached"
 	CacheBackendRedis     = "redis"
 	CacheBackendInMemory  = "inmemory"
+	CacheBackendDisk      = "disk"
 )
 
 type BucketCacheBackend struct {
@@ -44,6 +47,7 @@ type BucketCacheBackend struct {
 	InMemory   InMemoryBucketCacheConfig   `yaml:"inmemory"`
 	Memcached  MemcachedClientConfig       `yaml:"memcached"`
 	Redis      RedisClientConfig           `yaml:"redis"`
+	Disk       DiskCacheConfig             `yaml:"disk"`
 	MultiLevel MultiLevelBucketCacheConfig `yaml:"multilevel"`
 }
 
@@ -80,6 +84,10 @@ func (cfg *BucketCacheBackend) Validate() error {
 			if err := cfg.Redis.Validate(); err != nil {
 				return err
 			}
+		case CacheBackendDisk:
+			if err := cfg.Disk.Validate(); err != nil {
+				return err
+			}
 		case CacheBackendInMemory:
 		}
 
@@ -100,12 +108,13 @@ type ChunksCacheConfig struct {
 
 func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("The chunks cache backend type. Single or Multiple cache backend can be provided. "+
-		"Supported values in single cache: %s, %s, %s, and '' (disable). "+
-		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, strings.Join(supportedBucketCacheBackends, ", ")))
+		"Supported values in single cache: %s, %s, %s, %s, and '' (disable). "+
+		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, CacheBackendDisk, strings.Join(supportedBucketCacheBackends, ", ")))
 
 	cfg.Memcached.RegisterFlagsWithPrefix(f, prefix+"memcached.")
 	cfg.Redis.RegisterFlagsWithPrefix(f, prefix+"redis.")
 	cfg.InMemory.RegisterFlagsWithPrefix(f, prefix+"inmemory.", "chunks")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "chunks")
 	cfg.MultiLevel.RegisterFlagsWithPrefix(f, prefix+"multilevel.")
 
 	f.Int64Var(&cfg.SubrangeSize, prefix+"subrange-size", 16000, "Size of each subrange that bucket object is split into for better caching.")
@@ -160,12 +169,13 @@ type MetadataCacheConfig struct {
 
 func (cfg *MetadataCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("The metadata cache backend type. Single or Multiple cache backend can be provided. "+
-		"Supported values in single cache: %s, %s, %s, and '' (disable). "+
-		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, strings.Join(supportedBucketCacheBackends, ", ")))
+		"Supported values in single cache: %s, %s, %s, %s, and '' (disable). "+
+		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, CacheBackendDisk, strings.Join(supportedBucketCacheBackends, ", ")))
 
 	cfg.Memcached.RegisterFlagsWithPrefix(f, prefix+"memcached.")
 	cfg.Redis.RegisterFlagsWithPrefix(f, prefix+"redis.")
 	cfg.InMemory.RegisterFlagsWithPrefix(f, prefix+"inmemory.", "metadata")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "metadata")
 	cfg.MultiLevel.RegisterFlagsWithPrefix(f, prefix+"multilevel.")
 
 	f.DurationVar(&cfg.TenantsListTTL, prefix+"tenants-list-ttl", 15*time.Minute, "How long to cache list of tenants in the bucket.")
@@ -197,12 +207,13 @@ type ParquetLabelsCacheConfig struct {
 
 func (cfg *ParquetLabelsCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("The parquet labels cache backend type. Single or Multiple cache backend can be provided. "+
-		"Supported values in single cache: %s, %s, %s, and '' (disable). "+
-		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, strings.Join(supportedBucketCacheBackends, ", ")))
+		"Supported values in single cache: %s, %s, %s, %s, and '' (disable). "+
+		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, CacheBackendDisk, strings.Join(supportedBucketCacheBackends, ", ")))
 
 	cfg.Memcached.RegisterFlagsWithPrefix(f, prefix+"memcached.")
 	cfg.Redis.RegisterFlagsWithPrefix(f, prefix+"redis.")
 	cfg.InMemory.RegisterFlagsWithPrefix(f, prefix+"inmemory.", "parquet-labels")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "parquet-labels")
 	cfg.MultiLevel.RegisterFlagsWithPrefix(f, prefix+"multilevel.")
 
 	f.Int64Var(&cfg.SubrangeSize, prefix+"subrange-size", 16000, "Size of each subrange that bucket object is split into for better caching.")
@@ -226,12 +237,13 @@ type ParquetRowRangesCacheConfig struct {
 
 func (cfg *ParquetRowRangesCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.StringVar(&cfg.Backend, prefix+"backend", "", fmt.Sprintf("The parquet row ranges cache backend type. Single or Multiple cache backend can be provided. "+
-		"Supported values in single cache: %s, %s, %s, and '' (disable). "+
-		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, strings.Join(supportedBucketCacheBackends, ", ")))
+		"Supported values in single cache: %s, %s, %s, %s, and '' (disable). "+
+		"Supported values in multi level cache: a comma-separated list of (%s)", CacheBackendMemcached, CacheBackendRedis, CacheBackendInMemory, CacheBackendDisk, strings.Join(supportedBucketCacheBackends, ", ")))
 
 	cfg.Memcached.RegisterFlagsWithPrefix(f, prefix+"memcached.")
 	cfg.Redis.RegisterFlagsWithPrefix(f, prefix+"redis.")
 	cfg.InMemory.RegisterFlagsWithPrefix(f, prefix+"inmemory.", "parquet-row-ranges")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "parquet-row-ranges")
 	cfg.MultiLevel.RegisterFlagsWithPrefix(f, prefix+"multilevel.")
 
 	f.DurationVar(&cfg.TTL, prefix+"ttl", 10*time.Minute, "TTL for caching parquet row ranges.")
@@ -383,12 +395,40 @@ func createBucketCache(cacheName string, cacheBackend *BucketCacheBackend, logge
 				return nil, errors.Wrapf(err, "failed to create redis client")
 			}
 			caches = append(caches, cache.NewRedisCache(cacheName, logger, redisCache, reg))
+		case CacheBackendDisk:
+			diskCache, err := chunkcache.NewDiskCache(cacheName, cacheBackend.Disk.ToDiskCacheConfig(), reg, logger)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to create disk cache")
+			}
+			caches = append(caches, &diskBucketCache{name: cacheName, diskCache: diskCache})
 		}
 	}
 
 	return newMultiLevelBucketCache(cacheName, cacheBackend.MultiLevel, reg, caches...), nil
 }
 
+// diskBucketCache adapts the disk cache to be used as a bucket cache.
+type diskBucketCache struct {
+	name      string
+	diskCache *chunkcache.DiskCache
+}
+
+// Store data identified by keys. The data is written asynchronously, and dropped
+// if the disk cache async buffer is full.
+func (c *diskBucketCache) Store(data map[string][]byte, ttl time.Duration) {
+	for key, value := range data {
+		_ = c.diskCache.SetAsync(key, value, ttl)
+	}
+}
+
+func (c *diskBucketCache) Fetch(ctx context.Context, keys []string) map[string][]byte {
+	return c.diskCache.GetMulti(ctx, keys)
+}
+
+func (c *diskBucketCache) Name() string {
+	return c.name
+}
+
 type Matchers struct {
 	matcherMap map[string]func(string) bool
 }
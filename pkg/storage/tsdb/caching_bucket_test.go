@@ -14,6 +14,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/util/test"
 )
 
 type countingBucket struct {
@@ -69,6 +71,29 @@ func Test_BucketCacheBackendValidation(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		"valid bucket cache type (disk)": {
+			cfg: BucketCacheBackend{
+				Backend: CacheBackendDisk,
+				Disk: DiskCacheConfig{
+					Path:                "/data/chunks-cache",
+					MaxSizeBytes:        1024,
+					MaxAsyncConcurrency: 1,
+					MaxAsyncBufferSize:  1,
+				},
+			},
+			expectedErr: nil,
+		},
+		"invalid disk bucket cache max size": {
+			cfg: BucketCacheBackend{
+				Backend: CacheBackendDisk,
+				Disk: DiskCacheConfig{
+					Path:                "/data/chunks-cache",
+					MaxAsyncConcurrency: 1,
+					MaxAsyncBufferSize:  1,
+				},
+			},
+			expectedErr: errInvalidDiskCacheMaxSize,
+		},
 		"invalid bucket cache type": {
 			cfg: BucketCacheBackend{
 				Backend: "dummy",
@@ -77,13 +102,19 @@ func Test_BucketCacheBackendValidation(t *testing.T) {
 		},
 		"valid multi bucket cache type": {
 			cfg: BucketCacheBackend{
-				Backend: fmt.Sprintf("%s,%s,%s", CacheBackendInMemory, CacheBackendMemcached, CacheBackendRedis),
+				Backend: fmt.Sprintf("%s,%s,%s,%s", CacheBackendInMemory, CacheBackendDisk, CacheBackendMemcached, CacheBackendRedis),
 				Memcached: MemcachedClientConfig{
 					Addresses: "dns+localhost:11211",
 				},
 				Redis: RedisClientConfig{
 					Addresses: "localhost:6379",
 				},
+				Disk: DiskCacheConfig{
+					Path:                "/data/chunks-cache",
+					MaxSizeBytes:        1024,
+					MaxAsyncConcurrency: 1,
+					MaxAsyncBufferSize:  1,
+				},
 				MultiLevel: MultiLevelBucketCacheConfig{
 					MaxAsyncConcurrency: 1,
 					MaxAsyncBufferSize:  1,
@@ -212,6 +243,30 @@ func Test_BucketIndexCache(t *testing.T) {
 	}
 }
 
+func Test_DiskBucketCache(t *testing.T) {
+	cacheBackend := &BucketCacheBackend{
+		Backend: CacheBackendDisk,
+		Disk: DiskCacheConfig{
+			Path:                t.TempDir(),
+			MaxSizeBytes:        1024 * 1024,
+			MaxAsyncConcurrency: 1,
+			MaxAsyncBufferSize:  10,
+		},
+	}
+
+	c, err := createBucketCache("chunks-cache", cacheBackend, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	assert.Equal(t, "chunks-cache", c.Name())
+
+	c.Store(map[string][]byte{"key-1": []byte("value-1"), "key-2": []byte("value-2")}, time.Hour)
+
+	// The items are stored asynchronously.
+	test.Poll(t, 5*time.Second, 2, func() any {
+		return len(c.Fetch(context.Background(), []string{"key-1", "key-2", "key-3"}))
+	})
+	assert.Equal(t, map[string][]byte{"key-1": []byte("value-1")}, c.Fetch(context.Background(), []string{"key-1"}))
+}
+
 func Test_BucketIndexCacheForCompactor(t *testing.T) {
 	const bucketIndexFile = "user1/bucket-index.json.gz"
 	const fileContent = "test-content"
@@ -59,6 +59,7 @@ var (
 	errEmptyBlockranges               = errors.New("empty block ranges for TSDB")
 	errUnSupportedWALCompressionType  = errors.New("unsupported WAL compression type, valid types are (zstd, snappy and '')")
 	errInvalidParquetQueryConcurrency = errors.New("invalid parquet query concurrency, the value must be greater than 0")
+	errInvalidIndexHeaderWarmup       = errors.New("invalid index-header warmup config, the top metric names must be equal or greater than 0 and the query samples window and timeout must be greater than 0")
 
 	ErrInvalidBucketIndexBlockDiscoveryStrategy         = errors.New("bucket index block discovery strategy can only be enabled when bucket index is enabled")
 	ErrBlockDiscoveryStrategy                           = errors.New("invalid block discovery strategy")
@@ -72,6 +73,7 @@ var (
 //nolint:revive
 type BlocksStorageConfig struct {
 	Bucket       bucket.Config            `yaml:",inline"`
+	ColdStorage  bucket.ColdStorageConfig `yaml:"cold_storage" doc:"description=This configures the cold storage tier, where the compactor moves the old blocks to."`
 	BucketStore  BucketStoreConfig        `yaml:"bucket_store" doc:"description=This configures how the querier and store-gateway discover and synchronize blocks stored in the bucket."`
 	TSDB         TSDBConfig               `yaml:"tsdb"`
 	UsersScanner users.UsersScannerConfig `yaml:"users_scanner"`
@@ -117,6 +119,7 @@ func (d *DurationList) ToMilliseconds() []int64 {
 // RegisterFlags registers the block storage flags
 func (cfg *BlocksStorageConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.Bucket.RegisterFlagsWithPrefix("blocks-storage.", f)
+	cfg.ColdStorage.RegisterFlagsWithPrefix("blocks-storage.cold-storage.", f)
 	cfg.BucketStore.RegisterFlags(f)
 	cfg.TSDB.RegisterFlags(f)
 	cfg.UsersScanner.RegisterFlagsWithPrefix("blocks-storage.", f)
@@ -128,6 +131,10 @@ func (cfg *BlocksStorageConfig) Validate() error {
 		return err
 	}
 
+	if err := cfg.ColdStorage.Validate(); err != nil {
+		return errors.Wrap(err, "cold storage configuration")
+	}
+
 	if err := cfg.TSDB.Validate(); err != nil {
 		return err
 	}
@@ -179,6 +186,9 @@ type TSDBConfig struct {
 	// Enable snapshotting of in-memory TSDB data on disk when shutting down.
 	MemorySnapshotOnShutdown bool `yaml:"memory_snapshot_on_shutdown"`
 
+	// How frequently the head of each TSDB is uploaded to the storage. 0 to disable.
+	HeadSnapshotUploadInterval time.Duration `yaml:"head_snapshot_upload_interval"`
+
 	// OutOfOrderCapMax is maximum capacity for OOO chunks (in samples).
 	OutOfOrderCapMax int64 `yaml:"out_of_order_cap_max"`
 
@@ -196,7 +206,7 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&cfg.BlockRanges, "blocks-storage.tsdb.block-ranges-period", "TSDB blocks range period.")
 	f.DurationVar(&cfg.Retention, "blocks-storage.tsdb.retention-period", 6*time.Hour, "TSDB blocks retention in the ingester before a block is removed. This should be larger than the block_ranges_period and large enough to give store-gateways and queriers enough time to discover newly uploaded blocks.")
 	f.DurationVar(&cfg.ShipInterval, "blocks-storage.tsdb.ship-interval", 1*time.Minute, "How frequently the TSDB blocks are scanned and new ones are shipped to the storage. 0 means shipping is disabled.")
-	f.IntVar(&cfg.ShipConcurrency, "blocks-storage.tsdb.ship-concurrency", 10, "Maximum number of tenants concurrently shipping blocks to the storage.")
+	f.IntVar(&cfg.ShipConcurrency, "blocks-storage.tsdb.ship-concurrency", 10, "Maximum number of tenants concurrently shipping blocks or head snapshots to the storage.")
 	f.IntVar(&cfg.MaxTSDBOpeningConcurrencyOnStartup, "blocks-storage.tsdb.max-tsdb-opening-concurrency-on-startup", 10, "limit the number of concurrently opening TSDB's on startup")
 	f.DurationVar(&cfg.HeadCompactionInterval, "blocks-storage.tsdb.head-compaction-interval", 1*time.Minute, "How frequently does Cortex try to compact TSDB head. Block is only created if data covers smallest block range. Must be greater than 0 and max 30 minutes. Note that up to 50% jitter is added to the value for the first compaction to avoid ingesters compacting concurrently.")
 	f.IntVar(&cfg.HeadCompactionConcurrency, "blocks-storage.tsdb.head-compaction-concurrency", 5, "Maximum number of tenants concurrently compacting TSDB head into a new block")
@@ -210,6 +220,7 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.HeadChunksWriteQueueSize, "blocks-storage.tsdb.head-chunks-write-queue-size", chunks.DefaultWriteQueueSize, "The size of the in-memory queue used before flushing chunks to the disk.")
 	f.IntVar(&cfg.MaxExemplars, "blocks-storage.tsdb.max-exemplars", 0, "Deprecated, use maxExemplars in limits instead. If the MaxExemplars value in limits is set to zero, cortex will fallback on this value. This setting enables support for exemplars in TSDB and sets the maximum number that will be stored. 0 or less means disabled.")
 	f.BoolVar(&cfg.MemorySnapshotOnShutdown, "blocks-storage.tsdb.memory-snapshot-on-shutdown", false, "True to enable snapshotting of in-memory TSDB data on disk when shutting down.")
+	f.DurationVar(&cfg.HeadSnapshotUploadInterval, "blocks-storage.tsdb.head-snapshot-upload-interval", 0, "[EXPERIMENTAL] How frequently the ingesters upload a snapshot of the head of each TSDB (chunk snapshot, WAL checkpoint and segments, head chunks) to the storage. Only the files which changed since the previous snapshot are uploaded. An ingester starting with an empty TSDB directory restores the TSDBs from the latest snapshots it uploaded before joining the ring. Enabling it also enables the memory snapshot on shutdown, so that the restored TSDBs only replay the WAL written after the chunk snapshot. 0 means disabled.")
 	f.Int64Var(&cfg.OutOfOrderCapMax, "blocks-storage.tsdb.out-of-order-cap-max", tsdb.DefaultOutOfOrderCapMax, "[EXPERIMENTAL] Configures the maximum number of samples per chunk that can be out-of-order.")
 
 	flagext.DeprecatedFlag(f, "blocks-storage.tsdb.wal-compression-enabled", "Deprecated (use blocks-storage.tsdb.wal-compression-type instead): True to enable TSDB WAL compression.", util_log.Logger)
@@ -219,7 +230,7 @@ func (cfg *TSDBConfig) RegisterFlags(f *flag.FlagSet) {
 
 // Validate the config.
 func (cfg *TSDBConfig) Validate() error {
-	if cfg.ShipInterval > 0 && cfg.ShipConcurrency <= 0 {
+	if (cfg.IsBlocksShippingEnabled() || cfg.IsHeadSnapshotUploadEnabled()) && cfg.ShipConcurrency <= 0 {
 		return errInvalidShipConcurrency
 	}
 
@@ -276,6 +287,11 @@ func (cfg *TSDBConfig) IsBlocksShippingEnabled() bool {
 	return cfg.ShipInterval > 0
 }
 
+// IsHeadSnapshotUploadEnabled returns whether the upload of the TSDB head snapshots is enabled.
+func (cfg *TSDBConfig) IsHeadSnapshotUploadEnabled() bool {
+	return cfg.HeadSnapshotUploadInterval > 0
+}
+
 // BucketStoreConfig holds the config information for Bucket Stores used by the querier and store-gateway.
 type BucketStoreConfig struct {
 	SyncDir                  string                      `yaml:"sync_dir"`
@@ -308,6 +324,9 @@ type BucketStoreConfig struct {
 	IndexHeaderLazyLoadingEnabled     bool          `yaml:"index_header_lazy_loading_enabled"`
 	IndexHeaderLazyLoadingIdleTimeout time.Duration `yaml:"index_header_lazy_loading_idle_timeout"`
 
+	// Controls the warmup of the blocks loaded by the store-gateway.
+	IndexHeaderWarmup IndexHeaderWarmupConfig `yaml:"index_header_warmup"`
+
 	// Controls whether lazy expanded posting optimization is enabled or not.
 	LazyExpandedPostingsEnabled bool `yaml:"lazy_expanded_postings_enabled"`
 
@@ -365,6 +384,7 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.ParquetLabelsCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.parquet-labels-cache.")
 	cfg.ParquetRowRangesCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.parquet-row-ranges-cache.")
 	cfg.BucketIndex.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.bucket-index.")
+	cfg.IndexHeaderWarmup.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.index-header-warmup.")
 
 	f.StringVar(&cfg.SyncDir, "blocks-storage.bucket-store.sync-dir", "tsdb-sync", "Directory to store synchronized TSDB index headers.")
 	f.DurationVar(&cfg.SyncInterval, "blocks-storage.bucket-store.sync-interval", 15*time.Minute, "How frequently to scan the bucket, or to refresh the bucket index (if enabled), in order to look for changes (new blocks shipped by ingesters and blocks deleted by retention or compaction).")
@@ -445,6 +465,34 @@ func (cfg *BucketStoreConfig) Validate() error {
 	if cfg.ParquetQueryConcurrency <= 0 {
 		return errInvalidParquetQueryConcurrency
 	}
+	if err := cfg.IndexHeaderWarmup.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// IndexHeaderWarmupConfig holds the config of the warmup of the blocks newly loaded by the store-gateway.
+type IndexHeaderWarmupConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	TopMetricNames     int           `yaml:"top_metric_names"`
+	QuerySamplesWindow time.Duration `yaml:"query_samples_window"`
+	Timeout            time.Duration `yaml:"timeout"`
+}
+
+func (cfg *IndexHeaderWarmupConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "[Experimental] If enabled, the store-gateway preloads the index-headers of the blocks it newly owns, at startup and when the ring changes, before they're added to the blocks it queries. The blocks whose index-header is already on disk, e.g. after a restart, are not warmed up. At startup, the store-gateway switches to ACTIVE in the ring only once the warmup is completed. This is mostly useful when index-header lazy loading is enabled.")
+	f.IntVar(&cfg.TopMetricNames, prefix+"top-metric-names", 0, "[Experimental] Number of the most queried metric names of each tenant, collected from a rolling log of the series requests received by the store-gateway, whose postings are preloaded in the index cache during the warmup. 0 to disable.")
+	f.DurationVar(&cfg.QuerySamplesWindow, prefix+"query-samples-window", 24*time.Hour, "[Experimental] Time window of the rolling log of queried metric names used to find the most queried metric names. The log is persisted in the sync directory to survive restarts.")
+	f.DurationVar(&cfg.Timeout, prefix+"timeout", 5*time.Minute, "[Experimental] Maximum time spent warming up the index-header of each newly owned block, and the postings of the blocks newly owned at each sync. Once the timeout expires, the store-gateway proceeds without waiting for the warmup to complete.")
+}
+
+func (cfg *IndexHeaderWarmupConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TopMetricNames < 0 || cfg.QuerySamplesWindow <= 0 || cfg.Timeout <= 0 {
+		return errInvalidIndexHeaderWarmup
+	}
 	return nil
 }
 
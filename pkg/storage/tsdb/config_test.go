@@ -163,6 +163,27 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		"should pass on valid index-header warmup config": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderWarmup.Enabled = true
+				cfg.BucketStore.IndexHeaderWarmup.TopMetricNames = 10
+			},
+			expectedErr: nil,
+		},
+		"should fail on negative index-header warmup top metric names": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderWarmup.Enabled = true
+				cfg.BucketStore.IndexHeaderWarmup.TopMetricNames = -1
+			},
+			expectedErr: errInvalidIndexHeaderWarmup,
+		},
+		"should fail on index-header warmup timeout set to 0": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexHeaderWarmup.Enabled = true
+				cfg.BucketStore.IndexHeaderWarmup.Timeout = 0
+			},
+			expectedErr: errInvalidIndexHeaderWarmup,
+		},
 	}
 
 	for testName, testData := range tests {
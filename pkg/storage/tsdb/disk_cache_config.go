@@ -0,0 +1,64 @@
+package tsdb
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/pkg/errors"
+
+	chunkcache "github.com/cortexproject/cortex/pkg/chunk/cache"
+)
+
+var (
+	errNoDiskCachePath           = errors.New("no disk cache path")
+	errInvalidDiskCacheMaxSize   = errors.New("invalid disk cache max_size_bytes, must greater than 0")
+	errInvalidDiskCacheSyncIndex = errors.New("invalid disk cache index_sync_period, must not be negative")
+)
+
+type DiskCacheConfig struct {
+	Path                string        `yaml:"path"`
+	MaxSizeBytes        uint64        `yaml:"max_size_bytes"`
+	IndexSyncPeriod     time.Duration `yaml:"index_sync_period"`
+	MaxAsyncConcurrency int           `yaml:"max_async_concurrency"`
+	MaxAsyncBufferSize  int           `yaml:"max_async_buffer_size"`
+}
+
+func (cfg *DiskCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string, item string) {
+	f.StringVar(&cfg.Path, prefix+"path", "", fmt.Sprintf("Directory where the disk %s cache stores its entries. It must not be shared with any other cache.", item))
+	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", uint64(10*units.Gibibyte), fmt.Sprintf("Maximum size in bytes of the disk %s cache (shared between all tenants). The least recently used entries are evicted once the size is exceeded.", item))
+	f.DurationVar(&cfg.IndexSyncPeriod, prefix+"index-sync-period", time.Minute, "How frequently the disk cache index is persisted, to warm start the cache after a restart. The index is also persisted on shutdown. 0 to persist the index only on shutdown.")
+	f.IntVar(&cfg.MaxAsyncConcurrency, prefix+"max-async-concurrency", 3, "The maximum number of concurrent asynchronous writes to the disk cache.")
+	f.IntVar(&cfg.MaxAsyncBufferSize, prefix+"max-async-buffer-size", 10000, "The maximum number of enqueued asynchronous writes to the disk cache allowed.")
+}
+
+// Validate the config.
+func (cfg *DiskCacheConfig) Validate() error {
+	if cfg.Path == "" {
+		return errNoDiskCachePath
+	}
+	if cfg.MaxSizeBytes == 0 {
+		return errInvalidDiskCacheMaxSize
+	}
+	if cfg.IndexSyncPeriod < 0 {
+		return errInvalidDiskCacheSyncIndex
+	}
+	if cfg.MaxAsyncConcurrency <= 0 {
+		return errInvalidMaxAsyncConcurrency
+	}
+	if cfg.MaxAsyncBufferSize <= 0 {
+		return errInvalidMaxAsyncBufferSize
+	}
+	return nil
+}
+
+func (cfg DiskCacheConfig) ToDiskCacheConfig() chunkcache.DiskCacheConfig {
+	return chunkcache.DiskCacheConfig{
+		Path:                cfg.Path,
+		MaxSizeBytes:        cfg.MaxSizeBytes,
+		IndexSyncPeriod:     cfg.IndexSyncPeriod,
+		MaxAsyncConcurrency: cfg.MaxAsyncConcurrency,
+		MaxAsyncBufferSize:  cfg.MaxAsyncBufferSize,
+	}
+}
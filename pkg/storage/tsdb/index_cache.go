@@ -15,6 +15,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/model"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 
+	chunkcache "github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 )
 
@@ -28,6 +29,9 @@ const (
 	// IndexCacheBackendRedis is the value for the redis index cache backend.
 	IndexCacheBackendRedis = "redis"
 
+	// IndexCacheBackendDisk is the value for the local disk index cache backend.
+	IndexCacheBackendDisk = "disk"
+
 	// IndexCacheBackendDefault is the value for the default index cache backend.
 	IndexCacheBackendDefault = IndexCacheBackendInMemory
 
@@ -37,7 +41,7 @@ const (
 )
 
 var (
-	supportedIndexCacheBackends = []string{IndexCacheBackendInMemory, IndexCacheBackendMemcached, IndexCacheBackendRedis}
+	supportedIndexCacheBackends = []string{IndexCacheBackendInMemory, IndexCacheBackendMemcached, IndexCacheBackendRedis, IndexCacheBackendDisk}
 
 	errUnsupportedIndexCacheBackend = errors.New("unsupported index cache backend")
 	errDuplicatedIndexCacheBackend  = errors.New("duplicated index cache backend")
@@ -52,6 +56,7 @@ type IndexCacheConfig struct {
 	InMemory   InMemoryIndexCacheConfig   `yaml:"inmemory"`
 	Memcached  MemcachedIndexCacheConfig  `yaml:"memcached"`
 	Redis      RedisIndexCacheConfig      `yaml:"redis"`
+	Disk       DiskIndexCacheConfig       `yaml:"disk"`
 	MultiLevel MultiLevelIndexCacheConfig `yaml:"multilevel"`
 }
 
@@ -68,6 +73,7 @@ func (cfg *IndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix str
 	cfg.InMemory.RegisterFlagsWithPrefix(f, prefix+"inmemory.")
 	cfg.Memcached.RegisterFlagsWithPrefix(f, prefix+"memcached.")
 	cfg.Redis.RegisterFlagsWithPrefix(f, prefix+"redis.")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.")
 	cfg.MultiLevel.RegisterFlagsWithPrefix(f, prefix+"multilevel.")
 }
 
@@ -101,6 +107,10 @@ func (cfg *IndexCacheConfig) Validate() error {
 			if err := cfg.Redis.Validate(); err != nil {
 				return err
 			}
+		case IndexCacheBackendDisk:
+			if err := cfg.Disk.Validate(); err != nil {
+				return err
+			}
 		default:
 			if err := cfg.InMemory.Validate(); err != nil {
 				return err
@@ -189,6 +199,23 @@ func (cfg *RedisIndexCacheConfig) Validate() error {
 	return storecache.ValidateEnabledItems(cfg.EnabledItems)
 }
 
+type DiskIndexCacheConfig struct {
+	DiskConfig   DiskCacheConfig `yaml:",inline"`
+	EnabledItems []string        `yaml:"enabled_items"`
+}
+
+func (cfg *DiskIndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	cfg.DiskConfig.RegisterFlagsWithPrefix(f, prefix, "index")
+	f.Var((*flagext.StringSlice)(&cfg.EnabledItems), prefix+"enabled-items", "Selectively cache index item types. Supported values are Postings, ExpandedPostings and Series")
+}
+
+func (cfg *DiskIndexCacheConfig) Validate() error {
+	if err := cfg.DiskConfig.Validate(); err != nil {
+		return err
+	}
+	return storecache.ValidateEnabledItems(cfg.EnabledItems)
+}
+
 // NewIndexCache creates a new index cache based on the input configuration.
 func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheus.Registerer) (storecache.IndexCache, error) {
 	splitBackends := strings.Split(cfg.Backend, ",")
@@ -237,6 +264,17 @@ func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheu
 			}
 			caches = append(caches, cache)
 			enabledItems = append(enabledItems, cfg.Redis.EnabledItems)
+		case IndexCacheBackendDisk:
+			c, err := chunkcache.NewDiskCache("index-cache", cfg.Disk.DiskConfig.ToDiskCacheConfig(), iReg, logger)
+			if err != nil {
+				return nil, errors.Wrapf(err, "create index cache disk cache")
+			}
+			cache, err := storecache.NewRemoteIndexCache(logger, c, nil, iReg, defaultTTL)
+			if err != nil {
+				return nil, err
+			}
+			caches = append(caches, cache)
+			enabledItems = append(enabledItems, cfg.Disk.EnabledItems)
 		default:
 			return nil, errUnsupportedIndexCacheBackend
 		}
@@ -76,6 +76,40 @@ func TestIndexCacheConfig_Validate(t *testing.T) {
 			},
 			expected: fmt.Errorf("unsupported item type foo"),
 		},
+		"no disk path should fail": {
+			cfg: IndexCacheConfig{
+				Backend: "disk",
+			},
+			expected: errNoDiskCachePath,
+		},
+		"valid disk config should pass": {
+			cfg: IndexCacheConfig{
+				Backend: "disk",
+				Disk: DiskIndexCacheConfig{
+					DiskConfig: DiskCacheConfig{
+						Path:                "/data/index-cache",
+						MaxSizeBytes:        1024,
+						MaxAsyncConcurrency: 1,
+						MaxAsyncBufferSize:  1,
+					},
+				},
+			},
+		},
+		"invalid enabled items disk": {
+			cfg: IndexCacheConfig{
+				Backend: "disk",
+				Disk: DiskIndexCacheConfig{
+					DiskConfig: DiskCacheConfig{
+						Path:                "/data/index-cache",
+						MaxSizeBytes:        1024,
+						MaxAsyncConcurrency: 1,
+						MaxAsyncBufferSize:  1,
+					},
+					EnabledItems: []string{"foo", "bar"},
+				},
+			},
+			expected: fmt.Errorf("unsupported item type foo"),
+		},
 	}
 
 	for testName, testData := range tests {
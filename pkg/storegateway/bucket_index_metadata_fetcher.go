@@ -115,6 +115,10 @@ func (f *BucketIndexMetadataFetcher) Fetch(ctx context.Context) (metas map[ulid.
 	// Build block metas out of the index.
 	metas = make(map[ulid.ULID]*metadata.Meta, len(idx.Blocks))
 	for _, b := range idx.Blocks {
+		// Parquet-only blocks have no TSDB index and chunks to load.
+		if b.ParquetOnly {
+			continue
+		}
 		metas[b.ID] = b.ThanosMeta(f.userID)
 	}
 
@@ -95,6 +95,9 @@ type ThanosBucketStores struct {
 	// Keeps number of inflight requests
 	inflightRequests *util.InflightRequestTracker
 
+	// Warms up the newly loaded blocks. Nil if the warmup is disabled.
+	warmer *indexHeaderWarmer
+
 	// Metrics.
 	syncTimes         prometheus.Histogram
 	syncLastSuccess   prometheus.Gauge
@@ -198,6 +201,10 @@ func newThanosBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy Shardi
 		}))
 	}
 
+	if cfg.BucketStore.IndexHeaderWarmup.Enabled {
+		u.warmer = newIndexHeaderWarmer(cfg.BucketStore, logger, reg)
+	}
+
 	if reg != nil {
 		reg.MustRegister(u.bucketStoreMetrics, u.metaFetcherMetrics)
 	}
@@ -217,14 +224,30 @@ func (u *ThanosBucketStores) InitialSync(ctx context.Context) error {
 	}
 
 	level.Info(u.logger).Log("msg", "successfully synchronized TSDB blocks for all users")
+
+	// The store-gateway switches to ACTIVE in the ring once the initial sync is
+	// done, so the blocks are warmed up before they're queried.
+	u.warmupBlocks(ctx)
 	return nil
 }
 
 // SyncBlocks synchronizes the stores state with the Bucket store for every user.
 func (u *ThanosBucketStores) SyncBlocks(ctx context.Context) error {
-	return u.syncUsersBlocksWithRetries(ctx, func(ctx context.Context, s *store.BucketStore) error {
+	err := u.syncUsersBlocksWithRetries(ctx, func(ctx context.Context, s *store.BucketStore) error {
 		return s.SyncBlocks(ctx)
 	})
+
+	// Warm up the blocks loaded even if the sync partially failed.
+	u.warmupBlocks(ctx)
+	return err
+}
+
+// warmupBlocks warms up the blocks loaded since the previous warmup, if enabled.
+func (u *ThanosBucketStores) warmupBlocks(ctx context.Context) {
+	if u.warmer == nil {
+		return
+	}
+	u.warmer.warmup(ctx, u.getStore)
 }
 
 func (u *ThanosBucketStores) syncUsersBlocksWithRetries(ctx context.Context, f func(context.Context, *store.BucketStore) error) error {
@@ -356,6 +379,10 @@ func (u *ThanosBucketStores) Series(req *storepb.SeriesRequest, srv storepb.Stor
 		return fmt.Errorf("no userID")
 	}
 
+	if u.warmer != nil {
+		u.warmer.observeSeriesRequest(userID, req)
+	}
+
 	err := u.getStoreError(userID)
 	userBkt := bucket.NewUserBucketClient(userID, u.bucket, u.limits)
 	if err != nil {
@@ -675,12 +702,17 @@ func (u *ThanosBucketStores) getOrCreateStore(userID string) (*store.BucketStore
 		store.WithPostingGroupMaxKeySeriesRatio(u.cfg.BucketStore.LazyExpandedPostingGroupMaxKeySeriesRatio),
 		store.WithSeriesMatchRatio(0.5), // TODO: expose this as a config.
 		store.WithDontResort(true),      // Cortex doesn't need to resort series in store gateway.
-		store.WithBlockLifecycleCallback(&shardingBlockLifecycleCallbackAdapter{
-			userID:   userID,
-			strategy: u.shardingStrategy,
-			logger:   userLogger,
-		}),
 	}
+
+	var lifecycleCallback store.BlockLifecycleCallback = &shardingBlockLifecycleCallbackAdapter{
+		userID:   userID,
+		strategy: u.shardingStrategy,
+		logger:   userLogger,
+	}
+	if u.warmer != nil {
+		lifecycleCallback = &warmupBlockLifecycleCallback{BlockLifecycleCallback: lifecycleCallback, userID: userID, dir: u.syncDirForUser(userID), bkt: userBkt, warmer: u.warmer}
+	}
+	bucketStoreOpts = append(bucketStoreOpts, store.WithBlockLifecycleCallback(lifecycleCallback))
 	if u.logLevel.String() == "debug" {
 		bucketStoreOpts = append(bucketStoreOpts, store.WithDebugLogging())
 	}
@@ -131,6 +131,9 @@ type StoreGateway struct {
 
 	resourceBasedLimiter *util_limiter.ResourceBasedLimiter
 
+	// Daily budget of object storage GET requests of each tenant.
+	getRequestsBudget *getRequestsBudget
+
 	bucketSync *prometheus.CounterVec
 }
 
@@ -238,6 +241,10 @@ func newStoreGateway(gatewayCfg Config, storageCfg cortex_tsdb.BlocksStorageConf
 		shardingStrategy = NewNoShardingStrategy(logger, allowedTenants)
 	}
 
+	// Track the GET requests run against the object storage for each tenant, except the ones served by the caching bucket.
+	g.getRequestsBudget = newGetRequestsBudget(limits, reg)
+	bucketClient = &getRequestsBudgetBucket{InstrumentedBucket: bucketClient, budget: g.getRequestsBudget}
+
 	g.stores, err = NewBucketStores(storageCfg, shardingStrategy, bucketClient, limits, logLevel, logger, extprom.WrapRegistererWith(prometheus.Labels{"component": "store-gateway"}, reg))
 	if err != nil {
 		return nil, errors.Wrap(err, "create bucket stores")
@@ -412,7 +419,10 @@ func (g *StoreGateway) Series(req *storepb.SeriesRequest, srv storegatewaypb.Sto
 	if err := g.checkResourceUtilization(); err != nil {
 		return err
 	}
-	return g.stores.Series(req, srv)
+	if err := g.checkGetRequestsBudget(srv.Context()); err != nil {
+		return err
+	}
+	return g.stores.Series(req, spanSeriesServer{Store_SeriesServer: srv, ctx: contextWithGetRequestsBudget(srv.Context())})
 }
 
 // LabelNames implements the Storegateway proto service.
@@ -420,7 +430,10 @@ func (g *StoreGateway) LabelNames(ctx context.Context, req *storepb.LabelNamesRe
 	if err := g.checkResourceUtilization(); err != nil {
 		return nil, err
 	}
-	return g.stores.LabelNames(ctx, req)
+	if err := g.checkGetRequestsBudget(ctx); err != nil {
+		return nil, err
+	}
+	return g.stores.LabelNames(contextWithGetRequestsBudget(ctx), req)
 }
 
 // LabelValues implements the Storegateway proto service.
@@ -428,7 +441,10 @@ func (g *StoreGateway) LabelValues(ctx context.Context, req *storepb.LabelValues
 	if err := g.checkResourceUtilization(); err != nil {
 		return nil, err
 	}
-	return g.stores.LabelValues(ctx, req)
+	if err := g.checkGetRequestsBudget(ctx); err != nil {
+		return nil, err
+	}
+	return g.stores.LabelValues(contextWithGetRequestsBudget(ctx), req)
 }
 
 func (g *StoreGateway) checkResourceUtilization() error {
@@ -444,6 +460,21 @@ func (g *StoreGateway) checkResourceUtilization() error {
 	return nil
 }
 
+// checkGetRequestsBudget returns a limit error if the tenant of the request has exceeded
+// its daily budget of object storage GET requests.
+func (g *StoreGateway) checkGetRequestsBudget(ctx context.Context) error {
+	userID := getUserIDFromGRPCContext(ctx)
+	if userID == "" {
+		return nil
+	}
+
+	if err := g.getRequestsBudget.check(userID); err != nil {
+		level.Warn(g.logger).Log("msg", "rejected request because the tenant exceeded the daily budget of object storage GET requests", "user", userID)
+		return err
+	}
+	return nil
+}
+
 func (g *StoreGateway) OnRingInstanceRegister(lc *ring.BasicLifecycler, ringDesc ring.Desc, instanceExists bool, instanceID string, instanceDesc ring.InstanceDesc) (ring.InstanceState, ring.Tokens) {
 	// When we initialize the store-gateway instance in the ring we want to start from
 	// a clean situation, so whatever is the state we set it JOINING, while we keep existing
@@ -467,7 +498,7 @@ func (g *StoreGateway) OnRingInstanceHeartbeat(_ *ring.BasicLifecycler, _ *ring.
 }
 
 func createBucketClient(cfg cortex_tsdb.BlocksStorageConfig, hedgedRoundTripper func(rt http.RoundTripper) http.RoundTripper, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
-	bucketClient, err := bucket.NewClient(context.Background(), cfg.Bucket, hedgedRoundTripper, "store-gateway", logger, reg)
+	bucketClient, err := cortex_tsdb.NewBucketClient(context.Background(), cfg, hedgedRoundTripper, "store-gateway", logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "create bucket client")
 	}
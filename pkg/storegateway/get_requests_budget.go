@@ -0,0 +1,184 @@
+package storegateway
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+const errGetRequestsBudgetExceeded = "the daily budget of object storage GET requests of the tenant has been exceeded (limit: %d)"
+
+// GetRequestsBudgetLimits is the per-tenant limits used by the getRequestsBudget.
+type GetRequestsBudgetLimits interface {
+	MaxBucketGetRequestsPerDay(userID string) int64
+}
+
+type getRequestsBudgetContextKey struct{}
+
+// contextWithGetRequestsBudget returns a context whose GET requests are charged to the budget of the
+// tenant they're run on behalf of. The GET requests run by background operations, like the blocks
+// sync, don't use such a context and so are not charged.
+func contextWithGetRequestsBudget(ctx context.Context) context.Context {
+	return context.WithValue(ctx, getRequestsBudgetContextKey{}, true)
+}
+
+func isGetRequestsBudgetCharged(ctx context.Context) bool {
+	charged, _ := ctx.Value(getRequestsBudgetContextKey{}).(bool)
+	return charged
+}
+
+// getRequestsBudget tracks the GET requests run against the object storage on behalf of each
+// tenant during the current day (UTC), in order to enforce the tenants daily budget. The budget
+// is tracked in memory by each store-gateway, so it applies per replica and is reset on restart.
+type getRequestsBudget struct {
+	limits GetRequestsBudgetLimits
+	now    func() time.Time
+
+	mtx      sync.RWMutex
+	day      int64
+	requests map[string]*atomic.Int64
+
+	rejected prometheus.Counter
+}
+
+func newGetRequestsBudget(limits GetRequestsBudgetLimits, reg prometheus.Registerer) *getRequestsBudget {
+	return &getRequestsBudget{
+		limits:   limits,
+		now:      time.Now,
+		requests: map[string]*atomic.Int64{},
+		rejected: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_storegateway_get_requests_budget_rejected_requests_total",
+			Help: "Total number of requests and object storage GET requests rejected because the tenant exceeded the daily budget of object storage GET requests.",
+		}),
+	}
+}
+
+func (b *getRequestsBudget) today() int64 {
+	return b.now().UTC().Unix() / int64((24 * time.Hour).Seconds())
+}
+
+// add tracks a GET request run on behalf of the tenant.
+func (b *getRequestsBudget) add(userID string) {
+	day := b.today()
+
+	b.mtx.RLock()
+	if b.day == day {
+		if count, ok := b.requests[userID]; ok {
+			count.Add(1)
+			b.mtx.RUnlock()
+			return
+		}
+	}
+	b.mtx.RUnlock()
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	// A new day starts with a fresh budget.
+	if b.day != day {
+		b.day = day
+		b.requests = map[string]*atomic.Int64{}
+	}
+	count, ok := b.requests[userID]
+	if !ok {
+		count = &atomic.Int64{}
+		b.requests[userID] = count
+	}
+	count.Add(1)
+}
+
+// count returns the number of GET requests run on behalf of the tenant during the current day.
+func (b *getRequestsBudget) count(userID string) int64 {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	if b.day != b.today() {
+		return 0
+	}
+	if count, ok := b.requests[userID]; ok {
+		return count.Load()
+	}
+	return 0
+}
+
+// check returns a limit error if the tenant has exceeded its daily budget.
+func (b *getRequestsBudget) check(userID string) error {
+	limit := b.limits.MaxBucketGetRequestsPerDay(userID)
+	if limit <= 0 {
+		return nil
+	}
+	if b.count(userID) < limit {
+		return nil
+	}
+
+	b.rejected.Inc()
+	return status.Errorf(codes.ResourceExhausted, errGetRequestsBudgetExceeded, limit)
+}
+
+// charge tracks a GET request run on behalf of the tenant, unless the tenant has exceeded its
+// daily budget, in which case a limit error is returned and the request must not be run.
+func (b *getRequestsBudget) charge(userID string) error {
+	if err := b.check(userID); err != nil {
+		return err
+	}
+	b.add(userID)
+	return nil
+}
+
+// getRequestsBudgetBucket is a wrapper around a objstore.InstrumentedBucket which charges the
+// GET requests run on behalf of each tenant to the budget as they are issued, and rejects them
+// once the tenant has exceeded its budget.
+type getRequestsBudgetBucket struct {
+	objstore.InstrumentedBucket
+
+	budget *getRequestsBudget
+}
+
+func (b *getRequestsBudgetBucket) charge(ctx context.Context) error {
+	if !isGetRequestsBudgetCharged(ctx) {
+		return nil
+	}
+	if userID, ok := bucket.TenantFromContext(ctx); ok {
+		return b.budget.charge(userID)
+	}
+	return nil
+}
+
+func (b *getRequestsBudgetBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := b.charge(ctx); err != nil {
+		return nil, err
+	}
+	return b.InstrumentedBucket.Get(ctx, name)
+}
+
+func (b *getRequestsBudgetBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if err := b.charge(ctx); err != nil {
+		return nil, err
+	}
+	return b.InstrumentedBucket.GetRange(ctx, name, off, length)
+}
+
+func (b *getRequestsBudgetBucket) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+func (b *getRequestsBudgetBucket) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := b.InstrumentedBucket.WithExpectedErrs(fn).(objstore.InstrumentedBucket); ok {
+		return &getRequestsBudgetBucket{
+			InstrumentedBucket: ib,
+			budget:             b.budget,
+		}
+	}
+
+	return b
+}
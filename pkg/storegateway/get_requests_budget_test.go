@@ -0,0 +1,117 @@
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+)
+
+type getRequestsBudgetLimitsMock map[string]int64
+
+func (m getRequestsBudgetLimitsMock) MaxBucketGetRequestsPerDay(userID string) int64 {
+	return m[userID]
+}
+
+func TestGetRequestsBudget(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	budget := newGetRequestsBudget(getRequestsBudgetLimitsMock{"user-1": 2}, prometheus.NewPedanticRegistry())
+	budget.now = func() time.Time { return now }
+
+	// Tenants without a limit are never rejected.
+	for i := 0; i < 5; i++ {
+		budget.add("user-2")
+	}
+	assert.Equal(t, int64(5), budget.count("user-2"))
+	require.NoError(t, budget.check("user-2"))
+
+	budget.add("user-1")
+	require.NoError(t, budget.check("user-1"))
+	budget.add("user-1")
+
+	err := budget.check("user-1")
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, float64(1), prom_testutil.ToFloat64(budget.rejected))
+
+	// The budget is reset when a new day starts.
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, int64(0), budget.count("user-1"))
+	require.NoError(t, budget.check("user-1"))
+
+	budget.add("user-1")
+	assert.Equal(t, int64(1), budget.count("user-1"))
+	assert.Equal(t, int64(0), budget.count("user-2"))
+}
+
+func TestGetRequestsBudgetBucket(t *testing.T) {
+	ctx := contextWithGetRequestsBudget(context.Background())
+	budget := newGetRequestsBudget(getRequestsBudgetLimitsMock{}, nil)
+	bkt := &getRequestsBudgetBucket{
+		InstrumentedBucket: objstore.WithNoopInstr(objstore.NewInMemBucket()),
+		budget:             budget,
+	}
+	require.NoError(t, bkt.Upload(ctx, "user-1/object", bytes.NewReader([]byte("content"))))
+
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+	_, err := userBkt.Get(ctx, "object")
+	require.NoError(t, err)
+	_, err = userBkt.GetRange(ctx, "object", 0, 1)
+	require.NoError(t, err)
+	_, err = userBkt.Exists(ctx, "object")
+	require.NoError(t, err)
+
+	// The requests run through a bucket with expected errors are tracked too.
+	_, err = userBkt.ReaderWithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, "object")
+	require.NoError(t, err)
+
+	// The requests not run on behalf of a tenant are not tracked.
+	_, err = bkt.Get(ctx, "user-1/object")
+	require.NoError(t, err)
+
+	// The requests run by background operations, like the blocks sync, are not tracked.
+	_, err = userBkt.Get(context.Background(), "object")
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), budget.count("user-1"))
+}
+
+func TestGetRequestsBudgetBucket_ShouldRejectRequestsOnceBudgetExceeded(t *testing.T) {
+	ctx := contextWithGetRequestsBudget(context.Background())
+	budget := newGetRequestsBudget(getRequestsBudgetLimitsMock{"user-1": 2}, prometheus.NewPedanticRegistry())
+	bkt := &getRequestsBudgetBucket{
+		InstrumentedBucket: objstore.WithNoopInstr(objstore.NewInMemBucket()),
+		budget:             budget,
+	}
+	require.NoError(t, bkt.Upload(ctx, "user-1/object", bytes.NewReader([]byte("content"))))
+
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+	_, err := userBkt.Get(ctx, "object")
+	require.NoError(t, err)
+	_, err = userBkt.GetRange(ctx, "object", 0, 1)
+	require.NoError(t, err)
+
+	// The requests issued once the budget is exceeded are rejected and not tracked.
+	_, err = userBkt.Get(ctx, "object")
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = userBkt.GetRange(ctx, "object", 0, 1)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, int64(2), budget.count("user-1"))
+	assert.Equal(t, float64(2), prom_testutil.ToFloat64(budget.rejected))
+
+	// Background operations are not affected by the budget.
+	_, err = userBkt.Get(context.Background(), "object")
+	require.NoError(t, err)
+}
@@ -0,0 +1,468 @@
+package storegateway
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/indexheader"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+)
+
+const (
+	// querySamplesFilename is the name of the file, in the sync directory, where the
+	// query samples log is persisted.
+	querySamplesFilename = "query-samples.json"
+
+	// querySampleLogSlots is the number of time slots the query samples log window is split into.
+	querySampleLogSlots = 24
+
+	// querySampleLogNamesPerTopMetric is the number of metric names tracked per tenant, for each
+	// top metric name warmed up.
+	querySampleLogNamesPerTopMetric = 10
+)
+
+// querySampleLog is a rolling log of the metric names queried by each tenant.
+type querySampleLog struct {
+	window       time.Duration
+	slotDuration time.Duration
+	maxNames     int
+
+	mtx sync.Mutex
+	// Number of series requests per tenant, metric name and time slot.
+	counts map[string]map[string]map[int64]int64
+}
+
+// newQuerySampleLog makes a new querySampleLog tracking up to maxNames metric names per tenant.
+func newQuerySampleLog(window time.Duration, maxNames int) *querySampleLog {
+	return &querySampleLog{
+		window:       window,
+		slotDuration: max(window/querySampleLogSlots, time.Second),
+		maxNames:     maxNames,
+		counts:       map[string]map[string]map[int64]int64{},
+	}
+}
+
+func (l *querySampleLog) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(l.slotDuration)
+}
+
+// add records the metric names selected by the equality matchers of a series request. Once the
+// tenant reaches the max number of tracked metric names, the least queried one is evicted to
+// make room for a new one.
+func (l *querySampleLog) add(userID string, matchers []storepb.LabelMatcher, now time.Time) {
+	slot := l.slot(now)
+
+	for _, m := range matchers {
+		if m.Name != labels.MetricName || m.Type != storepb.LabelMatcher_EQ || m.Value == "" {
+			continue
+		}
+
+		l.mtx.Lock()
+		metrics, ok := l.counts[userID]
+		if !ok {
+			metrics = map[string]map[int64]int64{}
+			l.counts[userID] = metrics
+		}
+		slots, ok := metrics[m.Value]
+		if !ok {
+			if len(metrics) >= l.maxNames {
+				evictLeastQueried(metrics, len(metrics)-l.maxNames+1)
+			}
+			slots = map[int64]int64{}
+			metrics[m.Value] = slots
+		}
+		slots[slot]++
+		l.mtx.Unlock()
+	}
+}
+
+// topMetricNames returns up to n metric names most queried by the tenant within the window.
+func (l *querySampleLog) topMetricNames(userID string, n int, now time.Time) []string {
+	type metricCount struct {
+		name  string
+		count int64
+	}
+
+	minSlot := l.slot(now.Add(-l.window))
+
+	l.mtx.Lock()
+	metrics := make([]metricCount, 0, len(l.counts[userID]))
+	for name, slots := range l.counts[userID] {
+		count := int64(0)
+		for slot, c := range slots {
+			if slot >= minSlot {
+				count += c
+			}
+		}
+		if count > 0 {
+			metrics = append(metrics, metricCount{name: name, count: count})
+		}
+	}
+	l.mtx.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].count != metrics[j].count {
+			return metrics[i].count > metrics[j].count
+		}
+		return metrics[i].name < metrics[j].name
+	})
+
+	names := make([]string, 0, min(n, len(metrics)))
+	for i := 0; i < len(metrics) && i < n; i++ {
+		names = append(names, metrics[i].name)
+	}
+	return names
+}
+
+// prune removes the samples which are out of the window.
+func (l *querySampleLog) prune(now time.Time) {
+	minSlot := l.slot(now.Add(-l.window))
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	for userID, metrics := range l.counts {
+		for name, slots := range metrics {
+			for slot := range slots {
+				if slot < minSlot {
+					delete(slots, slot)
+				}
+			}
+			if len(slots) == 0 {
+				delete(metrics, name)
+			}
+		}
+		if len(metrics) == 0 {
+			delete(l.counts, userID)
+		}
+	}
+}
+
+// save persists the log to the file at path.
+func (l *querySampleLog) save(path string) error {
+	l.mtx.Lock()
+	data, err := json.Marshal(l.counts)
+	l.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a crash never leaves a partially written log.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// load replaces the log content with the one persisted to the file at path.
+func (l *querySampleLog) load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]map[string]map[int64]int64{}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return err
+	}
+
+	for _, metrics := range counts {
+		if len(metrics) > l.maxNames {
+			evictLeastQueried(metrics, len(metrics)-l.maxNames)
+		}
+	}
+
+	l.mtx.Lock()
+	l.counts = counts
+	l.mtx.Unlock()
+	return nil
+}
+
+// evictLeastQueried removes the n metric names with the lowest number of series requests.
+func evictLeastQueried(metrics map[string]map[int64]int64, n int) {
+	type metricCount struct {
+		name  string
+		count int64
+	}
+
+	counts := make([]metricCount, 0, len(metrics))
+	for name, slots := range metrics {
+		count := int64(0)
+		for _, c := range slots {
+			count += c
+		}
+		counts = append(counts, metricCount{name: name, count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count < counts[j].count
+		}
+		return counts[i].name > counts[j].name
+	})
+
+	for i := 0; i < n && i < len(counts); i++ {
+		delete(metrics, counts[i].name)
+	}
+}
+
+// indexHeaderWarmer preloads the index-headers, and optionally the postings of the most queried
+// metric names, of the blocks newly owned by the bucket stores, so that the first queries hitting
+// them don't have to load them. The index-headers are built and loaded before the blocks are
+// added to the bucket stores, while the postings are preloaded once the blocks have been added.
+type indexHeaderWarmer struct {
+	cfg                         tsdb.IndexHeaderWarmupConfig
+	concurrency                 int
+	postingOffsetsInMemSampling int
+	logger                      log.Logger
+	querySamples                *querySampleLog
+	querySamplesPath            string
+	binaryReaderMetrics         *indexheader.BinaryReaderMetrics
+
+	// Blocks added to the bucket stores whose postings are not warmed up yet, by tenant.
+	pendingMtx sync.Mutex
+	pending    map[string][]ulid.ULID
+
+	warmedBlocks prometheus.Counter
+	failures     prometheus.Counter
+	duration     prometheus.Histogram
+}
+
+func newIndexHeaderWarmer(cfg tsdb.BucketStoreConfig, logger log.Logger, reg prometheus.Registerer) *indexHeaderWarmer {
+	w := &indexHeaderWarmer{
+		cfg:                         cfg.IndexHeaderWarmup,
+		concurrency:                 cfg.TenantSyncConcurrency,
+		postingOffsetsInMemSampling: cfg.PostingOffsetsInMemSampling,
+		logger:                      logger,
+		binaryReaderMetrics:         indexheader.NewBinaryReaderMetrics(nil),
+		pending:                     map[string][]ulid.ULID{},
+		warmedBlocks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_index_header_warmup_blocks_total",
+			Help: "Total number of blocks whose index-header has been warmed up.",
+		}),
+		failures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_index_header_warmup_failures_total",
+			Help: "Total number of failed warmups of the index-header of a block or of the postings of a tenant's blocks.",
+		}),
+		duration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_bucket_stores_index_header_warmup_duration_seconds",
+			Help:    "The time it takes to warm up the blocks newly loaded by the bucket stores.",
+			Buckets: []float64{0.1, 1, 10, 30, 60, 120, 300, 600},
+		}),
+	}
+
+	if w.cfg.TopMetricNames > 0 {
+		w.querySamples = newQuerySampleLog(w.cfg.QuerySamplesWindow, querySampleLogNamesPerTopMetric*w.cfg.TopMetricNames)
+		w.querySamplesPath = filepath.Join(cfg.SyncDir, querySamplesFilename)
+
+		if err := w.querySamples.load(w.querySamplesPath); err != nil && !os.IsNotExist(err) {
+			level.Warn(logger).Log("msg", "failed to load the query samples log", "path", w.querySamplesPath, "err", err)
+		}
+	}
+
+	return w
+}
+
+// blockAdded enqueues the block for the next warmup.
+func (w *indexHeaderWarmer) blockAdded(userID string, blockID ulid.ULID) {
+	w.pendingMtx.Lock()
+	defer w.pendingMtx.Unlock()
+
+	w.pending[userID] = append(w.pending[userID], blockID)
+}
+
+// warmupIndexHeader builds the index-header of the block in the dir, and loads it once, so that
+// it's in the page cache when the block is added to the bucket store.
+func (w *indexHeaderWarmer) warmupIndexHeader(userID string, bkt objstore.BucketReader, dir string, blockID ulid.ULID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+	defer cancel()
+
+	r, err := indexheader.NewBinaryReader(ctx, w.logger, bkt, dir, blockID, w.postingOffsetsInMemSampling, w.binaryReaderMetrics)
+	if err != nil {
+		w.failures.Inc()
+		return err
+	}
+	w.warmedBlocks.Inc()
+	return r.Close()
+}
+
+// observeSeriesRequest records the metric names queried by the request in the query samples log.
+func (w *indexHeaderWarmer) observeSeriesRequest(userID string, req *storepb.SeriesRequest) {
+	if w.querySamples == nil {
+		return
+	}
+	w.querySamples.add(userID, req.Matchers, time.Now())
+}
+
+// warmup warms up the blocks added since the previous warmup. The blocks which can't be warmed
+// up within the timeout are skipped.
+func (w *indexHeaderWarmer) warmup(ctx context.Context, getStore func(userID string) *store.BucketStore) {
+	w.pendingMtx.Lock()
+	pending := w.pending
+	w.pending = map[string][]ulid.ULID{}
+	w.pendingMtx.Unlock()
+
+	if len(pending) > 0 {
+		start := time.Now()
+		level.Info(w.logger).Log("msg", "warming up newly loaded blocks", "users", len(pending))
+
+		userIDs := make([]string, 0, len(pending))
+		for userID := range pending {
+			userIDs = append(userIDs, userID)
+		}
+
+		warmupCtx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+		_ = concurrency.ForEachUser(warmupCtx, userIDs, w.concurrency, func(ctx context.Context, userID string) error {
+			bs := getStore(userID)
+			if bs == nil {
+				return nil
+			}
+
+			if err := w.warmupUser(ctx, userID, bs, pending[userID]); err != nil {
+				w.failures.Inc()
+				level.Warn(w.logger).Log("msg", "failed to warm up newly loaded blocks", "user", userID, "err", err)
+			}
+			return nil
+		})
+		cancel()
+
+		w.duration.Observe(time.Since(start).Seconds())
+		level.Info(w.logger).Log("msg", "warmed up newly loaded blocks", "users", len(pending), "duration", time.Since(start))
+	}
+
+	if w.querySamples != nil {
+		w.querySamples.prune(time.Now())
+		if err := w.querySamples.save(w.querySamplesPath); err != nil {
+			level.Warn(w.logger).Log("msg", "failed to persist the query samples log", "path", w.querySamplesPath, "err", err)
+		}
+	}
+}
+
+func (w *indexHeaderWarmer) warmupUser(ctx context.Context, userID string, bs *store.BucketStore, blockIDs []ulid.ULID) error {
+	// Selectively query only the newly loaded blocks.
+	blockMatchers := []storepb.LabelMatcher{
+		{
+			Type:  storepb.LabelMatcher_RE,
+			Name:  block.BlockIDLabel,
+			Value: strings.Join(convertULIDsToString(blockIDs), "|"),
+		},
+	}
+
+	// The label names are read from the index-header, so the request loads
+	// the index-header of each block it touches.
+	labelNamesHints, err := types.MarshalAny(&hintspb.LabelNamesRequestHints{BlockMatchers: blockMatchers})
+	if err != nil {
+		return errors.Wrap(err, "marshal label names request hints")
+	}
+	if _, err := bs.LabelNames(ctx, &storepb.LabelNamesRequest{Start: math.MinInt64, End: math.MaxInt64, Hints: labelNamesHints}); err != nil {
+		return errors.Wrap(err, "preload index-headers")
+	}
+
+	if w.querySamples == nil {
+		return nil
+	}
+
+	seriesHints, err := types.MarshalAny(&hintspb.SeriesRequestHints{BlockMatchers: blockMatchers})
+	if err != nil {
+		return errors.Wrap(err, "marshal series request hints")
+	}
+
+	// Fetching the series without chunks loads the postings of the metric name in the index cache.
+	for _, metricName := range w.querySamples.topMetricNames(userID, w.cfg.TopMetricNames, time.Now()) {
+		req := &storepb.SeriesRequest{
+			MinTime:    math.MinInt64,
+			MaxTime:    math.MaxInt64,
+			Matchers:   []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: metricName}},
+			SkipChunks: true,
+			Hints:      seriesHints,
+		}
+		if err := bs.Series(req, &discardSeriesServer{ctx: ctx}); err != nil {
+			return errors.Wrapf(err, "preload postings of metric %s", metricName)
+		}
+	}
+
+	return nil
+}
+
+// warmupBlockLifecycleCallback warms up the index-header of the blocks newly owned by a bucket
+// store before they're added, and enqueues them for the warmup of their postings.
+type warmupBlockLifecycleCallback struct {
+	store.BlockLifecycleCallback
+
+	userID string
+	dir    string
+	bkt    objstore.BucketReader
+	warmer *indexHeaderWarmer
+}
+
+func (c *warmupBlockLifecycleCallback) PreAdd(meta metadata.Meta) error {
+	if err := c.BlockLifecycleCallback.PreAdd(meta); err != nil {
+		return err
+	}
+
+	// The index-header of the blocks owned before, e.g. before a restart, is already on disk and
+	// isn't built again. The block is added even if the warmup failed, as it would be if the
+	// warmup was disabled.
+	if _, err := os.Stat(filepath.Join(c.dir, meta.ULID.String(), block.IndexHeaderFilename)); err != nil {
+		if err := c.warmer.warmupIndexHeader(c.userID, c.bkt, c.dir, meta.ULID); err != nil {
+			level.Warn(c.warmer.logger).Log("msg", "failed to warm up the index-header of newly owned block", "user", c.userID, "block", meta.ULID.String(), "err", err)
+		}
+	}
+
+	// The index-header is loaded back in memory, and the postings are preloaded, even after a
+	// restart, because the lazy loaded index-headers are not kept in memory across restarts.
+	c.warmer.blockAdded(c.userID, meta.ULID)
+	return nil
+}
+
+// discardSeriesServer is a fake in-memory gRPC server discarding the series it receives.
+type discardSeriesServer struct {
+	// This field just exist to pseudo-implement the unused methods of the interface.
+	storepb.Store_SeriesServer
+
+	ctx context.Context
+}
+
+func (s *discardSeriesServer) Send(*storepb.SeriesResponse) error {
+	return nil
+}
+
+func (s *discardSeriesServer) Context() context.Context {
+	return s.ctx
+}
+
+func convertULIDsToString(ids []ulid.ULID) []string {
+	res := make([]string, len(ids))
+	for i, id := range ids {
+		res[i] = id.String()
+	}
+	return res
+}
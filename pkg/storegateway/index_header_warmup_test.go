@@ -0,0 +1,177 @@
+package storegateway
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket/filesystem"
+)
+
+func TestQuerySampleLog(t *testing.T) {
+	now := time.Now()
+	l := newQuerySampleLog(time.Hour, 10)
+
+	metricMatcher := func(name string) []storepb.LabelMatcher {
+		return []storepb.LabelMatcher{
+			{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: name},
+			{Type: storepb.LabelMatcher_EQ, Name: "job", Value: "test"},
+		}
+	}
+
+	for range 3 {
+		l.add("user-1", metricMatcher("series_1"), now)
+	}
+	for range 2 {
+		l.add("user-1", metricMatcher("series_2"), now)
+	}
+	l.add("user-1", metricMatcher("series_3"), now)
+	l.add("user-2", metricMatcher("series_4"), now)
+
+	// Matchers other than metric name equality are not recorded.
+	l.add("user-1", []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_.*"}}, now)
+
+	// Samples out of the window are ignored.
+	for range 10 {
+		l.add("user-1", metricMatcher("series_old"), now.Add(-2*time.Hour))
+	}
+
+	assert.Equal(t, []string{"series_1", "series_2"}, l.topMetricNames("user-1", 2, now))
+	assert.Equal(t, []string{"series_1", "series_2", "series_3"}, l.topMetricNames("user-1", 10, now))
+	assert.Equal(t, []string{"series_4"}, l.topMetricNames("user-2", 10, now))
+	assert.Empty(t, l.topMetricNames("user-3", 10, now))
+
+	l.prune(now)
+	assert.NotContains(t, l.counts["user-1"], "series_old")
+
+	// The log survives a save and load.
+	path := filepath.Join(t.TempDir(), querySamplesFilename)
+	require.NoError(t, l.save(path))
+
+	loaded := newQuerySampleLog(time.Hour, 10)
+	require.NoError(t, loaded.load(path))
+	assert.Equal(t, l.counts, loaded.counts)
+	assert.Equal(t, []string{"series_1", "series_2"}, loaded.topMetricNames("user-1", 2, now))
+
+	// The metric names in excess are evicted when loading the log with a lower limit.
+	capped := newQuerySampleLog(time.Hour, 2)
+	require.NoError(t, capped.load(path))
+	assert.Len(t, capped.counts["user-1"], 2)
+	assert.Equal(t, []string{"series_1", "series_2"}, capped.topMetricNames("user-1", 10, now))
+}
+
+func TestQuerySampleLog_ShouldEvictLeastQueriedMetricNames(t *testing.T) {
+	now := time.Now()
+	l := newQuerySampleLog(time.Hour, 2)
+
+	add := func(name string, times int) {
+		for range times {
+			l.add("user-1", []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: name}}, now)
+		}
+	}
+
+	add("series_1", 3)
+	add("series_2", 1)
+	add("series_3", 2)
+	assert.Len(t, l.counts["user-1"], 2)
+	assert.Equal(t, []string{"series_1", "series_3"}, l.topMetricNames("user-1", 10, now))
+
+	// The names already tracked are not evicted when queried again.
+	add("series_3", 5)
+	assert.Equal(t, []string{"series_3", "series_1"}, l.topMetricNames("user-1", 10, now))
+}
+
+func TestBucketStores_IndexHeaderWarmup(t *testing.T) {
+	t.Parallel()
+
+	userToMetric := map[string]string{
+		"user-1": "series_1",
+		"user-2": "series_2",
+	}
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.IndexHeaderLazyLoadingEnabled = true
+	cfg.BucketStore.IndexHeaderWarmup.Enabled = true
+	cfg.BucketStore.IndexHeaderWarmup.TopMetricNames = 1
+
+	storageDir := t.TempDir()
+	for userID, metricName := range userToMetric {
+		generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	}
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	// Query series before the initial sync, in order to record them in the query samples log.
+	for userID, metricName := range userToMetric {
+		_, _, err := querySeries(stores, userID, metricName, 20, 40)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// The index-headers have been loaded by the warmup, before any query.
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_indexheader_lazy_load_total Total number of index-header lazy load operations.
+		# TYPE cortex_bucket_store_indexheader_lazy_load_total counter
+		cortex_bucket_store_indexheader_lazy_load_total 2
+
+		# HELP cortex_bucket_stores_index_header_warmup_blocks_total Total number of blocks whose index-header has been warmed up.
+		# TYPE cortex_bucket_stores_index_header_warmup_blocks_total counter
+		cortex_bucket_stores_index_header_warmup_blocks_total 2
+
+		# HELP cortex_bucket_stores_index_header_warmup_failures_total Total number of failed warmups of the index-header of a block or of the postings of a tenant's blocks.
+		# TYPE cortex_bucket_stores_index_header_warmup_failures_total counter
+		cortex_bucket_stores_index_header_warmup_failures_total 0
+	`),
+		"cortex_bucket_store_indexheader_lazy_load_total",
+		"cortex_bucket_stores_index_header_warmup_blocks_total",
+		"cortex_bucket_stores_index_header_warmup_failures_total",
+	))
+
+	// The blocks already warmed up are not warmed up again.
+	require.NoError(t, stores.SyncBlocks(ctx))
+	assert.Equal(t, float64(2), testutil.ToFloat64(stores.(*ThanosBucketStores).warmer.warmedBlocks))
+
+	// The index-headers already on disk are not built again after a restart, but they're
+	// still loaded by the warmup, before any query.
+	reg = prometheus.NewPedanticRegistry()
+	restarted, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, restarted.InitialSync(ctx))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_indexheader_lazy_load_total Total number of index-header lazy load operations.
+		# TYPE cortex_bucket_store_indexheader_lazy_load_total counter
+		cortex_bucket_store_indexheader_lazy_load_total 2
+
+		# HELP cortex_bucket_stores_index_header_warmup_blocks_total Total number of blocks whose index-header has been warmed up.
+		# TYPE cortex_bucket_stores_index_header_warmup_blocks_total counter
+		cortex_bucket_stores_index_header_warmup_blocks_total 0
+	`),
+		"cortex_bucket_store_indexheader_lazy_load_total",
+		"cortex_bucket_stores_index_header_warmup_blocks_total",
+	))
+
+	// The query samples log has been persisted in the sync directory.
+	loaded := newQuerySampleLog(cfg.BucketStore.IndexHeaderWarmup.QuerySamplesWindow, 10)
+	require.NoError(t, loaded.load(filepath.Join(cfg.BucketStore.SyncDir, querySamplesFilename)))
+	for userID, metricName := range userToMetric {
+		assert.Equal(t, []string{metricName}, loaded.topMetricNames(userID, 1, time.Now()))
+	}
+}
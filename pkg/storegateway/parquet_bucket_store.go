@@ -127,9 +127,12 @@ func (p *parquetBucketStore) Series(req *storepb.SeriesRequest, seriesSrv storep
 		return err
 	}
 
+	compactChunks := prom_storage.NewCompactingChunkSeriesMerger(prom_storage.ChainedSeriesMerge)
 	ss := convert.NewMergeChunkSeriesSet(seriesSet, labels.Compare, prom_storage.NewConcatenatingChunkSeriesMerger())
 	for ss.Next() {
-		cs := ss.At()
+		// The chunks of a series overlap when the queried blocks (e.g. out-of-order ones) overlap in time, and
+		// they're not sorted by time when the series mixes float and native histogram samples, so we compact them.
+		cs := compactChunks(ss.At())
 		cIter := cs.Iterator(nil)
 		chunks := make([]storepb.AggrChunk, 0)
 		for cIter.Next() {
@@ -7,15 +7,19 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
 	"github.com/prometheus-community/parquet-common/convert"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/promslog"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
@@ -384,3 +388,190 @@ func convertToParquetBlocksForTesting(userPath string, userBkt objstore.Instrume
 	}
 	return blockIDs, nil
 }
+
+func TestParquetBucketStores_Series_ShouldReturnTheSameSamplesAsTSDBBucketStores(t *testing.T) {
+	const userID = "user-1"
+
+	appendFloats := func(from, to, step int64) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for ts := from; ts < to; ts += step {
+				_, err := app.Append(0, lbls, ts, float64(ts))
+				require.NoError(t, err)
+			}
+		}
+	}
+	appendHistograms := func(from, to, step int64) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for ts := from; ts < to; ts += step {
+				_, err := app.AppendHistogram(0, lbls, ts, tsdbutil.GenerateTestHistogram(ts), nil)
+				require.NoError(t, err)
+			}
+		}
+	}
+	appendFloatHistograms := func(from, to, step int64) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for ts := from; ts < to; ts += step {
+				_, err := app.AppendHistogram(0, lbls, ts, nil, tsdbutil.GenerateTestFloatHistogram(ts))
+				require.NoError(t, err)
+			}
+		}
+	}
+	appendAll := func(fns ...func(*testing.T, storage.Appender, labels.Labels)) func(*testing.T, storage.Appender, labels.Labels) {
+		return func(t *testing.T, app storage.Appender, lbls labels.Labels) {
+			for _, fn := range fns {
+				fn(t, app, lbls)
+			}
+		}
+	}
+
+	tests := map[string]struct {
+		appendInOrder    func(*testing.T, storage.Appender, labels.Labels)
+		appendOutOfOrder func(*testing.T, storage.Appender, labels.Labels)
+	}{
+		"float samples": {
+			appendInOrder: appendFloats(0, 1000, 10),
+		},
+		"native histograms": {
+			appendInOrder: appendHistograms(0, 1000, 10),
+		},
+		"float native histograms": {
+			appendInOrder: appendFloatHistograms(0, 1000, 10),
+		},
+		"series switching between float and native histogram samples": {
+			appendInOrder: appendAll(appendFloats(0, 300, 10), appendHistograms(300, 600, 10), appendFloats(600, 800, 10), appendFloatHistograms(800, 1000, 10)),
+		},
+		"float samples with an overlapping out-of-order block": {
+			appendInOrder:    appendFloats(0, 1000, 10),
+			appendOutOfOrder: appendFloats(5, 1000, 10),
+		},
+		"native histograms with an overlapping out-of-order block": {
+			appendInOrder:    appendHistograms(0, 1000, 10),
+			appendOutOfOrder: appendHistograms(5, 1000, 10),
+		},
+		"float samples with an overlapping out-of-order block of native histograms": {
+			appendInOrder:    appendFloats(0, 1000, 10),
+			appendOutOfOrder: appendFloatHistograms(5, 1000, 10),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			storageDir := t.TempDir()
+			generateStorageBlocksWithOutOfOrder(t, storageDir, userID, "series_1", testData.appendInOrder, testData.appendOutOfOrder)
+
+			bkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+			require.NoError(t, err)
+			uBucket := bucket.NewUserBucketClient(userID, bkt, validation.NewOverrides(validation.Limits{}, nil))
+			blockIDs, err := convertToParquetBlocksForTesting(filepath.Join(storageDir, userID), uBucket)
+			require.NoError(t, err)
+			if testData.appendOutOfOrder != nil {
+				require.Len(t, blockIDs, 2)
+			}
+
+			newStores := func(storeType cortex_tsdb.BucketStoreType) BucketStores {
+				cfg := prepareStorageConfig(t)
+				cfg.BucketStore.BucketStoreType = string(storeType)
+				stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bkt), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), prometheus.NewPedanticRegistry())
+				require.NoError(t, err)
+				require.NoError(t, stores.InitialSync(context.Background()))
+				return stores
+			}
+
+			tsdbSeries, _, err := querySeries(newStores(cortex_tsdb.TSDBBucketStore), userID, "series_1", 0, 1000, blockIDs...)
+			require.NoError(t, err)
+			parquetSeries, _, err := querySeries(newStores(cortex_tsdb.ParquetBucketStore), userID, "series_1", 0, 1000, blockIDs...)
+			require.NoError(t, err)
+
+			require.Len(t, tsdbSeries, 1)
+			require.Len(t, parquetSeries, 1)
+			assert.Equal(t, tsdbSeries[0].Labels, parquetSeries[0].Labels)
+
+			// The parquet chunks are sorted and don't overlap, so they can be read as they are.
+			for i := 1; i < len(parquetSeries[0].Chunks); i++ {
+				assert.Greater(t, parquetSeries[0].Chunks[i].MinTime, parquetSeries[0].Chunks[i-1].MaxTime)
+			}
+
+			expected := readMergedSamplesFromChunks(t, tsdbSeries[0].Chunks)
+			require.NotEmpty(t, expected)
+			assert.Equal(t, expected, readMergedSamplesFromChunks(t, parquetSeries[0].Chunks))
+		})
+	}
+}
+
+// generateStorageBlocksWithOutOfOrder snapshots a TSDB block for the in-order samples into the
+// storage directory and, if any, a block compacted from the out-of-order head for the out-of-order ones.
+func generateStorageBlocksWithOutOfOrder(t *testing.T, storageDir, userID string, metricName string, appendInOrder, appendOutOfOrder func(*testing.T, storage.Appender, labels.Labels)) {
+	userDir := filepath.Join(storageDir, userID)
+	require.NoError(t, os.MkdirAll(userDir, os.ModePerm))
+
+	opts := tsdb.DefaultOptions()
+	opts.OutOfOrderTimeWindow = time.Hour.Milliseconds()
+	db, err := tsdb.Open(t.TempDir(), promslog.NewNopLogger(), nil, opts, nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	series := labels.FromStrings(labels.MetricName, metricName)
+
+	app := db.Appender(context.Background())
+	appendInOrder(t, app, series)
+	require.NoError(t, app.Commit())
+
+	if appendOutOfOrder != nil {
+		app = db.Appender(context.Background())
+		appendOutOfOrder(t, app, series)
+		require.NoError(t, app.Commit())
+		require.NoError(t, db.CompactOOOHead(context.Background()))
+	}
+
+	require.NoError(t, db.Snapshot(userDir, true))
+}
+
+type histogramSample struct {
+	t  int64
+	f  float64
+	h  *histogram.Histogram
+	fh *histogram.FloatHistogram
+}
+
+// readMergedSamplesFromChunks reads the samples from the chunks, merging the overlapping ones the same way the querier does.
+func readMergedSamplesFromChunks(t *testing.T, chks []storepb.AggrChunk) []histogramSample {
+	series := make([]storage.Series, 0, len(chks))
+	for _, chk := range chks {
+		var enc chunkenc.Encoding
+		switch chk.Raw.Type {
+		case storepb.Chunk_XOR:
+			enc = chunkenc.EncXOR
+		case storepb.Chunk_HISTOGRAM:
+			enc = chunkenc.EncHistogram
+		case storepb.Chunk_FLOAT_HISTOGRAM:
+			enc = chunkenc.EncFloatHistogram
+		}
+		c, err := chunkenc.FromData(enc, chk.Raw.Data)
+		require.NoError(t, err)
+		series = append(series, &storage.SeriesEntry{
+			SampleIteratorFn: func(it chunkenc.Iterator) chunkenc.Iterator { return c.Iterator(it) },
+		})
+	}
+
+	var samples []histogramSample
+	it := storage.ChainedSeriesMerge(series...).Iterator(nil)
+	for valType := it.Next(); valType != chunkenc.ValNone; valType = it.Next() {
+		s := histogramSample{t: it.AtT()}
+		switch valType {
+		case chunkenc.ValFloat:
+			_, s.f = it.At()
+		case chunkenc.ValHistogram:
+			_, s.h = it.AtHistogram(nil)
+			// The counter reset hint depends on how the samples have been split in chunks.
+			s.h.CounterResetHint = histogram.UnknownCounterReset
+		case chunkenc.ValFloatHistogram:
+			_, s.fh = it.AtFloatHistogram(nil)
+			s.fh.CounterResetHint = histogram.UnknownCounterReset
+		}
+		samples = append(samples, s)
+	}
+	require.NoError(t, it.Err())
+	return samples
+}
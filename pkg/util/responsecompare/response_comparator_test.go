@@ -1,4 +1,4 @@
-package querytee
+package responsecompare
 
 import (
 	"encoding/json"
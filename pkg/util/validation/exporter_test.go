@@ -60,9 +60,12 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="enforce_metric_name",user="tenant-a"} 1
 		cortex_overrides{limit_name="ha_max_clusters",user="tenant-a"} 0
 		cortex_overrides{limit_name="ha_tracker_failover_timeout",user="tenant-a"} 30
+		cortex_overrides{limit_name="ingester_max_fetched_chunk_bytes_per_query",user="tenant-a"} 0
+		cortex_overrides{limit_name="ingester_max_fetched_series_per_query",user="tenant-a"} 0
 		cortex_overrides{limit_name="ingestion_burst_size",user="tenant-a"} 50000
 		cortex_overrides{limit_name="ingestion_rate",user="tenant-a"} 25000
 		cortex_overrides{limit_name="ingestion_tenant_shard_size",user="tenant-a"} 0
+		cortex_overrides{limit_name="max_bucket_get_requests_per_day",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_cache_freshness",user="tenant-a"} 60
 		cortex_overrides{limit_name="max_downloaded_bytes_per_request",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_exemplars",user="tenant-a"} 0
@@ -75,6 +78,7 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="max_global_native_histogram_series_per_user",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_global_series_per_metric",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_global_series_per_user",user="tenant-a"} 0
+		cortex_overrides{limit_name="max_label_cardinality",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_label_cardinality_for_unoptimized_regex",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_label_name_length",user="tenant-a"} 1024
 		cortex_overrides{limit_name="max_label_names_per_series",user="tenant-a"} 30
@@ -90,9 +94,12 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="max_queriers_per_tenant",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_query_length",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_query_lookback",user="tenant-a"} 0
+		cortex_overrides{limit_name="max_query_memory_bytes",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_query_parallelism",user="tenant-a"} 14
 		cortex_overrides{limit_name="max_query_response_size",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_regex_pattern_length",user="tenant-a"} 0
+		cortex_overrides{limit_name="max_series_creation_burst",user="tenant-a"} 50000
+		cortex_overrides{limit_name="max_series_creation_rate",user="tenant-a"} 0
 		cortex_overrides{limit_name="max_series_per_metric",user="tenant-a"} 50000
 		cortex_overrides{limit_name="max_series_per_user",user="tenant-a"} 5e+06
 		cortex_overrides{limit_name="max_total_label_value_length_for_unoptimized_regex",user="tenant-a"} 0
@@ -100,6 +107,8 @@ func TestOverridesExporter_withConfig(t *testing.T) {
 		cortex_overrides{limit_name="native_histogram_ingestion_rate",user="tenant-a"} 1.7976931348623157e+308
 		cortex_overrides{limit_name="out_of_order_results_cache_ttl",user="tenant-a"} 0
 		cortex_overrides{limit_name="out_of_order_time_window",user="tenant-a"} 0
+		cortex_overrides{limit_name="parquet_converter_compaction_delete_source_blocks",user="tenant-a"} 0
+		cortex_overrides{limit_name="parquet_converter_compaction_enabled",user="tenant-a"} 0
 		cortex_overrides{limit_name="parquet_converter_enabled",user="tenant-a"} 0
 		cortex_overrides{limit_name="parquet_converter_tenant_shard_size",user="tenant-a"} 0
 		cortex_overrides{limit_name="parquet_max_fetched_chunk_bytes",user="tenant-a"} 0
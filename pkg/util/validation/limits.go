@@ -9,6 +9,7 @@ import (
 	"maps"
 	"math"
 	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
@@ -34,13 +35,20 @@ var errDuplicatePerLabelSetLimit = errors.New("duplicate per labelSet limits fou
 var errInvalidLabelName = errors.New("invalid label name")
 var errInvalidLabelValue = errors.New("invalid label value")
 var errInvalidMetricRelabelConfigs = errors.New("invalid metric_relabel_configs")
+var errInvalidLabelCardinalityAction = fmt.Errorf("invalid label cardinality action, supported values are: %s", strings.Join(supportedLabelCardinalityActions, ", "))
 
 // Supported values for enum limits
 const (
 	LocalIngestionRateStrategy  = "local"
 	GlobalIngestionRateStrategy = "global"
+
+	LabelCardinalityActionDrop    = "drop"
+	LabelCardinalityActionReplace = "replace"
+	LabelCardinalityActionReject  = "reject"
 )
 
+var supportedLabelCardinalityActions = []string{LabelCardinalityActionDrop, LabelCardinalityActionReplace, LabelCardinalityActionReject}
+
 // AccessDeniedError are errors that do not comply with the limits specified.
 type AccessDeniedError string
 
@@ -140,6 +148,8 @@ type Limits struct {
 	HAMaxClusters                     int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
 	HATrackerFailoverTimeout          model.Duration      `yaml:"ha_tracker_failover_timeout" json:"ha_tracker_failover_timeout"`
 	DropLabels                        flagext.StringSlice `yaml:"drop_labels" json:"drop_labels"`
+	MaxLabelCardinality               int                 `yaml:"max_label_cardinality" json:"max_label_cardinality"`
+	LabelCardinalityAction            string              `yaml:"label_cardinality_action" json:"label_cardinality_action"`
 	MaxLabelNameLength                int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
 	MaxLabelValueLength               int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
 	MaxLabelNamesPerSeries            int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
@@ -166,6 +176,8 @@ type Limits struct {
 	MaxGlobalSeriesPerUser                int                        `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
 	MaxGlobalSeriesPerMetric              int                        `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
 	MaxGlobalNativeHistogramSeriesPerUser int                        `yaml:"max_global_native_histogram_series_per_user" json:"max_global_native_histogram_series_per_user"`
+	MaxSeriesCreationRate                 float64                    `yaml:"max_series_creation_rate" json:"max_series_creation_rate"`
+	MaxSeriesCreationBurst                int                        `yaml:"max_series_creation_burst" json:"max_series_creation_burst"`
 	LimitsPerLabelSet                     []LimitsPerLabelSet        `yaml:"limits_per_label_set" json:"limits_per_label_set" doc:"nocli|description=[Experimental] Enable limits per LabelSet. Supported limits per labelSet: [max_series]"`
 	ActiveSeriesTrackers                  ActiveSeriesTrackersConfig `yaml:"active_series_trackers,omitempty" json:"active_series_trackers,omitempty" doc:"nocli|description=List of active series tracker configurations. Each tracker counts active series matching its matchers and exposes the count as a metric."`
 	EnableNativeHistograms                bool                       `yaml:"enable_native_histograms" json:"enable_native_histograms"`
@@ -175,6 +187,10 @@ type Limits struct {
 	MaxLabelCardinalityForUnoptimizedRegex      int `yaml:"max_label_cardinality_for_unoptimized_regex" json:"max_label_cardinality_for_unoptimized_regex"`
 	MaxTotalLabelValueLengthForUnoptimizedRegex int `yaml:"max_total_label_value_length_for_unoptimized_regex" json:"max_total_label_value_length_for_unoptimized_regex"`
 
+	// Ingester enforced query limits.
+	IngesterMaxFetchedSeriesPerQuery     int `yaml:"ingester_max_fetched_series_per_query" json:"ingester_max_fetched_series_per_query"`
+	IngesterMaxFetchedChunkBytesPerQuery int `yaml:"ingester_max_fetched_chunk_bytes_per_query" json:"ingester_max_fetched_chunk_bytes_per_query"`
+
 	// Metadata
 	MaxLocalMetricsWithMetadataPerUser  int `yaml:"max_metadata_per_user" json:"max_metadata_per_user"`
 	MaxLocalMetadataPerMetric           int `yaml:"max_metadata_per_metric" json:"max_metadata_per_metric"`
@@ -190,6 +206,7 @@ type Limits struct {
 	MaxFetchedSeriesPerQuery     int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
 	MaxFetchedChunkBytesPerQuery int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
 	MaxFetchedDataBytesPerQuery  int            `yaml:"max_fetched_data_bytes_per_query" json:"max_fetched_data_bytes_per_query"`
+	MaxQueryMemoryBytes          int            `yaml:"max_query_memory_bytes" json:"max_query_memory_bytes"`
 	MaxQueryLookback             model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxQueryLength               model.Duration `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryParallelism          int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
@@ -233,17 +250,22 @@ type Limits struct {
 	// Store-gateway.
 	StoreGatewayTenantShardSize  float64 `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
 	MaxDownloadedBytesPerRequest int     `yaml:"max_downloaded_bytes_per_request" json:"max_downloaded_bytes_per_request"`
+	MaxBucketGetRequestsPerDay   int64   `yaml:"max_bucket_get_requests_per_day" json:"max_bucket_get_requests_per_day"`
 
 	// Compactor.
-	CompactorBlocksRetentionPeriod   model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
-	CompactorTenantShardSize         float64        `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
-	CompactorPartitionIndexSizeBytes int64          `yaml:"compactor_partition_index_size_bytes" json:"compactor_partition_index_size_bytes"`
-	CompactorPartitionSeriesCount    int64          `yaml:"compactor_partition_series_count" json:"compactor_partition_series_count"`
+	CompactorBlocksRetentionPeriod   model.Duration       `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
+	CompactorTenantShardSize         float64              `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorPartitionIndexSizeBytes int64                `yaml:"compactor_partition_index_size_bytes" json:"compactor_partition_index_size_bytes"`
+	CompactorPartitionSeriesCount    int64                `yaml:"compactor_partition_series_count" json:"compactor_partition_series_count"`
+	RetentionRules                   RetentionRulesConfig `yaml:"retention_rules,omitempty" json:"retention_rules,omitempty" doc:"nocli|description=Experimental. List of per-series retention rules. The samples of the series matching a rule and older than its period are dropped by the compactor when compacting the blocks, and the blocks which are not compacted anymore are rewritten by the compactor blocks cleaner. They are also filtered out by the queriers. The first matching rule applies to a series. Rules can only shorten the retention of the series, as the blocks are deleted after the compactor blocks retention period anyway."`
 
 	// Parquet converter
 	ParquetConverterEnabled         bool     `yaml:"parquet_converter_enabled" json:"parquet_converter_enabled"`
 	ParquetConverterTenantShardSize float64  `yaml:"parquet_converter_tenant_shard_size" json:"parquet_converter_tenant_shard_size"`
 	ParquetConverterSortColumns     []string `yaml:"parquet_converter_sort_columns" json:"parquet_converter_sort_columns"`
+
+	ParquetConverterCompactionEnabled            bool `yaml:"parquet_converter_compaction_enabled" json:"parquet_converter_compaction_enabled"`
+	ParquetConverterCompactionDeleteSourceBlocks bool `yaml:"parquet_converter_compaction_delete_source_blocks" json:"parquet_converter_compaction_delete_source_blocks"`
 	// This config doesn't have a CLI flag registered here because they're registered in
 	// their own original config struct.
 	S3SSEType                 string `yaml:"s3_sse_type" json:"s3_sse_type" doc:"nocli|description=S3 server-side encryption type. Required to enable server-side encryption overrides for a specific tenant. If not set, the default S3 client settings are used."`
@@ -287,6 +309,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.HATrackerFailoverTimeout, "distributor.ha-tracker.failover-timeout", "If the elected replica doesn't send samples in this time, the HA tracker will accept a new replica. This value must be greater than the update timeout plus the maximum jitter.")
 	f.Var((*flagext.StringSliceCSV)(&l.PromoteResourceAttributes), "distributor.promote-resource-attributes", "Comma separated list of resource attributes that should be converted to labels.")
 	f.Var(&l.DropLabels, "distributor.drop-label", "This flag can be used to specify label names that to drop during sample ingestion within the distributor and can be repeated in order to drop multiple labels.")
+	f.IntVar(&l.MaxLabelCardinality, "distributor.max-label-cardinality", 0, "[Experimental] Maximum number of distinct values of a label, per metric name, observed by each distributor over -distributor.label-cardinality-window. The -distributor.label-cardinality-action is applied to the series with a label exceeding it. The metric name is never limited. 0 to disable.")
+	f.StringVar(&l.LabelCardinalityAction, "distributor.label-cardinality-action", LabelCardinalityActionDrop, fmt.Sprintf("[Experimental] Action applied to the series with a label exceeding -distributor.max-label-cardinality: the label is removed from the series (drop), the label value is replaced by a constant (replace), or the series samples are discarded (reject). Supported values are: %s.", strings.Join(supportedLabelCardinalityActions, ", ")))
 	f.BoolVar(&l.EnableTypeAndUnitLabels, "distributor.enable-type-and-unit-labels", false, "EXPERIMENTAL: If true, the __type__ and __unit__ labels are added to metrics. This applies to remote write v2 and OTLP requests.")
 	f.BoolVar(&l.EnableStartTimestamp, "distributor.enable-start-timestamp", false, "EXPERIMENTAL: If true, StartTimestampMs (ST) is handled for remote write v2 samples and histograms. CreatedTimestamp (CT) is used as a fallback when ST is not set.")
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names")
@@ -309,12 +333,18 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxLabelCardinalityForUnoptimizedRegex, "validation.max-label-cardinality-for-unoptimized-regex", 0, "Maximum cardinality of a label that can be queried with an unoptimized regex matcher. If exceeded, the query will be rejected with a limit error. 0 to disable. This is only enforced in Ingester.")
 	f.IntVar(&l.MaxTotalLabelValueLengthForUnoptimizedRegex, "validation.max-total-label-value-length-for-unoptimized-regex", 0, "Maximum total length (in bytes) of all label values combined for an unoptimized regex matcher. If exceeded, the query will be rejected with a limit error. 0 to disable. This is only enforced in Ingester.")
 
+	// Ingester query limits.
+	f.IntVar(&l.IngesterMaxFetchedSeriesPerQuery, "ingester.max-fetched-series-per-query", 0, "[Experimental] The maximum number of series a single query can fetch from each ingester. The limit is checked while the ingester streams the series, so that the query is rejected before the ingester builds the whole response. 0 to disable.")
+	f.IntVar(&l.IngesterMaxFetchedChunkBytesPerQuery, "ingester.max-fetched-chunk-bytes-per-query", 0, "[Experimental] The maximum size of all chunks in bytes a single query can fetch from each ingester. The limit is checked while the ingester streams the chunks, so that the query is rejected before the ingester builds the whole response. 0 to disable.")
+
 	f.IntVar(&l.MaxLocalSeriesPerUser, "ingester.max-series-per-user", 5000000, "The maximum number of active series per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxLocalSeriesPerMetric, "ingester.max-series-per-metric", 50000, "The maximum number of active series per metric name, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerUser, "ingester.max-global-series-per-user", 0, "The maximum number of active series per user, across the cluster before replication. 0 to disable. Supported only if -distributor.shard-by-all-labels is true.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, "ingester.max-global-series-per-metric", 0, "The maximum number of active series per metric name, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxLocalNativeHistogramSeriesPerUser, "ingester.max-native-histogram-series-per-user", 0, "The maximum number of active native histogram series per user, per ingester. 0 to disable. Supported only if ingester.active-series-metrics-enabled is true.")
 	f.IntVar(&l.MaxGlobalNativeHistogramSeriesPerUser, "ingester.max-global-native-histogram-series-per-user", 0, "The maximum number of active native histogram series per user, across the cluster before replication. 0 to disable. Supported only if -distributor.shard-by-all-labels and ingester.active-series-metrics-enabled is true.")
+	f.Float64Var(&l.MaxSeriesCreationRate, "ingester.max-series-creation-rate", 0, "[Experimental] The maximum number of new series per second a user can create. The limit is per ingester when -distributor.ingestion-rate-limit-strategy is local, and shared across the ingesters the user's series are written to when it is global and -distributor.shard-by-all-labels is true. 0 to disable.")
+	f.IntVar(&l.MaxSeriesCreationBurst, "ingester.max-series-creation-burst", 50000, "[Experimental] The maximum number of new series a user can create at once, per ingester.")
 	f.BoolVar(&l.EnableNativeHistograms, "blocks-storage.tsdb.enable-native-histograms", false, "[EXPERIMENTAL] True to enable native histogram.")
 	f.IntVar(&l.MaxExemplars, "ingester.max-exemplars", 0, "Enables support for exemplars in TSDB and sets the maximum number that will be stored. less than zero means disabled. If the value is set to zero, cortex will fallback to blocks-storage.tsdb.max-exemplars value.")
 	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "[Experimental] Configures the allowed time window for ingestion of out-of-order samples. Disabled (0s) by default.")
@@ -327,6 +357,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, "querier.max-fetched-series-per-query", 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and blocks storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable")
 	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, "querier.max-fetched-chunk-bytes-per-query", 0, "Deprecated (use max-fetched-data-bytes-per-query instead): The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.IntVar(&l.MaxFetchedDataBytesPerQuery, "querier.max-fetched-data-bytes-per-query", 0, "The maximum combined size of all data that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler for `query`, `query_range` and `series` APIs. 0 to disable.")
+	f.IntVar(&l.MaxQueryMemoryBytes, "querier.max-query-memory-bytes", 0, "Experimental. The maximum estimated memory, in bytes, retained by a single query in the querier for the series and samples it selects and for its result. When exceeded, the query either fails or spills series to disk, depending on -querier.query-memory-limit.action. 0 to disable.")
 
 	_ = l.QueryIngestersWithin.Set("0")
 	f.Var(&l.QueryIngestersWithin, "limits.query-ingesters-within", "Maximum lookback duration for querying data from ingesters. Queries for data older than this will only query the long-term storage. This is a per-tenant limit that can be overridden in the runtime configuration. Should be less than or equal to close-idle-tsdb-timeout.")
@@ -369,6 +400,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Float64Var(&l.ParquetConverterTenantShardSize, "parquet-converter.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by the parquet converter. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant. If the value is < 1 and > 0 the shard size will be a percentage of the total parquet converters.")
 	f.BoolVar(&l.ParquetConverterEnabled, "parquet-converter.enabled", false, "If set, enables the Parquet converter to create the parquet files.")
 	f.Var((*flagext.StringSlice)(&l.ParquetConverterSortColumns), "parquet-converter.sort-columns", "Additional label names for specific tenants to sort by after metric name, in order of precedence. These are applied during Parquet file generation.")
+	f.BoolVar(&l.ParquetConverterCompactionEnabled, "parquet-converter.compaction-enabled", false, "[Experimental] If set, the parquet converter compacts the parquet files of the tenant's converted blocks into parquet-only blocks at the ranges configured by -parquet-converter.compaction-block-ranges. The source blocks are kept, unless -parquet-converter.compaction-delete-source-blocks is set.")
+	f.BoolVar(&l.ParquetConverterCompactionDeleteSourceBlocks, "parquet-converter.compaction-delete-source-blocks", false, "[Experimental] If set, the source blocks of the tenant's parquet compactions are marked for deletion once compacted. The tenant's data in the compacted time ranges is then only available in parquet-only blocks, so the tenant must be queried with the parquet queryable.")
 
 	// Parquet Queryable enforced limits.
 	f.IntVar(&l.ParquetMaxFetchedRowCount, "querier.parquet-queryable.max-fetched-row-count", 0, "The maximum number of rows that can be fetched when querying parquet storage. Each row maps to a series in a parquet file. This limit applies before materializing chunks. 0 to disable.")
@@ -378,6 +411,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	// Store-gateway.
 	f.Float64Var(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used. Must be set when the store-gateway sharding is enabled with the shuffle-sharding strategy. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant. If the value is < 1 the shard size will be a percentage of the total store-gateways.")
 	f.IntVar(&l.MaxDownloadedBytesPerRequest, "store-gateway.max-downloaded-bytes-per-request", 0, "The maximum number of data bytes to download per gRPC request in Store Gateway, including Series/LabelNames/LabelValues requests. 0 to disable.")
+	f.Int64Var(&l.MaxBucketGetRequestsPerDay, "store-gateway.max-bucket-get-requests-per-day", 0, "[Experimental] The maximum number of GET requests each store-gateway can run against the object storage on behalf of a tenant's Series/LabelNames/LabelValues requests during a day (UTC). The limit applies per store-gateway replica, and the count is kept in memory, so it's reset when the store-gateway restarts. GET requests are counted as they are issued, and once the limit is reached, the further GET requests and the new requests of the tenant are rejected until the end of the day. The requests served by the caching bucket and the requests run in background, like the ones to sync the tenant's blocks, are not counted. 0 to disable.")
 
 	// Alertmanager.
 	f.Var(&l.AlertmanagerReceiversBlockCIDRNetworks, "alertmanager.receivers-firewall-block-cidr-networks", "Comma-separated list of network CIDRs to block in Alertmanager receiver integrations.")
@@ -419,6 +453,10 @@ func (l *Limits) Validate(nameValidationScheme model.ValidationScheme, shardByAl
 		return errMaxLocalNativeHistogramSeriesPerUserValidation
 	}
 
+	if l.MaxLabelCardinality > 0 && !slices.Contains(supportedLabelCardinalityActions, l.LabelCardinalityAction) {
+		return errInvalidLabelCardinalityAction
+	}
+
 	if err := l.RulerExternalLabels.Validate(func(l labels.Label) error {
 		if !nameValidationScheme.IsValidLabelName(l.Name) {
 			return fmt.Errorf("%w: %q", errInvalidLabelName, l.Name)
@@ -515,6 +553,10 @@ func (l *Limits) UnmarshalYAML(unmarshal func(any) error) error {
 		return err
 	}
 
+	if err := l.RetentionRules.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -549,6 +591,10 @@ func (l *Limits) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if err := l.RetentionRules.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -755,6 +801,16 @@ func (o *Overrides) HAReplicaLabel(userID string) string {
 	return o.GetOverridesForUser(userID).HAReplicaLabel
 }
 
+// MaxLabelCardinality returns the maximum number of distinct values of a label, per metric name, observed by a distributor.
+func (o *Overrides) MaxLabelCardinality(userID string) int {
+	return o.GetOverridesForUser(userID).MaxLabelCardinality
+}
+
+// LabelCardinalityAction returns the action applied to the series with a label exceeding the max label cardinality.
+func (o *Overrides) LabelCardinalityAction(userID string) string {
+	return o.GetOverridesForUser(userID).LabelCardinalityAction
+}
+
 // DropLabels returns the list of labels to be dropped when ingesting HA samples for the user.
 func (o *Overrides) DropLabels(userID string) flagext.StringSlice {
 	return o.GetOverridesForUser(userID).DropLabels
@@ -829,6 +885,16 @@ func (o *Overrides) MaxGlobalNativeHistogramSeriesPerUser(userID string) int {
 	return o.GetOverridesForUser(userID).MaxGlobalNativeHistogramSeriesPerUser
 }
 
+// MaxSeriesCreationRate returns the maximum number of new series per second a user is allowed to create.
+func (o *Overrides) MaxSeriesCreationRate(userID string) float64 {
+	return o.GetOverridesForUser(userID).MaxSeriesCreationRate
+}
+
+// MaxSeriesCreationBurst returns the maximum number of new series a user is allowed to create at once in a single ingester.
+func (o *Overrides) MaxSeriesCreationBurst(userID string) int {
+	return o.GetOverridesForUser(userID).MaxSeriesCreationBurst
+}
+
 // EnableNativeHistograms returns whether the Ingester should accept native histogram samples from this user.
 func (o *Overrides) EnableNativeHistograms(userID string) bool {
 	return o.GetOverridesForUser(userID).EnableNativeHistograms
@@ -882,12 +948,23 @@ func (o *Overrides) MaxFetchedDataBytesPerQuery(userID string) int {
 	return o.GetOverridesForUser(userID).MaxFetchedDataBytesPerQuery
 }
 
+// MaxQueryMemoryBytes returns the maximum estimated memory retained by a single query in the querier.
+func (o *Overrides) MaxQueryMemoryBytes(userID string) int {
+	return o.GetOverridesForUser(userID).MaxQueryMemoryBytes
+}
+
 // MaxDownloadedBytesPerRequest returns the maximum number of bytes to download for each gRPC request in Store Gateway,
 // including any data fetched from cache or object storage.
 func (o *Overrides) MaxDownloadedBytesPerRequest(userID string) int {
 	return o.GetOverridesForUser(userID).MaxDownloadedBytesPerRequest
 }
 
+// MaxBucketGetRequestsPerDay returns the maximum number of GET requests each store-gateway can run against
+// the object storage on behalf of a given user during a day.
+func (o *Overrides) MaxBucketGetRequestsPerDay(userID string) int64 {
+	return o.GetOverridesForUser(userID).MaxBucketGetRequestsPerDay
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).MaxQueryLookback)
@@ -1009,6 +1086,11 @@ func (o *Overrides) CompactorBlocksRetentionPeriod(userID string) time.Duration
 	return time.Duration(o.GetOverridesForUser(userID).CompactorBlocksRetentionPeriod)
 }
 
+// RetentionRules returns the per-series retention rules for a given user.
+func (o *Overrides) RetentionRules(userID string) RetentionRulesConfig {
+	return o.GetOverridesForUser(userID).RetentionRules
+}
+
 // CompactorTenantShardSize returns shard size (number of rulers) used by this tenant when using shuffle-sharding strategy.
 func (o *Overrides) CompactorTenantShardSize(userID string) float64 {
 	return o.GetOverridesForUser(userID).CompactorTenantShardSize
@@ -1029,6 +1111,16 @@ func (o *Overrides) ParquetConverterSortColumns(userID string) []string {
 	return o.GetOverridesForUser(userID).ParquetConverterSortColumns
 }
 
+// ParquetConverterCompactionEnabled returns true if parquet compaction is enabled for the tenant.
+func (o *Overrides) ParquetConverterCompactionEnabled(userID string) bool {
+	return o.GetOverridesForUser(userID).ParquetConverterCompactionEnabled
+}
+
+// ParquetConverterCompactionDeleteSourceBlocks returns true if the source blocks of the tenant's parquet compactions are deleted.
+func (o *Overrides) ParquetConverterCompactionDeleteSourceBlocks(userID string) bool {
+	return o.GetOverridesForUser(userID).ParquetConverterCompactionDeleteSourceBlocks
+}
+
 // ParquetMaxFetchedRowCount returns the maximum number of rows that can be fetched when querying parquet storage.
 func (o *Overrides) ParquetMaxFetchedRowCount(userID string) int {
 	return o.GetOverridesForUser(userID).ParquetMaxFetchedRowCount
@@ -1275,6 +1367,18 @@ func (o *Overrides) MaxTotalLabelValueLengthForUnoptimizedRegex(userID string) i
 	return o.GetOverridesForUser(userID).MaxTotalLabelValueLengthForUnoptimizedRegex
 }
 
+// IngesterMaxFetchedSeriesPerQuery returns the maximum number of series a query can fetch from each ingester.
+// This is only used in Ingester.
+func (o *Overrides) IngesterMaxFetchedSeriesPerQuery(userID string) int {
+	return o.GetOverridesForUser(userID).IngesterMaxFetchedSeriesPerQuery
+}
+
+// IngesterMaxFetchedChunkBytesPerQuery returns the maximum size of the chunks a query can fetch from each ingester.
+// This is only used in Ingester.
+func (o *Overrides) IngesterMaxFetchedChunkBytesPerQuery(userID string) int {
+	return o.GetOverridesForUser(userID).IngesterMaxFetchedChunkBytesPerQuery
+}
+
 func (o *Overrides) QueryIngestersWithin(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).QueryIngestersWithin)
 }
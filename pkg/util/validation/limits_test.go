@@ -188,6 +188,14 @@ func TestLimits_Validate(t *testing.T) {
 			},
 			expected: errInvalidMetricRelabelConfigs,
 		},
+		"max_label_cardinality enabled with a supported action": {
+			limits:   Limits{MaxLabelCardinality: 1000, LabelCardinalityAction: LabelCardinalityActionReplace},
+			expected: nil,
+		},
+		"max_label_cardinality enabled with an unsupported action": {
+			limits:   Limits{MaxLabelCardinality: 1000, LabelCardinalityAction: "unknown"},
+			expected: errInvalidLabelCardinalityAction,
+		},
 		"ha_tracker_failover_timeout too small": {
 			limits:                          Limits{HATrackerFailoverTimeout: model.Duration(5 * time.Second)},
 			haTrackerUpdateTimeout:          4 * time.Second,
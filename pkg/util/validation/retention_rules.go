@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+var errRetentionRuleInvalidPeriod = errors.New("retention rule period must be greater than 0")
+
+// RetentionRuleConfig defines the retention period of the series matching a set of label matchers.
+type RetentionRuleConfig struct {
+	Matchers string         `yaml:"matchers" json:"matchers" doc:"nocli|description=PromQL series selector (e.g. {__name__=~\"debug_.*\"}). All matchers must match for a series to be subject to the retention period."`
+	Period   model.Duration `yaml:"period" json:"period" doc:"nocli|description=Retention period of the matching series. Samples older than the period are dropped by the compactor and filtered out by the queriers.|default=0"`
+
+	// Parsed matchers, populated during validation.
+	parsedMatchers []*labels.Matcher `yaml:"-" json:"-" doc:"nocli"`
+}
+
+// ParsedMatchers returns the compiled matchers. Must call Validate() first.
+func (c *RetentionRuleConfig) ParsedMatchers() []*labels.Matcher {
+	return c.parsedMatchers
+}
+
+// Validate parses the matchers string into compiled label matchers.
+func (c *RetentionRuleConfig) Validate() error {
+	if c.Period <= 0 {
+		return fmt.Errorf("retention rule %q: %w", c.Matchers, errRetentionRuleInvalidPeriod)
+	}
+	matchers, err := parser.ParseMetricSelector(c.Matchers)
+	if err != nil {
+		return fmt.Errorf("retention rule %q: %w", c.Matchers, err)
+	}
+	c.parsedMatchers = matchers
+	return nil
+}
+
+// Matches returns whether the series with the given labels is subject to the retention rule.
+func (c *RetentionRuleConfig) Matches(lbls labels.Labels) bool {
+	for _, m := range c.parsedMatchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// RetentionRulesConfig is a list of retention rules. The first rule matching a series applies.
+type RetentionRulesConfig []RetentionRuleConfig
+
+// Validate parses and validates all retention rules.
+func (c RetentionRulesConfig) Validate() error {
+	for i := range c {
+		if err := c[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetentionPeriod returns the retention period of the series with the given labels, or 0 if no rule matches it.
+func (c RetentionRulesConfig) RetentionPeriod(lbls labels.Labels) time.Duration {
+	for i := range c {
+		if c[i].Matches(lbls) {
+			return time.Duration(c[i].Period)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRetentionRuleConfig_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		cfg := RetentionRuleConfig{Matchers: `{__name__=~"debug_.*", env="dev"}`, Period: model.Duration(time.Hour)}
+		require.NoError(t, cfg.Validate())
+		assert.Len(t, cfg.ParsedMatchers(), 2)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		cfg := RetentionRuleConfig{Matchers: `{__name__=~"[bad"}`, Period: model.Duration(time.Hour)}
+		assert.Error(t, cfg.Validate())
+	})
+
+	t.Run("empty matchers", func(t *testing.T) {
+		cfg := RetentionRuleConfig{Matchers: ``, Period: model.Duration(time.Hour)}
+		assert.Error(t, cfg.Validate())
+	})
+
+	t.Run("zero period", func(t *testing.T) {
+		cfg := RetentionRuleConfig{Matchers: `{__name__="foo"}`}
+		assert.ErrorIs(t, cfg.Validate(), errRetentionRuleInvalidPeriod)
+	})
+}
+
+func TestRetentionRulesConfig_RetentionPeriod(t *testing.T) {
+	rules := RetentionRulesConfig{
+		{Matchers: `{__name__="slo_metric"}`, Period: model.Duration(2 * 365 * 24 * time.Hour)},
+		{Matchers: `{__name__=~"debug_.*|slo_metric"}`, Period: model.Duration(14 * 24 * time.Hour)},
+	}
+	require.NoError(t, rules.Validate())
+
+	// The first matching rule applies.
+	assert.Equal(t, 2*365*24*time.Hour, rules.RetentionPeriod(labels.FromStrings("__name__", "slo_metric")))
+	assert.Equal(t, 14*24*time.Hour, rules.RetentionPeriod(labels.FromStrings("__name__", "debug_requests")))
+	assert.Equal(t, time.Duration(0), rules.RetentionPeriod(labels.FromStrings("__name__", "other_metric")))
+}
+
+func TestLimits_RetentionRulesUnmarshalYAML(t *testing.T) {
+	SetDefaultLimitsForYAMLUnmarshalling(Limits{})
+
+	var limits Limits
+	require.NoError(t, yaml.Unmarshal([]byte(`
+retention_rules:
+  - matchers: '{__name__=~"debug_.*"}'
+    period: 14d
+`), &limits))
+	require.Len(t, limits.RetentionRules, 1)
+	assert.Equal(t, model.Duration(14*24*time.Hour), limits.RetentionRules[0].Period)
+	assert.Len(t, limits.RetentionRules[0].ParsedMatchers(), 1)
+
+	require.Error(t, yaml.Unmarshal([]byte(`
+retention_rules:
+  - matchers: '{__name__=~"debug_.*"}'
+`), &limits))
+}
@@ -74,6 +74,8 @@ const (
 	DroppedByRelabelConfiguration = "relabel_configuration"
 	// DroppedByUserConfigurationOverride Samples discarded due to user configuration removing label __name__
 	DroppedByUserConfigurationOverride = "user_label_removal_configuration"
+	// LabelCardinalityLimitExceeded Samples discarded because a label of the series exceeded the label cardinality limit
+	LabelCardinalityLimitExceeded = "label_cardinality_limit_exceeded"
 
 	// The combined length of the label names and values of an Exemplar's LabelSet MUST NOT exceed 128 UTF-8 characters
 	// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md#exemplars